@@ -90,6 +90,11 @@ func (pFix *DbFixer) FixTriggerStep(pStep *TriggerStep) (rc int) {
 		if pFix.FixSelect(pStep.Select) != SQLITE_OK || pFix.FixExpr(pStep.Where) != SQLITE_OK || pFix.FixExprList(pStep.ExprList) != SQLITE_OK {
 			return SQLITE_ERROR
 		}
+		if p := pStep.pUpsert; p != nil {
+			if pFix.FixExprList(p.pTarget) != SQLITE_OK || pFix.FixExpr(p.pTargetWhere) != SQLITE_OK || pFix.FixExprList(p.pSet) != SQLITE_OK || pFix.FixExpr(p.pWhere) != SQLITE_OK {
+				return SQLITE_ERROR
+			}
+		}
 	}
 	return
 }
@@ -204,6 +204,9 @@ static void renameTriggerFunc(
     FUNCTION(sqlite_rename_table,   2, 0, 0, renameTableFunc),
     FUNCTION(sqlite_rename_trigger, 2, 0, 0, renameTriggerFunc),
     FUNCTION(sqlite_rename_parent,  3, 0, 0, renameParentFunc),
+    FUNCTION(sqlite_rename_column,  8, 0, 0, renameColumnFunc),
+    FUNCTION(sqlite_drop_column,    2, 0, 0, dropColumnFunc),
+    FUNCTION(sqlite_rename_test,    4, 0, 0, renameTestFunc),
   };
   int i;
   FuncDefHash *pHash = &sqlite3GlobalFunctions
@@ -325,7 +328,7 @@ static void reloadTableSchema(Parse *pParse, Table *pTab, const char *Name){
   }
 }
 
-//	Parameter Name is the name of a table that is about to be altered (either with ALTER TABLE ... RENAME TO or ALTER TABLE ... ADD COLUMN). If the table is a system table, this function leaves an error message in pParse.zErr (system tables may not be altered) and returns non-zero.
+//	Parameter Name is the name of a table that is about to be altered (with ALTER TABLE ... RENAME TO, ADD COLUMN, RENAME COLUMN or DROP COLUMN). If the table is a system table, this function leaves an error message in pParse.zErr (system tables may not be altered) and returns non-zero.
 //	Or, if Name is not a system table, zero is returned.
 func (pParse *Parse) isSystemTable(Name string) (ok bool)
 	 if len(Name) > 6 && CaseInsensitiveMatchN(Name, "sqlite_", 7) {
@@ -596,11 +599,22 @@ exit_rename_table:
     pParse.SetErrorMsg("Cannot add a REFERENCES column with non-NULL default value")
     return
   }
-  if( pCol.notNull && !pDflt ){
+  if( pCol.notNull && !pDflt && !pCol.IsGenerated() ){
     pParse.SetErrorMsg("Cannot add a NOT NULL column with default value NULL");
     return;
   }
 
+	//	The existing rows have no values for a STORED column. The values of a VIRTUAL column are computed as they are read.
+	if pCol.IsGenerated() {
+		if pCol.isStored {
+			pParse.SetErrorMsg("cannot add a STORED column")
+			return
+		}
+		if pParse.GeneratedResolve(pNew) {
+			return
+		}
+	}
+
 	//	Ensure the default expression is something that sqlite3::ValueFromExpr() can handle (i.e. not CURRENT_TIME etc.)
 	if pDflt != nil {
 		switch rc, pVal := db.ValueFromExpr(pDflt, SQLITE_UTF8, SQLITE_AFF_NONE); {
@@ -613,8 +627,14 @@ exit_rename_table:
     	}
   	}
 
-  /* Modify the CREATE TABLE statement. */
-  zCol = sqlite3DbStrNDup(db, (char*)pColDef.z, pColDef.n);
+  /* Modify the CREATE TABLE statement. The column definition that was parsed
+  ** had its GENERATED ALWAYS AS clause blanked out by ParseGeneratedColumns(). */
+  if pParse.zGenColText != "" {
+    zCol = sqlite3DbStrDup(db, pParse.zGenColText)
+    pColDef.n = len(pParse.zGenColText)
+  }else{
+    zCol = sqlite3DbStrNDup(db, (char*)pColDef.z, pColDef.n);
+  }
   if( zCol ){
     char *zEnd = &zCol[pColDef.n-1];
     int savedDbFlags = db.flags;
@@ -688,6 +708,7 @@ void sqlite3AlterBeginAddColumn(Parse *pParse, SrcList *pSrc){
 			  memcpy(pNew.Columns, pTab.Columns, sizeof(Column)*pNew.nCol);
 			  for _, pCol := range pNew.Columns {
 			    pCol.Name = sqlite3DbStrDup(db, pCol.Name)
+			    pCol.pGenerated = pCol.pGenerated.Dup()
 			  }
 
 			  //	Begin a transaction and increment the schema cookie
@@ -0,0 +1,780 @@
+import (
+	"fmt"
+	"strings"
+)
+
+//	This file implements ALTER TABLE ... RENAME COLUMN and ALTER TABLE ... DROP COLUMN.
+//
+//		ALTER TABLE t RENAME COLUMN a TO b
+//		ALTER TABLE t DROP COLUMN c
+//
+//	The grammar has no rules for either statement, so Parse.Run() hands each statement that begins with ALTER or EXPLAIN to
+//	Parse.ParseAlterColumn(), which parses and codes it without the grammar when it is one of them.
+//
+//	Like RENAME TO, both statements work on the text of the schema. RENAME COLUMN rewrites the references to the column in every
+//	CREATE statement of the database with sqlite_rename_column(): the definition and constraints of the table, the indices on it, the
+//	REFERENCES clauses of other tables, and the views and triggers. Which names in a view or trigger refer to the column is decided by
+//	name resolution: each name that matches the column is replaced in turn by a marker, the object is parsed and resolved again, and
+//	the name is renamed if lookupName() resolves the marker to the column. DROP COLUMN removes the definition of the column with
+//	sqlite_drop_column() and rewrites every row of the table without its value. The objects of the table that could use a dropped
+//	column - indices, CHECK constraints, generated columns and foreign keys - have been resolved already, so they are checked before
+//	any code is generated. The views and triggers are only resolved when they are used, so once the schema has been reloaded
+//	sqlite_rename_test() resolves each of them again, through the same DbFixer and name resolution as CREATE VIEW and the coding of
+//	a trigger program. An error aborts the statement and the schema change is rolled back.
+
+//	The column of an ALTER TABLE ... RENAME COLUMN or DROP COLUMN found by ParseAlterColumn().
+type alterColumn struct {
+	zName		string			//	Name of the column
+	zNew		string			//	New name of the column for RENAME COLUMN
+	isDrop		bool			//	True for DROP COLUMN
+}
+
+//	If the statement that begins at offset iStart of zSql is an ALTER TABLE ... RENAME COLUMN or DROP COLUMN, possibly after EXPLAIN
+//	or EXPLAIN QUERY PLAN, code it and return the offset of the end of the statement, after its ";" if it has one. lastToken is the
+//	type of the token parsed before iStart, which is -1 or TK_SEMI if a statement begins there, unless it is in the body of a trigger.
+//	Return 0 if the statement is anything else, which is left to the grammar, and also after leaving an error in pParse.
+func (pParse *Parse) ParseAlterColumn(zSql string, iStart, lastToken int) int {
+	if lastToken != -1 && lastToken != TK_SEMI || pParse.pNewTrigger != nil {
+		return 0
+	}
+	s := newSqlScanner(zSql[iStart:])
+	explainFlag := 0
+	if s.Type == TK_EXPLAIN {
+		explainFlag = 1
+		if s.Next(); s.Type == TK_QUERY {
+			if !s.Next() || s.Type != TK_PLAN {
+				return 0
+			}
+			explainFlag = 2
+			s.Next()
+		}
+	}
+	if s.Type != TK_ALTER || !s.Next() || s.Type != TK_TABLE || !s.Next() || !s.IsName() {
+		return 0
+	}
+
+	//	The table is named as by "fullname ::= nm dbnm" in the grammar.
+	zName1, zName2 := s.Text, ""
+	if s.Next(); s.Type == TK_DOT {
+		if !s.Next() || !s.IsName() {
+			return 0
+		}
+		zName2 = s.Text
+		s.Next()
+	}
+	p := &alterColumn{ isDrop: s.Type == TK_DROP }
+	switch s.Type {
+	case TK_RENAME:
+		if s.Next(); s.Type == TK_TO {
+			return 0
+		}
+		if s.Type == TK_COLUMNKW {
+			s.Next()
+		}
+		if s.Type == 0 || s.Type == TK_SEMI {
+			pParse.scanError(s)
+			return 0
+		}
+		p.zName = Dequote(s.Text)
+		if !s.Next() || s.Type != TK_TO || !s.Next() || s.Type == TK_SEMI {
+			pParse.scanError(s)
+			return 0
+		}
+		p.zNew = Dequote(s.Text)
+	case TK_DROP:
+		if s.Next(); s.Type == TK_COLUMNKW {
+			s.Next()
+		}
+		if s.Type == 0 || s.Type == TK_SEMI {
+			pParse.scanError(s)
+			return 0
+		}
+		p.zName = Dequote(s.Text)
+	default:
+		return 0
+	}
+	if s.Next(); s.Type != 0 && s.Type != TK_SEMI {
+		pParse.scanError(s)
+		return 0
+	}
+	iEnd := iStart + s.Start
+	if s.Type == TK_SEMI {
+		iEnd = iStart + s.iNext
+	}
+
+	sqlite3BeginParse(pParse, explainFlag)
+	pParse.AlterColumn(pParse.db.SrcListAppend(nil, zName1, zName2), p)
+	if pParse.nErr > 0 {
+		return 0
+	}
+	sqlite3FinishCoding(pParse)
+	return iEnd
+}
+
+//	Generate code for the ALTER TABLE ... RENAME COLUMN or DROP COLUMN of column p on the table named by pSrc.
+func (pParse *Parse) AlterColumn(pSrc *SrcList, p *alterColumn) {
+	db := pParse.db
+	defer sqlite3SrcListDelete(db, pSrc)
+	if db.mallocFailed {
+		return
+	}
+	pTab := pParse.LocateTable(pSrc.a[0].Name, pSrc.a[0].zDatabase, false)
+	if pTab == nil || pParse.isSystemTable(pTab.Name) {
+		return
+	}
+	zOp := "rename columns of"
+	if p.isDrop {
+		zOp = "drop column from"
+	}
+	switch {
+	case pTab.Select != nil:
+		pParse.SetErrorMsg("cannot %v view \"%v\"", zOp, pTab.Name)
+		return
+	case pTab.IsVirtual():
+		pParse.SetErrorMsg("cannot %v virtual table \"%v\"", zOp, pTab.Name)
+		return
+	}
+	iCol := pTab.ColumnIndex(p.zName)
+	if iCol < 0 {
+		pParse.SetErrorMsg("no such column: \"%v\"", p.zName)
+		return
+	}
+	iDb := db.SchemaToIndex(pTab.Schema)
+	if pParse.AuthCheck(SQLITE_ALTER_TABLE, db.Databases[iDb].Name, pTab.Name, 0) != SQLITE_OK {
+		return
+	}
+
+	savedDbFlags := db.flags
+	db.flags |= SQLITE_PreferBuiltin
+	if p.isDrop {
+		pParse.alterDropColumn(pTab, iCol)
+	} else {
+		pParse.alterRenameColumn(pTab, iCol, p.zNew)
+	}
+	db.flags = savedDbFlags
+}
+
+//	Generate code that renames column iCol of table pTab to zNew.
+func (pParse *Parse) alterRenameColumn(pTab *Table, iCol int, zNew string) {
+	db := pParse.db
+	if pTab.ColumnIndex(zNew) >= 0 {
+		pParse.SetErrorMsg("duplicate column name: %v", zNew)
+		return
+	}
+	iDb := db.SchemaToIndex(pTab.Schema)
+	zDb := db.Databases[iDb].Name
+	if pParse.GetVdbe() == nil {
+		return
+	}
+	pParse.BeginWriteOperation(0, iDb)
+	zOld := pTab.Columns[iCol].Name
+	sqlite3NestedParse(pParse,
+		"UPDATE \"%w\".%s SET sql = sqlite_rename_column(sql, type, name, %Q, %Q, %Q, %Q, %Q) " +
+		"WHERE sql IS NOT NULL AND sql NOT LIKE 'create virtual%%' AND (type != 'index' OR tbl_name = %Q COLLATE nocase)",
+		zDb, SCHEMA_TABLE(iDb), zDb, zDb, pTab.Name, zOld, zNew, pTab.Name)
+
+	//	The views and triggers of the TEMP database may refer to the table as well.
+	if iDb != 1 {
+		sqlite3NestedParse(pParse,
+			"UPDATE temp.%s SET sql = sqlite_rename_column(sql, type, name, 'temp', %Q, %Q, %Q, %Q) WHERE type IN ('view', 'trigger')",
+			SCHEMA_TABLE(1), zDb, pTab.Name, zOld, zNew)
+	}
+	sqlite3ChangeCookie(pParse, iDb)
+	pParse.alterReloadSchema(pTab)
+	pParse.alterTestSchema(pTab, "")
+}
+
+//	Generate code that drops column iCol of table pTab.
+func (pParse *Parse) alterDropColumn(pTab *Table, iCol int) {
+	db := pParse.db
+	pCol := pTab.Columns[iCol]
+	switch {
+	case pCol.isPrimKey || iCol == pTab.iPKey:
+		pParse.SetErrorMsg("cannot drop PRIMARY KEY column: \"%v\"", pCol.Name)
+		return
+	case len(pTab.Columns) == 1:
+		pParse.SetErrorMsg("cannot drop column \"%v\": no other columns exist", pCol.Name)
+		return
+	}
+	for _, pIdx := range pTab.Indices {
+		used := pIdx.ExprUses(iCol) || pIdx.PartialIndexUses(iCol) || pIdx.GeneratedUses(iCol)
+		for _, column := range pIdx.Columns {
+			used = used || column == iCol
+		}
+		switch {
+		case used && pIdx.onError != OE_None:
+			pParse.SetErrorMsg("cannot drop UNIQUE column: \"%v\"", pCol.Name)
+			return
+		case used:
+			pParse.SetErrorMsg("error in index %v after drop column: no such column: %v", pIdx.Name, pCol.Name)
+			return
+		}
+	}
+	for j := range pTab.Columns {
+		if j != iCol && pTab.ColumnDependsOn(j, iCol) {
+			pParse.SetErrorMsg("error in table %v after drop column: no such column: %v", pTab.Name, pCol.Name)
+			return
+		}
+	}
+	if pTab.pCheck != nil {
+		for _, item := range pTab.pCheck.Items {
+			if exprUsesColumn(item.Expr, iCol) {
+				pParse.SetErrorMsg("error in table %v after drop column: no such column: %v", pTab.Name, pCol.Name)
+				return
+			}
+		}
+	}
+	for pFKey := pTab.ForeignKey; pFKey != nil; pFKey = pFKey.NextFrom {
+		for _, pMap := range pFKey.Columns {
+			if pMap.From == iCol {
+				pParse.SetErrorMsg("error in table %v after drop column: unknown column \"%v\" in foreign key definition", pTab.Name, pCol.Name)
+				return
+			}
+		}
+	}
+
+	iDb := db.SchemaToIndex(pTab.Schema)
+	zDb := db.Databases[iDb].Name
+	if pParse.GetVdbe() == nil {
+		return
+	}
+	pParse.BeginWriteOperation(0, iDb)
+	sqlite3NestedParse(pParse,
+		"UPDATE \"%w\".%s SET sql = sqlite_drop_column(sql, %Q) WHERE type = 'table' AND name = %Q COLLATE nocase",
+		zDb, SCHEMA_TABLE(iDb), pCol.Name, pTab.Name)
+	if !pCol.IsVirtualGenerated() {
+		pParse.dropColumnRecords(pTab, iCol)
+	}
+	sqlite3ChangeCookie(pParse, iDb)
+	pParse.alterReloadSchema(pTab)
+	pParse.alterTestSchema(pTab, " after drop column")
+}
+
+//	Generate code that rewrites every row of table pTab without the value of column iCol. The keys of the rows are collected first,
+//	so that the table is not changed while it is being scanned.
+func (pParse *Parse) dropColumnRecords(pTab *Table, iCol int) {
+	db := pParse.db
+	v := pParse.pVdbe
+	iDb := db.SchemaToIndex(pTab.Schema)
+	iCur := pParse.nTab
+	pParse.nTab++
+	pParse.OpenTable(pTab, iCur, iDb, OP_OpenWrite)
+
+	pParse.nMem++
+	regKey := pParse.nMem
+	iSet := pParse.RowKeySetOpen(pTab)
+	addrEmpty := v.AddOp1(OP_Rewind, iCur)
+	pParse.CodeRowKey(pTab, iCur, regKey)
+	pParse.RowKeySetAdd(pTab, iSet, regKey)
+	v.AddOp2(OP_Next, iCur, addrEmpty + 1)
+	v.JumpHere(addrEmpty)
+
+	//	Rewrite each row. The values of the other columns are copied as they are stored, except that the defaults of columns added
+	//	by ALTER TABLE ADD COLUMN are filled in for the rows that predate them.
+	aCol := []int{}
+	for _, j := range pTab.storageOrder() {
+		if j != iCol {
+			aCol = append(aCol, j)
+		}
+	}
+	regBase := pParse.GetTempRange(len(aCol))
+	regRec := pParse.GetTempReg()
+	addrEnd := v.MakeLabel()
+	addrLoop := pParse.RowKeySetRead(pTab, iSet, addrEnd, regKey)
+	pParse.SeekRowKey(pTab, iCur, addrLoop, regKey)
+	for i, j := range aCol {
+		v.AddOp3(OP_Column, iCur, pTab.StorageColumn(j), regBase + i)
+		if j != pTab.iPKey {
+			v.ColumnDefault(pTab, j, regBase + i)
+		}
+	}
+	v.AddOp3(OP_MakeRecord, regBase, len(aCol), regRec)
+	if pTab.HasRowid() {
+		v.AddOp3(OP_Insert, iCur, regRec, regKey)
+	} else {
+		//	The key of the row does not change, but the entry of a WITHOUT ROWID table is the whole record.
+		v.AddOp1(OP_Delete, iCur)
+		v.AddOp2(OP_IdxInsert, iCur, regRec)
+	}
+	v.AddOp2(OP_Goto, 0, addrLoop)
+	v.ResolveLabel(addrEnd)
+	v.AddOp1(OP_Close, iCur)
+	pParse.ReleaseTempReg(regRec)
+	pParse.ReleaseTempRange(regBase, len(aCol))
+}
+
+//	Generate code that reloads the parts of the schema whose text a RENAME COLUMN or DROP COLUMN on table pTab may have changed: the
+//	table itself with its indices and triggers, the tables with foreign keys that refer to it, and every view and trigger of its
+//	database and of the TEMP database.
+func (pParse *Parse) alterReloadSchema(pTab *Table) {
+	db := pParse.db
+	v := pParse.pVdbe
+	for p := pTab.FkReferences(); p != nil; p = p.NextTo {
+		if pFrom := p.pFrom; pFrom != pTab {
+			reloadTableSchema(pParse, pFrom, pFrom.Name)
+		}
+	}
+	reloadTableSchema(pParse, pTab, pTab.Name)
+
+	iDb := db.SchemaToIndex(pTab.Schema)
+	for _, i := range []int{ iDb, 1 } {
+		if i == 1 && iDb == 1 {
+			break
+		}
+		pSchema := db.Databases[i].Schema
+		for _, pTrig := range pSchema.Triggers {
+			if pTrig.table() != pTab {
+				sqlite3VdbeAddOp4(v, OP_DropTrigger, i, 0, 0, pTrig.Name, 0)
+			}
+		}
+		for _, pView := range pSchema.Tables {
+			if pView.Select != nil {
+				sqlite3VdbeAddOp4(v, OP_DropTable, i, 0, 0, pView.Name, 0)
+			}
+		}
+		v.AddParseSchemaOp(i, fmt.Sprintf("type='view' OR (type='trigger' AND tbl_name<>'%v')", pTab.Name))
+	}
+}
+
+//	Generate code that resolves every view and trigger of the database of table pTab and of the TEMP database once the schema has
+//	been reloaded, and fails with an error if one of them no longer resolves. zWhen is added to the name of the object in the error.
+func (pParse *Parse) alterTestSchema(pTab *Table, zWhen string) {
+	db := pParse.db
+	iDb := db.SchemaToIndex(pTab.Schema)
+	zDb := db.Databases[iDb].Name
+	sqlite3NestedParse(pParse,
+		"SELECT 1 FROM \"%w\".%s WHERE type IN ('view', 'trigger') AND sqlite_rename_test(%Q, type, name, %Q) = NULL",
+		zDb, SCHEMA_TABLE(iDb), zDb, zWhen)
+	if iDb != 1 {
+		sqlite3NestedParse(pParse,
+			"SELECT 1 FROM temp.%s WHERE type IN ('view', 'trigger') AND sqlite_rename_test('temp', type, name, %Q) = NULL",
+			SCHEMA_TABLE(1), zWhen)
+	}
+}
+
+//	A token of a schema statement that is being rewritten.
+type alterToken struct {
+	Type		int
+	Text		string
+	Start		int
+	End			int
+}
+
+//	Split zSql into tokens.
+func alterTokens(zSql string) (aTok []alterToken) {
+	for s := newSqlScanner(zSql); s.Type != 0; s.Next() {
+		aTok = append(aTok, alterToken{ Type: s.Type, Text: s.Text, Start: s.Start, End: s.iNext })
+	}
+	return
+}
+
+//	Return true if token t is the name zName, quoted or not.
+func (t alterToken) is(zName string) bool {
+	return CaseInsensitiveMatch(Dequote(t.Text), zName)
+}
+
+//	Return the type of token i of aTok, or 0 if there is no such token.
+func alterTokenType(aTok []alterToken, i int) int {
+	if i < 0 || i >= len(aTok) {
+		return 0
+	}
+	return aTok[i].Type
+}
+
+//	Return true if token i of aTok could be a reference to the column zName: an identifier that is not the name of a function or of
+//	a collation sequence.
+func alterColumnRef(aTok []alterToken, i int, zName string) bool {
+	return aTok[i].Type == TK_ID && aTok[i].is(zName) && alterTokenType(aTok, i + 1) != TK_LP && alterTokenType(aTok, i - 1) != TK_COLLATE
+}
+
+//	Return zSql with each token of aTok listed in aEdit, in order, replaced by zNew as a quoted identifier.
+func alterReplace(zSql string, aTok []alterToken, aEdit []int, zNew string) string {
+	zQuoted := `"` + strings.Replace(zNew, `"`, `""`, -1) + `"`
+	b := &strings.Builder{}
+	i := 0
+	for _, j := range aEdit {
+		b.WriteString(zSql[i:aTok[j].Start])
+		b.WriteString(zQuoted)
+		i = aTok[j].End
+	}
+	b.WriteString(zSql[i:])
+	return b.String()
+}
+
+//	Return the tokens of aTok, the CREATE TABLE statement of table zTab, that name the column zOld: the name in its definition and the
+//	references in the table constraints, CHECK constraints, generated columns and REFERENCES clauses that refer to the table itself.
+func renameInTable(aTok []alterToken, zTab, zOld string) (aEdit []int) {
+	i := 0
+	for i < len(aTok) && aTok[i].Type != TK_LP {
+		i++
+	}
+	atStart := false				//	True at the first token of a column definition or table constraint
+	zRef := ""						//	Table named by the REFERENCES clause being scanned
+	for depth := 0; i < len(aTok); i++ {
+		t := aTok[i]
+		switch {
+		case t.Type == TK_LP:
+			if depth++; depth == 1 {
+				atStart = true
+			}
+			continue
+		case t.Type == TK_RP:
+			if depth--; depth == 1 {
+				zRef = ""
+			} else if depth == 0 {
+				return
+			}
+		case t.Type == TK_COMMA && depth == 1:
+			atStart, zRef = true, ""
+			continue
+		case t.Type == TK_REFERENCES && i + 1 < len(aTok):
+			zRef = Dequote(aTok[i + 1].Text)
+		case depth == 1 && atStart:
+			switch t.Type {
+			case TK_CONSTRAINT, TK_PRIMARY, TK_UNIQUE, TK_CHECK, TK_FOREIGN:
+			default:
+				if t.is(zOld) {
+					aEdit = append(aEdit, i)
+				}
+			}
+		case depth > 1 && (zRef == "" || CaseInsensitiveMatch(zRef, zTab)) && alterColumnRef(aTok, i, zOld):
+			aEdit = append(aEdit, i)
+		}
+		atStart = false
+	}
+	return
+}
+
+//	Return the tokens of aTok, a CREATE INDEX statement, that name the column zOld in the key or the WHERE clause of the index.
+func renameInIndex(aTok []alterToken, zOld string) (aEdit []int) {
+	i := 0
+	for i < len(aTok) && aTok[i].Type != TK_LP {
+		i++
+	}
+	for ; i < len(aTok); i++ {
+		if alterColumnRef(aTok, i, zOld) {
+			aEdit = append(aEdit, i)
+		}
+	}
+	return
+}
+
+//	Return the tokens of aTok, the CREATE TABLE statement of a table other than zTab, that name the column zOld in a REFERENCES
+//	clause that refers to zTab.
+func renameInReferences(aTok []alterToken, zTab, zOld string) (aEdit []int) {
+	for i := 0; i + 2 < len(aTok); i++ {
+		if aTok[i].Type != TK_REFERENCES || !aTok[i + 1].is(zTab) || aTok[i + 2].Type != TK_LP {
+			continue
+		}
+		for i += 3; i < len(aTok) && aTok[i].Type != TK_RP; i++ {
+			if aTok[i].is(zOld) {
+				aEdit = append(aEdit, i)
+			}
+		}
+	}
+	return
+}
+
+//	The name that stands in for a name of the column being renamed while sqlite_rename_column() resolves a view or trigger.
+const renameMarker = "sqlite_rename_column"
+
+//	The column of a table whose references sqlite_rename_column() looks for in a view or trigger, and the count of the references that
+//	resolve to it. While Parse.pRename is set, lookupName() and ResolveTrigger() take renameMarker to mean the name of the column.
+type renameColumn struct {
+	pTab		*Table
+	iCol		int
+	nRef		int				//	Number of times renameMarker resolved to the column
+	pTrigger	*Trigger		//	Trigger built by sqlite3FinishTrigger() from the text being resolved
+}
+
+//	Return the name of the column being renamed if zName is renameMarker and the names of a view or trigger are being resolved for
+//	sqlite_rename_column(). Otherwise return zName.
+func (pParse *Parse) renameName(zName string) string {
+	if p := pParse.pRename; p != nil && zName == renameMarker {
+		return p.pTab.Columns[p.iCol].Name
+	}
+	return zName
+}
+
+//	Record that zName, a name in a view or trigger, has resolved to column iCol of pTab, or to its rowid if iCol is negative. It counts
+//	as a reference if it is renameMarker and the column is the one that sqlite_rename_column() is renaming.
+func (pParse *Parse) renameResolved(zName string, pTab *Table, iCol int) {
+	if p := pParse.pRename; p != nil && zName == renameMarker && pTab == p.pTab && (iCol == p.iCol || iCol < 0 && p.iCol == pTab.iPKey) {
+		p.nRef++
+	}
+}
+
+//	Return the tokens of aTok, the text zSql of the CREATE VIEW or CREATE TRIGGER statement of object zName in database iDb, that refer
+//	to column iCol of table pTab. Each identifier that matches the name of the column, other than a function name or the qualifier of
+//	another name, is replaced in turn by renameMarker and the object is resolved again; the identifier refers to the column if the
+//	marker resolves to it.
+func renameInQuery(db *sqlite3, iDb int, zSql string, aTok []alterToken, isTrigger bool, zName string, pTab *Table, iCol int) (aEdit []int) {
+	zOld := pTab.Columns[iCol].Name
+	sName := Token{ z: zName, n: len(zName) }
+
+	//	The SELECT of a view follows the first AS outside parentheses.
+	iAs := 0
+	if !isTrigger {
+		for depth := 0; iAs < len(aTok) && (depth > 0 || aTok[iAs].Type != TK_AS); iAs++ {
+			switch aTok[iAs].Type {
+			case TK_LP:
+				depth++
+			case TK_RP:
+				depth--
+			}
+		}
+		if iAs == len(aTok) {
+			return
+		}
+	}
+	for i := iAs; i < len(aTok); i++ {
+		if !alterColumnRef(aTok, i, zOld) || alterTokenType(aTok, i + 1) == TK_DOT {
+			continue
+		}
+		p := &renameColumn{ pTab: pTab, iCol: iCol }
+		pParse := &Parse{ db: db, nQueryLoop: 1, pRename: p }
+		zText := alterReplace(zSql, aTok, []int{ i }, renameMarker)
+		if isTrigger {
+			//	sqlite3FinishTrigger() leaves the trigger in p.pTrigger rather than adding it to the schema.
+			pParse.Run(zText)
+			if p.pTrigger != nil {
+				pResolve := &Parse{ db: db, nQueryLoop: 1, pRename: p }
+				pResolve.ResolveTrigger(p.pTrigger)
+				db.DeleteTrigger(p.pTrigger)
+			}
+		} else if pSel := pParse.ParseSelect(zText[aTok[iAs].End:]); pSel != nil {
+			if pFix := pParse.NewDbFixer(iDb, "view", sName); pFix == nil || pFix.FixSelect(pSel) == SQLITE_OK {
+				sqlite3SelectPrep(pParse, pSel, nil)
+			}
+			sqlite3SelectDelete(db, pSel)
+		}
+		if p.nRef > 0 {
+			aEdit = append(aEdit, i)
+		}
+	}
+	return
+}
+
+//	This function is used by the SQL generated for ALTER TABLE ... RENAME COLUMN. Its arguments are the text, type and name of an
+//	object of the schema, the name of the database that holds the object, the names of the database and the table whose column is
+//	renamed, the name of the column and the new name of the column. It returns the text of the object with each reference to the
+//	column renamed:
+//
+//		sqlite_rename_column('CREATE INDEX i ON t(a, b)', 'index', 'i', 'main', 'main', 't', 'a', 'c')
+//			-> 'CREATE INDEX i ON t("c", b)'
+func renameColumnFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return
+	}
+	db := sqlite3_context_db_handle(context)
+	zSql := sqlite3_value_text(argv[0])
+	zType := sqlite3_value_text(argv[1])
+	zName := sqlite3_value_text(argv[2])
+	zDb := sqlite3_value_text(argv[3])
+	zTabDb := sqlite3_value_text(argv[4])
+	zTab := sqlite3_value_text(argv[5])
+	zOld := sqlite3_value_text(argv[6])
+	zNew := sqlite3_value_text(argv[7])
+
+	aTok := alterTokens(zSql)
+	var aEdit []int
+	switch zType {
+	case "table":
+		if CaseInsensitiveMatch(zName, zTab) {
+			aEdit = renameInTable(aTok, zTab, zOld)
+		} else {
+			aEdit = renameInReferences(aTok, zTab, zOld)
+		}
+	case "index":
+		aEdit = renameInIndex(aTok, zOld)
+	case "view", "trigger":
+		pTab := db.FindTable(zTab, zTabDb)
+		if iDb := db.FindDbName(zDb); iDb >= 0 && pTab != nil && pTab.ColumnIndex(zOld) >= 0 {
+			aEdit = renameInQuery(db, iDb, zSql, aTok, zType == "trigger", zName, pTab, pTab.ColumnIndex(zOld))
+		}
+	}
+	sqlite3_result_text(context, alterReplace(zSql, aTok, aEdit, zNew), -1, SQLITE_TRANSIENT)
+}
+
+//	This function is used by the SQL generated for ALTER TABLE ... DROP COLUMN. Its arguments are the text of a CREATE TABLE
+//	statement and the name of a column. It returns the text without the definition of the column:
+//
+//		sqlite_drop_column('CREATE TABLE t(a, b INTEGER, c)', 'b')
+//			-> 'CREATE TABLE t(a, c)'
+func dropColumnFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return
+	}
+	zSql := sqlite3_value_text(argv[0])
+	zCol := sqlite3_value_text(argv[1])
+	aTok := alterTokens(zSql)
+	iComma := -1					//	Offset of the "," before the definition being scanned
+	iFirst := -1					//	Token that starts the definition being scanned
+	iDef := -1						//	Offset of the definition of the column, once it is found
+	for depth, i := 0, 0; i < len(aTok); i++ {
+		switch t := aTok[i]; {
+		case t.Type == TK_LP:
+			if depth++; depth == 1 {
+				iFirst = i + 1
+			}
+		case t.Type == TK_RP:
+			if depth--; depth == 0 {
+				if iDef >= 0 && iComma >= 0 {
+					//	The column was the last definition. Remove the "," before it.
+					zSql = zSql[:iComma] + zSql[t.Start:]
+				}
+				i = len(aTok)
+			}
+		case t.Type == TK_COMMA && depth == 1:
+			if iDef >= 0 {
+				zSql = zSql[:iDef] + zSql[aTok[i + 1].Start:]
+				i = len(aTok)
+				break
+			}
+			iComma, iFirst = t.Start, i + 1
+		case i == iFirst && depth == 1 && iDef < 0 && t.is(zCol):
+			switch t.Type {
+			case TK_CONSTRAINT, TK_PRIMARY, TK_UNIQUE, TK_CHECK, TK_FOREIGN:
+			default:
+				iDef = t.Start
+			}
+		}
+	}
+	sqlite3_result_text(context, zSql, -1, SQLITE_TRANSIENT)
+}
+
+//	This function is used by the SQL generated for ALTER TABLE ... RENAME COLUMN and DROP COLUMN once the changed schema has been
+//	reloaded. Its arguments are the name of a database and the type and name of a view or trigger in it, and a phrase to add to the
+//	name of the object in an error message. It resolves the view or trigger again and fails with an error if it no longer resolves.
+//	Otherwise it returns NULL.
+func renameTestFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	db := sqlite3_context_db_handle(context)
+	zDb := sqlite3_value_text(argv[0])
+	zType := sqlite3_value_text(argv[1])
+	zName := sqlite3_value_text(argv[2])
+	zWhen := sqlite3_value_text(argv[3])
+	iDb := db.FindDbName(zDb)
+	if iDb < 0 {
+		return
+	}
+	pParse := &Parse{ db: db, nQueryLoop: 1 }
+	sName := Token{ z: zName, n: len(zName) }
+	switch zType {
+	case "view":
+		if pView := db.FindTable(zName, zDb); pView != nil && pView.Select != nil {
+			pSel := pView.Select.Dup()
+			if pFix := pParse.NewDbFixer(iDb, "view", sName); pFix == nil || pFix.FixSelect(pSel) == SQLITE_OK {
+				sqlite3ViewGetColumnNames(pParse, pView)
+			}
+			sqlite3SelectDelete(db, pSel)
+		}
+	case "trigger":
+		if pTrig := db.Databases[iDb].Schema.Triggers[zName]; pTrig != nil {
+			if pFix := pParse.NewDbFixer(iDb, "trigger", sName); pFix == nil || pFix.FixTriggerStep(pTrig.Steps) == SQLITE_OK {
+				pParse.ResolveTrigger(pTrig)
+			}
+		}
+	}
+	if pParse.nErr > 0 {
+		sqlite3_result_error(context, fmt.Sprintf("error in %v %v%v: %v", zType, zName, zWhen, pParse.zErrMsg), -1)
+	}
+}
+
+//	Return the index of the column of table t named zName, or -1 if there is no such column.
+func (t *Table) ColumnIndex(zName string) int {
+	for i, pCol := range t.Columns {
+		if CaseInsensitiveMatch(pCol.Name, zName) {
+			return i
+		}
+	}
+	return -1
+}
+
+//	Resolve the names in the WHEN clause and the program of trigger pTrig as they would be resolved when the trigger is coded. The
+//	trigger itself is not changed. Return true if there are errors, which are left in pParse.
+func (pParse *Parse) ResolveTrigger(pTrig *Trigger) bool {
+	db := pParse.db
+	pTab := pTrig.table()
+	if pTab == nil {
+		return false
+	}
+	pParse.pTriggerTab = pTab
+	pParse.eTriggerOp = pTrig.OP
+	if pTrig.Columns != nil {
+		for _, item := range pTrig.Columns {
+			iCol := pTab.ColumnIndex(pParse.renameName(item.Name))
+			if iCol < 0 {
+				pParse.SetErrorMsg("no such column: %v", item.Name)
+				return true
+			}
+			pParse.renameResolved(item.Name, pTab, iCol)
+		}
+	}
+	if pTrig.When != nil {
+		pWhen := pTrig.When.Dup()
+		sqlite3ResolveExprNames(&NameContext{ Parse: pParse }, pWhen)
+		db.ExprDelete(pWhen)
+	}
+	for pStep := pTrig.Steps; pStep != nil && pParse.nErr == 0; pStep = pStep.Next {
+		pSel := pStep.Select.Dup()
+		pList := pStep.ExprList.Dup()
+		pWhere := pStep.Where.Dup()
+		switch pStep.OP {
+		case TK_SELECT:
+			sqlite3SelectPrep(pParse, pSel, nil)
+		case TK_INSERT:
+			pParse.resolveTriggerTarget(pStep, func(pTarget *Table, sNC *NameContext) {
+				for _, item := range pStep.IdList {
+					iCol := pTarget.ColumnIndex(pParse.renameName(item.Name))
+					if iCol < 0 && !(pTarget.HasRowid() && sqlite3IsRowid(item.Name)) {
+						pParse.SetErrorMsg("table %v has no column named %v", pTarget.Name, item.Name)
+						return
+					}
+					pParse.renameResolved(item.Name, pTarget, iCol)
+				}
+				if pSel != nil {
+					sqlite3SelectPrep(pParse, pSel, nil)
+				}
+				if pList != nil {
+					for _, item := range pList.Items {
+						sqlite3ResolveExprNames(&NameContext{ Parse: pParse }, item.Expr)
+					}
+				}
+			})
+		case TK_UPDATE:
+			pParse.resolveTriggerTarget(pStep, func(pTarget *Table, sNC *NameContext) {
+				for _, item := range pList.Items {
+					iCol := pTarget.ColumnIndex(pParse.renameName(item.Name))
+					if iCol < 0 && !(pTarget.HasRowid() && sqlite3IsRowid(item.Name)) {
+						pParse.SetErrorMsg("no such column: %v", item.Name)
+						return
+					}
+					pParse.renameResolved(item.Name, pTarget, iCol)
+					sqlite3ResolveExprNames(sNC, item.Expr)
+				}
+				sqlite3ResolveExprNames(sNC, pWhere)
+			})
+		case TK_DELETE:
+			pParse.resolveTriggerTarget(pStep, func(pTarget *Table, sNC *NameContext) {
+				sqlite3ResolveExprNames(sNC, pWhere)
+			})
+		}
+		sqlite3SelectDelete(db, pSel)
+		db.ExprListDelete(pList)
+		db.ExprDelete(pWhere)
+	}
+	return pParse.nErr > 0
+}
+
+//	Look up the table that INSERT, UPDATE or DELETE trigger step pStep writes to and call xResolve with it and a name context in
+//	which the names of its columns resolve. Errors are left in pParse.
+func (pParse *Parse) resolveTriggerTarget(pStep *TriggerStep, xResolve func(*Table, *NameContext)) {
+	pSrc := targetSrcList(pParse, pStep)
+	defer sqlite3SrcListDelete(pParse.db, pSrc)
+	if pTarget := pParse.LocateTable(pSrc.a[0].Name, pSrc.a[0].zDatabase, false); pTarget != nil {
+		pSrc.a[0].pTab = pTarget
+		pSrc.a[0].iCursor = pParse.nTab
+		pParse.nTab++
+		xResolve(pTarget, &NameContext{ Parse: pParse, SrcList: pSrc })
+	}
+}
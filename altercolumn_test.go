@@ -0,0 +1,93 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestAlterRenameColumn(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	for _, query := range []string{
+		"CREATE TABLE p(id INTEGER PRIMARY KEY, a, b CHECK (b > a))",
+		"CREATE INDEX p_a ON p(a, b) WHERE a IS NOT NULL",
+		"CREATE TABLE ch(x REFERENCES p(a), y, FOREIGN KEY(y) REFERENCES p(a))",
+		"CREATE TABLE log(a, note)",
+		"CREATE VIEW v AS SELECT a, b AS bb FROM p WHERE a > 0",
+		"CREATE TRIGGER tr AFTER INSERT ON p BEGIN INSERT INTO log(a, note) VALUES(new.a, 'a'); END",
+	} {
+		testExec(t, db, query)
+	}
+	testExec(t, db, "ALTER TABLE p RENAME COLUMN a TO alpha")
+
+	//	Every reference to p.a is renamed. The column a of log and the string 'a' are not.
+	testQueryIs(t, db, strings.Join([]string{
+		`p|CREATE TABLE p(id INTEGER PRIMARY KEY, "alpha", b CHECK (b > "alpha"))`,
+		`p_a|CREATE INDEX p_a ON p("alpha", b) WHERE "alpha" IS NOT NULL`,
+		`ch|CREATE TABLE ch(x REFERENCES p("alpha"), y, FOREIGN KEY(y) REFERENCES p("alpha"))`,
+		`log|CREATE TABLE log(a, note)`,
+		`v|CREATE VIEW v AS SELECT "alpha", b AS bb FROM p WHERE "alpha" > 0`,
+		`tr|CREATE TRIGGER tr AFTER INSERT ON p BEGIN INSERT INTO log(a, note) VALUES(new."alpha", 'a'); END`,
+	}, "\n"), "SELECT name, sql FROM sqlite_master ORDER BY rowid")
+
+	testExec(t, db, "INSERT INTO p(alpha, b) VALUES(1, 2)")
+	testQueryIs(t, db, "1|a", "SELECT a, note FROM log")
+	testQueryIs(t, db, "1|2", "SELECT alpha, bb FROM v")
+	if _, err := db.Exec("INSERT INTO p(alpha, b) VALUES(5, 2)"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("CHECK on the renamed column: %v, want SQLITE_CONSTRAINT", err)
+	}
+
+	for query, want := range map[string]string{
+		"ALTER TABLE p RENAME COLUMN alpha TO B":	"duplicate column name: B",
+		"ALTER TABLE p RENAME COLUMN zz TO yy":		`no such column: "zz"`,
+		"ALTER TABLE v RENAME COLUMN bb TO cc":		`cannot rename columns of view "v"`,
+		"ALTER TABLE nope RENAME COLUMN a TO b":		"no such table: nope",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+}
+
+func TestAlterDropColumn(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	for _, query := range []string{
+		"CREATE TABLE p(id INTEGER PRIMARY KEY)",
+		"CREATE TABLE d(id INTEGER PRIMARY KEY, u UNIQUE, i, k CHECK (k > 0), f REFERENCES p(id), spare, used)",
+		"CREATE INDEX d_i ON d(i)",
+		"CREATE VIEW dv AS SELECT used FROM d",
+		"CREATE TABLE one(x)",
+		"INSERT INTO d VALUES(1, 'u', 2, 3, NULL, 'gone', 'kept')",
+	} {
+		testExec(t, db, query)
+	}
+
+	//	Columns that the key, an index, a constraint or a view uses cannot be dropped, and the table is left as it was.
+	for query, want := range map[string]string{
+		"ALTER TABLE d DROP COLUMN id":		`cannot drop PRIMARY KEY column: "id"`,
+		"ALTER TABLE d DROP COLUMN u":		`cannot drop UNIQUE column: "u"`,
+		"ALTER TABLE d DROP COLUMN i":		"error in index d_i after drop column: no such column: i",
+		"ALTER TABLE d DROP COLUMN k":		"error in table d after drop column: no such column: k",
+		"ALTER TABLE d DROP COLUMN f":		`error in table d after drop column: unknown column "f" in foreign key definition`,
+		"ALTER TABLE d DROP COLUMN used":	"error in view dv after drop column: no such column: used",
+		"ALTER TABLE one DROP COLUMN x":		`cannot drop column "x": no other columns exist`,
+		"ALTER TABLE dv DROP COLUMN used":	`cannot drop column from view "dv"`,
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+	testQueryIs(t, db, "1|u|2|3|NULL|gone|kept", "SELECT * FROM d")
+	testQueryIs(t, db, "kept", "SELECT * FROM dv")
+
+	//	Dropping a column removes its definition and its value from every row.
+	testExec(t, db, "ALTER TABLE d DROP COLUMN spare")
+	testQueryIs(t, db, "CREATE TABLE d(id INTEGER PRIMARY KEY, u UNIQUE, i, k CHECK (k > 0), f REFERENCES p(id), used)", "SELECT sql FROM sqlite_master WHERE name = 'd'")
+	testQueryIs(t, db, "1|u|2|3|NULL|kept", "SELECT * FROM d")
+	testExec(t, db, "INSERT INTO d VALUES(2, 'v', 4, 5, NULL, 'new')")
+	testQueryIs(t, db, "1|kept\n2|new", "SELECT id, used FROM d ORDER BY id")
+	testQueryIs(t, db, "ok", "PRAGMA integrity_check")
+
+	//	Once the view is gone the last column can be dropped too.
+	testExec(t, db, "DROP VIEW dv")
+	testExec(t, db, "ALTER TABLE d DROP COLUMN used")
+	testQueryIs(t, db, "CREATE TABLE d(id INTEGER PRIMARY KEY, u UNIQUE, i, k CHECK (k > 0), f REFERENCES p(id))", "SELECT sql FROM sqlite_master WHERE name = 'd'")
+	testQueryIs(t, db, "1|u|2|3|NULL\n2|v|4|5|NULL", "SELECT * FROM d ORDER BY id")
+}
@@ -503,8 +503,7 @@ func (pParse *Parse) analyzeOneTable(table *Table, relevant_index *Index, stat1_
 			v.ChangeP5(2)
 			v.AddOp1(OP_IsNull, regTemp1)
 			v.AddOp3(OP_NotExists, iTabCur, shortJump, regTemp1)
-			v.AddOp3(OP_Column, iTabCur, index.Columns[0], regSample)
-			v.ColumnDefault(table, index.Columns[0], regSample)
+			pParse.IndexColumnCode(index, 0, iTabCur, regSample)
 			sqlite3VdbeAddOp4(v, OP_Function, 1, regAccum, regNumEq, (char*)&stat3GetFuncdef, P4_FUNCDEF)
 			v.ChangeP5(3)
 			sqlite3VdbeAddOp4(v, OP_Function, 1, regAccum, regNumLt, (char*)&stat3GetFuncdef, P4_FUNCDEF)
@@ -142,10 +142,8 @@ static void attachFunc(
   }
 
   if( rc==SQLITE_OK ){
-    extern int sqlite3CodecAttach(sqlite3*, int, const void*, int);
-    extern void sqlite3CodecGetKey(sqlite3*, int, void**, int*);
     int nKey;
-    char *zKey;
+    var zKey string
     int t = sqlite3_value_type(argv[2]);
     switch( t ){
       case SQLITE_INTEGER:
@@ -157,13 +155,13 @@ static void attachFunc(
       case SQLITE_TEXT:
       case SQLITE_BLOB:
         nKey = sqlite3_value_bytes(argv[2]);
-        zKey = (char *)sqlite3_value_blob(argv[2]);
+        zKey = string(sqlite3_value_blob(argv[2]));
         rc = sqlite3CodecAttach(db, len(db.Databases) - 1, zKey, nKey);
         break;
 
       case SQLITE_NULL:
         /* No key specified.  Use the key from the main database */
-        sqlite3CodecGetKey(db, 0, (void**)&zKey, &nKey);
+        sqlite3CodecGetKey(db, 0, &zKey, &nKey);
         if( nKey>0 || sqlite3BtreeGetReserve(db.Databases[0].pBt)>0 ){
           rc = sqlite3CodecAttach(db, len(db.Databases) - 1, zKey, nKey);
         }
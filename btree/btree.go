@@ -1483,7 +1483,12 @@ func (p *Btree) BeginTransaction(wrflag int) (rc int) {
 				if pBt.Flags & BTS_READ_ONLY != 0 {
 					rc = SQLITE_READONLY
 				} else {
-					if rc = sqlite3PagerBegin(pBt.pPager, wrflag > 1, sqlite3TempInMemory(p.db)); rc == SQLITE_OK {
+					if wrflag == 1 && p.db.isConcurrent && p.db.autoCommit == 0 {
+						rc = sqlite3PagerBeginConcurrent(pBt.pPager, sqlite3TempInMemory(p.db))
+					} else {
+						rc = sqlite3PagerBegin(pBt.pPager, wrflag > 1, sqlite3TempInMemory(p.db))
+					}
+					if rc == SQLITE_OK {
 						rc = pBt.NewDatabase()
 					}
 				}
@@ -1493,7 +1498,8 @@ func (p *Btree) BeginTransaction(wrflag int) (rc int) {
 				unlockBtreeIfUnused(pBt)
 			}
 
-			if !( rc & 0xFF == SQLITE_BUSY && pBt.inTransaction == TRANS_NONE && btreeInvokeBusyHandler(pBt) ) {
+			//	A snapshot that cannot be opened will not become available by waiting.
+			if !( rc & 0xFF == SQLITE_BUSY && rc != SQLITE_BUSY_SNAPSHOT && pBt.inTransaction == TRANS_NONE && btreeInvokeBusyHandler(pBt) ) {
 				break
 			}
 		}
@@ -1911,7 +1917,13 @@ func (p *Btree) CommitPhaseOne(master_journal string) (rc int) {
 				return rc
 			}
 		}
-		rc = sqlite3PagerCommitPhaseOne(pBt.pPager, master_journal, 0)
+		//	The commit of a BEGIN CONCURRENT transaction waits for the WAL write lock, which other connections only hold while they commit.
+		for {
+			rc = sqlite3PagerCommitPhaseOne(pBt.pPager, master_journal, 0)
+			if rc != SQLITE_BUSY || !sqlite3PagerIsConcurrent(pBt.pPager) || !btreeInvokeBusyHandler(pBt) {
+				break
+			}
+		}
 		p.Unlock()
 	}
 	return
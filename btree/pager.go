@@ -449,16 +449,19 @@ typedef struct Wal Wal;
 */
 #define UNKNOWN_LOCK                (EXCLUSIVE_LOCK+1)
 
+//	Decode page pgno in place. A codec that fails without setting an error code has run out of memory.
 func (p *Pager) Codec1(data *byte, pgno PageNumber) (rc int) {
-	if p != nil && p.xCodec != nil && p.xCodec(p.pCodec, data, pgno, 3) == nil {
+	if p != nil && p.xCodec != nil && p.xCodec(p.pCodec, data, pgno, 3, &rc) == nil && rc == SQLITE_OK {
 		rc = SQLITE_NOMEM
 	}
 	return
 }
 
+//	Encode page pgno with op i and return the buffer holding the result.
 func (p *Pager) Codec2(data *byte, pgno PageNumber, i int) (buffer *byte, rc int) {
+	buffer = data
 	if p.xCodec != nil {
-		if buffer = p.xCodec(p.pCodec, data, pgno, i); buffer == nil {
+		if buffer = p.xCodec(p.pCodec, data, pgno, i, &rc); buffer == nil && rc == SQLITE_OK {
 			rc = SQLITE_NOMEM
 		}
 	}
@@ -672,6 +675,7 @@ struct Pager {
   byte doNotSpill;              /* Do not spill the cache when non-zero */
   byte doNotSyncSpill;          /* Do not do a spill that requires jrnl sync */
   byte subjInMemory;            /* True to use in-memory sub-journals */
+	isConcurrent		bool			//	True in a BEGIN CONCURRENT write transaction, which takes the WAL write lock only to commit
   PageNumber dbSize;                /* Number of pages in the database */
   PageNumber dbOrigSize;            /* dbSize before the current transaction */
   PageNumber dbFileSize;            /* Number of pages in the database file */
@@ -706,7 +710,7 @@ struct Pager {
   void *pBusyHandlerArg;      /* Context argument for xBusyHandler */
   int aStat[3];               /* Total cache hits, misses and writes */
   void (*xReiniter)(DbPage*); /* Call this routine when reloading pages */
-  void *(*xCodec)(void*,void*,PageNumber,int); /* Routine for en/decoding data */
+  void *(*xCodec)(void*,void*,PageNumber,int,int*); /* Routine for en/decoding data */
   void (*xCodecSizeChng)(void*,int,int); /* Notify of page size changes */
   void (*xCodecFree)(void*);             /* Destructor for the codec */
   void *pCodec;               /* First argument to xCodec... methods */
@@ -1756,6 +1760,7 @@ static int pager_end_transaction(Pager *pPager, int hasMaster){
     rc2 = sqlite3WalEndWriteTransaction(pPager.pWal);
     assert( rc2==SQLITE_OK );
   }
+  pPager.isConcurrent = false
   if !pPager.exclusiveMode && (!pagerUseWal(pPager) || pPager.pWal.ExclusiveMode(0)) {
     rc2 = pagerUnlockDb(pPager, SHARED_LOCK);
     pPager.changeCountDone = 0;
@@ -2016,7 +2021,7 @@ static int pager_playback_one_page(
       pPager.dbFileSize = pgno;
     }
     if( pPager.pBackup ){
-      rc = pPager.Codec(aData, pgno)
+      rc = pPager.Codec1(aData, pgno)
       sqlite3BackupUpdate(pPager.pBackup, pgno, (byte*)aData);
 	aData, rc = pPager.Codec2(aData, pgno, 7)
 
@@ -3825,7 +3830,8 @@ static int pagerStress(void *p, PgHdr *pPg){
 	//	The doNotSyncSpill flag is set during times when doing a sync of journal (and adding a new header) is not allowed. This occurs during calls to DbPage::Write() while trying to journal multiple pages belonging to the same sector.
 	//	The doNotSpill flag inhibits all cache spilling regardless of whether or not a sync is required. This is set during a rollback.
 	//	Spilling is also prohibited when in an error state since that could lead to database corruption. In the current implementaton it is impossible for PCache::Fetch() to be called with createFlag == true while in the error state, hence it is impossible for this routine to be called in the error state.
-	if pPager.errCode || pPager.doNotSpill || (pPager.doNotSyncSpill && pPg.flags & PGHDR_NEED_SYNC != 0) {
+	//	A BEGIN CONCURRENT transaction cannot spill either, as it does not hold the WAL write lock until it commits.
+	if pPager.errCode || pPager.doNotSpill || pPager.isConcurrent || (pPager.doNotSyncSpill && pPg.flags & PGHDR_NEED_SYNC != 0) {
 		return SQLITE_OK
 	}
 
@@ -4071,6 +4077,11 @@ static int pagerStress(void *p, PgHdr *pPg){
     assert( !memDb );
     readOnly = (fout&SQLITE_OPEN_READONLY);
 
+    /* A VFS that locks the database for the whole process, as unix-excl
+    ** does, reports SQLITE_OPEN_EXCLUSIVE_PROCESS. The database is then in
+    ** exclusive-process mode, with its wal-index in process memory. */
+    pPager.vfsFlags |= (fout&SQLITE_OPEN_EXCLUSIVE_PROCESS);
+
     /* If the file was successfully opened for read/write access,
     ** choose a default page size in case we have to create the
     ** database file. The default page size is the maximum of:
@@ -4783,8 +4794,12 @@ static int pager_open_journal(Pager *pPager){
       ** PAGER_RESERVED state. Otherwise, return an error code to the caller.
       ** The busy-handler is not invoked if another connection already
       ** holds the write-lock. If possible, the upper layer will call it.
+      **
+      ** A BEGIN CONCURRENT transaction leaves the write lock to its commit.
       */
-      rc = sqlite3WalBeginWriteTransaction(pPager.pWal);
+      if( !pPager.isConcurrent ){
+        rc = sqlite3WalBeginWriteTransaction(pPager.pWal);
+      }
     }else{
       /* Obtain a RESERVED lock on the database file. If the exFlag parameter
       ** is true, then immediately upgrade this to an EXCLUSIVE lock. The
@@ -4823,6 +4838,25 @@ static int pager_open_journal(Pager *pPager){
   return rc;
 }
 
+//	Begin the write-transaction of a BEGIN CONCURRENT transaction (see concurrent.go). On a database in WAL mode the WAL write lock is
+//	not taken until the transaction commits, so that other connections can build their own write transactions meanwhile. Otherwise
+//	this is sqlite3PagerBegin().
+func sqlite3PagerBeginConcurrent(pPager *Pager, subjInMemory int) (rc int) {
+	if pPager.eState != PAGER_READER || !pagerUseWal(pPager) || pPager.exclusiveMode {
+		return sqlite3PagerBegin(pPager, 0, subjInMemory)
+	}
+	pPager.isConcurrent = true
+	if rc = sqlite3PagerBegin(pPager, 0, subjInMemory); rc != SQLITE_OK {
+		pPager.isConcurrent = false
+	}
+	return
+}
+
+//	Return true if pPager has an open BEGIN CONCURRENT write transaction.
+func sqlite3PagerIsConcurrent(pPager *Pager) bool {
+	return pPager.isConcurrent
+}
+
 //	Mark a single data page as writeable. The page is written into the main journal or sub-journal as required. If the page is written into one of the journals,
 //	the corresponding bit is set in the Pager.pInJournal BitVector and the PagerSavepoint.pInSavepoint BitVectors of any open savepoints as appropriate.
 static int pager_write(PgHdr *pPg){
@@ -5259,7 +5293,19 @@ static int pager_incr_changecounter(Pager *pPager, int isDirectMode){
         pList.pDirty = nil
       }
       assert( rc==SQLITE_OK );
-      if( pList ){
+      if( pList && pPager.isConcurrent ){
+        /* Take the WAL write lock that a BEGIN CONCURRENT transaction put
+        ** off. The commit fails with SQLITE_BUSY_SNAPSHOT if one of the
+        ** pages in pList was written by a transaction committed since this
+        ** one began. If this transaction did not change the size of the
+        ** database, the size is as the other transactions left it.
+        */
+        PageNumber nPage;
+        if nPage, rc = pPager.pWal.CommitConcurrent(pList); rc==SQLITE_OK && nPage>0 && pPager.dbSize==pPager.dbOrigSize {
+          pPager.dbSize = nPage
+        }
+      }
+      if( pList && rc==SQLITE_OK ){
         rc = pagerWalFrames(pPager, pList, pPager.dbSize, 1);
       }
       pPageOne.Unref()
@@ -5714,11 +5760,13 @@ func (pPager *Pager) Savepoint(op, iSavepoint int) (rc int) {
 }
 
 /*
-** Set or retrieve the codec for this pager
+** Set or retrieve the codec for this pager. xCodec returns NULL if it
+** fails, after setting its last argument to an error code unless it has
+** run out of memory.
 */
  void sqlite3PagerSetCodec(
   Pager *pPager,
-  void *(*xCodec)(void*,void*,PageNumber,int),
+  void *(*xCodec)(void*,void*,PageNumber,int,int*),
   void (*xCodecSizeChng)(void*,int,int),
   void (*xCodecFree)(void*),
   void *pCodec
@@ -6085,12 +6133,32 @@ int sqlite3PagerLockingMode(Pager *pPager, int eMode){
   return pPager.pWal.Callback()
 }
 
+//	Return the snapshot of the read transaction of pPager, which must be in WAL mode.
+func sqlite3PagerSnapshotGet(pPager *Pager) (*WalIndexHdr, int) {
+	if pPager.pWal == nil {
+		return nil, SQLITE_ERROR
+	}
+	return pPager.pWal.SnapshotGet()
+}
+
+//	Make the next read transaction of pPager open pSnapshot, or the head of the WAL if pSnapshot is nil. pPager must be in WAL mode.
+func sqlite3PagerSnapshotOpen(pPager *Pager, pSnapshot *WalIndexHdr) int {
+	if pPager.pWal == nil {
+		return SQLITE_ERROR
+	}
+	pPager.pWal.SnapshotOpen(pSnapshot)
+	return SQLITE_OK
+}
+
 /*
 ** Return true if the underlying VFS for the given pager supports the
-** primitives necessary for write-ahead logging.
+** primitives necessary for write-ahead logging. A database opened in
+** exclusive-process mode needs no shared-memory primitives, as its
+** wal-index is held in the memory of the process.
 */
  int sqlite3PagerWalSupported(Pager *pPager){
   const sqlite3_io_methods *pMethods = pPager.fd.pMethods;
+  if( pPager.vfsFlags & SQLITE_OPEN_EXCLUSIVE_PROCESS ) return 1;
   return pPager.exclusiveMode || (pMethods.iVersion>=2 && pMethods.xShmMap);
 }
 
@@ -6116,7 +6184,9 @@ static int pagerExclusiveLock(Pager *pPager){
 ** Call sqlite3WalOpen() to open the WAL handle. If the pager is in
 ** exclusive-locking mode when this function is called, take an EXCLUSIVE
 ** lock on the database file and use heap-memory to store the wal-index
-** in. Otherwise, use the normal shared-memory.
+** in. Otherwise, if the database was opened in exclusive-process mode,
+** share a wal-index in the memory of the process with the other
+** connections to the database, or else use the normal shared-memory.
 */
 static int pagerOpenWal(Pager *pPager){
   int rc = SQLITE_OK;
@@ -6142,6 +6212,11 @@ static int pagerOpenWal(Pager *pPager){
         pPager.journalSizeLimit, &pPager.pWal
     );
   }
+  if( rc==SQLITE_OK && !pPager.exclusiveMode
+   && (pPager.vfsFlags & SQLITE_OPEN_EXCLUSIVE_PROCESS)
+  ){
+    pPager.pWal.UseProcessShm()
+  }
 
   return rc;
 }
@@ -6249,7 +6324,7 @@ static int pagerOpenWal(Pager *pPager){
 //
 //	This function returns a pointer to a buffer containing the encrypted page content. If a malloc fails, this function may return NULL.
 func (pPg *PgHdr) Codec() (data *byte, rc int) {
-	return pPg.pPager.Codec2(pPg.pData, pPg.pgno, 6, 0)
+	return pPg.pPager.Codec2(pPg.pData, pPg.pgno, 6)
 }
 
 #endif /* !SQLITE_OMIT_WAL */
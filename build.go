@@ -242,7 +242,16 @@ func (fb *sqlite3) FindTable(table, database string) (p *Table) {
 //	The difference between this routine and FindTable() is that this routine leaves an error message in pParse.zErrMsg where FindTable() does not.
 func (pParse *Parse) LocateTable(name, database string, isView bool) (p *Table) {
 	if pParse.ReadSchema() == SQLITE_OK {
-		if p = pParse.db.FindTable(Name, zDbase); p == nil {
+		p = pParse.db.FindTable(Name, zDbase)
+		if p == nil && (database == "" || CaseInsensitiveMatch(database, pParse.db.Databases[0].Name)) {
+			//	Not a table in the schema. It may be the eponymous virtual table of a module.
+			if pMod := pParse.db.Modules[name]; pMod != nil {
+				if p = pParse.VtabEponymousTable(pMod); p == nil && pParse.nErr > 0 {
+					return
+				}
+			}
+		}
+		if p == nil {
 			message		string
 			if isView {
 				message = "no such view"
@@ -368,6 +377,7 @@ static void sqliteDeleteColumnNames(sqlite3 *db, Table *pTable){
     for(i=0; i<pTable.nCol; i++, pCol++){
       pCol.Name = nil
       db.ExprDelete(pCol.pDflt)
+      db.ExprDelete(pCol.pGenerated)
       pCol.zDflt = nil
       pCol.zType = nil
       pCol.zColl = nil
@@ -624,6 +634,9 @@ func (pParse *Parse) TwoPartName(pName1, pName2 Token) (pUnqual Token, iDb int)
 		nRef:		1,
 		nRowEst:	1000000,
 	}
+	if pParse.withoutRowid {
+		pTable.tabFlags |= TF_WithoutRowid
+	}
   assert( pParse.pNewTable==0 );
   pParse.pNewTable = pTable;
 
@@ -683,6 +696,9 @@ func (pParse *Parse) TwoPartName(pName1, pName2 Token) (pUnqual Token, iDb int)
     */
     if( isView || isVirtual ){
       v.AddOp2(OP_Integer, 0, reg2);
+    }else if !pTable.HasRowid() {
+      //	The rows of a WITHOUT ROWID table are stored in an index b-tree keyed by the PRIMARY KEY
+      v.AddOp2(OP_CreateIndex, iDb, reg2)
     }else
     {
       v.AddOp2(OP_CreateTable, iDb, reg2);
@@ -747,6 +763,7 @@ begin_table_error:
   ** be called next to set pCol.affinity correctly.
   */
   pCol.affinity = SQLITE_AFF_NONE;
+  pParse.AttachGenerated(pCol)
   p.nCol++;
 }
 
@@ -860,7 +877,9 @@ begin_table_error:
   p = pParse.pNewTable;
   if( p!=0 ){
     pCol = &(p.Columns[p.nCol-1]);
-    if( !sqlite3ExprIsConstantOrFunction(pSpan.Expr) ){
+    if pCol.IsGenerated() {
+      pParse.SetErrorMsg("cannot use DEFAULT on a generated column")
+    }else if( !sqlite3ExprIsConstantOrFunction(pSpan.Expr) ){
       pParse.SetErrorMsg("default value of column [%v] is not constant", pCol.Name);
     }else{
       /* A copy of pExpr is used instead of the original, as pExpr contains
@@ -912,11 +931,19 @@ begin_table_error:
   pTab.tabFlags |= TF_HasPrimaryKey;
   if pList == nil {
     iCol = pTab.nCol - 1
+    if pTab.Columns[iCol].IsGenerated() {
+      pParse.SetErrorMsg("generated columns cannot be part of the PRIMARY KEY")
+      goto primary_key_exit
+    }
     pTab.Columns[iCol].isPrimKey = 1;
   }else{
 	for _, item := range pList.Items {
 		for i, column := range pTab.Columns {
 			if CaseInsensitiveMatch(item.Name, column.Name) {
+				if column.IsGenerated() {
+					pParse.SetErrorMsg("generated columns cannot be part of the PRIMARY KEY")
+					goto primary_key_exit
+				}
 				if i < len(pTab.Columns) {
 					column.isPrimKey = true
 				}
@@ -931,14 +958,18 @@ begin_table_error:
   if iCol >= 0 && iCol < len(pTab.Columns) {
     zType = pTab.Columns[iCol].zType
   }
-  if zType != "" && CaseInsensitiveMatch(zType, "INTEGER") && sortOrder==SQLITE_SO_ASC {
+  if zType != "" && CaseInsensitiveMatch(zType, "INTEGER") && sortOrder==SQLITE_SO_ASC && pTab.HasRowid() {
     pTab.iPKey = iCol;
     pTab.keyConf = (byte)onError;
     assert( autoInc==0 || autoInc==1 );
     pTab.tabFlags |= autoInc*TF_Autoincrement;
   }else if( autoInc ){
 #ifndef SQLITE_OMIT_AUTOINCREMENT
-    pParse.SetErrorMsg("AUTOINCREMENT is only allowed on an INTEGER PRIMARY KEY");
+    if !pTab.HasRowid() {
+      pParse.SetErrorMsg("AUTOINCREMENT not allowed on WITHOUT ROWID tables")
+    } else {
+      pParse.SetErrorMsg("AUTOINCREMENT is only allowed on an INTEGER PRIMARY KEY");
+    }
 #endif
   }else{
     Index *p;
@@ -1220,6 +1251,25 @@ static char *createTableStmt(sqlite3 *db, Table *p){
 	}
   }
 
+	//	Resolve names in the expressions of the generated columns.
+	if pParse.GeneratedResolve(p) {
+		return
+	}
+
+	//	A WITHOUT ROWID table is stored in the b-tree of its PRIMARY KEY, so it must have one. The columns of the key may not be NULL.
+	pPk := p.PrimaryKey()
+	if !p.HasRowid() {
+		if pPk == nil {
+			pParse.SetErrorMsg("PRIMARY KEY missing on table %v", p.Name)
+			return
+		}
+		for _, iCol := range pPk.Columns {
+			if p.Columns[iCol].notNull == OE_None {
+				p.Columns[iCol].notNull = OE_Abort
+			}
+		}
+	}
+
   /* If the db.init.busy is 1 it means we are reading the SQL off the
   ** "sqlite_master" or "sqlite_temp_master" table on the disk.
   ** So do not write to the disk again.  Extract the root page number
@@ -1228,6 +1278,9 @@ static char *createTableStmt(sqlite3 *db, Table *p){
   */
   if( db.init.busy ){
     p.tnum = db.init.newTnum;
+    if !p.HasRowid() {
+      pPk.tnum = p.tnum
+    }
   }
 
   /* If not initializing, then create a record for the new table
@@ -1301,8 +1354,16 @@ static char *createTableStmt(sqlite3 *db, Table *p){
     if( pSelect ){
       zStmt = createTableStmt(db, p);
     }else{
-      n = (int)(pEnd.z - pParse.sNameToken.z) + 1;
-      zStmt = fmt.Sprintf("CREATE %v %v.*%v", zType2, n, pParse.sNameToken.z);
+      if pParse.zGenColText != "" {
+        //	ParseGeneratedColumns() blanked the GENERATED ALWAYS AS clauses out of the text that was parsed
+        zStmt = fmt.Sprintf("CREATE %v %v", zType2, pParse.zGenColText)
+      }else{
+        n = (int)(pEnd.z - pParse.sNameToken.z) + 1;
+        zStmt = fmt.Sprintf("CREATE %v %v.*%v", zType2, n, pParse.sNameToken.z);
+      }
+      if !p.HasRowid() {
+        zStmt += " WITHOUT ROWID"
+      }
     }
 
     /* A slot for the record has already been allocated in the
@@ -1496,7 +1557,11 @@ static char *createTableStmt(sqlite3 *db, Table *p){
     db.lookaside.bEnabled = 0;
     xAuth = db.xAuth;
     db.xAuth = 0;
+    /* No CTE of the statement that uses the view is in scope within it */
+    pSavedWith := pParse.pWith
+    pParse.pWith = nil
     pSelTab = sqlite3ResultSetOfSelect(pParse, pSel);
+    pParse.pWith = pSavedWith
     db.xAuth = xAuth;
     db.lookaside.bEnabled = enableLookaside;
     pParse.nTab = n;
@@ -1576,6 +1641,10 @@ func (pParse *Parse) destroyTable(table *Table) {
 			iLargest = iTab
 		}
 		for _, index := range table.Indices {
+			if index.IsClustered() {
+				//	The PRIMARY KEY of a WITHOUT ROWID table shares the root page of the table.
+				continue
+			}
 			iIdx := index.tnum
 			assert( index.Schema == table.Schema )
 			if (iDestroyed == 0 || (iIdx < iDestroyed)) && iIdx > iLargest {
@@ -1743,7 +1812,7 @@ void sqlite3CodeDropTable(Parse *pParse, Table *pTab, int iDb, int isView){
       goto exit_drop_table;
     }
   }
-  if CaseInsensitiveMatchN(pTab.Name, "sqlite_", 7) && !CaseInsensitiveMatchN(pTab.Name, "sqlite_stat", 11) {
+  if (CaseInsensitiveMatchN(pTab.Name, "sqlite_", 7) && !CaseInsensitiveMatchN(pTab.Name, "sqlite_stat", 11)) || pTab.tabFlags & TF_Eponymous != 0 {
     pParse.SetErrorMsg("table %v may not be dropped", pTab.Name);
     goto exit_drop_table;
   }
@@ -1944,15 +2013,26 @@ static void sqlite3RefillIndex(Parse *pParse, Index *pIndex, int memRootPage){
 
   //	Open the sorter cursor if we are to use one.
   iSorter = pParse.nTab++;
-  sqlite3VdbeAddOp4(v, OP_SorterOpen, iSorter, 0, 0, (char*)pKey, P4_KEYINFO);
+  if pTab.HasRowid() {
+    sqlite3VdbeAddOp4(v, OP_SorterOpen, iSorter, 0, 0, (char*)pKey, P4_KEYINFO);
+  } else {
+    //	The entries of an index on a WITHOUT ROWID table end with the PRIMARY KEY columns rather than a rowid. Sort on the indexed
+    //	columns alone, as OP_SorterCompare expects.
+    pSortKey := pParse.IndexKeyinfo(pIndex)
+    pSortKey.nField = uint16(len(pIndex.Columns))
+    sqlite3VdbeAddOp4(v, OP_SorterOpen, iSorter, 0, 0, (char*)pSortKey, P4_KEYINFO_HANDOFF)
+  }
 
   //	Open the table. Loop through all rows of the table, inserting index records into the sorter.
   pParse.OpenTable(pTab, iTab, iDb, OP_OpenRead)
   addr1 = v.AddOp2(OP_Rewind, iTab, 0);
   regRecord = pParse.GetTempReg()
 
+  skip := v.MakeLabel()
+  pParse.PartialIndexSkip(pIndex, iTab, skip)
   sqlite3GenerateIndexKey(pParse, pIndex, iTab, regRecord, 1);
   v.AddOp2(OP_SorterInsert, iSorter, regRecord);
+  v.ResolveLabel(skip)
   v.AddOp2(OP_Next, iTab, addr1+1);
   v.JumpHere(addr1)
   addr1 = v.AddOp2(OP_SorterSort, iSorter, 0);
@@ -2183,6 +2263,21 @@ func (pParse *Parse) CreateIndex(pName1, pName2 Token, pTblName *SrcList, pList
 	pIndex.autoIndex = (byte)(pName == 0)
 	pIndex.Schema = db.Databases[iDb].Schema
 
+	//	Attach the WHERE clause of a partial index and take the indexed expressions. Only an explicit CREATE INDEX statement can have them.
+	aIdxExpr := pParse.aIdxExpr
+	pParse.aIdxExpr = nil
+	if pParse.pPartIdxWhere != nil {
+		pIndex.pPartIdxWhere = pParse.pPartIdxWhere
+		pParse.pPartIdxWhere = nil
+		if pTblName == nil {
+			pParse.SetErrorMsg("near \"WHERE\": syntax error")
+			return
+		}
+		if pParse.IndexResolve(pIndex, pIndex.pPartIdxWhere, NC_PartIdx) {
+			return
+		}
+	}
+
 	//	Check to see if we should honor DESC requests on index columns
 	if pDb.Schema.file_format >= 4 {
 		sortOrderMask = -1			//	Honor DESC
@@ -2199,17 +2294,39 @@ func (pParse *Parse) CreateIndex(pName1, pName2 Token, pTblName *SrcList, pList
 		requestedSortOrder	int
 		zColl				string			//	Collation sequence name
 
-		for j, column = range pTab.Columns {
-			if CaseInsensitiveMatch(zColName, column.Name) {
-				break
+		var pExpr	*Expr					//	The indexed expression, if the column is one
+		if i < len(aIdxExpr) {
+			pExpr = aIdxExpr[i]
+		}
+		if pExpr != nil {
+			if pParse.IndexResolve(pIndex, pExpr, NC_IdxExpr) {
+				return
+			}
+			//	A column name in parentheses is indexed as the column itself.
+			if pExpr.op == TK_COLUMN && pExpr.iColumn >= 0 && pExpr.flags & EP_ExpCollate == 0 {
+				zColName = pTab.Columns[pExpr.iColumn].Name
+				pExpr = nil
 			}
 		}
-    	if j >= len(pTab.Columns) {
-			pParse.SetErrorMsg("table %v has no column named %v", pTab.Name, zColName)
-			pParse.checkSchema = 1
-			return
+		if pExpr != nil {
+			if pIndex.aColExpr == nil {
+				pIndex.aColExpr = make([]*Expr, nCol)
+			}
+			pIndex.aColExpr[i] = pExpr
+			pIndex.Columns[i] = XN_EXPR
+		} else {
+			for j, column = range pTab.Columns {
+				if CaseInsensitiveMatch(zColName, column.Name) {
+					break
+				}
+			}
+    		if j >= len(pTab.Columns) {
+				pParse.SetErrorMsg("table %v has no column named %v", pTab.Name, zColName)
+				pParse.checkSchema = 1
+				return
+			}
+			pIndex.Columns[i] = j
 		}
-		pIndex.Columns[i] = j
 		//	Justification of the pListItem.Expr.pColl: Because of the way the "idxlist" non-terminal is constructed by the parser, if pListItem.Expr is not null then either pListItem.Expr.pColl must exist or else there must have been an OOM error. But if there was an OOM error, we would never reach this point.
 		if pListItem.Expr != nil && pListItem.Expr.pColl != nil {
 			int nColl;
@@ -2220,6 +2337,12 @@ func (pParse *Parse) CreateIndex(pName1, pName2 Token, pTblName *SrcList, pList
 			zColl = zExtra
 			zExtra += nColl
 			nExtra -= nColl
+		} else if pExpr != nil {
+			if pColl := sqlite3ExprCollSeq(pParse, pExpr); pColl != nil {
+				zColl = pColl.Name
+			} else {
+				zColl = db.pDfltColl.Name
+			}
 		} else {
 			if zColl = pTab.Columns[j].zColl; zColl == nil {
 				zColl = db.pDfltColl.Name
@@ -2234,7 +2357,10 @@ func (pParse *Parse) CreateIndex(pName1, pName2 Token, pTblName *SrcList, pList
 	}
 	sqlite3DefaultRowEst(pIndex)
 
-	if pTab == pParse.pNewTable {
+	//	The PRIMARY KEY of a WITHOUT ROWID table shares the b-tree of the table, so it has no root page or sqlite_master entry of its own.
+	isClustered := pTab == pParse.pNewTable && !pTab.HasRowid() && pTab.tabFlags & TF_HasPrimaryKey != 0 && pTab.PrimaryKey() == nil
+
+	if pTab == pParse.pNewTable && !isClustered {
 		//	This routine has been called to create an automatic index as a result of a PRIMARY KEY or UNIQUE clause on a column definition, or a PRIMARY KEY or UNIQUE clause following the column definitions. i.e. one of:
 		//			CREATE TABLE t(x PRIMARY KEY, y);
 		//			CREATE TABLE t(x, y, UNIQUE(x, y));
@@ -2276,7 +2402,7 @@ func (pParse *Parse) CreateIndex(pName1, pName2 Token, pTblName *SrcList, pList
 		if pTblName != "" {
 			pIndex.tnum = db.init.newTnum
 		}
-	} else{
+	} else if !isClustered {
 		//	If the db.init.busy is 0 then create the index on disk. This involves writing the index into the master table and filling in the index with the current table contents.
 		//	The db.init.busy is 0 when the user first enters a CREATE INDEX command. db.init.busy is 1 when a database is opened and CREATE INDEX statements are read out of the master table. In the latter case the index already exists on disk, which is why we don't want to recreate it.
 		//	If pTblName == "" it means this index is generated as a primary key or UNIQUE constraint of a CREATE TABLE statement. Since the table has just been created, it contains no data and the index initialization step can be skipped.
@@ -2297,6 +2423,10 @@ func (pParse *Parse) CreateIndex(pName1, pName2 Token, pTblName *SrcList, pList
 			assert( pEnd != nil )
 			//	A named index with an explicit CREATE INDEX statement
 				statement = fmt.Sprintf("CREATE%v INDEX %.v%v", onError == OE_None ? "" : " UNIQUE", int(pEnd.z - pName.z) + 1, pName.z);
+			if pParse.zIndexText != "" {
+				//	The statement was rewritten by ParseCreateIndex(). Save the original.
+				statement = fmt.Sprintf("CREATE%v INDEX %v", onError == OE_None ? "" : " UNIQUE", pParse.zIndexText)
+			}
 		}
 
 		//	Add an entry in sqlite_master for this index
@@ -2652,6 +2782,7 @@ func (db *sqlite3) SrcListAppend(pList SrcList, table, database string) *SrcList
     sqlite3SelectDelete(db, pItem.Select);
     db.ExprDelete(pItem.pOn)
     sqlite3IdListDelete(db, pItem.pUsing);
+    db.ExprListDelete(pItem.pFuncArg)
   }
   pList = nil
 }
@@ -2773,7 +2904,11 @@ func (pParse *Parse) BeginTransaction(t int) {
 					sqlite3VdbeUsesBtree(v, i)
 				}
 			}
-			v.AddOp2(OP_AutoCommit, 0, 0)
+			if pParse.isConcurrent {
+				v.AddOp3(OP_AutoCommit, 0, 0, 1)
+			} else {
+				v.AddOp2(OP_AutoCommit, 0, 0)
+			}
 		}
 	}
 }
@@ -3039,7 +3174,8 @@ void sqlite3Reindex(Parse *pParse, Token *pName1, Token *pName2){
 //	Return a dynamicly allocated KeyInfo structure that can be used with OP_OpenRead or OP_OpenWrite to access database index pIdx.
 //	If successful, a pointer to the new structure is returned. If an error occurs (out of memory or missing collation sequence), NULL is returned and the state of pParse updated to reflect the error.
 func (pParse *Parse) IndexKeyinfo(pIdx *Index) (pKey *KeyInfo) {
-	nCol := len(pIdx.Columns)
+	azColl, aSortOrder := pIdx.KeySequence()
+	nCol := len(azColl)
 	nBytes := sizeof(KeyInfo) + (nCol-1)*sizeof(CollSeq*) + nCol
 	db := pParse.db
 	if pKey = (KeyInfo *)sqlite3DbMallocZero(db, nBytes); pKey != nil {
@@ -3047,10 +3183,10 @@ func (pParse *Parse) IndexKeyinfo(pIdx *Index) (pKey *KeyInfo) {
 		pKey.aSortOrder = (byte *)&(pKey.Collations[nCol])
 		assert( &pKey.aSortOrder[nCol] == &(((byte *)pKey)[nBytes]) )
 		for i := 0; i < nCol; i++ {
-			zColl := pIdx.azColl[i]
+			zColl := azColl[i]
 			assert( zColl != "" )
 			pKey.Collations[i] = pParse.LocateCollSeq(zColl)
-			pKey.aSortOrder[i] = pIdx.aSortOrder[i]
+			pKey.aSortOrder[i] = aSortOrder[i]
 		}
 		pKey.nField = uint16(nCol)
 		if pParse.nErr > 0 {
@@ -0,0 +1,363 @@
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"unsafe"
+)
+
+//	This file implements the built-in page codec, which encrypts the pages the pager writes to the database file, the rollback and
+//	statement journals and the WAL with AES-256 in GCM mode:
+//
+//		PRAGMA key = 'passphrase'				Use the passphrase for an encrypted database, or make a new database encrypted. This must
+//												come before the database is first read.
+//		PRAGMA rekey = 'new passphrase'			Re-encrypt every page of the database with a new passphrase, encrypting the database if
+//												it was not, or decrypting it if the passphrase is empty. Only a database that has the
+//												reserved bytes of the codec can be encrypted; see below.
+//		PRAGMA hexkey = '...'					As key and rekey, with the key given in hexadecimal.
+//		PRAGMA hexrekey = '...'
+//		ATTACH 'x.db' AS x KEY 'passphrase'		Attach an encrypted database. Without a KEY the attached database uses the key of main.
+//
+//	The AES key is derived from the passphrase with PBKDF2-HMAC-SHA256 and a random 16 byte salt, which is stored in place of the
+//	"SQLite format 3" string at the start of page 1. Bytes 16 to 23 of page 1, which hold the page size and the number of reserved
+//	bytes, are authenticated but not encrypted, so that the pager can size its pages before any page is decrypted. The rest of every
+//	page is encrypted, but for the last codecReserve bytes: a 16 byte GCM tag and the 12 byte nonce, which is chosen at random each
+//	time the page is written. The page number is authenticated with the page, so a page copied to another place does not decrypt.
+//	A database must therefore be created with at least codecReserve reserved bytes per page, which PRAGMA key arranges for a new
+//	database. The reserved bytes of an existing database cannot be changed in place, so PRAGMA rekey can only encrypt a plaintext
+//	database that was created with them: one that was made with PRAGMA key and later decrypted by rekeying it with an empty
+//	passphrase. It fails on any other plaintext database, whose content must be copied into a new database opened with PRAGMA key
+//	instead.
+//
+//	A codec holds two keys. Pages are decrypted with whichever key authenticates them. Pages written to the database file or the WAL
+//	are encrypted with the write key, and pages written to the journals with the read key, the key of the database as it was when the
+//	transaction began, so that a rollback restores pages the database can read. The two keys differ only while PRAGMA rekey rewrites
+//	every page of the database in one transaction. A nil key stands for plain pages, for rekeying to or from an unencrypted database.
+
+const (
+	codecNonce = 12							//	Bytes of the nonce of a page
+	codecTag = 16							//	Bytes of the GCM tag of a page
+	codecReserve = codecTag + codecNonce	//	Reserved bytes used by the codec at the end of each page
+	codecSalt = 16							//	Bytes of the salt at the start of page 1
+	codecKdfIter = 256000					//	PBKDF2 iterations
+	codecMagic = "SQLite format 3\000"		//	The start of page 1 of an unencrypted database
+)
+
+//	A codecKey is a passphrase and the AES-GCM cipher derived from it.
+type codecKey struct {
+	zPass			[]byte
+	aead			cipher.AEAD				//	Derived from zPass when the salt of the database is known
+}
+
+//	A codec is the codec of one pager.
+type codec struct {
+	pRead			*codecKey				//	Key pages are read and journalled with
+	pWrite			*codecKey				//	Key pages are written to the database and WAL with
+	aSalt			[]byte					//	Salt of the database, or nil until it is read or made
+	pageSize		int
+	nReserve		int
+	aBuf			[]byte					//	Buffer that encrypted pages are returned in
+}
+
+func newCodecKey(zPass string) *codecKey {
+	if zPass == "" {
+		return nil
+	}
+	return &codecKey{ zPass: []byte(zPass) }
+}
+
+//	Return the cipher of pKey, deriving it if need be. The salt of the database must be known.
+func (p *codec) cipher(pKey *codecKey) cipher.AEAD {
+	if pKey.aead == nil {
+		aKey, err := pbkdf2.Key(sha256.New, string(pKey.zPass), p.aSalt, codecKdfIter, 32)
+		if err != nil {
+			return nil
+		}
+		pBlock, err := aes.NewCipher(aKey)
+		if err != nil {
+			return nil
+		}
+		if pKey.aead, err = cipher.NewGCM(pBlock); err != nil {
+			return nil
+		}
+	}
+	return pKey.aead
+}
+
+//	Return the additional data authenticated with page pgno, whose content is aData.
+func codecAdditionalData(aData []byte, pgno PageNumber) []byte {
+	aAD := binary.BigEndian.AppendUint32(nil, uint32(pgno))
+	if pgno == 1 {
+		aAD = append(aAD, aData[16:24]...)
+	}
+	return aAD
+}
+
+//	Return the offset of the first encrypted byte of page pgno.
+func codecStart(pgno PageNumber) int {
+	if pgno == 1 {
+		return 24
+	}
+	return 0
+}
+
+//	Encrypt page pgno with pKey into the codec's buffer and return it, or nil if that fails.
+func (p *codec) encrypt(aData []byte, pgno PageNumber, pKey *codecKey) []byte {
+	if pKey == nil {
+		return aData
+	}
+	if p.nReserve < codecReserve {
+		return nil
+	}
+	if p.aSalt == nil {
+		aSalt := make([]byte, codecSalt)
+		if _, err := rand.Read(aSalt); err != nil {
+			return nil
+		}
+		p.aSalt = aSalt
+	}
+	aead := p.cipher(pKey)
+	if aead == nil {
+		return nil
+	}
+	if len(p.aBuf) != p.pageSize {
+		p.aBuf = make([]byte, p.pageSize)
+	}
+	iStart, iEnd := codecStart(pgno), p.pageSize - codecReserve
+	aNonce := p.aBuf[iEnd + codecTag:iEnd + codecReserve]
+	if _, err := rand.Read(aNonce); err != nil {
+		return nil
+	}
+	copy(p.aBuf[:iStart], aData[:iStart])
+	if pgno == 1 {
+		copy(p.aBuf, p.aSalt)
+	}
+	aead.Seal(p.aBuf[iStart:iStart], aNonce, aData[iStart:iEnd], codecAdditionalData(aData, pgno))
+	return p.aBuf
+}
+
+//	Decrypt page pgno in place with whichever key authenticates it. Return SQLITE_NOTADB if page 1 is not authenticated by either key
+//	or is plain text when the database is keyed, or SQLITE_CORRUPT if another page is not authenticated.
+func (p *codec) decrypt(aData []byte, pgno PageNumber) int {
+	if pgno == 1 {
+		//	The pager reads page 1 with the page size it has before it knows the page size of the database. Leave the page for the btree
+		//	layer to see the size in the header, which is not encrypted, and read it again.
+		if pageSize := int(binary.BigEndian.Uint16(aData[16:])); pageSize != p.pageSize && !(pageSize == 1 && p.pageSize == 65536) {
+			copy(aData, codecMagic)
+			return SQLITE_OK
+		}
+		if string(aData[:codecSalt]) == codecMagic {
+			if p.pRead == nil || p.pWrite == nil {
+				return SQLITE_OK
+			}
+			return SQLITE_NOTADB
+		}
+		if p.aSalt == nil {
+			p.aSalt = append([]byte{}, aData[:codecSalt]...)
+		}
+	}
+	isPlain := p.pRead == nil || p.pWrite == nil
+	if p.nReserve >= codecReserve && p.aSalt != nil {
+		iStart, iEnd := codecStart(pgno), p.pageSize - codecReserve
+		aNonce := aData[iEnd + codecTag:iEnd + codecReserve]
+		aAD := codecAdditionalData(aData, pgno)
+		if len(p.aBuf) != p.pageSize {
+			p.aBuf = make([]byte, p.pageSize)
+		}
+		for _, pKey := range []*codecKey{ p.pWrite, p.pRead } {
+			if pKey == nil {
+				continue
+			}
+			aead := p.cipher(pKey)
+			if aead == nil {
+				continue
+			}
+			if _, err := aead.Open(p.aBuf[iStart:iStart], aNonce, aData[iStart:iEnd + codecTag], aAD); err == nil {
+				copy(aData[iStart:iEnd], p.aBuf[iStart:iEnd])
+				if pgno == 1 {
+					copy(aData, codecMagic)
+				}
+				return SQLITE_OK
+			}
+		}
+	}
+	switch {
+	case isPlain:
+		return SQLITE_OK
+	case pgno == 1:
+		return SQLITE_NOTADB
+	}
+	return SQLITE_CORRUPT_BKPT
+}
+
+//	The xCodec method of the codec, called by the pager with op 3 to decrypt a page read from any file in place, with op 6 to encrypt a
+//	page for the database file or the WAL and with op 7 to encrypt a page for a journal. Encrypted pages are returned in a buffer of the
+//	codec that is valid until the next call. A page that does not decrypt is reported through *pRc.
+func sqlite3Codec(pCodec interface{}, pData *byte, pgno PageNumber, op int, pRc *int) *byte {
+	p := pCodec.(*codec)
+	aData := unsafe.Slice(pData, p.pageSize)
+	switch op {
+	case 0, 2, 3:
+		if *pRc = p.decrypt(aData, pgno); *pRc != SQLITE_OK {
+			return nil
+		}
+		return pData
+	case 6:
+		if aOut := p.encrypt(aData, pgno, p.pWrite); aOut != nil {
+			return &aOut[0]
+		}
+	case 7:
+		if aOut := p.encrypt(aData, pgno, p.pRead); aOut != nil {
+			return &aOut[0]
+		}
+	}
+	return nil
+}
+
+func sqlite3CodecSizeChange(pCodec interface{}, pageSize, nReserve int) {
+	p := pCodec.(*codec)
+	p.pageSize, p.nReserve = pageSize, nReserve
+}
+
+func sqlite3CodecFree(pCodec interface{}) {
+	p := pCodec.(*codec)
+	p.pRead, p.pWrite, p.aBuf = nil, nil, nil
+}
+
+//	Set the key of database iDb to the nKey bytes of pKey, or use no codec if nKey is 0. A new database is given the reserved bytes
+//	the codec needs.
+func sqlite3CodecAttach(db *sqlite3, iDb int, pKey string, nKey int) int {
+	pBt := db.Databases[iDb].pBt
+	if pBt == nil {
+		return SQLITE_OK
+	}
+	pPager := pBt.Pager()
+	if nKey == 0 {
+		sqlite3PagerSetCodec(pPager, nil, nil, nil, nil)
+		return SQLITE_OK
+	}
+	pCodecKey := newCodecKey(pKey[:nKey])
+	sqlite3PagerSetCodec(pPager, sqlite3Codec, sqlite3CodecSizeChange, sqlite3CodecFree, &codec{ pRead: pCodecKey, pWrite: pCodecKey })
+	if sqlite3BtreeGetReserve(pBt) < codecReserve {
+		//	This has no effect on an existing database, whose header sets the reserved bytes when it is read.
+		if rc := sqlite3BtreeSetPageSize(pBt, -1, codecReserve, 0); rc != SQLITE_OK && rc != SQLITE_READONLY {
+			return rc
+		}
+	}
+	return SQLITE_OK
+}
+
+//	Return the key of database iDb, as ATTACH and VACUUM need it.
+func sqlite3CodecGetKey(db *sqlite3, iDb int, pzKey *string, pnKey *int) {
+	*pzKey, *pnKey = "", 0
+	if pBt := db.Databases[iDb].pBt; pBt != nil {
+		if p, ok := sqlite3PagerGetCodec(pBt.Pager()).(*codec); ok && p.pRead != nil {
+			*pzKey, *pnKey = string(p.pRead.zPass), len(p.pRead.zPass)
+		}
+	}
+}
+
+//	Return the index of the database named zDbName, main if it is empty, or -1 if there is none.
+func codecFindDb(db *sqlite3, zDbName string) int {
+	if zDbName == "" {
+		return 0
+	}
+	return db.FindDbName(zDbName)
+}
+
+func sqlite3_key(db *sqlite3, pKey string, nKey int) int {
+	return sqlite3_key_v2(db, "", pKey, nKey)
+}
+
+//	Set the key of the database named zDbName. This must be called before the database is first read.
+func sqlite3_key_v2(db *sqlite3, zDbName string, pKey string, nKey int) (rc int) {
+	db.mutex.CriticalSection(func() {
+		if iDb := codecFindDb(db, zDbName); iDb < 0 {
+			db.Error(SQLITE_ERROR, "unknown database %v", zDbName)
+			rc = SQLITE_ERROR
+		} else {
+			rc = sqlite3CodecAttach(db, iDb, pKey, nKey)
+		}
+	})
+	return
+}
+
+func sqlite3_rekey(db *sqlite3, pKey string, nKey int) int {
+	return sqlite3_rekey_v2(db, "", pKey, nKey)
+}
+
+//	Change the key of the database named zDbName to the nKey bytes of pKey by rewriting every page in a single write transaction, or
+//	decrypt the database if nKey is 0. If the transaction fails the database keeps its old key. A plaintext database can only be
+//	encrypted if it has codecReserve reserved bytes per page, which only a database created with a key has.
+func sqlite3_rekey_v2(db *sqlite3, zDbName string, pKey string, nKey int) (rc int) {
+	db.mutex.CriticalSection(func() {
+		iDb := codecFindDb(db, zDbName)
+		if iDb < 0 || db.Databases[iDb].pBt == nil {
+			db.Error(SQLITE_ERROR, "unknown database %v", zDbName)
+			rc = SQLITE_ERROR
+			return
+		}
+		pBt := db.Databases[iDb].pBt
+		pPager := pBt.Pager()
+		if pBt.IsInTrans() {
+			db.Error(SQLITE_ERROR, "cannot rekey a database within a transaction")
+			rc = SQLITE_ERROR
+			return
+		}
+		p, _ := sqlite3PagerGetCodec(pPager).(*codec)
+		if p == nil {
+			if nKey == 0 {
+				return
+			}
+			if rc = pBt.BeginTransaction(0); rc == SQLITE_OK {
+				//	The reserved bytes are known once page 1 has been read.
+				if sqlite3BtreeGetReserve(pBt) < codecReserve {
+					db.Error(SQLITE_ERROR, "database has too few reserved bytes per page to be encrypted")
+					rc = SQLITE_ERROR
+				}
+				pBt.Commit()
+			}
+			if rc != SQLITE_OK {
+				return
+			}
+			p = new(codec)
+			sqlite3PagerSetCodec(pPager, sqlite3Codec, sqlite3CodecSizeChange, sqlite3CodecFree, p)
+		}
+		p.pWrite = newCodecKey(pKey[:nKey])
+		if rc = pBt.BeginTransaction(1); rc == SQLITE_OK {
+			if p.pWrite != nil && sqlite3BtreeGetReserve(pBt) < codecReserve {
+				db.Error(SQLITE_ERROR, "database has too few reserved bytes per page to be encrypted")
+				rc = SQLITE_ERROR
+			}
+			var nPage int
+			sqlite3PagerPagecount(pPager, &nPage)
+			for pgno := PageNumber(1); rc == SQLITE_OK && pgno <= PageNumber(nPage); pgno++ {
+				if pgno == PAGER_MJ_PGNO(pPager) {
+					continue
+				}
+				var pPage *DbPage
+				if pPage, rc = pPager.Acquire(pgno, false); rc == SQLITE_OK {
+					rc = pPage.Write()
+					pPage.Unref()
+				}
+			}
+			if rc == SQLITE_OK {
+				rc = pBt.Commit()
+			}
+			if rc != SQLITE_OK {
+				pBt.Rollback(SQLITE_OK)
+			}
+		}
+		if rc != SQLITE_OK {
+			p.pWrite = p.pRead
+		} else {
+			p.pRead = p.pWrite
+		}
+		if p.pRead == nil && p.pWrite == nil {
+			sqlite3PagerSetCodec(pPager, nil, nil, nil, nil)
+		}
+	})
+	return
+}
@@ -0,0 +1,160 @@
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestCodecPage(t *testing.T) {
+	const pageSize = 1024
+	const iEnd = pageSize - codecReserve
+	pKey := newCodecKey("secret")
+	p := &codec{ pRead: pKey, pWrite: pKey, pageSize: pageSize, nReserve: codecReserve }
+
+	aPage := make([]byte, pageSize)
+	rand.New(rand.NewSource(1)).Read(aPage)
+	aEnc := append([]byte{}, p.encrypt(aPage, 2, pKey)...)
+	if bytes.Equal(aEnc[:iEnd], aPage[:iEnd]) {
+		t.Fatal("page not encrypted")
+	}
+	if bytes.Equal(p.encrypt(aPage, 2, pKey), aEnc) {
+		t.Error("page encrypted twice with the same nonce")
+	}
+	aDec := append([]byte{}, aEnc...)
+	if p.decrypt(aDec, 2); !bytes.Equal(aDec[:iEnd], aPage[:iEnd]) {
+		t.Error("page does not decrypt")
+	}
+
+	//	A page read in another place, a changed page or a page read with another key is corrupt.
+	aDec = append([]byte{}, aEnc...)
+	if rc := p.decrypt(aDec, 3); rc != SQLITE_CORRUPT {
+		t.Errorf("page decrypts as another page: %v", rc)
+	}
+	aDec = append([]byte{}, aEnc...)
+	aDec[10] ^= 1
+	if rc := p.decrypt(aDec, 2); rc != SQLITE_CORRUPT {
+		t.Errorf("changed page decrypts: %v", rc)
+	}
+	pOther := newCodecKey("other")
+	q := &codec{ pRead: pOther, pWrite: pOther, aSalt: p.aSalt, pageSize: pageSize, nReserve: codecReserve }
+	aDec = append([]byte{}, aEnc...)
+	if rc := q.decrypt(aDec, 2); rc != SQLITE_CORRUPT {
+		t.Errorf("page decrypts with another key: %v", rc)
+	}
+
+	//	Page 1 keeps the page size and reserved bytes in plain text, and the salt in place of the magic string.
+	copy(aPage, codecMagic)
+	binary.BigEndian.PutUint16(aPage[16:], pageSize)
+	aPage[20] = codecReserve
+	aEnc = append([]byte{}, p.encrypt(aPage, 1, pKey)...)
+	if !bytes.Equal(aEnc[:codecSalt], p.aSalt) || !bytes.Equal(aEnc[16:24], aPage[16:24]) {
+		t.Error("page 1 header not kept")
+	}
+	aDec = append([]byte{}, aEnc...)
+	if rc := q.decrypt(aDec, 1); rc != SQLITE_NOTADB {
+		t.Errorf("page 1 decrypts with another key: %v", rc)
+	}
+	if rc := p.decrypt(aEnc, 1); rc != SQLITE_OK || !bytes.Equal(aEnc[:iEnd], aPage[:iEnd]) {
+		t.Errorf("page 1 does not decrypt: %v", rc)
+	}
+
+	//	A page without room for the nonce and tag cannot be encrypted.
+	p.nReserve = 0
+	if p.encrypt(aPage, 2, pKey) != nil {
+		t.Error("page encrypted without reserved bytes")
+	}
+}
+
+//	Open zFile with the passphrase zKey, or without a key if it is empty.
+func testOpenKey(t *testing.T, zFile, zKey string) *sql.DB {
+	t.Helper()
+	db := testOpen(t, zFile)
+	if zKey != "" {
+		testExec(t, db, "PRAGMA key = '" + zKey + "'")
+	}
+	return db
+}
+
+//	Report an error unless zFile can be read with zKey and holds the row written by TestCodec, or cannot be read if ok is false.
+func testCodecRead(t *testing.T, zFile, zKey string, ok bool) {
+	t.Helper()
+	db := testOpenKey(t, zFile, zKey)
+	defer db.Close()
+	var s string
+	switch err := db.QueryRow("SELECT s FROM t").Scan(&s); {
+	case ok && err != nil:
+		t.Errorf("key %q: %v", zKey, err)
+	case ok && s != "plaintext marker":
+		t.Errorf("key %q: read %q", zKey, s)
+	case !ok && err == nil:
+		t.Errorf("key %q: database read", zKey)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	zFile := testFile(t)
+	db := testOpenKey(t, zFile, "secret")
+	testExec(t, db, "CREATE TABLE t(s); INSERT INTO t VALUES('plaintext marker')")
+	db.Close()
+
+	aData, err := os.ReadFile(zFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(aData, []byte("plaintext marker")) || bytes.HasPrefix(aData, []byte(codecMagic)) {
+		t.Error("database file not encrypted")
+	}
+	testCodecRead(t, zFile, "secret", true)
+	testCodecRead(t, zFile, "wrong", false)
+	testCodecRead(t, zFile, "", false)
+	db = testOpenKey(t, zFile, "wrong")
+	if _, err := db.Exec("SELECT * FROM t"); testCode(err) != SQLITE_NOTADB {
+		t.Errorf("read with the wrong key: %v, want SQLITE_NOTADB", err)
+	}
+	db.Close()
+
+	db = testOpenKey(t, zFile, "secret")
+	testExec(t, db, "PRAGMA rekey = 'changed'")
+	db.Close()
+	testCodecRead(t, zFile, "changed", true)
+	testCodecRead(t, zFile, "secret", false)
+
+	//	Rekeying with an empty passphrase decrypts the database, which keeps the reserved bytes to be encrypted again.
+	db = testOpenKey(t, zFile, "changed")
+	testExec(t, db, "PRAGMA rekey = ''")
+	db.Close()
+	if aData, _ = os.ReadFile(zFile); !bytes.HasPrefix(aData, []byte(codecMagic)) {
+		t.Error("database file not decrypted")
+	}
+	testCodecRead(t, zFile, "", true)
+	db = testOpen(t, zFile)
+	testExec(t, db, "PRAGMA rekey = 'again'")
+	db.Close()
+	testCodecRead(t, zFile, "again", true)
+	testCodecRead(t, zFile, "", false)
+}
+
+func TestCodecPlainDatabase(t *testing.T) {
+	db := testOpen(t, testFile(t))
+	testExec(t, db, "CREATE TABLE t(s); INSERT INTO t VALUES('x')")
+	if _, err := db.Exec("PRAGMA rekey = 'secret'"); err == nil {
+		t.Error("database without reserved bytes encrypted")
+	}
+	testQueryIs(t, db, "x", "SELECT s FROM t")
+}
+
+func TestCodecHexKey(t *testing.T) {
+	zFile := testFile(t)
+	db := testOpen(t, zFile)
+	for _, query := range []string{ "PRAGMA hexkey = 'abc'", "PRAGMA hexkey = '0g'", "PRAGMA hexrekey = 'xyz1'" } {
+		if _, err := db.Exec(query); err == nil {
+			t.Errorf("%v: no error", query)
+		}
+	}
+	testExec(t, db, "PRAGMA hexkey = '736563726574'")
+	testExec(t, db, "CREATE TABLE t(s); INSERT INTO t VALUES('plaintext marker')")
+	db.Close()
+	testCodecRead(t, zFile, "secret", true)
+}
@@ -0,0 +1,564 @@
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sort"
+	"sync"
+)
+
+//	This file implements CompressVFS, a VFS shim that stores database files compressed on any other Go VFS:
+//
+//			RegisterVFS("compress", NewCompressVFS(UnixVFS{}, flate.BestCompression), false)
+//			sqlite3_open_v2("archive.db", &db, SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE, "compress")
+//
+//	Only main database files are compressed. Journals, WALs and temporary files are passed to the underlying VFS as they are, and so is
+//	a database that already exists and is not compressed. The pager, and through it the backup API, sees the ordinary pages of an
+//	ordinary database, so a backup of a compressed database into one opened through another VFS is a normal database file.
+//
+//	The logical file is divided into blocks, whose size is that of the first write to a new file, normally the page size. Each block is
+//	compressed with DEFLATE and stored wherever it fits in the underlying file, or stored as it is if it does not compress, or not at all
+//	if it is all zeroes. The underlying file is laid out as:
+//
+//		0		compressHeaderSize bytes of header:
+//					0	16	compressMagic
+//					16	4	Block size
+//					20	4	Generation, incremented each time the header is written
+//					24	8	Size of the logical file
+//					32	8	Offset of the index
+//					40	4	Bytes in the index
+//					44	4	CRC-32 of the index
+//					48	4	CRC-32 of bytes 0 to 47 of the header
+//		512		Stored blocks, the index and free space, in any order.
+//
+//	The index maps each block to its place in the file with 12 bytes: the offset of the stored block as 8 bytes, then 4 bytes holding its
+//	length, with the high bit set if it is stored uncompressed. Space is allocated in units of compressGrain bytes, first fit from the
+//	free space that the file had at the last time the header was written, or else at the end of the file.
+//
+//	Blocks are never overwritten in place. A write stores the block in free space and leaves the space it had until the header is next
+//	written, which is on Sync(), on Unlock() and on Close(). At that point the new index is written to free space too, and only then,
+//	with a sync in between if Sync() is the caller, the header that points to it. A crash therefore leaves the file as it was at the
+//	last header, from which the pager's journal restores the database as usual. A connection reads the generation in the header
+//	before each read or write of the file and reloads the index if another connection has changed it. Taking a SHARED lock is not
+//	enough to know that: in WAL mode a reader keeps its SHARED lock across transactions while another connection checkpoints into the
+//	file.
+
+//	CompressVFS implements VFS on top of another VFS.
+type CompressVFS struct {
+	base		VFS
+	level		int
+}
+
+const (
+	compressMagic = "SQLite compress\000"
+	compressHeaderSize = 512
+	compressGrain = 64							//	Unit of allocation in the underlying file
+	compressDefaultBlock = 4096					//	Block size of a file whose first write is not a page
+	compressRaw = 0x80000000					//	Flag in the index for a block stored uncompressed
+)
+
+//	errNotCompressed is returned by compressFile.load() for a file that is not in the compressed format.
+var errNotCompressed = errors.New("sqlite3: file is not compressed")
+
+//	NewCompressVFS returns a CompressVFS that stores files through base, compressed at level, one of the compress/flate levels.
+func NewCompressVFS(base VFS, level int) *CompressVFS {
+	return &CompressVFS{ base: base, level: level }
+}
+
+//	A compressBlock is the entry of the index for one block. A block that is all zeroes is not stored and has an iOff of 0.
+type compressBlock struct {
+	iOff		int64
+	nByte		int						//	Bytes stored
+	isRaw		bool					//	True if the block is stored uncompressed
+}
+
+//	A compressExtent is a range of bytes of the underlying file.
+type compressExtent struct {
+	iOff, nByte		int64
+}
+
+//	compressFile implements File for a database file stored compressed.
+type compressFile struct {
+	mutex			sync.Mutex
+	base			File
+	level			int
+	blockSize		int
+	iGeneration		uint32				//	Generation of the header the index was read from or last written with
+	size			int64				//	Size of the logical file
+	aBlock			[]compressBlock
+	index			compressExtent		//	Where the index is stored, if nByte is not zero
+	aFree			[]compressExtent	//	Free space, by offset
+	aPending		[]compressExtent	//	Space freed since the header was written, which cannot be used until it is written again
+	nEnd			int64				//	Size of the underlying file
+	isDirty			bool				//	True if the header must be written
+	lock			int
+
+	iCache			int					//	Block held decompressed in aCache, or -1
+	aCache			[]byte
+	pWriter			*flate.Writer
+	zBuf			bytes.Buffer
+}
+
+func (v *CompressVFS) Open(name string, flags int) (f File, outFlags int, err error) {
+	if f, outFlags, err = v.base.Open(name, flags); err != nil || flags & SQLITE_OPEN_MAIN_DB == 0 {
+		return
+	}
+	p := &compressFile{ base: f, level: v.level, iCache: -1 }
+	switch err = p.load(); {
+	case err == errNotCompressed:
+		return f, outFlags, nil
+	case err != nil:
+		f.Close()
+		return nil, 0, err
+	}
+	if p.pWriter, err = flate.NewWriter(&p.zBuf, v.level); err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return p, outFlags, nil
+}
+
+func (v *CompressVFS) Delete(name string, syncDir bool) error {
+	return v.base.Delete(name, syncDir)
+}
+
+func (v *CompressVFS) Access(name string, flags int) (bool, error) {
+	return v.base.Access(name, flags)
+}
+
+func (v *CompressVFS) FullPathname(name string) (string, error) {
+	return v.base.FullPathname(name)
+}
+
+//	Return n rounded up to the unit of allocation.
+func compressRound(n int64) int64 {
+	return (n + compressGrain - 1) / compressGrain * compressGrain
+}
+
+//	Read the header and, if its generation is not the one already loaded, the index. A file that is empty is a new compressed file.
+func (p *compressFile) load() error {
+	nFile, err := p.base.Size()
+	if err != nil {
+		return err
+	}
+	if nFile == 0 {
+		p.blockSize, p.iGeneration, p.size, p.aBlock, p.index = 0, 0, 0, nil, compressExtent{}
+		p.aFree, p.aPending, p.nEnd, p.iCache = nil, nil, compressHeaderSize, -1
+		return nil
+	}
+	aHdr := make([]byte, 52)
+	if _, err := p.base.ReadAt(aHdr, 0); err != nil && err != io.EOF {
+		return err
+	}
+	if string(aHdr[:16]) != compressMagic {
+		return errNotCompressed
+	}
+	if crc32.ChecksumIEEE(aHdr[:48]) != binary.BigEndian.Uint32(aHdr[48:]) {
+		return &Error{ Code: SQLITE_CORRUPT }
+	}
+	iGeneration := binary.BigEndian.Uint32(aHdr[20:])
+	if iGeneration == p.iGeneration && p.blockSize != 0 {
+		return nil
+	}
+	index := compressExtent{ int64(binary.BigEndian.Uint64(aHdr[32:])), int64(binary.BigEndian.Uint32(aHdr[40:])) }
+	aIndex := make([]byte, index.nByte)
+	if _, err := p.base.ReadAt(aIndex, index.iOff); err != nil {
+		return &Error{ Code: SQLITE_CORRUPT }
+	}
+	if crc32.ChecksumIEEE(aIndex) != binary.BigEndian.Uint32(aHdr[44:]) || len(aIndex) % 12 != 0 {
+		return &Error{ Code: SQLITE_CORRUPT }
+	}
+	p.blockSize = int(binary.BigEndian.Uint32(aHdr[16:]))
+	p.iGeneration = iGeneration
+	p.size = int64(binary.BigEndian.Uint64(aHdr[24:]))
+	p.index = index
+	p.aBlock = make([]compressBlock, len(aIndex) / 12)
+	for i := range p.aBlock {
+		n := binary.BigEndian.Uint32(aIndex[i * 12 + 8:])
+		p.aBlock[i] = compressBlock{ int64(binary.BigEndian.Uint64(aIndex[i * 12:])), int(n &^ compressRaw), n & compressRaw != 0 }
+	}
+	p.iCache = -1
+
+	//	Everything that neither the header, the index nor a block uses is free.
+	aUsed := []compressExtent{ { 0, compressHeaderSize } }
+	if p.index.nByte > 0 {
+		aUsed = append(aUsed, compressExtent{ p.index.iOff, compressRound(p.index.nByte) })
+	}
+	for _, pBlock := range p.aBlock {
+		if pBlock.iOff != 0 {
+			aUsed = append(aUsed, compressExtent{ pBlock.iOff, compressRound(int64(pBlock.nByte)) })
+		}
+	}
+	sort.Slice(aUsed, func(i, j int) bool { return aUsed[i].iOff < aUsed[j].iOff })
+	p.aFree, p.aPending, p.nEnd = nil, nil, 0
+	for _, x := range aUsed {
+		if x.iOff > p.nEnd {
+			p.aFree = append(p.aFree, compressExtent{ p.nEnd, x.iOff - p.nEnd })
+		}
+		if x.iOff + x.nByte > p.nEnd {
+			p.nEnd = x.iOff + x.nByte
+		}
+	}
+	return nil
+}
+
+//	Reload the index if another connection has written the header since this one last read or wrote it. Nothing is reloaded while
+//	this connection has changes it has not written a header for, as only the connection that holds the write lock, or that runs a
+//	checkpoint, writes the file.
+func (p *compressFile) revalidate() error {
+	if p.isDirty {
+		return nil
+	}
+	return p.load()
+}
+
+//	Allocate nByte bytes of the underlying file.
+func (p *compressFile) alloc(nByte int64) int64 {
+	nByte = compressRound(nByte)
+	for i, x := range p.aFree {
+		if x.nByte >= nByte {
+			if x.nByte == nByte {
+				p.aFree = append(p.aFree[:i], p.aFree[i + 1:]...)
+			} else {
+				p.aFree[i] = compressExtent{ x.iOff + nByte, x.nByte - nByte }
+			}
+			return x.iOff
+		}
+	}
+	iOff := p.nEnd
+	p.nEnd += nByte
+	return iOff
+}
+
+//	Free the nByte bytes at iOff once the header has been written.
+func (p *compressFile) free(iOff, nByte int64) {
+	if iOff != 0 {
+		p.aPending = append(p.aPending, compressExtent{ iOff, compressRound(nByte) })
+	}
+}
+
+//	Move the space freed since the header was last written to the free list, and return free space at the end of the file to the
+//	underlying VFS.
+func (p *compressFile) release() error {
+	aFree := append(p.aFree, p.aPending...)
+	p.aPending = nil
+	sort.Slice(aFree, func(i, j int) bool { return aFree[i].iOff < aFree[j].iOff })
+	p.aFree = aFree[:0]
+	for _, x := range aFree {
+		if n := len(p.aFree); n > 0 && p.aFree[n - 1].iOff + p.aFree[n - 1].nByte == x.iOff {
+			p.aFree[n - 1].nByte += x.nByte
+		} else {
+			p.aFree = append(p.aFree, x)
+		}
+	}
+	if n := len(p.aFree); n > 0 && p.aFree[n - 1].iOff + p.aFree[n - 1].nByte == p.nEnd {
+		p.nEnd = p.aFree[n - 1].iOff
+		p.aFree = p.aFree[:n - 1]
+		return p.base.Truncate(p.nEnd)
+	}
+	return nil
+}
+
+//	Return the content of block i. The slice is the cache, which the next call may overwrite.
+func (p *compressFile) readBlock(i int) ([]byte, error) {
+	if i == p.iCache {
+		return p.aCache, nil
+	}
+	if len(p.aCache) != p.blockSize {
+		p.aCache = make([]byte, p.blockSize)
+	}
+	p.iCache = -1
+	if i >= len(p.aBlock) || p.aBlock[i].iOff == 0 {
+		for j := range p.aCache {
+			p.aCache[j] = 0
+		}
+		p.iCache = i
+		return p.aCache, nil
+	}
+	pBlock := p.aBlock[i]
+	aData := make([]byte, pBlock.nByte)
+	if _, err := p.base.ReadAt(aData, pBlock.iOff); err != nil {
+		return nil, err
+	}
+	if pBlock.isRaw {
+		copy(p.aCache, aData)
+	} else {
+		r := flate.NewReader(bytes.NewReader(aData))
+		_, err := io.ReadFull(r, p.aCache)
+		r.Close()
+		if err != nil {
+			return nil, &Error{ Code: SQLITE_CORRUPT }
+		}
+	}
+	p.iCache = i
+	return p.aCache, nil
+}
+
+//	Store aData, blockSize bytes, as block i.
+func (p *compressFile) writeBlock(i int, aData []byte) error {
+	for len(p.aBlock) <= i {
+		p.aBlock = append(p.aBlock, compressBlock{})
+	}
+	p.free(p.aBlock[i].iOff, int64(p.aBlock[i].nByte))
+	p.aBlock[i] = compressBlock{}
+	p.isDirty = true
+	if i == p.iCache {
+		p.iCache = -1
+	}
+	isZero := true
+	for _, c := range aData {
+		if c != 0 {
+			isZero = false
+			break
+		}
+	}
+	if isZero {
+		return nil
+	}
+	p.zBuf.Reset()
+	p.pWriter.Reset(&p.zBuf)
+	if _, err := p.pWriter.Write(aData); err != nil {
+		return err
+	}
+	if err := p.pWriter.Close(); err != nil {
+		return err
+	}
+	pBlock := compressBlock{ nByte: p.zBuf.Len() }
+	zStored := p.zBuf.Bytes()
+	if pBlock.nByte >= len(aData) {
+		pBlock = compressBlock{ nByte: len(aData), isRaw: true }
+		zStored = aData
+	}
+	pBlock.iOff = p.alloc(int64(pBlock.nByte))
+	if _, err := p.base.WriteAt(zStored, pBlock.iOff); err != nil {
+		return err
+	}
+	p.aBlock[i] = pBlock
+	return nil
+}
+
+//	Write the index and then the header, syncing the underlying file after each if isSync is true.
+func (p *compressFile) flush(isSync bool, flags int) error {
+	if !p.isDirty {
+		if isSync {
+			return p.base.Sync(flags)
+		}
+		return nil
+	}
+	aIndex := make([]byte, len(p.aBlock) * 12)
+	for i, pBlock := range p.aBlock {
+		n := uint32(pBlock.nByte)
+		if pBlock.isRaw {
+			n |= compressRaw
+		}
+		binary.BigEndian.PutUint64(aIndex[i * 12:], uint64(pBlock.iOff))
+		binary.BigEndian.PutUint32(aIndex[i * 12 + 8:], n)
+	}
+	index := compressExtent{ 0, int64(len(aIndex)) }
+	if index.nByte > 0 {
+		index.iOff = p.alloc(index.nByte)
+		if _, err := p.base.WriteAt(aIndex, index.iOff); err != nil {
+			return err
+		}
+	}
+	if isSync {
+		if err := p.base.Sync(flags); err != nil {
+			return err
+		}
+	}
+	aHdr := make([]byte, 52)
+	copy(aHdr, compressMagic)
+	binary.BigEndian.PutUint32(aHdr[16:], uint32(p.blockSize))
+	binary.BigEndian.PutUint32(aHdr[20:], p.iGeneration + 1)
+	binary.BigEndian.PutUint64(aHdr[24:], uint64(p.size))
+	binary.BigEndian.PutUint64(aHdr[32:], uint64(index.iOff))
+	binary.BigEndian.PutUint32(aHdr[40:], uint32(index.nByte))
+	binary.BigEndian.PutUint32(aHdr[44:], crc32.ChecksumIEEE(aIndex))
+	binary.BigEndian.PutUint32(aHdr[48:], crc32.ChecksumIEEE(aHdr[:48]))
+	if _, err := p.base.WriteAt(aHdr, 0); err != nil {
+		return err
+	}
+	if isSync {
+		if err := p.base.Sync(flags); err != nil {
+			return err
+		}
+	}
+	p.free(p.index.iOff, p.index.nByte)
+	p.index = index
+	p.iGeneration++
+	p.isDirty = false
+	return p.release()
+}
+
+func (p *compressFile) ReadAt(b []byte, off int64) (n int, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.revalidate(); err != nil {
+		return 0, err
+	}
+	for n < len(b) && off < p.size {
+		aData, err := p.readBlock(int(off / int64(p.blockSize)))
+		if err != nil {
+			return n, err
+		}
+		iStart := int(off % int64(p.blockSize))
+		nCopy := copy(b[n:], aData[iStart:])
+		if rest := p.size - off; int64(nCopy) > rest {
+			nCopy = int(rest)
+		}
+		n += nCopy
+		off += int64(nCopy)
+	}
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (p *compressFile) WriteAt(b []byte, off int64) (n int, err error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.revalidate(); err != nil {
+		return 0, err
+	}
+	if p.blockSize == 0 {
+		p.blockSize = compressDefaultBlock
+		if nByte := len(b); nByte >= 512 && nByte <= 65536 && nByte & (nByte - 1) == 0 && off % int64(nByte) == 0 {
+			p.blockSize = nByte
+		}
+	}
+	for n < len(b) {
+		i := int(off / int64(p.blockSize))
+		iStart := int(off % int64(p.blockSize))
+		nCopy := len(b) - n
+		if nCopy > p.blockSize - iStart {
+			nCopy = p.blockSize - iStart
+		}
+		aData := b[n:n + nCopy]
+		if nCopy < p.blockSize {
+			aOld, err := p.readBlock(i)
+			if err != nil {
+				return n, err
+			}
+			aData = append([]byte{}, aOld...)
+			copy(aData[iStart:], b[n:n + nCopy])
+		}
+		if err := p.writeBlock(i, aData); err != nil {
+			return n, err
+		}
+		n += nCopy
+		off += int64(nCopy)
+	}
+	if off > p.size {
+		p.size = off
+		p.isDirty = true
+	}
+	return n, nil
+}
+
+func (p *compressFile) Truncate(size int64) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.revalidate(); err != nil {
+		return err
+	}
+	if size >= p.size {
+		return nil
+	}
+	nBlock := 0
+	if p.blockSize > 0 {
+		nBlock = int((size + int64(p.blockSize) - 1) / int64(p.blockSize))
+	}
+	if nBlock < len(p.aBlock) {
+		for _, pBlock := range p.aBlock[nBlock:] {
+			p.free(pBlock.iOff, int64(pBlock.nByte))
+		}
+		p.aBlock = p.aBlock[:nBlock]
+		if p.iCache >= nBlock {
+			p.iCache = -1
+		}
+	}
+	//	Zero the end of the last block, so that it reads as zeroes if the file grows again.
+	if nBlock > 0 && size % int64(p.blockSize) != 0 {
+		aOld, err := p.readBlock(nBlock - 1)
+		if err != nil {
+			return err
+		}
+		aData := make([]byte, p.blockSize)
+		copy(aData, aOld[:size % int64(p.blockSize)])
+		if err := p.writeBlock(nBlock - 1, aData); err != nil {
+			return err
+		}
+	}
+	p.size = size
+	p.isDirty = true
+	return nil
+}
+
+func (p *compressFile) Sync(flags int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.flush(true, flags)
+}
+
+func (p *compressFile) Size() (int64, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.revalidate(); err != nil {
+		return 0, err
+	}
+	return p.size, nil
+}
+
+//	Taking a SHARED lock reloads the index if another connection has written the file since this one last saw it, so that an error in
+//	the header is reported by the lock rather than the first read.
+func (p *compressFile) Lock(level int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := p.base.Lock(level); err != nil {
+		return err
+	}
+	if p.lock == SQLITE_LOCK_NONE {
+		if err := p.load(); err != nil {
+			p.base.Unlock(SQLITE_LOCK_NONE)
+			return err
+		}
+	}
+	p.lock = level
+	return nil
+}
+
+//	The header is written before a write lock is given up, so that the next connection to lock the file sees the changes.
+func (p *compressFile) Unlock(level int) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	err := p.flush(false, 0)
+	p.lock = level
+	if e := p.base.Unlock(level); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (p *compressFile) CheckReservedLock() (bool, error) {
+	return p.base.CheckReservedLock()
+}
+
+func (p *compressFile) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	err := p.flush(false, 0)
+	if e := p.base.Close(); err == nil {
+		err = e
+	}
+	return err
+}
+
+func (p *compressFile) SectorSize() int {
+	if s, ok := p.base.(SectorSizer); ok {
+		return s.SectorSize()
+	}
+	return SQLITE_DEFAULT_SECTOR_SIZE
+}
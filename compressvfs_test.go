@@ -0,0 +1,189 @@
+import (
+	"bytes"
+	"compress/flate"
+	"database/sql"
+	"fmt"
+	"testing"
+)
+
+//	Open zName through the VFS called zVfs with a single connection.
+func testOpenVfs(t *testing.T, zName, zVfs string) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(&testConnector{ zName: zName, flags: SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE | SQLITE_OPEN_FULLMUTEX, zVfs: zVfs })
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+//	Return the first 16 bytes and the size of the file called name of v, as stored by v.
+func testRawFile(t *testing.T, v VFS, name string) (string, int64) {
+	t.Helper()
+	f, _, err := v.Open(name, SQLITE_OPEN_READWRITE)
+	if err != nil {
+		t.Fatalf("%v: %v", name, err)
+	}
+	defer f.Close()
+	aHdr := make([]byte, 16)
+	f.ReadAt(aHdr, 0)
+	nByte, err := f.Size()
+	if err != nil {
+		t.Fatalf("%v: %v", name, err)
+	}
+	return string(aHdr), nByte
+}
+
+func TestCompressVFS(t *testing.T) {
+	base := NewMemoryVFS()
+	RegisterVFS("test-compress", NewCompressVFS(base, flate.BestCompression), false)
+	RegisterVFS("test-compress-base", base, false)
+
+	db := testOpenVfs(t, "c.db", "test-compress")
+	testExec(t, db, "CREATE TABLE t(id INTEGER PRIMARY KEY, v)")
+	testExec(t, db, "INSERT INTO t(v) VALUES(hex(zeroblob(400)))")
+	for i := 0; i < 7; i++ {
+		testExec(t, db, "INSERT INTO t(v) SELECT v || id FROM t")
+	}
+	var nPage, szPage int64
+	if err := db.QueryRow("PRAGMA page_count").Scan(&nPage); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.QueryRow("PRAGMA page_size").Scan(&szPage); err != nil {
+		t.Fatal(err)
+	}
+
+	//	The file is stored compressed, in much less space than the pages it holds.
+	zMagic, nStored := testRawFile(t, base, "c.db")
+	if zMagic != compressMagic {
+		t.Fatalf("file starts with %q, want %q", zMagic, compressMagic)
+	}
+	if nStored > nPage * szPage / 4 {
+		t.Errorf("%v pages of %v bytes stored in %v bytes", nPage, szPage, nStored)
+	}
+	want := testQuery(t, db, "SELECT count(*), sum(length(v)), max(v) FROM t")
+
+	//	Another connection reads the database from the header and index.
+	db2 := testOpenVfs(t, "c.db", "test-compress")
+	testQueryIs(t, db2, want, "SELECT count(*), sum(length(v)), max(v) FROM t")
+	testQueryIs(t, db2, "ok", "PRAGMA integrity_check")
+
+	//	Rewriting every page again and again reuses the space the old blocks leave rather than growing the file.
+	for i := 0; i < 20; i++ {
+		testExec(t, db, "UPDATE t SET v = ? || substr(v, 2)", i % 10)
+	}
+	testQueryIs(t, db2, "128|9", "SELECT count(*), max(substr(v, 1, 1)) FROM t")
+	if _, nByte := testRawFile(t, base, "c.db"); nByte > 3 * nStored {
+		t.Errorf("file grew from %v to %v bytes", nStored, nByte)
+	}
+
+	//	A backup into a database of another VFS is an ordinary database file.
+	want = testQuery(t, db, "SELECT count(*), sum(length(v)), max(v) FROM t")
+	plain := testOpenVfs(t, "plain.db", "test-compress-base")
+	testRaw(t, db, func(pSrc *sqlite3) {
+		testRaw(t, plain, func(pDest *sqlite3) {
+			p := pDest.backup_init("main", pSrc, "main")
+			if p == nil {
+				t.Fatal("backup_init() failed")
+			}
+			p.step(-1)
+			if rc := p.finish(); rc != SQLITE_OK {
+				t.Fatalf("backup failed: %v", rc)
+			}
+		})
+	})
+	if zMagic, _ := testRawFile(t, base, "plain.db"); zMagic != "SQLite format 3\000" {
+		t.Errorf("backup starts with %q", zMagic)
+	}
+	testQueryIs(t, plain, want, "SELECT count(*), sum(length(v)), max(v) FROM t")
+
+	//	A database that is not compressed is used as it is through the CompressVFS.
+	plain2 := testOpenVfs(t, "plain.db", "test-compress")
+	testExec(t, plain2, "DELETE FROM t WHERE id > 1")
+	testQueryIs(t, plain, "1", "SELECT count(*) FROM t")
+	if zMagic, _ := testRawFile(t, base, "plain.db"); zMagic != "SQLite format 3\000" {
+		t.Errorf("uncompressed database starts with %q after a write", zMagic)
+	}
+}
+
+//	A testRecordVFS records the writes and syncs made to the files it opens.
+type testRecordVFS struct {
+	VFS
+	aOp		[]string
+}
+
+type testRecordFile struct {
+	File
+	v		*testRecordVFS
+}
+
+func (v *testRecordVFS) Open(name string, flags int) (File, int, error) {
+	f, outFlags, err := v.VFS.Open(name, flags)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &testRecordFile{ f, v }, outFlags, nil
+}
+
+func (f *testRecordFile) WriteAt(b []byte, off int64) (int, error) {
+	f.v.aOp = append(f.v.aOp, fmt.Sprintf("write %v", off))
+	return f.File.WriteAt(b, off)
+}
+
+func (f *testRecordFile) Sync(flags int) error {
+	f.v.aOp = append(f.v.aOp, "sync")
+	return f.File.Sync(flags)
+}
+
+func TestCompressVFSFile(t *testing.T) {
+	const flags = SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE | SQLITE_OPEN_MAIN_DB
+	base := NewMemoryVFS()
+	rec := &testRecordVFS{ VFS: base }
+	v := NewCompressVFS(rec, flate.DefaultCompression)
+	f, _, err := v.Open("f", flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	aBlock := func(c byte) []byte {
+		return bytes.Repeat([]byte{ c }, 4096)
+	}
+	aRead := make([]byte, 4096)
+
+	//	Sync() writes the block and the index, syncs them, and only then writes and syncs the header that points to them.
+	f.WriteAt(aBlock(1), 0)
+	f.Sync(SQLITE_SYNC_NORMAL)
+	if n := len(rec.aOp); n != 5 || rec.aOp[n - 3] != "sync" || rec.aOp[n - 2] != "write 0" || rec.aOp[n - 1] != "sync" {
+		t.Errorf("Sync() made %q, want two writes, a sync, the header and a sync", rec.aOp)
+	}
+	for _, zOp := range rec.aOp[:2] {
+		if zOp == "write 0" {
+			t.Errorf("header written before the sync: %q", rec.aOp)
+		}
+	}
+
+	//	Until the header is written, a new block does not replace the old one, so a crash leaves the file as it was at the last Sync().
+	f.WriteAt(aBlock(2), 0)
+	g, _, err := v.Open("f", flags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	if g.ReadAt(aRead, 0); !bytes.Equal(aRead, aBlock(1)) {
+		t.Errorf("block written without a header seen by another handle")
+	}
+	f.Sync(SQLITE_SYNC_NORMAL)
+	if g.ReadAt(aRead, 0); !bytes.Equal(aRead, aBlock(2)) {
+		t.Errorf("block written with a header not seen by another handle")
+	}
+
+	//	The space of old blocks and indexes is reused once a later header no longer points to it.
+	for i := 0; i < 10; i++ {
+		f.WriteAt(aBlock(byte(3 + i)), 0)
+		f.Sync(SQLITE_SYNC_NORMAL)
+	}
+	if g.ReadAt(aRead, 0); !bytes.Equal(aRead, aBlock(12)) {
+		t.Errorf("last block not read back")
+	}
+	if _, nByte := testRawFile(t, base, "f"); nByte > compressHeaderSize + 8 * compressGrain {
+		t.Errorf("file of one block grew to %v bytes", nByte)
+	}
+}
@@ -0,0 +1,86 @@
+//	This file implements BEGIN CONCURRENT transactions, which let several connections to a database in WAL mode build write
+//	transactions at the same time:
+//
+//		BEGIN CONCURRENT;
+//		INSERT INTO log VALUES(...);
+//		COMMIT;
+//
+//	Parse.ParseBeginConcurrent() (see Parse.Run()) removes CONCURRENT from the statement and sets Parse.isConcurrent. The BEGIN is
+//	then coded as a deferred BEGIN whose OP_AutoCommit has P3 set, which marks the transaction of the connection with
+//	sqlite3.isConcurrent.
+//
+//	When such a transaction first writes to a database in WAL mode, Btree.BeginTransaction() opens the write transaction of the pager
+//	with sqlite3PagerBeginConcurrent() rather than sqlite3PagerBegin(), which does not take the WAL write lock. The changes are made to
+//	the snapshot of the read transaction and kept in the page cache, which is never spilled to the WAL. Only COMMIT takes the write lock,
+//	in Wal.CommitConcurrent(), and compares the pages on the dirty list of the pager with the pages of the frames that other
+//	connections have committed since the snapshot was taken. If none of them is the same the frames of the transaction are appended
+//	after the others; otherwise the commit fails with SQLITE_BUSY_SNAPSHOT, the transaction is rolled back and can be run again. While
+//	another connection holds the write lock to commit, COMMIT waits through the busy handler or fails with SQLITE_BUSY.
+//
+//	Conflicts are found between the pages that transactions write, not the pages that they read. Page 1 holds the size of the database
+//	and the head of the freelist, so two transactions that both grow the database, or both free or reuse pages, always conflict. A
+//	transaction that only changes pages in place conflicts only with transactions that change the same pages. If the WAL has been
+//	restarted since the snapshot was taken, the pages written in the meantime are not known and the commit fails as well.
+//
+//	BEGIN CONCURRENT is the same as BEGIN on a database that is not in WAL mode or is in locking_mode=EXCLUSIVE.
+
+//	If the first statement of zSql is a BEGIN CONCURRENT, set pParse.isConcurrent and return the statement without CONCURRENT.
+//	Otherwise return zSql unchanged.
+func (pParse *Parse) ParseBeginConcurrent(zSql string) string {
+	s := newSqlScanner(zSql)
+	if s.Type != TK_BEGIN {
+		return zSql
+	}
+	iEnd := s.iNext
+	if !s.Next() || !s.IsWord("CONCURRENT") {
+		return zSql
+	}
+	s.Next()
+	pParse.isConcurrent = true
+	return zSql[:iEnd] + " " + zSql[s.Start:]
+}
+
+//	Take the WAL write lock to commit a BEGIN CONCURRENT transaction, which writes the pages of pList. If a transaction committed since
+//	the read transaction of pWal began wrote one of them, release the lock and return SQLITE_BUSY_SNAPSHOT. Otherwise move hdr to the
+//	end of the WAL, so that the frames of the commit follow those of the other transactions, and return the size of the database as
+//	they left it, or 0 if there were none.
+func (pWal *Wal) CommitConcurrent(pList *PgHdr) (nPage PageNumber, rc int) {
+	assert( pWal.readLock >= 0 && !pWal.writeLock )
+	if pWal.readOnly != 0 {
+		return 0, SQLITE_READONLY
+	}
+	if rc = walLockExclusive(pWal, WAL_WRITE_LOCK, 1); rc != SQLITE_OK {
+		return
+	}
+	pWal.writeLock = true
+
+	//	Only a connection holding the write lock changes the wal-index header.
+	var hdr WalIndexHdr
+	walHdrLoad(&hdr, walIndexHdr(pWal))
+	if hdr != pWal.hdr {
+		if hdr.aSalt != pWal.hdr.aSalt || hdr.mxFrame < pWal.hdr.mxFrame {
+			rc = SQLITE_BUSY_SNAPSHOT
+		} else {
+			aDirty := make(map[PageNumber]bool)
+			for p := pList; p != nil; p = p.pDirty {
+				aDirty[p.pgno] = true
+			}
+			for iFrame := pWal.hdr.mxFrame + 1; rc == SQLITE_OK && iFrame <= hdr.mxFrame; iFrame++ {
+				//	The wal-index pages that hold frames committed since the snapshot may not be mapped yet.
+				var aPage *uint32
+				if rc = walIndexPage(pWal, walFramePage(iFrame), &aPage); rc == SQLITE_OK && aDirty[walFramePageNumber(pWal, iFrame)] {
+					rc = SQLITE_BUSY_SNAPSHOT
+				}
+			}
+		}
+		if rc != SQLITE_OK {
+			walUnlockExclusive(pWal, WAL_WRITE_LOCK, 1)
+			pWal.writeLock = false
+			return
+		}
+		pWal.hdr = hdr
+		pWal.cacheStale = true
+		nPage = hdr.nPage
+	}
+	return
+}
@@ -0,0 +1,70 @@
+import (
+	"database/sql"
+	"testing"
+)
+
+//	Open two connections to a new WAL database with tables a and b, which are on different pages.
+func testOpenConcurrent(t *testing.T) (db1, db2 *sql.DB) {
+	t.Helper()
+	zFile := testFile(t)
+	db1 = testOpen(t, zFile)
+	testQueryIs(t, db1, "wal", "PRAGMA journal_mode = WAL")
+	testExec(t, db1, "CREATE TABLE a(id INTEGER PRIMARY KEY, v); CREATE TABLE b(id INTEGER PRIMARY KEY, v)")
+	testExec(t, db1, "INSERT INTO a VALUES(1, 0), (2, 0); INSERT INTO b VALUES(1, 0), (2, 0)")
+	return db1, testOpen(t, zFile)
+}
+
+func TestConcurrent(t *testing.T) {
+	db1, db2 := testOpenConcurrent(t)
+	testExec(t, db1, "BEGIN CONCURRENT")
+	testExec(t, db1, "UPDATE a SET v = 1 WHERE id = 1")
+	testExec(t, db2, "BEGIN CONCURRENT")
+	testExec(t, db2, "UPDATE b SET v = 2 WHERE id = 1")
+
+	//	Neither transaction holds the write lock until it commits, and they wrote different pages.
+	testQueryIs(t, db1, "0", "SELECT v FROM b WHERE id = 1")
+	testExec(t, db1, "COMMIT")
+	testExec(t, db2, "COMMIT")
+	for _, db := range []*sql.DB{ db1, db2 } {
+		testQueryIs(t, db, "1|2", "SELECT (SELECT v FROM a WHERE id = 1), (SELECT v FROM b WHERE id = 1)")
+	}
+}
+
+func TestConcurrentConflict(t *testing.T) {
+	db1, db2 := testOpenConcurrent(t)
+	testExec(t, db1, "BEGIN CONCURRENT")
+	testExec(t, db1, "UPDATE a SET v = v + 1 WHERE id = 1")
+	testExec(t, db2, "BEGIN CONCURRENT")
+	testExec(t, db2, "UPDATE a SET v = v + 10 WHERE id = 2")
+	testExec(t, db1, "COMMIT")
+
+	//	The second commit wrote the same page as the first, so it is rolled back and must be run again.
+	testRaw(t, db2, func(db *sqlite3) {
+		sqlite3_extended_result_codes(db, 1)
+	})
+	if _, err := db2.Exec("COMMIT"); testCode(err) != SQLITE_BUSY_SNAPSHOT {
+		t.Fatalf("conflicting COMMIT: %v, want SQLITE_BUSY_SNAPSHOT", err)
+	}
+	testRaw(t, db2, func(db *sqlite3) {
+		if sqlite3_get_autocommit(db) == 0 {
+			t.Error("transaction still open after the conflicting COMMIT")
+		}
+	})
+	testQueryIs(t, db2, "1|1\n2|0", "SELECT id, v FROM a ORDER BY id")
+	testExec(t, db2, "BEGIN CONCURRENT")
+	testExec(t, db2, "UPDATE a SET v = v + 10 WHERE id = 2")
+	testExec(t, db2, "COMMIT")
+	testQueryIs(t, db1, "1|1\n2|10", "SELECT id, v FROM a ORDER BY id")
+}
+
+func TestConcurrentWithoutWal(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x)")
+	testExec(t, db, "BEGIN CONCURRENT; INSERT INTO t VALUES(1)")
+	testExec(t, db, "ROLLBACK")
+	testExec(t, db, "BEGIN CONCURRENT; INSERT INTO t VALUES(2); COMMIT")
+	testQueryIs(t, db, "2", "SELECT x FROM t")
+	if _, err := db.Exec("BEGIN CONCURRENT; BEGIN CONCURRENT"); err == nil {
+		t.Error("nested BEGIN CONCURRENT")
+	}
+}
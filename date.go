@@ -1059,9 +1059,9 @@ static void currentTimeFunc(
     FUNCTION(time,             -1, 0, 0, timeFunc      ),
     FUNCTION(datetime,         -1, 0, 0, datetimeFunc  ),
     FUNCTION(strftime,         -1, 0, 0, strftimeFunc  ),
-    FUNCTION(current_time,      0, 0, 0, ctimeFunc     ),
-    FUNCTION(current_timestamp, 0, 0, 0, ctimestampFunc),
-    FUNCTION(current_date,      0, 0, 0, cdateFunc     ),
+    FUNCTION2(current_time,     0, 0, 0, ctimeFunc, SQLITE_FUNC_NONDETERM),
+    FUNCTION2(current_timestamp, 0, 0, 0, ctimestampFunc, SQLITE_FUNC_NONDETERM),
+    FUNCTION2(current_date,     0, 0, 0, cdateFunc, SQLITE_FUNC_NONDETERM),
 #else
     STR_FUNCTION(current_time,      0, "%H:%M:%S",          0, currentTimeFunc),
     STR_FUNCTION(current_date,      0, "%Y-%m-%d",          0, currentTimeFunc),
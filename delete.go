@@ -206,9 +206,12 @@ limit_where_cleanup_2:
 
   int isView;                  /* True if attempting to delete from a view */
   Trigger *pTrigger;           /* List of table triggers, if required */
+  Returning *pReturning;       /* The RETURNING clause, if any */
 
   memset(&sContext, 0, sizeof(sContext));
   db = pParse.db;
+  pReturning = pParse.pReturning
+  pParse.pReturning = nil
   if( pParse.nErr || db.mallocFailed ){
     goto delete_from_cleanup;
   }
@@ -268,6 +271,9 @@ limit_where_cleanup_2:
   }
   if( pParse.nested==0 ) sqlite3VdbeCountChanges(v);
   pParse.BeginWriteOperation(1, iDb)
+	if pReturning != nil && pParse.ReturningBegin(pReturning, pTab) != SQLITE_OK {
+		goto delete_from_cleanup
+	}
 
   /* If we are trying to delete from a view, realize that view into
   ** a ephemeral table.
@@ -298,8 +304,8 @@ limit_where_cleanup_2:
   ** It is easier just to erase the whole table. Prior to version 3.6.5,
   ** this optimization caused the row change count (the value returned by 
   ** API function sqlite3_count_changes) to be set incorrectly.  */
-  if( rcauth==SQLITE_OK && pWhere==0 && !pTrigger && !pTab.IsVirtual() 
-   && 0==sqlite3FkRequired(pParse, pTab, 0, 0)
+  if( rcauth==SQLITE_OK && pWhere==0 && !pTrigger && !pTab.IsVirtual() && pReturning==0
+   && 0==sqlite3FkRequired(pParse, pTab, 0, 0) && pTab.HasRowid()
   ){
     assert( !isView );
     sqlite3VdbeAddOp4(v, OP_Clear, pTab.tnum, iDb, memCnt, pTab.Name, P4_STATIC);
@@ -313,17 +319,17 @@ limit_where_cleanup_2:
   ** the table and pick which records to delete.
   */
   {
-    int iRowSet = ++pParse.nMem;   /* Register for rowset of rows to delete */
+    int iRowSet;                    /* Row key set of rows to delete */
     int iRowid = ++pParse.nMem;    /* Used for storing rowid values. */
-    int regRowid;                   /* Actual register containing rowids */
 
-    /* Collect rowids of every row to be deleted.
+    /* Collect rowids of every row to be deleted, or the PRIMARY KEY records
+    ** of the rows of a WITHOUT ROWID table.
     */
-    v.AddOp2(OP_Null, 0, iRowSet);
+    iRowSet = pParse.RowKeySetOpen(pTab)
     pWInfo = pParse.WhereBegin(pTabList, pWhere, nil, nil, WHERE_DUPLICATES_OK)
     if( pWInfo==0 ) goto delete_from_cleanup;
-    regRowid = pParse.ExprCodeGetColumn(pTab, -1, iCur, iRowid, 0)
-    v.AddOp2(OP_RowSetAdd, iRowSet, regRowid);
+    pParse.CodeRowKey(pTab, iCur, iRowid)
+    pParse.RowKeySetAdd(pTab, iRowSet, iRowid)
     if( db.flags & SQLITE_CountRows ){
       v.AddOp2(OP_AddImm, memCnt, 1);
     }
@@ -342,7 +348,7 @@ limit_where_cleanup_2:
       pParse.OpenTableAndIndices(pTab, iCur, OP_OpenWrite)
     }
 
-    addr = v.AddOp3(OP_RowSetRead, iRowSet, end, iRowid);
+    addr = pParse.RowKeySetRead(pTab, iRowSet, end, iRowid)
 
     /* Delete the row */
     if( pTab.IsVirtual() ){
@@ -354,6 +360,10 @@ limit_where_cleanup_2:
     }else
     {
       int count = (pParse.nested==0);    /* True to count changes */
+		//	Save the RETURNING values of the row while it still exists.
+		if pReturning != nil {
+			pParse.ReturningRow(pReturning, iRowid)
+		}
       sqlite3GenerateRowDelete(pParse, pTab, iCur, iRowid, count, pTrigger, OE_Default);
     }
 
@@ -379,11 +389,16 @@ limit_where_cleanup_2:
     sqlite3AutoincrementEnd(pParse);
   }
 
+	//	Return the rows saved for the RETURNING clause. These replace the count of rows deleted.
+	if pReturning != nil {
+		pParse.ReturningEnd(pReturning)
+	}
+
   /* Return the number of rows that were deleted. If this routine is 
   ** generating code because of a call to sqlite3NestedParse(), do not
   ** invoke the callback function.
   */
-  if( (db.flags&SQLITE_CountRows) && !pParse.nested && !pParse.pTriggerTab ){
+  if( (db.flags&SQLITE_CountRows) && !pParse.nested && !pParse.pTriggerTab && pReturning==0 ){
     v.AddOp2(OP_ResultRow, memCnt, 1);
     sqlite3VdbeSetNumCols(v, 1);
     sqlite3VdbeSetColName(v, 0, COLNAME_NAME, "rows deleted", SQLITE_STATIC);
@@ -444,7 +459,7 @@ delete_from_cleanup:
   ** (this can happen if a trigger program has already deleted it), do
   ** not attempt to delete it or fire any DELETE triggers.  */
   iLabel = v.MakeLabel()
-  v.AddOp3(OP_NotExists, iCur, iLabel, iRowid);
+  pParse.SeekRowKey(pTab, iCur, iLabel, iRowid)
  
   /* If there are any triggers to fire, allocate a range of registers to
   ** use for the old.* references in the triggers.  */
@@ -466,7 +481,7 @@ delete_from_cleanup:
     v.AddOp2(OP_Copy, iRowid, iOld);
     for(iCol=0; iCol<pTab.nCol; iCol++){
       if( mask==0xffffffff || mask&(1<<iCol) ){
-        v.ExprCodeGetColumnOfTable(pTab, iCur, iCol, iOld + iCol + 1)
+        pParse.ExprCodeGetColumnOfTable(pTab, iCur, iCol, iOld + iCol + 1)
       }
     }
 
@@ -479,7 +494,7 @@ delete_from_cleanup:
     ** the BEFORE triggers coded above have already removed the row
     ** being deleted. Do not attempt to delete the row a second time, and 
     ** do not fire AFTER triggers.  */
-    v.AddOp3(OP_NotExists, iCur, iLabel, iRowid);
+    pParse.SeekRowKey(pTab, iCur, iLabel, iRowid)
 
     /* Do FK processing. This call checks that any FK constraints that
     ** refer to this table (i.e. constraints attached to other tables) 
@@ -493,7 +508,9 @@ delete_from_cleanup:
   if( pTab.Select==0 ){
     pParse.GenerateRowIndexDelete(pTab, iCur, 0)
     v.AddOp2(OP_Delete, iCur, (count?OPFLAG_NCHANGE:0));
-    if( count ){
+    /* The update hook reports the rowid of the row, so it is not invoked for
+    ** a WITHOUT ROWID table */
+    if( count && pTab.HasRowid() ){
       sqlite3VdbeChangeP4(v, -1, pTab.Name, P4_TRANSIENT);
     }
   }
@@ -519,14 +536,21 @@ delete_from_cleanup:
 //		1.  A read/write cursor pointing to pTab, the table containing the row to be deleted, must be opened as cursor number "iCur".
 //		2.  Read/write cursors for all indices of pTab must be open as cursor number iCur+i for the i-th index.
 //		3.  The "iCur" cursor must be pointing to the row that is to be deleted.
+//	If aRegIdx is not empty, only the indices for which it holds a non-zero register are changed. A partial index has no entry to delete
+//	if the row fails its WHERE clause.
 func (pParse *Parse) GenerateRowIndexDelete(table *Table, iCur int, aRegIdx []int) {
-	if len(aRegIdx) > 0 {
-		for i, pIdx := range table.Indices {
-			if aRegIdx[i] != 0 {
-				r1 := sqlite3GenerateIndexKey(pParse, pIdx, iCur, 0, 0)
-				i++
-				pParse.pVdbe.AddOp3(OP_IdxDelete, iCur + i, r1, len(pIdx.Columns) + 1)
-			}
+	v := pParse.pVdbe
+	for i, pIdx := range table.Indices {
+		//	The entry of the PRIMARY KEY of a WITHOUT ROWID table is the row itself, deleted by the caller.
+		if pIdx.IsClustered() {
+			continue
+		}
+		if len(aRegIdx) == 0 || aRegIdx[i] != 0 {
+			skip := v.MakeLabel()
+			pParse.PartialIndexSkip(pIdx, iCur, skip)
+			r1 := sqlite3GenerateIndexKey(pParse, pIdx, iCur, 0, 0)
+			v.AddOp3(OP_IdxDelete, iCur + i + 1, r1, pIdx.KeyFields())
+			v.ResolveLabel(skip)
 		}
 	}
 }
@@ -547,15 +571,22 @@ int sqlite3GenerateIndexKey(
   int nCol;
 
   nCol = len(pIdx.Columns)
-  regBase = pParse.GetTempRange(nCol + 1)
-  v.AddOp2(OP_Rowid, iCur, regBase+nCol);
+  nKey := pIdx.KeyFields()
+  regBase = pParse.GetTempRange(nKey)
+  if pTab.HasRowid() {
+    v.AddOp2(OP_Rowid, iCur, regBase+nCol);
+  }else{
+    /* The entry ends with the PRIMARY KEY columns, which begin the record of the row */
+    for j = nCol; j < nKey; j++ {
+      v.AddOp3(OP_Column, iCur, j - nCol, regBase + j)
+    }
+  }
   for(j=0; j<nCol; j++){
     int idx = pIdx.Columns[j];
     if( idx==pTab.iPKey ){
       v.AddOp2(OP_SCopy, regBase+nCol, regBase+j);
     }else{
-      v.AddOp3(OP_Column, iCur, idx, regBase+j);
-      v.ColumnDefault(pTab, idx, -1)
+      pParse.IndexColumnCode(pIdx, j, iCur, regBase+j)
     }
   }
   if( doMakeRec ){
@@ -565,9 +596,9 @@ int sqlite3GenerateIndexKey(
     } else {
       zAff = v.IndexAffinityStr(pIdx)
     }
-    v.AddOp3(OP_MakeRecord, regBase, nCol+1, regOut);
+    v.AddOp3(OP_MakeRecord, regBase, nKey, regOut);
     sqlite3VdbeChangeP4(v, -1, zAff, P4_TRANSIENT);
   }
-  pParse.ReleaseTempRange(regBase, nCol + 1)
+  pParse.ReleaseTempRange(regBase, nKey)
   return regBase;
 }
@@ -0,0 +1,500 @@
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+//	This file implements the database/sql/driver interfaces on top of the sqlite3 connection and prepared statement APIs. Programs normally
+//	use it indirectly through database/sql:
+//
+//			db, err := sql.Open("sqlite3", "file.db")
+//
+//	A Conn owns exactly one sqlite3 connection handle. database/sql never uses a driver.Conn from more than one goroutine at a time so no
+//	locking beyond that already performed by the connection mutex is required here.
+
+func init() {
+	sql.Register("sqlite3", &Driver{})
+}
+
+//	Error is returned by the driver whenever a call into the library fails. Code holds the primary result code (one of the SQLITE_* values)
+//	and Message the text returned by sqlite3_errmsg() at the time of the failure.
+type Error struct {
+	Code		int
+	Message		string
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return sqlite3ErrStr(e.Code)
+	}
+	return e.Message
+}
+
+//	Build an *Error from the current error state of connection db. If rc is SQLITE_OK no error is returned.
+func (db *sqlite3) lastError(rc int) error {
+	if rc == SQLITE_OK {
+		return nil
+	}
+	return &Error{ Code: rc, Message: sqlite3_errmsg(db) }
+}
+
+//	Convert the result of a failed StepContext() into an error. An interrupt caused by ctx is reported as the context error so callers
+//	can test for context.Canceled and context.DeadlineExceeded.
+func (c *Conn) stepError(ctx context.Context, rc int) error {
+	if rc == SQLITE_INTERRUPT && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return c.db.lastError(rc)
+}
+
+//	Formats accepted when converting TEXT values from DATE, DATETIME and TIMESTAMP columns into time.Time. Time values are bound using
+//	the first of these.
+var driverTimeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+//	Driver implements driver.Driver. The name passed to Open is handed unchanged to sqlite3_open_v2() so any filename or URI accepted
+//	there may be used.
+type Driver struct{}
+
+func (d *Driver) Open(name string) (driver.Conn, error) {
+	var db *sqlite3
+	if rc := sqlite3_open_v2(name, &db, SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE | SQLITE_OPEN_FULLMUTEX, ""); rc != SQLITE_OK {
+		err := db.lastError(rc)
+		if db != nil {
+			db.Close()
+		}
+		return nil, err
+	}
+	return &Conn{ db: db }, nil
+}
+
+//	Conn implements driver.Conn, driver.ExecerContext, driver.QueryerContext, driver.ConnPrepareContext and driver.ConnBeginTx on a
+//	single database connection.
+type Conn struct {
+	db		*sqlite3
+}
+
+//	Prepare compiles the first statement in query. Any text following the first statement is ignored.
+func (c *Conn) Prepare(query string) (driver.Stmt, error) {
+	s, _, err := c.prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (c *Conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.Prepare(query)
+}
+
+//	Compile the first statement in query and return it along with the unused tail. A query consisting only of comments and white-space
+//	yields a nil *Stmt and no error.
+func (c *Conn) prepare(query string) (s *Stmt, tail string, err error) {
+	pStmt, tail, rc := c.db.PrepareV2(query)
+	if rc != SQLITE_OK {
+		return nil, "", c.db.lastError(rc)
+	}
+	if pStmt == nil {
+		return nil, tail, nil
+	}
+	return &Stmt{ c: c, s: pStmt }, tail, nil
+}
+
+//	Close closes the underlying connection. Statements still open on the connection cause SQLITE_BUSY to be returned.
+func (c *Conn) Close() error {
+	if c.db == nil {
+		return nil
+	}
+	if rc := c.db.Close(); rc != SQLITE_OK {
+		return c.db.lastError(rc)
+	}
+	c.db = nil
+	return nil
+}
+
+//	RegisterFunc registers the Go function fn as an SQL function of the connection. It is reached through sql.Conn.Raw(). See
+//	sqlite3.RegisterFunc() for the argument and result types allowed.
+func (c *Conn) RegisterFunc(name string, fn interface{}) error {
+	return c.db.RegisterFunc(name, fn)
+}
+
+//	RegisterAggregate registers an SQL aggregate function of the connection whose value for each group is computed by an Aggregate
+//	made by newAggregate.
+func (c *Conn) RegisterAggregate(name string, nArg int, newAggregate func() Aggregate) error {
+	return c.db.RegisterAggregate(name, nArg, newAggregate)
+}
+
+//	CreateModule registers module as a virtual table module of the connection. See sqlite3.CreateModule().
+func (c *Conn) CreateModule(name string, module VTabModule) error {
+	return c.db.CreateModule(name, module)
+}
+
+func (c *Conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+//	BeginTx starts a transaction. A read-only transaction is started with a deferred BEGIN and PRAGMA query_only is on until it ends, so
+//	that any statement of the transaction that writes to a database fails with SQLITE_READONLY. Isolation levels other than the default
+//	and serializable are not supported.
+func (c *Conn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelSerializable:
+	default:
+		return nil, fmt.Errorf("sqlite3: unsupported isolation level: %v", sql.IsolationLevel(opts.Isolation))
+	}
+	begin := "BEGIN IMMEDIATE"
+	if opts.ReadOnly {
+		begin = "BEGIN; PRAGMA query_only = ON"
+	}
+	if _, err := c.exec(ctx, begin, nil); err != nil {
+		if opts.ReadOnly && c.db.autoCommit == 0 {
+			c.exec(context.Background(), "ROLLBACK", nil)
+		}
+		return nil, err
+	}
+	return &Tx{ c: c, readOnly: opts.ReadOnly }, nil
+}
+
+//	Exec runs every statement in query in turn. Arguments are consumed in order by each statement so that a script with several
+//	parameterised statements may be run in a single call. The Result describes the last statement executed.
+func (c *Conn) Exec(query string, args []driver.Value) (driver.Result, error) {
+	return c.exec(context.Background(), query, valuesToNamed(args))
+}
+
+func (c *Conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.exec(ctx, query, args)
+}
+
+func (c *Conn) exec(ctx context.Context, query string, args []driver.NamedValue) (res driver.Result, err error) {
+	res = driver.ResultNoRows
+	for {
+		s, tail, err := c.prepare(query)
+		switch {
+		case err != nil:
+			return nil, err
+		case s == nil:
+			return res, nil
+		}
+		n := s.NumInput()
+		if n > len(args) {
+			n = len(args)
+		}
+		res, err = s.exec(ctx, args[:n])
+		s.Close()
+		if err != nil {
+			return nil, err
+		}
+		args = args[n:]
+		if query = strings.TrimSpace(tail); query == "" {
+			return res, nil
+		}
+	}
+}
+
+//	Query prepares and runs the first statement in query.
+func (c *Conn) Query(query string, args []driver.Value) (driver.Rows, error) {
+	return c.query(context.Background(), query, valuesToNamed(args))
+}
+
+func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.query(ctx, query, args)
+}
+
+func (c *Conn) query(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	s, _, err := c.prepare(query)
+	switch {
+	case err != nil:
+		return nil, err
+	case s == nil:
+		return nil, fmt.Errorf("sqlite3: query contains no SQL statement")
+	}
+	rows, err := s.query(ctx, args)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+	rows.closeStmt = true
+	return rows, nil
+}
+
+//	Tx implements driver.Tx.
+type Tx struct {
+	c			*Conn
+	readOnly	bool				//	PRAGMA query_only is on for the transaction
+}
+
+func (t *Tx) Commit() error {
+	return t.end("COMMIT")
+}
+
+func (t *Tx) Rollback() error {
+	return t.end("ROLLBACK")
+}
+
+//	Run COMMIT or ROLLBACK and turn PRAGMA query_only off again after a read-only transaction, whether or not the transaction ended.
+func (t *Tx) end(query string) (err error) {
+	_, err = t.c.exec(context.Background(), query, nil)
+	if t.readOnly {
+		if _, e := t.c.exec(context.Background(), "PRAGMA query_only = OFF", nil); err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+//	Stmt implements driver.Stmt, driver.StmtExecContext and driver.StmtQueryContext for a single prepared statement.
+type Stmt struct {
+	c		*Conn
+	s		*sqlite3_stmt
+	closed	bool
+}
+
+func (s *Stmt) Close() error {
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if rc := sqlite3_finalize(s.s); rc != SQLITE_OK {
+		return s.c.db.lastError(rc)
+	}
+	return nil
+}
+
+//	NumInput returns the number of host parameters in the statement. Named parameters count once each however many times they appear.
+func (s *Stmt) NumInput() int {
+	return sqlite3_bind_parameter_count(s.s)
+}
+
+func (s *Stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.exec(context.Background(), valuesToNamed(args))
+}
+
+func (s *Stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.exec(ctx, args)
+}
+
+func (s *Stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.query(context.Background(), valuesToNamed(args))
+}
+
+func (s *Stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.query(ctx, args)
+}
+
+//	Bind args to the statement host parameters. An argument carrying a name is bound to the parameter of that name using any of the
+//	":", "@" or "$" prefixes. Unnamed arguments are bound by position.
+func (s *Stmt) bind(args []driver.NamedValue) (err error) {
+	if rc := sqlite3_reset(s.s); rc != SQLITE_OK {
+		return s.c.db.lastError(rc)
+	}
+	if rc := s.s.ClearBindings(); rc != SQLITE_OK {
+		return s.c.db.lastError(rc)
+	}
+	for n, arg := range args {
+		i := n + 1
+		if arg.Name != "" {
+			i = 0
+			for _, prefix := range []string{ ":", "@", "$" } {
+				if i = sqlite3_bind_parameter_index(s.s, prefix + arg.Name); i != 0 {
+					break
+				}
+			}
+			if i == 0 {
+				return fmt.Errorf("sqlite3: no such named parameter: %v", arg.Name)
+			}
+		}
+		if err = s.bindValue(i, arg.Value); err != nil {
+			return
+		}
+	}
+	return
+}
+
+//	Bind a single Go value to host parameter i using the matching sqlite3_bind_* routine.
+func (s *Stmt) bindValue(i int, v driver.Value) error {
+	var rc int
+	switch v := v.(type) {
+	case nil:
+		rc = sqlite3_bind_null(s.s, i)
+	case int64:
+		rc = sqlite3_bind_int64(s.s, i, v)
+	case float64:
+		rc = sqlite3_bind_double(s.s, i, v)
+	case bool:
+		if v {
+			rc = sqlite3_bind_int64(s.s, i, 1)
+		} else {
+			rc = sqlite3_bind_int64(s.s, i, 0)
+		}
+	case []byte:
+		if v == nil {
+			rc = sqlite3_bind_null(s.s, i)
+		} else {
+			rc = s.s.BindBlob(i, string(v), SQLITE_TRANSIENT)
+		}
+	case string:
+		rc = s.s.BindText(i, v, SQLITE_TRANSIENT, SQLITE_UTF8)
+	case time.Time:
+		rc = s.s.BindText(i, v.Format(driverTimeFormats[0]), SQLITE_TRANSIENT, SQLITE_UTF8)
+	default:
+		return fmt.Errorf("sqlite3: unsupported type %T for parameter %v", v, i)
+	}
+	if rc != SQLITE_OK {
+		return s.c.db.lastError(rc)
+	}
+	return nil
+}
+
+//	Run the statement to completion. If ctx ends first the statement is interrupted and the context error is returned.
+func (s *Stmt) exec(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	if err := s.bind(args); err != nil {
+		return nil, err
+	}
+	for {
+		switch rc := StepContext(ctx, s.s); rc {
+		case SQLITE_ROW:
+			//	Rows produced by a statement run through Exec are discarded.
+		case SQLITE_DONE:
+			db := s.c.db
+			return &Result{ lastInsertId: sqlite3_last_insert_rowid(db), rowsAffected: int64(sqlite3_changes(db)) }, nil
+		default:
+			sqlite3_reset(s.s)
+			return nil, s.c.stepError(ctx, rc)
+		}
+	}
+}
+
+func (s *Stmt) query(ctx context.Context, args []driver.NamedValue) (*Rows, error) {
+	if err := s.bind(args); err != nil {
+		return nil, err
+	}
+	n := sqlite3_column_count(s.s)
+	rows := &Rows{ s: s, ctx: ctx, columns: make([]string, n), decltypes: make([]string, n) }
+	for i := 0; i < n; i++ {
+		rows.columns[i] = sqlite3_column_name(s.s, i)
+		rows.decltypes[i] = strings.ToUpper(sqlite3_column_decltype(s.s, i))
+	}
+	return rows, nil
+}
+
+//	Result implements driver.Result using the values of sqlite3_last_insert_rowid() and sqlite3_changes() captured when the statement
+//	finished.
+type Result struct {
+	lastInsertId	int64
+	rowsAffected	int64
+}
+
+func (r *Result) LastInsertId() (int64, error) {
+	return r.lastInsertId, nil
+}
+
+func (r *Result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
+
+//	Rows implements driver.Rows by stepping the statement once per call to Next.
+type Rows struct {
+	s			*Stmt
+	ctx			context.Context	//	Context passed to QueryContext; it governs every call to Next
+	columns		[]string
+	decltypes	[]string
+	closeStmt	bool			//	True if the statement was prepared on behalf of Conn.Query and must be finalized by Close
+	done		bool
+}
+
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+//	ColumnTypeDatabaseTypeName returns the declared type of column i, or "" if the column is an expression.
+func (r *Rows) ColumnTypeDatabaseTypeName(i int) string {
+	return r.decltypes[i]
+}
+
+func (r *Rows) Close() (err error) {
+	if !r.done {
+		sqlite3_reset(r.s.s)
+		r.done = true
+	}
+	if r.closeStmt {
+		err = r.s.Close()
+	}
+	return
+}
+
+//	Next steps the statement and copies the row into dest. Column values are mapped from their storage class as reported by
+//	sqlite3_column_type(): INTEGER to int64, REAL to float64, TEXT to string, BLOB to []byte and NULL to nil. TEXT values in DATE,
+//	DATETIME and TIMESTAMP columns are converted to time.Time when they parse as such.
+func (r *Rows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	switch rc := StepContext(r.ctx, r.s.s); rc {
+	case SQLITE_ROW:
+	case SQLITE_DONE:
+		r.done = true
+		return io.EOF
+	default:
+		r.done = true
+		sqlite3_reset(r.s.s)
+		return r.s.c.stepError(r.ctx, rc)
+	}
+	for i := range dest {
+		switch sqlite3_column_type(r.s.s, i) {
+		case SQLITE_INTEGER:
+			v := sqlite3_column_int64(r.s.s, i)
+			if r.decltypes[i] == "BOOLEAN" {
+				dest[i] = v != 0
+			} else {
+				dest[i] = v
+			}
+		case SQLITE_FLOAT:
+			dest[i] = sqlite3_column_double(r.s.s, i)
+		case SQLITE_TEXT:
+			dest[i] = r.text(i)
+		case SQLITE_BLOB:
+			//	The buffer returned by sqlite3_column_blob() is only valid until the next step so it must be copied.
+			dest[i] = append([]byte(nil), sqlite3_column_blob(r.s.s, i)...)
+		default:
+			dest[i] = nil
+		}
+	}
+	return nil
+}
+
+//	Return TEXT column i as a string, or as a time.Time if the column is declared with a date or time type and the value parses as one.
+func (r *Rows) text(i int) driver.Value {
+	s := string(sqlite3_column_text(r.s.s, i))
+	switch r.decltypes[i] {
+	case "DATE", "DATETIME", "TIMESTAMP":
+		for _, format := range driverTimeFormats {
+			if t, err := time.ParseInLocation(format, s, time.UTC); err == nil {
+				return t
+			}
+		}
+	}
+	return s
+}
+
+//	Convert positional arguments into the NamedValue form used internally.
+func valuesToNamed(args []driver.Value) (named []driver.NamedValue) {
+	named = make([]driver.NamedValue, len(args))
+	for i, v := range args {
+		named[i] = driver.NamedValue{ Ordinal: i + 1, Value: v }
+	}
+	return
+}
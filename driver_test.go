@@ -0,0 +1,236 @@
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+//	Open name through database/sql with a single connection, so that every statement of a test sees the same :memory: database.
+func testOpen(t *testing.T, name string) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+//	Return the name of a database file in a directory removed when the test ends.
+func testFile(t *testing.T) string {
+	return filepath.Join(t.TempDir(), "test.db")
+}
+
+func testExec(t *testing.T, db *sql.DB, query string, args ...interface{}) {
+	t.Helper()
+	if _, err := db.Exec(query, args...); err != nil {
+		t.Fatalf("%v: %v", query, err)
+	}
+}
+
+//	Run query and return its rows, one per line, with the columns of each separated by "|" and NULL shown as "NULL".
+func testQuery(t *testing.T, db *sql.DB, query string, args ...interface{}) string {
+	t.Helper()
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		t.Fatalf("%v: %v", query, err)
+	}
+	defer rows.Close()
+	columns, _ := rows.Columns()
+	var lines []string
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			t.Fatalf("%v: %v", query, err)
+		}
+		fields := make([]string, len(values))
+		for i, v := range values {
+			switch v := v.(type) {
+			case nil:
+				fields[i] = "NULL"
+			case []byte:
+				fields[i] = string(v)
+			default:
+				fields[i] = fmt.Sprint(v)
+			}
+		}
+		lines = append(lines, strings.Join(fields, "|"))
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("%v: %v", query, err)
+	}
+	return strings.Join(lines, "\n")
+}
+
+//	Report an error unless query returns want.
+func testQueryIs(t *testing.T, db *sql.DB, want, query string, args ...interface{}) {
+	t.Helper()
+	if got := testQuery(t, db, query, args...); got != want {
+		t.Errorf("%v:\ngot:\n%v\nwant:\n%v", query, got, want)
+	}
+}
+
+//	Return the detail column of the EXPLAIN QUERY PLAN output for query, one line per row.
+func testQueryPlan(t *testing.T, db *sql.DB, query string, args ...interface{}) string {
+	t.Helper()
+	var lines []string
+	for _, line := range strings.Split(testQuery(t, db, "EXPLAIN QUERY PLAN " + query, args...), "\n") {
+		lines = append(lines, line[strings.LastIndex(line, "|") + 1:])
+	}
+	return strings.Join(lines, "\n")
+}
+
+//	Call f with the connection handle of db.
+func testRaw(t *testing.T, db *sql.DB, f func(db *sqlite3)) {
+	t.Helper()
+	c, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Raw(func(dc interface{}) error {
+		f(dc.(*Conn).db)
+		return nil
+	})
+}
+
+//	Return the result code of err, or -1 if err did not come from the driver.
+func testCode(err error) int {
+	var e *Error
+	if !errors.As(err, &e) {
+		return -1
+	}
+	return e.Code
+}
+
+func TestDriverRoundTrip(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(i INTEGER, r REAL, s TEXT, b BLOB, n, d DATETIME)")
+	when := time.Date(2024, 2, 29, 13, 14, 15, 500000000, time.UTC)
+	blob := []byte{ 0, 1, 2, 0xff }
+	res, err := db.Exec("INSERT INTO t VALUES(?, ?, ?, ?, ?, ?)", int64(-7), 2.5, "héllo", blob, nil, when)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := res.LastInsertId(); id != 1 {
+		t.Errorf("LastInsertId() = %v, want 1", id)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("RowsAffected() = %v, want 1", n)
+	}
+
+	var i int64
+	var r float64
+	var s string
+	var b []byte
+	var n interface{}
+	var d time.Time
+	if err := db.QueryRow("SELECT i, r, s, b, n, d FROM t").Scan(&i, &r, &s, &b, &n, &d); err != nil {
+		t.Fatal(err)
+	}
+	if i != -7 || r != 2.5 || s != "héllo" || !bytes.Equal(b, blob) || n != nil || !d.Equal(when) {
+		t.Errorf("got %v %v %q %v %v %v", i, r, s, b, n, d)
+	}
+
+	testQueryIs(t, db, "integer|real|text|blob|null|text", "SELECT typeof(i), typeof(r), typeof(s), typeof(b), typeof(n), typeof(d) FROM t")
+	testQueryIs(t, db, "-7|héllo", "SELECT i, s FROM t WHERE i = :i AND s = @s", sql.Named("i", -7), sql.Named("s", "héllo"))
+}
+
+func TestDriverExecScript(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x); INSERT INTO t VALUES(?); INSERT INTO t VALUES(?), (?)", 1, 2, 3)
+	testQueryIs(t, db, "1\n2\n3", "SELECT x FROM t ORDER BY x")
+}
+
+func TestDriverError(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x UNIQUE)")
+	testExec(t, db, "INSERT INTO t VALUES(1)")
+	if _, err := db.Exec("INSERT INTO t VALUES(1)"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("duplicate insert: %v, want SQLITE_CONSTRAINT", err)
+	}
+	if _, err := db.Exec("SELECT * FROM missing"); testCode(err) != SQLITE_ERROR || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("unknown table: %v", err)
+	}
+}
+
+func TestDriverTransactions(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x)")
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.Exec("INSERT INTO t VALUES(1)")
+	if err := tx.Rollback(); err != nil {
+		t.Fatal(err)
+	}
+	testQueryIs(t, db, "0", "SELECT count(*) FROM t")
+
+	if tx, err = db.BeginTx(context.Background(), &sql.TxOptions{ ReadOnly: true }); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tx.Exec("INSERT INTO t VALUES(1)"); testCode(err) & 0xff != SQLITE_READONLY {
+		t.Errorf("insert in read-only transaction: %v, want SQLITE_READONLY", err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	testExec(t, db, "INSERT INTO t VALUES(1)")
+	testQueryIs(t, db, "1", "SELECT count(*) FROM t")
+}
+
+//	A query that only ends when it is interrupted.
+const testEndless = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT count(*) FROM c"
+
+func TestDriverContextDeadline(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	ctx, cancel := context.WithTimeout(context.Background(), 50 * time.Millisecond)
+	defer cancel()
+	var n int64
+	if err := db.QueryRowContext(ctx, testEndless).Scan(&n); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	//	The interrupt must not carry over to the next statement.
+	testQueryIs(t, db, "1", "SELECT 1")
+}
+
+func TestDriverContextCancel(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x)")
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50 * time.Millisecond, cancel)
+	if _, err := db.ExecContext(ctx, "INSERT INTO t " + testEndless); !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want %v", err, context.Canceled)
+	}
+	testQueryIs(t, db, "0", "SELECT count(*) FROM t")
+}
+
+func TestDriverContextRows(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rows, err := db.QueryContext(ctx, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c) SELECT x FROM c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10 && rows.Next(); i++ {}
+	cancel()
+	for rows.Next() {}
+	if err := rows.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	rows.Close()
+	testQueryIs(t, db, "3", "SELECT 1 + 2")
+}
@@ -497,6 +497,7 @@ func (p *Expr) Dup() (pNew *Expr) {
 			op2:				p.op2,
 			pAggInfo:			p.pAggInfo,
 			pTab:				p.pTab,
+			pWin:				p.pWin.Dup(),
 		}
 
 		if p.HasProperty(EP_xIsSelect) {
@@ -543,6 +544,7 @@ func (p SrcList) Dup(flags int) (pNew SrcList) {
 				addrFillSub: pOldItem.addrFillSub,
 				regReturn: pOldItem.regReturn,
 				isCorrelated: pOldItem.isCorrelated,
+				isRecursive: pOldItem.isRecursive,
 				zIndex: pOldItem.zIndex,
 				notIndexed: pOldItem.notIndexed,
 				pIndex: pOldItem.pIndex,
@@ -550,6 +552,7 @@ func (p SrcList) Dup(flags int) (pNew SrcList) {
 				Select: pOldItem.Select.Dup(),
 				pOn: pOldItem.pOn.Dup(),
 				pUsing: pOldItem.pUsing.Dup(),
+				pFuncArg: pOldItem.pFuncArg.Dup(),
 				colUsed: pOldItem.colUsed,
 			}
 			if pTab := pNewItem.pTab; pTab != nil {
@@ -587,6 +590,7 @@ func (p *Select) Dup() (pNew *Select) {
 			pOffset: p.pOffset.Dup(),
 			selFlags: p.selFlags & ~SF_UsesEphemeral,
 			addrOpenEphm: []int{ -1, -1, -1 },
+			pWith: p.pWith.Dup(),
 		}
 		if pPrior != nil {
 			pPrior.Next = pNew
@@ -1441,13 +1445,18 @@ func (pParse *Parse) ExprCachePinRegister(register int) {
 	}
 }
 
-//	Generate code to extract the value of the iCol-th column of a table.
-func (v *Vdbe) ExprCodeGetColumnOfTable(table *Table, cursor, column, register int) {
+//	Generate code to extract the value of the iCol-th column of a table. The value of a VIRTUAL generated column is computed from the
+//	other columns of the row.
+func (pParse *Parse) ExprCodeGetColumnOfTable(table *Table, cursor, column, register int) {
+	v := pParse.pVdbe
 	if column < 0 || column == table.iPKey {
 		v.AddOp2(OP_Rowid, cursor, register)
+	} else if table.Columns[column].IsVirtualGenerated() {
+		pParse.CodeGeneratedColumn(table, column, cursor, register)
+		return
 	} else {
 		op := table.IsVirtual() ? OP_VColumn : OP_Column
-		v.AddOp3(op, cursor, column, register)
+		v.AddOp3(op, cursor, table.StorageColumn(column), register)
 	}
 	if column >= 0 {
 		v.ColumnDefault(table, column, register)
@@ -1466,9 +1475,12 @@ func (pParse *Parse) ExprCodeGetColumn(table *Table, column, cursor, register in
 		}
 	}
 	assert( v != nil )
-	v.ExprCodeGetColumnOfTable(table, cursor, column, register)
+	pParse.ExprCodeGetColumnOfTable(table, cursor, column, register)
 	if p5 != 0 {
-		v.ChangeP5(p5)
+		//	P5 is only meaningful to an OP_Column, which a VIRTUAL generated column does not end with
+		if column < 0 || !table.Columns[column].IsVirtualGenerated() {
+			v.ChangeP5(p5)
+		}
 	} else {
 		sqlite3ExprCacheStore(pParse, cursor, column, register)
 	}
@@ -154,7 +154,7 @@ func (p *Parse) LocateFkeyIndex(pParent *Table, key *ForeignKey) (index *Index,
 	}
 
 	for _, index = range pParent.Indices {
-		if len(index.Columns) == nCol && index.onError != OE_None {
+		if len(index.Columns) == nCol && index.onError != OE_None && index.aColExpr == nil && index.pPartIdxWhere == nil {
 			//	index is a UNIQUE index (or a PRIMARY KEY) and has the right number of columns. If each indexed column corresponds to a foreign key column of ForeignKey, then this index is a winner.
 			if zKey == "" {
 				//	If zKey is NULL, then this foreign key is implicitly mapped to the PRIMARY KEY of table pParent. The PRIMARY KEY index may be identified by the test (Index.autoIndex == 2).
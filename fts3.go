@@ -4159,9 +4159,9 @@ static void hashDestroy(void *p){
 }
 
 /*
-** The fts3 built-in tokenizers - "simple", "porter" and "icu"- are
-** implemented in files fts3_tokenizer1.c, fts3_porter.c and fts3_icu.c
-** respectively. The following three forward declarations are for functions
+** The fts3 built-in tokenizers - "simple", "porter", "unicode61" and "icu"- are
+** implemented in files fts3_tokenizer1.c, fts3_porter.c, fts3_unicode.go and
+** fts3_icu.c respectively. The following forward declarations are for functions
 ** declared in these files used to retrieve the respective implementations.
 **
 ** Calling sqlite3Fts3SimpleTokenizerModule() sets the value pointed
@@ -4170,6 +4170,7 @@ static void hashDestroy(void *p){
 */
  void sqlite3Fts3SimpleTokenizerModule(sqlite3_tokenizer_module const**ppModule);
  void sqlite3Fts3PorterTokenizerModule(sqlite3_tokenizer_module const**ppModule);
+ void sqlite3Fts3UnicodeTokenizer(sqlite3_tokenizer_module const**ppModule);
 
 /*
 ** Initialise the fts3 extension. If this extension is built as part
@@ -4182,12 +4183,14 @@ static void hashDestroy(void *p){
   Fts3Hash *pHash = 0;
   const sqlite3_tokenizer_module *pSimple = 0;
   const sqlite3_tokenizer_module *pPorter = 0;
+  const sqlite3_tokenizer_module *pUnicode = 0;
 
   rc = sqlite3Fts3InitAux(db);
   if( rc!=SQLITE_OK ) return rc;
 
   sqlite3Fts3SimpleTokenizerModule(&pSimple);
   sqlite3Fts3PorterTokenizerModule(&pPorter);
+  sqlite3Fts3UnicodeTokenizer(&pUnicode);
 
   /* Allocate and initialise the hash-table used to store tokenizers. */
   pHash = sqlite3_malloc(sizeof(Fts3Hash));
@@ -4201,6 +4204,7 @@ static void hashDestroy(void *p){
   if( rc==SQLITE_OK ){
     if( sqlite3Fts3HashInsert(pHash, "simple", 7, (void *)pSimple)
      || sqlite3Fts3HashInsert(pHash, "porter", 7, (void *)pPorter)
+     || sqlite3Fts3HashInsert(pHash, "unicode61", 10, (void *)pUnicode)
     ){
       rc = SQLITE_NOMEM;
     }
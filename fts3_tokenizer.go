@@ -149,6 +149,10 @@ static void scalarFunc(
   sqlite3Fts3Dequote(z);
 
   m = (sqlite3_tokenizer_module *)sqlite3Fts3HashFind(pHash, z, len(z))+1);
+  if( !m ){
+    /* Not a tokenizer of this connection. Try the Go tokenizers. */
+    m = goTokenizerModule(z)
+  }
   if( !m ){
     *pzErr = fmt.Sprintf("unknown tokenizer: %v", z);
     rc = SQLITE_ERROR;
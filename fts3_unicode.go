@@ -0,0 +1,300 @@
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//	This file implements the "unicode61" full-text-search tokenizer, which classifies characters with the tables of the unicode
+//	package rather than as ASCII only:
+//
+//		CREATE VIRTUAL TABLE t USING fts4(body, tokenize=unicode61 "remove_diacritics=0" "tokenchars=-_")
+//		CREATE VIRTUAL TABLE t USING fts5(body, tokenize = "unicode61 separators '.'")
+//
+//	Tokens are runs of letters, numbers and private use characters, folded to lower case. The options are given as "name=value" or
+//	as a name followed by its value:
+//
+//		remove_diacritics N		1 (the default) or 2 to fold letters with diacritics to the letter without them, so that "Příliš"
+//								and "prilis" are the same token, 0 to keep them.
+//		tokenchars S			The characters of S are part of tokens.
+//		separators S			The characters of S separate tokens.
+//
+//	The tokenizer is registered with fts3 as an sqlite3_tokenizer_module and, like the Go tokenizers of tokenizer.go, by name for fts5.
+
+//	A unicodeTokenizer is a unicode61 tokenizer configured by its options.
+type unicodeTokenizer struct {
+	removeDiacritics	int
+	aException			map[rune]bool		//	Characters whose class is the opposite of the default, from tokenchars and separators
+}
+
+//	Letters with diacritics, after folding to lower case, by the letter without them. Combining marks are removed separately.
+var unicodeDiacritics = map[rune]string{
+	'a':	"àáâãäåāăąǎǟǡǻȁȃȧạảấầẩẫậắằẳẵặ",
+	'c':	"çćĉċč",
+	'd':	"ď",
+	'e':	"èéêëēĕėęěȅȇȩẹẻẽếềểễệ",
+	'g':	"ĝğġģǧǵ",
+	'h':	"ĥȟ",
+	'i':	"ìíîïĩīĭįǐȉȋỉị",
+	'j':	"ĵǰ",
+	'k':	"ķǩ",
+	'l':	"ĺļľ",
+	'n':	"ñńņňǹ",
+	'o':	"òóôõöōŏőơǒǫǭȍȏȯọỏốồổỗộớờởỡợ",
+	'r':	"ŕŗřȑȓ",
+	's':	"śŝşšș",
+	't':	"ţťț",
+	'u':	"ùúûüũūŭůűųưǔǖǘǚǜȕȗụủứừửữự",
+	'w':	"ŵẁẃẅ",
+	'y':	"ýÿŷȳỳỵỷỹ",
+	'z':	"źżž",
+}
+
+var unicodeFold map[rune]rune
+
+func init() {
+	unicodeFold = make(map[rune]rune)
+	for base, zLetters := range unicodeDiacritics {
+		for _, r := range zLetters {
+			unicodeFold[r] = base
+		}
+	}
+}
+
+//	Make a unicode61 tokenizer from its options.
+func newUnicodeTokenizer(azArg []string) (p *unicodeTokenizer, err error) {
+	p = &unicodeTokenizer{ removeDiacritics: 1, aException: make(map[rune]bool) }
+	for i := 0; i < len(azArg); i++ {
+		zName, zValue := azArg[i], ""
+		if j := strings.IndexByte(zName, '='); j >= 0 {
+			zName, zValue = zName[:j], zName[j + 1:]
+		} else if i + 1 < len(azArg) {
+			i++
+			zValue = azArg[i]
+		} else {
+			return nil, fmt.Errorf("unicode61: missing value for option %v", zName)
+		}
+		switch zName {
+		case "remove_diacritics":
+			n, e := strconv.Atoi(zValue)
+			if e != nil || n < 0 || n > 2 {
+				return nil, fmt.Errorf("unicode61: bad remove_diacritics value: %v", zValue)
+			}
+			p.removeDiacritics = n
+		case "tokenchars", "separators":
+			isToken := zName == "tokenchars"
+			for _, r := range zValue {
+				if p.isDefaultTokenChar(r) != isToken {
+					p.aException[r] = true
+				} else {
+					delete(p.aException, r)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unicode61: unrecognized option: %v", zName)
+		}
+	}
+	return
+}
+
+func (p *unicodeTokenizer) isDefaultTokenChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.Is(unicode.Co, r) || unicode.Is(unicode.Mn, r)
+}
+
+func (p *unicodeTokenizer) isTokenChar(r rune) bool {
+	return p.isDefaultTokenChar(r) != p.aException[r]
+}
+
+//	Append the folded form of r, a character of a token, to zToken.
+func (p *unicodeTokenizer) fold(zToken []byte, r rune) []byte {
+	r = unicode.ToLower(r)
+	if p.removeDiacritics != 0 {
+		if unicode.Is(unicode.Mn, r) && !p.aException[r] {
+			return zToken
+		}
+		if base, ok := unicodeFold[r]; ok {
+			r = base
+		}
+	}
+	return append(zToken, string(r)...)
+}
+
+//	Return the first token of zText at or after byte offset iOff, and the byte offsets of its text. ok is false if there is none.
+func (p *unicodeTokenizer) next(zText string, iOff int) (zToken string, iStart, iEnd int, ok bool) {
+	for iOff < len(zText) {
+		r, n := utf8.DecodeRuneInString(zText[iOff:])
+		if p.isTokenChar(r) {
+			break
+		}
+		iOff += n
+	}
+	if iOff >= len(zText) {
+		return "", iOff, iOff, false
+	}
+	iStart = iOff
+	var zFolded []byte
+	for iOff < len(zText) {
+		r, n := utf8.DecodeRuneInString(zText[iOff:])
+		if !p.isTokenChar(r) {
+			break
+		}
+		zFolded = p.fold(zFolded, r)
+		iOff += n
+	}
+	return string(zFolded), iStart, iOff, true
+}
+
+func (p *unicodeTokenizer) Tokenize(text string, emit func(TextToken) error) error {
+	for iOff, iPos := 0, 0; ; iPos++ {
+		zToken, iStart, iEnd, ok := p.next(text, iOff)
+		if !ok {
+			return nil
+		}
+		if err := emit(TextToken{ Text: zToken, Start: iStart, End: iEnd, Position: iPos }); err != nil {
+			return err
+		}
+		iOff = iEnd
+	}
+}
+
+//	Make a unicode61 Tokenizer. This is the function that registers the tokenizer in tokenizer.go.
+func newUnicode61Tokenizer(args []string) (Tokenizer, error) {
+	p, err := newUnicodeTokenizer(args)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+#if defined(SQLITE_ENABLE_FTS3)
+
+typedef struct unicode_tokenizer {
+  sqlite3_tokenizer base;
+  unicodeTokenizer *p;         /* Options of the tokenizer */
+} unicode_tokenizer;
+
+typedef struct unicode_tokenizer_cursor {
+  sqlite3_tokenizer_cursor base;
+  const char *zInput;          /* input we are tokenizing */
+  int iOffset;                 /* current position in zInput */
+  int iToken;                  /* index of next token to be returned */
+  const char *zToken;          /* storage for current token */
+} unicode_tokenizer_cursor;
+
+/*
+** Create a new tokenizer instance.
+*/
+static int unicodeCreate(
+  int argc, const char * const *argv,
+  sqlite3_tokenizer **ppTokenizer
+){
+  unicode_tokenizer *t;
+  p, err := newUnicodeTokenizer(argv[:argc])
+  if( err!=nil ){
+    return SQLITE_ERROR;
+  }
+  t = &unicode_tokenizer{ p: p }
+  *ppTokenizer = &t.base;
+  return SQLITE_OK;
+}
+
+/*
+** Destroy a tokenizer
+*/
+static int unicodeDestroy(sqlite3_tokenizer *pTokenizer){
+  pTokenizer = nil
+  return SQLITE_OK;
+}
+
+/*
+** Prepare to begin tokenizing a particular string.  The input
+** string to be tokenized is pInput[0..nBytes-1].  A cursor
+** used to incrementally tokenize this string is returned in
+** *ppCursor.
+*/
+static int unicodeOpen(
+  sqlite3_tokenizer *pTokenizer,         /* The tokenizer */
+  const char *pInput, int nBytes,        /* String to be tokenized */
+  sqlite3_tokenizer_cursor **ppCursor    /* OUT: Tokenization cursor */
+){
+  unicode_tokenizer_cursor *c;
+
+  c = &unicode_tokenizer_cursor{}
+  if( nBytes<0 || nBytes>len(pInput) ){
+    c.zInput = pInput;
+  }else{
+    c.zInput = pInput[:nBytes];
+  }
+  c.iOffset = 0;                 /* start tokenizing at the beginning */
+  c.iToken = 0;
+
+  *ppCursor = &c.base;
+  return SQLITE_OK;
+}
+
+/*
+** Close a tokenization cursor previously opened by a call to
+** unicodeOpen() above.
+*/
+static int unicodeClose(sqlite3_tokenizer_cursor *pCursor){
+  unicode_tokenizer_cursor *c = (unicode_tokenizer_cursor *) pCursor;
+  c.zToken = ""
+  c = nil
+  return SQLITE_OK;
+}
+
+/*
+** Extract the next token from a tokenization cursor.  The cursor must
+** have been opened by a prior call to unicodeOpen().
+*/
+static int unicodeNext(
+  sqlite3_tokenizer_cursor *pCursor,  /* Cursor returned by unicodeOpen */
+  const char **ppToken,               /* OUT: *ppToken is the token text */
+  int *pnBytes,                       /* OUT: Number of bytes in token */
+  int *piStartOffset,                 /* OUT: Starting offset of token */
+  int *piEndOffset,                   /* OUT: Ending offset of token */
+  int *piPosition                     /* OUT: Position integer of token */
+){
+  unicode_tokenizer_cursor *c = (unicode_tokenizer_cursor *) pCursor;
+  unicode_tokenizer *t = (unicode_tokenizer *) pCursor.pTokenizer;
+
+  zToken, iStart, iEnd, ok := t.p.next(c.zInput, c.iOffset)
+  if( !ok ){
+    c.iOffset = len(c.zInput)
+    return SQLITE_DONE;
+  }
+  c.zToken = zToken;
+  c.iOffset = iEnd;
+  *ppToken = c.zToken;
+  *pnBytes = len(c.zToken)
+  *piStartOffset = iStart;
+  *piEndOffset = iEnd;
+  *piPosition = c.iToken++;
+  return SQLITE_OK;
+}
+
+/*
+** The set of routines that implement the unicode61 tokenizer
+*/
+static const sqlite3_tokenizer_module unicodeTokenizerModule = {
+  0,
+  unicodeCreate,
+  unicodeDestroy,
+  unicodeOpen,
+  unicodeClose,
+  unicodeNext,
+  0,
+};
+
+/*
+** Allocate a new unicode61 tokenizer.  Return a pointer to the new
+** tokenizer in *ppModule
+*/
+ void sqlite3Fts3UnicodeTokenizer(
+  sqlite3_tokenizer_module const**ppModule
+){
+  *ppModule = &unicodeTokenizerModule;
+}
+
+#endif /* defined(SQLITE_ENABLE_FTS3) */
@@ -0,0 +1,103 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnicodeTokenizer(t *testing.T) {
+	for _, test := range []struct {
+		azArg		[]string
+		text		string
+		want		string				//	The tokens, separated by spaces
+	}{
+		//	Letters are folded to lower case and, by default, to the letter without their diacritics.
+		{ nil, "Příliš žluťoučký KŮŇ", "prilis zlutoucky kun" },
+		{ nil, "Hello, World! 42x", "hello world 42x" },
+		{ nil, "日本語 text", "日本語 text" },
+		{ []string{ "remove_diacritics=0" }, "Příliš KŮŇ", "příliš kůň" },
+		{ []string{ "remove_diacritics", "2" }, "Ångström", "angstrom" },
+
+		//	Combining marks are part of a token, and removed with the diacritics.
+		{ nil, "e\u0301te\u0301", "ete" },
+		{ []string{ "remove_diacritics=0" }, "e\u0301te\u0301", "e\u0301te\u0301" },
+
+		//	tokenchars and separators change the class of characters. The last option for a character wins.
+		{ nil, "well-known snake_case", "well known snake case" },
+		{ []string{ "tokenchars=-_" }, "well-known snake_case", "well-known snake_case" },
+		{ []string{ "separators=x" }, "axb", "a b" },
+		{ []string{ "separators", "1" }, "v1.2", "v 2" },
+		{ []string{ "tokenchars=.", "separators=." }, "a.b", "a b" },
+		{ []string{ "separators=.", "tokenchars=." }, "a.b", "a.b" },
+		{ []string{ "separators=\u0301" }, "e\u0301t", "e t" },
+	} {
+		p, err := newUnicodeTokenizer(test.azArg)
+		if err != nil {
+			t.Fatalf("%q: %v", test.azArg, err)
+		}
+		var azToken []string
+		p.Tokenize(test.text, func(tok TextToken) error {
+			azToken = append(azToken, tok.Text)
+			return nil
+		})
+		if got := strings.Join(azToken, " "); got != test.want {
+			t.Errorf("%q with %q: %q, want %q", test.text, test.azArg, got, test.want)
+		}
+	}
+
+	//	Offsets are those of the text before folding. Positions count the tokens.
+	p, _ := newUnicodeTokenizer(nil)
+	var aToken []TextToken
+	p.Tokenize("  Kůň, kůň!", func(tok TextToken) error {
+		aToken = append(aToken, tok)
+		return nil
+	})
+	want := []TextToken{ { "kun", 2, 7, 0 }, { "kun", 9, 14, 1 } }
+	if len(aToken) != len(want) || aToken[0] != want[0] || aToken[1] != want[1] {
+		t.Errorf("tokens %+v, want %+v", aToken, want)
+	}
+
+	for _, azArg := range [][]string{ { "remove_diacritics=3" }, { "remove_diacritics=x" }, { "tokenchars" }, { "foo=1" } } {
+		if _, err := newUnicodeTokenizer(azArg); err == nil || !strings.HasPrefix(err.Error(), "unicode61: ") {
+			t.Errorf("options %q: %v", azArg, err)
+		}
+	}
+}
+
+func TestUnicodeTokenizerTables(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE VIRTUAL TABLE f4 USING fts4(body, tokenize=unicode61 \"tokenchars=_\")")
+	testExec(t, db, "CREATE VIRTUAL TABLE f4keep USING fts4(body, tokenize=unicode61 \"remove_diacritics=0\")")
+	testExec(t, db, "CREATE VIRTUAL TABLE f5 USING fts5(body, tokenize = \"unicode61 tokenchars '.' separators 'ž'\")")
+	for _, zTab := range []string{ "f4", "f4keep", "f5" } {
+		testExec(t, db, "INSERT INTO " + zTab + "(rowid, body) VALUES(1, 'Příliš žluťoučký kůň'), (2, 'snake_case v1.2 Straße')")
+	}
+
+	for _, test := range []struct {
+		zTab, zQuery, want	string
+	}{
+		//	Documents and queries are folded the same way.
+		{ "f4", "prilis", "1" },
+		{ "f4", "KŮŇ", "1" },
+		{ "f4keep", "kun", "" },
+		{ "f4keep", "kůň", "1" },
+		{ "f5", "STRASSE", "" },
+		{ "f5", "straße", "2" },
+
+		//	tokenchars and separators.
+		{ "f4", "snake_case", "2" },
+		{ "f4", "snake", "" },
+		{ "f4keep", "snake", "2" },
+		{ "f5", "snake", "2" },
+		{ "f5", "lutoucky", "1" },
+		{ "f5", "zlutoucky", "" },
+		{ "f5", "\"v1.2\"", "2" },
+		{ "f5", "v1", "" },
+		{ "f4", "v1", "2" },
+		{ "f4", "\"v1 2\"", "2" },
+	} {
+		testQueryIs(t, db, test.want, "SELECT rowid FROM " + test.zTab + " WHERE " + test.zTab + " MATCH ?", test.zQuery)
+	}
+
+	if _, err := db.Exec("CREATE VIRTUAL TABLE bad USING fts5(body, tokenize = 'unicode61 remove_diacritics 9')"); err == nil || !strings.Contains(err.Error(), "bad remove_diacritics value: 9") {
+		t.Errorf("bad option: %v", err)
+	}
+}
@@ -0,0 +1,782 @@
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//	This file implements the fts5 virtual table module, a full-text index with relevance ranking:
+//
+//		CREATE VIRTUAL TABLE docs USING fts5(title, body, tokenize = 'ascii')
+//		SELECT title, highlight(docs, 1, '[', ']') FROM docs WHERE docs MATCH 'body:"full text" OR NEAR(search rank, 5)' ORDER BY rank
+//
+//	Each argument of CREATE VIRTUAL TABLE is either a column, which may be followed by UNINDEXED to store it without indexing it, or
+//	an option:
+//
+//		content = 'tbl'			The documents are the rows of the table tbl, which has a column of the same name as each column of
+//								the fts5 table. The index is kept up to date by the application. See fts5Table.command().
+//		content = ''			The documents are not stored at all. Columns of the table read as NULL.
+//		content_rowid = 'col'	The column of the content table that holds the rowid of each document. The default is the rowid.
+//		tokenize = 'name args'	The tokenizer that splits text into terms: ascii, the default, unicode61 or one registered with
+//								RegisterTokenizer().
+//
+//	Besides its columns the table has two hidden columns: one with the name of the table, which is the left operand of MATCH for a
+//	query on all columns and the first argument of the auxiliary functions of fts5_aux.go, and rank, which holds the bm25() score of
+//	each row of a full-text query. The query language is implemented in fts5_expr.go.
+//
+//	The table is stored in shadow tables named after it:
+//
+//		%_content(id INTEGER PRIMARY KEY, c0, c1, ...)	The documents, unless the content option is given.
+//		%_docsize(id INTEGER PRIMARY KEY, sz)			The number of tokens in each column of each document.
+//		%_idx(term, id, pos) WITHOUT ROWID				For each term and document that contains it, the column and position of each
+//														instance of the term, keyed by (term, id).
+//		%_config(k PRIMARY KEY, v) WITHOUT ROWID		The 'totals' row holds the number of documents and the number of tokens in
+//														each column of all of them, for bm25().
+//
+//	Lists of integers in the shadow tables are stored as blobs of varints. The fts5 module is written against the Go virtual table
+//	interface of uservtab.go. fts5Module keeps the open cursors of the connection so that auxiliary functions can find the cursor
+//	whose row they are called for from the value of the hidden column.
+
+//	Ways an fts5 table keeps its documents.
+const (
+	fts5ContentNormal = iota				//	In the %_content shadow table
+	fts5ContentExternal						//	In the table named by the content option
+	fts5ContentNone							//	Not at all
+)
+
+//	Bits of the idxNum of an fts5 plan. The idxStr holds a word for each argument passed to xFilter: "m" for a full-text query of
+//	all columns, "m" followed by a column number for a query of that column, and "r" for a rowid.
+const (
+	fts5Desc = 1 << iota					//	Return the rows in descending order of rowid
+)
+
+//	An fts5Module is the fts5 module of a connection.
+type fts5Module struct {
+	db				*sqlite3
+	aCursor			map[int64]*fts5Cursor	//	Open cursors, by the value of the hidden column named after the table
+	iCursor			int64					//	Identifier of the cursor opened last
+}
+
+//	An fts5Table is an fts5 virtual table.
+type fts5Table struct {
+	pModule			*fts5Module
+	db				*sqlite3
+	zDb				string					//	Name of the database holding the table
+	zName			string					//	Name of the table
+	azCol			[]string				//	Names of the columns
+	abUnindexed		[]bool					//	True for each column declared UNINDEXED
+	eContent		int						//	One of the fts5Content constants
+	zContent		string					//	Name of the external content table
+	zContentRowid	string					//	Name of its rowid column
+	pTokenizer		Tokenizer
+}
+
+//	A position in a document: the column and the number of the token within it.
+type fts5Pos struct {
+	iCol, iPos		int
+}
+
+//	An fts5Cursor is a cursor on an fts5 table.
+type fts5Cursor struct {
+	pTab			*fts5Table
+	iId				int64					//	Key of the cursor in fts5Module.aCursor
+	pExpr			*fts5Expr				//	Full-text query, or nil
+	aPhrase			[]*fts5Phrase			//	The phrases of pExpr
+	nDoc			int64					//	Number of documents in the table, if pExpr is not nil
+	aTotal			[]int64					//	Number of tokens in each column of all documents, if pExpr is not nil
+	aRowid			[]int64					//	The rows to return, in order
+	i				int						//	Index in aRowid of the current row
+	aValue			[]interface{}			//	Values of the columns of the current row, once loaded
+	aSize			[]int64					//	Number of tokens in each column of the current row, once loaded
+}
+
+func (m *fts5Module) Create(args []string) (string, VTab, error) {
+	return m.connect(args, true)
+}
+
+func (m *fts5Module) Connect(args []string) (string, VTab, error) {
+	return m.connect(args, false)
+}
+
+func (m *fts5Module) connect(args []string, isCreate bool) (zSchema string, vtab VTab, err error) {
+	p := &fts5Table{ pModule: m, db: m.db, zDb: args[1], zName: args[2] }
+	if err = p.configure(args[3:]); err != nil {
+		return
+	}
+	if isCreate {
+		if err = p.createShadowTables(); err != nil {
+			return
+		}
+	}
+	var azCol []string
+	for _, zCol := range p.azCol {
+		azCol = append(azCol, fts5Quote(zCol))
+	}
+	azCol = append(azCol, fts5Quote(p.zName) + " HIDDEN", "rank HIDDEN")
+	return fmt.Sprintf("CREATE TABLE x(%v)", strings.Join(azCol, ", ")), p, nil
+}
+
+//	Set up p from the arguments of its CREATE VIRTUAL TABLE statement.
+func (p *fts5Table) configure(azArg []string) (err error) {
+	zTokenize := "ascii"
+	for _, zArg := range azArg {
+		s := newSqlScanner(zArg)
+		if s.Type == 0 {
+			return fmt.Errorf("fts5: empty argument")
+		}
+		zWord := Dequote(s.Text)
+		if s.Next(); s.Type == TK_EQ {
+			s.Next()
+			zValue := Dequote(strings.TrimSpace(s.Rest()))
+			switch strings.ToLower(zWord) {
+			case "content":
+				if p.zContent = zValue; zValue == "" {
+					p.eContent = fts5ContentNone
+				} else {
+					p.eContent = fts5ContentExternal
+				}
+			case "content_rowid":
+				p.zContentRowid = zValue
+			case "tokenize":
+				zTokenize = zValue
+			default:
+				return fmt.Errorf("unrecognized option: %q", zWord)
+			}
+			continue
+		}
+		switch strings.ToLower(zWord) {
+		case "rowid", "rank", strings.ToLower(p.zName):
+			return fmt.Errorf("reserved fts5 column name: %v", zWord)
+		}
+		isUnindexed := false
+		for ; s.Type != 0; s.Next() {
+			if !s.IsWord("UNINDEXED") {
+				return fmt.Errorf("unrecognized column option: %v", s.Text)
+			}
+			isUnindexed = true
+		}
+		p.azCol = append(p.azCol, zWord)
+		p.abUnindexed = append(p.abUnindexed, isUnindexed)
+	}
+	if len(p.azCol) == 0 {
+		return fmt.Errorf("fts5: no columns")
+	}
+	if p.zContentRowid != "" && p.eContent != fts5ContentExternal {
+		return fmt.Errorf("content_rowid may only be used with an external content table")
+	}
+	if p.zContentRowid == "" {
+		p.zContentRowid = "rowid"
+	}
+	azTokenize := strings.Fields(zTokenize)
+	if len(azTokenize) == 0 {
+		azTokenize = []string{ "ascii" }
+	}
+	xNew := findTokenizer(azTokenize[0])
+	if xNew == nil {
+		return fmt.Errorf("no such tokenizer: %v", azTokenize[0])
+	}
+	for i := 1; i < len(azTokenize); i++ {
+		azTokenize[i] = Dequote(azTokenize[i])
+	}
+	p.pTokenizer, err = xNew(azTokenize[1:])
+	return
+}
+
+//	Return z quoted as an SQL identifier.
+func fts5Quote(z string) string {
+	return `"` + strings.Replace(z, `"`, `""`, -1) + `"`
+}
+
+//	Return the qualified name of the shadow table %_zSuffix of p.
+func (p *fts5Table) shadow(zSuffix string) string {
+	return fts5Quote(p.zDb) + "." + fts5Quote(p.zName + "_" + zSuffix)
+}
+
+func (p *fts5Table) createShadowTables() error {
+	azSql := []string{
+		fmt.Sprintf("CREATE TABLE %v(id INTEGER PRIMARY KEY, sz BLOB)", p.shadow("docsize")),
+		fmt.Sprintf("CREATE TABLE %v(term, id, pos, PRIMARY KEY(term, id)) WITHOUT ROWID", p.shadow("idx")),
+		fmt.Sprintf("CREATE TABLE %v(k PRIMARY KEY, v) WITHOUT ROWID", p.shadow("config")),
+	}
+	if p.eContent == fts5ContentNormal {
+		azCol := []string{ "id INTEGER PRIMARY KEY" }
+		for iCol := range p.azCol {
+			azCol = append(azCol, fmt.Sprintf("c%v", iCol))
+		}
+		azSql = append(azSql, fmt.Sprintf("CREATE TABLE %v(%v)", p.shadow("content"), strings.Join(azCol, ", ")))
+	}
+	for _, zSql := range azSql {
+		if err := p.exec(zSql, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//	Run zSql on the connection of p with args bound to its parameters in order. xRow, if not nil, is called for each row of the
+//	result.
+func (p *fts5Table) exec(zSql string, args []interface{}, xRow func(pStmt *sqlite3_stmt)) error {
+	pStmt, _, rc := p.db.PrepareV2(zSql)
+	if rc != SQLITE_OK {
+		return p.db.lastError(rc)
+	}
+	defer sqlite3_finalize(pStmt)
+	for i, arg := range args {
+		switch v := arg.(type) {
+		case int64:
+			rc = sqlite3_bind_int64(pStmt, i + 1, v)
+		case float64:
+			rc = sqlite3_bind_double(pStmt, i + 1, v)
+		case string:
+			rc = pStmt.BindText(i + 1, v, SQLITE_TRANSIENT, SQLITE_UTF8)
+		case []byte:
+			rc = pStmt.BindBlob(i + 1, string(v), SQLITE_TRANSIENT)
+		default:
+			rc = sqlite3_bind_null(pStmt, i + 1)
+		}
+		if rc != SQLITE_OK {
+			return p.db.lastError(rc)
+		}
+	}
+	for {
+		switch rc = sqlite3_step(pStmt); rc {
+		case SQLITE_ROW:
+			if xRow != nil {
+				xRow(pStmt)
+			}
+		case SQLITE_DONE:
+			return nil
+		default:
+			return p.db.lastError(rc)
+		}
+	}
+}
+
+//	Return the text that is indexed for value v of a column.
+func fts5Text(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+	return fmt.Sprint(v)
+}
+
+//	Return v as an integer rowid. ok is false if it is not an integer.
+func fts5Rowid(v interface{}) (iRowid int64, ok bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), float64(int64(v)) == v
+	case string:
+		iRowid, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		return iRowid, err == nil
+	}
+	return 0, false
+}
+
+func fts5PutInts(a []int64) []byte {
+	b := make([]byte, 0, len(a))
+	buf := make([]byte, binary.MaxVarintLen64)
+	for _, v := range a {
+		b = append(b, buf[:binary.PutVarint(buf, v)]...)
+	}
+	return b
+}
+
+func fts5GetInts(b []byte) (a []int64) {
+	for len(b) > 0 {
+		v, n := binary.Varint(b)
+		if n <= 0 {
+			break
+		}
+		a = append(a, v)
+		b = b[n:]
+	}
+	return
+}
+
+func fts5PutPos(aPos []fts5Pos) []byte {
+	a := make([]int64, 0, 2 * len(aPos))
+	for _, pos := range aPos {
+		a = append(a, int64(pos.iCol), int64(pos.iPos))
+	}
+	return fts5PutInts(a)
+}
+
+func fts5GetPos(b []byte) (aPos []fts5Pos) {
+	a := fts5GetInts(b)
+	for i := 0; i + 1 < len(a); i += 2 {
+		aPos = append(aPos, fts5Pos{ int(a[i]), int(a[i + 1]) })
+	}
+	return
+}
+
+//	Return the text of a SELECT statement that reads the rowid and columns of the documents: the one whose rowid is bound to its
+//	parameter if isLookup is true, otherwise all of them.
+func (p *fts5Table) contentQuery(isLookup bool) string {
+	var azCol []string
+	zTab, zRowid := p.shadow("content"), "id"
+	if p.eContent == fts5ContentExternal {
+		zTab, zRowid = fts5Quote(p.zDb) + "." + fts5Quote(p.zContent), fts5Quote(p.zContentRowid)
+	}
+	azCol = append(azCol, zRowid)
+	for iCol, zCol := range p.azCol {
+		if p.eContent == fts5ContentExternal {
+			azCol = append(azCol, fts5Quote(zCol))
+		} else {
+			azCol = append(azCol, fmt.Sprintf("c%v", iCol))
+		}
+	}
+	zSql := fmt.Sprintf("SELECT %v FROM %v", strings.Join(azCol, ", "), zTab)
+	if isLookup {
+		zSql += fmt.Sprintf(" WHERE %v = ?", zRowid)
+	}
+	return zSql
+}
+
+//	Read the values of the columns of document iRowid. ok is false if the document does not exist. The columns of a contentless table
+//	are all NULL.
+func (p *fts5Table) document(iRowid int64) (aValue []interface{}, ok bool, err error) {
+	if p.eContent == fts5ContentNone {
+		return make([]interface{}, len(p.azCol)), true, nil
+	}
+	err = p.exec(p.contentQuery(true), []interface{}{ iRowid }, func(pStmt *sqlite3_stmt) {
+		aValue, ok = make([]interface{}, len(p.azCol)), true
+		for iCol := range aValue {
+			aValue[iCol] = goValue(sqlite3_column_value(pStmt, iCol + 1))
+		}
+	})
+	return
+}
+
+//	Split the indexed columns of a document into terms. Return the positions of each term and the number of positions in each column.
+//	Tokens that the tokenizer gives the same position are all indexed at that position.
+func (p *fts5Table) tokenizeDocument(aValue []interface{}) (aTerm map[string][]fts5Pos, aSize []int64, err error) {
+	aTerm = make(map[string][]fts5Pos)
+	aSize = make([]int64, len(p.azCol))
+	for iCol := range p.azCol {
+		if p.abUnindexed[iCol] {
+			continue
+		}
+		err = tokenizeText(p.pTokenizer, fts5Text(aValue[iCol]), func(tok TextToken) {
+			aTerm[tok.Text] = append(aTerm[tok.Text], fts5Pos{ iCol, tok.Position })
+			aSize[iCol] = int64(tok.Position + 1)
+		})
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+//	Return the number of documents in the table and the number of tokens in each column of all of them.
+func (p *fts5Table) totals() (nDoc int64, aTotal []int64, err error) {
+	aTotal = make([]int64, len(p.azCol))
+	err = p.exec(fmt.Sprintf("SELECT v FROM %v WHERE k = 'totals'", p.shadow("config")), nil, func(pStmt *sqlite3_stmt) {
+		a := fts5GetInts(sqlite3_column_blob(pStmt, 0))
+		if len(a) > 0 {
+			nDoc = a[0]
+			copy(aTotal, a[1:])
+		}
+	})
+	return
+}
+
+//	Add nDoc documents with aSize tokens in each column to the totals. Both are negative when documents are removed.
+func (p *fts5Table) addTotals(nDoc int64, aSize []int64) error {
+	nTotal, aTotal, err := p.totals()
+	if err != nil {
+		return err
+	}
+	a := []int64{ nTotal + nDoc }
+	for iCol, n := range aTotal {
+		a = append(a, n + aSize[iCol])
+	}
+	return p.exec(fmt.Sprintf("REPLACE INTO %v(k, v) VALUES('totals', ?)", p.shadow("config")), []interface{}{ fts5PutInts(a) }, nil)
+}
+
+//	Return the number of tokens in each column of document iRowid.
+func (p *fts5Table) docsize(iRowid int64) (aSize []int64, err error) {
+	aSize = make([]int64, len(p.azCol))
+	err = p.exec(fmt.Sprintf("SELECT sz FROM %v WHERE id = ?", p.shadow("docsize")), []interface{}{ iRowid }, func(pStmt *sqlite3_stmt) {
+		copy(aSize, fts5GetInts(sqlite3_column_blob(pStmt, 0)))
+	})
+	return
+}
+
+//	Add document iRowid with column values aValue to the index.
+func (p *fts5Table) index(iRowid int64, aValue []interface{}) error {
+	aTerm, aSize, err := p.tokenizeDocument(aValue)
+	if err != nil {
+		return err
+	}
+	zSql := fmt.Sprintf("INSERT INTO %v(id, sz) VALUES(?, ?)", p.shadow("docsize"))
+	if err := p.exec(zSql, []interface{}{ iRowid, fts5PutInts(aSize) }, nil); err != nil {
+		return err
+	}
+	zSql = fmt.Sprintf("INSERT INTO %v(term, id, pos) VALUES(?, ?, ?)", p.shadow("idx"))
+	for zTerm, aPos := range aTerm {
+		if err := p.exec(zSql, []interface{}{ zTerm, iRowid, fts5PutPos(aPos) }, nil); err != nil {
+			return err
+		}
+	}
+	return p.addTotals(1, aSize)
+}
+
+//	Remove document iRowid, whose column values were aValue when it was indexed, from the index.
+func (p *fts5Table) deindex(iRowid int64, aValue []interface{}) error {
+	aSize, err := p.docsize(iRowid)
+	if err != nil {
+		return err
+	}
+	aTerm, _, err := p.tokenizeDocument(aValue)
+	if err != nil {
+		return err
+	}
+	zSql := fmt.Sprintf("DELETE FROM %v WHERE term = ? AND id = ?", p.shadow("idx"))
+	for zTerm := range aTerm {
+		if err := p.exec(zSql, []interface{}{ zTerm, iRowid }, nil); err != nil {
+			return err
+		}
+	}
+	zSql = fmt.Sprintf("DELETE FROM %v WHERE id = ?", p.shadow("docsize"))
+	if err := p.exec(zSql, []interface{}{ iRowid }, nil); err != nil {
+		return err
+	}
+	for iCol := range aSize {
+		aSize[iCol] = -aSize[iCol]
+	}
+	return p.addTotals(-1, aSize)
+}
+
+//	Remove every document from the index.
+func (p *fts5Table) clear() error {
+	for _, zSuffix := range []string{ "idx", "docsize", "config" } {
+		if err := p.exec(fmt.Sprintf("DELETE FROM %v", p.shadow(zSuffix)), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//	Index every document of the content table again.
+func (p *fts5Table) rebuild() error {
+	if err := p.clear(); err != nil {
+		return err
+	}
+	var aRowid []int64
+	var aDoc [][]interface{}
+	err := p.exec(p.contentQuery(false), nil, func(pStmt *sqlite3_stmt) {
+		aValue := make([]interface{}, len(p.azCol))
+		for iCol := range aValue {
+			aValue[iCol] = goValue(sqlite3_column_value(pStmt, iCol + 1))
+		}
+		aRowid = append(aRowid, sqlite3_column_int64(pStmt, 0))
+		aDoc = append(aDoc, aValue)
+	})
+	for i := 0; err == nil && i < len(aRowid); i++ {
+		err = p.index(aRowid[i], aDoc[i])
+	}
+	return err
+}
+
+//	Run a special command, written as an INSERT of the command into the hidden column named after the table:
+//
+//		INSERT INTO ft(ft, rowid, a, b) VALUES('delete', 5, 'old a', 'old b')		--	Remove document 5 with the values it was indexed with
+//		INSERT INTO ft(ft) VALUES('delete-all')										--	Empty the index
+//		INSERT INTO ft(ft) VALUES('rebuild')										--	Index the content table again
+//
+//	'delete' and 'delete-all' are for tables whose content is external or absent, which the application keeps in step with the
+//	content itself.
+func (p *fts5Table) command(zCmd string, rowid interface{}, aValue []interface{}) error {
+	switch zCmd {
+	case "delete":
+		if p.eContent == fts5ContentNormal {
+			return fmt.Errorf("'delete' may not be used with a contentful fts5 table")
+		}
+		iRowid, ok := fts5Rowid(rowid)
+		if !ok {
+			return fmt.Errorf("'delete' needs the rowid of the document")
+		}
+		return p.deindex(iRowid, aValue)
+	case "delete-all":
+		if p.eContent == fts5ContentNormal {
+			return fmt.Errorf("'delete-all' may only be used with a contentless or external content fts5 table")
+		}
+		return p.clear()
+	case "rebuild":
+		if p.eContent == fts5ContentNone {
+			return fmt.Errorf("'rebuild' may not be used with a contentless fts5 table")
+		}
+		return p.rebuild()
+	}
+	return fmt.Errorf("unknown special command: %v", zCmd)
+}
+
+func (p *fts5Table) Insert(rowid interface{}, aValue []interface{}) (iRowid int64, err error) {
+	nCol := len(p.azCol)
+	if zCmd := aValue[nCol]; zCmd != nil {
+		return 0, p.command(fts5Text(zCmd), rowid, aValue[:nCol])
+	}
+	iRowid, ok := fts5Rowid(rowid)
+	if !ok {
+		zSql := fmt.Sprintf("SELECT coalesce(max(id), 0) + 1 FROM %v", p.shadow("docsize"))
+		if err = p.exec(zSql, nil, func(pStmt *sqlite3_stmt) { iRowid = sqlite3_column_int64(pStmt, 0) }); err != nil {
+			return
+		}
+	}
+	return iRowid, p.insert(iRowid, aValue[:nCol])
+}
+
+func (p *fts5Table) insert(iRowid int64, aValue []interface{}) error {
+	if p.eContent == fts5ContentNormal {
+		azParam := []string{ "?" }
+		args := []interface{}{ iRowid }
+		for _, v := range aValue {
+			azParam = append(azParam, "?")
+			args = append(args, v)
+		}
+		zSql := fmt.Sprintf("INSERT INTO %v VALUES(%v)", p.shadow("content"), strings.Join(azParam, ", "))
+		if err := p.exec(zSql, args, nil); err != nil {
+			return err
+		}
+	}
+	return p.index(iRowid, aValue)
+}
+
+func (p *fts5Table) Update(iOld, iNew int64, aValue []interface{}) error {
+	if p.eContent == fts5ContentNone {
+		return fmt.Errorf("cannot UPDATE contentless fts5 table: %v", p.zName)
+	}
+	if err := p.Delete(iOld); err != nil {
+		return err
+	}
+	return p.insert(iNew, aValue[:len(p.azCol)])
+}
+
+//	Delete document iRowid. The terms to remove from the index are found by reading the document, so with external content the
+//	document must not have been changed since it was indexed.
+func (p *fts5Table) Delete(iRowid int64) error {
+	if p.eContent == fts5ContentNone {
+		return fmt.Errorf("cannot DELETE from contentless fts5 table: %v", p.zName)
+	}
+	aValue, ok, err := p.document(iRowid)
+	if err != nil || !ok {
+		return err
+	}
+	if err = p.deindex(iRowid, aValue); err != nil || p.eContent != fts5ContentNormal {
+		return err
+	}
+	return p.exec(fmt.Sprintf("DELETE FROM %v WHERE id = ?", p.shadow("content")), []interface{}{ iRowid }, nil)
+}
+
+//	Full-text queries, of all columns or of one, are passed to xFilter along with a rowid to look up. They are combined with AND.
+func (p *fts5Table) BestIndex(info *IndexInfo) error {
+	nCol := len(p.azCol)
+	var azArg []string
+	nMatch, hasRowid := 0, false
+	for i := range info.Constraints {
+		pCons := &info.Constraints[i]
+		switch {
+		case !pCons.Usable:
+			continue
+		case pCons.Op == SQLITE_INDEX_CONSTRAINT_MATCH && pCons.Column >= 0 && pCons.Column < nCol:
+			azArg = append(azArg, fmt.Sprintf("m%v", pCons.Column))
+			nMatch++
+		case (pCons.Op == SQLITE_INDEX_CONSTRAINT_MATCH || pCons.Op == SQLITE_INDEX_CONSTRAINT_EQ) && pCons.Column == nCol:
+			azArg = append(azArg, "m")
+			nMatch++
+		case pCons.Op == SQLITE_INDEX_CONSTRAINT_EQ && pCons.Column < 0 && !hasRowid:
+			azArg = append(azArg, "r")
+			hasRowid = true
+		default:
+			continue
+		}
+		pCons.ArgvIndex = len(azArg)
+		pCons.Omit = true
+	}
+	info.IdxStr = strings.Join(azArg, " ")
+	switch {
+	case hasRowid:
+		info.EstimatedCost = 1
+	case nMatch > 0:
+		info.EstimatedCost = 1000
+	default:
+		info.EstimatedCost = 1000000
+	}
+	if len(info.OrderBy) == 1 && info.OrderBy[0].Column < 0 {
+		if info.OrderBy[0].Desc {
+			info.IdxNum |= fts5Desc
+		}
+		info.OrderByConsumed = true
+	}
+	return nil
+}
+
+func (p *fts5Table) Open() (VTabCursor, error) {
+	m := p.pModule
+	m.iCursor++
+	pCur := &fts5Cursor{ pTab: p, iId: m.iCursor }
+	m.aCursor[pCur.iId] = pCur
+	return pCur, nil
+}
+
+func (p *fts5Table) Disconnect() error {
+	return nil
+}
+
+//	Drop the shadow tables along with the table.
+func (p *fts5Table) Destroy() error {
+	azSuffix := []string{ "idx", "docsize", "config" }
+	if p.eContent == fts5ContentNormal {
+		azSuffix = append(azSuffix, "content")
+	}
+	for _, zSuffix := range azSuffix {
+		if err := p.exec(fmt.Sprintf("DROP TABLE IF EXISTS %v", p.shadow(zSuffix)), nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (pCur *fts5Cursor) Filter(idxNum int, idxStr string, args []interface{}) (err error) {
+	p := pCur.pTab
+	*pCur = fts5Cursor{ pTab: p, iId: pCur.iId }
+	var aRowid []int64
+	hasRowid := false
+	for i, zArg := range strings.Fields(idxStr) {
+		if zArg == "r" {
+			iRowid, ok := fts5Rowid(args[i])
+			if !ok {
+				return
+			}
+			aRowid, hasRowid = []int64{ iRowid }, true
+			continue
+		}
+		if args[i] == nil {
+			return
+		}
+		pExpr, aPhrase, err := p.parseQuery(fts5Text(args[i]))
+		if err != nil {
+			return err
+		}
+		if zArg != "m" {
+			iCol, _ := strconv.Atoi(zArg[1:])
+			pExpr.restrict([]int{ iCol })
+		}
+		pCur.pExpr = pCur.pExpr.and(pExpr)
+		pCur.aPhrase = append(pCur.aPhrase, aPhrase...)
+	}
+	switch {
+	case pCur.pExpr != nil:
+		for _, pPhrase := range pCur.aPhrase {
+			if err = p.loadPhrase(pPhrase); err != nil {
+				return
+			}
+		}
+		if pCur.nDoc, pCur.aTotal, err = p.totals(); err != nil {
+			return
+		}
+		aRow := pCur.pExpr.rows()
+		if hasRowid {
+			if !aRow[aRowid[0]] {
+				aRowid = nil
+			}
+		} else {
+			for iRowid := range aRow {
+				aRowid = append(aRowid, iRowid)
+			}
+			sort.Slice(aRowid, func(i, j int) bool { return aRowid[i] < aRowid[j] })
+		}
+	case hasRowid:
+		zSql := fmt.Sprintf("SELECT id FROM %v WHERE id = ?", p.shadow("docsize"))
+		iRowid := aRowid[0]
+		aRowid = nil
+		err = p.exec(zSql, []interface{}{ iRowid }, func(pStmt *sqlite3_stmt) { aRowid = append(aRowid, iRowid) })
+	default:
+		zSql := fmt.Sprintf("SELECT id FROM %v ORDER BY id", p.shadow("docsize"))
+		err = p.exec(zSql, nil, func(pStmt *sqlite3_stmt) { aRowid = append(aRowid, sqlite3_column_int64(pStmt, 0)) })
+	}
+	if idxNum & fts5Desc != 0 {
+		for i, j := 0, len(aRowid) - 1; i < j; i, j = i + 1, j - 1 {
+			aRowid[i], aRowid[j] = aRowid[j], aRowid[i]
+		}
+	}
+	pCur.aRowid = aRowid
+	return
+}
+
+func (pCur *fts5Cursor) Next() error {
+	pCur.i++
+	pCur.aValue, pCur.aSize = nil, nil
+	return nil
+}
+
+func (pCur *fts5Cursor) Eof() bool {
+	return pCur.i >= len(pCur.aRowid)
+}
+
+//	Return the values of the columns of the current row, reading them the first time they are needed.
+func (pCur *fts5Cursor) document() ([]interface{}, error) {
+	if pCur.aValue == nil {
+		aValue, _, err := pCur.pTab.document(pCur.aRowid[pCur.i])
+		if err != nil {
+			return nil, err
+		}
+		if aValue == nil {
+			aValue = make([]interface{}, len(pCur.pTab.azCol))
+		}
+		pCur.aValue = aValue
+	}
+	return pCur.aValue, nil
+}
+
+func (pCur *fts5Cursor) Column(i int) (interface{}, error) {
+	nCol := len(pCur.pTab.azCol)
+	switch {
+	case i < nCol:
+		aValue, err := pCur.document()
+		if err != nil {
+			return nil, err
+		}
+		return aValue[i], nil
+	case i == nCol:
+		return pCur.iId, nil
+	case pCur.pExpr != nil:
+		return pCur.bm25(nil)
+	}
+	return nil, nil
+}
+
+func (pCur *fts5Cursor) Rowid() (int64, error) {
+	return pCur.aRowid[pCur.i], nil
+}
+
+func (pCur *fts5Cursor) Close() error {
+	delete(pCur.pTab.pModule.aCursor, pCur.iId)
+	return nil
+}
+
+//	Register the fts5 module and its auxiliary functions with connection db. This is called when the connection is opened.
+func (db *sqlite3) Fts5Init() int {
+	m := &fts5Module{ db: db, aCursor: make(map[int64]*fts5Cursor) }
+	for _, err := range []error{
+		db.CreateModule("fts5", m),
+		db.RegisterFunc("bm25", m.bm25),
+		db.RegisterFunc("highlight", m.highlight),
+		db.RegisterFunc("snippet", m.snippet),
+	} {
+		if e, ok := err.(*Error); ok {
+			return e.Code
+		} else if err != nil {
+			return SQLITE_ERROR
+		}
+	}
+	return SQLITE_OK
+}
@@ -0,0 +1,257 @@
+import (
+	"fmt"
+	"math"
+)
+
+//	This file implements the auxiliary functions of the fts5 module. Each takes the hidden column named after the table as its first
+//	argument, which identifies the cursor of the row the function is called for:
+//
+//		bm25(ft, w0, w1, ...)									The relevance of the row to the full-text query, as a negative
+//																number so that the best matches sort first. Instances of the
+//																query in column i count w[i] times, and once if w[i] is not given.
+//		highlight(ft, col, open, close)							The text of column col with each instance of a phrase of the
+//																query between open and close.
+//		snippet(ft, col, open, close, ellipsis, ntoken)			A fragment of at most ntoken tokens of column col, or of the column
+//																that gives the best fragment if col is negative, highlighted like
+//																highlight() and marked with ellipsis where text was left out.
+//
+//	bm25() computes the Okapi BM25 score of the row, summed over the phrases of the query:
+//
+//		IDF(phrase) * f * (k1 + 1) / (f + k1 * (1 - b + b * D / avgdl))
+//
+//	where f is the weighted number of instances of the phrase in the row, D the number of tokens in the row and avgdl the average
+//	number of tokens in a row. IDF is log((N - n + 0.5) / (n + 0.5)) for a table of N rows of which n contain the phrase, and at least
+//	1e-6. k1 is 1.2 and b 0.75. The hidden rank column is bm25() with no weights.
+
+const (
+	fts5K1 = 1.2
+	fts5B = 0.75
+)
+
+//	A token of a column of the current row, as the auxiliary functions see it.
+type fts5Token struct {
+	iStart, iEnd	int						//	Byte offsets of the text of the token
+	isHit			bool					//	True if the token is part of an instance of a phrase of the query
+}
+
+//	Return the cursor identified by iCursor, which must be on a row. zFunc is the name of the auxiliary function, for the error.
+func (m *fts5Module) cursor(iCursor int64, zFunc string) (*fts5Cursor, error) {
+	if pCur := m.aCursor[iCursor]; pCur != nil && !pCur.Eof() {
+		return pCur, nil
+	}
+	return nil, fmt.Errorf("unable to use function %v in the requested context", zFunc)
+}
+
+func (m *fts5Module) bm25(iCursor int64, aWeight ...float64) (float64, error) {
+	pCur, err := m.cursor(iCursor, "bm25")
+	if err != nil {
+		return 0, err
+	}
+	return pCur.bm25(aWeight)
+}
+
+func (pCur *fts5Cursor) bm25(aWeight []float64) (float64, error) {
+	if len(pCur.aPhrase) == 0 {
+		return 0, nil
+	}
+	p := pCur.pTab
+	iRowid := pCur.aRowid[pCur.i]
+	if pCur.aSize == nil {
+		aSize, err := p.docsize(iRowid)
+		if err != nil {
+			return 0, err
+		}
+		pCur.aSize = aSize
+	}
+	nDoc := float64(pCur.nDoc)
+	D, nTotal := 0.0, 0.0
+	for iCol := range p.azCol {
+		D += float64(pCur.aSize[iCol])
+		nTotal += float64(pCur.aTotal[iCol])
+	}
+	avgdl := 1.0
+	if nDoc > 0 && nTotal > 0 {
+		avgdl = nTotal / nDoc
+	}
+	score := 0.0
+	for _, pPhrase := range pCur.aPhrase {
+		n := float64(len(pPhrase.aHit))
+		idf := math.Log((nDoc - n + 0.5) / (n + 0.5))
+		if idf <= 0 {
+			idf = 1e-6
+		}
+		f := 0.0
+		for _, pos := range pPhrase.aHit[iRowid] {
+			if pos.iCol < len(aWeight) {
+				f += aWeight[pos.iCol]
+			} else {
+				f++
+			}
+		}
+		score += idf * f * (fts5K1 + 1) / (f + fts5K1 * (1 - fts5B + fts5B * D / avgdl))
+	}
+	return -score, nil
+}
+
+//	Return the text of column iCol of the current row, or ok false if it is NULL, and its tokens.
+func (pCur *fts5Cursor) tokens(iCol int) (zText string, aToken []fts5Token, ok bool, err error) {
+	aValue, err := pCur.document()
+	if err != nil || aValue[iCol] == nil {
+		return
+	}
+	zText, ok = fts5Text(aValue[iCol]), true
+	//	aToken holds a token for each position. A position that has synonyms covers the text of all of them, and one that is skipped
+	//	is an empty token.
+	err = tokenizeText(pCur.pTab.pTokenizer, zText, func(tok TextToken) {
+		for len(aToken) <= tok.Position {
+			aToken = append(aToken, fts5Token{ iStart: tok.Start, iEnd: tok.Start })
+		}
+		pToken := &aToken[tok.Position]
+		if pToken.iStart == pToken.iEnd {
+			pToken.iStart = tok.Start
+		}
+		if tok.End > pToken.iEnd {
+			pToken.iEnd = tok.End
+		}
+	})
+	if err != nil {
+		return
+	}
+	iRowid := pCur.aRowid[pCur.i]
+	for _, pPhrase := range pCur.aPhrase {
+		for _, pos := range pPhrase.aHit[iRowid] {
+			if pos.iCol != iCol {
+				continue
+			}
+			for i := pos.iPos; i < pos.iPos + len(pPhrase.azTerm) && i < len(aToken); i++ {
+				aToken[i].isHit = true
+			}
+		}
+	}
+	return
+}
+
+//	Return zText[iStart:iEnd], which holds aToken, with each run of tokens that are hits between zOpen and zClose.
+func fts5Highlight(zText string, aToken []fts5Token, iStart, iEnd int, zOpen, zClose string) string {
+	var zOut []byte
+	for i := 0; i < len(aToken); i++ {
+		if !aToken[i].isHit {
+			continue
+		}
+		j := i
+		for j + 1 < len(aToken) && aToken[j + 1].isHit {
+			j++
+		}
+		//	The text of a token that stands for synonyms may overlap the tokens that follow it.
+		iFrom, iTo := aToken[i].iStart, aToken[j].iEnd
+		if iFrom < iStart {
+			iFrom = iStart
+		}
+		if iTo < iFrom {
+			iTo = iFrom
+		}
+		zOut = append(zOut, zText[iStart:iFrom]...)
+		zOut = append(zOut, zOpen...)
+		zOut = append(zOut, zText[iFrom:iTo]...)
+		zOut = append(zOut, zClose...)
+		iStart = iTo
+		i = j
+	}
+	if iEnd < iStart {
+		iEnd = iStart
+	}
+	return string(append(zOut, zText[iStart:iEnd]...))
+}
+
+func (m *fts5Module) highlight(iCursor int64, iCol int, zOpen, zClose string) (interface{}, error) {
+	pCur, err := m.cursor(iCursor, "highlight")
+	if err != nil {
+		return nil, err
+	}
+	if iCol < 0 || iCol >= len(pCur.pTab.azCol) {
+		return nil, &Error{ Code: SQLITE_RANGE }
+	}
+	zText, aToken, ok, err := pCur.tokens(iCol)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return fts5Highlight(zText, aToken, 0, len(zText), zOpen, zClose), nil
+}
+
+//	Choose the nToken tokens of aToken that make the best fragment: the one with the most hits, or the first of those. Return the
+//	index of its first token and the number of hits in it.
+func fts5BestFragment(aToken []fts5Token, nToken int) (iBest, nBest int) {
+	iLast := len(aToken) - nToken
+	if iLast < 0 {
+		iLast = 0
+	}
+	nBest = -1
+	for i := 0; i <= iLast; i++ {
+		if i > 0 && !aToken[i].isHit {
+			continue
+		}
+		n := 0
+		for j := i; j < i + nToken && j < len(aToken); j++ {
+			if aToken[j].isHit {
+				n++
+			}
+		}
+		if n > nBest {
+			iBest, nBest = i, n
+		}
+	}
+	return
+}
+
+func (m *fts5Module) snippet(iCursor int64, iCol int, zOpen, zClose, zEllipsis string, nToken int) (interface{}, error) {
+	pCur, err := m.cursor(iCursor, "snippet")
+	if err != nil {
+		return nil, err
+	}
+	if nToken < 1 {
+		nToken = 1
+	} else if nToken > 64 {
+		nToken = 64
+	}
+	aCol := []int{ iCol }
+	if iCol < 0 || iCol >= len(pCur.pTab.azCol) {
+		aCol = nil
+		for i := range pCur.pTab.azCol {
+			aCol = append(aCol, i)
+		}
+	}
+	var zSnippet interface{}
+	nBest := -1
+	for _, i := range aCol {
+		zText, aToken, ok, err := pCur.tokens(i)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		iFirst, n := fts5BestFragment(aToken, nToken)
+		if n <= nBest {
+			continue
+		}
+		nBest = n
+		if len(aToken) == 0 {
+			zSnippet = zText
+			continue
+		}
+		iLast := iFirst + nToken
+		if iLast > len(aToken) {
+			iLast = len(aToken)
+		}
+		iStart, iEnd := 0, len(zText)
+		zPrefix, zSuffix := "", ""
+		if iFirst > 0 {
+			iStart, zPrefix = aToken[iFirst].iStart, zEllipsis
+		}
+		if iLast < len(aToken) {
+			iEnd, zSuffix = aToken[iLast - 1].iEnd, zEllipsis
+		}
+		zSnippet = zPrefix + fts5Highlight(zText, aToken[iFirst:iLast], iStart, iEnd, zOpen, zClose) + zSuffix
+	}
+	return zSnippet, nil
+}
@@ -0,0 +1,573 @@
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//	This file implements the full-text query language of the fts5 module:
+//
+//		one two					Rows that contain both terms. AND may be written between them.
+//		one OR two				Rows that contain either term
+//		one NOT two				Rows that contain one but not two
+//		"one two"				Rows that contain the phrase: the two terms one after the other. one + two is the same phrase.
+//		on*						Rows that contain a term that starts with "on". The last term of a phrase may be a prefix.
+//		NEAR(one "two three", 5)	Rows in which the phrases appear in the same column with at most 5 tokens between them. The
+//								default is 10.
+//		title : one				Rows with one in the title column. {title body} : one searches two columns and -title : one all
+//								columns but title. A column filter applies to the expression that follows it.
+//		(one OR two) three		Parentheses group expressions.
+//
+//	NOT binds tighter than AND, which binds tighter than OR. Barewords are made of letters, digits, "_" and characters outside the
+//	ASCII range, and anything else must be quoted. Both are split into terms by the tokenizer of the table.
+//
+//	A query is evaluated by reading the positions of the terms of each phrase from the %_idx table, finding the instances of the
+//	phrase among them, and combining the sets of rows that contain the phrases.
+
+//	Types of fts5Expr node.
+const (
+	fts5ExprPhrase = iota					//	A phrase, in aPhrase[0]
+	fts5ExprNear							//	A NEAR group of the phrases in aPhrase
+	fts5ExprAnd
+	fts5ExprOr
+	fts5ExprNot
+)
+
+//	An fts5Expr is a node of a parsed full-text query.
+type fts5Expr struct {
+	eType			int
+	pLeft			*fts5Expr				//	Operands of AND, OR and NOT
+	pRight			*fts5Expr
+	aPhrase			[]*fts5Phrase			//	Phrases of a phrase or NEAR node
+	nNear			int						//	Greatest number of tokens between the phrases of a NEAR group
+}
+
+//	An fts5Phrase is a sequence of terms that must appear one after the other.
+type fts5Phrase struct {
+	azTerm			[]string
+	isPrefix		bool					//	True if the last term is a prefix
+	aCol			[]int					//	The columns the phrase is looked for in, or nil for all of them
+	aHit			map[int64][]fts5Pos		//	The position of the first term of each instance of the phrase, by rowid
+}
+
+//	Tokens of the query language.
+const (
+	fts5TokEof = iota
+	fts5TokString
+	fts5TokBareword
+	fts5TokLp
+	fts5TokRp
+	fts5TokLcp
+	fts5TokRcp
+	fts5TokColon
+	fts5TokStar
+	fts5TokPlus
+	fts5TokComma
+	fts5TokMinus
+	fts5TokAnd
+	fts5TokOr
+	fts5TokNot
+)
+
+//	An fts5Parser parses a full-text query.
+type fts5Parser struct {
+	pTab			*fts5Table
+	zQuery			string
+	iNext			int						//	Offset of the first byte after the current token
+	eTok			int						//	fts5Tok code of the current token
+	zTok			string					//	Text of the current token, dequoted if it is a string
+	iTok			int						//	Offset of the current token
+	aPhrase			[]*fts5Phrase			//	The phrases of the query, in order
+}
+
+//	Parse zQuery, returning the expression and its phrases.
+func (p *fts5Table) parseQuery(zQuery string) (pExpr *fts5Expr, aPhrase []*fts5Phrase, err error) {
+	pParse := &fts5Parser{ pTab: p, zQuery: zQuery }
+	if err = pParse.next(); err != nil {
+		return
+	}
+	if pExpr, err = pParse.or(); err != nil {
+		return
+	}
+	if pParse.eTok != fts5TokEof {
+		return nil, nil, pParse.syntaxError()
+	}
+	return pExpr, pParse.aPhrase, nil
+}
+
+func (pParse *fts5Parser) syntaxError() error {
+	zNear := pParse.zTok
+	if pParse.eTok != fts5TokString {
+		zNear = pParse.zQuery[pParse.iTok:pParse.iNext]
+	}
+	return fmt.Errorf("fts5: syntax error near \"%v\"", zNear)
+}
+
+func fts5IsBareword(c byte) bool {
+	return c >= 0x80 || c == '_' || c == 0x1a || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+//	Move to the next token of the query.
+func (pParse *fts5Parser) next() error {
+	z := pParse.zQuery
+	i := pParse.iNext
+	for i < len(z) && (z[i] == ' ' || z[i] == '\t' || z[i] == '\n' || z[i] == '\r') {
+		i++
+	}
+	pParse.iTok = i
+	pParse.zTok = ""
+	if i == len(z) {
+		pParse.eTok, pParse.iNext = fts5TokEof, i
+		return nil
+	}
+	if eTok, ok := map[byte]int{ '(': fts5TokLp, ')': fts5TokRp, '{': fts5TokLcp, '}': fts5TokRcp, ':': fts5TokColon, '*': fts5TokStar, '+': fts5TokPlus, ',': fts5TokComma, '-': fts5TokMinus }[z[i]]; ok {
+		pParse.eTok, pParse.iNext = eTok, i + 1
+		return nil
+	}
+	if z[i] == '"' {
+		var zTok []byte
+		for i++; ; i++ {
+			if i == len(z) {
+				pParse.iNext = i
+				return fmt.Errorf("fts5: syntax error near \"%v\"", z[pParse.iTok:])
+			}
+			if z[i] == '"' {
+				if i + 1 < len(z) && z[i + 1] == '"' {
+					i++
+				} else {
+					break
+				}
+			}
+			zTok = append(zTok, z[i])
+		}
+		pParse.eTok, pParse.zTok, pParse.iNext = fts5TokString, string(zTok), i + 1
+		return nil
+	}
+	if !fts5IsBareword(z[i]) {
+		pParse.eTok, pParse.iNext = fts5TokEof, i + 1
+		return pParse.syntaxError()
+	}
+	for i < len(z) && fts5IsBareword(z[i]) {
+		i++
+	}
+	pParse.eTok, pParse.zTok, pParse.iNext = fts5TokBareword, z[pParse.iTok:i], i
+	switch pParse.zTok {
+	case "AND":
+		pParse.eTok = fts5TokAnd
+	case "OR":
+		pParse.eTok = fts5TokOr
+	case "NOT":
+		pParse.eTok = fts5TokNot
+	}
+	return nil
+}
+
+//	Return the first character after the current token that is not white-space, or 0 at the end of the query.
+func (pParse *fts5Parser) peek() byte {
+	z := strings.TrimLeft(pParse.zQuery[pParse.iNext:], " \t\n\r")
+	if z == "" {
+		return 0
+	}
+	return z[0]
+}
+
+//	Check that the current token is of type eTok and move past it.
+func (pParse *fts5Parser) expect(eTok int) error {
+	if pParse.eTok != eTok {
+		return pParse.syntaxError()
+	}
+	return pParse.next()
+}
+
+//	or := and ( "OR" and )*
+func (pParse *fts5Parser) or() (pExpr *fts5Expr, err error) {
+	if pExpr, err = pParse.and(); err != nil {
+		return
+	}
+	for pParse.eTok == fts5TokOr {
+		if err = pParse.next(); err != nil {
+			return
+		}
+		pRight, err := pParse.and()
+		if err != nil {
+			return nil, err
+		}
+		pExpr = &fts5Expr{ eType: fts5ExprOr, pLeft: pExpr, pRight: pRight }
+	}
+	return
+}
+
+//	and := not ( ["AND"] not )*
+func (pParse *fts5Parser) and() (pExpr *fts5Expr, err error) {
+	if pExpr, err = pParse.not(); err != nil {
+		return
+	}
+	for {
+		switch pParse.eTok {
+		case fts5TokAnd:
+			if err = pParse.next(); err != nil {
+				return
+			}
+		case fts5TokString, fts5TokBareword, fts5TokLp, fts5TokLcp, fts5TokMinus:
+		default:
+			return
+		}
+		pRight, err := pParse.not()
+		if err != nil {
+			return nil, err
+		}
+		pExpr = &fts5Expr{ eType: fts5ExprAnd, pLeft: pExpr, pRight: pRight }
+	}
+}
+
+//	not := primary ( "NOT" primary )*
+func (pParse *fts5Parser) not() (pExpr *fts5Expr, err error) {
+	if pExpr, err = pParse.primary(); err != nil {
+		return
+	}
+	for pParse.eTok == fts5TokNot {
+		if err = pParse.next(); err != nil {
+			return
+		}
+		pRight, err := pParse.primary()
+		if err != nil {
+			return nil, err
+		}
+		pExpr = &fts5Expr{ eType: fts5ExprNot, pLeft: pExpr, pRight: pRight }
+	}
+	return
+}
+
+//	primary := "(" or ")" | colset ":" primary | "NEAR" "(" phrase+ [ "," number ] ")" | phrase
+func (pParse *fts5Parser) primary() (pExpr *fts5Expr, err error) {
+	switch {
+	case pParse.eTok == fts5TokLp:
+		if err = pParse.next(); err != nil {
+			return
+		}
+		if pExpr, err = pParse.or(); err != nil {
+			return
+		}
+		return pExpr, pParse.expect(fts5TokRp)
+	case pParse.eTok == fts5TokMinus || pParse.eTok == fts5TokLcp || (pParse.eTok == fts5TokBareword && pParse.peek() == ':'):
+		aCol, err := pParse.colset()
+		if err != nil {
+			return nil, err
+		}
+		if pExpr, err = pParse.primary(); err != nil {
+			return nil, err
+		}
+		pExpr.restrict(aCol)
+		return pExpr, nil
+	case pParse.eTok == fts5TokBareword && pParse.zTok == "NEAR" && pParse.peek() == '(':
+		return pParse.near()
+	}
+	pPhrase, err := pParse.phrase()
+	if err != nil {
+		return
+	}
+	return &fts5Expr{ eType: fts5ExprPhrase, aPhrase: []*fts5Phrase{ pPhrase } }, nil
+}
+
+//	colset := [ "-" ] ( name | "{" name* "}" ) ":"
+func (pParse *fts5Parser) colset() (aCol []int, err error) {
+	isNot := pParse.eTok == fts5TokMinus
+	if isNot {
+		if err = pParse.next(); err != nil {
+			return
+		}
+	}
+	var azName []string
+	if pParse.eTok == fts5TokLcp {
+		if err = pParse.next(); err != nil {
+			return
+		}
+		for pParse.eTok == fts5TokBareword || pParse.eTok == fts5TokString {
+			azName = append(azName, pParse.zTok)
+			if err = pParse.next(); err != nil {
+				return
+			}
+		}
+		if err = pParse.expect(fts5TokRcp); err != nil {
+			return
+		}
+	} else if pParse.eTok == fts5TokBareword || pParse.eTok == fts5TokString {
+		azName = append(azName, pParse.zTok)
+		if err = pParse.next(); err != nil {
+			return
+		}
+	} else {
+		return nil, pParse.syntaxError()
+	}
+	if err = pParse.expect(fts5TokColon); err != nil {
+		return
+	}
+	abCol := make([]bool, len(pParse.pTab.azCol))
+	for _, zName := range azName {
+		iCol := 0
+		for iCol < len(abCol) && !strings.EqualFold(pParse.pTab.azCol[iCol], zName) {
+			iCol++
+		}
+		if iCol == len(abCol) {
+			return nil, fmt.Errorf("fts5: no such column: %v", zName)
+		}
+		abCol[iCol] = true
+	}
+	for iCol, b := range abCol {
+		if b != isNot {
+			aCol = append(aCol, iCol)
+		}
+	}
+	return aCol, nil
+}
+
+//	NEAR(phrase phrase ... [, number])
+func (pParse *fts5Parser) near() (pExpr *fts5Expr, err error) {
+	pExpr = &fts5Expr{ eType: fts5ExprNear, nNear: 10 }
+	if err = pParse.next(); err != nil {
+		return
+	}
+	if err = pParse.expect(fts5TokLp); err != nil {
+		return
+	}
+	for pParse.eTok == fts5TokString || pParse.eTok == fts5TokBareword {
+		pPhrase, err := pParse.phrase()
+		if err != nil {
+			return nil, err
+		}
+		pExpr.aPhrase = append(pExpr.aPhrase, pPhrase)
+	}
+	if len(pExpr.aPhrase) == 0 {
+		return nil, pParse.syntaxError()
+	}
+	if pParse.eTok == fts5TokComma {
+		if err = pParse.next(); err != nil {
+			return
+		}
+		n, e := strconv.Atoi(pParse.zTok)
+		if pParse.eTok != fts5TokBareword || e != nil || n < 0 {
+			return nil, pParse.syntaxError()
+		}
+		pExpr.nNear = n
+		if err = pParse.next(); err != nil {
+			return
+		}
+	}
+	return pExpr, pParse.expect(fts5TokRp)
+}
+
+//	phrase := ( string | bareword ) ( "+" ( string | bareword ) )* [ "*" ]
+func (pParse *fts5Parser) phrase() (pPhrase *fts5Phrase, err error) {
+	pPhrase = new(fts5Phrase)
+	for {
+		if pParse.eTok != fts5TokString && pParse.eTok != fts5TokBareword {
+			return nil, pParse.syntaxError()
+		}
+		//	Of tokens at the same position, the first is the term of the phrase. The others are synonyms of it, which are indexed with
+		//	it wherever it appears in a document.
+		iPos := -1
+		err = tokenizeText(pParse.pTab.pTokenizer, pParse.zTok, func(tok TextToken) {
+			if tok.Position != iPos {
+				pPhrase.azTerm = append(pPhrase.azTerm, tok.Text)
+				iPos = tok.Position
+			}
+		})
+		if err != nil {
+			return
+		}
+		if err = pParse.next(); err != nil {
+			return
+		}
+		if pParse.eTok == fts5TokStar {
+			pPhrase.isPrefix = true
+			if err = pParse.next(); err != nil {
+				return
+			}
+			break
+		}
+		if pParse.eTok != fts5TokPlus {
+			break
+		}
+		if err = pParse.next(); err != nil {
+			return
+		}
+	}
+	pParse.aPhrase = append(pParse.aPhrase, pPhrase)
+	return
+}
+
+//	Restrict every phrase of pExpr to the columns in aCol, which is in increasing order.
+func (pExpr *fts5Expr) restrict(aCol []int) {
+	if pExpr == nil {
+		return
+	}
+	for _, pPhrase := range pExpr.aPhrase {
+		if pPhrase.aCol == nil {
+			pPhrase.aCol = append([]int{}, aCol...)
+			continue
+		}
+		var aNew []int
+		for _, iCol := range pPhrase.aCol {
+			if i := sort.SearchInts(aCol, iCol); i < len(aCol) && aCol[i] == iCol {
+				aNew = append(aNew, iCol)
+			}
+		}
+		pPhrase.aCol = append([]int{}, aNew...)
+	}
+	pExpr.pLeft.restrict(aCol)
+	pExpr.pRight.restrict(aCol)
+}
+
+//	Return the expression that matches the rows that match both pExpr and pRight. pExpr may be nil.
+func (pExpr *fts5Expr) and(pRight *fts5Expr) *fts5Expr {
+	if pExpr == nil {
+		return pRight
+	}
+	return &fts5Expr{ eType: fts5ExprAnd, pLeft: pExpr, pRight: pRight }
+}
+
+//	Return the positions of term zTerm, or of every term that starts with zTerm if isPrefix is true, by rowid.
+func (p *fts5Table) loadTerm(zTerm string, isPrefix bool) (aHit map[int64][]fts5Pos, err error) {
+	aHit = make(map[int64][]fts5Pos)
+	zSql := fmt.Sprintf("SELECT id, pos FROM %v WHERE term = ?", p.shadow("idx"))
+	args := []interface{}{ zTerm }
+	if isPrefix {
+		//	The terms that start with zTerm sort from zTerm up to zTerm with its last byte that is not 0xff incremented.
+		zSql = fmt.Sprintf("SELECT id, pos FROM %v WHERE term >= ?", p.shadow("idx"))
+		zEnd := []byte(zTerm)
+		for len(zEnd) > 0 && zEnd[len(zEnd) - 1] == 0xff {
+			zEnd = zEnd[:len(zEnd) - 1]
+		}
+		if len(zEnd) > 0 {
+			zEnd[len(zEnd) - 1]++
+			zSql += " AND term < ?"
+			args = append(args, string(zEnd))
+		}
+	}
+	err = p.exec(zSql, args, func(pStmt *sqlite3_stmt) {
+		iRowid := sqlite3_column_int64(pStmt, 0)
+		aHit[iRowid] = append(aHit[iRowid], fts5GetPos(sqlite3_column_blob(pStmt, 1))...)
+	})
+	return
+}
+
+//	Find the instances of pPhrase in the documents of p.
+func (p *fts5Table) loadPhrase(pPhrase *fts5Phrase) error {
+	pPhrase.aHit = make(map[int64][]fts5Pos)
+	nTerm := len(pPhrase.azTerm)
+	if nTerm == 0 {
+		return nil
+	}
+	aTerm := make([]map[int64][]fts5Pos, nTerm)
+	for i, zTerm := range pPhrase.azTerm {
+		var err error
+		if aTerm[i], err = p.loadTerm(zTerm, pPhrase.isPrefix && i == nTerm - 1); err != nil {
+			return err
+		}
+	}
+	hasCol := func(iCol int) bool {
+		if pPhrase.aCol == nil {
+			return true
+		}
+		i := sort.SearchInts(pPhrase.aCol, iCol)
+		return i < len(pPhrase.aCol) && pPhrase.aCol[i] == iCol
+	}
+	for iRowid, aPos := range aTerm[0] {
+		aSet := make([]map[fts5Pos]bool, nTerm)
+		for i := 1; i < nTerm; i++ {
+			aSet[i] = make(map[fts5Pos]bool)
+			for _, pos := range aTerm[i][iRowid] {
+				aSet[i][pos] = true
+			}
+		}
+		var aHit []fts5Pos
+		for _, pos := range aPos {
+			if !hasCol(pos.iCol) {
+				continue
+			}
+			i := 1
+			for i < nTerm && aSet[i][fts5Pos{ pos.iCol, pos.iPos + i }] {
+				i++
+			}
+			if i == nTerm {
+				aHit = append(aHit, pos)
+			}
+		}
+		if len(aHit) > 0 {
+			sort.Slice(aHit, func(i, j int) bool {
+				return aHit[i].iCol < aHit[j].iCol || (aHit[i].iCol == aHit[j].iCol && aHit[i].iPos < aHit[j].iPos)
+			})
+			pPhrase.aHit[iRowid] = aHit
+		}
+	}
+	return nil
+}
+
+//	Return the set of rows that match pExpr. The phrases of pExpr must have been loaded.
+func (pExpr *fts5Expr) rows() map[int64]bool {
+	switch pExpr.eType {
+	case fts5ExprAnd, fts5ExprOr, fts5ExprNot:
+		aLeft, aRight := pExpr.pLeft.rows(), pExpr.pRight.rows()
+		if pExpr.eType == fts5ExprOr {
+			for iRowid := range aRight {
+				aLeft[iRowid] = true
+			}
+			return aLeft
+		}
+		for iRowid := range aLeft {
+			if aRight[iRowid] == (pExpr.eType == fts5ExprNot) {
+				delete(aLeft, iRowid)
+			}
+		}
+		return aLeft
+	}
+	aRow := make(map[int64]bool)
+	for iRowid := range pExpr.aPhrase[0].aHit {
+		if pExpr.isNear(iRowid) {
+			aRow[iRowid] = true
+		}
+	}
+	return aRow
+}
+
+//	Return true if every phrase of a phrase or NEAR node occurs in row iRowid and, for a NEAR group, if there is an instance of each
+//	in the same column with at most nNear tokens that are not part of the instances between the first and the last.
+func (pExpr *fts5Expr) isNear(iRowid int64) bool {
+	for _, pPhrase := range pExpr.aPhrase {
+		if len(pPhrase.aHit[iRowid]) == 0 {
+			return false
+		}
+	}
+	if pExpr.eType != fts5ExprNear {
+		return true
+	}
+	aPos := make([]fts5Pos, len(pExpr.aPhrase))
+	var xTry func(i int) bool
+	xTry = func(i int) bool {
+		if i == len(aPos) {
+			iFirst, iLast, nToken := aPos[0].iPos, 0, 0
+			for j, pos := range aPos {
+				n := len(pExpr.aPhrase[j].azTerm)
+				if pos.iPos < iFirst {
+					iFirst = pos.iPos
+				}
+				if pos.iPos + n > iLast {
+					iLast = pos.iPos + n
+				}
+				nToken += n
+			}
+			return iLast - iFirst - nToken <= pExpr.nNear
+		}
+		for _, pos := range pExpr.aPhrase[i].aHit[iRowid] {
+			if i > 0 && pos.iCol != aPos[0].iCol {
+				continue
+			}
+			aPos[i] = pos
+			if xTry(i + 1) {
+				return true
+			}
+		}
+		return false
+	}
+	return xTry(0)
+}
@@ -0,0 +1,152 @@
+import (
+	"database/sql"
+	"strings"
+	"testing"
+)
+
+//	Open a database with an fts5 table of recipes, whose rows have distinct bm25() scores for the terms the tests search for.
+func testOpenFts5(t *testing.T) *sql.DB {
+	t.Helper()
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE VIRTUAL TABLE docs USING fts5(title, body)")
+	testExec(t, db, `INSERT INTO docs(rowid, title, body) VALUES
+		(1, 'apple pie', 'a recipe for apple pie with cinnamon'),
+		(2, 'banana bread', 'bread made with ripe banana and a little apple'),
+		(3, 'fruit salad', 'apple banana orange grape'),
+		(4, 'cherry tart', 'no fruit named here except cherry')`)
+	return db
+}
+
+func TestFts5Rank(t *testing.T) {
+	db := testOpenFts5(t)
+	testQueryIs(t, db, "1\n3\n2", "SELECT rowid FROM docs WHERE docs MATCH 'apple' ORDER BY rank")
+	testQueryIs(t, db, "1\n3\n2", "SELECT rowid FROM docs WHERE docs MATCH 'apple' ORDER BY bm25(docs)")
+	testQueryIs(t, db, "3", "SELECT count(*) FROM docs WHERE docs MATCH 'apple' AND rank < 0 AND rank = bm25(docs)")
+
+	//	Weights: a hit in the title of row 2 ranks it first unless hits in the title count for nothing.
+	testQueryIs(t, db, "2\n3", "SELECT rowid FROM docs WHERE docs MATCH 'banana' ORDER BY rank")
+	testQueryIs(t, db, "3\n2", "SELECT rowid FROM docs WHERE docs MATCH 'banana' ORDER BY bm25(docs, 0.0, 1.0)")
+
+	//	The rowid order of a full-text query, and the scores of a query that is not full-text.
+	testQueryIs(t, db, "3\n2\n1", "SELECT rowid FROM docs WHERE docs MATCH 'apple' ORDER BY rowid DESC")
+	testQueryIs(t, db, "4|NULL|0", "SELECT count(*), max(rank), max(bm25(docs)) FROM docs")
+	if _, err := db.Exec("SELECT bm25(5)"); err == nil || !strings.Contains(err.Error(), "unable to use function bm25") {
+		t.Errorf("bm25() without a cursor: %v", err)
+	}
+}
+
+func TestFts5Query(t *testing.T) {
+	db := testOpenFts5(t)
+	for zQuery, want := range map[string]string{
+		//	Column filters.
+		"title : apple":				"1",
+		"body : banana":				"2\n3",
+		"{title body} : cherry":		"4",
+		"-title : banana":				"2\n3",
+		"-body : pie":					"1",
+		"title : (fruit OR pie)":		"1\n3",
+
+		//	Phrases and prefixes.
+		`"apple pie"`:					"1",
+		"apple + pie":					"1",
+		`"pie apple"`:					"",
+		`"ripe banana"`:				"2",
+		"ban*":							"2\n3",
+		`"apple p"*`:					"1",
+		"ch*":							"4",
+
+		//	NEAR groups, with the default distance and with a distance.
+		"NEAR(banana apple)":			"2\n3",
+		"NEAR(banana apple, 2)":		"3",
+		`NEAR("apple pie" cinnamon, 1)`:	"1",
+		`NEAR("apple pie" cinnamon, 0)`:	"",
+
+		//	Boolean operators.
+		"apple NOT banana":				"1",
+		"cherry OR pie":				"1\n4",
+		"apple AND banana":				"2\n3",
+		"(cherry OR grape) fruit":		"3\n4",
+		"APPLE":						"1\n2\n3",
+	} {
+		testQueryIs(t, db, want, "SELECT rowid FROM docs WHERE docs MATCH ? ORDER BY rowid", zQuery)
+	}
+
+	//	MATCH on a column, and a MATCH combined with a rowid lookup.
+	testQueryIs(t, db, "2", "SELECT rowid FROM docs WHERE title MATCH 'banana'")
+	testQueryIs(t, db, "3", "SELECT rowid FROM docs WHERE docs MATCH 'apple' AND rowid = 3")
+	testQueryIs(t, db, "", "SELECT rowid FROM docs WHERE docs MATCH 'cherry' AND rowid = 3")
+
+	for zQuery, want := range map[string]string{
+		"apple AND":		"fts5: syntax error near \"\"",
+		`"apple`:			"fts5: syntax error near \"\"apple\"",
+		"apple & pie":		"fts5: syntax error near \"&\"",
+		"nope : apple":		"fts5: no such column: nope",
+		"NEAR(apple, x)":	"fts5: syntax error near \"x\"",
+	} {
+		if _, err := db.Exec("SELECT * FROM docs WHERE docs MATCH ?", zQuery); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: %v, want %q", zQuery, err, want)
+		}
+	}
+}
+
+func TestFts5Highlight(t *testing.T) {
+	db := testOpenFts5(t)
+	testQueryIs(t, db, "[apple pie]|a recipe for [apple pie] with cinnamon",
+		"SELECT highlight(docs, 0, '[', ']'), highlight(docs, 1, '[', ']') FROM docs WHERE docs MATCH 'apple pie'")
+	testQueryIs(t, db, "banana bread|bread made with <ripe banana> and a little apple",
+		`SELECT highlight(docs, 0, '<', '>'), highlight(docs, 1, '<', '>') FROM docs WHERE docs MATCH '"ripe banana"'`)
+	testQueryIs(t, db, "apple pie|a recipe for apple pie with [cinnamon]",
+		"SELECT highlight(docs, 0, '[', ']'), highlight(docs, 1, '[', ']') FROM docs WHERE docs MATCH 'body : cin*'")
+
+	//	snippet() starts the fragment at the first hit of the best one, and marks text left out at either end.
+	testQueryIs(t, db, "...[ripe] banana and...", "SELECT snippet(docs, 1, '[', ']', '...', 3) FROM docs WHERE docs MATCH 'ripe'")
+	testQueryIs(t, db, "a [recipe] for apple...", "SELECT snippet(docs, 1, '[', ']', '...', 4) FROM docs WHERE docs MATCH 'recipe'")
+	testQueryIs(t, db, "3|[fruit] salad\n4|no [fruit] named here...",
+		"SELECT rowid, snippet(docs, -1, '[', ']', '...', 4) FROM docs WHERE docs MATCH 'fruit' ORDER BY rowid")
+}
+
+func TestFts5Content(t *testing.T) {
+	db := testOpen(t, ":memory:")
+
+	//	An external content table, kept up to date by triggers.
+	testExec(t, db, "CREATE TABLE src(id INTEGER PRIMARY KEY, a, b, extra)")
+	testExec(t, db, "INSERT INTO src VALUES(1, 'red fish', 'blue fish', 'x'), (2, 'one fish', 'two fish', 'y')")
+	testExec(t, db, "CREATE VIRTUAL TABLE ext USING fts5(a, b, content = 'src', content_rowid = 'id')")
+	testExec(t, db, "INSERT INTO ext(ext) VALUES('rebuild')")
+	testExec(t, db, `CREATE TRIGGER src_ai AFTER INSERT ON src BEGIN
+		INSERT INTO ext(rowid, a, b) VALUES(new.id, new.a, new.b);
+	END`)
+	testExec(t, db, `CREATE TRIGGER src_ad AFTER DELETE ON src BEGIN
+		INSERT INTO ext(ext, rowid, a, b) VALUES('delete', old.id, old.a, old.b);
+	END`)
+	testQueryIs(t, db, "1|red fish|blue fish\n2|one fish|two fish", "SELECT rowid, a, b FROM ext WHERE ext MATCH 'fish' ORDER BY rowid")
+	testExec(t, db, "INSERT INTO src VALUES(3, 'old fish', 'new fish', 'z')")
+	testExec(t, db, "DELETE FROM src WHERE id = 1")
+	testQueryIs(t, db, "2|one\n3|old", "SELECT rowid, a FROM ext WHERE ext MATCH 'fish' ORDER BY rowid")
+	testQueryIs(t, db, "", "SELECT rowid FROM ext WHERE ext MATCH 'red OR blue'")
+	testQueryIs(t, db, "3|[new] fish", "SELECT rowid, highlight(ext, 1, '[', ']') FROM ext WHERE ext MATCH 'new'")
+
+	//	Special commands that only make sense for some kinds of table.
+	testExec(t, db, "CREATE VIRTUAL TABLE own USING fts5(a)")
+	for query, want := range map[string]string{
+		"INSERT INTO own(own, rowid, a) VALUES('delete', 1, 'x')":	"'delete' may not be used with a contentful fts5 table",
+		"INSERT INTO own(own) VALUES('delete-all')":				"'delete-all' may only be used with a contentless or external content fts5 table",
+		"INSERT INTO own(own) VALUES('optimize')":					"unknown special command: optimize",
+		"CREATE VIRTUAL TABLE bad USING fts5(a, content_rowid = 'id')":	"content_rowid may only be used with an external content table",
+		"CREATE VIRTUAL TABLE bad USING fts5(a, rank)":				"reserved fts5 column name: rank",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+
+	//	A contentless table indexes its documents but returns NULL for their columns.
+	testExec(t, db, "CREATE VIRTUAL TABLE bare USING fts5(a, content = '')")
+	testExec(t, db, "INSERT INTO bare(rowid, a) VALUES(7, 'only the index'), (8, 'nothing else')")
+	testQueryIs(t, db, "7|NULL", "SELECT rowid, a FROM bare WHERE bare MATCH 'index'")
+	testExec(t, db, "INSERT INTO bare(bare, rowid, a) VALUES('delete', 7, 'only the index')")
+	testQueryIs(t, db, "8", "SELECT rowid FROM bare WHERE bare MATCH 'only OR else'")
+	if _, err := db.Exec("DELETE FROM bare WHERE rowid = 8"); err == nil || !strings.Contains(err.Error(), "cannot DELETE from contentless fts5 table: bare") {
+		t.Errorf("DELETE from a contentless table: %v", err)
+	}
+}
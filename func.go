@@ -1171,6 +1171,26 @@ static void sumStep(sqlite3_context *context, int argc, sqlite3_value **argv){
     }
   }
 }
+
+//	The inverse of sumStep(), used when sum(), total() or avg() is a window function whose frame start moves forward.
+func sumInverse(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	assert( argc == 1 )
+	p := sqlite3_aggregate_context(context, sizeof(*p))
+	if t := argv[0].NumericType(); p != nil && t != SQLITE_NULL {
+		assert( p.cnt > 0 )
+		p.cnt--
+		if t == SQLITE_INTEGER && p.approx == 0 {
+			v := sqlite3_value_int64(argv[0])
+			p.rSum -= float64(v)
+			if p.overflow == 0 && sqlite3AddInt64(&p.iSum, -v) {
+				p.overflow = 1
+			}
+		} else {
+			p.rSum -= sqlite3_value_double(argv[0])
+		}
+	}
+}
+
 static void sumFinalize(sqlite3_context *context){
 	if p := sqlite3_aggregate_context(context, 0); p != nil && p.cnt > 0 {
 		switch {
@@ -1212,6 +1232,15 @@ static void countStep(sqlite3_context *context, int argc, sqlite3_value **argv){
     p.n++;
   }
 }   
+
+//	The inverse of countStep(), used when count() is a window function whose frame start moves forward.
+func countInverse(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	p := sqlite3_aggregate_context(context, sizeof(*p))
+	if (argc == 0 || sqlite3_value_type(argv[0]) != SQLITE_NULL) && p != nil {
+		p.n--
+	}
+}
+
 static void countFinalize(sqlite3_context *context){
   CountCtx *p;
   p = sqlite3_aggregate_context(context, 0);
@@ -1414,8 +1443,8 @@ int sqlite3IsLikeFunction(sqlite3 *db, Expr *pExpr, int *pIsNocase, char *aWc){
     FUNCTION2(coalesce,         -1, 0, 0, ifnullFunc,  SQLITE_FUNC_COALESCE),
     FUNCTION(hex,                1, 0, 0, hexFunc          ),
     FUNCTION2(ifnull,            2, 0, 0, ifnullFunc,  SQLITE_FUNC_COALESCE),
-    FUNCTION(random,             0, 0, 0, randomFunc       ),
-    FUNCTION(randomblob,         1, 0, 0, randomBlob       ),
+    FUNCTION2(random,            0, 0, 0, randomFunc, SQLITE_FUNC_NONDETERM),
+    FUNCTION2(randomblob,        1, 0, 0, randomBlob, SQLITE_FUNC_NONDETERM),
     FUNCTION(nullif,             2, 0, 1, nullifFunc       ),
     FUNCTION(sqlite_version,     0, 0, 0, versionFunc      ),
     FUNCTION(sqlite_source_id,   0, 0, 0, sourceidFunc     ),
@@ -1425,9 +1454,9 @@ int sqlite3IsLikeFunction(sqlite3 *db, Expr *pExpr, int *pIsNocase, char *aWc){
     FUNCTION(sqlite_compileoption_get, 1, 0, 0, compileoptiongetFunc  ),
 #endif /* SQLITE_OMIT_COMPILEOPTION_DIAGS */
     FUNCTION(quote,              1, 0, 0, quoteFunc        ),
-    FUNCTION(last_insert_rowid,  0, 0, 0, last_insert_rowid),
-    FUNCTION(changes,            0, 0, 0, changes          ),
-    FUNCTION(total_changes,      0, 0, 0, total_changes    ),
+    FUNCTION2(last_insert_rowid, 0, 0, 0, last_insert_rowid, SQLITE_FUNC_NONDETERM),
+    FUNCTION2(changes,           0, 0, 0, changes, SQLITE_FUNC_NONDETERM),
+    FUNCTION2(total_changes,     0, 0, 0, total_changes, SQLITE_FUNC_NONDETERM),
     FUNCTION(replace,            3, 0, 0, replaceFunc      ),
     FUNCTION(zeroblob,           1, 0, 0, zeroblobFunc     ),
   #ifdef SQLITE_SOUNDEX
@@ -1437,14 +1466,32 @@ int sqlite3IsLikeFunction(sqlite3 *db, Expr *pExpr, int *pIsNocase, char *aWc){
     FUNCTION(load_extension,     1, 0, 0, loadExt          ),
     FUNCTION(load_extension,     2, 0, 0, loadExt          ),
   #endif
-    AGGREGATE(sum,               1, 0, 0, sumStep,         sumFinalize    ),
-    AGGREGATE(total,             1, 0, 0, sumStep,         totalFinalize    ),
-    AGGREGATE(avg,               1, 0, 0, sumStep,         avgFinalize    ),
+    WINDOWAGG(sum,               1, 0, 0, sumStep,         sumFinalize,   sumFinalize,   sumInverse   ),
+    WINDOWAGG(total,             1, 0, 0, sumStep,         totalFinalize, totalFinalize, sumInverse   ),
+    WINDOWAGG(avg,               1, 0, 0, sumStep,         avgFinalize,   avgFinalize,   sumInverse   ),
  /* AGGREGATE(count,             0, 0, 0, countStep,       countFinalize  ), */
-    {0,SQLITE_UTF8,SQLITE_FUNC_COUNT,0,0,0,countStep,countFinalize,"count",0,0},
-    AGGREGATE(count,             1, 0, 0, countStep,       countFinalize  ),
+    {0,SQLITE_UTF8,SQLITE_FUNC_COUNT,0,0,0,countStep,countFinalize,"count",0,0,countFinalize,countInverse},
+    WINDOWAGG(count,             1, 0, 0, countStep,       countFinalize, countFinalize, countInverse ),
     AGGREGATE(group_concat,      1, 0, 0, groupConcatStep, groupConcatFinalize),
     AGGREGATE(group_concat,      2, 0, 0, groupConcatStep, groupConcatFinalize),
+
+    /* Window-only functions. These are evaluated by the window engine in
+    ** window.go, which recognizes them by name. */
+    WINDOWFUNC(row_number,       0),
+    WINDOWFUNC(rank,             0),
+    WINDOWFUNC(dense_rank,       0),
+    WINDOWFUNC(percent_rank,     0),
+    WINDOWFUNC(cume_dist,        0),
+    WINDOWFUNC(ntile,            1),
+    WINDOWFUNC(lag,              1),
+    WINDOWFUNC(lag,              2),
+    WINDOWFUNC(lag,              3),
+    WINDOWFUNC(lead,             1),
+    WINDOWFUNC(lead,             2),
+    WINDOWFUNC(lead,             3),
+    WINDOWFUNC(first_value,      1),
+    WINDOWFUNC(last_value,       1),
+    WINDOWFUNC(nth_value,        2),
   
     LIKEFUNC(glob, 2, &globInfo, SQLITE_FUNC_LIKE|SQLITE_FUNC_CASE),
   #ifdef SQLITE_CASE_SENSITIVE_LIKE
@@ -1464,6 +1511,7 @@ int sqlite3IsLikeFunction(sqlite3 *db, Expr *pExpr, int *pIsNocase, char *aWc){
     pHash.Insert(aFunc[i])
   }
   sqlite3RegisterDateTimeFunctions();
+  sqlite3RegisterJsonFunctions()
 #ifndef SQLITE_OMIT_ALTERTABLE
   sqlite3AlterFunctions();
 #endif
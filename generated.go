@@ -0,0 +1,362 @@
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+//	This file implements generated columns: columns whose values are computed from the other columns of the same row.
+//
+//		CREATE TABLE t(a, b, c AS (a + b), d TEXT GENERATED ALWAYS AS (upper(b)) STORED)
+//		ALTER TABLE t ADD COLUMN e AS (a * 2) VIRTUAL
+//
+//	Parse.ParseGeneratedColumns() (see Parse.Run()) blanks the GENERATED ALWAYS AS clauses out of the statement and records them on
+//	Parse.aGenCol, where sqlite3AddColumn() finds them. Blanking the clauses rather than removing them keeps the character offsets
+//	within the CREATE TABLE statement that ALTER TABLE ADD COLUMN relies on. The original text is kept in Parse.zGenColText for the
+//	sqlite_master table. The expression of a generated column is resolved against its table in the same way as a CHECK constraint.
+//
+//	A STORED column is stored in the records of its table like any other column. A VIRTUAL column takes up no space in the records and
+//	is computed from the other columns whenever it is read, so Table.StorageColumn() has no position for it. Both kinds are computed
+//	over the registers of the new row during INSERT and UPDATE, so that CHECK constraints and the indices on generated columns see
+//	their values. Values may not be given for generated columns by INSERT or UPDATE.
+
+//	A GENERATED ALWAYS AS clause blanked out of a column definition by ParseGeneratedColumns().
+type genColumnClause struct {
+	zName		string			//	Name of the column
+	pExpr		*Expr			//	Expression that computes the value of the column
+	stored		bool			//	True for a STORED column, false for a VIRTUAL one
+}
+
+//	If the first statement of zSql is a CREATE TABLE or an ALTER TABLE ADD COLUMN with generated columns, record their clauses in
+//	pParse.aGenCol and return the statement with the clauses blanked out. Otherwise return zSql unchanged. Errors are left in pParse.
+func (pParse *Parse) ParseGeneratedColumns(zSql string) string {
+	aBlank := [][2]int{}
+	s := newSqlScanner(zSql)
+	switch s.Type {
+	case TK_CREATE:
+		if s.Next(); s.Type == TK_TEMP {
+			s.Next()
+		}
+		if s.Type != TK_TABLE {
+			return zSql
+		}
+		if s.Next(); s.Type == TK_IF {
+			s.Next()			//	NOT
+			s.Next()			//	EXISTS
+			s.Next()
+		}
+		iName := s.Start
+		if s.Next(); s.Type == TK_DOT {
+			s.Next()
+			iName = s.Start
+			s.Next()
+		}
+		if s.Type != TK_LP {
+			return zSql
+		}
+
+		//	The column definitions come first in the list, then the table constraints.
+		for s.Next() {
+			if s.Type == TK_CONSTRAINT || s.Type == TK_PRIMARY || s.Type == TK_UNIQUE || s.Type == TK_CHECK || s.Type == TK_FOREIGN {
+				break
+			}
+			if iStart, iEnd := pParse.scanColumnDef(s); iStart >= 0 {
+				aBlank = append(aBlank, [2]int{ iStart, iEnd })
+			}
+			if pParse.nErr > 0 || s.Type != TK_COMMA {
+				break
+			}
+		}
+		if pParse.nErr > 0 || len(aBlank) == 0 {
+			return zSql
+		}
+		for depth := 0; s.Type != TK_RP || depth > 0; s.Next() {
+			switch s.Type {
+			case 0, TK_SEMI:
+				return zSql
+			case TK_LP:
+				depth++
+			case TK_RP:
+				depth--
+			}
+		}
+		pParse.zGenColText = zSql[iName:s.iNext]
+
+	case TK_ALTER:
+		for s.Type != 0 && s.Type != TK_SEMI && s.Type != TK_ADD {
+			s.Next()
+		}
+		if s.Type != TK_ADD {
+			return zSql
+		}
+		if s.Next(); s.Type == TK_COLUMNKW {
+			s.Next()
+		}
+		iDef := s.Start
+		iStart, iEnd := pParse.scanColumnDef(s)
+		if pParse.nErr > 0 || iStart < 0 {
+			return zSql
+		}
+		aBlank = append(aBlank, [2]int{ iStart, iEnd })
+		pParse.zGenColText = strings.TrimRight(zSql[iDef:s.Start], " \t\r\n")
+
+	default:
+		return zSql
+	}
+
+	zOut := ""
+	i := 0
+	for _, r := range aBlank {
+		zOut += zSql[i:r[0]] + strings.Repeat(" ", utf8.RuneCountInString(zSql[r[0]:r[1]]))
+		i = r[1]
+	}
+	return zOut + zSql[i:]
+}
+
+//	Scan the column definition that starts at the current token of s, up to the "," or ")" or end of statement that follows it. If
+//	the definition has a GENERATED ALWAYS AS clause, append it to pParse.aGenCol and return the offsets of the clause in the statement
+//	text. Otherwise return -1, -1. Errors are left in pParse.
+func (pParse *Parse) scanColumnDef(s *sqlScanner) (iStart, iEnd int) {
+	iStart, iEnd = -1, -1
+	zName := Dequote(s.Text)
+	for depth := 0; s.Next(); {
+		switch {
+		case s.Type == TK_SEMI:
+			return
+		case s.Type == TK_LP:
+			depth++
+		case s.Type == TK_RP && depth > 0:
+			depth--
+		case depth == 0 && (s.Type == TK_COMMA || s.Type == TK_RP):
+			return
+		case depth == 0 && (s.IsWord("GENERATED") || s.Type == TK_AS):
+			if iStart >= 0 {
+				pParse.scanError(s)
+				return -1, -1
+			}
+			iClause := s.Start
+			if s.IsWord("GENERATED") {
+				if !s.Next() || !s.IsWord("ALWAYS") || !s.Next() || s.Type != TK_AS {
+					pParse.scanError(s)
+					return -1, -1
+				}
+			}
+			if !s.Next() || s.Type != TK_LP {
+				pParse.scanError(s)
+				return -1, -1
+			}
+			zExpr, ok := s.Parenthesized()
+			if !ok {
+				pParse.scanError(s)
+				return -1, -1
+			}
+			iStart, iEnd = iClause, s.iNext
+			stored := false
+			if t := *s; t.Next() && (t.IsWord("STORED") || t.IsWord("VIRTUAL")) {
+				stored = t.IsWord("STORED")
+				*s = t
+				iEnd = s.iNext
+			}
+			pSel := pParse.ParseSelect("SELECT " + zExpr)
+			if pSel == nil {
+				return -1, -1
+			}
+			if pSel.pSrc != nil && pSel.pSrc.nSrc > 0 || pSel.Where != nil || pSel.pGroupBy != nil || pSel.pOrderBy != nil || pSel.pLimit != nil || pSel.pEList.Len() != 1 {
+				pParse.SetErrorMsg("near \"%v\": syntax error", strings.TrimSpace(zExpr))
+				return -1, -1
+			}
+			pParse.aGenCol = append(pParse.aGenCol, &genColumnClause{ zName: zName, pExpr: pSel.pEList.Items[0].Expr, stored: stored })
+		}
+	}
+	return
+}
+
+//	Leave an error in pParse for the unexpected current token of s.
+func (pParse *Parse) scanError(s *sqlScanner) {
+	if s.Type == 0 {
+		pParse.SetErrorMsg("incomplete input")
+	} else {
+		pParse.SetErrorMsg("near \"%v\": syntax error", s.Text)
+	}
+}
+
+//	Make pCol, a column named in the statement being parsed, a generated column if ParseGeneratedColumns() found a clause for it.
+func (pParse *Parse) AttachGenerated(pCol *Column) {
+	for _, pClause := range pParse.aGenCol {
+		if CaseInsensitiveMatch(pClause.zName, pCol.Name) {
+			pCol.pGenerated = pClause.pExpr.Dup()
+			pCol.isStored = pClause.stored
+		}
+	}
+}
+
+//	Return true if column c is a generated column.
+func (c *Column) IsGenerated() bool {
+	return c.pGenerated != nil
+}
+
+//	Return true if column c is a VIRTUAL generated column, which is not stored in the records of its table.
+func (c *Column) IsVirtualGenerated() bool {
+	return c.pGenerated != nil && !c.isStored
+}
+
+//	Return true if table t has a generated column.
+func (t *Table) HasGenerated() bool {
+	for _, pCol := range t.Columns {
+		if pCol.IsGenerated() {
+			return true
+		}
+	}
+	return false
+}
+
+//	Return true if the value of column iCol of table t depends on column iDep, or on the rowid if iDep is negative: that is, if iCol is
+//	iDep or is a generated column computed from iDep, directly or through other generated columns.
+func (t *Table) ColumnDependsOn(iCol, iDep int) bool {
+	if iCol == iDep {
+		return true
+	}
+	if iCol < 0 || !t.Columns[iCol].IsGenerated() {
+		return false
+	}
+	pExpr := t.Columns[iCol].pGenerated
+	if iDep < 0 && exprUsesColumn(pExpr, -1) {
+		return true
+	}
+	for j := range t.Columns {
+		if j != iCol && exprUsesColumn(pExpr, j) && t.ColumnDependsOn(j, iDep) {
+			return true
+		}
+	}
+	return false
+}
+
+//	Return a colUsed bitmask of the columns of table t that VIRTUAL generated column iCol is computed from.
+func (t *Table) GeneratedColUsed(iCol int) (mask Bitmask) {
+	for j := range t.Columns {
+		if j != iCol && t.ColumnDependsOn(iCol, j) {
+			//	Columns past the last bit share it, as in lookupName().
+			iBit := j
+			if iBit >= BMS {
+				iBit = BMS - 1
+			}
+			mask |= Bitmask(1) << uint(iBit)
+		}
+	}
+	return
+}
+
+//	Return true if the key or the WHERE clause of index pIdx uses a generated column whose value depends on column iCol of the
+//	indexed table.
+func (pIdx *Index) GeneratedUses(iCol int) bool {
+	pTab := pIdx.pTable
+	for j, pCol := range pTab.Columns {
+		if j == iCol || !pCol.IsGenerated() || !pTab.ColumnDependsOn(j, iCol) {
+			continue
+		}
+		for _, column := range pIdx.Columns {
+			if column == j {
+				return true
+			}
+		}
+		if pIdx.PartialIndexUses(j) || pIdx.ExprUses(j) {
+			return true
+		}
+	}
+	return false
+}
+
+//	Return the generated columns of table t in an order in which each comes after the generated columns that it is computed from. A
+//	generated column that is computed from itself, directly or through other generated columns, is left out.
+func (t *Table) generatedOrder() (aCol []int) {
+	done := make([]bool, len(t.Columns))
+	for progress := true; progress; {
+		progress = false
+		for i, pCol := range t.Columns {
+			if done[i] || !pCol.IsGenerated() {
+				continue
+			}
+			ready := true
+			for j, pDep := range t.Columns {
+				if pDep.IsGenerated() && !done[j] && exprUsesColumn(pCol.pGenerated, j) {
+					ready = false
+				}
+			}
+			if ready {
+				done[i] = true
+				aCol = append(aCol, i)
+				progress = true
+			}
+		}
+	}
+	return
+}
+
+//	Resolve the names in the expressions of the generated columns of pTab, a table being created or altered, and make sure that none
+//	of them is computed from itself. Return true if there are errors.
+func (pParse *Parse) GeneratedResolve(pTab *Table) bool {
+	db := pParse.db
+	iDb := db.SchemaToIndex(pTab.Schema)
+	pSrc := db.SrcListAppend(nil, pTab.Name, db.Databases[iDb].Name)
+	pSrc.a[0].pTab = pTab
+	pSrc.a[0].iCursor = -1
+	nGenerated := 0
+	for _, pCol := range pTab.Columns {
+		if pCol.IsGenerated() {
+			nGenerated++
+			sNC := NameContext{ Parse: pParse, SrcList: pSrc, Flags: NC_GenCol }
+			if sqlite3ResolveExprNames(&sNC, pCol.pGenerated) {
+				return true
+			}
+		}
+	}
+	if aCol := pTab.generatedOrder(); len(aCol) < nGenerated {
+		for i, pCol := range pTab.Columns {
+			found := !pCol.IsGenerated()
+			for _, iCol := range aCol {
+				found = found || iCol == i
+			}
+			if !found {
+				pParse.SetErrorMsg("generated column loop on \"%v\"", pCol.Name)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//	Generate code that applies the affinity of generated column pCol to the value computed for it in register iReg.
+func (v *Vdbe) generatedAffinity(pCol *Column, iReg int) {
+	if pCol.affinity != SQLITE_AFF_NONE {
+		sqlite3VdbeAddOp4(v, OP_Affinity, iReg, 1, 0, string([]byte{ pCol.affinity }), P4_TRANSIENT)
+	}
+}
+
+//	Generate code that computes VIRTUAL generated column iCol of table pTab for the row that cursor iCur points to and stores it in
+//	register regOut.
+func (pParse *Parse) CodeGeneratedColumn(pTab *Table, iCol, iCur, regOut int) {
+	pCol := pTab.Columns[iCol]
+	pExpr := indexExprOnCursor(pCol.pGenerated, iCur)
+	pParse.ExprCachePush()
+	sqlite3ExprCode(pParse, pExpr, regOut)
+	pParse.ExprCachePop(1)
+	pParse.db.ExprDelete(pExpr)
+	pParse.pVdbe.generatedAffinity(pCol, regOut)
+}
+
+//	Generate code that computes every generated column of table pTab for a row whose columns are in the registers starting at regData,
+//	and stores each in the register of its column. The rowid of the row must be in register regData - 1.
+func (pParse *Parse) CodeGeneratedColumns(pTab *Table, regData int) {
+	if !pTab.HasGenerated() {
+		return
+	}
+	v := pParse.pVdbe
+	ckBase := pParse.ckBase
+	pParse.ckBase = regData
+	pParse.ExprCachePush()
+	for _, iCol := range pTab.generatedOrder() {
+		pCol := pTab.Columns[iCol]
+		sqlite3ExprCode(pParse, pCol.pGenerated, regData + iCol)
+		v.generatedAffinity(pCol, regData + iCol)
+	}
+	pParse.ExprCachePop(1)
+	pParse.ckBase = ckBase
+}
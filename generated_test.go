@@ -0,0 +1,110 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestGeneratedColumns(t *testing.T) {
+	zFile := testFile(t)
+	db := testOpen(t, zFile)
+	const zCreate = "CREATE TABLE t(a INTEGER, b TEXT, c AS (a * 2), d TEXT GENERATED ALWAYS AS (upper(b) || c) STORED, e AS (c + 1) VIRTUAL)"
+	testExec(t, db, zCreate)
+	testExec(t, db, "INSERT INTO t(a, b) VALUES(1, 'x')")
+	testExec(t, db, "INSERT INTO t VALUES(2, 'y')")
+
+	//	Generated columns are computed on INSERT and UPDATE, from other generated columns too, with the affinity of their type.
+	testQueryIs(t, db, "1|x|2|X2|3\n2|y|4|Y4|5", "SELECT * FROM t ORDER BY a")
+	testQueryIs(t, db, "integer|text|integer", "SELECT typeof(c), typeof(d), typeof(e) FROM t WHERE a = 1")
+	testExec(t, db, "UPDATE t SET a = 10 WHERE b = 'x'")
+	testExec(t, db, "UPDATE t SET b = 'z' WHERE a = 2")
+	testQueryIs(t, db, "2|z|4|Z4|5\n10|x|20|X20|21", "SELECT * FROM t ORDER BY a")
+	testQueryIs(t, db, "Z4", "SELECT d FROM t WHERE e = 5")
+
+	for query, want := range map[string]string{
+		"INSERT INTO t(a, b, c) VALUES(1, 'x', 2)":		`cannot INSERT into generated column "c"`,
+		"INSERT INTO t VALUES(1, 'x', 2, 'X2', 3)":		"table t has 2 columns but 5 values were supplied",
+		"UPDATE t SET d = 'X'":							`cannot UPDATE generated column "d"`,
+		"UPDATE t SET e = 0, a = 1":					`cannot UPDATE generated column "e"`,
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+
+	//	table_info lists every column, and table_xinfo tells VIRTUAL (2) and STORED (3) columns apart.
+	testQueryIs(t, db, "0|a|INTEGER|0|NULL|0\n1|b|TEXT|0|NULL|0\n2|c||0|NULL|0\n3|d|TEXT|0|NULL|0\n4|e||0|NULL|0", "PRAGMA table_info(t)")
+	testQueryIs(t, db, "0|a|INTEGER|0|NULL|0|0\n1|b|TEXT|0|NULL|0|0\n2|c||0|NULL|0|2\n3|d|TEXT|0|NULL|0|3\n4|e||0|NULL|0|2", "PRAGMA table_xinfo(t)")
+
+	//	The definitions are stored as written and work in a connection that loads them from the schema.
+	testQueryIs(t, db, zCreate, "SELECT sql FROM sqlite_master WHERE name = 't'")
+	db2 := testOpen(t, zFile)
+	testExec(t, db2, "INSERT INTO t(a, b) VALUES(3, 'w')")
+	testQueryIs(t, db2, "3|w|6|W6|7", "SELECT * FROM t WHERE a = 3")
+
+	//	ALTER TABLE adds VIRTUAL columns, whose values are computed for the rows already there, but not STORED ones.
+	testExec(t, db2, "ALTER TABLE t ADD COLUMN f AS (e * 10)")
+	testQueryIs(t, db2, "2|50\n3|70\n10|210", "SELECT a, f FROM t ORDER BY a")
+	if _, err := db2.Exec("ALTER TABLE t ADD COLUMN g AS (1) STORED"); err == nil || !strings.Contains(err.Error(), "cannot add a STORED column") {
+		t.Errorf("ADD COLUMN of a STORED column: %v", err)
+	}
+	testQueryIs(t, db2, "ok", "PRAGMA integrity_check")
+}
+
+func TestGeneratedColumnErrors(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	for query, want := range map[string]string{
+		"CREATE TABLE e(a, b AS (b + 1))":								`generated column loop on "b"`,
+		"CREATE TABLE e(a, b AS (c), c AS (b))":						"generated column loop on",
+		"CREATE TABLE e(a, b AS (random()))":							"non-deterministic functions prohibited in generated columns",
+		"CREATE TABLE e(a, b AS (x))":									"no such column: x",
+		"CREATE TABLE e(a, b AS (a) PRIMARY KEY)":						"generated columns cannot be part of the PRIMARY KEY",
+		"CREATE TABLE e(a, b AS (a), PRIMARY KEY(b))":					"generated columns cannot be part of the PRIMARY KEY",
+		"CREATE TABLE e(a, b DEFAULT 1 AS (a))":						"cannot use DEFAULT on a generated column",
+		"CREATE TABLE e(a, b AS (a) AS (a))":							`near "AS": syntax error`,
+		"CREATE TABLE e(a, b GENERATED AS (a))":						`near "AS": syntax error`,
+		"CREATE TABLE e(a, b AS a)":									`near "a": syntax error`,
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+
+	//	A CHECK constraint sees the value of a generated column.
+	testExec(t, db, "CREATE TABLE ck(a, b AS (a * 2) CHECK (b < 10))")
+	testExec(t, db, "INSERT INTO ck(a) VALUES(4)")
+	if _, err := db.Exec("INSERT INTO ck(a) VALUES(5)"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("CHECK on a generated column: %v, want SQLITE_CONSTRAINT", err)
+	}
+	if _, err := db.Exec("UPDATE ck SET a = 6"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("CHECK on a generated column after UPDATE: %v, want SQLITE_CONSTRAINT", err)
+	}
+	testQueryIs(t, db, "4|8", "SELECT a, b FROM ck")
+}
+
+func TestGeneratedColumnIndex(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(a, b, c AS (a + b), d AS (a || '-' || b) STORED)")
+	testExec(t, db, "CREATE INDEX t_c ON t(c)")
+	testExec(t, db, "CREATE INDEX t_d ON t(d)")
+	testExec(t, db, "INSERT INTO t(a, b) VALUES(1, 2), (3, 4), (5, 6)")
+
+	//	Indices on both kinds of column are used by the planner and kept up to date when the columns they are computed from change.
+	for _, test := range []struct {
+		query, index	string
+	}{
+		{ "SELECT a FROM t WHERE c = 7", "t_c" },
+		{ "SELECT a FROM t WHERE c > 7", "t_c" },
+		{ "SELECT a FROM t WHERE d = '1-2'", "t_d" },
+	} {
+		if plan := testQueryPlan(t, db, test.query); !strings.Contains(plan, "INDEX " + test.index + " ") {
+			t.Errorf("%v: plan %q, want index %q", test.query, plan, test.index)
+		}
+	}
+	testQueryIs(t, db, "3", "SELECT a FROM t WHERE c = 7")
+	testExec(t, db, "UPDATE t SET b = 10 WHERE a = 3")
+	testQueryIs(t, db, "", "SELECT a FROM t WHERE c = 7")
+	testQueryIs(t, db, "3|13|3-10", "SELECT a, c, d FROM t WHERE c = 13")
+	testQueryIs(t, db, "3", "SELECT a FROM t WHERE d = '3-10'")
+	testExec(t, db, "DELETE FROM t WHERE c = 3")
+	testQueryIs(t, db, "3|13\n5|11", "SELECT a, c FROM t ORDER BY d")
+	testQueryIs(t, db, "ok", "PRAGMA integrity_check")
+}
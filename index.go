@@ -0,0 +1,282 @@
+import (
+	"strings"
+)
+
+//	This file implements the parts of CREATE INDEX that the grammar in parse.go does not understand: the WHERE clause of a partial
+//	index (see partialindex.go) and index keys that are expressions rather than column names.
+//
+//		CREATE INDEX i1 ON t(lower(email))
+//		CREATE INDEX i2 ON t(substr(code, 1, 3), b DESC)
+//
+//	Parse.ParseCreateIndex() (see Parse.Run()) removes both from the statement. Each expression in the column list is replaced by a
+//	placeholder name and recorded in Parse.aIdxExpr at the position of the placeholder. sqlite3CreateIndex() stores XN_EXPR in
+//	Index.Columns for that position and keeps the expression, resolved against the indexed table, in Index.aColExpr. The expression
+//	is evaluated over the registers of a new row during INSERT and UPDATE, and over a table cursor everywhere else an index key is
+//	built. The query planner treats a WHERE or ORDER BY term that is the same expression as the key as a reference to that index
+//	column.
+
+//	The name that takes the place of an expression in the column list passed to the LEMON parser.
+const idxExprPlaceholder = `"sqlite_expr"`
+
+//	If the first statement of zSql is a CREATE INDEX with expressions in its column list or a WHERE clause, record them in
+//	pParse.aIdxExpr and pParse.pPartIdxWhere and return the statement without them. Otherwise return zSql unchanged. The original text
+//	of the statement from the index name onwards is saved in pParse.zIndexText for the sqlite_master table. Errors are left in pParse.
+func (pParse *Parse) ParseCreateIndex(zSql string) string {
+	s := newSqlScanner(zSql)
+	if s.Type != TK_CREATE {
+		return zSql
+	}
+	if s.Next(); s.Type == TK_TEMP {
+		s.Next()
+	}
+	if s.Type == TK_UNIQUE {
+		s.Next()
+	}
+	if s.Type != TK_INDEX {
+		return zSql
+	}
+	if s.Next(); s.Type == TK_IF {
+		s.Next()			//	NOT
+		s.Next()			//	EXISTS
+		s.Next()
+	}
+	iName := s.Start
+
+	//	The column list is the first parenthesized text of the statement. Split it into terms at the commas outside of parentheses.
+	for s.Type != 0 && s.Type != TK_SEMI && s.Type != TK_LP {
+		s.Next()
+	}
+	if s.Type != TK_LP {
+		return zSql
+	}
+	iList := s.iNext
+	aTerm := []string{}
+	aIdxExpr := []*Expr{}
+	hasExpr := false
+terms:
+	for depth, iTerm := 0, iList; ; {
+		if !s.Next() || s.Type == TK_SEMI {
+			return zSql
+		}
+		switch {
+		case s.Type == TK_LP:
+			depth++
+		case s.Type == TK_RP && depth > 0:
+			depth--
+		case depth == 0 && (s.Type == TK_COMMA || s.Type == TK_RP):
+			zTerm, pExpr := pParse.indexTerm(zSql[iTerm:s.Start])
+			if pParse.nErr > 0 {
+				return zSql
+			}
+			aTerm = append(aTerm, zTerm)
+			aIdxExpr = append(aIdxExpr, pExpr)
+			hasExpr = hasExpr || pExpr != nil
+			if s.Type == TK_RP {
+				break terms
+			}
+			iTerm = s.iNext
+		}
+	}
+	iTail := s.iNext
+	iEnd := iTail
+
+	//	The WHERE clause runs to the end of the statement.
+	var pWhere *Expr
+	zTail := zSql[iTail:]
+	if s.Next(); s.Type == TK_WHERE {
+		iStart := s.Start
+		s.Next()
+		iWhere := s.Start
+		for depth := 0; s.Type != 0 && (s.Type != TK_SEMI || depth > 0); s.Next() {
+			if s.Type == TK_LP {
+				depth++
+			} else if s.Type == TK_RP {
+				depth--
+			}
+		}
+		pSel := pParse.ParseSelect("SELECT 0 WHERE " + zSql[iWhere:s.Start])
+		if pSel == nil {
+			return zSql
+		}
+		if pWhere = pSel.Where; pWhere == nil {
+			pParse.SetErrorMsg("near \"WHERE\": syntax error")
+			return zSql
+		}
+		iEnd = s.Start
+		zTail = zSql[iTail:iStart] + zSql[s.Start:]
+	}
+	if !hasExpr && pWhere == nil {
+		return zSql
+	}
+	if hasExpr {
+		pParse.aIdxExpr = aIdxExpr
+	}
+	pParse.pPartIdxWhere = pWhere
+	pParse.zIndexText = strings.TrimRight(zSql[iName:iEnd], " \t\r\n")
+	return zSql[:iList] + strings.Join(aTerm, ",") + ")" + zTail
+}
+
+//	Examine zTerm, one term of the column list of a CREATE INDEX statement. If it is a column name, optionally followed by a COLLATE
+//	clause and a sort order, return it unchanged and a nil expression. Otherwise parse it as an expression followed by an optional sort
+//	order and return the placeholder that replaces it in the statement text. Errors are left in pParse.
+func (pParse *Parse) indexTerm(zTerm string) (zText string, pExpr *Expr) {
+	s := newSqlScanner(zTerm)
+	if s.Type == 0 {
+		return zTerm, nil
+	}
+	if s.IsName() {
+		t := *s
+		t.Next()
+		if t.Type == TK_COLLATE && t.Next() && t.IsName() {
+			t.Next()
+		}
+		if t.Type == TK_ASC || t.Type == TK_DESC {
+			t.Next()
+		}
+		if t.Type == 0 {
+			return zTerm, nil
+		}
+	}
+
+	//	Separate a trailing ASC or DESC from the expression.
+	iOrder := len(zTerm)
+	for ; s.Type != 0; s.Next() {
+		if s.Type == TK_ASC || s.Type == TK_DESC {
+			iOrder = s.Start
+		} else {
+			iOrder = len(zTerm)
+		}
+	}
+	pSel := pParse.ParseSelect("SELECT " + zTerm[:iOrder])
+	if pSel == nil {
+		return zTerm, nil
+	}
+	if pSel.pSrc != nil && pSel.pSrc.nSrc > 0 || pSel.Where != nil || pSel.pGroupBy != nil || pSel.pOrderBy != nil || pSel.pLimit != nil || pSel.pEList.Len() != 1 {
+		pParse.SetErrorMsg("near \"%v\": syntax error", strings.TrimSpace(zTerm))
+		return zTerm, nil
+	}
+	return idxExprPlaceholder + " " + zTerm[iOrder:], pSel.pEList.Items[0].Expr
+}
+
+//	Resolve the names in pExpr, an expression of index pIdx, against the table it indexes. flags is NC_PartIdx for the WHERE clause of
+//	a partial index or NC_IdxExpr for an indexed expression. Return true if there are errors.
+func (pParse *Parse) IndexResolve(pIdx *Index, pExpr *Expr, flags byte) bool {
+	db := pParse.db
+	pTab := pIdx.pTable
+	iDb := db.SchemaToIndex(pTab.Schema)
+	pSrc := db.SrcListAppend(nil, pTab.Name, db.Databases[iDb].Name)
+	pSrc.a[0].pTab = pTab
+	pSrc.a[0].iCursor = -1
+	sNC := NameContext{ Parse: pParse, SrcList: pSrc, Flags: flags }
+	return sqlite3ResolveExprNames(&sNC, pExpr)
+}
+
+//	Return a copy of pExpr, an expression of an index resolved by IndexResolve(), in which the column references read from cursor iCur.
+func indexExprOnCursor(pExpr *Expr, iCur int) (pNew *Expr) {
+	pNew = pExpr.Dup()
+	w := &Walker{
+		ExprCallback: func(w *Walker, pExpr *Expr) int {
+			if pExpr.op == TK_COLUMN {
+				pExpr.iTable = iCur
+			}
+			return WRC_Continue
+		},
+	}
+	w.Expr(pNew)
+	return
+}
+
+//	Return true if pExpr, an expression in a statement that reads the indexed table through cursor iCur, is the same as the
+//	expression in column iCol of index pIdx.
+func (pIdx *Index) ExprMatches(iCol, iCur int, pExpr *Expr) bool {
+	if iCol >= len(pIdx.Columns) || pIdx.Columns[iCol] != XN_EXPR {
+		return false
+	}
+	return pExpr.Compare(indexExprOnCursor(pIdx.aColExpr[iCol], iCur)) == 0
+}
+
+//	Return true if pExpr, an expression resolved by IndexResolve(), refers to column iCol of the indexed table, or to the rowid if iCol
+//	is negative.
+func exprUsesColumn(pExpr *Expr, iCol int) (found bool) {
+	w := &Walker{
+		ExprCallback: func(w *Walker, pExpr *Expr) int {
+			if pExpr.op == TK_COLUMN && (pExpr.iColumn == iCol || (iCol < 0 && pExpr.iColumn < 0)) {
+				found = true
+				return WRC_Abort
+			}
+			return WRC_Continue
+		},
+	}
+	w.Expr(pExpr)
+	return
+}
+
+//	Return true if one of the expressions indexed by pIdx refers to column iCol of the indexed table.
+func (pIdx *Index) ExprUses(iCol int) bool {
+	for _, pExpr := range pIdx.aColExpr {
+		if pExpr != nil && exprUsesColumn(pExpr, iCol) {
+			return true
+		}
+	}
+	return false
+}
+
+//	Return true if one of the indices of a table in pSrc has an expression column that is the same as pExpr. If so, also return the
+//	cursor of the table.
+func indexedExprCursor(pSrc *SrcList, pExpr *Expr) (iCur int, ok bool) {
+	if pExpr == nil || pExpr.op == TK_COLUMN {
+		return -1, false
+	}
+	for i := 0; i < pSrc.nSrc; i++ {
+		pItem := &pSrc.a[i]
+		if pItem.pTab == nil {
+			continue
+		}
+		for _, pIdx := range pItem.pTab.Indices {
+			for j := range pIdx.aColExpr {
+				if pIdx.ExprMatches(j, pItem.iCursor, pExpr) {
+					return pItem.iCursor, true
+				}
+			}
+		}
+	}
+	return -1, false
+}
+
+//	Return the affinity of column iCol of index pIdx. The column after the last indexed column is the rowid, or for a WITHOUT ROWID
+//	table, the columns after the last indexed column are those of the PRIMARY KEY, or for the PRIMARY KEY itself, the other columns.
+func (pIdx *Index) ColumnAffinity(iCol int) (aff byte) {
+	switch pTab := pIdx.pTable; {
+	case iCol >= len(pIdx.Columns) && pIdx.IsClustered():
+		return pTab.Columns[pTab.TableColumn(iCol)].affinity
+	case iCol >= len(pIdx.Columns) && !pTab.HasRowid():
+		return pTab.PrimaryKey().ColumnAffinity(iCol - len(pIdx.Columns))
+	case iCol >= len(pIdx.Columns) || pIdx.Columns[iCol] < 0 && pIdx.Columns[iCol] != XN_EXPR:
+		return SQLITE_AFF_INTEGER
+	case pIdx.Columns[iCol] == XN_EXPR:
+		if aff = sqlite3ExprAffinity(pIdx.aColExpr[iCol]); aff == 0 {
+			aff = SQLITE_AFF_NONE
+		}
+		return
+	}
+	return pIdx.pTable.Columns[pIdx.Columns[iCol]].affinity
+}
+
+//	Generate code that stores the value of column iCol of index pIdx for the row that cursor iCur points to in register regOut.
+func (pParse *Parse) IndexColumnCode(pIdx *Index, iCol, iCur, regOut int) {
+	v := pParse.pVdbe
+	pTab := pIdx.pTable
+	switch idx := pIdx.Columns[iCol]; {
+	case idx == XN_EXPR:
+		pExpr := indexExprOnCursor(pIdx.aColExpr[iCol], iCur)
+		sqlite3ExprCode(pParse, pExpr, regOut)
+		pParse.db.ExprDelete(pExpr)
+	case idx == pTab.iPKey:
+		v.AddOp2(OP_Rowid, iCur, regOut)
+	case pTab.Columns[idx].IsVirtualGenerated():
+		pParse.CodeGeneratedColumn(pTab, idx, iCur, regOut)
+	default:
+		v.AddOp3(OP_Column, iCur, pTab.StorageColumn(idx), regOut)
+		v.ColumnDefault(pTab, idx, -1)
+	}
+}
@@ -0,0 +1,86 @@
+import (
+	"strings"
+	"testing"
+)
+
+//	An index on expressions holds the values of the expressions for every row, however the row comes to change, and is stored with
+//	the text it was created with.
+func TestIndexExpr(t *testing.T) {
+	zFile := testFile(t)
+	db := testOpen(t, zFile)
+	testExec(t, db, "CREATE TABLE t(id INTEGER PRIMARY KEY, email, code)")
+	testExec(t, db, "CREATE UNIQUE INDEX t_lower ON t(lower(email))")
+	testExec(t, db, "CREATE INDEX t_prefix ON t(substr(code, 1, 3), id DESC)")
+	testExec(t, db, "INSERT INTO t VALUES(1, 'Ann@x.org', 'abc123'), (2, 'bob@x.org', 'abd456'), (3, 'Cid@y.org', 'abc789')")
+	if _, err := db.Exec("INSERT INTO t VALUES(4, 'ANN@x.org', 'zzz')"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("duplicate expression value: %v, want SQLITE_CONSTRAINT", err)
+	}
+	testExec(t, db, "UPDATE t SET email = 'Bob@z.org' WHERE id = 2")
+	testExec(t, db, "INSERT INTO t VALUES(4, 'bob@x.org', 'abd000')")
+	testExec(t, db, "DELETE FROM t WHERE id = 3")
+	testQueryIs(t, db, "2", "SELECT id FROM t INDEXED BY t_lower WHERE lower(email) = 'bob@z.org'")
+	testQueryIs(t, db, "4", "SELECT id FROM t INDEXED BY t_lower WHERE lower(email) = 'bob@x.org'")
+	testQueryIs(t, db, "4\n2", "SELECT id FROM t INDEXED BY t_prefix WHERE substr(code, 1, 3) = 'abd'")
+	testQueryIs(t, db, "ok", "PRAGMA integrity_check")
+
+	testQueryIs(t, db, "CREATE INDEX t_prefix ON t(substr(code, 1, 3), id DESC)", "SELECT sql FROM sqlite_master WHERE name = 't_prefix'")
+	db2 := testOpen(t, zFile)
+	if _, err := db2.Exec("INSERT INTO t VALUES(5, 'BOB@Z.ORG', 'x')"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("duplicate expression value in a new connection: %v, want SQLITE_CONSTRAINT", err)
+	}
+
+	for query, want := range map[string]string{
+		"CREATE INDEX e ON t(random())":			"non-deterministic functions prohibited in index expressions",
+		"CREATE INDEX e ON t((SELECT 1))":			"subqueries prohibited in index expressions",
+		"CREATE INDEX e ON t(nosuch + 1)":			"no such column: nosuch",
+		"CREATE INDEX e ON t(email, code FROM t)":	`near "code FROM t": syntax error`,
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+}
+
+//	The planner uses an index on an expression for a WHERE or ORDER BY term that is the same expression.
+func TestIndexExprPlanner(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(id INTEGER PRIMARY KEY, email, code)")
+	testExec(t, db, "CREATE INDEX t_lower ON t(lower(email))")
+	testExec(t, db, "CREATE INDEX t_prefix ON t(substr(code, 1, 3), id)")
+	testExec(t, db, "INSERT INTO t VALUES(1, 'Ann@x.org', 'abc123'), (2, 'bob@x.org', 'abd456'), (3, 'Cid@y.org', 'abc789')")
+	for _, test := range []struct {
+		query	string
+		index	string
+		sorted	bool			//	True if the index gives the rows in the ORDER BY order, with no temporary b-tree
+	}{
+		{ "SELECT id FROM t WHERE lower(email) = 'bob@x.org'", "t_lower", true },
+		{ "SELECT id FROM t WHERE 'bob@x.org' = lower(email)", "t_lower", true },
+		{ "SELECT id FROM t WHERE lower(email) > 'b'", "t_lower", true },
+		{ "SELECT id FROM t WHERE substr(code, 1, 3) = 'abc' AND id > 1", "t_prefix", true },
+		{ "SELECT id FROM t ORDER BY lower(email)", "t_lower", true },
+		{ "SELECT id FROM t WHERE substr(code, 1, 3) = 'abc' ORDER BY id", "t_prefix", true },
+		{ "SELECT id FROM t WHERE lower(email) > 'b' ORDER BY lower(email) DESC", "t_lower", true },
+
+		//	An expression that differs from the indexed one, or a column of it, does not use the index.
+		{ "SELECT id FROM t WHERE upper(email) = 'BOB@X.ORG'", "", true },
+		{ "SELECT id FROM t WHERE email = 'bob@x.org'", "", true },
+		{ "SELECT id FROM t WHERE substr(code, 1, 4) = 'abc1'", "", true },
+		{ "SELECT id FROM t ORDER BY upper(email)", "", false },
+	} {
+		plan := testQueryPlan(t, db, test.query)
+		if uses := strings.Contains(plan, "INDEX"); uses != (test.index != "") || !strings.Contains(plan, test.index) {
+			t.Errorf("%v: plan %q, want index %q", test.query, plan, test.index)
+		}
+		if sorted := !strings.Contains(plan, "TEMP B-TREE"); sorted != test.sorted {
+			t.Errorf("%v: plan %q, want sorted by the index %v", test.query, plan, test.sorted)
+		}
+	}
+	if plan := testQueryPlan(t, db, "SELECT id FROM t WHERE lower(email) = ?", "x"); plan != "SEARCH TABLE t USING INDEX t_lower (<expr>=?)" {
+		t.Errorf("plan %q", plan)
+	}
+
+	//	The rows found through the index are those a full scan finds.
+	testQueryIs(t, db, "2\n3", "SELECT id FROM t WHERE lower(email) > 'b' ORDER BY lower(email)")
+	testQueryIs(t, db, "3\n1", "SELECT id FROM t WHERE substr(code, 1, 3) = 'abc' ORDER BY id DESC")
+	testQueryIs(t, db, "1\n2\n3", "SELECT id FROM t ORDER BY lower(email)")
+}
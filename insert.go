@@ -10,8 +10,14 @@ func (p *Parse) OpenTable(table *Table, iCur, iDb, opcode int) {
 		} else {
 			p.TableLock(iDb, table.tnum, table.Name, true)
 		}
-		v.AddOp3(opcode, iCur, table.tnum, iDb)
-		sqlite3VdbeChangeP4(v, -1, SQLITE_INT_TO_PTR(table.nCol), P4_INT32)
+		if table.HasRowid() {
+			v.AddOp3(opcode, iCur, table.tnum, iDb)
+			sqlite3VdbeChangeP4(v, -1, SQLITE_INT_TO_PTR(table.nCol), P4_INT32)
+		} else {
+			//	The rows of a WITHOUT ROWID table are the entries of its PRIMARY KEY
+			pKey := p.IndexKeyinfo(table.PrimaryKey())
+			sqlite3VdbeAddOp4(v, opcode, iCur, table.tnum, iDb, (char*)pKey, P4_KEYINFO_HANDOFF)
+		}
 		v.Comment(table.Name)
 	}
 }
@@ -24,16 +30,24 @@ func (p *Parse) OpenTable(table *Table, iCur, iDb, opcode int) {
 //			'c'            NUMERIC
 //			'd'            INTEGER
 //			'e'            REAL
-//	An extra 'd' is appended to the end of the string to cover the rowid that appears as the last column in every index.
+//	An extra 'd' is appended to the end of the string to cover the rowid that appears as the last column in every index. The indices of a WITHOUT ROWID table end with the PRIMARY KEY columns instead, except for the PRIMARY KEY itself.
 //	Memory for the buffer containing the column index affinity string is managed along with the rest of the Index structure. It will be released when sqlite3DeleteIndex() is called.
 func (v *Vdbe) IndexAffinityStr(pIdx *Index) string {
 	if pIdx.zColAff == "" {
 		//	The first time a column affinity string for a particular index is required, it is allocated and populated here. It is then stored as a member of the Index structure for subsequent use.
 		//	The column affinity string will eventually be deleted by sqliteDeleteIndex() when the Index structure itself is cleaned up.
-		for _, column := range pIdx.Columns {
-			pIdx.zColAff = append(pIdx.zColAff, pIdx.pTable.Columns[column].affinity)
+		for i := range pIdx.Columns {
+			pIdx.zColAff = append(pIdx.zColAff, pIdx.ColumnAffinity(i))
+		}
+		switch pTab := pIdx.pTable; {
+		case pTab.HasRowid():
+			pIdx.zColAff = append(pIdx.zColAff, SQLITE_AFF_INTEGER)
+		case !pIdx.IsClustered():
+			pPk := pTab.PrimaryKey()
+			for i := range pPk.Columns {
+				pIdx.zColAff = append(pIdx.zColAff, pPk.ColumnAffinity(i))
+			}
 		}
-		pIdx.zColAff = append(pIdx.zColAff, SQLITE_AFF_INTEGER)
 	}
 	return pIdx.zColAff
 }
@@ -419,9 +433,15 @@ static int xferOptimization(
   int isView;                 /* True if attempting to insert into a view */
   Trigger *pTrigger;          /* List of triggers on pTab, if required */
   int tmask;                  /* Mask of trigger times */
+  Upsert *pUpsert;            /* The ON CONFLICT clause, if any */
+  Returning *pReturning;      /* The RETURNING clause, if any */
 
   db = pParse.db;
   memset(&dest, 0, sizeof(dest));
+  pUpsert = pParse.pUpsert
+  pParse.pUpsert = nil
+  pReturning = pParse.pReturning
+  pParse.pReturning = nil
   if( pParse.nErr || db.mallocFailed ){
     goto insert_cleanup;
   }
@@ -466,6 +486,20 @@ static int xferOptimization(
     goto insert_cleanup;
   }
 
+	//	Find the constraint that an ON CONFLICT clause applies to.
+	if pUpsert != nil {
+		switch {
+		case isView:
+			pParse.SetErrorMsg("cannot UPSERT a view")
+			goto insert_cleanup
+		case pTab.IsVirtual():
+			pParse.SetErrorMsg("UPSERT not implemented for virtual table \"%v\"", pTab.Name)
+			goto insert_cleanup
+		case pParse.UpsertAnalyzeTarget(pTab, pUpsert) != SQLITE_OK:
+			goto insert_cleanup
+		}
+	}
+
   /* Allocate a VDBE
   */
   v = pParse.GetVdbe()
@@ -473,6 +507,16 @@ static int xferOptimization(
   if( pParse.nested==0 ) sqlite3VdbeCountChanges(v);
   pParse.BeginWriteOperation(pSelect || pTrigger, iDb)
 
+	//	Rows changed by the DO UPDATE of an UPSERT are returned along with the rows inserted.
+	if pReturning != nil {
+		if pParse.ReturningBegin(pReturning, pTab) != SQLITE_OK {
+			goto insert_cleanup
+		}
+		if pUpsert != nil {
+			pUpsert.pReturning = pReturning
+		}
+	}
+
   /* If the statement is of the form
   **
   **       INSERT INTO <table1> SELECT * FROM <table2>;
@@ -482,7 +526,7 @@ static int xferOptimization(
   **
   ** This is the 2nd template.
   */
-  if( pColumn==0 && xferOptimization(pParse, pTab, pSelect, onError, iDb) ){
+  if( pColumn==0 && pUpsert==0 && pReturning==0 && xferOptimization(pParse, pTab, pSelect, onError, iDb) ){
     assert( !pTrigger );
     assert( pList==0 );
     goto insert_end;
@@ -604,14 +648,13 @@ static int xferOptimization(
   }
 
   /* Make sure the number of columns in the source data matches the number
-  ** of columns to be inserted into the table.
+  ** of columns to be inserted into the table. No values are supplied for
+  ** hidden and generated columns.
   */
-  if( pTab.IsVirtual() ){
-    for(i=0; i<pTab.nCol; i++){
-		if pTab.Columns[i].IsHidden {
-			nHidden++
-		}
-    }
+  for(i=0; i<pTab.nCol; i++){
+	if pTab.Columns[i].IsHidden || pTab.Columns[i].IsGenerated() {
+		nHidden++
+	}
   }
   if( pColumn==0 && nColumn && nColumn!=(pTab.nCol-nHidden) ){
     pParse.SetErrorMsg("table %v has %v columns but %v values were supplied", pTabList, pTab.nCol-nHidden, nColumn);
@@ -640,6 +683,10 @@ static int xferOptimization(
     for(i=0; i<pColumn.nId; i++){
       for(j=0; j<pTab.nCol; j++){
         if CaseInsensitiveMatch(pColumn.a[i].Name, pTab.Columns[j].Name) {
+          if pTab.Columns[j].IsGenerated() {
+            pParse.SetErrorMsg("cannot INSERT into generated column \"%v\"", pTab.Columns[j].Name)
+            goto insert_cleanup
+          }
           pColumn[i].idx = j;
           if( j==pTab.iPKey ){
             keyColumn = i;
@@ -648,7 +695,7 @@ static int xferOptimization(
         }
       }
       if( j>=pTab.nCol ){
-        if( sqlite3IsRowid(pColumn.a[i].Name) ){
+        if( pTab.HasRowid() && sqlite3IsRowid(pColumn.a[i].Name) ){
           keyColumn = i;
         }else{
           pParse.SetErrorMsg("table %v has no column named %v", pTabList, pColumn.a[i].Name);
@@ -665,6 +712,11 @@ static int xferOptimization(
   */
   if( pColumn==0 && nColumn>0 ){
     keyColumn = pTab.iPKey;
+    for i := 0; i < pTab.iPKey; i++ {
+      if pTab.Columns[i].IsGenerated() {
+        keyColumn--
+      }
+    }
   }
     
   /* Initialize the count of rows to be inserted
@@ -760,11 +812,18 @@ static int xferOptimization(
     */
     assert( !pTab.IsVirtual() );
 
-    /* Create the new column data
+    /* Create the new column data. Generated columns are computed from the
+    ** others once they are all loaded.
     */
+    nHidden = 0;
     for(i=0; i<pTab.nCol; i++){
+      if pTab.Columns[i].IsGenerated() {
+        v.AddOp2(OP_Null, 0, regCols+i+1)
+        nHidden++
+        continue
+      }
       if( pColumn==0 ){
-        j = i;
+        j = i - nHidden;
       }else{
         for(j=0; j<pColumn.nId; j++){
           if( pColumn[j].idx==i ) break;
@@ -779,6 +838,7 @@ static int xferOptimization(
         sqlite3ExprCodeAndCache(pParse, pList.a[j].Expr, regCols+i+1);
       }
     }
+    pParse.CodeGeneratedColumns(pTab, regCols+1)
 
     /* If this is an INSERT on a view with an INSTEAD OF INSERT trigger,
     ** do not attempt any conversions before assembling the record.
@@ -839,7 +899,9 @@ static int xferOptimization(
         }
         v.AddOp1(OP_MustBeInt, regRowid);
       }
-    }else if( pTab.IsVirtual() ){
+    }else if( pTab.IsVirtual() || !pTab.HasRowid() ){
+      /* The key of a row of a WITHOUT ROWID table is made from its PRIMARY KEY
+      ** columns by sqlite3GenerateConstraintChecks() */
       v.AddOp2(OP_Null, 0, regRowid);
     }else{
       v.AddOp3(OP_NewRowid, baseCur, regRowid, regAutoinc);
@@ -862,8 +924,8 @@ static int xferOptimization(
         continue;
       }
       if( pColumn==0 ){
-        if &pTab.Columns[i].IsHidden {
-          assert( pTab.IsVirtual() );
+        if pTab.Columns[i].IsHidden || pTab.Columns[i].IsGenerated() {
+          assert( pTab.IsVirtual() || pTab.Columns[i].IsGenerated() );
           j = -1;
           nHidden++;
         }else{
@@ -897,11 +959,13 @@ static int xferOptimization(
     }else
     {
       int isReplace;    /* Set to true if constraints may cause a replace */
+      pParse.CodeGeneratedColumns(pTab, regData)
       sqlite3GenerateConstraintChecks(pParse, pTab, baseCur, regIns, aRegIdx,
-          keyColumn>=0, 0, onError, endOfLoop, &isReplace
+          keyColumn>=0, 0, onError, endOfLoop, &isReplace, pUpsert
       );
       pParse.FkCheck(pTab, 0, regIns)
-      pParse.CompleteInsertion(pTab, baseCur, regIns, aRegIdx, 0, appendFlag, isReplace == 0)
+      //	A DO UPDATE may have moved the cursors, so the seek result of the uniqueness checks cannot be reused.
+      pParse.CompleteInsertion(pTab, baseCur, regIns, aRegIdx, 0, appendFlag, isReplace == 0 && pUpsert == nil)
     }
   }
 
@@ -917,6 +981,11 @@ static int xferOptimization(
         pTab, regData-2-pTab.nCol, onError, endOfLoop);
   }
 
+	//	Save the RETURNING values of the new row, as changed by defaults and triggers.
+	if pReturning != nil {
+		pParse.ReturningRow(pReturning, regRowid)
+	}
+
   /* The bottom of the main insertion loop, if the data source
   ** is a SELECT statement.
   */
@@ -947,12 +1016,17 @@ insert_end:
     sqlite3AutoincrementEnd(pParse);
   }
 
+	//	Return the rows saved for the RETURNING clause. These replace the count of rows inserted.
+	if pReturning != nil {
+		pParse.ReturningEnd(pReturning)
+	}
+
   /*
   ** Return the number of rows inserted. If this routine is 
   ** generating code because of a call to sqlite3NestedParse(), do not
   ** invoke the callback function.
   */
-  if( (db.flags&SQLITE_CountRows) && !pParse.nested && !pParse.pTriggerTab ){
+  if( (db.flags&SQLITE_CountRows) && !pParse.nested && !pParse.pTriggerTab && pReturning==0 ){
     v.AddOp2(OP_ResultRow, regRowCount, 1);
     sqlite3VdbeSetNumCols(v, 1);
     sqlite3VdbeSetColName(v, 0, COLNAME_NAME, "rows inserted", SQLITE_STATIC);
@@ -1000,6 +1074,7 @@ insert_cleanup:
 //			UNIQUE           REPLACE      The other row that conflicts with the row being inserted is removed.
 //			CHECK            REPLACE      Illegal.  The results in an exception.
 //	Which action to take is determined by the overrideError parameter. Or if overrideError == OE_Default, then the pParse.onError parameter is used. Or if pParse.onError == OE_Default then the onError value for the constraint is used.
+//	If pUpsert is not nil, a conflict on the constraint it targets (or on any PRIMARY KEY or UNIQUE constraint if it has no target) is resolved by the UPSERT instead: DO NOTHING acts as IGNORE, and DO UPDATE updates the conflicting row and then jumps to ignoreDest.
 //	The calling routine must open a read/write cursor for pTab with cursor number "baseCur". All indices of pTab must also have open read/write cursors with cursor number baseCur + i for the i-th cursor. Except, if there is no possibility of a REPLACE action then cursors do not need to be open for indices where aRegIdx[i] == 0.
 void sqlite3GenerateConstraintChecks(
   Parse *pParse,      /* The parser context */
@@ -1011,7 +1086,8 @@ void sqlite3GenerateConstraintChecks(
   int isUpdate,       /* True for UPDATE, False for INSERT */
   int overrideError,  /* Override onError to this if not OE_Default */
   int ignoreDest,     /* Jump to this label on an OE_Ignore resolution */
-  int *pbMayReplace   /* OUT: Set to true if constraint may cause a replace */
+  int *pbMayReplace,  /* OUT: Set to true if constraint may cause a replace */
+  Upsert *pUpsert     /* The ON CONFLICT clause of an INSERT, or NULL */
 ){
   int i;              /* loop counter */
   Vdbe *v;            /* VDBE under constrution */
@@ -1104,14 +1180,27 @@ void sqlite3GenerateConstraintChecks(
 		}
 	}
 
+	//	The key of a row of a WITHOUT ROWID table is a record of its PRIMARY KEY columns. It takes the place of the rowid.
+	pPk := pTab.PrimaryKey()
+	if !pTab.HasRowid() {
+		pParse.CodeRowKeyFromRegisters(pTab, regData, regRowid)
+	}
+
   //	If we have an INTEGER PRIMARY KEY, make sure the primary key of the new record does not previously exist. Except, if this is an UPDATE and the primary key is not changing, that is OK.
-  if( rowidChng ){
+  if( rowidChng && pTab.HasRowid() ){
     onError = pTab.keyConf;
     if( overrideError!=OE_Default ){
       onError = overrideError;
     }else if( onError==OE_Default ){
       onError = OE_Abort;
     }
+    if pUpsert.Matches(nil) {
+      if pUpsert.pSet == nil {
+        onError = OE_Ignore
+      } else {
+        onError = OE_Update
+      }
+    }
     
     if( isUpdate ){
       j2 = v.AddOp3(OP_Eq, regRowid, 0, rowidChng);
@@ -1156,6 +1245,10 @@ void sqlite3GenerateConstraintChecks(
         v.AddOp2(OP_Goto, 0, ignoreDest);
         break;
       }
+      case OE_Update:
+        pParse.UpsertDoUpdate(pUpsert, pTab, regRowid, regRowid)
+        v.AddOp2(OP_Goto, 0, ignoreDest)
+        break
     }
     v.JumpHere(j3)
     if( isUpdate ){
@@ -1170,24 +1263,55 @@ void sqlite3GenerateConstraintChecks(
 
     if( aRegIdx[iCur]==0 ) continue;  /* Skip unused indices */
 
-    /* Create a key for accessing the index entry */
-    regIdx = pParse.GetTempRange(len(pIdx.Columns) + 1)
+    /* A partial index has no entry for a row that fails its WHERE clause. aRegIdx[iCur] is left NULL so that
+    ** CompleteInsertion() does not insert one. */
+    skip := v.MakeLabel()
+    if pIdx.pPartIdxWhere != nil {
+      v.AddOp2(OP_Null, 0, aRegIdx[iCur])
+      pParse.ckBase = regData
+      sqlite3ExprIfFalse(pParse, pIdx.pPartIdxWhere, skip, SQLITE_JUMPIFNULL)
+    }
+
+    /* Create a key for accessing the index entry. It ends with the rowid, or
+    ** with the PRIMARY KEY columns of a WITHOUT ROWID table. There is no key for
+    ** the PRIMARY KEY of a WITHOUT ROWID table, whose entry is the row itself. */
+    nKeyCol := pIdx.KeyFields()
+    regIdx = pParse.GetTempRange(nKeyCol)
 	for i, column := range pIdx.Columns {
-		if column == pTab.iPKey {
+		if pIdx.IsClustered() {
+			break
+		}
+		switch column {
+		case XN_EXPR:
+			pParse.ckBase = regData
+			sqlite3ExprCode(pParse, pIdx.aColExpr[i], regIdx + i)
+		case pTab.iPKey:
 			v.AddOp2(OP_SCopy, regRowid, regIdx + i)
-		} else {
-			v.AddOp2(OP_SCopy, regData + idx, regIdx + i)
+		default:
+			v.AddOp2(OP_SCopy, regData + column, regIdx + i)
 		}
 	}
-    v.AddOp2(OP_SCopy, regRowid, regIdx+i);
-    v.AddOp3(OP_MakeRecord, regIdx, len(pIdx.Columns) + 1, aRegIdx[iCur])
-    sqlite3VdbeChangeP4(v, -1, v.IndexAffinityStr(pIdx), P4_TRANSIENT)
-    sqlite3ExprCacheAffinityChange(pParse, regIdx, len(pIdx.Columns) + 1)
+    switch {
+    case pTab.HasRowid():
+      v.AddOp2(OP_SCopy, regRowid, regIdx + len(pIdx.Columns))
+    case !pIdx.IsClustered():
+      for i, column := range pPk.Columns {
+        v.AddOp2(OP_SCopy, regData + column, regIdx + len(pIdx.Columns) + i)
+      }
+    }
+    if !pIdx.IsClustered() {
+      v.AddOp3(OP_MakeRecord, regIdx, nKeyCol, aRegIdx[iCur])
+      sqlite3VdbeChangeP4(v, -1, v.IndexAffinityStr(pIdx), P4_TRANSIENT)
+      sqlite3ExprCacheAffinityChange(pParse, regIdx, nKeyCol)
+    }
 
-    /* Find out what action to take in case there is an indexing conflict */
+    /* Find out what action to take in case there is an indexing conflict. The
+    ** key of a WITHOUT ROWID table cannot collide with itself in an UPDATE that
+    ** does not change it. */
     onError = pIdx.onError;
-    if( onError==OE_None ){ 
-      pParse.ReleaseTempRange(regIdx, len(pIdx.Columns) + 1)
+    if( onError==OE_None || pIdx.IsClustered() && isUpdate && rowidChng==0 ){ 
+      pParse.ReleaseTempRange(regIdx, nKeyCol)
+      v.ResolveLabel(skip)
       continue;  /* pIdx is not a UNIQUE index */
     }
     if( overrideError!=OE_Default ){
@@ -1199,15 +1323,53 @@ void sqlite3GenerateConstraintChecks(
       if( onError==OE_Ignore ) onError = OE_Replace;
       else if( onError==OE_Fail ) onError = OE_Abort;
     }
+    if pUpsert.Matches(pIdx) {
+      if pUpsert.pSet == nil {
+        onError = OE_Ignore
+      } else {
+        onError = OE_Update
+      }
+    }
     
-    /* Check to see if the new index entry will be unique */
+    /* Check to see if the new index entry will be unique. If not, leave the key of
+    ** the conflicting row in regR. For a WITHOUT ROWID table its PRIMARY KEY columns
+    ** are also left in the registers starting at regPk. */
     regR = pParse.GetTempReg()
-    v.AddOp2(OP_SCopy, regOldRowid, regR);
-    j3 = sqlite3VdbeAddOp4(v, OP_IsUnique, baseCur + iCur + 1, 0, regR, SQLITE_INT_TO_PTR(regIdx), P4_INT32)
-    pParse.ReleaseTempRange(regIdx, len(pIdx.Columns) + 1)
+    ok := v.MakeLabel()
+    regPk := 0
+    switch {
+    case pTab.HasRowid():
+      v.AddOp2(OP_SCopy, regOldRowid, regR);
+      sqlite3VdbeAddOp4(v, OP_IsUnique, baseCur + iCur + 1, ok, regR, SQLITE_INT_TO_PTR(regIdx), P4_INT32)
+    case pIdx.IsClustered():
+      if isUpdate {
+        v.AddOp3(OP_Eq, regRowid, ok, rowidChng)
+      }
+      pParse.SeekRowKey(pTab, baseCur, ok, regRowid)
+      v.AddOp2(OP_SCopy, regRowid, regR)
+      regPk = pParse.GetTempRange(len(pPk.Columns))
+      for i, column := range pPk.Columns {
+        v.AddOp2(OP_SCopy, regData + column, regPk + i)
+      }
+    default:
+      /* An entry with a NULL in it never conflicts */
+      for i := range pIdx.Columns {
+        v.AddOp2(OP_IsNull, regIdx + i, ok)
+      }
+      sqlite3VdbeAddOp4Int(v, OP_NotFound, baseCur + iCur + 1, ok, regIdx, len(pIdx.Columns))
+      regPk = pParse.GetTempRange(len(pPk.Columns))
+      for i := range pPk.Columns {
+        v.AddOp3(OP_Column, baseCur + iCur + 1, len(pIdx.Columns) + i, regPk + i)
+      }
+      v.AddOp3(OP_MakeRecord, regPk, len(pPk.Columns), regR)
+      if isUpdate {
+        v.AddOp3(OP_Eq, regR, ok, regOldRowid)
+      }
+    }
+    pParse.ReleaseTempRange(regIdx, nKeyCol)
 
     /* Generate code that executes if the new index entry is not unique */
-    assert( onError == OE_Rollback || onError == OE_Abort || onError == OE_Fail || onError == OE_Ignore || onError == OE_Replace )
+    assert( onError == OE_Rollback || onError == OE_Abort || onError == OE_Fail || onError == OE_Ignore || onError == OE_Replace || onError == OE_Update )
     switch onError {
       case OE_Rollback:
       case OE_Abort:
@@ -1222,23 +1384,38 @@ void sqlite3GenerateConstraintChecks(
 			zSep = "column "
 		}
 		for _, column := range pIdx.Columns {
+			if column == XN_EXPR {
+				errMsg = "indexed columns are not unique"
+				break
+			}
 			zCol = pTab.Columns[column].Name
 			errMsg = append(errMsg, zSep, zCol)
 			zSep = ", "
         }
-		if len(pIdx.Columns) > 1 {
+		switch {
+		case pIdx.aColExpr != nil:
+		case len(pIdx.Columns) > 1:
         	errMsg = append(errMsg, " are not unique")
-		} else {
+		default:
 			errMsg = append(errMsg, " is not unique")
 		}
         sqlite3HaltConstraint(pParse, onError, errMsg, 0);
         break;
       }
       case OE_Ignore: {
-        assert( seenReplace==0 );
+        assert( seenReplace==0 || pUpsert!=0 );
         v.AddOp2(OP_Goto, 0, ignoreDest);
         break;
       }
+      case OE_Update:
+        //	regR holds the rowid of the conflicting row.
+        if !pTab.HasRowid() {
+          pParse.UpsertDoUpdate(pUpsert, pTab, regRowid, regPk)
+        } else {
+          pParse.UpsertDoUpdate(pUpsert, pTab, regRowid, regR)
+        }
+        v.AddOp2(OP_Goto, 0, ignoreDest)
+        break
       default: {
         Trigger *pTrigger = 0;
         assert( onError==OE_Replace );
@@ -1251,8 +1428,12 @@ void sqlite3GenerateConstraintChecks(
         break;
       }
     }
-    v.JumpHere(j3)
+    v.ResolveLabel(ok)
+    if regPk > 0 {
+      pParse.ReleaseTempRange(regPk, len(pPk.Columns))
+    }
     pParse.ReleaseTempReg(regR)
+    v.ResolveLabel(skip)
   }
   
   if( pbMayReplace ){
@@ -1269,7 +1450,10 @@ func (pParse *Parse) CompleteInsertion(table *Table, baseCur, regRowid int, regi
 	assert( v != nil )
 	assert( table.Select == nil )										//	This table is not a VIEW
 	for i := len(table.Indices) - 1; i > -1; i-- {
-		if registers[i] != 0 {
+		if registers[i] != 0 && !table.Indices[i].IsClustered() {
+			if table.Indices[i].pPartIdxWhere != nil {
+				v.AddOp2(OP_IsNull, registers[i], v.CurrentAddr() + 2)
+			}
 			v.AddOp2(OP_IdxInsert, baseCur + i + 1, registers[i])
 			if useSeekResult {
 				v.ChangeP5(OPFLAG_USESEEKRESULT)
@@ -1278,10 +1462,18 @@ func (pParse *Parse) CompleteInsertion(table *Table, baseCur, regRowid int, regi
 	}
 	regData := regRowid + 1
 	regRec := pParse.GetTempReg()
-	v.AddOp3(OP_MakeRecord, regData, table.nCol, regRec)
-	sqlite3TableAffinityStr(v, table)
+	pParse.CodeTableRecord(table, regData, regRec)
 	sqlite3ExprCacheAffinityChange(pParse, regData, table.nCol)
 
+	//	The row of a WITHOUT ROWID table is the entry of its PRIMARY KEY
+	if !table.HasRowid() {
+		v.AddOp2(OP_IdxInsert, baseCur, regRec)
+		if !pParse.nested {
+			v.ChangeP5(OPFLAG_NCHANGE)
+		}
+		return
+	}
+
 	pik_flags		byte
 	if !pParse.nested {
 		pik_flags = OPFLAG_NCHANGE
@@ -1314,6 +1506,11 @@ func (pParse *Parse) OpenTableAndIndices(table *Table, baseCur, op int) (count i
 		pParse.OpenTable(table, baseCur, iDb, op)
 		i := 1
 		for _, index := range table.Indices {
+			//	The PRIMARY KEY of a WITHOUT ROWID table is read and written through the table cursor. Its cursor number is left unused.
+			if index.IsClustered() {
+				i++
+				continue
+			}
 			pKey := pParse.IndexKeyinfo(index)
 			assert( index.Schema == table.Schema )
 			sqlite3VdbeAddOp4(v, op, i + baseCur, pIdx.tnum, iDb, (char*)pKey, P4_KEYINFO_HANDOFF)
@@ -1333,17 +1530,22 @@ func (pParse *Parse) OpenTableAndIndices(table *Table, baseCur, op int) (count i
 //		*   The same DESC and ASC markings occurs on all columns
 //		*   The same onError processing (OE_Abort, OE_Ignore, etc)
 //	*   The same collating sequence on each column
+//		*   The same WHERE clause, if the indices are partial
+//		*   The same expression in each column that is an expression
 func (pSrc *Index) xferCompatible(pDest *Index) bool {
 	assert( pDest && pSrc )
 	assert( pDest.pTable != pSrc.pTable )
 
-	if len(pDest.Columns) != len(pSrc.Columns) || pDest.onError != pSrc.onError {
+	if len(pDest.Columns) != len(pSrc.Columns) || pDest.onError != pSrc.onError || pSrc.pPartIdxWhere.Compare(pDest.pPartIdxWhere) != 0 {
 		return false
 	}
 	for i, column := range pSrc.Columns {
 		if column != pDest.Columns[i] || pSrc.aSortOrder[i] != pDest.aSortOrder[i] || !CaseInsensitiveMatch(pSrc.azColl[i],pDest.azColl[i]) {
 			return false
 		}
+		if column == XN_EXPR && pSrc.aColExpr[i].Compare(pDest.aColExpr[i]) != 0 {
+			return false
+		}
 	}
 	return true
 }
@@ -1416,6 +1618,8 @@ static int xferOptimization(
 	case pSrc.Select:																fallthrough			//	tab2 may not be a view
 	case pDest.nCol != pSrc.nCol:													fallthrough			//	Number of columns must be the same in tab1 and tab2
 	case pDest.iPKey!=pSrc.iPKey:													fallthrough			//	Both tables must have the same INTEGER PRIMARY KEY */
+	case !pDest.HasRowid() || !pSrc.HasRowid():										fallthrough			//	Rows are only copied between tables with a rowid
+	case pDest.HasGenerated() || pSrc.HasGenerated():								fallthrough			//	Generated columns are computed rather than copied
 	case pDest.pCheck != nil && sqlite3ExprListCompare(pSrc.pCheck, pDest.pCheck):						//	Tables have different CHECK constraints.  Ticket #2252
 		return
 	}
@@ -0,0 +1,1350 @@
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+	"unsafe"
+)
+
+//	This file implements the JSON SQL functions and the json_each and json_tree table-valued functions.
+//
+//		json(X)                             X, validated and minified
+//		json_valid(X)                       1 if X is well-formed JSON, otherwise 0
+//		json_type(X [,P])                   Type of the element of X at path P: "null", "true", "object", ...
+//		json_array_length(X [,P])           Number of elements in the array at path P
+//		json_extract(X, P1, ...)            SQL value of the element of X at path P1, or an array of the elements at each path
+//		json_set(X, P1, V1, ...)            X with the element at each path Pn replaced or created with the value Vn
+//		json_insert(X, P1, V1, ...)         As json_set(), but existing elements are left unchanged
+//		json_replace(X, P1, V1, ...)        As json_set(), but missing elements are not created
+//		json_remove(X, P1, ...)             X with the element at each path removed
+//		json_array(V1, ...)                 An array of the values
+//		json_object(L1, V1, ...)            An object of the label/value pairs
+//		json_group_array(V)                 Aggregate: an array of the values in the group
+//		json_group_object(L, V)             Aggregate: an object of the label/value pairs in the group
+//		X -> P                              JSON text of the element of X at path P
+//		X ->> P                             SQL value of the element of X at path P
+//
+//	A path is "$" followed by zero or more ".label", ."quoted label", "[N]" or "[#-N]" steps. "[#]" is the element one past the end of
+//	an array, at which json_set() and json_insert() append. The right operand of -> and ->> may also be an integer array index or an
+//	object label.
+//
+//	Documents are parsed into a tree of jsonNode objects and rendered back into minified text. Text returned by a JSON function is
+//	marked with the JSON_SUBTYPE subtype, so that when it is passed to another JSON function it is embedded as JSON rather than
+//	quoted as a string.
+//
+//	Parse.ParseJsonOperators() (see Parse.Run()) rewrites the -> and ->> operators into calls of the functions "->" and "->>".
+
+//	The subtype of text that holds JSON.
+const JSON_SUBTYPE = 'J'
+
+//	JSON element types.
+const (
+	JSON_NULL = iota
+	JSON_TRUE
+	JSON_FALSE
+	JSON_INT
+	JSON_REAL
+	JSON_STRING
+	JSON_ARRAY
+	JSON_OBJECT
+)
+
+//	Names of the JSON element types, as returned by json_type() and the type column of json_each and json_tree.
+var jsonType = [...]string{ "null", "true", "false", "integer", "real", "text", "array", "object" }
+
+//	A jsonNode is an element of a parsed JSON document.
+type jsonNode struct {
+	eType		byte				//	JSON_NULL, JSON_TRUE, ...
+	zText		string				//	JSON_INT and JSON_REAL: the number as written. JSON_STRING: the decoded string
+	aElem		[]*jsonNode			//	JSON_ARRAY and JSON_OBJECT: the elements
+	aLabel		[]string			//	JSON_OBJECT: the label of each element
+}
+
+//	A jsonParser parses JSON text into a tree of jsonNode objects.
+type jsonParser struct {
+	z			string
+	i			int
+	iDepth		int
+}
+
+//	Nesting deeper than this is reported as malformed JSON, so that a hostile document cannot exhaust the stack.
+const jsonMaxDepth = 2000
+
+//	Parse z into a jsonNode tree. Return nil if z is not well-formed JSON.
+func jsonParse(z string) *jsonNode {
+	p := &jsonParser{ z: z }
+	pNode := p.parseValue()
+	if pNode != nil {
+		p.skipSpace()
+		if p.i != len(p.z) {
+			pNode = nil
+		}
+	}
+	return pNode
+}
+
+func (p *jsonParser) skipSpace() {
+	for p.i < len(p.z) {
+		switch p.z[p.i] {
+		case ' ', '\t', '\n', '\r':
+			p.i++
+		default:
+			return
+		}
+	}
+}
+
+func (p *jsonParser) parseValue() *jsonNode {
+	p.skipSpace()
+	if p.i >= len(p.z) {
+		return nil
+	}
+	switch c := p.z[p.i]; {
+	case c == '{':
+		if p.iDepth++; p.iDepth > jsonMaxDepth {
+			return nil
+		}
+		pNode := &jsonNode{ eType: JSON_OBJECT }
+		p.i++
+		if p.skipSpace(); p.i < len(p.z) && p.z[p.i] == '}' {
+			p.i++
+			p.iDepth--
+			return pNode
+		}
+		for {
+			if p.skipSpace(); p.i >= len(p.z) || p.z[p.i] != '"' {
+				return nil
+			}
+			zLabel, ok := p.parseString()
+			if !ok {
+				return nil
+			}
+			if p.skipSpace(); p.i >= len(p.z) || p.z[p.i] != ':' {
+				return nil
+			}
+			p.i++
+			pElem := p.parseValue()
+			if pElem == nil {
+				return nil
+			}
+			pNode.aLabel = append(pNode.aLabel, zLabel)
+			pNode.aElem = append(pNode.aElem, pElem)
+			if p.skipSpace(); p.i >= len(p.z) {
+				return nil
+			}
+			p.i++
+			switch p.z[p.i - 1] {
+			case '}':
+				p.iDepth--
+				return pNode
+			case ',':
+			default:
+				return nil
+			}
+		}
+	case c == '[':
+		if p.iDepth++; p.iDepth > jsonMaxDepth {
+			return nil
+		}
+		pNode := &jsonNode{ eType: JSON_ARRAY }
+		p.i++
+		if p.skipSpace(); p.i < len(p.z) && p.z[p.i] == ']' {
+			p.i++
+			p.iDepth--
+			return pNode
+		}
+		for {
+			pElem := p.parseValue()
+			if pElem == nil {
+				return nil
+			}
+			pNode.aElem = append(pNode.aElem, pElem)
+			if p.skipSpace(); p.i >= len(p.z) {
+				return nil
+			}
+			p.i++
+			switch p.z[p.i - 1] {
+			case ']':
+				p.iDepth--
+				return pNode
+			case ',':
+			default:
+				return nil
+			}
+		}
+	case c == '"':
+		if z, ok := p.parseString(); ok {
+			return &jsonNode{ eType: JSON_STRING, zText: z }
+		}
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	case strings.HasPrefix(p.z[p.i:], "null"):
+		p.i += 4
+		return &jsonNode{ eType: JSON_NULL }
+	case strings.HasPrefix(p.z[p.i:], "true"):
+		p.i += 4
+		return &jsonNode{ eType: JSON_TRUE }
+	case strings.HasPrefix(p.z[p.i:], "false"):
+		p.i += 5
+		return &jsonNode{ eType: JSON_FALSE }
+	}
+	return nil
+}
+
+//	Parse the string that starts at the '"' at p.i and return its decoded value.
+func (p *jsonParser) parseString() (string, bool) {
+	var b strings.Builder
+	for p.i++; p.i < len(p.z); {
+		c := p.z[p.i]
+		switch {
+		case c == '"':
+			p.i++
+			return b.String(), true
+		case c < 0x20:
+			return "", false
+		case c != '\\':
+			b.WriteByte(c)
+			p.i++
+			continue
+		}
+		if p.i++; p.i >= len(p.z) {
+			return "", false
+		}
+		switch c = p.z[p.i]; c {
+		case '"', '\\', '/':
+			b.WriteByte(c)
+		case 'b':
+			b.WriteByte('\b')
+		case 'f':
+			b.WriteByte('\f')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 't':
+			b.WriteByte('\t')
+		case 'u':
+			r, ok := p.parseHex4()
+			if !ok {
+				return "", false
+			}
+			if utf16.IsSurrogate(r) && strings.HasPrefix(p.z[p.i + 1:], "\\u") {
+				p.i += 2
+				r2, ok := p.parseHex4()
+				if !ok {
+					return "", false
+				}
+				r = utf16.DecodeRune(r, r2)
+			}
+			b.WriteRune(r)
+		default:
+			return "", false
+		}
+		p.i++
+	}
+	return "", false
+}
+
+//	Parse the four hex digits following p.i, leaving p.i at the last of them.
+func (p *jsonParser) parseHex4() (rune, bool) {
+	if p.i + 4 >= len(p.z) {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(p.z[p.i + 1:p.i + 5], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	p.i += 4
+	return rune(v), true
+}
+
+func (p *jsonParser) parseNumber() *jsonNode {
+	iStart := p.i
+	eType := byte(JSON_INT)
+	digits := func() bool {
+		n := p.i
+		for p.i < len(p.z) && p.z[p.i] >= '0' && p.z[p.i] <= '9' {
+			p.i++
+		}
+		return p.i > n
+	}
+	if p.z[p.i] == '-' {
+		p.i++
+	}
+	if p.i < len(p.z) && p.z[p.i] == '0' {
+		p.i++
+	} else if !digits() {
+		return nil
+	}
+	if p.i < len(p.z) && p.z[p.i] == '.' {
+		p.i++
+		if !digits() {
+			return nil
+		}
+		eType = JSON_REAL
+	}
+	if p.i < len(p.z) && (p.z[p.i] == 'e' || p.z[p.i] == 'E') {
+		p.i++
+		if p.i < len(p.z) && (p.z[p.i] == '+' || p.z[p.i] == '-') {
+			p.i++
+		}
+		if !digits() {
+			return nil
+		}
+		eType = JSON_REAL
+	}
+	return &jsonNode{ eType: eType, zText: p.z[iStart:p.i] }
+}
+
+//	Return the minified JSON text of p.
+func (p *jsonNode) String() string {
+	var b strings.Builder
+	p.render(&b)
+	return b.String()
+}
+
+func (p *jsonNode) render(b *strings.Builder) {
+	switch p.eType {
+	case JSON_NULL:
+		b.WriteString("null")
+	case JSON_TRUE:
+		b.WriteString("true")
+	case JSON_FALSE:
+		b.WriteString("false")
+	case JSON_INT, JSON_REAL:
+		b.WriteString(p.zText)
+	case JSON_STRING:
+		jsonQuote(b, p.zText)
+	case JSON_ARRAY:
+		b.WriteByte('[')
+		for i, pElem := range p.aElem {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			pElem.render(b)
+		}
+		b.WriteByte(']')
+	case JSON_OBJECT:
+		b.WriteByte('{')
+		for i, pElem := range p.aElem {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			jsonQuote(b, p.aLabel[i])
+			b.WriteByte(':')
+			pElem.render(b)
+		}
+		b.WriteByte('}')
+	}
+}
+
+//	Append z to b as a JSON string literal.
+func jsonQuote(b *strings.Builder, z string) {
+	b.WriteByte('"')
+	for _, r := range z {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r == '\b':
+			b.WriteString("\\b")
+		case r == '\f':
+			b.WriteString("\\f")
+		case r < 0x20:
+			fmt.Fprintf(b, "\\u%04x", r)
+		case r == utf8.RuneError:
+			b.WriteString("\\ufffd")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+}
+
+//	Return the index of the element of object p with label zLabel, or -1.
+func (p *jsonNode) find(zLabel string) int {
+	for i, z := range p.aLabel {
+		if z == zLabel {
+			return i
+		}
+	}
+	return -1
+}
+
+//	Remove element i of array or object p.
+func (p *jsonNode) removeAt(i int) {
+	p.aElem = append(p.aElem[:i], p.aElem[i + 1:]...)
+	if p.eType == JSON_OBJECT {
+		p.aLabel = append(p.aLabel[:i], p.aLabel[i + 1:]...)
+	}
+}
+
+//	A jsonPathStep is one step of a JSON path: either an object label or an array index.
+type jsonPathStep struct {
+	isLabel		bool
+	zLabel		string
+	iIndex		int					//	Array index. If fromEnd, the number of elements before the end of the array
+	fromEnd		bool				//	True for "[#]" and "[#-N]"
+}
+
+//	Return the array index selected by s in an array of n elements. The result is n for the "[#]" step and negative if s selects no
+//	element.
+func (s *jsonPathStep) index(n int) int {
+	if s.fromEnd {
+		return n - s.iIndex
+	}
+	return s.iIndex
+}
+
+//	Parse a JSON path. ok is false if zPath is not a well-formed path.
+func jsonParsePath(zPath string) (aStep []jsonPathStep, ok bool) {
+	if zPath == "" || zPath[0] != '$' {
+		return nil, false
+	}
+	for i := 1; i < len(zPath); {
+		switch zPath[i] {
+		case '.':
+			i++
+			var step jsonPathStep
+			step.isLabel = true
+			if i < len(zPath) && zPath[i] == '"' {
+				j := strings.IndexByte(zPath[i + 1:], '"')
+				if j < 0 {
+					return nil, false
+				}
+				step.zLabel = zPath[i + 1:i + 1 + j]
+				i += j + 2
+			} else {
+				j := i
+				for j < len(zPath) && zPath[j] != '.' && zPath[j] != '[' {
+					j++
+				}
+				if j == i {
+					return nil, false
+				}
+				step.zLabel = zPath[i:j]
+				i = j
+			}
+			aStep = append(aStep, step)
+		case '[':
+			j := strings.IndexByte(zPath[i:], ']')
+			if j < 0 {
+				return nil, false
+			}
+			z := zPath[i + 1:i + j]
+			i += j + 1
+			var step jsonPathStep
+			if strings.HasPrefix(z, "#") {
+				step.fromEnd = true
+				if z = z[1:]; z == "" {
+					aStep = append(aStep, step)
+					continue
+				}
+				if z[0] != '-' {
+					return nil, false
+				}
+				z = z[1:]
+			}
+			if z == "" || strings.IndexFunc(z, func(r rune) bool { return r < '0' || r > '9' }) >= 0 {
+				return nil, false
+			}
+			n, err := strconv.Atoi(z)
+			if err != nil {
+				return nil, false
+			}
+			step.iIndex = n
+			aStep = append(aStep, step)
+		default:
+			return nil, false
+		}
+	}
+	return aStep, true
+}
+
+//	Return the index of the element of p selected by step, or -1 if there is none.
+func (p *jsonNode) stepIndex(step jsonPathStep) int {
+	switch {
+	case step.isLabel && p.eType == JSON_OBJECT:
+		return p.find(step.zLabel)
+	case !step.isLabel && p.eType == JSON_ARRAY:
+		if i := step.index(len(p.aElem)); i >= 0 && i < len(p.aElem) {
+			return i
+		}
+	}
+	return -1
+}
+
+//	Return the element of p at the path aStep, or nil if there is none.
+func (p *jsonNode) lookup(aStep []jsonPathStep) *jsonNode {
+	for _, step := range aStep {
+		i := p.stepIndex(step)
+		if i < 0 {
+			return nil
+		}
+		p = p.aElem[i]
+	}
+	return p
+}
+
+//	Kinds of edit made by jsonEdit().
+const (
+	jsonEditSet = iota				//	json_set(): replace or create
+	jsonEditInsert					//	json_insert(): create only
+	jsonEditReplace					//	json_replace(): replace only
+	jsonEditRemove					//	json_remove()
+)
+
+//	Apply an edit at the path aStep below *pp. The edit is a no-op if the path does not exist and eEdit does not create elements, or
+//	if it passes through an element of the wrong type. Removing the element at path "$" sets *pp to nil.
+func jsonEdit(pp **jsonNode, aStep []jsonPathStep, pVal *jsonNode, eEdit int) {
+	if len(aStep) == 0 {
+		switch eEdit {
+		case jsonEditSet, jsonEditReplace:
+			*pp = pVal
+		case jsonEditRemove:
+			*pp = nil
+		}
+		return
+	}
+	p := *pp
+	step := aStep[0]
+	isLast := len(aStep) == 1
+	i := -1
+	switch {
+	case step.isLabel && p.eType == JSON_OBJECT:
+		i = p.find(step.zLabel)
+	case !step.isLabel && p.eType == JSON_ARRAY:
+		if i = step.index(len(p.aElem)); i >= len(p.aElem) {
+			//	Only "[#]" or an index equal to the length may be appended, and only as the last step.
+			if i == len(p.aElem) && isLast && (eEdit == jsonEditSet || eEdit == jsonEditInsert) {
+				p.aElem = append(p.aElem, pVal)
+			}
+			return
+		}
+	default:
+		return
+	}
+	switch {
+	case i >= 0 && isLast && eEdit == jsonEditRemove:
+		p.removeAt(i)
+	case i >= 0 && isLast && eEdit == jsonEditInsert:
+	case i >= 0:
+		jsonEdit(&p.aElem[i], aStep[1:], pVal, eEdit)
+	case step.isLabel && (eEdit == jsonEditSet || eEdit == jsonEditInsert):
+		//	Create the missing label, and objects for any labels after it.
+		if pNew := jsonBuild(aStep[1:], pVal); pNew != nil {
+			p.aLabel = append(p.aLabel, step.zLabel)
+			p.aElem = append(p.aElem, pNew)
+		}
+	}
+}
+
+//	Return pVal nested in objects so that it is at path aStep, or nil if aStep contains an array index.
+func jsonBuild(aStep []jsonPathStep, pVal *jsonNode) *jsonNode {
+	if len(aStep) == 0 {
+		return pVal
+	}
+	if !aStep[0].isLabel {
+		return nil
+	}
+	pElem := jsonBuild(aStep[1:], pVal)
+	if pElem == nil {
+		return nil
+	}
+	return &jsonNode{ eType: JSON_OBJECT, aLabel: []string{ aStep[0].zLabel }, aElem: []*jsonNode{ pElem } }
+}
+
+//	Return the path of element zLabel of an object whose path is zPath.
+func jsonPathAppendLabel(zPath, zLabel string) string {
+	quote := zLabel == ""
+	for _, r := range zLabel {
+		if !(r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')) {
+			quote = true
+			break
+		}
+	}
+	if quote {
+		return zPath + ".\"" + zLabel + "\""
+	}
+	return zPath + "." + zLabel
+}
+
+//	Convert an SQL value to a JSON element. Text with the JSON subtype is parsed as JSON. Other text becomes a JSON string. errMsg
+//	is set if the value cannot be held by JSON.
+func jsonFromValue(pVal *sqlite3_value) (pNode *jsonNode, errMsg string) {
+	switch sqlite3_value_type(pVal) {
+	case SQLITE_NULL:
+		return &jsonNode{ eType: JSON_NULL }, ""
+	case SQLITE_INTEGER:
+		return &jsonNode{ eType: JSON_INT, zText: strconv.FormatInt(sqlite3_value_int64(pVal), 10) }, ""
+	case SQLITE_FLOAT:
+		r := sqlite3_value_double(pVal)
+		switch {
+		case math.IsNaN(r):
+			return &jsonNode{ eType: JSON_NULL }, ""
+		case math.IsInf(r, 1):
+			return &jsonNode{ eType: JSON_REAL, zText: "9.0e999" }, ""
+		case math.IsInf(r, -1):
+			return &jsonNode{ eType: JSON_REAL, zText: "-9.0e999" }, ""
+		}
+		z := strconv.FormatFloat(r, 'g', 15, 64)
+		if !strings.ContainsAny(z, ".e") {
+			z += ".0"
+		}
+		return &jsonNode{ eType: JSON_REAL, zText: z }, ""
+	case SQLITE_TEXT:
+		z := sqlite3_value_text(pVal)
+		if sqlite3_value_subtype(pVal) == JSON_SUBTYPE {
+			if pNode = jsonParse(z); pNode == nil {
+				return nil, "malformed JSON"
+			}
+			return pNode, ""
+		}
+		return &jsonNode{ eType: JSON_STRING, zText: z }, ""
+	}
+	return nil, "JSON cannot hold BLOB values"
+}
+
+//	Parse argument pVal as a JSON document. Leave an error in context and return nil if it is not well-formed.
+func jsonArgument(context *sqlite3_context, pVal *sqlite3_value) *jsonNode {
+	if sqlite3_value_type(pVal) == SQLITE_BLOB {
+		sqlite3_result_error(context, "JSON cannot hold BLOB values", -1)
+		return nil
+	}
+	pNode := jsonParse(sqlite3_value_text(pVal))
+	if pNode == nil {
+		sqlite3_result_error(context, "malformed JSON", -1)
+	}
+	return pNode
+}
+
+//	Parse argument pVal as a JSON path. Leave an error in context and return false if it is not well-formed.
+func jsonPathArgument(context *sqlite3_context, pVal *sqlite3_value) ([]jsonPathStep, bool) {
+	zPath := sqlite3_value_text(pVal)
+	aStep, ok := jsonParsePath(zPath)
+	if !ok {
+		sqlite3_result_error(context, fmt.Sprintf("JSON path error near '%v'", zPath), -1)
+	}
+	return aStep, ok
+}
+
+//	Return p as JSON text.
+func jsonResultJson(context *sqlite3_context, p *jsonNode) {
+	sqlite3_result_text(context, p.String(), -1, SQLITE_TRANSIENT)
+	sqlite3_result_subtype(context, JSON_SUBTYPE)
+}
+
+//	Return p as an SQL value: null, true and false become NULL, 1 and 0, numbers and strings become INTEGER, REAL and TEXT, and
+//	arrays and objects become JSON text.
+func jsonResultValue(context *sqlite3_context, p *jsonNode) {
+	switch p.eType {
+	case JSON_NULL:
+		sqlite3_result_null(context)
+	case JSON_TRUE:
+		sqlite3_result_int(context, 1)
+	case JSON_FALSE:
+		sqlite3_result_int(context, 0)
+	case JSON_INT:
+		if v, err := strconv.ParseInt(p.zText, 10, 64); err == nil {
+			sqlite3_result_int64(context, v)
+			break
+		}
+		fallthrough
+	case JSON_REAL:
+		r, _ := strconv.ParseFloat(p.zText, 64)
+		context.SetFloat64(r)
+	case JSON_STRING:
+		sqlite3_result_text(context, p.zText, -1, SQLITE_TRANSIENT)
+	default:
+		jsonResultJson(context, p)
+	}
+}
+
+//	json(X)
+func jsonFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return
+	}
+	if p := jsonArgument(context, argv[0]); p != nil {
+		jsonResultJson(context, p)
+	}
+}
+
+//	json_valid(X)
+func jsonValidFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	switch sqlite3_value_type(argv[0]) {
+	case SQLITE_NULL:
+	case SQLITE_TEXT:
+		if jsonParse(sqlite3_value_text(argv[0])) != nil {
+			sqlite3_result_int(context, 1)
+		} else {
+			sqlite3_result_int(context, 0)
+		}
+	default:
+		sqlite3_result_int(context, 0)
+	}
+}
+
+//	Return the element of argv[0] at the path argv[1], or at "$" if there is no argv[1]. Return nil if either argument is NULL, if
+//	there is no such element or if there is an error, which is left in context.
+func jsonLookupArgs(context *sqlite3_context, argc int, argv []*sqlite3_value) *jsonNode {
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL || (argc > 1 && sqlite3_value_type(argv[1]) == SQLITE_NULL) {
+		return nil
+	}
+	p := jsonArgument(context, argv[0])
+	if p == nil || argc == 1 {
+		return p
+	}
+	aStep, ok := jsonPathArgument(context, argv[1])
+	if !ok {
+		return nil
+	}
+	return p.lookup(aStep)
+}
+
+//	json_type(X [,P])
+func jsonTypeFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if p := jsonLookupArgs(context, argc, argv); p != nil {
+		sqlite3_result_text(context, jsonType[p.eType], -1, SQLITE_STATIC)
+	}
+}
+
+//	json_array_length(X [,P])
+func jsonArrayLengthFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if p := jsonLookupArgs(context, argc, argv); p != nil {
+		if p.eType == JSON_ARRAY {
+			sqlite3_result_int64(context, int64(len(p.aElem)))
+		} else {
+			sqlite3_result_int(context, 0)
+		}
+	}
+}
+
+//	json_extract(X, P1, ...)
+func jsonExtractFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if argc < 2 || sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return
+	}
+	pDoc := jsonArgument(context, argv[0])
+	if pDoc == nil {
+		return
+	}
+	if argc == 2 {
+		if sqlite3_value_type(argv[1]) == SQLITE_NULL {
+			return
+		}
+		if aStep, ok := jsonPathArgument(context, argv[1]); ok {
+			if p := pDoc.lookup(aStep); p != nil {
+				jsonResultValue(context, p)
+			}
+		}
+		return
+	}
+	pRes := &jsonNode{ eType: JSON_ARRAY }
+	for _, pPath := range argv[1:argc] {
+		if sqlite3_value_type(pPath) == SQLITE_NULL {
+			return
+		}
+		aStep, ok := jsonPathArgument(context, pPath)
+		if !ok {
+			return
+		}
+		p := pDoc.lookup(aStep)
+		if p == nil {
+			p = &jsonNode{ eType: JSON_NULL }
+		}
+		pRes.aElem = append(pRes.aElem, p)
+	}
+	jsonResultJson(context, pRes)
+}
+
+//	The -> and ->> operators. The user data is non-nil for ->>.
+func jsonArrowFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL || sqlite3_value_type(argv[1]) == SQLITE_NULL {
+		return
+	}
+	pDoc := jsonArgument(context, argv[0])
+	if pDoc == nil {
+		return
+	}
+	var aStep []jsonPathStep
+	ok := true
+	switch sqlite3_value_type(argv[1]) {
+	case SQLITE_INTEGER:
+		aStep, ok = jsonParsePath(fmt.Sprintf("$[%v]", sqlite3_value_int64(argv[1])))
+	default:
+		switch zPath := sqlite3_value_text(argv[1]); {
+		case strings.HasPrefix(zPath, "$"):
+			aStep, ok = jsonParsePath(zPath)
+		case strings.HasPrefix(zPath, "["):
+			aStep, ok = jsonParsePath("$" + zPath)
+		default:
+			//	Any other text is a label, which is looked up as it is since a path cannot quote every label.
+			aStep = []jsonPathStep{ { isLabel: true, zLabel: zPath } }
+		}
+	}
+	if !ok {
+		sqlite3_result_error(context, fmt.Sprintf("JSON path error near '%v'", sqlite3_value_text(argv[1])), -1)
+		return
+	}
+	if p := pDoc.lookup(aStep); p != nil {
+		if sqlite3_user_data(context) != nil {
+			jsonResultValue(context, p)
+		} else {
+			jsonResultJson(context, p)
+		}
+	}
+}
+
+//	json_set(), json_insert() and json_replace(). The user data is the jsonEdit* code.
+func jsonSetFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	eEdit, _ := sqlite3_user_data(context).(int)
+	if argc % 2 == 0 {
+		zName := [...]string{ "set", "insert", "replace" }[eEdit]
+		sqlite3_result_error(context, fmt.Sprintf("json_%v() needs an odd number of arguments", zName), -1)
+		return
+	}
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return
+	}
+	pDoc := jsonArgument(context, argv[0])
+	if pDoc == nil {
+		return
+	}
+	for i := 1; i < argc; i += 2 {
+		if sqlite3_value_type(argv[i]) == SQLITE_NULL {
+			return
+		}
+		aStep, ok := jsonPathArgument(context, argv[i])
+		if !ok {
+			return
+		}
+		pVal, zErr := jsonFromValue(argv[i + 1])
+		if pVal == nil {
+			sqlite3_result_error(context, zErr, -1)
+			return
+		}
+		jsonEdit(&pDoc, aStep, pVal, eEdit)
+	}
+	jsonResultJson(context, pDoc)
+}
+
+//	json_remove(X, P1, ...)
+func jsonRemoveFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return
+	}
+	pDoc := jsonArgument(context, argv[0])
+	for i := 1; pDoc != nil && i < argc; i++ {
+		if sqlite3_value_type(argv[i]) == SQLITE_NULL {
+			return
+		}
+		aStep, ok := jsonPathArgument(context, argv[i])
+		if !ok {
+			return
+		}
+		jsonEdit(&pDoc, aStep, nil, jsonEditRemove)
+	}
+	if pDoc != nil {
+		jsonResultJson(context, pDoc)
+	}
+}
+
+//	json_array(V1, ...)
+func jsonArrayFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	pRes := &jsonNode{ eType: JSON_ARRAY }
+	for _, pArg := range argv[:argc] {
+		p, zErr := jsonFromValue(pArg)
+		if p == nil {
+			sqlite3_result_error(context, zErr, -1)
+			return
+		}
+		pRes.aElem = append(pRes.aElem, p)
+	}
+	jsonResultJson(context, pRes)
+}
+
+//	json_object(L1, V1, ...)
+func jsonObjectFunc(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if argc % 2 != 0 {
+		sqlite3_result_error(context, "json_object() requires an even number of arguments", -1)
+		return
+	}
+	pRes := &jsonNode{ eType: JSON_OBJECT }
+	for i := 0; i < argc; i += 2 {
+		if sqlite3_value_type(argv[i]) != SQLITE_TEXT {
+			sqlite3_result_error(context, "json_object() labels must be TEXT", -1)
+			return
+		}
+		p, zErr := jsonFromValue(argv[i + 1])
+		if p == nil {
+			sqlite3_result_error(context, zErr, -1)
+			return
+		}
+		pRes.aLabel = append(pRes.aLabel, sqlite3_value_text(argv[i]))
+		pRes.aElem = append(pRes.aElem, p)
+	}
+	jsonResultJson(context, pRes)
+}
+
+//	json_group_array(V) and json_group_object(L, V) accumulate their result in a jsonNode. Both may be used as window functions.
+func jsonGroupStep(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	pAcc := context.AggregateState(func() interface{} {
+		if argc == 1 {
+			return &jsonNode{ eType: JSON_ARRAY }
+		}
+		return &jsonNode{ eType: JSON_OBJECT }
+	}).(*jsonNode)
+	if argc == 2 {
+		if sqlite3_value_type(argv[0]) == SQLITE_NULL {
+			return
+		}
+		if sqlite3_value_type(argv[0]) != SQLITE_TEXT {
+			sqlite3_result_error(context, "json_group_object() labels must be TEXT", -1)
+			return
+		}
+	}
+	p, zErr := jsonFromValue(argv[argc - 1])
+	if p == nil {
+		sqlite3_result_error(context, zErr, -1)
+		return
+	}
+	if argc == 2 {
+		pAcc.aLabel = append(pAcc.aLabel, sqlite3_value_text(argv[0]))
+	}
+	pAcc.aElem = append(pAcc.aElem, p)
+}
+
+//	Remove the oldest element from the accumulator, undoing the first jsonGroupStep() call still in the window frame.
+func jsonGroupInverse(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	if argc == 2 && sqlite3_value_type(argv[0]) != SQLITE_TEXT {
+		return
+	}
+	if pAcc, _ := context.AggregateState(nil).(*jsonNode); pAcc != nil && len(pAcc.aElem) > 0 {
+		pAcc.removeAt(0)
+	}
+}
+
+func jsonGroupArrayValue(context *sqlite3_context) {
+	pAcc, _ := context.AggregateState(nil).(*jsonNode)
+	if pAcc == nil {
+		pAcc = &jsonNode{ eType: JSON_ARRAY }
+	}
+	jsonResultJson(context, pAcc)
+}
+
+func jsonGroupObjectValue(context *sqlite3_context) {
+	pAcc, _ := context.AggregateState(nil).(*jsonNode)
+	if pAcc == nil {
+		pAcc = &jsonNode{ eType: JSON_OBJECT }
+	}
+	jsonResultJson(context, pAcc)
+}
+
+//	Rewrite the -> and ->> operators in the first statement of zSql into calls of the functions "->" and "->>", and return the
+//	text that the parser should see. Like ||, with which they share a precedence level, the operators associate to the left. Errors
+//	are left in pParse.
+func (pParse *Parse) ParseJsonOperators(zSql string) string {
+	type token struct {
+		Type, Start, End	int
+	}
+	for {
+		var a []token
+		//	Only the first statement is rewritten, which for a CREATE TRIGGER runs to the END of the trigger program. The statements
+		//	after it are rewritten when they are prepared in turn.
+		isTrigger := false
+		for s := newSqlScanner(zSql); s.Type != 0; s.Next() {
+			if s.Type == TK_TRIGGER && len(a) > 0 && a[0].Type == TK_CREATE {
+				isTrigger = true
+			}
+			if s.Type == TK_SEMI && (!isTrigger || a[len(a) - 1].Type == TK_END) {
+				break
+			}
+			a = append(a, token{ s.Type, s.Start, s.iNext })
+		}
+		iOp := -1
+		for i := 0; i + 1 < len(a); i++ {
+			if a[i].Type == TK_MINUS && a[i + 1].Start == a[i].End && (a[i + 1].Type == TK_GT || a[i + 1].Type == TK_RSHIFT) {
+				iOp = i
+				break
+			}
+		}
+		if iOp < 0 {
+			return zSql
+		}
+		zOp := zSql[a[iOp].Start:a[iOp + 1].End]
+
+		//	matchLP and matchRP return the index of the parenthesis that balances the one at index i, or -1.
+		matchLP := func(i int) int {
+			for depth := 0; i >= 0; i-- {
+				switch a[i].Type {
+				case TK_RP:
+					depth++
+				case TK_LP:
+					if depth--; depth == 0 {
+						return i
+					}
+				}
+			}
+			return -1
+		}
+		matchRP := func(i int) int {
+			for depth := 0; i < len(a); i++ {
+				switch a[i].Type {
+				case TK_LP:
+					depth++
+				case TK_RP:
+					if depth--; depth == 0 {
+						return i
+					}
+				}
+			}
+			return -1
+		}
+		isName := func(i int) bool {
+			return i >= 0 && i < len(a) && (a[i].Type == TK_ID || a[i].Type == TK_STRING)
+		}
+		isCallee := func(i int) bool {
+			return i >= 0 && (a[i].Type == TK_ID || a[i].Type == TK_CAST || a[i].Type == TK_REPLACE || a[i].Type == TK_LIKE_KW)
+		}
+
+		//	Find the first token of the left operand: a chain of primary expressions joined by ||.
+		iLeft := -1
+		for j := iOp - 1; j >= 0; {
+			k := -1
+			switch a[j].Type {
+			case TK_RP:
+				if k = matchLP(j); k > 0 && isCallee(k - 1) {
+					k--
+				}
+			case TK_ID, TK_STRING, TK_INTEGER, TK_FLOAT, TK_BLOB, TK_VARIABLE, TK_NULL:
+				for k = j; k >= 2 && a[k - 1].Type == TK_DOT && isName(k - 2); k -= 2 {}
+			}
+			if k < 0 {
+				break
+			}
+			iLeft = k
+			if k < 2 || a[k - 1].Type != TK_CONCAT {
+				break
+			}
+			j = k - 2
+		}
+
+		//	Find the last token of the right operand: a single primary expression.
+		iRight := -1
+		switch j := iOp + 2; {
+		case j >= len(a):
+		case a[j].Type == TK_LP:
+			iRight = matchRP(j)
+		case (a[j].Type == TK_MINUS || a[j].Type == TK_PLUS) && j + 1 < len(a) && a[j + 1].Type == TK_INTEGER:
+			iRight = j + 1
+		case isCallee(j) && j + 1 < len(a) && a[j + 1].Type == TK_LP:
+			iRight = matchRP(j + 1)
+		case a[j].Type == TK_ID, a[j].Type == TK_STRING:
+			for iRight = j; iRight + 2 < len(a) && a[iRight + 1].Type == TK_DOT && isName(iRight + 2); iRight += 2 {}
+		case a[j].Type == TK_INTEGER, a[j].Type == TK_FLOAT, a[j].Type == TK_VARIABLE, a[j].Type == TK_NULL:
+			iRight = j
+		}
+
+		if iLeft < 0 || iRight < 0 {
+			pParse.SetErrorMsg("near \"%v\": syntax error", zOp)
+			return zSql
+		}
+		zSql = zSql[:a[iLeft].Start] + "\"" + zOp + "\"(" + zSql[a[iLeft].Start:a[iOp].Start] + ", " + zSql[a[iOp + 1].End:a[iRight].End] + ")" + zSql[a[iRight].End:]
+	}
+}
+
+//	The json_each and json_tree eponymous virtual tables. Each row is an element of the JSON document passed to the hidden "json"
+//	column, or of the element of it at the path passed to the hidden "root" column:
+//
+//		SELECT key, value FROM json_each WHERE json = '{"a":1,"b":[2,3]}'
+//		SELECT fullkey, type FROM json_tree WHERE json = ?1 AND root = '$.b'
+//
+//	json_each returns the immediate children of the root element, or the root itself if it is neither an array nor an object.
+//	json_tree walks the whole tree below the root, starting with the root itself.
+
+//	Columns of json_each and json_tree.
+const (
+	JEACH_KEY = iota
+	JEACH_VALUE
+	JEACH_TYPE
+	JEACH_ATOM
+	JEACH_ID
+	JEACH_PARENT
+	JEACH_FULLKEY
+	JEACH_PATH
+	JEACH_JSON
+	JEACH_ROOT
+)
+
+const jsonEachSchema = "CREATE TABLE x(key,value,type,atom,id,parent,fullkey,path,json HIDDEN,root HIDDEN)"
+
+type jsonEachVtab struct {
+	base		sqlite3_vtab
+	isTree		bool
+}
+
+type jsonEachCursor struct {
+	base		sqlite3_vtab_cursor
+	aRow		[]*jsonEachRow
+	iRow		int
+}
+
+//	A jsonEachRow is a row returned by a json_each or json_tree cursor.
+type jsonEachRow struct {
+	pNode		*jsonNode
+	key			interface{}			//	nil, int64 array index or string label
+	iId			int64
+	iParent		int64				//	-1 if the parent column is NULL
+	zFullkey	string
+	zPath		string
+}
+
+func jsonEachConnect(db *sqlite3, pAux interface{}, argc int, argv []string, ppVtab **sqlite3_vtab, pzErr *string) (rc int) {
+	if rc = db.DeclareVTab(jsonEachSchema); rc == SQLITE_OK {
+		p := &jsonEachVtab{ isTree: pAux != nil }
+		*ppVtab = &p.base
+	}
+	return
+}
+
+func jsonEachDisconnect(pVtab *sqlite3_vtab) int {
+	return SQLITE_OK
+}
+
+//	The only useful plan has an equality constraint on the json column and optionally the root column. Their values are passed to
+//	xFilter as argv[0] and argv[1], and idxNum has bit 1 set if there is a root. Without a json constraint there are no rows.
+func jsonEachBestIndex(pVtab *sqlite3_vtab, pIdxInfo *sqlite3_index_info) int {
+	iJson, iRoot := -1, -1
+	for i := 0; i < pIdxInfo.nConstraint; i++ {
+		pCons := &pIdxInfo.aConstraint[i]
+		if pCons.usable == 0 || pCons.op != SQLITE_INDEX_CONSTRAINT_EQ {
+			continue
+		}
+		switch pCons.iColumn {
+		case JEACH_JSON:
+			iJson = i
+		case JEACH_ROOT:
+			iRoot = i
+		}
+	}
+	if iJson < 0 {
+		pIdxInfo.idxNum = 0
+		pIdxInfo.estimatedCost = 1e99
+		return SQLITE_OK
+	}
+	pIdxInfo.estimatedCost = 1
+	pIdxInfo.aConstraintUsage[iJson].argvIndex = 1
+	pIdxInfo.aConstraintUsage[iJson].omit = 1
+	pIdxInfo.idxNum = 1
+	if iRoot >= 0 {
+		pIdxInfo.aConstraintUsage[iRoot].argvIndex = 2
+		pIdxInfo.aConstraintUsage[iRoot].omit = 1
+		pIdxInfo.idxNum = 3
+	}
+	return SQLITE_OK
+}
+
+func jsonEachOpen(pVtab *sqlite3_vtab, ppCursor **sqlite3_vtab_cursor) int {
+	pCur := new(jsonEachCursor)
+	*ppCursor = &pCur.base
+	return SQLITE_OK
+}
+
+func jsonEachClose(cur *sqlite3_vtab_cursor) int {
+	return SQLITE_OK
+}
+
+func jsonEachFilter(cur *sqlite3_vtab_cursor, idxNum int, idxStr string, argc int, argv []*sqlite3_value) int {
+	pCur := (*jsonEachCursor)(unsafe.Pointer(cur))
+	pTab := (*jsonEachVtab)(unsafe.Pointer(cur.pVtab))
+	pCur.aRow = nil
+	pCur.iRow = 0
+	if idxNum == 0 || sqlite3_value_type(argv[0]) == SQLITE_NULL {
+		return SQLITE_OK
+	}
+	pDoc := jsonParse(sqlite3_value_text(argv[0]))
+	if pDoc == nil {
+		pTab.base.zErrMsg = "malformed JSON"
+		return SQLITE_ERROR
+	}
+
+	//	Number every element of the document in pre-order, so that the id of an element does not depend on the root.
+	aId := make(map[*jsonNode]int64)
+	var number func(p *jsonNode)
+	number = func(p *jsonNode) {
+		aId[p] = int64(len(aId))
+		for _, pElem := range p.aElem {
+			number(pElem)
+		}
+	}
+	number(pDoc)
+
+	childRow := func(p *jsonNode, i int, zPath string, iParent int64) *jsonEachRow {
+		pRow := &jsonEachRow{ pNode: p.aElem[i], iId: aId[p.aElem[i]], iParent: iParent, zPath: zPath }
+		if p.eType == JSON_ARRAY {
+			pRow.key = int64(i)
+			pRow.zFullkey = fmt.Sprintf("%v[%v]", zPath, i)
+		} else {
+			pRow.key = p.aLabel[i]
+			pRow.zFullkey = jsonPathAppendLabel(zPath, p.aLabel[i])
+		}
+		return pRow
+	}
+
+	//	Find the root. Its row has the key and path that it has within its parent, but no parent id.
+	pRootRow := &jsonEachRow{ pNode: pDoc, iId: 0, iParent: -1, zFullkey: "$", zPath: "$" }
+	if idxNum & 2 != 0 {
+		if sqlite3_value_type(argv[1]) == SQLITE_NULL {
+			return SQLITE_OK
+		}
+		zRoot := sqlite3_value_text(argv[1])
+		aStep, ok := jsonParsePath(zRoot)
+		if !ok {
+			pTab.base.zErrMsg = fmt.Sprintf("JSON path error near '%v'", zRoot)
+			return SQLITE_ERROR
+		}
+		for _, step := range aStep {
+			p := pRootRow.pNode
+			i := p.stepIndex(step)
+			if i < 0 {
+				return SQLITE_OK
+			}
+			pRootRow = childRow(p, i, pRootRow.zFullkey, -1)
+		}
+	}
+	pRoot := pRootRow.pNode
+
+	if !pTab.isTree {
+		if pRoot.eType != JSON_ARRAY && pRoot.eType != JSON_OBJECT {
+			pCur.aRow = append(pCur.aRow, pRootRow)
+			return SQLITE_OK
+		}
+		for i := range pRoot.aElem {
+			pCur.aRow = append(pCur.aRow, childRow(pRoot, i, pRootRow.zFullkey, -1))
+		}
+		return SQLITE_OK
+	}
+
+	var walk func(pRow *jsonEachRow)
+	walk = func(pRow *jsonEachRow) {
+		pCur.aRow = append(pCur.aRow, pRow)
+		p := pRow.pNode
+		for i := range p.aElem {
+			walk(childRow(p, i, pRow.zFullkey, pRow.iId))
+		}
+	}
+	walk(pRootRow)
+	return SQLITE_OK
+}
+
+func jsonEachNext(cur *sqlite3_vtab_cursor) int {
+	(*jsonEachCursor)(unsafe.Pointer(cur)).iRow++
+	return SQLITE_OK
+}
+
+func jsonEachEof(cur *sqlite3_vtab_cursor) int {
+	pCur := (*jsonEachCursor)(unsafe.Pointer(cur))
+	if pCur.iRow >= len(pCur.aRow) {
+		return 1
+	}
+	return 0
+}
+
+func jsonEachColumn(cur *sqlite3_vtab_cursor, ctx *sqlite3_context, i int) int {
+	pCur := (*jsonEachCursor)(unsafe.Pointer(cur))
+	pRow := pCur.aRow[pCur.iRow]
+	p := pRow.pNode
+	switch i {
+	case JEACH_KEY:
+		switch key := pRow.key.(type) {
+		case int64:
+			sqlite3_result_int64(ctx, key)
+		case string:
+			sqlite3_result_text(ctx, key, -1, SQLITE_TRANSIENT)
+		}
+	case JEACH_VALUE:
+		jsonResultValue(ctx, p)
+	case JEACH_TYPE:
+		sqlite3_result_text(ctx, jsonType[p.eType], -1, SQLITE_STATIC)
+	case JEACH_ATOM:
+		if p.eType != JSON_ARRAY && p.eType != JSON_OBJECT {
+			jsonResultValue(ctx, p)
+		}
+	case JEACH_ID:
+		sqlite3_result_int64(ctx, pRow.iId)
+	case JEACH_PARENT:
+		if pRow.iParent >= 0 {
+			sqlite3_result_int64(ctx, pRow.iParent)
+		}
+	case JEACH_FULLKEY:
+		sqlite3_result_text(ctx, pRow.zFullkey, -1, SQLITE_TRANSIENT)
+	case JEACH_PATH:
+		sqlite3_result_text(ctx, pRow.zPath, -1, SQLITE_TRANSIENT)
+	}
+	//	The hidden json and root columns are only used as arguments. They read as NULL.
+	return SQLITE_OK
+}
+
+func jsonEachRowid(cur *sqlite3_vtab_cursor, pRowid *int64) int {
+	pCur := (*jsonEachCursor)(unsafe.Pointer(cur))
+	*pRowid = pCur.aRow[pCur.iRow].iId
+	return SQLITE_OK
+}
+
+//	json_each and json_tree have no xCreate method, which makes them eponymous-only.
+var jsonEachModule = sqlite3_module{
+	xConnect:		jsonEachConnect,
+	xBestIndex:		jsonEachBestIndex,
+	xDisconnect:	jsonEachDisconnect,
+	xOpen:			jsonEachOpen,
+	xClose:			jsonEachClose,
+	xFilter:		jsonEachFilter,
+	xNext:			jsonEachNext,
+	xEof:			jsonEachEof,
+	xColumn:		jsonEachColumn,
+	xRowid:			jsonEachRowid,
+}
+
+//	Register the JSON functions in the global function table. This is called by sqlite3RegisterGlobalFunctions().
+func sqlite3RegisterJsonFunctions() {
+	aJsonFunc := []FuncDef{
+		FUNCTION(json,                 1, 0, 0, jsonFunc            ),
+		FUNCTION(json_valid,           1, 0, 0, jsonValidFunc       ),
+		FUNCTION(json_type,            1, 0, 0, jsonTypeFunc        ),
+		FUNCTION(json_type,            2, 0, 0, jsonTypeFunc        ),
+		FUNCTION(json_array_length,    1, 0, 0, jsonArrayLengthFunc ),
+		FUNCTION(json_array_length,    2, 0, 0, jsonArrayLengthFunc ),
+		FUNCTION(json_extract,        -1, 0, 0, jsonExtractFunc     ),
+		FUNCTION(json_set,            -1, jsonEditSet, 0, jsonSetFunc ),
+		FUNCTION(json_insert,         -1, jsonEditInsert, 0, jsonSetFunc ),
+		FUNCTION(json_replace,        -1, jsonEditReplace, 0, jsonSetFunc ),
+		FUNCTION(json_remove,         -1, 0, 0, jsonRemoveFunc      ),
+		FUNCTION(json_array,          -1, 0, 0, jsonArrayFunc       ),
+		FUNCTION(json_object,         -1, 0, 0, jsonObjectFunc      ),
+		{ nArg: 2, iPrefEnc: SQLITE_UTF8, xFunc: jsonArrowFunc, Name: "->" },
+		{ nArg: 2, iPrefEnc: SQLITE_UTF8, xFunc: jsonArrowFunc, Name: "->>", pUserData: true },
+		WINDOWAGG(json_group_array,    1, 0, 0, jsonGroupStep, jsonGroupArrayValue,  jsonGroupArrayValue,  jsonGroupInverse ),
+		WINDOWAGG(json_group_object,   2, 0, 0, jsonGroupStep, jsonGroupObjectValue, jsonGroupObjectValue, jsonGroupInverse ),
+	}
+	pHash := &sqlite3GlobalFunctions
+	for i := range aJsonFunc {
+		pHash.Insert(&aJsonFunc[i])
+	}
+}
+
+//	Register the json_each and json_tree modules with connection db. This is called when the connection is opened.
+func (db *sqlite3) JsonInit() (rc int) {
+	if rc = db.create_module("json_each", &jsonEachModule, nil); rc == SQLITE_OK {
+		rc = db.create_module("json_tree", &jsonEachModule, true)
+	}
+	return
+}
@@ -0,0 +1,149 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestJsonPath(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	const doc = `{"a":{"b":[10,20,30]},"c.d":"dot","":"empty"}`
+	testQueryIs(t, db, "20|30|10|NULL", "SELECT json_extract(?1, '$.a.b[1]'), json_extract(?1, '$.a.b[#-1]'), json_extract(?1, '$.a.b[#-3]'), json_extract(?1, '$.a.b[#]')", doc)
+	testQueryIs(t, db, `dot|empty|{"b":[10,20,30]}`, `SELECT json_extract(?1, '$."c.d"'), json_extract(?1, '$.""'), json_extract(?1, '$.a')`, doc)
+
+	//	Paths that select nothing, and a path through an element of the wrong type.
+	testQueryIs(t, db, "NULL|NULL|NULL|NULL", "SELECT json_extract(?1, '$.x'), json_extract(?1, '$.a.b[3]'), json_extract(?1, '$.a[0]'), json_extract(?1, '$.a.b.c')", doc)
+
+	//	More than one path returns an array, with null for each path that selects nothing.
+	testQueryIs(t, db, `[10,null,"dot"]`, `SELECT json_extract(?1, '$.a.b[0]', '$.x', '$."c.d"')`, doc)
+	testQueryIs(t, db, "object|array|integer|3|0", "SELECT json_type(?1), json_type(?1, '$.a.b'), json_type(?1, '$.a.b[0]'), json_array_length(?1, '$.a.b'), json_array_length(?1, '$.a')", doc)
+
+	for _, zPath := range []string{ "a", "$a", "$.", "$[", "$[x]", "$[-1]", "$[#+1]", `$."a` } {
+		if _, err := db.Exec("SELECT json_extract('{}', ?)", zPath); err == nil || !strings.Contains(err.Error(), "JSON path error near '" + zPath + "'") {
+			t.Errorf("json_extract() with path %q: %v, want a JSON path error", zPath, err)
+		}
+	}
+	if _, err := db.Exec("SELECT json_extract('{\"a\":', '$')"); err == nil || !strings.Contains(err.Error(), "malformed JSON") {
+		t.Errorf("malformed document: %v", err)
+	}
+}
+
+func TestJsonEdit(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	const doc = `{"a":1,"b":[1,2]}`
+
+	//	json_set() replaces and creates, json_insert() only creates and json_replace() only replaces.
+	testQueryIs(t, db, `{"a":2,"b":[1,2],"c":3}`, "SELECT json_set(?, '$.a', 2, '$.c', 3)", doc)
+	testQueryIs(t, db, `{"a":1,"b":[1,2],"c":3}`, "SELECT json_insert(?, '$.a', 2, '$.c', 3)", doc)
+	testQueryIs(t, db, `{"a":2,"b":[1,2]}`, "SELECT json_replace(?, '$.a', 2, '$.c', 3)", doc)
+
+	//	Appending to an array, and creating the objects on the way to a missing label but not a missing array.
+	testQueryIs(t, db, `{"a":1,"b":[1,2,3,4]}`, "SELECT json_insert(?, '$.b[#]', 3, '$.b[3]', 4)", doc)
+	testQueryIs(t, db, `{"a":1,"b":[1,2],"x":{"y":{"z":0}}}`, "SELECT json_set(?, '$.x.y.z', 0)", doc)
+	testQueryIs(t, db, `{"a":1,"b":[1,2]}`, "SELECT json_set(?, '$.x[0]', 0, '$.b[5]', 0, '$.a.b', 0)", doc)
+
+	//	Values are embedded as JSON only if they come from a JSON function.
+	testQueryIs(t, db, `{"a":"[1]","b":[1]}`, `SELECT json_set('{}', '$.a', '[1]', '$.b', json('[1]'))`)
+	testQueryIs(t, db, `[null,1.5,"x",true]`, "SELECT json_set('[]', '$[#]', NULL, '$[#]', 1.5, '$[#]', 'x', '$[#]', json('true'))")
+	testQueryIs(t, db, "7", "SELECT json_set(?, '$', 7)", doc)
+
+	testQueryIs(t, db, `{"b":[2]}`, "SELECT json_remove(?, '$.a', '$.b[0]', '$.missing')", doc)
+	testQueryIs(t, db, `{"a":1,"b":[1]}`, "SELECT json_remove(?, '$.b[#-1]')", doc)
+	testQueryIs(t, db, "NULL|NULL", "SELECT json_remove(?, '$'), json_set(NULL, '$.a', 1)", doc)
+
+	for query, want := range map[string]string{
+		"SELECT json_set('{}', '$.a')":			"json_set() needs an odd number of arguments",
+		"SELECT json_insert('{}', '$.a')":		"json_insert() needs an odd number of arguments",
+		"SELECT json_set('{}', '$.a', x'00')":	"JSON cannot hold BLOB values",
+		"SELECT json_object('a')":				"json_object() requires an even number of arguments",
+		"SELECT json_object(1, 2)":				"json_object() labels must be TEXT",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+}
+
+func TestJsonOperators(t *testing.T) {
+	zFile := testFile(t)
+	db := testOpen(t, zFile)
+	const doc = `{"a":{"b":"x"},"c":[1,2,3],"d e":4}`
+
+	//	-> returns JSON and ->> an SQL value. The right operand is a path, an array index or a label.
+	testQueryIs(t, db, `{"b":"x"}|"x"|x`, "SELECT ?1 -> '$.a', ?1 -> '$.a.b', ?1 ->> '$.a.b'", doc)
+	testQueryIs(t, db, "2|3|4|NULL", "SELECT ?1 -> 'c' -> 1, ?1 ->> '$.c' ->> '[#-1]', ?1 ->> 'd e', ?1 -> 'missing'", doc)
+	testQueryIs(t, db, "text|integer", "SELECT typeof(?1 -> '$.c[0]'), typeof(?1 ->> '$.c[0]')", doc)
+
+	//	The operators bind as tightly as || and to the left, and are not seen inside strings or split by white space.
+	testQueryIs(t, db, `x!|1`, "SELECT ?1 ->> '$.a.b' || '!', ?1 -> 'c' ->> 0 = 1", doc)
+	testQueryIs(t, db, "->|1", "SELECT '->', 3 - -2 > 4")
+	if _, err := db.Exec("SELECT '{}' ->> '$['"); err == nil || !strings.Contains(err.Error(), "JSON path error") {
+		t.Errorf("bad path: %v", err)
+	}
+
+	testExec(t, db, "CREATE TABLE t(j)")
+	testExec(t, db, "INSERT INTO t VALUES(?), ('{\"n\":\"two\",\"v\":2}')", `{"n":"one","v":1}`)
+	testQueryIs(t, db, "one\ntwo", "SELECT j ->> 'n' FROM t WHERE j ->> '$.v' > 0 ORDER BY j ->> 'v'")
+	testExec(t, db, "CREATE INDEX t_n ON t(j ->> 'n')")
+	testQueryIs(t, db, "2", "SELECT j ->> 'v' FROM t WHERE j ->> 'n' = 'two'")
+
+	//	The operators in a trigger program, in statements after the first one of the program.
+	testExec(t, db, "CREATE TABLE log(n, v)")
+	testExec(t, db, `CREATE TRIGGER t_ins AFTER INSERT ON t BEGIN
+		INSERT INTO log VALUES('new', NULL);
+		INSERT INTO log VALUES(new.j ->> 'n', new.j -> '$.v');
+		UPDATE log SET v = new.j -> 'v' -> 'w' WHERE n = 'new';
+	END; SELECT 1 ->> 2`)
+	testExec(t, db, "INSERT INTO t VALUES(?)", `{"n":"three","v":{"w":[3]}}`)
+	testQueryIs(t, db, `new|[3]` + "\n" + `three|{"w":[3]}`, "SELECT n, v FROM log ORDER BY rowid")
+
+	//	The trigger also works in a connection that loads the schema from its stored text.
+	db2 := testOpen(t, zFile)
+	testExec(t, db2, "DELETE FROM log")
+	testExec(t, db2, "INSERT INTO t VALUES(?)", `{"n":"four","v":{"w":4}}`)
+	testQueryIs(t, db2, "new|4\nfour|{\"w\":4}", "SELECT n, v FROM log ORDER BY rowid")
+	testQueryIs(t, db2, "1", "SELECT count(*) FROM sqlite_master WHERE name = 't_ins' AND sql LIKE '%new.j ->> ''n''%'")
+}
+
+func TestJsonEach(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	const doc = `{"a":1,"b":[2,{"c":null}],"d e":"x"}`
+
+	testQueryIs(t, db, "a|1|integer|1|$.a\nb|[2,{\"c\":null}]|array|NULL|$.b\nd e|x|text|x|$.\"d e\"",
+		"SELECT key, value, type, atom, fullkey FROM json_each(?)", doc)
+	testQueryIs(t, db, "0|2|$.b[0]|$.b\n1|{\"c\":null}|$.b[1]|$.b", "SELECT key, value, fullkey, path FROM json_each(?, '$.b')", doc)
+	testQueryIs(t, db, "NULL|5|$", "SELECT key, value, fullkey FROM json_each('5')")
+	testQueryIs(t, db, "", "SELECT key FROM json_each(?, '$.missing')", doc)
+	testQueryIs(t, db, "", "SELECT key FROM json_each(NULL)")
+
+	//	json_tree walks the whole document in pre-order. Ids do not depend on the root.
+	testQueryIs(t, db, strings.Join([]string{
+		"NULL|object|0|NULL|$|$",
+		"a|integer|1|0|$.a|$",
+		"b|array|2|0|$.b|$",
+		"0|integer|3|2|$.b[0]|$.b",
+		"1|object|4|2|$.b[1]|$.b",
+		"c|null|5|4|$.b[1].c|$.b[1]",
+		"d e|text|6|0|$.\"d e\"|$",
+	}, "\n"), "SELECT key, type, id, parent, fullkey, path FROM json_tree(?)", doc)
+	testQueryIs(t, db, "b|2|NULL|$.b\n0|3|2|$.b[0]\n1|4|2|$.b[1]\nc|5|4|$.b[1].c", "SELECT key, id, parent, fullkey FROM json_tree(?, '$.b')", doc)
+
+	//	The hidden columns in a WHERE clause, and a join against a table.
+	testQueryIs(t, db, "$.b[1].c", "SELECT fullkey FROM json_tree WHERE json = ? AND type = 'null'", doc)
+	testExec(t, db, "CREATE TABLE t(id, tags)")
+	testExec(t, db, `INSERT INTO t VALUES(1, '["x","y"]'), (2, '["y"]'), (3, '[]')`)
+	testQueryIs(t, db, "1|x\n1|y\n2|y", "SELECT id, value FROM t, json_each(t.tags) ORDER BY id, value")
+	testQueryIs(t, db, "1|2", "SELECT id, (SELECT count(*) FROM json_each(t.tags)) FROM t WHERE id = 1")
+
+	//	A call in a trigger program, which is stored as written.
+	testExec(t, db, "CREATE TABLE tag(id, name)")
+	testExec(t, db, "CREATE TRIGGER t_tags AFTER INSERT ON t BEGIN INSERT INTO tag SELECT new.id, value FROM json_each(new.tags); END")
+	testExec(t, db, `INSERT INTO t VALUES(4, '["z","w"]')`)
+	testQueryIs(t, db, "4|w\n4|z", "SELECT id, name FROM tag ORDER BY name")
+	testQueryIs(t, db, "1", "SELECT count(*) FROM sqlite_master WHERE name = 't_tags' AND sql LIKE '%FROM json_each(new.tags);%'")
+
+	if _, err := db.Exec("SELECT * FROM json_each('[1')"); err == nil || !strings.Contains(err.Error(), "malformed JSON") {
+		t.Errorf("malformed document: %v", err)
+	}
+	if _, err := db.Exec("SELECT * FROM json_tree('[1]', 'x')"); err == nil || !strings.Contains(err.Error(), "JSON path error near 'x'") {
+		t.Errorf("bad root: %v", err)
+	}
+}
@@ -21,6 +21,21 @@ func int sqlite3_exec(
   sqlite3_callback xCallback, /* Invoke this callback routine */
   void *pArg,                 /* First argument to xCallback() */
   char **pzErrMsg             /* Write error messages here */
+){
+  return execContext(nil, db, zSql, xCallback, pArg, pzErrMsg);
+}
+
+/*
+** The implementation of sqlite3_exec() and ExecContext(). Each statement
+** is stepped with StepContext(), so that it stops if ctx ends.
+*/
+static int execContext(
+  context.Context ctx,        /* Context watched while stepping, or nil */
+  sqlite3 *db,                /* The database on which the SQL executes */
+  const char *zSql,           /* The SQL to be executed */
+  sqlite3_callback xCallback, /* Invoke this callback routine */
+  void *pArg,                 /* First argument to xCallback() */
+  char **pzErrMsg             /* Write error messages here */
 ){
   int rc = SQLITE_OK;         /* Return code */
   const char *zLeftover;      /* Tail of unprocessed SQL */
@@ -54,7 +69,7 @@ func int sqlite3_exec(
 
     while( 1 ){
       int i;
-      rc = sqlite3_step(pStmt);
+      rc = StepContext(ctx, pStmt);
 
       /* Invoke the callback function if required */
       if( xCallback && (SQLITE_ROW==rc || 
@@ -131,3 +146,10 @@ exec_out:
   db.mutex.Unlock()
   return rc;
 }
+
+//	ExecContext is sqlite3_exec() with cancellation. If ctx is cancelled or its deadline passes before all of zSql has run, the statement
+//	that is executing stops at its next interrupt check, no further statements are started and SQLITE_INTERRUPT is returned. Each
+//	statement watches ctx as StepContext() does.
+func ExecContext(ctx context.Context, db *sqlite3, zSql string, xCallback sqlite3_callback, pArg interface{}, pzErrMsg *string) (rc int) {
+	return execContext(ctx, db, zSql, xCallback, pArg, pzErrMsg)
+}
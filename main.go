@@ -618,6 +618,7 @@ func (db *sqlite3) Close() (rc int) {
 				}
 				db.Collations = make(map[string]*CollSeq)
 				for _, pMod := range db.Modules {
+					db.VtabEponymousTableClear(pMod)
 					if pMod.xDestroy {
 						pMod.xDestroy(pMod.Parameter)
 					}
@@ -849,7 +850,6 @@ func void sqlite3_interrupt(sqlite3 *db){
   db.u1.isInterrupted = 1;
 }
 
-
 /*
 ** This function is exactly the same as sqlite3_create_function(), except
 ** that it is designed to be called by internal code. The difference is
@@ -915,6 +915,8 @@ func void sqlite3_interrupt(sqlite3 *db){
   p.xFunc = xFunc;
   p.xStep = xStep;
   p.xFinalize = xFinal;
+  p.xValue = nil
+  p.xInverse = nil
   p.pUserData = pUserData;
   p.nArg = (uint16)nArg;
   return SQLITE_OK;
@@ -973,6 +975,32 @@ func int sqlite3_create_function_v2(
   return rc;
 }
 
+//	Create a new user aggregate that may also be used as a window function. xValue returns the current value of the aggregate without
+//	finalizing it, and xInverse removes the oldest row added by xStep that has not been removed yet. Both must be supplied, or both
+//	must be nil, in which case the aggregate is recomputed for each window frame.
+func sqlite3_create_window_function(db *sqlite3, zFunc string, nArg, enc int, p interface{}, xStep func(*sqlite3_context, int, []*sqlite3_value), xFinal, xValue func(*sqlite3_context), xInverse func(*sqlite3_context, int, []*sqlite3_value), xDestroy func(interface{})) (rc int) {
+	if xStep == nil || xFinal == nil || (xValue == nil) != (xInverse == nil) {
+		return SQLITE_MISUSE_BKPT
+	}
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	var pArg *FuncDestructor
+	if xDestroy != nil {
+		pArg = &FuncDestructor{ xDestroy: xDestroy, pUserData: p }
+	}
+	rc = sqlite3CreateFunc(db, zFunc, nArg, enc, p, nil, xStep, xFinal, pArg)
+	if pArg != nil && pArg.nRef == 0 {
+		assert( rc != SQLITE_OK )
+		xDestroy(p)
+	}
+	if rc == SQLITE_OK {
+		pDef := db.FindFunction(zFunc, nArg, SQLITE_UTF8, false)
+		pDef.xValue = xValue
+		pDef.xInverse = xInverse
+	}
+	return db.ApiExit(rc)
+}
+
 /*
 ** Declare that a function has been overloaded by a virtual table.
 **
@@ -1667,6 +1695,17 @@ func int sqlite3_limit(sqlite3 *db, int limitId, int newLimit){
           limit = mask & flags;
           zModeType = "access";
         }
+        if( nOpt==7 && memcmp("process", zOpt, 7)==0 ){
+          static struct OpenMode aProcessMode[] = {
+            { "exclusive", SQLITE_OPEN_EXCLUSIVE_PROCESS },
+            { 0, 0 }
+          };
+
+          mask = SQLITE_OPEN_EXCLUSIVE_PROCESS;
+          aMode = aProcessMode;
+          limit = mask;
+          zModeType = "process";
+        }
 
         if( aMode ){
           int i;
@@ -1771,7 +1810,8 @@ static int openDatabase(
   ** dealt with in the previous code block.  Besides these, the only
   ** valid input flags for sqlite3_open_v2() are SQLITE_OPEN_READONLY,
   ** SQLITE_OPEN_READWRITE, SQLITE_OPEN_CREATE, SQLITE_OPEN_SHAREDCACHE,
-  ** SQLITE_OPEN_PRIVATECACHE, and some reserved bits.  Silently mask
+  ** SQLITE_OPEN_PRIVATECACHE, SQLITE_OPEN_EXCLUSIVE_PROCESS, and some
+  ** reserved bits.  Silently mask
   ** off all other flags.
   */
   flags &=  ~( SQLITE_OPEN_DELETEONCLOSE |
@@ -1894,6 +1934,18 @@ static int openDatabase(
     }
   }
 
+  if !db.mallocFailed && rc == SQLITE_OK {
+    rc = db.JsonInit()
+  }
+
+  if !db.mallocFailed && rc == SQLITE_OK {
+    rc = db.SeriesInit()
+  }
+
+  if !db.mallocFailed && rc == SQLITE_OK {
+    rc = db.Fts5Init()
+  }
+
 #ifdef SQLITE_ENABLE_FTS1
   if( !db.mallocFailed ){
     extern int sqlite3Fts1Init(sqlite3*);
@@ -0,0 +1,222 @@
+import (
+	"io"
+	"sync"
+)
+
+//	This file implements MemoryVFS, a VFS that keeps every file in memory. A database opened through it behaves exactly like one on
+//	disk, including journals and locking between connections, but lasts only as long as the MemoryVFS itself. It is intended mainly for
+//	tests:
+//
+//			RegisterVFS("mem", NewMemoryVFS(), false)
+//			sqlite3_open_v2("test.db", &db, SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE, "mem")
+
+//	MemoryVFS implements VFS. Files are shared between all connections that open them by the same name through the same MemoryVFS.
+type MemoryVFS struct {
+	mutex		sync.Mutex
+	files		map[string]*memoryData
+}
+
+//	NewMemoryVFS returns an empty MemoryVFS.
+func NewMemoryVFS() *MemoryVFS {
+	return &MemoryVFS{ files: make(map[string]*memoryData) }
+}
+
+//	The content and lock state of a single file. The lock state follows the same rules as the posix advisory locks used by os_unix.go:
+//	any number of SHARED locks, at most one RESERVED lock, and a PENDING lock that blocks new SHARED locks while a writer waits for
+//	EXCLUSIVE.
+type memoryData struct {
+	mutex		sync.Mutex
+	data		[]byte
+	nShared		int				//	Number of handles holding SHARED or greater
+	reserved	*memoryFile		//	Handle holding RESERVED, PENDING or EXCLUSIVE, if any
+	pending		bool			//	True if the reserved handle holds PENDING or EXCLUSIVE
+	exclusive	bool			//	True if the reserved handle holds EXCLUSIVE
+}
+
+//	memoryFile implements File for a single open handle on a memoryData.
+type memoryFile struct {
+	vfs				*MemoryVFS
+	name			string
+	*memoryData
+	lock			int			//	Current SQLITE_LOCK_* level held by this handle
+	deleteOnClose	bool
+}
+
+func (v *MemoryVFS) Open(name string, flags int) (f File, outFlags int, err error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	d := v.files[name]
+	switch {
+	case name == "":
+		//	Anonymous temporary files are private to this handle.
+		d = new(memoryData)
+	case d == nil && flags & SQLITE_OPEN_CREATE == 0:
+		return nil, 0, &Error{ Code: SQLITE_CANTOPEN }
+	case d == nil:
+		d = new(memoryData)
+		v.files[name] = d
+	case flags & SQLITE_OPEN_EXCLUSIVE != 0:
+		return nil, 0, &Error{ Code: SQLITE_CANTOPEN }
+	}
+	return &memoryFile{ vfs: v, name: name, memoryData: d, deleteOnClose: name == "" || flags & SQLITE_OPEN_DELETEONCLOSE != 0 }, flags, nil
+}
+
+func (v *MemoryVFS) Delete(name string, syncDir bool) error {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	if _, ok := v.files[name]; !ok {
+		return &Error{ Code: SQLITE_IOERR_DELETE }
+	}
+	delete(v.files, name)
+	return nil
+}
+
+func (v *MemoryVFS) Access(name string, flags int) (bool, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	_, ok := v.files[name]
+	return ok, nil
+}
+
+//	Names in a MemoryVFS are flat, so every name is already canonical.
+func (v *MemoryVFS) FullPathname(name string) (string, error) {
+	return name, nil
+}
+
+func (f *memoryFile) Close() error {
+	f.Unlock(SQLITE_LOCK_NONE)
+	if f.deleteOnClose && f.name != "" {
+		f.vfs.mutex.Lock()
+		if f.vfs.files[f.name] == f.memoryData {
+			delete(f.vfs.files, f.name)
+		}
+		f.vfs.mutex.Unlock()
+	}
+	f.memoryData = nil
+	return nil
+}
+
+func (f *memoryFile) ReadAt(p []byte, off int64) (n int, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	if n = copy(p, f.data[off:]); n < len(p) {
+		err = io.EOF
+	}
+	return
+}
+
+func (f *memoryFile) WriteAt(p []byte, off int64) (n int, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if end := off + int64(len(p)); end > int64(len(f.data)) {
+		f.grow(end)
+	}
+	return copy(f.data[off:], p), nil
+}
+
+func (f *memoryFile) Truncate(size int64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if size < int64(len(f.data)) {
+		f.data = f.data[:size]
+	} else {
+		f.grow(size)
+	}
+	return nil
+}
+
+//	Extend the file to size bytes. The bytes past the old end read as zero even where the capacity still holds data cut off by an
+//	earlier Truncate().
+func (f *memoryFile) grow(size int64) {
+	if size > int64(cap(f.data)) {
+		data := make([]byte, size, 2 * size)
+		copy(data, f.data)
+		f.data = data
+		return
+	}
+	n := len(f.data)
+	f.data = f.data[:size]
+	clear(f.data[n:])
+}
+
+//	Memory needs no syncing.
+func (f *memoryFile) Sync(flags int) error {
+	return nil
+}
+
+func (f *memoryFile) Size() (int64, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return int64(len(f.data)), nil
+}
+
+func (f *memoryFile) Lock(level int) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.lock >= level {
+		return nil
+	}
+	switch level {
+	case SQLITE_LOCK_SHARED:
+		if f.pending {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		f.nShared++
+	case SQLITE_LOCK_RESERVED:
+		assert( f.lock == SQLITE_LOCK_SHARED )
+		if f.reserved != nil {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		f.reserved = f
+	case SQLITE_LOCK_EXCLUSIVE:
+		assert( f.lock >= SQLITE_LOCK_SHARED )
+		switch {
+		case f.reserved != nil && f.reserved != f:
+			return &Error{ Code: SQLITE_BUSY }
+		case f.nShared > 1:
+			//	Hold PENDING so that no new readers arrive while the existing ones finish.
+			f.reserved = f
+			f.pending = true
+			f.lock = SQLITE_LOCK_PENDING
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		f.reserved = f
+		f.pending = true
+		f.exclusive = true
+	}
+	f.lock = level
+	return nil
+}
+
+func (f *memoryFile) Unlock(level int) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if f.lock <= level {
+		return nil
+	}
+	if f.lock > SQLITE_LOCK_SHARED {
+		assert( f.reserved == f )
+		f.reserved = nil
+		f.pending = false
+		f.exclusive = false
+	}
+	if level == SQLITE_LOCK_NONE {
+		f.nShared--
+	}
+	f.lock = level
+	return nil
+}
+
+func (f *memoryFile) CheckReservedLock() (bool, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.reserved != nil, nil
+}
+
+//	Writes to memory are atomic at any size and cannot be torn by a crash.
+func (f *memoryFile) DeviceCharacteristics() int {
+	return SQLITE_IOCAP_ATOMIC | SQLITE_IOCAP_SAFE_APPEND | SQLITE_IOCAP_SEQUENTIAL | SQLITE_IOCAP_POWERSAFE_OVERWRITE
+}
@@ -0,0 +1,25 @@
+import (
+	"bytes"
+	"testing"
+)
+
+//	Bytes cut off by Truncate() must read as zero once the file grows back over them, whether by a write past the end or by Truncate().
+func TestMemoryVFSTruncateGrow(t *testing.T) {
+	f, _, err := NewMemoryVFS().Open("f", SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	f.WriteAt(bytes.Repeat([]byte{ 0xff }, 16), 0)
+	f.Truncate(4)
+	f.WriteAt([]byte{ 1 }, 8)
+	f.Truncate(12)
+	got := make([]byte, 12)
+	if n, _ := f.ReadAt(got, 0); n != 12 {
+		t.Fatalf("ReadAt() read %v bytes, want 12", n)
+	}
+	want := []byte{ 0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0, 1, 0, 0, 0 }
+	if !bytes.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
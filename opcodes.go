@@ -153,6 +153,7 @@
      /* 148 */ "Trace",
      /* 149 */ "Noop",
      /* 150 */ "Explain",
+     /* 151 */ "Window",
   };
   return aName[i];
 }
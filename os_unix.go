@@ -1,4 +1,11 @@
-import "crypto/rand"
+import (
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
 
 
 /* This file contains the VFS implementation for unix-like operating systems
@@ -3114,6 +3121,12 @@ static int unixOpen(
   if( syncDir )                 ctrlFlags |= UNIXFILE_DIRSYNC;
   if( flags & SQLITE_OPEN_URI ) ctrlFlags |= UNIXFILE_URI;
 
+  /* A database opened in exclusive-process mode is locked against other
+  ** processes for as long as it is open, as with the unix-excl VFS, which
+  ** is what allows its wal-index to be kept in the memory of this process
+  ** (see walshm.go). */
+  if( flags & SQLITE_OPEN_EXCLUSIVE_PROCESS ) ctrlFlags |= UNIXFILE_EXCL;
+
   rc = fillInUnixFile(pVfs, fd, pFile, zPath, ctrlFlags);
 
 open_finished:
@@ -3400,6 +3413,530 @@ static int unixGetLastError(sqlite3_vfs *NotUsed, int NotUsed2, char *NotUsed3){
 ************************ End of sqlite3_vfs methods ***************************
 ******************************************************************************/
 
+//	UnixVFS is the unix VFS, registered as "unix", the default, and as "unix-excl". It uses posix advisory locks on the same byte ranges
+//	as the other unix VFS implementations above, so connections using any of them may share a database file. Locks held by separate
+//	connections within this process are tracked in a table of unixGoInode objects for the reason described at unixInodeInfo: posix
+//	locks belong to the process, not to the file descriptor. The wal-index of a database in WAL mode is the -shm file next to it,
+//	mapped into memory by every process that has the database open.
+//
+//	If Exclusive is true, as for "unix-excl", the process takes a posix write lock on each database it opens and keeps it until the
+//	database is closed, so that no other process can use the database. Connections within the process are still locked against one
+//	another. Open reports SQLITE_OPEN_EXCLUSIVE_PROCESS in its out flags, so the database is in exclusive-process mode and its
+//	wal-index is shared in the memory of the process, as described in walshm.go. A database opened with SQLITE_OPEN_EXCLUSIVE_PROCESS
+//	is locked the same way.
+type UnixVFS struct {
+	Exclusive	bool
+}
+
+//	Identifies a file independently of the name it was opened by.
+type unixGoInodeKey struct {
+	dev		uint64
+	ino		uint64
+}
+
+//	Lock state shared by every UnixVFS handle open on the same file within this process.
+type unixGoInode struct {
+	mutex			sync.Mutex
+	key				unixGoInodeKey
+	nRef			int					//	Number of handles using this object
+	nShared			int					//	Number of handles holding SHARED or greater
+	reserved		*unixGoFile			//	Handle holding RESERVED, PENDING or EXCLUSIVE, if any
+	pending			bool				//	True if the reserved handle holds PENDING or EXCLUSIVE
+	exclusive		bool				//	True if the reserved handle holds EXCLUSIVE
+	bProcessLock	bool				//	True if the process holds a write lock on the whole file. See UnixVFS.Exclusive
+	aUnused			[]*os.File			//	Descriptors of closed handles, kept open while locks are held
+	pShmNode		*unixGoShmNode		//	Wal-index of the database, if any
+}
+
+var (
+	unixGoInodes		= make(map[unixGoInodeKey]*unixGoInode)
+	unixGoInodesLock	sync.Mutex
+)
+
+//	unixGoFile implements File and SharedMemory on an *os.File.
+type unixGoFile struct {
+	*os.File
+	name			string				//	Name the file was opened by
+	inode			*unixGoInode
+	lock			int
+	isReadonly		bool
+	isExclusive		bool				//	Lock the file for the process. See UnixVFS.Exclusive
+	pShm			*unixGoShm			//	Handle on the wal-index, once mapped
+}
+
+func (v UnixVFS) Open(name string, flags int) (f File, outFlags int, err error) {
+	var file *os.File
+	mode := os.O_RDONLY
+	if flags & SQLITE_OPEN_READWRITE != 0 {
+		mode = os.O_RDWR
+	}
+	if flags & SQLITE_OPEN_CREATE != 0 {
+		mode |= os.O_CREATE
+	}
+	if flags & SQLITE_OPEN_EXCLUSIVE != 0 {
+		mode |= os.O_EXCL
+	}
+	if name == "" {
+		file, err = os.CreateTemp(unixTempFileDir(), SQLITE_TEMP_FILE_PREFIX)
+	} else {
+		file, err = os.OpenFile(name, mode, SQLITE_DEFAULT_FILE_PERMISSIONS)
+		if err != nil && mode & os.O_RDWR != 0 && os.IsPermission(err) {
+			//	Fall back to a read-only handle as unixOpen() does.
+			flags = flags &^ (SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE) | SQLITE_OPEN_READONLY
+			file, err = os.OpenFile(name, os.O_RDONLY, 0)
+		}
+	}
+	if err != nil {
+		return nil, 0, &Error{ Code: SQLITE_CANTOPEN, Message: err.Error() }
+	}
+	var st syscall.Stat_t
+	if err = syscall.Fstat(int(file.Fd()), &st); err != nil {
+		file.Close()
+		return nil, 0, &Error{ Code: SQLITE_CANTOPEN, Message: err.Error() }
+	}
+	key := unixGoInodeKey{ dev: uint64(st.Dev), ino: uint64(st.Ino) }
+	unixGoInodesLock.Lock()
+	inode := unixGoInodes[key]
+	if inode == nil {
+		inode = &unixGoInode{ key: key }
+		unixGoInodes[key] = inode
+	}
+	inode.nRef++
+	unixGoInodesLock.Unlock()
+	if name == "" || flags & SQLITE_OPEN_DELETEONCLOSE != 0 {
+		//	Unlink at once so the file disappears even if the process dies.
+		os.Remove(file.Name())
+	}
+	if v.Exclusive {
+		flags |= SQLITE_OPEN_EXCLUSIVE_PROCESS
+	}
+	return &unixGoFile{
+		File:			file,
+		name:			name,
+		inode:			inode,
+		isReadonly:		flags & SQLITE_OPEN_READONLY != 0,
+		isExclusive:	flags & SQLITE_OPEN_EXCLUSIVE_PROCESS != 0,
+	}, flags, nil
+}
+
+func (UnixVFS) Delete(name string, syncDir bool) error {
+	if err := os.Remove(name); err != nil {
+		if os.IsNotExist(err) {
+			return &Error{ Code: SQLITE_IOERR_DELETE }
+		}
+		return err
+	}
+	if syncDir {
+		if dir, err := os.Open(filepath.Dir(name)); err == nil {
+			err = dir.Sync()
+			dir.Close()
+			if err != nil {
+				return &Error{ Code: SQLITE_IOERR_DIR_FSYNC, Message: err.Error() }
+			}
+		}
+	}
+	return nil
+}
+
+//	As with unixAccess(), a zero-length file is treated as not existing.
+func (UnixVFS) Access(name string, flags int) (bool, error) {
+	switch flags {
+	case SQLITE_ACCESS_EXISTS:
+		info, err := os.Stat(name)
+		return err == nil && info.Size() > 0, nil
+	case SQLITE_ACCESS_READWRITE:
+		return syscall.Access(name, 0x6) == nil, nil
+	default:
+		return syscall.Access(name, 0x4) == nil, nil
+	}
+}
+
+func (UnixVFS) FullPathname(name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return name, nil
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", &Error{ Code: SQLITE_CANTOPEN, Message: err.Error() }
+	}
+	return cwd + "/" + name, nil
+}
+
+//	Closing a descriptor releases every posix lock the process holds on the file, so while other handles hold locks the descriptor is
+//	kept open with the inode, as unixClose() does with unixInodeInfo.pUnused.
+func (f *unixGoFile) Close() (err error) {
+	if f.pShm != nil {
+		f.ShmUnmap(false)
+	}
+	f.Unlock(SQLITE_LOCK_NONE)
+	inode := f.inode
+	unixGoInodesLock.Lock()
+	defer unixGoInodesLock.Unlock()
+	inode.mutex.Lock()
+	defer inode.mutex.Unlock()
+	switch inode.nRef--; {
+	case inode.nRef == 0:
+		delete(unixGoInodes, inode.key)
+		for _, file := range inode.aUnused {
+			file.Close()
+		}
+		inode.aUnused = nil
+		err = f.File.Close()
+	case inode.nShared > 0 || inode.bProcessLock:
+		inode.aUnused = append(inode.aUnused, f.File)
+	default:
+		err = f.File.Close()
+	}
+	f.inode = nil
+	return
+}
+
+func (f *unixGoFile) Sync(flags int) error {
+	return f.File.Sync()
+}
+
+func (f *unixGoFile) Size() (int64, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+//	Set a posix advisory lock of type lockType on n bytes starting at offset start of the file with descriptor fd. A lock that
+//	conflicts with one held by another process is reported as SQLITE_BUSY.
+func unixGoSetLock(fd uintptr, lockType int16, start, n int64) error {
+	lock := syscall.Flock_t{ Type: lockType, Whence: 0, Start: start, Len: n }
+	switch err := syscall.FcntlFlock(fd, syscall.F_SETLK, &lock); err {
+	case nil:
+		return nil
+	case syscall.EAGAIN, syscall.EACCES:
+		return &Error{ Code: SQLITE_BUSY }
+	default:
+		return err
+	}
+}
+
+//	Set a posix advisory lock on the file, as unixFileLock() does. A file locked for the process takes a write lock on the whole of
+//	the shared range the first time and changes no posix locks after that.
+func (f *unixGoFile) setLock(lockType int16, start, n int64) error {
+	if f.isExclusive && !f.isReadonly {
+		if !f.inode.bProcessLock {
+			if err := unixGoSetLock(f.File.Fd(), syscall.F_WRLCK, SHARED_FIRST, SHARED_SIZE); err != nil {
+				return err
+			}
+			f.inode.bProcessLock = true
+		}
+		return nil
+	}
+	return unixGoSetLock(f.File.Fd(), lockType, start, n)
+}
+
+//	Lock follows the same sequence as unixLock(). Within the process the unixGoInode decides whether the lock can be granted; the posix
+//	locks are only changed when the process as a whole acquires or releases a level.
+func (f *unixGoFile) Lock(level int) (err error) {
+	inode := f.inode
+	inode.mutex.Lock()
+	defer inode.mutex.Unlock()
+	if f.lock >= level {
+		return nil
+	}
+	switch level {
+	case SQLITE_LOCK_SHARED:
+		if inode.pending {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		if inode.nShared == 0 {
+			//	A PENDING lock held by another process blocks new readers, so take and drop a read lock on it first.
+			if err = f.setLock(syscall.F_RDLCK, PENDING_BYTE, 1); err != nil {
+				return
+			}
+			err = f.setLock(syscall.F_RDLCK, SHARED_FIRST, SHARED_SIZE)
+			f.setLock(syscall.F_UNLCK, PENDING_BYTE, 1)
+			if err != nil {
+				return
+			}
+		}
+		inode.nShared++
+	case SQLITE_LOCK_RESERVED:
+		assert( f.lock == SQLITE_LOCK_SHARED )
+		if inode.reserved != nil {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		if err = f.setLock(syscall.F_WRLCK, RESERVED_BYTE, 1); err != nil {
+			return
+		}
+		inode.reserved = f
+	case SQLITE_LOCK_EXCLUSIVE:
+		assert( f.lock >= SQLITE_LOCK_SHARED )
+		if inode.reserved != nil && inode.reserved != f {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		if !inode.pending {
+			if err = f.setLock(syscall.F_WRLCK, PENDING_BYTE, 1); err != nil {
+				return
+			}
+			inode.reserved = f
+			inode.pending = true
+			f.lock = SQLITE_LOCK_PENDING
+		}
+		if inode.nShared > 1 {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		if err = f.setLock(syscall.F_WRLCK, SHARED_FIRST, SHARED_SIZE); err != nil {
+			return
+		}
+		inode.exclusive = true
+	}
+	f.lock = level
+	return nil
+}
+
+func (f *unixGoFile) Unlock(level int) (err error) {
+	inode := f.inode
+	inode.mutex.Lock()
+	defer inode.mutex.Unlock()
+	if f.lock <= level {
+		return nil
+	}
+	if f.lock > SQLITE_LOCK_SHARED {
+		assert( inode.reserved == f )
+		if inode.exclusive && level == SQLITE_LOCK_SHARED {
+			if err = f.setLock(syscall.F_RDLCK, SHARED_FIRST, SHARED_SIZE); err != nil {
+				return &Error{ Code: SQLITE_IOERR_RDLOCK, Message: err.Error() }
+			}
+		}
+		f.setLock(syscall.F_UNLCK, PENDING_BYTE, 2)
+		inode.reserved = nil
+		inode.pending = false
+		inode.exclusive = false
+	}
+	if level == SQLITE_LOCK_NONE {
+		if inode.nShared--; inode.nShared == 0 {
+			if err = f.setLock(syscall.F_UNLCK, SHARED_FIRST, SHARED_SIZE); err != nil {
+				return &Error{ Code: SQLITE_IOERR_UNLOCK, Message: err.Error() }
+			}
+		}
+	}
+	f.lock = level
+	return nil
+}
+
+func (f *unixGoFile) CheckReservedLock() (bool, error) {
+	inode := f.inode
+	inode.mutex.Lock()
+	defer inode.mutex.Unlock()
+	if inode.reserved != nil {
+		return true, nil
+	}
+	if inode.bProcessLock {
+		return false, nil
+	}
+	lock := syscall.Flock_t{ Type: syscall.F_WRLCK, Whence: 0, Start: RESERVED_BYTE, Len: 1 }
+	if err := syscall.FcntlFlock(f.File.Fd(), syscall.F_GETLK, &lock); err != nil {
+		return false, &Error{ Code: SQLITE_IOERR_CHECKRESERVEDLOCK, Message: err.Error() }
+	}
+	return lock.Type != syscall.F_UNLCK, nil
+}
+
+func (f *unixGoFile) SectorSize() int {
+	return SQLITE_DEFAULT_SECTOR_SIZE
+}
+
+//	The wal-index of a database, shared by every UnixVFS handle open on the database within this process, as unixShmNode is. Each
+//	region is mapped from the -shm file. A database locked for the process never uses it, as its pager keeps the wal-index in a
+//	walShmNode.
+type unixGoShmNode struct {
+	mutex		sync.Mutex				//	Guards the fields below and the aShm of each handle
+	file		*os.File				//	The -shm file
+	isReadonly	bool					//	True if the -shm file could only be opened for reading
+	aRegion		[][]uint32				//	The regions of the wal-index
+	aMapping	[][]byte				//	The memory mapped for each region of the -shm file, to be unmapped
+	aShm		[]*unixGoShm			//	Handles on the wal-index
+}
+
+//	A handle on a unixGoShmNode, recording the wal-index locks that one database connection holds.
+type unixGoShm struct {
+	pNode		*unixGoShmNode
+	sharedMask	uint16					//	Locks held SHARED
+	exclMask	uint16					//	Locks held EXCLUSIVE
+}
+
+//	Take a posix lock on the -shm file of pNode.
+func (pNode *unixGoShmNode) systemLock(lockType int16, ofst, n int) error {
+	return unixGoSetLock(pNode.file.Fd(), lockType, int64(ofst), int64(n))
+}
+
+//	Open the wal-index of the database of f, as unixOpenSharedMemory() does. The -shm file is truncated if no other process holds
+//	the dead-man switch, since no other process is then using it.
+func (f *unixGoFile) openShm() error {
+	inode := f.inode
+	inode.mutex.Lock()
+	defer inode.mutex.Unlock()
+	pNode := inode.pShmNode
+	if pNode == nil {
+		pNode = new(unixGoShmNode)
+		zShmFilename := f.name + "-shm"
+		info, err := f.File.Stat()
+		if err != nil {
+			return &Error{ Code: SQLITE_IOERR_FSTAT, Message: err.Error() }
+		}
+		if pNode.file, err = os.OpenFile(zShmFilename, os.O_RDWR | os.O_CREATE, info.Mode().Perm()); err != nil {
+			if pNode.file, err = os.OpenFile(zShmFilename, os.O_RDONLY, 0); err != nil {
+				return &Error{ Code: SQLITE_CANTOPEN, Message: err.Error() }
+			}
+			pNode.isReadonly = true
+		}
+		if pNode.systemLock(syscall.F_WRLCK, UNIX_SHM_DMS, 1) == nil {
+			if err = pNode.file.Truncate(0); err != nil {
+				pNode.file.Close()
+				return &Error{ Code: SQLITE_IOERR_SHMOPEN, Message: err.Error() }
+			}
+		}
+		if err = pNode.systemLock(syscall.F_RDLCK, UNIX_SHM_DMS, 1); err != nil {
+			pNode.file.Close()
+			return err
+		}
+		inode.pShmNode = pNode
+	}
+	f.pShm = &unixGoShm{ pNode: pNode }
+	pNode.mutex.Lock()
+	pNode.aShm = append(pNode.aShm, f.pShm)
+	pNode.mutex.Unlock()
+	return nil
+}
+
+//	ShmMap follows unixShmMap(). The -shm file is grown to hold region iRegion if bExtend is true.
+func (f *unixGoFile) ShmMap(iRegion, szRegion int, bExtend bool) ([]uint32, error) {
+	if f.pShm == nil {
+		if err := f.openShm(); err != nil {
+			return nil, err
+		}
+	}
+	pNode := f.pShm.pNode
+	pNode.mutex.Lock()
+	defer pNode.mutex.Unlock()
+	if len(pNode.aRegion) <= iRegion {
+		info, err := pNode.file.Stat()
+		if err != nil {
+			return nil, &Error{ Code: SQLITE_IOERR_SHMSIZE, Message: err.Error() }
+		}
+		if nByte := int64(iRegion + 1) * int64(szRegion); info.Size() < nByte {
+			if !bExtend {
+				return nil, nil
+			}
+			if err = pNode.file.Truncate(nByte); err != nil {
+				return nil, &Error{ Code: SQLITE_IOERR_SHMSIZE, Message: err.Error() }
+			}
+		}
+		for len(pNode.aRegion) <= iRegion {
+			prot := syscall.PROT_READ | syscall.PROT_WRITE
+			if pNode.isReadonly {
+				prot = syscall.PROT_READ
+			}
+			aMem, err := syscall.Mmap(int(pNode.file.Fd()), int64(len(pNode.aRegion)) * int64(szRegion), szRegion, prot, syscall.MAP_SHARED)
+			if err != nil {
+				return nil, &Error{ Code: SQLITE_IOERR_SHMMAP, Message: err.Error() }
+			}
+			pNode.aMapping = append(pNode.aMapping, aMem)
+			pNode.aRegion = append(pNode.aRegion, unsafe.Slice((*uint32)(unsafe.Pointer(&aMem[0])), szRegion / 4))
+		}
+	}
+	if pNode.isReadonly {
+		return pNode.aRegion[iRegion], &Error{ Code: SQLITE_READONLY }
+	}
+	return pNode.aRegion[iRegion], nil
+}
+
+//	ShmLock follows unixShmLock(). The posix lock on the -shm file is only changed when the first handle of the process takes a lock or
+//	the last one releases it.
+func (f *unixGoFile) ShmLock(ofst, n, flags int) (err error) {
+	p := f.pShm
+	pNode := p.pNode
+	mask := uint16((1 << uint(ofst + n)) - (1 << uint(ofst)))
+	assert( ofst >= 0 && ofst + n <= SQLITE_SHM_NLOCK && n >= 1 )
+	pNode.mutex.Lock()
+	defer pNode.mutex.Unlock()
+	var allShared, allExcl uint16
+	for _, pX := range pNode.aShm {
+		if pX != p {
+			allShared |= pX.sharedMask
+			allExcl |= pX.exclMask
+		}
+	}
+	switch {
+	case flags & SQLITE_SHM_UNLOCK != 0:
+		if mask & allShared == 0 {
+			if err = pNode.systemLock(syscall.F_UNLCK, ofst + UNIX_SHM_BASE, n); err != nil {
+				return
+			}
+		}
+		p.exclMask &^= mask
+		p.sharedMask &^= mask
+	case flags & SQLITE_SHM_SHARED != 0:
+		assert( n == 1 )
+		if mask & allExcl != 0 {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		if mask & (allShared | p.sharedMask) == 0 {
+			if err = pNode.systemLock(syscall.F_RDLCK, ofst + UNIX_SHM_BASE, n); err != nil {
+				return
+			}
+		}
+		p.sharedMask |= mask
+	default:
+		if mask & (allShared | allExcl) != 0 {
+			return &Error{ Code: SQLITE_BUSY }
+		}
+		if err = pNode.systemLock(syscall.F_WRLCK, ofst + UNIX_SHM_BASE, n); err != nil {
+			return
+		}
+		assert( p.sharedMask & mask == 0 )
+		p.exclMask |= mask
+	}
+	return nil
+}
+
+//	The mutex of the node orders the wal-index accesses of the goroutines that take it, as the master mutex does in unixShmBarrier().
+func (f *unixGoFile) ShmBarrier() {
+	pNode := f.pShm.pNode
+	pNode.mutex.Lock()
+	pNode.mutex.Unlock()
+}
+
+//	ShmUnmap follows unixShmUnmap(). The regions are unmapped and the -shm file closed with the last handle of the process.
+func (f *unixGoFile) ShmUnmap(deleteFlag bool) (err error) {
+	p := f.pShm
+	if p == nil {
+		return nil
+	}
+	inode := f.inode
+	pNode := p.pNode
+	inode.mutex.Lock()
+	defer inode.mutex.Unlock()
+	pNode.mutex.Lock()
+	for i, pX := range pNode.aShm {
+		if pX == p {
+			pNode.aShm = append(pNode.aShm[:i], pNode.aShm[i + 1:]...)
+			break
+		}
+	}
+	nShm := len(pNode.aShm)
+	pNode.mutex.Unlock()
+	f.pShm = nil
+	if nShm == 0 {
+		for _, aMem := range pNode.aMapping {
+			syscall.Munmap(aMem)
+		}
+		if deleteFlag {
+			os.Remove(f.name + "-shm")
+		}
+		err = pNode.file.Close()
+		inode.pShmNode = nil
+	}
+	return
+}
+
 /*
 ** Initialize the operating system interface.
 **
@@ -3467,10 +4004,8 @@ func int sqlite3_os_init(void){
   ** array cannot be const.
   */
   static sqlite3_vfs aVfs[] = {
-    UNIXVFS("unix",          posixIoFinder ),
     UNIXVFS("unix-none",     nolockIoFinder ),
     UNIXVFS("unix-dotfile",  dotlockIoFinder ),
-    UNIXVFS("unix-excl",     posixIoFinder ),
   };
   uint i;          /* Loop counter */
 
@@ -3479,8 +4014,11 @@ func int sqlite3_os_init(void){
   assert( ArraySize(aSyscall)==22 );
 
   /* Register all VFSes defined in the aVfs[] array */
+  /* The posix-locking VFS implementations are UnixVFS. */
+  RegisterVFS("unix", UnixVFS{}, true)
+  RegisterVFS("unix-excl", UnixVFS{ Exclusive: true }, false)
   for(i=0; i<(sizeof(aVfs)/sizeof(sqlite3_vfs)); i++){
-    sqlite3_vfs_register(&aVfs[i], i==0);
+    sqlite3_vfs_register(&aVfs[i], 0);
   }
   return SQLITE_OK; 
 }
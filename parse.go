@@ -178,28 +178,29 @@ struct ValueList {
 **    YYERRORSYMBOL      is the code number of the error symbol.  If not
 **                       defined, then do no error processing.
 */
-#define YYCODETYPE unsigned char
-#define YYNOCODE 251
+#define YYCODETYPE unsigned short int
+#define YYNOCODE 273
 #define YYACTIONTYPE unsigned short int
 #define YYWILDCARD 67
 #define sqlite3ParserTOKENTYPE Token
 typedef union {
   int yyinit;
   sqlite3ParserTOKENTYPE yy0;
-  struct LimitVal yy64;
-  Expr* yy122;
-  Select* yy159;
-  IdList* yy180;
-  struct {int value; int mask;} yy207;
-  byte yy258;
-  struct LikeOp yy318;
-  TriggerStep* yy327;
-  ExprSpan yy342;
-  SrcList* yy347;
-  int yy392;
-  struct TrigEvent yy410;
-  ExprList* yy442;
-  struct ValueList yy487;
+  struct LikeOp yy54;
+  int yy60;
+  struct {int value; int mask;} yy175;
+  struct ValueList yy211;
+  struct LimitVal yy212;
+  ExprSpan yy214;
+  With* yy227;
+  TriggerStep* yy251;
+  IdList* yy272;
+  byte yy274;
+  SrcList* yy291;
+  Select* yy331;
+  Expr* yy386;
+  ExprList* yy402;
+  struct TrigEvent yy403;
 } YYMINORTYPE;
 #ifndef YYSTACKDEPTH
 #define YYSTACKDEPTH 100
@@ -208,8 +209,8 @@ typedef union {
 #define sqlite3ParserARG_PDECL ,Parse *pParse
 #define sqlite3ParserARG_FETCH Parse *pParse = yypParser.pParse
 #define sqlite3ParserARG_STORE yypParser.pParse = pParse
-#define YYNSTATE 627
-#define YYNRULE 327
+#define YYNSTATE 657
+#define YYNRULE 336
 #define YYFALLBACK 1
 #define YY_NO_ACTION      (YYNSTATE+YYNRULE+2)
 #define YY_ACCEPT_ACTION  (YYNSTATE+YYNRULE+1)
@@ -266,474 +267,494 @@ static const YYMINORTYPE yyzerominor = { 0 };
 **                     shifting non-terminals after a reduce.
 **  yy_default[]       Default action for each state.
 */
-#define YY_ACTTAB_COUNT (1564)
+#define YY_ACTTAB_COUNT (1634)
 static const YYACTIONTYPE yy_action[] = {
- /*     0 */   309,  955,  184,  417,    2,  171,  624,  594,   56,   56,
- /*    10 */    56,   56,   49,   54,   54,   54,   54,   53,   53,   52,
- /*    20 */    52,   52,   51,  233,  620,  619,  298,  620,  619,  234,
- /*    30 */   587,  581,   56,   56,   56,   56,   19,   54,   54,   54,
- /*    40 */    54,   53,   53,   52,   52,   52,   51,  233,  605,   57,
- /*    50 */    58,   48,  579,  578,  580,  580,   55,   55,   56,   56,
- /*    60 */    56,   56,  541,   54,   54,   54,   54,   53,   53,   52,
- /*    70 */    52,   52,   51,  233,  309,  594,  325,  196,  195,  194,
- /*    80 */    33,   54,   54,   54,   54,   53,   53,   52,   52,   52,
- /*    90 */    51,  233,  617,  616,  165,  617,  616,  380,  377,  376,
- /*   100 */   407,  532,  576,  576,  587,  581,  303,  422,  375,   59,
- /*   110 */    53,   53,   52,   52,   52,   51,  233,   50,   47,  146,
- /*   120 */   574,  545,   65,   57,   58,   48,  579,  578,  580,  580,
- /*   130 */    55,   55,   56,   56,   56,   56,  213,   54,   54,   54,
- /*   140 */    54,   53,   53,   52,   52,   52,   51,  233,  309,  223,
- /*   150 */   539,  420,  170,  176,  138,  280,  383,  275,  382,  168,
- /*   160 */   489,  551,  409,  668,  620,  619,  271,  438,  409,  438,
- /*   170 */   550,  604,   67,  482,  507,  618,  599,  412,  587,  581,
- /*   180 */   600,  483,  618,  412,  618,  598,   91,  439,  440,  439,
- /*   190 */   335,  598,   73,  669,  222,  266,  480,   57,   58,   48,
- /*   200 */   579,  578,  580,  580,   55,   55,   56,   56,   56,   56,
- /*   210 */   670,   54,   54,   54,   54,   53,   53,   52,   52,   52,
- /*   220 */    51,  233,  309,  279,  232,  231,    1,  132,  200,  385,
- /*   230 */   620,  619,  617,  616,  278,  435,  289,  563,  175,  262,
- /*   240 */   409,  264,  437,  497,  436,  166,  441,  568,  336,  568,
- /*   250 */   201,  537,  587,  581,  599,  412,  165,  594,  600,  380,
- /*   260 */   377,  376,  597,  598,   92,  523,  618,  569,  569,  592,
- /*   270 */   375,   57,   58,   48,  579,  578,  580,  580,   55,   55,
- /*   280 */    56,   56,   56,   56,  597,   54,   54,   54,   54,   53,
- /*   290 */    53,   52,   52,   52,   51,  233,  309,  463,  617,  616,
- /*   300 */   590,  590,  590,  174,  272,  396,  409,  272,  409,  548,
- /*   310 */   397,  620,  619,   68,  326,  620,  619,  620,  619,  618,
- /*   320 */   546,  412,  618,  412,  471,  594,  587,  581,  472,  598,
- /*   330 */    92,  598,   92,   52,   52,   52,   51,  233,  513,  512,
- /*   340 */   206,  322,  363,  464,  221,   57,   58,   48,  579,  578,
- /*   350 */   580,  580,   55,   55,   56,   56,   56,   56,  529,   54,
- /*   360 */    54,   54,   54,   53,   53,   52,   52,   52,   51,  233,
- /*   370 */   309,  396,  409,  396,  597,  372,  386,  530,  347,  617,
- /*   380 */   616,  575,  202,  617,  616,  617,  616,  412,  620,  619,
- /*   390 */   145,  255,  346,  254,  577,  598,   74,  351,   45,  489,
- /*   400 */   587,  581,  235,  189,  464,  544,  167,  296,  187,  469,
- /*   410 */   479,   67,   62,   39,  618,  546,  597,  345,  573,   57,
- /*   420 */    58,   48,  579,  578,  580,  580,   55,   55,   56,   56,
- /*   430 */    56,   56,    6,   54,   54,   54,   54,   53,   53,   52,
- /*   440 */    52,   52,   51,  233,  309,  562,  558,  407,  528,  576,
- /*   450 */   576,  344,  255,  346,  254,  182,  617,  616,  503,  504,
- /*   460 */   314,  409,  557,  235,  166,  271,  409,  352,  564,  181,
- /*   470 */   407,  546,  576,  576,  587,  581,  412,  537,  556,  561,
- /*   480 */   517,  412,  618,  249,  598,   16,    7,   36,  467,  598,
- /*   490 */    92,  516,  618,   57,   58,   48,  579,  578,  580,  580,
- /*   500 */    55,   55,   56,   56,   56,   56,  541,   54,   54,   54,
- /*   510 */    54,   53,   53,   52,   52,   52,   51,  233,  309,  327,
- /*   520 */   572,  571,  525,  558,  560,  394,  871,  246,  409,  248,
- /*   530 */   171,  392,  594,  219,  407,  409,  576,  576,  502,  557,
- /*   540 */   364,  145,  510,  412,  407,  229,  576,  576,  587,  581,
- /*   550 */   412,  598,   92,  381,  269,  556,  166,  400,  598,   69,
- /*   560 */   501,  419,  945,  199,  945,  198,  546,   57,   58,   48,
- /*   570 */   579,  578,  580,  580,   55,   55,   56,   56,   56,   56,
- /*   580 */   568,   54,   54,   54,   54,   53,   53,   52,   52,   52,
- /*   590 */    51,  233,  309,  317,  419,  944,  508,  944,  308,  597,
- /*   600 */   594,  565,  490,  212,  173,  247,  423,  615,  614,  613,
- /*   610 */   323,  197,  143,  405,  572,  571,  489,   66,   50,   47,
- /*   620 */   146,  594,  587,  581,  232,  231,  559,  427,   67,  555,
- /*   630 */    15,  618,  186,  543,  303,  421,   35,  206,  432,  423,
- /*   640 */   552,   57,   58,   48,  579,  578,  580,  580,   55,   55,
- /*   650 */    56,   56,   56,   56,  205,   54,   54,   54,   54,   53,
- /*   660 */    53,   52,   52,   52,   51,  233,  309,  569,  569,  260,
- /*   670 */   268,  597,   12,  373,  568,  166,  409,  313,  409,  420,
- /*   680 */   409,  473,  473,  365,  618,   50,   47,  146,  597,  594,
- /*   690 */   468,  412,  166,  412,  351,  412,  587,  581,   32,  598,
- /*   700 */    94,  598,   97,  598,   95,  627,  625,  329,  142,   50,
- /*   710 */    47,  146,  333,  349,  358,   57,   58,   48,  579,  578,
- /*   720 */   580,  580,   55,   55,   56,   56,   56,   56,  409,   54,
- /*   730 */    54,   54,   54,   53,   53,   52,   52,   52,   51,  233,
- /*   740 */   309,  409,  388,  412,  409,   22,  565,  404,  212,  362,
- /*   750 */   389,  598,  104,  359,  409,  156,  412,  409,  603,  412,
- /*   760 */   537,  331,  569,  569,  598,  103,  493,  598,  105,  412,
- /*   770 */   587,  581,  412,  260,  549,  618,   11,  598,  106,  521,
- /*   780 */   598,  133,  169,  457,  456,  170,   35,  601,  618,   57,
- /*   790 */    58,   48,  579,  578,  580,  580,   55,   55,   56,   56,
- /*   800 */    56,   56,  409,   54,   54,   54,   54,   53,   53,   52,
- /*   810 */    52,   52,   51,  233,  309,  409,  259,  412,  409,   50,
- /*   820 */    47,  146,  357,  318,  355,  598,  134,  527,  352,  337,
- /*   830 */   412,  409,  356,  412,  357,  409,  357,  618,  598,   98,
- /*   840 */   129,  598,  102,  618,  587,  581,  412,   21,  235,  618,
- /*   850 */   412,  618,  211,  143,  598,  101,   30,  167,  598,   93,
- /*   860 */   350,  535,  203,   57,   58,   48,  579,  578,  580,  580,
- /*   870 */    55,   55,   56,   56,   56,   56,  409,   54,   54,   54,
- /*   880 */    54,   53,   53,   52,   52,   52,   51,  233,  309,  409,
- /*   890 */   526,  412,  409,  425,  215,  305,  597,  551,  141,  598,
- /*   900 */   100,   40,  409,   38,  412,  409,  550,  412,  409,  228,
- /*   910 */   220,  314,  598,   77,  500,  598,   96,  412,  587,  581,
- /*   920 */   412,  338,  253,  412,  218,  598,  137,  379,  598,  136,
- /*   930 */    28,  598,  135,  270,  715,  210,  481,   57,   58,   48,
- /*   940 */   579,  578,  580,  580,   55,   55,   56,   56,   56,   56,
- /*   950 */   409,   54,   54,   54,   54,   53,   53,   52,   52,   52,
- /*   960 */    51,  233,  309,  409,  272,  412,  409,  315,  147,  597,
- /*   970 */   272,  626,    2,  598,   76,  209,  409,  127,  412,  618,
- /*   980 */   126,  412,  409,  621,  235,  618,  598,   90,  374,  598,
- /*   990 */    89,  412,  587,  581,   27,  260,  350,  412,  618,  598,
- /*  1000 */    75,  321,  541,  541,  125,  598,   88,  320,  278,  597,
- /*  1010 */   618,   57,   46,   48,  579,  578,  580,  580,   55,   55,
- /*  1020 */    56,   56,   56,   56,  409,   54,   54,   54,   54,   53,
- /*  1030 */    53,   52,   52,   52,   51,  233,  309,  409,  450,  412,
- /*  1040 */   164,  284,  282,  272,  609,  424,  304,  598,   87,  370,
- /*  1050 */   409,  477,  412,  409,  608,  409,  607,  602,  618,  618,
- /*  1060 */   598,   99,  586,  585,  122,  412,  587,  581,  412,  618,
- /*  1070 */   412,  618,  618,  598,   86,  366,  598,   17,  598,   85,
- /*  1080 */   319,  185,  519,  518,  583,  582,   58,   48,  579,  578,
- /*  1090 */   580,  580,   55,   55,   56,   56,   56,   56,  409,   54,
- /*  1100 */    54,   54,   54,   53,   53,   52,   52,   52,   51,  233,
- /*  1110 */   309,  584,  409,  412,  409,  260,  260,  260,  408,  591,
- /*  1120 */   474,  598,   84,  170,  409,  466,  518,  412,  121,  412,
- /*  1130 */   618,  618,  618,  618,  618,  598,   83,  598,   72,  412,
- /*  1140 */   587,  581,   51,  233,  625,  329,  470,  598,   71,  257,
- /*  1150 */   159,  120,   14,  462,  157,  158,  117,  260,  448,  447,
- /*  1160 */   446,   48,  579,  578,  580,  580,   55,   55,   56,   56,
- /*  1170 */    56,   56,  618,   54,   54,   54,   54,   53,   53,   52,
- /*  1180 */    52,   52,   51,  233,   44,  403,  260,    3,  409,  459,
- /*  1190 */   260,  413,  619,  118,  398,   10,   25,   24,  554,  348,
- /*  1200 */   217,  618,  406,  412,  409,  618,    4,   44,  403,  618,
- /*  1210 */     3,  598,   82,  618,  413,  619,  455,  542,  115,  412,
- /*  1220 */   538,  401,  536,  274,  506,  406,  251,  598,   81,  216,
- /*  1230 */   273,  563,  618,  243,  453,  618,  154,  618,  618,  618,
- /*  1240 */   449,  416,  623,  110,  401,  618,  409,  236,   64,  123,
- /*  1250 */   487,   41,   42,  531,  563,  204,  409,  267,   43,  411,
- /*  1260 */   410,  412,  265,  592,  108,  618,  107,  434,  332,  598,
- /*  1270 */    80,  412,  618,  263,   41,   42,  443,  618,  409,  598,
- /*  1280 */    70,   43,  411,  410,  433,  261,  592,  149,  618,  597,
- /*  1290 */   256,  237,  188,  412,  590,  590,  590,  589,  588,   13,
- /*  1300 */   618,  598,   18,  328,  235,  618,   44,  403,  360,    3,
- /*  1310 */   418,  461,  339,  413,  619,  227,  124,  590,  590,  590,
- /*  1320 */   589,  588,   13,  618,  406,  409,  618,  409,  139,   34,
- /*  1330 */   403,  387,    3,  148,  622,  312,  413,  619,  311,  330,
- /*  1340 */   412,  460,  412,  401,  180,  353,  412,  406,  598,   79,
- /*  1350 */   598,   78,  250,  563,  598,    9,  618,  612,  611,  610,
- /*  1360 */   618,    8,  452,  442,  242,  415,  401,  618,  239,  235,
- /*  1370 */   179,  238,  428,   41,   42,  288,  563,  618,  618,  618,
- /*  1380 */    43,  411,  410,  618,  144,  592,  618,  618,  177,   61,
- /*  1390 */   618,  596,  391,  620,  619,  287,   41,   42,  414,  618,
- /*  1400 */   293,   30,  393,   43,  411,  410,  292,  618,  592,   31,
- /*  1410 */   618,  395,  291,   60,  230,   37,  590,  590,  590,  589,
- /*  1420 */   588,   13,  214,  553,  183,  290,  172,  301,  300,  299,
- /*  1430 */   178,  297,  595,  563,  451,   29,  285,  390,  540,  590,
- /*  1440 */   590,  590,  589,  588,   13,  283,  520,  534,  150,  533,
- /*  1450 */   241,  281,  384,  192,  191,  324,  515,  514,  276,  240,
- /*  1460 */   510,  523,  307,  511,  128,  592,  509,  225,  226,  486,
- /*  1470 */   485,  224,  152,  491,  464,  306,  484,  163,  153,  371,
- /*  1480 */   478,  151,  162,  258,  369,  161,  367,  208,  475,  476,
- /*  1490 */    26,  160,  465,  140,  361,  131,  590,  590,  590,  116,
- /*  1500 */   119,  454,  343,  155,  114,  342,  113,  112,  445,  111,
- /*  1510 */   130,  109,  431,  316,  426,  430,   23,  429,   20,  606,
- /*  1520 */   190,  507,  255,  341,  244,   63,  294,  593,  310,  570,
- /*  1530 */   277,  402,  354,  235,  567,  496,  495,  492,  494,  302,
- /*  1540 */   458,  378,  286,  245,  566,    5,  252,  547,  193,  444,
- /*  1550 */   233,  340,  207,  524,  368,  505,  334,  522,  499,  399,
- /*  1560 */   295,  498,  956,  488,
+ /*     0 */   313,  994,  192,  436,    2,  176,  986,  625,   57,   57,
+ /*    10 */    57,   57,   50,   55,   55,   55,   55,   54,   54,   53,
+ /*    20 */    53,   53,   52,  233,   53,   53,   53,   52,  233,  327,
+ /*    30 */   618,  612,   57,   57,   57,   57,  233,   55,   55,   55,
+ /*    40 */    55,   54,   54,   53,   53,   53,   52,  233,  108,   58,
+ /*    50 */    59,   49,  610,  609,  611,  611,   56,   56,   57,   57,
+ /*    60 */    57,   57,  108,   55,   55,   55,   55,   54,   54,   53,
+ /*    70 */    53,   53,   52,  233,  313,  625,   55,   55,   55,   55,
+ /*    80 */    54,   54,   53,   53,   53,   52,  233,  594,  418,  171,
+ /*    90 */   650,  649,  383,  380,  379,  625,  593,  541,  428,  425,
+ /*   100 */   606,  605,  223,  378,  618,  612,  184,  144,  278,  386,
+ /*   110 */   273,  385,  173,  431,  655,  335,  650,  649,  108,  271,
+ /*   120 */   235,  628,   17,   58,   59,   49,  610,  609,  611,  611,
+ /*   130 */    56,   56,   57,   57,   57,   57,  517,   55,   55,   55,
+ /*   140 */    55,   54,   54,   53,   53,   53,   52,  233,  313,  543,
+ /*   150 */   232,  231,  505,  526,   34,  277,  650,  649,  647,  646,
+ /*   160 */   506,  454,  602,  625,  401,  428,  276,  428,  456,  511,
+ /*   170 */   455,   54,   54,   53,   53,   53,   52,  233,  618,  612,
+ /*   180 */   431,  355,  431,   60,  647,  646,  564,  564,  628,   93,
+ /*   190 */   628,   93,  518,  603,  603,  289,  648,   58,   59,   49,
+ /*   200 */   610,  609,  611,  611,   56,   56,   57,   57,   57,   57,
+ /*   210 */   648,   55,   55,   55,   55,   54,   54,   53,   53,   53,
+ /*   220 */    52,  233,  313,  428,  647,  646,  171,  554,  226,  383,
+ /*   230 */   380,  379,  410,  390,  410,  428,  457,  394,  431,  411,
+ /*   240 */   378,  487,  537,   51,   48,  152,  628,   75,  524,  215,
+ /*   250 */   431,  648,  618,  612,  570,   22,  458,  341,  628,   93,
+ /*   260 */   232,  231,  650,  649,   51,   48,  152,  438,  976,  196,
+ /*   270 */   976,   58,   59,   49,  610,  609,  611,  611,   56,   56,
+ /*   280 */    57,   57,   57,   57,  451,   55,   55,   55,   55,   54,
+ /*   290 */    54,   53,   53,   53,   52,  233,  313,  421,  484,  498,
+ /*   300 */   457,   23,  410,  603,  603,   52,  233,  389,  435,  653,
+ /*   310 */   428,  540,  442,  428,  236,  648,  126,  342,  602,  631,
+ /*   320 */   458,  459,  466,  371,  633,  431,  618,  612,  431,  414,
+ /*   330 */   647,  646,  516,  628,   93,  196,  628,   91,  307,  440,
+ /*   340 */   333,  606,  605,  208,  485,   58,   59,   49,  610,  609,
+ /*   350 */   611,  611,   56,   56,   57,   57,   57,   57,  107,   55,
+ /*   360 */    55,   55,   55,   54,   54,   53,   53,   53,   52,  233,
+ /*   370 */   313,  212,  549,  512,  268,  392,  600,  399,   51,   48,
+ /*   380 */   152,  460,  602,  439,  634,   69,  299,  428,  648,  290,
+ /*   390 */   431,  428,  255,  352,  254,  132,  345,  395,  628,   10,
+ /*   400 */   618,  612,  431,  602,  572,  396,  431,  222,  336,  503,
+ /*   410 */   628,   93,   67,  475,  628,   79,  625,  485,  597,   58,
+ /*   420 */    59,   49,  610,  609,  611,  611,   56,   56,   57,   57,
+ /*   430 */    57,   57,  501,   55,   55,   55,   55,   54,   54,   53,
+ /*   440 */    53,   53,   52,  233,  313,  108,  542,  512,  527,  196,
+ /*   450 */   623,   51,   48,  152,  331,  300,    6,  375,  502,   69,
+ /*   460 */   538,  537,  648,  650,  649,  255,  347,  244,  196,  650,
+ /*   470 */   649,  306,  354,   68,  618,  612,  235,  650,  649,  485,
+ /*   480 */   249,  621,  621,  621,  625,    1,  573,   63,  172,  650,
+ /*   490 */   649,  464,  351,   58,   59,   49,  610,  609,  611,  611,
+ /*   500 */    56,   56,   57,   57,   57,   57,  291,   55,   55,   55,
+ /*   510 */    55,   54,   54,   53,   53,   53,   52,  233,  313,  564,
+ /*   520 */   585,  648,  544,  234,  246,  552,  248,  255,  352,  254,
+ /*   530 */    20,  647,  646,   51,   48,  152,  584,  647,  646,  650,
+ /*   540 */   649,  594,  318,  322,  512,  647,  646,  271,  618,  612,
+ /*   550 */   593,  260,  583,  162,  536,  446,   69,  647,  646,  648,
+ /*   560 */   280,  438,  977,  567,  977,  535,  648,   58,   59,   49,
+ /*   570 */   610,  609,  611,  611,   56,   56,   57,   57,   57,   57,
+ /*   580 */   108,   55,   55,   55,   55,   54,   54,   53,   53,   53,
+ /*   590 */    52,  233,  313,  346,  243,  428,  548,  428,  245,  360,
+ /*   600 */   312,  428,  247,  599,  513,  211,  442,  647,  646,  569,
+ /*   610 */   431,  428,  431,  428,  648,  428,  431,  252,  628,   80,
+ /*   620 */   628,   19,  618,  612,  628,   71,  431,    4,  431,  176,
+ /*   630 */   431,  625,  393,  568,  628,   81,  628,   82,  628,   83,
+ /*   640 */   479,   58,   59,   49,  610,  609,  611,  611,   56,   56,
+ /*   650 */    57,   57,   57,   57,   31,   55,   55,   55,   55,   54,
+ /*   660 */    54,   53,   53,   53,   52,  233,  313,  428,  196,  428,
+ /*   670 */   490,  428,  599,  424,  211,  357,  190,  180,  569,  151,
+ /*   680 */   206,  149,  431,  428,  431,  428,  431,  428,  560,  218,
+ /*   690 */   628,   72,  628,   73,  628,   84,  618,  612,  431,  625,
+ /*   700 */   431,  317,  431,  648,  569,  564,  628,   85,  628,   86,
+ /*   710 */   628,   18,  350,  307,  441,   58,   59,   49,  610,  609,
+ /*   720 */   611,  611,   56,   56,   57,   57,   57,   57,  428,   55,
+ /*   730 */    55,   55,   55,   54,   54,   53,   53,   53,   52,  233,
+ /*   740 */   313,  428,  381,  431,  428,  260,  282,  427,  151,  488,
+ /*   750 */   515,  628,   87,  321,  428,  529,  431,  428,  439,  431,
+ /*   760 */   648,  428,  648,  526,  628,  100,  358,  628,   88,  431,
+ /*   770 */   618,  612,  431,  569,  359,  365,  431,  628,   76,  362,
+ /*   780 */   628,   89,  266,   36,  628,   90,  585,  903,  216,   58,
+ /*   790 */    59,   49,  610,  609,  611,  611,   56,   56,   57,   57,
+ /*   800 */    57,   57,  584,   55,   55,   55,   55,   54,   54,   53,
+ /*   810 */    53,   53,   52,  233,  313,  428,  202,  428,  583,  138,
+ /*   820 */   415,  521,  657,  655,  335,  629,  262,  428,  264,  630,
+ /*   830 */   431,  428,  431,  355,  360,  275,  384,  428,  628,   77,
+ /*   840 */   628,   92,  431,  520,  618,  612,  431,  286,  648,  648,
+ /*   850 */   628,  141,  431,  417,  628,  142,  391,   39,  550,  550,
+ /*   860 */   628,  143,  564,   58,   59,   49,  610,  609,  611,  611,
+ /*   870 */    56,   56,   57,   57,   57,   57,  428,   55,   55,   55,
+ /*   880 */    55,   54,   54,   53,   53,   53,   52,  233,  313,  428,
+ /*   890 */   590,  431,  428,  325,  360,  287,  291,  443,  308,  628,
+ /*   900 */    97,  219,  428,  284,  431,  428,  592,  431,  428,  648,
+ /*   910 */   648,  648,  628,   78,  591,  628,  101,  431,  618,  612,
+ /*   920 */   431,  260,  601,  431,  253,  628,   94,  604,  628,  102,
+ /*   930 */   624,  628,  103,  323,  656,    2,  648,   58,   59,   49,
+ /*   940 */   610,  609,  611,  611,   56,   56,   57,   57,   57,   57,
+ /*   950 */   428,   55,   55,   55,   55,   54,   54,   53,   53,   53,
+ /*   960 */    52,  233,  313,  428,  217,  431,  428,  494,  494,  368,
+ /*   970 */   291,  212,  549,  628,   99,  298,  428,  343,  431,  428,
+ /*   980 */   560,  431,  428,  398,  220,  648,  628,  140,  571,  628,
+ /*   990 */   139,  431,  618,  612,  431,  648,  199,  431,  648,  628,
+ /*  1000 */   109,  636,  628,  106,  422,  628,  104,  324,  418,  445,
+ /*  1010 */   463,   58,   47,   49,  610,  609,  611,  611,   56,   56,
+ /*  1020 */    57,   57,   57,   57,  428,   55,   55,   55,   55,   54,
+ /*  1030 */    54,   53,   53,   53,   52,  233,  313,  428,  108,  431,
+ /*  1040 */   196,  428,  176,  289,  625,  367,  196,  628,  105,  645,
+ /*  1050 */   644,  643,  431,  428,  560,  428,  431,  428,  648,  178,
+ /*  1060 */   628,   96,  617,  616,  628,   98,  618,  612,  431,  648,
+ /*  1070 */   431,  344,  431,  205,  204,  203,  628,   95,  628,   70,
+ /*  1080 */   628,   74,  198,  260,  614,  613,   59,   49,  610,  609,
+ /*  1090 */   611,  611,   56,   56,   57,   57,   57,   57,  648,   55,
+ /*  1100 */    55,   55,   55,   54,   54,   53,   53,   53,   52,  233,
+ /*  1110 */   313,  615,  625,  260,  260,  260,  260,  260,  291,  328,
+ /*  1120 */   492,  150,  388,  745,  493,  504,  467,  288,  648,  648,
+ /*  1130 */   648,  648,  648,  648,  447,  291,  648,   64,  366,  238,
+ /*  1140 */   618,  612,  648,  319,  153,  408,   40,  629,   38,  648,
+ /*  1150 */   648,  630,  172,  475,  648,  221,  339,  468,  469,  471,
+ /*  1160 */   259,   49,  610,  609,  611,  611,   56,   56,   57,   57,
+ /*  1170 */    57,   57,  332,   55,   55,   55,   55,   54,   54,   53,
+ /*  1180 */    53,   53,   52,  233,   45,  423,  477,    3,  405,  196,
+ /*  1190 */   450,  432,  649,  409,  239,  242,  448,   45,  423,  461,
+ /*  1200 */     3,   16,  426,  194,  432,  649,  318,  337,  449,  648,
+ /*  1210 */   648,  214,  354,  473,  648,  426,  305,  304,  303,  186,
+ /*  1220 */   301,  416,  196,  472,  196,  250,  356,  481,  648,  196,
+ /*  1230 */   482,  597,  361,   21,  416,  363,   24,  156,  256,  241,
+ /*  1240 */   648,  648,  648,  320,  597,  648,  119,  353,  240,  329,
+ /*  1250 */   648,   42,   43,  648,  330,  210,  149,  146,   44,  430,
+ /*  1260 */   429,  158,  261,  623,   42,   43,  134,  159,  603,  603,
+ /*  1270 */   157,   44,  430,  429,  263,   31,  623,  648,  265,  112,
+ /*  1280 */   444,  986,  309,   45,  423,  267,    3,  510,  525,  648,
+ /*  1290 */   432,  649,  465,  648,  621,  621,  621,  620,  619,   13,
+ /*  1300 */   648,  426,  648,  648,  272,  348,  559,  621,  621,  621,
+ /*  1310 */   620,  619,   13,    7,  522,  523,  349,  314,  114,  648,
+ /*  1320 */   416,  648,  115,  108,  561,  565,  575,  413,  292,  293,
+ /*  1330 */   597,  622,  632,  637,  116,  478,  108,  175,  638,  648,
+ /*  1340 */   648,  648,  648,  648,  648,  340,  648,  648,  648,  117,
+ /*  1350 */    42,   43,  553,  648,  639,  532,  531,   44,  430,  429,
+ /*  1360 */   374,  651,  623,  489,  108,  180,  376,    8,  180,  648,
+ /*  1370 */    45,  423,  269,    3,  180,  161,  648,  432,  649,  562,
+ /*  1380 */   579,  175,  412,  581,  135,  412,  476,  364,  426,  428,
+ /*  1390 */   589,  122,   41,  621,  621,  621,  620,  619,   13,  403,
+ /*  1400 */   595,  608,  180,   66,  431,  486,  166,  416,   27,  496,
+ /*  1410 */   370,  497,  628,   93,  209,  167,  168,  597,  372,  500,
+ /*  1420 */   169,  133,  310,  258,  387,  514,  507,  508,  509,  326,
+ /*  1430 */   529,  528,  533,  311,  397,  196,  555,   42,   43,   30,
+ /*  1440 */   530,  274,  534,  539,   44,  430,  429,  279,  177,  623,
+ /*  1450 */   556,  557,  281,  283,  574,  407,  410,   35,  423,  563,
+ /*  1460 */     3,  404,  229,   37,  432,  649,  420,  402,  295,   61,
+ /*  1470 */   285,   32,  227,  400,  626,  426,  294,  228,  230,  297,
+ /*  1480 */   621,  621,  621,  620,  619,   13,  296,    9,  627,  433,
+ /*  1490 */   640,  185,  187,  188,  416,  127,  224,  434,  641,  642,
+ /*  1500 */   652,  145,  437,  334,  597,  197,  237,  155,  338,   65,
+ /*  1510 */   412,  452,  453,  462,  110,  111,  113,   62,  154,  470,
+ /*  1520 */   251,  650,  649,  160,   42,   43,  315,  474,  118,   25,
+ /*  1530 */   316,   44,  430,  429,   26,  120,  623,  121,  480,  164,
+ /*  1540 */   163,  483,   15,  123,  165,  175,  369,  491,  257,  124,
+ /*  1550 */   495,  125,  193,  499,  373,  276,  170,  270,  128,  377,
+ /*  1560 */    28,  597,  382,  129,  130,   29,  225,  621,  621,  621,
+ /*  1570 */   620,  619,   13,  519,  545,  546,  547,  551,  147,  148,
+ /*  1580 */   189,  201,  200,  191,  174,  558,  131,  566,   41,  542,
+ /*  1590 */    11,   33,  576,  623,   12,  577,  578,  136,  406,  588,
+ /*  1600 */   580,  181,  582,    5,  419,  586,  654,  587,  179,  207,
+ /*  1610 */   995,   14,  137,  302,  596,  195,  995,  598,  607,   46,
+ /*  1620 */   182,  213,  995,  635,  621,  621,  621,  183,  995,  995,
+ /*  1630 */   700,  699,  995,  698,
 };
 static const YYCODETYPE yy_lookahead[] = {
- /*     0 */    19,  142,  143,  144,  145,   24,    1,   26,   77,   78,
+ /*     0 */    19,  161,  162,  163,  164,   24,  116,   26,   77,   78,
  /*    10 */    79,   80,   81,   82,   83,   84,   85,   86,   87,   88,
- /*    20 */    89,   90,   91,   92,   26,   27,   15,   26,   27,  197,
- /*    30 */    49,   50,   77,   78,   79,   80,  204,   82,   83,   84,
- /*    40 */    85,   86,   87,   88,   89,   90,   91,   92,   23,   68,
+ /*    20 */    89,   90,   91,   92,   88,   89,   90,   91,   92,   19,
+ /*    30 */    49,   50,   77,   78,   79,   80,   92,   82,   83,   84,
+ /*    40 */    85,   86,   87,   88,   89,   90,   91,   92,  158,   68,
  /*    50 */    69,   70,   71,   72,   73,   74,   75,   76,   77,   78,
- /*    60 */    79,   80,  166,   82,   83,   84,   85,   86,   87,   88,
- /*    70 */    89,   90,   91,   92,   19,   94,   19,  105,  106,  107,
- /*    80 */    25,   82,   83,   84,   85,   86,   87,   88,   89,   90,
- /*    90 */    91,   92,   94,   95,   96,   94,   95,   99,  100,  101,
- /*   100 */   112,  205,  114,  115,   49,   50,   22,   23,  110,   54,
- /*   110 */    86,   87,   88,   89,   90,   91,   92,  221,  222,  223,
- /*   120 */    23,  120,   25,   68,   69,   70,   71,   72,   73,   74,
- /*   130 */    75,   76,   77,   78,   79,   80,   22,   82,   83,   84,
- /*   140 */    85,   86,   87,   88,   89,   90,   91,   92,   19,   92,
- /*   150 */    23,   67,   25,   96,   97,   98,   99,  100,  101,  102,
- /*   160 */   150,   32,  150,  118,   26,   27,  109,  150,  150,  150,
- /*   170 */    41,  161,  162,  180,  181,  165,  113,  165,   49,   50,
- /*   180 */   117,  188,  165,  165,  165,  173,  174,  170,  171,  170,
- /*   190 */   171,  173,  174,  118,  184,   16,  186,   68,   69,   70,
+ /*    60 */    79,   80,  158,   82,   83,   84,   85,   86,   87,   88,
+ /*    70 */    89,   90,   91,   92,   19,   94,   82,   83,   84,   85,
+ /*    80 */    86,   87,   88,   89,   90,   91,   92,   32,  128,   96,
+ /*    90 */    26,   27,   99,  100,  101,   26,   41,  192,  169,  188,
+ /*   100 */   189,  190,   92,  110,   49,   50,   96,   97,   98,   99,
+ /*   110 */   100,  101,  102,  184,    1,    2,   26,   27,  158,  109,
+ /*   120 */   116,  192,  193,   68,   69,   70,   71,   72,   73,   74,
+ /*   130 */    75,   76,   77,   78,   79,   80,  201,   82,   83,   84,
+ /*   140 */    85,   86,   87,   88,   89,   90,   91,   92,   19,  192,
+ /*   150 */    86,   87,  199,  200,   25,   98,   26,   27,   94,   95,
+ /*   160 */   207,   97,  251,   94,  235,  169,  109,  169,  104,  205,
+ /*   170 */   106,   86,   87,   88,   89,   90,   91,   92,   49,   50,
+ /*   180 */   184,  169,  184,   54,   94,   95,  185,  185,  192,  193,
+ /*   190 */   192,  193,  201,  129,  130,  169,  184,   68,   69,   70,
  /*   200 */    71,   72,   73,   74,   75,   76,   77,   78,   79,   80,
- /*   210 */   118,   82,   83,   84,   85,   86,   87,   88,   89,   90,
- /*   220 */    91,   92,   19,   98,   86,   87,   22,   24,  160,   88,
- /*   230 */    26,   27,   94,   95,  109,   97,  224,   66,  118,   60,
- /*   240 */   150,   62,  104,   23,  106,   25,  229,  230,  229,  230,
- /*   250 */   160,  150,   49,   50,  113,  165,   96,   26,  117,   99,
- /*   260 */   100,  101,  194,  173,  174,   94,  165,  129,  130,   98,
- /*   270 */   110,   68,   69,   70,   71,   72,   73,   74,   75,   76,
- /*   280 */    77,   78,   79,   80,  194,   82,   83,   84,   85,   86,
- /*   290 */    87,   88,   89,   90,   91,   92,   19,   11,   94,   95,
- /*   300 */   129,  130,  131,  118,  150,  215,  150,  150,  150,   25,
- /*   310 */   220,   26,   27,   22,  213,   26,   27,   26,   27,  165,
- /*   320 */    25,  165,  165,  165,   30,   94,   49,   50,   34,  173,
- /*   330 */   174,  173,  174,   88,   89,   90,   91,   92,    7,    8,
- /*   340 */   160,  187,   48,   57,  187,   68,   69,   70,   71,   72,
- /*   350 */    73,   74,   75,   76,   77,   78,   79,   80,   23,   82,
+ /*   210 */   184,   82,   83,   84,   85,   86,   87,   88,   89,   90,
+ /*   220 */    91,   92,   19,  169,   94,   95,   96,  226,  226,   99,
+ /*   230 */   100,  101,  236,  179,  236,  169,  169,  241,  184,  241,
+ /*   240 */   110,  209,  210,  242,  243,  244,  192,  193,  201,  237,
+ /*   250 */   184,  184,   49,   50,  229,   52,  189,  190,  192,  193,
+ /*   260 */    86,   87,   26,   27,  242,  243,  244,   22,   23,  215,
+ /*   270 */    25,   68,   69,   70,   71,   72,   73,   74,   75,   76,
+ /*   280 */    77,   78,   79,   80,  262,   82,   83,   84,   85,   86,
+ /*   290 */    87,   88,   89,   90,   91,   92,   19,  271,   11,  179,
+ /*   300 */   169,   24,  236,  129,  130,   91,   92,  241,  165,  166,
+ /*   310 */   169,  184,   67,  169,  171,  184,  173,  250,  251,  192,
+ /*   320 */   189,  190,  179,  255,  191,  184,   49,   50,  184,  210,
+ /*   330 */    94,   95,  199,  192,  193,  215,  192,  193,   22,   23,
+ /*   340 */   188,  189,  190,  254,   57,   68,   69,   70,   71,   72,
+ /*   350 */    73,   74,   75,   76,   77,   78,   79,   80,  215,   82,
  /*   360 */    83,   84,   85,   86,   87,   88,   89,   90,   91,   92,
- /*   370 */    19,  215,  150,  215,  194,   19,  220,   88,  220,   94,
- /*   380 */    95,   23,  160,   94,   95,   94,   95,  165,   26,   27,
- /*   390 */    95,  105,  106,  107,  113,  173,  174,  217,   22,  150,
- /*   400 */    49,   50,  116,  119,   57,  120,   50,  158,   22,   21,
- /*   410 */   161,  162,  232,  136,  165,  120,  194,  237,   23,   68,
+ /*   370 */    19,  213,  214,  169,   23,  169,  185,  236,  242,  243,
+ /*   380 */   244,  250,  251,   67,  180,  181,  216,  169,  184,  245,
+ /*   390 */   184,  169,  105,  106,  107,  159,  238,   19,  192,  193,
+ /*   400 */    49,   50,  184,  251,  195,   27,  184,  203,  265,  205,
+ /*   410 */   192,  193,   22,  179,  192,  193,   26,   57,   66,   68,
  /*   420 */    69,   70,   71,   72,   73,   74,   75,   76,   77,   78,
- /*   430 */    79,   80,   22,   82,   83,   84,   85,   86,   87,   88,
- /*   440 */    89,   90,   91,   92,   19,   23,   12,  112,   23,  114,
- /*   450 */   115,   63,  105,  106,  107,   23,   94,   95,   97,   98,
- /*   460 */   104,  150,   28,  116,   25,  109,  150,  150,   23,   23,
- /*   470 */   112,   25,  114,  115,   49,   50,  165,  150,   44,   11,
- /*   480 */    46,  165,  165,   16,  173,  174,   76,  136,  100,  173,
- /*   490 */   174,   57,  165,   68,   69,   70,   71,   72,   73,   74,
- /*   500 */    75,   76,   77,   78,   79,   80,  166,   82,   83,   84,
- /*   510 */    85,   86,   87,   88,   89,   90,   91,   92,   19,  169,
- /*   520 */   170,  171,   23,   12,   23,  214,  138,   60,  150,   62,
- /*   530 */    24,  215,   26,  216,  112,  150,  114,  115,   36,   28,
- /*   540 */   213,   95,  103,  165,  112,  205,  114,  115,   49,   50,
- /*   550 */   165,  173,  174,   51,   23,   44,   25,   46,  173,  174,
- /*   560 */    58,   22,   23,   22,   25,  160,  120,   68,   69,   70,
+ /*   430 */    79,   80,  179,   82,   83,   84,   85,   86,   87,   88,
+ /*   440 */    89,   90,   91,   92,   19,  158,   94,  169,   23,  215,
+ /*   450 */    98,  242,  243,  244,  236,  177,  217,   19,  180,  181,
+ /*   460 */   209,  210,  184,   26,   27,  105,  106,  107,  215,   26,
+ /*   470 */    27,  167,  238,   22,   49,   50,  116,   26,   27,   57,
+ /*   480 */    16,  129,  130,  131,   94,   22,  195,  253,   50,   26,
+ /*   490 */    27,  220,  258,   68,   69,   70,   71,   72,   73,   74,
+ /*   500 */    75,   76,   77,   78,   79,   80,  169,   82,   83,   84,
+ /*   510 */    85,   86,   87,   88,   89,   90,   91,   92,   19,  185,
+ /*   520 */    12,  184,   23,  218,   60,   88,   62,  105,  106,  107,
+ /*   530 */   225,   94,   95,  242,  243,  244,   28,   94,   95,   26,
+ /*   540 */    27,   32,  104,  206,  169,   94,   95,  109,   49,   50,
+ /*   550 */    41,  169,   44,   25,   46,  180,  181,   94,   95,  184,
+ /*   560 */   226,   22,   23,  120,   25,   57,  184,   68,   69,   70,
  /*   570 */    71,   72,   73,   74,   75,   76,   77,   78,   79,   80,
- /*   580 */   230,   82,   83,   84,   85,   86,   87,   88,   89,   90,
- /*   590 */    91,   92,   19,  215,   22,   23,   23,   25,  163,  194,
- /*   600 */    94,  166,  167,  168,   25,  138,   67,    7,    8,    9,
- /*   610 */   108,  206,  207,  169,  170,  171,  150,   22,  221,  222,
- /*   620 */   223,   26,   49,   50,   86,   87,   23,  161,  162,   23,
- /*   630 */    22,  165,   24,  120,   22,   23,   25,  160,  241,   67,
- /*   640 */   176,   68,   69,   70,   71,   72,   73,   74,   75,   76,
- /*   650 */    77,   78,   79,   80,  160,   82,   83,   84,   85,   86,
- /*   660 */    87,   88,   89,   90,   91,   92,   19,  129,  130,  150,
- /*   670 */    23,  194,   35,   23,  230,   25,  150,  155,  150,   67,
- /*   680 */   150,  105,  106,  107,  165,  221,  222,  223,  194,   94,
- /*   690 */    23,  165,   25,  165,  217,  165,   49,   50,   25,  173,
- /*   700 */   174,  173,  174,  173,  174,    0,    1,    2,  118,  221,
- /*   710 */   222,  223,  193,  219,  237,   68,   69,   70,   71,   72,
- /*   720 */    73,   74,   75,   76,   77,   78,   79,   80,  150,   82,
+ /*   580 */   158,   82,   83,   84,   85,   86,   87,   88,   89,   90,
+ /*   590 */    91,   92,   19,  230,  212,  169,   23,  169,  230,  169,
+ /*   600 */   182,  169,  138,  185,  186,  187,   67,   94,   95,   25,
+ /*   610 */   184,  169,  184,  169,  184,  169,  184,  260,  192,  193,
+ /*   620 */   192,  193,   49,   50,  192,  193,  184,   22,  184,   24,
+ /*   630 */   184,   26,  179,  120,  192,  193,  192,  193,  192,  193,
+ /*   640 */   220,   68,   69,   70,   71,   72,   73,   74,   75,   76,
+ /*   650 */    77,   78,   79,   80,  126,   82,   83,   84,   85,   86,
+ /*   660 */    87,   88,   89,   90,   91,   92,   19,  169,  215,  169,
+ /*   670 */    21,  169,  185,  186,  187,  260,   23,   25,   25,   95,
+ /*   680 */   227,  228,  184,  169,  184,  169,  184,  169,  169,  259,
+ /*   690 */   192,  193,  192,  193,  192,  193,   49,   50,  184,   94,
+ /*   700 */   184,  174,  184,  184,  120,  185,  192,  193,  192,  193,
+ /*   710 */   192,  193,   63,   22,   23,   68,   69,   70,   71,   72,
+ /*   720 */    73,   74,   75,   76,   77,   78,   79,   80,  169,   82,
  /*   730 */    83,   84,   85,   86,   87,   88,   89,   90,   91,   92,
- /*   740 */    19,  150,   19,  165,  150,   24,  166,  167,  168,  227,
- /*   750 */    27,  173,  174,  231,  150,   25,  165,  150,  172,  165,
- /*   760 */   150,  242,  129,  130,  173,  174,  180,  173,  174,  165,
- /*   770 */    49,   50,  165,  150,  176,  165,   35,  173,  174,  165,
- /*   780 */   173,  174,   35,   23,   23,   25,   25,  173,  165,   68,
+ /*   740 */    19,  169,  196,  184,  169,  169,  226,  169,   95,  100,
+ /*   750 */   185,  192,  193,  234,  169,  103,  184,  169,   67,  184,
+ /*   760 */   184,  169,  184,  200,  192,  193,   19,  192,  193,  184,
+ /*   770 */    49,   50,  184,  120,   27,  248,  184,  192,  193,  252,
+ /*   780 */   192,  193,   16,  136,  192,  193,   12,  138,  212,   68,
  /*   790 */    69,   70,   71,   72,   73,   74,   75,   76,   77,   78,
- /*   800 */    79,   80,  150,   82,   83,   84,   85,   86,   87,   88,
- /*   810 */    89,   90,   91,   92,   19,  150,  193,  165,  150,  221,
- /*   820 */   222,  223,  150,  213,   19,  173,  174,   23,  150,   97,
- /*   830 */   165,  150,   27,  165,  150,  150,  150,  165,  173,  174,
- /*   840 */    22,  173,  174,  165,   49,   50,  165,   52,  116,  165,
- /*   850 */   165,  165,  206,  207,  173,  174,  126,   50,  173,  174,
- /*   860 */   128,   27,  160,   68,   69,   70,   71,   72,   73,   74,
- /*   870 */    75,   76,   77,   78,   79,   80,  150,   82,   83,   84,
- /*   880 */    85,   86,   87,   88,   89,   90,   91,   92,   19,  150,
- /*   890 */    23,  165,  150,   23,  216,   25,  194,   32,   39,  173,
- /*   900 */   174,  135,  150,  137,  165,  150,   41,  165,  150,   52,
- /*   910 */   238,  104,  173,  174,   29,  173,  174,  165,   49,   50,
- /*   920 */   165,  219,  238,  165,  238,  173,  174,   52,  173,  174,
- /*   930 */    22,  173,  174,   23,   23,  160,   25,   68,   69,   70,
+ /*   800 */    79,   80,   28,   82,   83,   84,   85,   86,   87,   88,
+ /*   810 */    89,   90,   91,   92,   19,  169,  204,  169,   44,   24,
+ /*   820 */    46,   36,    0,    1,    2,  113,   60,  169,   62,  117,
+ /*   830 */   184,  169,  184,  169,  169,  195,   51,  169,  192,  193,
+ /*   840 */   192,  193,  184,   58,   49,   50,  184,  230,  184,  184,
+ /*   850 */   192,  193,  184,  247,  192,  193,  112,  136,  114,  115,
+ /*   860 */   192,  193,  185,   68,   69,   70,   71,   72,   73,   74,
+ /*   870 */    75,   76,   77,   78,   79,   80,  169,   82,   83,   84,
+ /*   880 */    85,   86,   87,   88,   89,   90,   91,   92,   19,  169,
+ /*   890 */   195,  184,  169,  108,  169,  169,  169,  268,  269,  192,
+ /*   900 */   193,  237,  169,  226,  184,  169,  195,  184,  169,  184,
+ /*   910 */   184,  184,  192,  193,  185,  192,  193,  184,   49,   50,
+ /*   920 */   184,  169,  251,  184,  259,  192,  193,  251,  192,  193,
+ /*   930 */   185,  192,  193,  206,  163,  164,  184,   68,   69,   70,
  /*   940 */    71,   72,   73,   74,   75,   76,   77,   78,   79,   80,
- /*   950 */   150,   82,   83,   84,   85,   86,   87,   88,   89,   90,
- /*   960 */    91,   92,   19,  150,  150,  165,  150,  245,  246,  194,
- /*   970 */   150,  144,  145,  173,  174,  160,  150,   22,  165,  165,
- /*   980 */    22,  165,  150,  150,  116,  165,  173,  174,   52,  173,
- /*   990 */   174,  165,   49,   50,   22,  150,  128,  165,  165,  173,
- /*  1000 */   174,  187,  166,  166,   22,  173,  174,  187,  109,  194,
- /*  1010 */   165,   68,   69,   70,   71,   72,   73,   74,   75,   76,
- /*  1020 */    77,   78,   79,   80,  150,   82,   83,   84,   85,   86,
- /*  1030 */    87,   88,   89,   90,   91,   92,   19,  150,  193,  165,
- /*  1040 */   102,  205,  205,  150,  150,  247,  248,  173,  174,   19,
- /*  1050 */   150,   20,  165,  150,  150,  150,  150,  150,  165,  165,
- /*  1060 */   173,  174,   49,   50,  104,  165,   49,   50,  165,  165,
- /*  1070 */   165,  165,  165,  173,  174,   43,  173,  174,  173,  174,
- /*  1080 */   187,   24,  190,  191,   71,   72,   69,   70,   71,   72,
- /*  1090 */    73,   74,   75,   76,   77,   78,   79,   80,  150,   82,
+ /*   950 */   169,   82,   83,   84,   85,   86,   87,   88,   89,   90,
+ /*   960 */    91,   92,   19,  169,  212,  184,  169,  105,  106,  107,
+ /*   970 */   169,  213,  214,  192,  193,  219,  169,   97,  184,  169,
+ /*   980 */   169,  184,  169,  169,  259,  184,  192,  193,   25,  192,
+ /*   990 */   193,  184,   49,   50,  184,  184,  217,  184,  184,  192,
+ /*  1000 */   193,  172,  192,  193,  179,  192,  193,  206,  128,   38,
+ /*  1010 */   179,   68,   69,   70,   71,   72,   73,   74,   75,   76,
+ /*  1020 */    77,   78,   79,   80,  169,   82,   83,   84,   85,   86,
+ /*  1030 */    87,   88,   89,   90,   91,   92,   19,  169,  158,  184,
+ /*  1040 */   215,  169,   24,  169,   26,  234,  215,  192,  193,    7,
+ /*  1050 */     8,    9,  184,  169,  169,  169,  184,  169,  184,  118,
+ /*  1060 */   192,  193,   49,   50,  192,  193,   49,   50,  184,  184,
+ /*  1070 */   184,  240,  184,  105,  106,  107,  192,  193,  192,  193,
+ /*  1080 */   192,  193,  119,  169,   71,   72,   69,   70,   71,   72,
+ /*  1090 */    73,   74,   75,   76,   77,   78,   79,   80,  184,   82,
  /*  1100 */    83,   84,   85,   86,   87,   88,   89,   90,   91,   92,
- /*  1110 */    19,   98,  150,  165,  150,  150,  150,  150,  150,  150,
- /*  1120 */    59,  173,  174,   25,  150,  190,  191,  165,   53,  165,
- /*  1130 */   165,  165,  165,  165,  165,  173,  174,  173,  174,  165,
- /*  1140 */    49,   50,   91,   92,    1,    2,   53,  173,  174,  138,
- /*  1150 */   104,   22,    5,    1,   35,  118,  127,  150,  193,  193,
- /*  1160 */   193,   70,   71,   72,   73,   74,   75,   76,   77,   78,
- /*  1170 */    79,   80,  165,   82,   83,   84,   85,   86,   87,   88,
- /*  1180 */    89,   90,   91,   92,   19,   20,  150,   22,  150,   27,
- /*  1190 */   150,   26,   27,  108,  150,   22,   76,   76,  150,   25,
- /*  1200 */   193,  165,   37,  165,  150,  165,   22,   19,   20,  165,
- /*  1210 */    22,  173,  174,  165,   26,   27,   23,  150,  119,  165,
- /*  1220 */   150,   56,  150,  150,  150,   37,   16,  173,  174,  193,
- /*  1230 */   150,   66,  165,  193,    1,  165,  121,  165,  165,  165,
- /*  1240 */    20,  146,  147,  119,   56,  165,  150,  152,   16,  154,
- /*  1250 */   150,   86,   87,   88,   66,  160,  150,  150,   93,   94,
- /*  1260 */    95,  165,  150,   98,  108,  165,  127,   23,   65,  173,
- /*  1270 */   174,  165,  165,  150,   86,   87,  128,  165,  150,  173,
- /*  1280 */   174,   93,   94,   95,   23,  150,   98,   15,  165,  194,
- /*  1290 */   150,  140,   22,  165,  129,  130,  131,  132,  133,  134,
- /*  1300 */   165,  173,  174,    3,  116,  165,   19,   20,  150,   22,
- /*  1310 */     4,  150,  217,   26,   27,  179,  179,  129,  130,  131,
- /*  1320 */   132,  133,  134,  165,   37,  150,  165,  150,  164,   19,
- /*  1330 */    20,  150,   22,  246,  149,  249,   26,   27,  249,  244,
- /*  1340 */   165,  150,  165,   56,    6,  150,  165,   37,  173,  174,
- /*  1350 */   173,  174,  150,   66,  173,  174,  165,  149,  149,   13,
- /*  1360 */   165,   25,  150,  150,  150,  149,   56,  165,  150,  116,
- /*  1370 */   151,  150,  150,   86,   87,  150,   66,  165,  165,  165,
- /*  1380 */    93,   94,   95,  165,  150,   98,  165,  165,  151,   22,
- /*  1390 */   165,  194,  150,   26,   27,  150,   86,   87,  159,  165,
- /*  1400 */   199,  126,  123,   93,   94,   95,  200,  165,   98,  124,
- /*  1410 */   165,  122,  201,  125,  225,  135,  129,  130,  131,  132,
- /*  1420 */   133,  134,    5,  157,  157,  202,  118,   10,   11,   12,
- /*  1430 */    13,   14,  203,   66,   17,  104,  210,  121,  211,  129,
- /*  1440 */   130,  131,  132,  133,  134,  210,  175,  211,   31,  211,
- /*  1450 */    33,  210,  104,   86,   87,   47,  175,  183,  175,   42,
- /*  1460 */   103,   94,  178,  177,   22,   98,  175,   92,  228,  175,
- /*  1470 */   175,  228,   55,  183,   57,  178,  175,  156,   61,   18,
- /*  1480 */   157,   64,  156,  235,  157,  156,   45,  157,  236,  157,
- /*  1490 */   135,  156,  189,   68,  157,  218,  129,  130,  131,   22,
- /*  1500 */   189,  199,  157,  156,  192,   18,  192,  192,  199,  192,
- /*  1510 */   218,  189,   40,  157,   38,  157,  240,  157,  240,  153,
- /*  1520 */   196,  181,  105,  106,  107,  243,  198,  166,  111,  230,
- /*  1530 */   176,  226,  239,  116,  230,  176,  166,  166,  176,  148,
- /*  1540 */   199,  177,  209,  209,  166,  196,  239,  208,  185,  199,
- /*  1550 */    92,  209,  233,  173,  234,  182,  139,  173,  182,  191,
- /*  1560 */   195,  182,  250,  186,
+ /*  1110 */    19,   98,   94,  169,  169,  169,  169,  169,  169,  234,
+ /*  1120 */    30,  169,   88,   23,   34,   25,  212,  169,  184,  184,
+ /*  1130 */   184,  184,  184,  184,  169,  169,  184,  264,   48,  169,
+ /*  1140 */    49,   50,  184,  266,  267,  271,  135,  113,  137,  184,
+ /*  1150 */   184,  117,   50,  179,  184,  206,  212,  212,  212,  212,
+ /*  1160 */   212,   70,   71,   72,   73,   74,   75,   76,   77,   78,
+ /*  1170 */    79,   80,  206,   82,   83,   84,   85,   86,   87,   88,
+ /*  1180 */    89,   90,   91,   92,   19,   20,  179,   22,  179,  215,
+ /*  1190 */    40,   26,   27,  179,  169,  169,  176,   19,   20,  169,
+ /*  1200 */    22,   22,   37,   24,   26,   27,  104,  263,  176,  184,
+ /*  1210 */   184,    5,  238,  169,  184,   37,   10,   11,   12,   13,
+ /*  1220 */    14,   56,  215,   17,  215,  169,  169,  169,  184,  215,
+ /*  1230 */   169,   66,  258,  261,   56,  169,  261,   31,  169,   33,
+ /*  1240 */   184,  184,  184,  176,   66,  184,   22,  240,   42,  240,
+ /*  1250 */   184,   86,   87,  184,  240,  227,  228,   68,   93,   94,
+ /*  1260 */    95,   55,  169,   98,   86,   87,  239,   61,  129,  130,
+ /*  1270 */    64,   93,   94,   95,  169,  126,   98,  184,  169,  208,
+ /*  1280 */    23,  116,   25,   19,   20,  169,   22,  169,  169,  184,
+ /*  1290 */    26,   27,  220,  184,  129,  130,  131,  132,  133,  134,
+ /*  1300 */   184,   37,  184,  184,  169,   18,  169,  129,  130,  131,
+ /*  1310 */   132,  133,  134,   22,   97,   98,  176,  111,  211,  184,
+ /*  1320 */    56,  184,  211,  158,  169,  169,  169,  169,  169,  169,
+ /*  1330 */    66,  169,  169,  169,  211,   23,  158,   25,  169,  184,
+ /*  1340 */   184,  184,  184,  184,  184,  139,  184,  184,  184,  211,
+ /*  1350 */    86,   87,   88,  184,  169,    7,    8,   93,   94,   95,
+ /*  1360 */    18,  169,   98,   23,  158,   25,   23,   76,   25,  184,
+ /*  1370 */    19,   20,   23,   22,   25,  175,  184,   26,   27,   23,
+ /*  1380 */    23,   25,   25,   23,  239,   25,  220,  176,   37,  169,
+ /*  1390 */    23,  208,   25,  129,  130,  131,  132,  133,  134,  179,
+ /*  1400 */    23,   23,   25,   25,  184,  208,  175,   56,  135,  257,
+ /*  1410 */    45,  176,  192,  193,  176,  175,  175,   66,  176,  176,
+ /*  1420 */   175,   22,  197,  256,  104,  202,  194,  194,  194,   47,
+ /*  1430 */   103,  194,  202,  197,  121,  215,  232,   86,   87,  104,
+ /*  1440 */   196,  194,  194,  194,   93,   94,   95,  231,  176,   98,
+ /*  1450 */   232,  232,  231,  231,  176,  197,  236,   19,   20,  232,
+ /*  1460 */    22,  241,   92,  135,   26,   27,  197,  122,  222,  125,
+ /*  1470 */   231,  124,  246,  123,  224,   37,  223,  249,  249,  220,
+ /*  1480 */   129,  130,  131,  132,  133,  134,  221,   25,  214,  178,
+ /*  1490 */    13,  170,  170,    6,   56,  198,  198,  168,  168,  168,
+ /*  1500 */   168,  183,    4,    3,   66,   22,  140,   15,   65,   16,
+ /*  1510 */    25,   23,   23,  128,  127,  108,  119,   22,  267,   20,
+ /*  1520 */    16,   26,   27,  121,   86,   87,  270,    1,  119,   76,
+ /*  1530 */   270,   93,   94,   95,   76,  127,   98,  108,   27,  118,
+ /*  1540 */    35,    1,    5,   22,  104,   25,   43,   53,  138,   53,
+ /*  1550 */    59,  104,   24,   20,   19,  109,  102,   23,   22,   52,
+ /*  1560 */    22,   66,   52,   22,   22,   22,   52,  129,  130,  131,
+ /*  1570 */   132,  133,  134,   29,   23,   23,   23,  113,   39,  118,
+ /*  1580 */    23,   86,   87,   23,   35,   27,   22,  120,   25,   94,
+ /*  1590 */    35,   25,   23,   98,   35,   23,   23,   22,   24,   11,
+ /*  1600 */    23,   25,   23,   22,   24,   23,    1,   23,   25,   22,
+ /*  1610 */   272,   22,   22,   15,   23,   22,  272,   23,   23,   22,
+ /*  1620 */   118,   22,  272,   23,  129,  130,  131,  118,  272,  272,
+ /*  1630 */   118,  118,  272,  118,
 };
-#define YY_SHIFT_USE_DFLT (-70)
-#define YY_SHIFT_COUNT (416)
-#define YY_SHIFT_MIN   (-69)
-#define YY_SHIFT_MAX   (1487)
+#define YY_SHIFT_USE_DFLT (-111)
+#define YY_SHIFT_COUNT (435)
+#define YY_SHIFT_MIN   (-110)
+#define YY_SHIFT_MAX   (1605)
 static const short yy_shift_ofst[] = {
- /*     0 */  1143, 1188, 1417, 1188, 1287, 1287,  138,  138,   -2,  -19,
- /*    10 */  1287, 1287, 1287, 1287,  347,  362,  129,  129,  795, 1165,
- /*    20 */  1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287,
- /*    30 */  1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287,
- /*    40 */  1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1310, 1287,
- /*    50 */  1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287, 1287,
- /*    60 */  1287, 1287,  286,  362,  362,  538,  538,  231, 1253,   55,
- /*    70 */   721,  647,  573,  499,  425,  351,  277,  203,  869,  869,
+ /*     0 */   113, 1165, 1206, 1178, 1351, 1351, 1351,   64,   64,  130,
+ /*    10 */   -19, 1351, 1351, 1351, 1351,  422,   90,   55,   55,  203,
+ /*    20 */  1264, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351,
+ /*    30 */  1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351,
+ /*    40 */  1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1438,
+ /*    50 */  1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351, 1351,
+ /*    60 */  1351, 1351, 1351,  287,   90,   90,  174,  174, -110,   69,
+ /*    70 */   129,  277,  351,  425,  499,  573,  647,  721,  795,  869,
  /*    80 */   869,  869,  869,  869,  869,  869,  869,  869,  869,  869,
- /*    90 */   869,  869,  869,  943,  869, 1017, 1091, 1091,  -69,  -45,
- /*   100 */   -45,  -45,  -45,  -45,   -1,   24,  245,  362,  362,  362,
- /*   110 */   362,  362,  362,  362,  362,  362,  362,  362,  362,  362,
- /*   120 */   362,  362,  362,  388,  356,  362,  362,  362,  362,  362,
- /*   130 */   732,  868,  231, 1051, 1458,  -70,  -70,  -70, 1367,   57,
- /*   140 */   434,  434,  289,  291,  285,    1,  204,  572,  539,  362,
- /*   150 */   362,  362,  362,  362,  362,  362,  362,  362,  362,  362,
- /*   160 */   362,  362,  362,  362,  362,  362,  362,  362,  362,  362,
- /*   170 */   362,  362,  362,  362,  362,  362,  362,  362,  362,  362,
- /*   180 */   362,  506,  506,  506,  705, 1253, 1253, 1253,  -70,  -70,
- /*   190 */   -70,  171,  171,  160,  502,  502,  502,  446,  432,  511,
- /*   200 */   422,  358,  335,  -12,  -12,  -12,  -12,  576,  294,  -12,
- /*   210 */   -12,  295,  595,  141,  600,  730,  723,  723,  805,  730,
- /*   220 */   805,  439,  911,  231,  865,  231,  865,  807,  865,  723,
- /*   230 */   766,  633,  633,  231,  284,   63,  608, 1476, 1308, 1308,
- /*   240 */  1472, 1472, 1308, 1477, 1425, 1275, 1487, 1487, 1487, 1487,
- /*   250 */  1308, 1461, 1275, 1477, 1425, 1425, 1308, 1461, 1355, 1441,
- /*   260 */  1308, 1308, 1461, 1308, 1461, 1308, 1461, 1442, 1348, 1348,
- /*   270 */  1348, 1408, 1375, 1375, 1442, 1348, 1357, 1348, 1408, 1348,
- /*   280 */  1348, 1316, 1331, 1316, 1331, 1316, 1331, 1308, 1308, 1280,
- /*   290 */  1288, 1289, 1285, 1279, 1275, 1253, 1336, 1346, 1346, 1338,
- /*   300 */  1338, 1338, 1338,  -70,  -70,  -70,  -70,  -70,  -70, 1013,
- /*   310 */   467,  612,   84,  179,  -28,  870,  410,  761,  760,  667,
- /*   320 */   650,  531,  220,  361,  331,  125,  127,   97, 1306, 1300,
- /*   330 */  1270, 1151, 1272, 1203, 1232, 1261, 1244, 1148, 1174, 1139,
- /*   340 */  1156, 1124, 1220, 1115, 1210, 1233, 1099, 1193, 1184, 1174,
- /*   350 */  1173, 1029, 1121, 1120, 1085, 1162, 1119, 1037, 1152, 1147,
- /*   360 */  1129, 1046, 1011, 1093, 1098, 1075, 1061, 1032,  960, 1057,
- /*   370 */  1031, 1030,  899,  938,  982,  936,  972,  958,  910,  955,
- /*   380 */   875,  885,  908,  857,  859,  867,  804,  590,  834,  747,
- /*   390 */   818,  513,  611,  741,  673,  637,  611,  606,  603,  579,
- /*   400 */   501,  541,  468,  386,  445,  395,  376,  281,  185,  120,
- /*   410 */    92,   75,   45,  114,   25,   11,    5,
+ /*    90 */   869,  869,  869,  869,  943,  869, 1017, 1091, 1091,  -69,
+ /*   100 */   -45,  -45,  -45,  -45,  -45,   -6,   85,  360,  236,  -64,
+ /*   110 */    90,   90,   90,   90,   90,   90,   90,   90,   90,   90,
+ /*   120 */    90,   90,   90,   90,   90,   90,  649,  438,   90,   90,
+ /*   130 */    90,   90,   90,   90,  880,  -40,  -40,  -40,   69,  214,
+ /*   140 */   -56, -111, -111, -111, 1495,   10,  508,  508,  437,  451,
+ /*   150 */   443,  513,  463,  245,  539,   90,   90,   90,   90,   90,
+ /*   160 */    90,   90,   90,   90,   90,   90,   90,   90,   90,   90,
+ /*   170 */    90,   90,   90,   90,   90,   90,   90,  605,   90,   90,
+ /*   180 */    90,   90,   90,   90,   90,   90,   90,   90,   90, 1018,
+ /*   190 */  1018, 1018,  822,  -96,  -96,  -96,    4, -111, -111, -111,
+ /*   200 */   352,  352,   -7,  785,  785,  785,  653,  774,  862, 1090,
+ /*   210 */   584,  390,  744, 1034, 1042,  528,  378,  378,  747,  528,
+ /*   220 */   747,  652, 1100,   69, 1102,  509,  378, 1011,  509,   69,
+ /*   230 */   509, 1139, 1139,   69,  963,  712, 1179,  971,  941,  941,
+ /*   240 */  1150, 1150,  941, 1224, 1189, 1149, 1287, 1287, 1287, 1287,
+ /*   250 */   941, 1342, 1149, 1224, 1189, 1189,  941, 1342, 1273, 1365,
+ /*   260 */   941,  941, 1342,  941, 1342,  941, 1342, 1399, 1320, 1320,
+ /*   270 */  1320, 1382, 1399, 1320, 1327, 1320, 1382, 1320, 1320, 1313,
+ /*   280 */  1335, 1313, 1335, 1313, 1335, 1313, 1335,  941,  941, 1399,
+ /*   290 */  1328, 1370, 1370, 1399, 1344, 1345, 1347, 1350, 1149,    4,
+ /*   300 */  1462, 1477, 1477, 1487, 1487, 1487, 1487, -111, -111, -111,
+ /*   310 */  -111, -111, -111, 1013,  464,  316,  691,  766,  968, 1257,
+ /*   320 */  1291, 1312, 1340, 1343, 1349, 1217, 1348,   57, 1356, 1357,
+ /*   330 */  1360, 1367, 1377, 1378, 1498, 1500, 1483, 1366, 1492, 1443,
+ /*   340 */  1493, 1488, 1489, 1385, 1485, 1387, 1407, 1397, 1499, 1402,
+ /*   350 */  1504, 1526, 1409, 1485, 1408, 1453, 1458, 1429, 1511, 1505,
+ /*   360 */  1421, 1540, 1537, 1521, 1440, 1410, 1494, 1520, 1496, 1491,
+ /*   370 */  1503, 1447, 1528, 1533, 1535, 1446, 1454, 1536, 1507, 1538,
+ /*   380 */  1541, 1534, 1542, 1510, 1544, 1543, 1514, 1539, 1551, 1552,
+ /*   390 */  1553, 1464, 1461, 1557, 1560, 1558, 1549, 1564, 1467, 1563,
+ /*   400 */  1555, 1566, 1559, 1569, 1572, 1573, 1575, 1574, 1576, 1577,
+ /*   410 */  1563, 1579, 1581, 1582, 1583, 1584, 1587, 1588, 1589, 1590,
+ /*   420 */  1580, 1576, 1591, 1593, 1594, 1595, 1597, 1502, 1509, 1512,
+ /*   430 */  1513, 1515, 1599, 1600, 1598, 1605,
 };
-#define YY_REDUCE_USE_DFLT (-169)
-#define YY_REDUCE_COUNT (308)
-#define YY_REDUCE_MIN   (-168)
-#define YY_REDUCE_MAX   (1391)
+#define YY_REDUCE_USE_DFLT (-161)
+#define YY_REDUCE_COUNT (312)
+#define YY_REDUCE_MIN   (-160)
+#define YY_REDUCE_MAX   (1332)
 static const short yy_reduce_ofst[] = {
- /*     0 */  -141,   90, 1095,  222,  158,  156,   19,   17,   10, -104,
- /*    10 */   378,  316,  311,   12,  180,  249,  598,  464,  397, 1181,
- /*    20 */  1177, 1175, 1128, 1106, 1096, 1054, 1038,  974,  964,  962,
- /*    30 */   948,  905,  903,  900,  887,  874,  832,  826,  816,  813,
- /*    40 */   800,  758,  755,  752,  742,  739,  726,  685,  681,  668,
- /*    50 */   665,  652,  607,  604,  594,  591,  578,  530,  528,  526,
- /*    60 */   385,   18,  477,  466,  519,  444,  350,  435,  405,  488,
- /*    70 */   488,  488,  488,  488,  488,  488,  488,  488,  488,  488,
- /*    80 */   488,  488,  488,  488,  488,  488,  488,  488,  488,  488,
- /*    90 */   488,  488,  488,  488,  488,  488,  488,  488,  488,  488,
- /*   100 */   488,  488,  488,  488,  488,  488,  488, 1040,  678, 1036,
- /*   110 */  1007,  967,  966,  965,  845,  686,  610,  684,  317,  672,
- /*   120 */   893,  327,  623,  522,   -7,  820,  814,  157,  154,  101,
- /*   130 */   702,  494,  580,  488,  488,  488,  488,  488,  614,  586,
- /*   140 */   935,  892,  968, 1245, 1242, 1234, 1225,  798,  798, 1222,
- /*   150 */  1221, 1218, 1214, 1213, 1212, 1202, 1195, 1191, 1161, 1158,
- /*   160 */  1140, 1135, 1123, 1112, 1107, 1100, 1080, 1074, 1073, 1072,
- /*   170 */  1070, 1067, 1048, 1044,  969,  968,  907,  906,  904,  894,
- /*   180 */   833,  837,  836,  340,  827,  815,  775,   68,  722,  646,
- /*   190 */  -168, 1384, 1380, 1377, 1379, 1376, 1373, 1339, 1365, 1368,
- /*   200 */  1365, 1365, 1365, 1365, 1365, 1365, 1365, 1320, 1319, 1365,
- /*   210 */  1365, 1339, 1378, 1349, 1391, 1350, 1342, 1334, 1307, 1341,
- /*   220 */  1293, 1364, 1363, 1371, 1362, 1370, 1359, 1340, 1354, 1333,
- /*   230 */  1305, 1304, 1299, 1361, 1328, 1324, 1366, 1282, 1360, 1358,
- /*   240 */  1278, 1276, 1356, 1292, 1322, 1309, 1317, 1315, 1314, 1312,
- /*   250 */  1345, 1347, 1302, 1277, 1311, 1303, 1337, 1335, 1252, 1248,
- /*   260 */  1332, 1330, 1329, 1327, 1326, 1323, 1321, 1297, 1301, 1295,
- /*   270 */  1294, 1290, 1243, 1240, 1284, 1291, 1286, 1283, 1274, 1281,
- /*   280 */  1271, 1238, 1241, 1236, 1235, 1227, 1226, 1267, 1266, 1189,
- /*   290 */  1229, 1223, 1211, 1206, 1201, 1197, 1239, 1237, 1219, 1216,
- /*   300 */  1209, 1208, 1185, 1089, 1086, 1087, 1137, 1136, 1164,
+ /*     0 */  -160, 1220,  143,   54,   -4,   -2,   66,   67,  131,  204,
+ /*    10 */     1,  141,  -71,  144,  218,  234,  278,  209,  291,   22,
+ /*    20 */   206,  222,  426,  428,  432,  442,  444,  446,  498,  500,
+ /*    30 */   502,  514,  516,  518,  559,  572,  575,  585,  588,  592,
+ /*    40 */   646,  648,  658,  662,  668,  707,  720,  723,  733,  736,
+ /*    50 */   739,  781,  794,  797,  807,  810,  813,  855,  868,  872,
+ /*    60 */   884,  886,  888,  974,  375,  944,  -89,  152,  453,  418,
+ /*    70 */   136,  136,  136,  136,  136,  136,  136,  136,  136,  136,
+ /*    80 */   136,  136,  136,  136,  136,  136,  136,  136,  136,  136,
+ /*    90 */   136,  136,  136,  136,  136,  136,  136,  136,  136,  136,
+ /*   100 */   136,  136,  136,  136,  136,  136,  136,  158,   26,  136,
+ /*   110 */   382,   12,  576,  752,  914,  945,  946,  947,  430,  519,
+ /*   120 */   665,  664,  725,  337,  811,  948,  527,  -47,  727,  801,
+ /*   130 */   949,  885,  874,  966,  831, 1007, 1009, 1014,  487,  136,
+ /*   140 */   136,  136,  136,  136,  127,  133,   32,  251,  578,  726,
+ /*   150 */   814,  952,  958,  629,  629,  965,  970, 1025, 1026, 1030,
+ /*   160 */  1044, 1056, 1057, 1058, 1061, 1066, 1069, 1093, 1105, 1109,
+ /*   170 */  1116, 1118, 1119, 1135, 1137, 1155, 1156,    2, 1157, 1158,
+ /*   180 */  1159, 1160, 1162,  578, 1163, 1164, 1169, 1185, 1192,  334,
+ /*   190 */   520,  677,  771,  120,  253,  825,  758,  877, 1028,  305,
+ /*   200 */   -95,  -43,  -36,  -65,   -9,   47,   25,  119,   68,   89,
+ /*   210 */    25,  191,  170,  239,  304,  271,  363,  368,  357,  420,
+ /*   220 */   415,  546,  612,  565,  563,  640,  617,  606,  695,  729,
+ /*   230 */   711,  671,  676,  745,  756,  779,  829,  873, 1020, 1032,
+ /*   240 */   972,  975, 1067, 1027, 1071, 1072, 1107, 1111, 1123, 1138,
+ /*   250 */  1140, 1200, 1166, 1145, 1183, 1197, 1211, 1231, 1152, 1167,
+ /*   260 */  1235, 1238, 1240, 1242, 1241, 1243, 1245, 1225, 1232, 1233,
+ /*   270 */  1234, 1223, 1236, 1237, 1244, 1247, 1230, 1248, 1249, 1204,
+ /*   280 */  1216, 1218, 1221, 1219, 1222, 1227, 1239, 1272, 1278, 1258,
+ /*   290 */  1226, 1228, 1229, 1269, 1250, 1253, 1246, 1265, 1259, 1274,
+ /*   300 */  1311, 1321, 1322, 1329, 1330, 1331, 1332, 1256, 1260, 1251,
+ /*   310 */  1297, 1298, 1318,
 };
 static const YYACTIONTYPE yy_default[] = {
- /*     0 */   632,  866,  954,  954,  866,  866,  954,  954,  954,  756,
- /*    10 */   954,  954,  954,  864,  954,  954,  784,  784,  928,  954,
- /*    20 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*    30 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*    40 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*    50 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*    60 */   954,  954,  954,  954,  954,  954,  954,  671,  760,  790,
- /*    70 */   954,  954,  954,  954,  954,  954,  954,  954,  927,  929,
- /*    80 */   798,  797,  907,  771,  795,  788,  792,  867,  860,  861,
- /*    90 */   859,  863,  868,  954,  791,  827,  844,  826,  838,  843,
- /*   100 */   850,  842,  839,  829,  828,  830,  831,  954,  954,  954,
- /*   110 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   120 */   954,  954,  954,  658,  725,  954,  954,  954,  954,  954,
- /*   130 */   954,  954,  954,  832,  833,  847,  846,  845,  954,  663,
- /*   140 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   150 */   934,  932,  954,  879,  954,  954,  954,  954,  954,  954,
- /*   160 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   170 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   180 */   638,  756,  756,  756,  632,  954,  954,  954,  946,  760,
- /*   190 */   750,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   200 */   954,  954,  954,  800,  739,  917,  919,  954,  900,  737,
- /*   210 */   660,  758,  673,  748,  640,  794,  773,  773,  912,  794,
- /*   220 */   912,  696,  719,  954,  784,  954,  784,  693,  784,  773,
- /*   230 */   862,  954,  954,  954,  757,  748,  954,  939,  764,  764,
- /*   240 */   931,  931,  764,  806,  729,  794,  736,  736,  736,  736,
- /*   250 */   764,  655,  794,  806,  729,  729,  764,  655,  906,  904,
- /*   260 */   764,  764,  655,  764,  655,  764,  655,  872,  727,  727,
- /*   270 */   727,  711,  876,  876,  872,  727,  696,  727,  711,  727,
- /*   280 */   727,  777,  772,  777,  772,  777,  772,  764,  764,  954,
- /*   290 */   789,  778,  787,  785,  794,  954,  714,  648,  648,  637,
- /*   300 */   637,  637,  637,  951,  951,  946,  698,  698,  681,  954,
- /*   310 */   954,  954,  954,  954,  954,  954,  881,  954,  954,  954,
- /*   320 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  633,
- /*   330 */   941,  954,  954,  938,  954,  954,  954,  954,  799,  954,
- /*   340 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  916,
- /*   350 */   954,  954,  954,  954,  954,  954,  954,  910,  954,  954,
- /*   360 */   954,  954,  954,  954,  903,  902,  954,  954,  954,  954,
- /*   370 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   380 */   954,  954,  954,  954,  954,  954,  954,  954,  954,  954,
- /*   390 */   954,  954,  786,  954,  779,  954,  865,  954,  954,  954,
- /*   400 */   954,  954,  954,  954,  954,  954,  954,  742,  815,  954,
- /*   410 */   814,  818,  813,  665,  954,  646,  954,  629,  634,  950,
- /*   420 */   953,  952,  949,  948,  947,  942,  940,  937,  936,  935,
- /*   430 */   933,  930,  926,  885,  883,  890,  889,  888,  887,  886,
- /*   440 */   884,  882,  880,  801,  796,  793,  925,  878,  738,  735,
- /*   450 */   734,  654,  943,  909,  918,  805,  804,  807,  915,  914,
- /*   460 */   913,  911,  908,  895,  803,  802,  730,  870,  869,  657,
- /*   470 */   899,  898,  897,  901,  905,  896,  766,  656,  653,  662,
- /*   480 */   717,  718,  726,  724,  723,  722,  721,  720,  716,  664,
- /*   490 */   672,  710,  695,  694,  875,  877,  874,  873,  703,  702,
- /*   500 */   708,  707,  706,  705,  704,  701,  700,  699,  692,  691,
- /*   510 */   697,  690,  713,  712,  709,  689,  733,  732,  731,  728,
- /*   520 */   688,  687,  686,  818,  685,  684,  824,  823,  811,  854,
- /*   530 */   753,  752,  751,  763,  762,  775,  774,  809,  808,  776,
- /*   540 */   761,  755,  754,  770,  769,  768,  767,  759,  749,  781,
- /*   550 */   783,  782,  780,  856,  765,  853,  924,  923,  922,  921,
- /*   560 */   920,  858,  857,  825,  822,  676,  677,  893,  892,  894,
- /*   570 */   891,  679,  678,  675,  674,  855,  744,  743,  851,  848,
- /*   580 */   840,  836,  852,  849,  841,  837,  835,  834,  820,  819,
- /*   590 */   817,  816,  812,  821,  667,  745,  741,  740,  810,  747,
- /*   600 */   746,  683,  682,  680,  661,  659,  652,  650,  649,  651,
- /*   610 */   647,  645,  644,  643,  642,  641,  670,  669,  668,  666,
- /*   620 */   665,  639,  636,  635,  631,  630,  628,
+ /*     0 */   662,  898,  986,  986,  898,  898,  898,  993,  993,  993,
+ /*    10 */   787,  993,  993,  896,  993,  986,  993,  816,  816,  960,
+ /*    20 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*    30 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*    40 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*    50 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*    60 */   993,  993,  993,  986,  993,  993,  993,  993,  791,  701,
+ /*    70 */   822,  993,  993,  993,  993,  993,  993,  993,  993,  959,
+ /*    80 */   961,  830,  829,  939,  803,  827,  820,  824,  892,  893,
+ /*    90 */   891,  895,  899,  900,  993,  823,  859,  876,  858,  870,
+ /*   100 */   875,  882,  874,  871,  861,  860,  862,  993,  993,  863,
+ /*   110 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*   120 */   993,  993,  993,  993,  993,  993,  688,  755,  993,  993,
+ /*   130 */   993,  993,  993,  993,  986,  986,  986,  986,  993,  864,
+ /*   140 */   865,  879,  878,  877,  993,  693,  993,  993,  993,  993,
+ /*   150 */   993,  993,  993,  993,  993,  993,  966,  964,  993,  911,
+ /*   160 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*   170 */   993,  993,  993,  993,  993,  993,  993,  787,  993,  993,
+ /*   180 */   993,  993,  993,  993,  993,  993,  993,  993,  668,  787,
+ /*   190 */   787,  787,  662,  986,  986,  986,  993,  978,  791,  781,
+ /*   200 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  932,
+ /*   210 */   789,  703,  770,  779,  670,  826,  805,  805,  944,  826,
+ /*   220 */   944,  726,  749,  993,  723,  816,  805,  894,  816,  993,
+ /*   230 */   816,  993,  993,  993,  788,  779,  993,  971,  796,  796,
+ /*   240 */   963,  963,  796,  838,  759,  826,  766,  766,  766,  766,
+ /*   250 */   796,  685,  826,  838,  759,  759,  796,  685,  938,  936,
+ /*   260 */   796,  796,  685,  796,  685,  796,  685,  904,  757,  757,
+ /*   270 */   757,  741,  904,  757,  726,  757,  741,  757,  757,  809,
+ /*   280 */   804,  809,  804,  809,  804,  809,  804,  796,  796,  904,
+ /*   290 */   993,  908,  908,  904,  821,  810,  819,  817,  826,  993,
+ /*   300 */   744,  678,  678,  667,  667,  667,  667,  983,  983,  978,
+ /*   310 */   728,  728,  711,  993,  993,  993,  993,  993,  993,  993,
+ /*   320 */   913,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*   330 */   993,  993,  993,  993,  993,  663,  973,  993,  993,  970,
+ /*   340 */   993,  993,  993,  993,  831,  993,  993,  993,  993,  993,
+ /*   350 */   993,  993,  993,  948,  993,  993,  993,  993,  993,  993,
+ /*   360 */   942,  993,  993,  993,  993,  993,  993,  935,  934,  993,
+ /*   370 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*   380 */   993,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*   390 */   993,  773,  993,  993,  993,  993,  993,  993,  993,  818,
+ /*   400 */   993,  811,  993,  993,  993,  993,  993,  993,  988,  993,
+ /*   410 */   897,  993,  993,  993,  993,  993,  993,  993,  993,  993,
+ /*   420 */   993,  987,  993,  993,  993,  993,  993,  847,  993,  846,
+ /*   430 */   850,  845,  695,  993,  676,  993,  659,  664,  982,  985,
+ /*   440 */   984,  981,  980,  979,  974,  972,  969,  968,  967,  965,
+ /*   450 */   962,  958,  917,  915,  922,  921,  920,  919,  918,  916,
+ /*   460 */   914,  912,  833,  832,  828,  825,  769,  957,  910,  768,
+ /*   470 */   765,  764,  684,  975,  941,  951,  950,  949,  839,  947,
+ /*   480 */   946,  945,  943,  940,  927,  835,  834,  760,  902,  901,
+ /*   490 */   687,  931,  930,  929,  933,  937,  928,  798,  767,  686,
+ /*   500 */   683,  690,  692,  747,  748,  756,  754,  753,  752,  751,
+ /*   510 */   750,  746,  694,  702,  740,  725,  724,  733,  732,  738,
+ /*   520 */   737,  736,  735,  734,  731,  730,  729,  722,  721,  727,
+ /*   530 */   720,  743,  742,  739,  719,  763,  762,  761,  758,  718,
+ /*   540 */   717,  716,  850,  715,  714,  856,  855,  886,  843,  771,
+ /*   550 */   775,  774,  784,  783,  782,  794,  795,  793,  807,  806,
+ /*   560 */   841,  840,  808,  792,  786,  785,  802,  801,  800,  799,
+ /*   570 */   790,  780,  813,  812,  888,  797,  887,  885,  989,  990,
+ /*   580 */   991,  992,  837,  956,  955,  954,  953,  952,  890,  836,
+ /*   590 */   907,  909,  906,  815,  814,  905,  889,  857,  854,  706,
+ /*   600 */   707,  925,  924,  926,  923,  709,  708,  705,  704,  883,
+ /*   610 */   880,  872,  868,  884,  881,  873,  869,  867,  866,  852,
+ /*   620 */   851,  849,  848,  844,  853,  697,  776,  772,  842,  778,
+ /*   630 */   777,  713,  712,  710,  691,  689,  682,  680,  679,  681,
+ /*   640 */   677,  675,  674,  673,  672,  671,  700,  699,  698,  696,
+ /*   650 */   695,  669,  666,  665,  661,  660,  658,
 };
 
 /* The next table maps tokens into fallback tokens.  If a construct
@@ -815,6 +836,99 @@ static const YYCODETYPE yyFallback[] = {
    26,  /*    REINDEX => ID */
    26,  /*     RENAME => ID */
    26,  /*   CTIME_KW => ID */
+    0,  /*        ANY => nothing */
+    0,  /*         OR => nothing */
+    0,  /*        AND => nothing */
+    0,  /*         IS => nothing */
+    0,  /*    BETWEEN => nothing */
+    0,  /*         IN => nothing */
+    0,  /*     ISNULL => nothing */
+    0,  /*    NOTNULL => nothing */
+    0,  /*         NE => nothing */
+    0,  /*         EQ => nothing */
+    0,  /*         GT => nothing */
+    0,  /*         LE => nothing */
+    0,  /*         LT => nothing */
+    0,  /*         GE => nothing */
+    0,  /*     ESCAPE => nothing */
+    0,  /*     BITAND => nothing */
+    0,  /*      BITOR => nothing */
+    0,  /*     LSHIFT => nothing */
+    0,  /*     RSHIFT => nothing */
+    0,  /*       PLUS => nothing */
+    0,  /*      MINUS => nothing */
+    0,  /*       STAR => nothing */
+    0,  /*      SLASH => nothing */
+    0,  /*        REM => nothing */
+    0,  /*     CONCAT => nothing */
+    0,  /*    COLLATE => nothing */
+    0,  /*     BITNOT => nothing */
+    0,  /*     STRING => nothing */
+    0,  /*    JOIN_KW => nothing */
+    0,  /* CONSTRAINT => nothing */
+    0,  /*    DEFAULT => nothing */
+    0,  /*       NULL => nothing */
+    0,  /*    PRIMARY => nothing */
+    0,  /*     UNIQUE => nothing */
+    0,  /*      CHECK => nothing */
+    0,  /* REFERENCES => nothing */
+    0,  /*   AUTOINCR => nothing */
+    0,  /*         ON => nothing */
+    0,  /*     INSERT => nothing */
+    0,  /*     DELETE => nothing */
+    0,  /*     UPDATE => nothing */
+    0,  /*        SET => nothing */
+    0,  /* DEFERRABLE => nothing */
+    0,  /*    FOREIGN => nothing */
+    0,  /*       DROP => nothing */
+    0,  /*      UNION => nothing */
+    0,  /*        ALL => nothing */
+    0,  /*     EXCEPT => nothing */
+    0,  /*  INTERSECT => nothing */
+    0,  /*     SELECT => nothing */
+    0,  /*   DISTINCT => nothing */
+    0,  /*        DOT => nothing */
+    0,  /*       FROM => nothing */
+    0,  /*       JOIN => nothing */
+    0,  /*      USING => nothing */
+    0,  /*      ORDER => nothing */
+    0,  /*      GROUP => nothing */
+    0,  /*     HAVING => nothing */
+    0,  /*      LIMIT => nothing */
+    0,  /*      WHERE => nothing */
+    0,  /*       INTO => nothing */
+    0,  /*     VALUES => nothing */
+    0,  /*    INTEGER => nothing */
+    0,  /*      FLOAT => nothing */
+    0,  /*       BLOB => nothing */
+    0,  /*   REGISTER => nothing */
+    0,  /*   VARIABLE => nothing */
+    0,  /*       CASE => nothing */
+    0,  /*       WHEN => nothing */
+    0,  /*       THEN => nothing */
+    0,  /*       ELSE => nothing */
+    0,  /*      INDEX => nothing */
+    0,  /*      ALTER => nothing */
+    0,  /*        ADD => nothing */
+    0,  /*    TO_TEXT => nothing */
+    0,  /*    TO_BLOB => nothing */
+    0,  /* TO_NUMERIC => nothing */
+    0,  /*     TO_INT => nothing */
+    0,  /*    TO_REAL => nothing */
+    0,  /*      ISNOT => nothing */
+    0,  /* END_OF_FILE => nothing */
+    0,  /*    ILLEGAL => nothing */
+    0,  /*      SPACE => nothing */
+    0,  /* UNCLOSED_STRING => nothing */
+    0,  /*   FUNCTION => nothing */
+    0,  /*     COLUMN => nothing */
+    0,  /* AGG_FUNCTION => nothing */
+    0,  /* AGG_COLUMN => nothing */
+    0,  /* CONST_FUNC => nothing */
+    0,  /*     UMINUS => nothing */
+    0,  /*      UPLUS => nothing */
+   26,  /*       WITH => ID */
+   26,  /*  RECURSIVE => ID */
 };
 #endif /* YYFALLBACK */
 
@@ -925,76 +1039,77 @@ static void yy_destructor(
     ** which appear on the RHS of the rule, but which are not used
     ** inside the C code.
     */
-    case 160: /* select */
-    case 194: /* oneselect */
+    case 179: /* select */
+    case 213: /* selectnowith */
+    case 214: /* oneselect */
 {
-sqlite3SelectDelete(pParse.db, (yypminor.yy159));
+sqlite3SelectDelete(pParse.db, (yypminor.yy331));
 }
       break;
-    case 173: /* term */
-    case 174: /* expr */
+    case 192: /* term */
+    case 193: /* expr */
 {
-pParse.db.ExprDelete((yypminor.yy342).Expr)
+pParse.db.ExprDelete((yypminor.yy214).Expr)
 }
       break;
-    case 178: /* idxlist_opt */
-    case 187: /* idxlist */
-    case 197: /* selcollist */
-    case 200: /* groupby_opt */
-    case 202: /* orderby_opt */
-    case 204: /* sclp */
-    case 214: /* sortlist */
-    case 215: /* nexprlist */
-    case 216: /* setlist */
-    case 220: /* exprlist */
-    case 225: /* case_exprlist */
-{
-pParse.db.ExprListDelete((yypminor.yy442));
+    case 197: /* idxlist_opt */
+    case 206: /* idxlist */
+    case 218: /* selcollist */
+    case 221: /* groupby_opt */
+    case 223: /* orderby_opt */
+    case 225: /* sclp */
+    case 235: /* sortlist */
+    case 236: /* nexprlist */
+    case 237: /* setlist */
+    case 241: /* exprlist */
+    case 246: /* case_exprlist */
+{
+pParse.db.ExprListDelete((yypminor.yy402));
 }
       break;
-    case 193: /* fullname */
-    case 198: /* from */
-    case 206: /* seltablist */
-    case 207: /* stl_prefix */
+    case 212: /* fullname */
+    case 219: /* from */
+    case 227: /* seltablist */
+    case 228: /* stl_prefix */
 {
-sqlite3SrcListDelete(pParse.db, (yypminor.yy347));
+sqlite3SrcListDelete(pParse.db, (yypminor.yy291));
 }
       break;
-    case 199: /* where_opt */
-    case 201: /* having_opt */
-    case 210: /* on_opt */
-    case 224: /* case_operand */
-    case 226: /* case_else */
-    case 236: /* when_clause */
-    case 241: /* key_opt */
+    case 220: /* where_opt */
+    case 222: /* having_opt */
+    case 231: /* on_opt */
+    case 245: /* case_operand */
+    case 247: /* case_else */
+    case 257: /* when_clause */
+    case 262: /* key_opt */
 {
-pParse.db.ExprDelete((yypminor.yy122))
+pParse.db.ExprDelete((yypminor.yy386))
 }
       break;
-    case 211: /* using_opt */
-    case 213: /* inscollist */
-    case 218: /* inscollist_opt */
+    case 232: /* using_opt */
+    case 234: /* inscollist */
+    case 239: /* inscollist_opt */
 {
-sqlite3IdListDelete(pParse.db, (yypminor.yy180));
+sqlite3IdListDelete(pParse.db, (yypminor.yy272));
 }
       break;
-    case 219: /* valuelist */
+    case 240: /* valuelist */
 {
 
-  pParse.db.ExprListDelete((yypminor.yy487).pList);
-  sqlite3SelectDelete(pParse.db, (yypminor.yy487).Select);
+  pParse.db.ExprListDelete((yypminor.yy211).pList);
+  sqlite3SelectDelete(pParse.db, (yypminor.yy211).Select);
 
 }
       break;
-    case 232: /* trigger_cmd_list */
-    case 237: /* trigger_cmd */
+    case 253: /* trigger_cmd_list */
+    case 258: /* trigger_cmd */
 {
-pParse.db.DeleteTriggerStep((yypminor.yy327))
+pParse.db.DeleteTriggerStep((yypminor.yy251))
 }
       break;
-    case 234: /* trigger_event */
+    case 255: /* trigger_event */
 {
-sqlite3IdListDelete(pParse.db, (yypminor.yy410).b);
+sqlite3IdListDelete(pParse.db, (yypminor.yy403).b);
 }
       break;
     default:  break;   /* If no destructor action specified: do nothing */
@@ -1204,333 +1319,342 @@ static const struct {
   YYCODETYPE lhs;         /* Symbol on the left-hand side of the rule */
   unsigned char nrhs;     /* Number of right-hand side symbols in the rule */
 } yyRuleInfo[] = {
-  { 142, 1 },
-  { 143, 2 },
-  { 143, 1 },
-  { 144, 1 },
-  { 144, 3 },
-  { 145, 0 },
-  { 145, 1 },
-  { 145, 3 },
-  { 146, 1 },
-  { 147, 3 },
-  { 149, 0 },
-  { 149, 1 },
-  { 149, 2 },
-  { 148, 0 },
-  { 148, 1 },
-  { 148, 1 },
-  { 148, 1 },
-  { 147, 2 },
-  { 147, 2 },
-  { 147, 2 },
-  { 151, 1 },
-  { 151, 0 },
-  { 147, 2 },
-  { 147, 3 },
-  { 147, 5 },
-  { 147, 2 },
-  { 152, 6 },
-  { 154, 1 },
-  { 156, 0 },
-  { 156, 3 },
-  { 155, 1 },
-  { 155, 0 },
-  { 153, 4 },
-  { 153, 2 },
-  { 158, 3 },
-  { 158, 1 },
-  { 161, 3 },
+  { 161, 1 },
+  { 162, 2 },
   { 162, 1 },
-  { 165, 1 },
-  { 165, 1 },
-  { 166, 1 },
-  { 150, 1 },
-  { 150, 1 },
-  { 150, 1 },
-  { 163, 0 },
   { 163, 1 },
-  { 167, 1 },
-  { 167, 4 },
-  { 167, 6 },
+  { 163, 3 },
+  { 164, 0 },
+  { 164, 1 },
+  { 164, 3 },
+  { 165, 1 },
+  { 166, 3 },
+  { 168, 0 },
   { 168, 1 },
   { 168, 2 },
-  { 169, 1 },
-  { 169, 1 },
-  { 164, 2 },
-  { 164, 0 },
-  { 172, 2 },
-  { 172, 2 },
-  { 172, 4 },
-  { 172, 3 },
-  { 172, 3 },
-  { 172, 2 },
-  { 172, 2 },
-  { 172, 3 },
-  { 172, 5 },
-  { 172, 2 },
-  { 172, 4 },
+  { 167, 0 },
+  { 167, 1 },
+  { 167, 1 },
+  { 167, 1 },
+  { 166, 2 },
+  { 166, 2 },
+  { 166, 2 },
+  { 170, 1 },
+  { 170, 0 },
+  { 166, 2 },
+  { 166, 3 },
+  { 166, 5 },
+  { 166, 2 },
+  { 171, 6 },
+  { 173, 1 },
+  { 175, 0 },
+  { 175, 3 },
+  { 174, 1 },
+  { 174, 0 },
   { 172, 4 },
-  { 172, 1 },
   { 172, 2 },
-  { 177, 0 },
+  { 177, 3 },
   { 177, 1 },
-  { 179, 0 },
-  { 179, 2 },
-  { 181, 2 },
-  { 181, 3 },
-  { 181, 3 },
-  { 181, 3 },
-  { 182, 2 },
-  { 182, 2 },
-  { 182, 1 },
-  { 182, 1 },
-  { 182, 2 },
   { 180, 3 },
-  { 180, 2 },
-  { 183, 0 },
-  { 183, 2 },
-  { 183, 2 },
-  { 159, 0 },
-  { 159, 2 },
-  { 184, 3 },
+  { 181, 1 },
+  { 184, 1 },
   { 184, 1 },
   { 185, 1 },
-  { 185, 0 },
-  { 186, 2 },
-  { 186, 7 },
-  { 186, 5 },
-  { 186, 5 },
-  { 186, 10 },
-  { 188, 0 },
+  { 169, 1 },
+  { 169, 1 },
+  { 169, 1 },
+  { 182, 0 },
+  { 182, 1 },
+  { 186, 1 },
+  { 186, 4 },
+  { 186, 6 },
+  { 187, 1 },
+  { 187, 2 },
   { 188, 1 },
-  { 175, 0 },
-  { 175, 3 },
-  { 189, 0 },
-  { 189, 2 },
-  { 190, 1 },
-  { 190, 1 },
-  { 190, 1 },
-  { 147, 4 },
-  { 192, 2 },
-  { 192, 0 },
-  { 147, 8 },
-  { 147, 4 },
-  { 147, 1 },
-  { 160, 1 },
-  { 160, 3 },
-  { 195, 1 },
-  { 195, 2 },
-  { 195, 1 },
-  { 194, 9 },
-  { 196, 1 },
-  { 196, 1 },
+  { 188, 1 },
+  { 183, 2 },
+  { 183, 0 },
+  { 191, 2 },
+  { 191, 2 },
+  { 191, 4 },
+  { 191, 3 },
+  { 191, 3 },
+  { 191, 2 },
+  { 191, 2 },
+  { 191, 3 },
+  { 191, 5 },
+  { 191, 2 },
+  { 191, 4 },
+  { 191, 4 },
+  { 191, 1 },
+  { 191, 2 },
   { 196, 0 },
-  { 204, 2 },
-  { 204, 0 },
-  { 197, 3 },
-  { 197, 2 },
-  { 197, 4 },
-  { 205, 2 },
-  { 205, 1 },
-  { 205, 0 },
+  { 196, 1 },
   { 198, 0 },
   { 198, 2 },
-  { 207, 2 },
-  { 207, 0 },
-  { 206, 7 },
-  { 206, 7 },
-  { 206, 7 },
-  { 157, 0 },
-  { 157, 2 },
-  { 193, 2 },
-  { 208, 1 },
-  { 208, 2 },
-  { 208, 3 },
-  { 208, 4 },
-  { 210, 2 },
-  { 210, 0 },
-  { 209, 0 },
-  { 209, 3 },
-  { 209, 2 },
-  { 211, 4 },
-  { 211, 0 },
-  { 202, 0 },
-  { 202, 3 },
-  { 214, 4 },
-  { 214, 2 },
-  { 176, 1 },
-  { 176, 1 },
-  { 176, 0 },
-  { 200, 0 },
+  { 200, 2 },
+  { 200, 3 },
   { 200, 3 },
-  { 201, 0 },
+  { 200, 3 },
+  { 201, 2 },
+  { 201, 2 },
+  { 201, 1 },
+  { 201, 1 },
   { 201, 2 },
-  { 203, 0 },
-  { 203, 2 },
-  { 203, 4 },
-  { 203, 4 },
-  { 147, 5 },
-  { 199, 0 },
+  { 199, 3 },
   { 199, 2 },
-  { 147, 7 },
-  { 216, 5 },
-  { 216, 3 },
-  { 147, 5 },
-  { 147, 5 },
-  { 147, 6 },
-  { 217, 2 },
+  { 202, 0 },
+  { 202, 2 },
+  { 202, 2 },
+  { 178, 0 },
+  { 178, 2 },
+  { 203, 3 },
+  { 203, 1 },
+  { 204, 1 },
+  { 204, 0 },
+  { 205, 2 },
+  { 205, 7 },
+  { 205, 5 },
+  { 205, 5 },
+  { 205, 10 },
+  { 207, 0 },
+  { 207, 1 },
+  { 194, 0 },
+  { 194, 3 },
+  { 208, 0 },
+  { 208, 2 },
+  { 209, 1 },
+  { 209, 1 },
+  { 209, 1 },
+  { 166, 4 },
+  { 211, 2 },
+  { 211, 0 },
+  { 166, 8 },
+  { 166, 4 },
+  { 166, 1 },
+  { 179, 2 },
+  { 213, 1 },
+  { 213, 3 },
+  { 216, 1 },
+  { 216, 2 },
+  { 216, 1 },
+  { 214, 9 },
+  { 217, 1 },
   { 217, 1 },
-  { 219, 4 },
-  { 219, 5 },
-  { 218, 0 },
+  { 217, 0 },
+  { 225, 2 },
+  { 225, 0 },
   { 218, 3 },
-  { 213, 3 },
-  { 213, 1 },
-  { 174, 1 },
-  { 174, 3 },
-  { 173, 1 },
-  { 174, 1 },
-  { 174, 1 },
-  { 174, 3 },
-  { 174, 5 },
-  { 173, 1 },
-  { 173, 1 },
-  { 174, 1 },
-  { 174, 1 },
-  { 174, 3 },
-  { 174, 6 },
-  { 174, 5 },
-  { 174, 4 },
-  { 173, 1 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 3 },
-  { 221, 1 },
-  { 221, 2 },
-  { 221, 1 },
-  { 221, 2 },
-  { 174, 3 },
-  { 174, 5 },
-  { 174, 2 },
-  { 174, 3 },
-  { 174, 3 },
-  { 174, 4 },
-  { 174, 2 },
-  { 174, 2 },
-  { 174, 2 },
-  { 174, 2 },
-  { 222, 1 },
-  { 222, 2 },
-  { 174, 5 },
-  { 223, 1 },
-  { 223, 2 },
-  { 174, 5 },
-  { 174, 3 },
-  { 174, 5 },
-  { 174, 4 },
-  { 174, 4 },
-  { 174, 5 },
-  { 225, 5 },
-  { 225, 4 },
+  { 218, 2 },
+  { 218, 4 },
   { 226, 2 },
+  { 226, 1 },
   { 226, 0 },
-  { 224, 1 },
-  { 224, 0 },
-  { 220, 1 },
-  { 220, 0 },
-  { 215, 3 },
-  { 215, 1 },
-  { 147, 11 },
-  { 227, 1 },
-  { 227, 0 },
-  { 178, 0 },
-  { 178, 3 },
-  { 187, 5 },
-  { 187, 3 },
-  { 228, 0 },
+  { 219, 0 },
+  { 219, 2 },
   { 228, 2 },
-  { 147, 4 },
-  { 147, 1 },
-  { 147, 2 },
-  { 147, 3 },
-  { 147, 5 },
-  { 147, 6 },
-  { 147, 5 },
-  { 147, 6 },
-  { 229, 1 },
-  { 229, 1 },
-  { 229, 1 },
-  { 229, 1 },
+  { 228, 0 },
+  { 227, 7 },
+  { 227, 9 },
+  { 227, 7 },
+  { 227, 7 },
+  { 176, 0 },
+  { 176, 2 },
+  { 212, 2 },
   { 229, 1 },
-  { 170, 2 },
-  { 170, 1 },
-  { 171, 2 },
-  { 230, 1 },
-  { 147, 5 },
-  { 231, 11 },
-  { 233, 1 },
-  { 233, 1 },
-  { 233, 2 },
-  { 233, 0 },
-  { 234, 1 },
-  { 234, 1 },
-  { 234, 3 },
-  { 235, 0 },
-  { 235, 3 },
-  { 236, 0 },
-  { 236, 2 },
-  { 232, 3 },
-  { 232, 2 },
+  { 229, 2 },
+  { 229, 3 },
+  { 229, 4 },
+  { 231, 2 },
+  { 231, 0 },
+  { 230, 0 },
+  { 230, 3 },
+  { 230, 2 },
+  { 232, 4 },
+  { 232, 0 },
+  { 223, 0 },
+  { 223, 3 },
+  { 235, 4 },
+  { 235, 2 },
+  { 195, 1 },
+  { 195, 1 },
+  { 195, 0 },
+  { 221, 0 },
+  { 221, 3 },
+  { 222, 0 },
+  { 222, 2 },
+  { 224, 0 },
+  { 224, 2 },
+  { 224, 4 },
+  { 224, 4 },
+  { 166, 6 },
+  { 220, 0 },
+  { 220, 2 },
+  { 166, 8 },
+  { 237, 5 },
+  { 237, 3 },
+  { 166, 6 },
+  { 166, 6 },
+  { 166, 7 },
+  { 238, 2 },
   { 238, 1 },
-  { 238, 3 },
+  { 240, 4 },
+  { 240, 5 },
   { 239, 0 },
   { 239, 3 },
-  { 239, 2 },
-  { 237, 7 },
-  { 237, 5 },
-  { 237, 5 },
-  { 237, 5 },
-  { 237, 1 },
-  { 174, 4 },
-  { 174, 6 },
-  { 191, 1 },
-  { 191, 1 },
-  { 191, 1 },
-  { 147, 4 },
-  { 147, 6 },
-  { 147, 3 },
-  { 241, 0 },
-  { 241, 2 },
-  { 240, 1 },
-  { 240, 0 },
-  { 147, 1 },
-  { 147, 3 },
-  { 147, 1 },
-  { 147, 3 },
-  { 147, 6 },
-  { 147, 6 },
+  { 234, 3 },
+  { 234, 1 },
+  { 193, 1 },
+  { 193, 3 },
+  { 192, 1 },
+  { 193, 1 },
+  { 193, 1 },
+  { 193, 3 },
+  { 193, 5 },
+  { 192, 1 },
+  { 192, 1 },
+  { 193, 1 },
+  { 193, 1 },
+  { 193, 3 },
+  { 193, 6 },
+  { 193, 5 },
+  { 193, 4 },
+  { 192, 1 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 3 },
+  { 242, 1 },
+  { 242, 2 },
   { 242, 1 },
-  { 243, 0 },
+  { 242, 2 },
+  { 193, 3 },
+  { 193, 5 },
+  { 193, 2 },
+  { 193, 3 },
+  { 193, 3 },
+  { 193, 4 },
+  { 193, 2 },
+  { 193, 2 },
+  { 193, 2 },
+  { 193, 2 },
   { 243, 1 },
-  { 147, 1 },
-  { 147, 4 },
-  { 244, 8 },
+  { 243, 2 },
+  { 193, 5 },
+  { 244, 1 },
+  { 244, 2 },
+  { 193, 5 },
+  { 193, 3 },
+  { 193, 5 },
+  { 193, 4 },
+  { 193, 4 },
+  { 193, 5 },
+  { 246, 5 },
+  { 246, 4 },
+  { 247, 2 },
+  { 247, 0 },
   { 245, 1 },
-  { 245, 3 },
-  { 246, 0 },
-  { 246, 2 },
-  { 247, 1 },
-  { 247, 3 },
+  { 245, 0 },
+  { 241, 1 },
+  { 241, 0 },
+  { 236, 3 },
+  { 236, 1 },
+  { 166, 11 },
   { 248, 1 },
+  { 248, 0 },
+  { 197, 0 },
+  { 197, 3 },
+  { 206, 5 },
+  { 206, 3 },
   { 249, 0 },
-  { 249, 4 },
   { 249, 2 },
+  { 166, 4 },
+  { 166, 1 },
+  { 166, 2 },
+  { 166, 3 },
+  { 166, 5 },
+  { 166, 6 },
+  { 166, 5 },
+  { 166, 6 },
+  { 250, 1 },
+  { 250, 1 },
+  { 250, 1 },
+  { 250, 1 },
+  { 250, 1 },
+  { 189, 2 },
+  { 189, 1 },
+  { 190, 2 },
+  { 251, 1 },
+  { 166, 5 },
+  { 252, 11 },
+  { 254, 1 },
+  { 254, 1 },
+  { 254, 2 },
+  { 254, 0 },
+  { 255, 1 },
+  { 255, 1 },
+  { 255, 3 },
+  { 256, 0 },
+  { 256, 3 },
+  { 257, 0 },
+  { 257, 2 },
+  { 253, 3 },
+  { 253, 2 },
+  { 259, 1 },
+  { 259, 3 },
+  { 260, 0 },
+  { 260, 3 },
+  { 260, 2 },
+  { 258, 7 },
+  { 258, 5 },
+  { 258, 5 },
+  { 258, 5 },
+  { 258, 1 },
+  { 193, 4 },
+  { 193, 6 },
+  { 210, 1 },
+  { 210, 1 },
+  { 210, 1 },
+  { 166, 4 },
+  { 166, 6 },
+  { 166, 3 },
+  { 262, 0 },
+  { 262, 2 },
+  { 261, 1 },
+  { 261, 0 },
+  { 166, 1 },
+  { 166, 3 },
+  { 166, 1 },
+  { 166, 3 },
+  { 166, 6 },
+  { 166, 6 },
+  { 263, 1 },
+  { 264, 0 },
+  { 264, 1 },
+  { 166, 1 },
+  { 166, 4 },
+  { 265, 8 },
+  { 266, 1 },
+  { 266, 3 },
+  { 267, 0 },
+  { 267, 2 },
+  { 268, 1 },
+  { 268, 3 },
+  { 269, 1 },
+  { 270, 0 },
+  { 270, 4 },
+  { 270, 2 },
+  { 215, 0 },
+  { 215, 2 },
+  { 215, 3 },
+  { 271, 6 },
+  { 271, 6 },
+  { 271, 8 },
+  { 271, 8 },
 };
 
 static void yy_accept(yyParser*);  /* Forward Declaration */
@@ -1591,17 +1715,17 @@ static void yy_reduce(
 { sqlite3FinishCoding(pParse); }
         break;
       case 9: /* cmd ::= BEGIN transtype trans_opt */
-{pParse.BeginTransaction(yymsp[-1].minor.yy392)}
+{pParse.BeginTransaction(yymsp[-1].minor.yy60)}
         break;
       case 13: /* transtype ::= */
-{yygotominor.yy392 = TK_DEFERRED;}
+{yygotominor.yy60 = TK_DEFERRED;}
         break;
       case 14: /* transtype ::= DEFERRED */
       case 15: /* transtype ::= IMMEDIATE */
       case 16: /* transtype ::= EXCLUSIVE */
-      case 115: /* multiselect_op ::= UNION */
-      case 117: /* multiselect_op ::= EXCEPT|INTERSECT */
-{yygotominor.yy392 = yymsp[0].major;}
+      case 116: /* multiselect_op ::= UNION */
+      case 118: /* multiselect_op ::= EXCEPT|INTERSECT */
+{yygotominor.yy60 = yymsp[0].major;}
         break;
       case 17: /* cmd ::= COMMIT trans_opt */
       case 18: /* cmd ::= END trans_opt */
@@ -1627,7 +1751,7 @@ static void yy_reduce(
         break;
       case 26: /* create_table ::= createkw temp TABLE ifnotexists nm dbnm */
 {
-   sqlite3StartTable(pParse,&yymsp[-1].minor.yy0,&yymsp[0].minor.yy0,yymsp[-4].minor.yy392,0,0,yymsp[-2].minor.yy392);
+   sqlite3StartTable(pParse,&yymsp[-1].minor.yy0,&yymsp[0].minor.yy0,yymsp[-4].minor.yy60,0,0,yymsp[-2].minor.yy60);
 }
         break;
       case 27: /* createkw ::= CREATE */
@@ -1644,21 +1768,21 @@ static void yy_reduce(
       case 86: /* init_deferred_pred_opt ::= INITIALLY IMMEDIATE */
       case 98: /* defer_subclause_opt ::= */
       case 109: /* ifexists ::= */
-      case 120: /* distinct ::= ALL */
-      case 121: /* distinct ::= */
-      case 221: /* between_op ::= BETWEEN */
-      case 224: /* in_op ::= IN */
-{yygotominor.yy392 = 0;}
+      case 121: /* distinct ::= ALL */
+      case 122: /* distinct ::= */
+      case 223: /* between_op ::= BETWEEN */
+      case 226: /* in_op ::= IN */
+{yygotominor.yy60 = 0;}
         break;
       case 29: /* ifnotexists ::= IF NOT EXISTS */
       case 30: /* temp ::= TEMP */
       case 70: /* autoinc ::= AUTOINCR */
       case 85: /* init_deferred_pred_opt ::= INITIALLY DEFERRED */
       case 108: /* ifexists ::= IF EXISTS */
-      case 119: /* distinct ::= DISTINCT */
-      case 222: /* between_op ::= NOT BETWEEN */
-      case 225: /* in_op ::= NOT IN */
-{yygotominor.yy392 = 1;}
+      case 120: /* distinct ::= DISTINCT */
+      case 224: /* between_op ::= NOT BETWEEN */
+      case 227: /* in_op ::= NOT IN */
+{yygotominor.yy60 = 1;}
         break;
       case 32: /* create_table_args ::= LP columnlist conslist_opt RP */
 {
@@ -1667,8 +1791,8 @@ static void yy_reduce(
         break;
       case 33: /* create_table_args ::= AS select */
 {
-  sqlite3EndTable(pParse,0,0,yymsp[0].minor.yy159);
-  sqlite3SelectDelete(pParse.db, yymsp[0].minor.yy159);
+  sqlite3EndTable(pParse,0,0,yymsp[0].minor.yy331);
+  sqlite3SelectDelete(pParse.db, yymsp[0].minor.yy331);
 }
         break;
       case 36: /* column ::= columnid type carglist */
@@ -1692,21 +1816,21 @@ static void yy_reduce(
       case 43: /* nm ::= JOIN_KW */
       case 46: /* typetoken ::= typename */
       case 49: /* typename ::= ids */
-      case 127: /* as ::= AS nm */
-      case 128: /* as ::= ids */
-      case 138: /* dbnm ::= DOT nm */
-      case 147: /* indexed_opt ::= INDEXED BY nm */
-      case 250: /* collate ::= COLLATE ids */
-      case 259: /* nmnum ::= plus_num */
-      case 260: /* nmnum ::= nm */
-      case 261: /* nmnum ::= ON */
-      case 262: /* nmnum ::= DELETE */
-      case 263: /* nmnum ::= DEFAULT */
-      case 264: /* plus_num ::= PLUS number */
-      case 265: /* plus_num ::= number */
-      case 266: /* minus_num ::= MINUS number */
-      case 267: /* number ::= INTEGER|FLOAT */
-      case 283: /* trnm ::= nm */
+      case 128: /* as ::= AS nm */
+      case 129: /* as ::= ids */
+      case 140: /* dbnm ::= DOT nm */
+      case 149: /* indexed_opt ::= INDEXED BY nm */
+      case 252: /* collate ::= COLLATE ids */
+      case 261: /* nmnum ::= plus_num */
+      case 262: /* nmnum ::= nm */
+      case 263: /* nmnum ::= ON */
+      case 264: /* nmnum ::= DELETE */
+      case 265: /* nmnum ::= DEFAULT */
+      case 266: /* plus_num ::= PLUS number */
+      case 267: /* plus_num ::= number */
+      case 268: /* minus_num ::= MINUS number */
+      case 269: /* number ::= INTEGER|FLOAT */
+      case 285: /* trnm ::= nm */
 {yygotominor.yy0 = yymsp[0].minor.yy0;}
         break;
       case 45: /* type ::= typetoken */
@@ -1733,17 +1857,17 @@ static void yy_reduce(
         break;
       case 56: /* ccons ::= DEFAULT term */
       case 58: /* ccons ::= DEFAULT PLUS term */
-{sqlite3AddDefaultValue(pParse,&yymsp[0].minor.yy342);}
+{sqlite3AddDefaultValue(pParse,&yymsp[0].minor.yy214);}
         break;
       case 57: /* ccons ::= DEFAULT LP expr RP */
-{sqlite3AddDefaultValue(pParse,&yymsp[-1].minor.yy342);}
+{sqlite3AddDefaultValue(pParse,&yymsp[-1].minor.yy214);}
         break;
       case 59: /* ccons ::= DEFAULT MINUS term */
 {
   ExprSpan v;
-  v.Expr = pParse.Expr(TK_UMINUS, yymsp[0].minor.yy342.Expr, nil, "")
+  v.Expr = pParse.Expr(TK_UMINUS, yymsp[0].minor.yy214.Expr, nil, "")
   v.zStart = yymsp[-1].minor.yy0.z;
-  v.zEnd = yymsp[0].minor.yy342.zEnd;
+  v.zEnd = yymsp[0].minor.yy214.zEnd;
   sqlite3AddDefaultValue(pParse,&v);
 }
         break;
@@ -1755,62 +1879,62 @@ static void yy_reduce(
 }
         break;
       case 62: /* ccons ::= NOT NULL onconf */
-{sqlite3AddNotNull(pParse, yymsp[0].minor.yy392);}
+{sqlite3AddNotNull(pParse, yymsp[0].minor.yy60);}
         break;
       case 63: /* ccons ::= PRIMARY KEY sortorder onconf autoinc */
-{sqlite3AddPrimaryKey(pParse,0,yymsp[-1].minor.yy392,yymsp[0].minor.yy392,yymsp[-2].minor.yy392);}
+{sqlite3AddPrimaryKey(pParse,0,yymsp[-1].minor.yy60,yymsp[0].minor.yy60,yymsp[-2].minor.yy60);}
         break;
       case 64: /* ccons ::= UNIQUE onconf */
-{sqlite3CreateIndex(pParse,0,0,0,0,yymsp[0].minor.yy392,0,0,0,0);}
+{sqlite3CreateIndex(pParse,0,0,0,0,yymsp[0].minor.yy60,0,0,0,0);}
         break;
       case 65: /* ccons ::= CHECK LP expr RP */
-{sqlite3AddCheckConstraint(pParse,yymsp[-1].minor.yy342.Expr);}
+{sqlite3AddCheckConstraint(pParse,yymsp[-1].minor.yy214.Expr);}
         break;
       case 66: /* ccons ::= REFERENCES nm idxlist_opt refargs */
-{sqlite3CreateForeignKey(pParse,0,&yymsp[-2].minor.yy0,yymsp[-1].minor.yy442,yymsp[0].minor.yy392);}
+{sqlite3CreateForeignKey(pParse,0,&yymsp[-2].minor.yy0,yymsp[-1].minor.yy402,yymsp[0].minor.yy60);}
         break;
       case 67: /* ccons ::= defer_subclause */
-{sqlite3DeferForeignKey(pParse,yymsp[0].minor.yy392);}
+{sqlite3DeferForeignKey(pParse,yymsp[0].minor.yy60);}
         break;
       case 68: /* ccons ::= COLLATE ids */
 {pParse.AddCollateType(&yymsp[0].minor.yy0)}
         break;
       case 71: /* refargs ::= */
-{ yygotominor.yy392 = OE_None*0x0101; /* EV: R-19803-45884 */}
+{ yygotominor.yy60 = OE_None*0x0101; /* EV: R-19803-45884 */}
         break;
       case 72: /* refargs ::= refargs refarg */
-{ yygotominor.yy392 = (yymsp[-1].minor.yy392 & ~yymsp[0].minor.yy207.mask) | yymsp[0].minor.yy207.value; }
+{ yygotominor.yy60 = (yymsp[-1].minor.yy60 & ~yymsp[0].minor.yy175.mask) | yymsp[0].minor.yy175.value; }
         break;
       case 73: /* refarg ::= MATCH nm */
       case 74: /* refarg ::= ON INSERT refact */
-{ yygotominor.yy207.value = 0;     yygotominor.yy207.mask = 0x000000; }
+{ yygotominor.yy175.value = 0;     yygotominor.yy175.mask = 0x000000; }
         break;
       case 75: /* refarg ::= ON DELETE refact */
-{ yygotominor.yy207.value = yymsp[0].minor.yy392;     yygotominor.yy207.mask = 0x0000ff; }
+{ yygotominor.yy175.value = yymsp[0].minor.yy60;     yygotominor.yy175.mask = 0x0000ff; }
         break;
       case 76: /* refarg ::= ON UPDATE refact */
-{ yygotominor.yy207.value = yymsp[0].minor.yy392<<8;  yygotominor.yy207.mask = 0x00ff00; }
+{ yygotominor.yy175.value = yymsp[0].minor.yy60<<8;  yygotominor.yy175.mask = 0x00ff00; }
         break;
       case 77: /* refact ::= SET NULL */
-{ yygotominor.yy392 = OE_SetNull;  /* EV: R-33326-45252 */}
+{ yygotominor.yy60 = OE_SetNull;  /* EV: R-33326-45252 */}
         break;
       case 78: /* refact ::= SET DEFAULT */
-{ yygotominor.yy392 = OE_SetDflt;  /* EV: R-33326-45252 */}
+{ yygotominor.yy60 = OE_SetDflt;  /* EV: R-33326-45252 */}
         break;
       case 79: /* refact ::= CASCADE */
-{ yygotominor.yy392 = OE_Cascade;  /* EV: R-33326-45252 */}
+{ yygotominor.yy60 = OE_Cascade;  /* EV: R-33326-45252 */}
         break;
       case 80: /* refact ::= RESTRICT */
-{ yygotominor.yy392 = OE_Restrict; /* EV: R-33326-45252 */}
+{ yygotominor.yy60 = OE_Restrict; /* EV: R-33326-45252 */}
         break;
       case 81: /* refact ::= NO ACTION */
-{ yygotominor.yy392 = OE_None;     /* EV: R-33326-45252 */}
+{ yygotominor.yy60 = OE_None;     /* EV: R-33326-45252 */}
         break;
       case 83: /* defer_subclause ::= DEFERRABLE init_deferred_pred_opt */
       case 99: /* defer_subclause_opt ::= defer_subclause */
       case 101: /* onconf ::= ON CONFLICT resolvetype */
       case 104: /* resolvetype ::= raisetype */
-{yygotominor.yy392 = yymsp[0].minor.yy392;}
+{yygotominor.yy60 = yymsp[0].minor.yy60;}
         break;
       case 87: /* conslist_opt ::= */
 {yygotominor.yy0.n = 0; yygotominor.yy0.z = 0;}
@@ -1822,474 +1946,512 @@ static void yy_reduce(
 {pParse.constraintName.n = 0;}
         break;
       case 94: /* tcons ::= PRIMARY KEY LP idxlist autoinc RP onconf */
-{sqlite3AddPrimaryKey(pParse,yymsp[-3].minor.yy442,yymsp[0].minor.yy392,yymsp[-2].minor.yy392,0);}
+{sqlite3AddPrimaryKey(pParse,yymsp[-3].minor.yy402,yymsp[0].minor.yy60,yymsp[-2].minor.yy60,0);}
         break;
       case 95: /* tcons ::= UNIQUE LP idxlist RP onconf */
-{sqlite3CreateIndex(pParse,0,0,0,yymsp[-2].minor.yy442,yymsp[0].minor.yy392,0,0,0,0);}
+{sqlite3CreateIndex(pParse,0,0,0,yymsp[-2].minor.yy402,yymsp[0].minor.yy60,0,0,0,0);}
         break;
       case 96: /* tcons ::= CHECK LP expr RP onconf */
-{sqlite3AddCheckConstraint(pParse,yymsp[-2].minor.yy342.Expr);}
+{sqlite3AddCheckConstraint(pParse,yymsp[-2].minor.yy214.Expr);}
         break;
       case 97: /* tcons ::= FOREIGN KEY LP idxlist RP REFERENCES nm idxlist_opt refargs defer_subclause_opt */
 {
-    sqlite3CreateForeignKey(pParse, yymsp[-6].minor.yy442, &yymsp[-3].minor.yy0, yymsp[-2].minor.yy442, yymsp[-1].minor.yy392);
-    sqlite3DeferForeignKey(pParse, yymsp[0].minor.yy392);
+    sqlite3CreateForeignKey(pParse, yymsp[-6].minor.yy402, &yymsp[-3].minor.yy0, yymsp[-2].minor.yy402, yymsp[-1].minor.yy60);
+    sqlite3DeferForeignKey(pParse, yymsp[0].minor.yy60);
 }
         break;
       case 100: /* onconf ::= */
-{yygotominor.yy392 = OE_Default;}
+{yygotominor.yy60 = OE_Default;}
         break;
       case 102: /* OnConflict ::= */
-{yygotominor.yy258 = OE_Default;}
+{yygotominor.yy274 = OE_Default;}
         break;
       case 103: /* OnConflict ::= OR resolvetype */
-{yygotominor.yy258 = (byte)yymsp[0].minor.yy392;}
+{yygotominor.yy274 = (byte)yymsp[0].minor.yy60;}
         break;
       case 105: /* resolvetype ::= IGNORE */
-{yygotominor.yy392 = OE_Ignore;}
+{yygotominor.yy60 = OE_Ignore;}
         break;
       case 106: /* resolvetype ::= REPLACE */
-{yygotominor.yy392 = OE_Replace;}
+{yygotominor.yy60 = OE_Replace;}
         break;
       case 107: /* cmd ::= DROP TABLE ifexists fullname */
 {
-  sqlite3DropTable(pParse, yymsp[0].minor.yy347, 0, yymsp[-1].minor.yy392);
+  sqlite3DropTable(pParse, yymsp[0].minor.yy291, 0, yymsp[-1].minor.yy60);
 }
         break;
       case 110: /* cmd ::= createkw temp VIEW ifnotexists nm dbnm AS select */
 {
-  sqlite3CreateView(pParse, &yymsp[-7].minor.yy0, &yymsp[-3].minor.yy0, &yymsp[-2].minor.yy0, yymsp[0].minor.yy159, yymsp[-6].minor.yy392, yymsp[-4].minor.yy392);
+  sqlite3CreateView(pParse, &yymsp[-7].minor.yy0, &yymsp[-3].minor.yy0, &yymsp[-2].minor.yy0, yymsp[0].minor.yy331, yymsp[-6].minor.yy60, yymsp[-4].minor.yy60);
 }
         break;
       case 111: /* cmd ::= DROP VIEW ifexists fullname */
 {
-  sqlite3DropTable(pParse, yymsp[0].minor.yy347, 1, yymsp[-1].minor.yy392);
+  sqlite3DropTable(pParse, yymsp[0].minor.yy291, 1, yymsp[-1].minor.yy60);
 }
         break;
       case 112: /* cmd ::= select */
 {
-  SelectDest dest = {SRT_Output, 0, 0, 0, 0};
-  sqlite3Select(pParse, yymsp[0].minor.yy159, &dest);
-  sqlite3SelectDelete(pParse.db, yymsp[0].minor.yy159);
+  if pParse.captureSelect {
+    //	ParseSelect() wants the parse tree rather than code.
+    pParse.pCapture = yymsp[0].minor.yy331
+  } else {
+    SelectDest dest = {SRT_Output, 0, 0, 0, 0};
+    sqlite3Select(pParse, yymsp[0].minor.yy331, &dest);
+    sqlite3SelectDelete(pParse.db, yymsp[0].minor.yy331);
+  }
+}
+        break;
+      case 113: /* select ::= with selectnowith */
+{
+  Select *p = yymsp[0].minor.yy331;
+  if( p ){
+    //	The WITH clause belongs to the left-most SELECT of the compound (see pushWith()).
+    for p.pPrior != nil {
+      p = p.pPrior
+    }
+    p.pWith = yymsp[-1].minor.yy227
+  }
+  yygotominor.yy331 = yymsp[0].minor.yy331;
 }
         break;
-      case 113: /* select ::= oneselect */
-{yygotominor.yy159 = yymsp[0].minor.yy159;}
+      case 114: /* selectnowith ::= oneselect */
+{yygotominor.yy331 = yymsp[0].minor.yy331;}
         break;
-      case 114: /* select ::= select multiselect_op oneselect */
+      case 115: /* selectnowith ::= selectnowith multiselect_op oneselect */
 {
-  if( yymsp[0].minor.yy159 ){
-    yymsp[0].minor.yy159.op = (byte)yymsp[-1].minor.yy392;
-    yymsp[0].minor.yy159.pPrior = yymsp[-2].minor.yy159;
+  if( yymsp[0].minor.yy331 ){
+    yymsp[0].minor.yy331.op = (byte)yymsp[-1].minor.yy60;
+    yymsp[0].minor.yy331.pPrior = yymsp[-2].minor.yy331;
   }else{
-    sqlite3SelectDelete(pParse.db, yymsp[-2].minor.yy159);
+    sqlite3SelectDelete(pParse.db, yymsp[-2].minor.yy331);
   }
-  yygotominor.yy159 = yymsp[0].minor.yy159;
+  yygotominor.yy331 = yymsp[0].minor.yy331;
 }
         break;
-      case 116: /* multiselect_op ::= UNION ALL */
-{yygotominor.yy392 = TK_ALL;}
+      case 117: /* multiselect_op ::= UNION ALL */
+{yygotominor.yy60 = TK_ALL;}
         break;
-      case 118: /* oneselect ::= SELECT distinct selcollist from where_opt groupby_opt having_opt orderby_opt limit_opt */
+      case 119: /* oneselect ::= SELECT distinct selcollist from where_opt groupby_opt having_opt orderby_opt limit_opt */
 {
-  yygotominor.yy159 = sqlite3SelectNew(pParse,yymsp[-6].minor.yy442,yymsp[-5].minor.yy347,yymsp[-4].minor.yy122,yymsp[-3].minor.yy442,yymsp[-2].minor.yy122,yymsp[-1].minor.yy442,yymsp[-7].minor.yy392,yymsp[0].minor.yy64.pLimit,yymsp[0].minor.yy64.pOffset);
+  yygotominor.yy331 = sqlite3SelectNew(pParse,yymsp[-6].minor.yy402,yymsp[-5].minor.yy291,yymsp[-4].minor.yy386,yymsp[-3].minor.yy402,yymsp[-2].minor.yy386,yymsp[-1].minor.yy402,yymsp[-7].minor.yy60,yymsp[0].minor.yy212.pLimit,yymsp[0].minor.yy212.pOffset);
+  pParse.attachWindows(yygotominor.yy331)
 }
         break;
-      case 122: /* sclp ::= selcollist COMMA */
-      case 246: /* idxlist_opt ::= LP idxlist RP */
-{yygotominor.yy442 = yymsp[-1].minor.yy442;}
+      case 123: /* sclp ::= selcollist COMMA */
+      case 248: /* idxlist_opt ::= LP idxlist RP */
+{yygotominor.yy402 = yymsp[-1].minor.yy402;}
         break;
-      case 123: /* sclp ::= */
-      case 151: /* orderby_opt ::= */
-      case 158: /* groupby_opt ::= */
-      case 239: /* exprlist ::= */
-      case 245: /* idxlist_opt ::= */
-{yygotominor.yy442 = 0;}
+      case 124: /* sclp ::= */
+      case 153: /* orderby_opt ::= */
+      case 160: /* groupby_opt ::= */
+      case 241: /* exprlist ::= */
+      case 247: /* idxlist_opt ::= */
+{yygotominor.yy402 = 0;}
         break;
-      case 124: /* selcollist ::= sclp expr as */
+      case 125: /* selcollist ::= sclp expr as */
 {
-   yygotominor.yy442 = append(yymsp[-2].minor.yy442, yymsp[-1].minor.yy342.Expr)
+   yygotominor.yy402 = append(yymsp[-2].minor.yy402, yymsp[-1].minor.yy214.Expr)
    if len(yymsp[0].minor.yy0) > 0 {
-	   yygotominor.yy442.SetName(&yymsp[0].minor.yy0, true)
+	   yygotominor.yy402.SetName(&yymsp[0].minor.yy0, true)
 	}
-   sqlite3ExprListSetSpan(pParse,yygotominor.yy442,&yymsp[-1].minor.yy342);
+   sqlite3ExprListSetSpan(pParse,yygotominor.yy402,&yymsp[-1].minor.yy214);
 }
         break;
-      case 125: /* selcollist ::= sclp STAR */
+      case 126: /* selcollist ::= sclp STAR */
 {
   Expr *p = pParse.db.Expr(TK_ALL, "")
-  yygotominor.yy442 = append(yymsp[-1].minor.yy442, p)
+  yygotominor.yy402 = append(yymsp[-1].minor.yy402, p)
 }
         break;
-      case 126: /* selcollist ::= sclp nm DOT STAR */
+      case 127: /* selcollist ::= sclp nm DOT STAR */
 {
   pRight := pParse.Expr(TK_ALL, nil, nil, &yymsp[0].minor.yy0)
   pLeft := pParse.Expr(TK_ID, nil, nil, &yymsp[-2].minor.yy0)
-  yygotominor.yy442 = append(yymsp[-3].minor.yy442, pParse.Expr(TK_DOT, pLeft, pRight, nil))
+  yygotominor.yy402 = append(yymsp[-3].minor.yy402, pParse.Expr(TK_DOT, pLeft, pRight, nil))
 }
         break;
-      case 129: /* as ::= */
+      case 130: /* as ::= */
 {yygotominor.yy0.n = 0;}
         break;
-      case 130: /* from ::= */
-{yygotominor.yy347 = sqlite3DbMallocZero(pParse.db, sizeof(*yygotominor.yy347));}
+      case 131: /* from ::= */
+{yygotominor.yy291 = sqlite3DbMallocZero(pParse.db, sizeof(*yygotominor.yy291));}
         break;
-      case 131: /* from ::= FROM seltablist */
+      case 132: /* from ::= FROM seltablist */
 {
-  yygotominor.yy347 = yymsp[0].minor.yy347;
-  sqlite3SrcListShiftJoinType(yygotominor.yy347);
+  yygotominor.yy291 = yymsp[0].minor.yy291;
+  sqlite3SrcListShiftJoinType(yygotominor.yy291);
 }
         break;
-      case 132: /* stl_prefix ::= seltablist joinop */
+      case 133: /* stl_prefix ::= seltablist joinop */
 {
-   yygotominor.yy347 = yymsp[-1].minor.yy347;
-   if( yygotominor.yy347 && yygotominor.yy347.nSrc>0 ) yygotominor.yy347.a[yygotominor.yy347.nSrc-1].jointype = (byte)yymsp[0].minor.yy392;
+   yygotominor.yy291 = yymsp[-1].minor.yy291;
+   if( yygotominor.yy291 && yygotominor.yy291.nSrc>0 ) yygotominor.yy291.a[yygotominor.yy291.nSrc-1].jointype = (byte)yymsp[0].minor.yy60;
 }
         break;
-      case 133: /* stl_prefix ::= */
-{yygotominor.yy347 = 0;}
+      case 134: /* stl_prefix ::= */
+{yygotominor.yy291 = 0;}
         break;
-      case 134: /* seltablist ::= stl_prefix nm dbnm as indexed_opt on_opt using_opt */
+      case 135: /* seltablist ::= stl_prefix nm dbnm as indexed_opt on_opt using_opt */
 {
-  yygotominor.yy347 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-6].minor.yy347,&yymsp[-5].minor.yy0,&yymsp[-4].minor.yy0,&yymsp[-3].minor.yy0,0,yymsp[-1].minor.yy122,yymsp[0].minor.yy180);
-  pParse.ListIndexedBy(yygotominor.yy347, &yymsp[-2].minor.yy0);
+  yygotominor.yy291 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-6].minor.yy291,&yymsp[-5].minor.yy0,&yymsp[-4].minor.yy0,&yymsp[-3].minor.yy0,0,yymsp[-1].minor.yy386,yymsp[0].minor.yy272);
+  pParse.ListIndexedBy(yygotominor.yy291, &yymsp[-2].minor.yy0);
 }
         break;
-      case 135: /* seltablist ::= stl_prefix LP select RP as on_opt using_opt */
+      case 136: /* seltablist ::= stl_prefix nm dbnm LP exprlist RP as on_opt using_opt */
 {
-    yygotominor.yy347 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-6].minor.yy347,0,0,&yymsp[-2].minor.yy0,yymsp[-4].minor.yy159,yymsp[-1].minor.yy122,yymsp[0].minor.yy180);
+  yygotominor.yy291 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-8].minor.yy291,&yymsp[-7].minor.yy0,&yymsp[-6].minor.yy0,&yymsp[-2].minor.yy0,0,yymsp[-1].minor.yy386,yymsp[0].minor.yy272);
+  pParse.SrcListFuncArgs(yygotominor.yy291, yymsp[-4].minor.yy402)
+}
+        break;
+      case 137: /* seltablist ::= stl_prefix LP select RP as on_opt using_opt */
+{
+    yygotominor.yy291 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-6].minor.yy291,0,0,&yymsp[-2].minor.yy0,yymsp[-4].minor.yy331,yymsp[-1].minor.yy386,yymsp[0].minor.yy272);
   }
         break;
-      case 136: /* seltablist ::= stl_prefix LP seltablist RP as on_opt using_opt */
+      case 138: /* seltablist ::= stl_prefix LP seltablist RP as on_opt using_opt */
 {
-    if( yymsp[-6].minor.yy347==0 && yymsp[-2].minor.yy0.n==0 && yymsp[-1].minor.yy122==0 && yymsp[0].minor.yy180==0 ){
-      yygotominor.yy347 = yymsp[-4].minor.yy347;
+    if( yymsp[-6].minor.yy291==0 && yymsp[-2].minor.yy0.n==0 && yymsp[-1].minor.yy386==0 && yymsp[0].minor.yy272==0 ){
+      yygotominor.yy291 = yymsp[-4].minor.yy291;
     }else{
       Select *pSubquery;
-      sqlite3SrcListShiftJoinType(yymsp[-4].minor.yy347);
-      pSubquery = sqlite3SelectNew(pParse,0,yymsp[-4].minor.yy347,0,0,0,0,0,0,0);
-      yygotominor.yy347 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-6].minor.yy347,0,0,&yymsp[-2].minor.yy0,pSubquery,yymsp[-1].minor.yy122,yymsp[0].minor.yy180);
+      sqlite3SrcListShiftJoinType(yymsp[-4].minor.yy291);
+      pSubquery = sqlite3SelectNew(pParse,0,yymsp[-4].minor.yy291,0,0,0,0,0,0,0);
+      yygotominor.yy291 = sqlite3SrcListAppendFromTerm(pParse,yymsp[-6].minor.yy291,0,0,&yymsp[-2].minor.yy0,pSubquery,yymsp[-1].minor.yy386,yymsp[0].minor.yy272);
     }
   }
         break;
-      case 137: /* dbnm ::= */
-      case 146: /* indexed_opt ::= */
+      case 139: /* dbnm ::= */
+      case 148: /* indexed_opt ::= */
 {yygotominor.yy0.z=0; yygotominor.yy0.n=0;}
         break;
-      case 139: /* fullname ::= nm dbnm */
-{yygotominor.yy347 = pParse.db.SrcListAppend(nil, &yymsp[-1].minor.yy0, &yymsp[0].minor.yy0);}
+      case 141: /* fullname ::= nm dbnm */
+{yygotominor.yy291 = pParse.db.SrcListAppend(nil, &yymsp[-1].minor.yy0, &yymsp[0].minor.yy0);}
         break;
-      case 140: /* joinop ::= COMMA|JOIN */
-{ yygotominor.yy392 = JT_INNER; }
+      case 142: /* joinop ::= COMMA|JOIN */
+{ yygotominor.yy60 = JT_INNER; }
         break;
-      case 141: /* joinop ::= JOIN_KW JOIN */
-{ yygotominor.yy392 = sqlite3JoinType(pParse,&yymsp[-1].minor.yy0,0,0); }
+      case 143: /* joinop ::= JOIN_KW JOIN */
+{ yygotominor.yy60 = sqlite3JoinType(pParse,&yymsp[-1].minor.yy0,0,0); }
         break;
-      case 142: /* joinop ::= JOIN_KW nm JOIN */
-{ yygotominor.yy392 = sqlite3JoinType(pParse,&yymsp[-2].minor.yy0,&yymsp[-1].minor.yy0,0); }
+      case 144: /* joinop ::= JOIN_KW nm JOIN */
+{ yygotominor.yy60 = sqlite3JoinType(pParse,&yymsp[-2].minor.yy0,&yymsp[-1].minor.yy0,0); }
         break;
-      case 143: /* joinop ::= JOIN_KW nm nm JOIN */
-{ yygotominor.yy392 = sqlite3JoinType(pParse,&yymsp[-3].minor.yy0,&yymsp[-2].minor.yy0,&yymsp[-1].minor.yy0); }
+      case 145: /* joinop ::= JOIN_KW nm nm JOIN */
+{ yygotominor.yy60 = sqlite3JoinType(pParse,&yymsp[-3].minor.yy0,&yymsp[-2].minor.yy0,&yymsp[-1].minor.yy0); }
         break;
-      case 144: /* on_opt ::= ON expr */
-      case 161: /* having_opt ::= HAVING expr */
-      case 168: /* where_opt ::= WHERE expr */
-      case 234: /* case_else ::= ELSE expr */
-      case 236: /* case_operand ::= expr */
-{yygotominor.yy122 = yymsp[0].minor.yy342.Expr;}
+      case 146: /* on_opt ::= ON expr */
+      case 163: /* having_opt ::= HAVING expr */
+      case 170: /* where_opt ::= WHERE expr */
+      case 236: /* case_else ::= ELSE expr */
+      case 238: /* case_operand ::= expr */
+{yygotominor.yy386 = yymsp[0].minor.yy214.Expr;}
         break;
-      case 145: /* on_opt ::= */
-      case 160: /* having_opt ::= */
-      case 167: /* where_opt ::= */
-      case 235: /* case_else ::= */
-      case 237: /* case_operand ::= */
-{yygotominor.yy122 = 0;}
+      case 147: /* on_opt ::= */
+      case 162: /* having_opt ::= */
+      case 169: /* where_opt ::= */
+      case 237: /* case_else ::= */
+      case 239: /* case_operand ::= */
+{yygotominor.yy386 = 0;}
         break;
-      case 148: /* indexed_opt ::= NOT INDEXED */
+      case 150: /* indexed_opt ::= NOT INDEXED */
 {yygotominor.yy0.z=0; yygotominor.yy0.n=1;}
         break;
-      case 149: /* using_opt ::= USING LP inscollist RP */
-      case 180: /* inscollist_opt ::= LP inscollist RP */
-{yygotominor.yy180 = yymsp[-1].minor.yy180;}
+      case 151: /* using_opt ::= USING LP inscollist RP */
+      case 182: /* inscollist_opt ::= LP inscollist RP */
+{yygotominor.yy272 = yymsp[-1].minor.yy272;}
         break;
-      case 150: /* using_opt ::= */
-      case 179: /* inscollist_opt ::= */
-{yygotominor.yy180 = 0;}
+      case 152: /* using_opt ::= */
+      case 181: /* inscollist_opt ::= */
+{yygotominor.yy272 = 0;}
         break;
-      case 152: /* orderby_opt ::= ORDER BY sortlist */
-      case 159: /* groupby_opt ::= GROUP BY nexprlist */
-      case 238: /* exprlist ::= nexprlist */
-	  	yygotominor.yy442 = yymsp[0].minor.yy442
+      case 154: /* orderby_opt ::= ORDER BY sortlist */
+      case 161: /* groupby_opt ::= GROUP BY nexprlist */
+      case 240: /* exprlist ::= nexprlist */
+	  	yygotominor.yy402 = yymsp[0].minor.yy402
 
-      case 153: /* sortlist ::= sortlist COMMA expr sortorder */
-		yygotominor.yy442 = append(yymsp[-3].minor.yy442, yymsp[-1].minor.yy342.Expr)
-		if yygotominor.yy442 != nil {
-			yygotominor.yy442.Last().sortOrder = byte(yymsp[0].minor.yy392)
+      case 155: /* sortlist ::= sortlist COMMA expr sortorder */
+		yygotominor.yy402 = append(yymsp[-3].minor.yy402, yymsp[-1].minor.yy214.Expr)
+		if yygotominor.yy402 != nil {
+			yygotominor.yy402.Last().sortOrder = byte(yymsp[0].minor.yy60)
 		}
 
-      case 154: /* sortlist ::= expr sortorder */
-		yygotominor.yy442 = NewExprList(yymsp[-1].minor.yy342.Expr)
-		if yygotominor.yy442 != nil && yygotominor.yy442.Len() > 0 {
-			yygotominor.yy442.a[0].sortOrder = byte(yymsp[0].minor.yy392)
+      case 156: /* sortlist ::= expr sortorder */
+		yygotominor.yy402 = NewExprList(yymsp[-1].minor.yy214.Expr)
+		if yygotominor.yy402 != nil && yygotominor.yy402.Len() > 0 {
+			yygotominor.yy402.a[0].sortOrder = byte(yymsp[0].minor.yy60)
 		}
 
-      case 155: /* sortorder ::= ASC */
-      case 157: /* sortorder ::= */
-{yygotominor.yy392 = SQLITE_SO_ASC;}
+      case 157: /* sortorder ::= ASC */
+      case 159: /* sortorder ::= */
+{yygotominor.yy60 = SQLITE_SO_ASC;}
         break;
-      case 156: /* sortorder ::= DESC */
-{yygotominor.yy392 = SQLITE_SO_DESC;}
+      case 158: /* sortorder ::= DESC */
+{yygotominor.yy60 = SQLITE_SO_DESC;}
         break;
-      case 162: /* limit_opt ::= */
-{yygotominor.yy64.pLimit = 0; yygotominor.yy64.pOffset = 0;}
+      case 164: /* limit_opt ::= */
+{yygotominor.yy212.pLimit = 0; yygotominor.yy212.pOffset = 0;}
         break;
-      case 163: /* limit_opt ::= LIMIT expr */
-{yygotominor.yy64.pLimit = yymsp[0].minor.yy342.Expr; yygotominor.yy64.pOffset = 0;}
+      case 165: /* limit_opt ::= LIMIT expr */
+{yygotominor.yy212.pLimit = yymsp[0].minor.yy214.Expr; yygotominor.yy212.pOffset = 0;}
         break;
-      case 164: /* limit_opt ::= LIMIT expr OFFSET expr */
-{yygotominor.yy64.pLimit = yymsp[-2].minor.yy342.Expr; yygotominor.yy64.pOffset = yymsp[0].minor.yy342.Expr;}
+      case 166: /* limit_opt ::= LIMIT expr OFFSET expr */
+{yygotominor.yy212.pLimit = yymsp[-2].minor.yy214.Expr; yygotominor.yy212.pOffset = yymsp[0].minor.yy214.Expr;}
         break;
-      case 165: /* limit_opt ::= LIMIT expr COMMA expr */
-{yygotominor.yy64.pOffset = yymsp[-2].minor.yy342.Expr; yygotominor.yy64.pLimit = yymsp[0].minor.yy342.Expr;}
+      case 167: /* limit_opt ::= LIMIT expr COMMA expr */
+{yygotominor.yy212.pOffset = yymsp[-2].minor.yy214.Expr; yygotominor.yy212.pLimit = yymsp[0].minor.yy214.Expr;}
         break;
-      case 166: /* cmd ::= DELETE FROM fullname indexed_opt where_opt */
+      case 168: /* cmd ::= with DELETE FROM fullname indexed_opt where_opt */
 {
-  pParse.ListIndexedBy(yymsp[-2].minor.yy347, &yymsp[-1].minor.yy0);
-  sqlite3DeleteFrom(pParse,yymsp[-2].minor.yy347,yymsp[0].minor.yy122);
+  pParse.pWith = yymsp[-5].minor.yy227
+  pParse.ListIndexedBy(yymsp[-2].minor.yy291, &yymsp[-1].minor.yy0);
+  sqlite3DeleteFrom(pParse,yymsp[-2].minor.yy291,yymsp[0].minor.yy386);
 }
         break;
-      case 169: /* cmd ::= UPDATE OnConflict fullname indexed_opt SET setlist where_opt */
+      case 171: /* cmd ::= with UPDATE OnConflict fullname indexed_opt SET setlist where_opt */
 {
-  pParse.ListIndexedBy(yymsp[-4].minor.yy347, &yymsp[-3].minor.yy0);
-  sqlite3Update(pParse,yymsp[-4].minor.yy347,yymsp[-1].minor.yy442,yymsp[0].minor.yy122,yymsp[-5].minor.yy258);
+  pParse.pWith = yymsp[-7].minor.yy227
+  pParse.ListIndexedBy(yymsp[-4].minor.yy291, &yymsp[-3].minor.yy0);
+  sqlite3Update(pParse,yymsp[-4].minor.yy291,yymsp[-1].minor.yy402,yymsp[0].minor.yy386,yymsp[-5].minor.yy274);
 }
         break;
-      case 170: /* setlist ::= setlist COMMA nm EQ expr */
+      case 172: /* setlist ::= setlist COMMA nm EQ expr */
 {
-  yygotominor.yy442 = append(yymsp[-4].minor.yy442, yymsp[0].minor.yy342.Expr)
-  yygotominor.yy442.SetName(&yymsp[-2].minor.yy0, true)
+  yygotominor.yy402 = append(yymsp[-4].minor.yy402, yymsp[0].minor.yy214.Expr)
+  yygotominor.yy402.SetName(&yymsp[-2].minor.yy0, true)
 }
         break;
-      case 171: /* setlist ::= nm EQ expr */
+      case 173: /* setlist ::= nm EQ expr */
 {
-  yygotominor.yy442 = NewExprList(yymsp[0].minor.yy342.Expr)
-  yygotominor.yy442.SetName(&yymsp[-2].minor.yy0, true)
+  yygotominor.yy402 = NewExprList(yymsp[0].minor.yy214.Expr)
+  yygotominor.yy402.SetName(&yymsp[-2].minor.yy0, true)
 }
         break;
-      case 172: /* cmd ::= insert_cmd INTO fullname inscollist_opt valuelist */
-{sqlite3Insert(pParse, yymsp[-2].minor.yy347, yymsp[0].minor.yy487.pList, yymsp[0].minor.yy487.Select, yymsp[-1].minor.yy180, yymsp[-4].minor.yy258);}
+      case 174: /* cmd ::= with insert_cmd INTO fullname inscollist_opt valuelist */
+{
+  pParse.pWith = yymsp[-5].minor.yy227
+  sqlite3Insert(pParse, yymsp[-2].minor.yy291, yymsp[0].minor.yy211.pList, yymsp[0].minor.yy211.Select, yymsp[-1].minor.yy272, yymsp[-4].minor.yy274);
+}
         break;
-      case 173: /* cmd ::= insert_cmd INTO fullname inscollist_opt select */
-{sqlite3Insert(pParse, yymsp[-2].minor.yy347, 0, yymsp[0].minor.yy159, yymsp[-1].minor.yy180, yymsp[-4].minor.yy258);}
+      case 175: /* cmd ::= with insert_cmd INTO fullname inscollist_opt select */
+{
+  pParse.pWith = yymsp[-5].minor.yy227
+  sqlite3Insert(pParse, yymsp[-2].minor.yy291, 0, yymsp[0].minor.yy331, yymsp[-1].minor.yy272, yymsp[-4].minor.yy274);
+}
         break;
-      case 174: /* cmd ::= insert_cmd INTO fullname inscollist_opt DEFAULT VALUES */
-{sqlite3Insert(pParse, yymsp[-3].minor.yy347, 0, 0, yymsp[-2].minor.yy180, yymsp[-5].minor.yy258);}
+      case 176: /* cmd ::= with insert_cmd INTO fullname inscollist_opt DEFAULT VALUES */
+{
+  pParse.pWith = yymsp[-6].minor.yy227
+  sqlite3Insert(pParse, yymsp[-3].minor.yy291, 0, 0, yymsp[-2].minor.yy272, yymsp[-5].minor.yy274);
+}
         break;
-      case 175: /* insert_cmd ::= INSERT OnConflict */
-{yygotominor.yy258 = yymsp[0].minor.yy258;}
+      case 177: /* insert_cmd ::= INSERT OnConflict */
+{yygotominor.yy274 = yymsp[0].minor.yy274;}
         break;
-      case 176: /* insert_cmd ::= REPLACE */
-{yygotominor.yy258 = OE_Replace;}
+      case 178: /* insert_cmd ::= REPLACE */
+{yygotominor.yy274 = OE_Replace;}
         break;
-      case 177: /* valuelist ::= VALUES LP nexprlist RP */
+      case 179: /* valuelist ::= VALUES LP nexprlist RP */
 {
-  yygotominor.yy487.pList = yymsp[-1].minor.yy442;
-  yygotominor.yy487.Select = 0;
+  yygotominor.yy211.pList = yymsp[-1].minor.yy402;
+  yygotominor.yy211.Select = 0;
 }
         break;
-      case 178: /* valuelist ::= valuelist COMMA LP exprlist RP */
+      case 180: /* valuelist ::= valuelist COMMA LP exprlist RP */
 {
-  Select *pRight = sqlite3SelectNew(pParse, yymsp[-1].minor.yy442, 0, 0, 0, 0, 0, 0, 0, 0);
-  if( yymsp[-4].minor.yy487.pList ){
-    yymsp[-4].minor.yy487.Select = sqlite3SelectNew(pParse, yymsp[-4].minor.yy487.pList, 0, 0, 0, 0, 0, 0, 0, 0);
-    yymsp[-4].minor.yy487.pList = 0;
+  Select *pRight = sqlite3SelectNew(pParse, yymsp[-1].minor.yy402, 0, 0, 0, 0, 0, 0, 0, 0);
+  if( yymsp[-4].minor.yy211.pList ){
+    yymsp[-4].minor.yy211.Select = sqlite3SelectNew(pParse, yymsp[-4].minor.yy211.pList, 0, 0, 0, 0, 0, 0, 0, 0);
+    yymsp[-4].minor.yy211.pList = 0;
   }
-  yygotominor.yy487.pList = 0;
-  if( yymsp[-4].minor.yy487.Select==0 || pRight==0 ){
+  yygotominor.yy211.pList = 0;
+  if( yymsp[-4].minor.yy211.Select==0 || pRight==0 ){
     sqlite3SelectDelete(pParse.db, pRight);
-    sqlite3SelectDelete(pParse.db, yymsp[-4].minor.yy487.Select);
-    yygotominor.yy487.Select = 0;
+    sqlite3SelectDelete(pParse.db, yymsp[-4].minor.yy211.Select);
+    yygotominor.yy211.Select = 0;
   }else{
     pRight.op = TK_ALL;
-    pRight.pPrior = yymsp[-4].minor.yy487.Select;
+    pRight.pPrior = yymsp[-4].minor.yy211.Select;
     pRight.selFlags |= SF_Values;
     pRight.pPrior.selFlags |= SF_Values;
-    yygotominor.yy487.Select = pRight;
+    yygotominor.yy211.Select = pRight;
   }
 }
         break;
-      case 181: /* inscollist ::= inscollist COMMA nm */
-{yygotominor.yy180 = sqlite3IdListAppend(pParse.db,yymsp[-2].minor.yy180,&yymsp[0].minor.yy0);}
+      case 183: /* inscollist ::= inscollist COMMA nm */
+{yygotominor.yy272 = sqlite3IdListAppend(pParse.db,yymsp[-2].minor.yy272,&yymsp[0].minor.yy0);}
         break;
-      case 182: /* inscollist ::= nm */
-{yygotominor.yy180 = sqlite3IdListAppend(pParse.db,0,&yymsp[0].minor.yy0);}
+      case 184: /* inscollist ::= nm */
+{yygotominor.yy272 = sqlite3IdListAppend(pParse.db,0,&yymsp[0].minor.yy0);}
         break;
-      case 183: /* expr ::= term */
-{yygotominor.yy342 = yymsp[0].minor.yy342;}
+      case 185: /* expr ::= term */
+{yygotominor.yy214 = yymsp[0].minor.yy214;}
         break;
-      case 184: /* expr ::= LP expr RP */
-{yygotominor.yy342.Expr = yymsp[-1].minor.yy342.Expr; spanSet(&yygotominor.yy342,&yymsp[-2].minor.yy0,&yymsp[0].minor.yy0);}
+      case 186: /* expr ::= LP expr RP */
+{yygotominor.yy214.Expr = yymsp[-1].minor.yy214.Expr; spanSet(&yygotominor.yy214,&yymsp[-2].minor.yy0,&yymsp[0].minor.yy0);}
         break;
-      case 185: /* term ::= NULL */
-      case 190: /* term ::= INTEGER|FLOAT|BLOB */
-      case 191: /* term ::= STRING */
-{spanExpr(&yygotominor.yy342, pParse, yymsp[0].major, &yymsp[0].minor.yy0);}
+      case 187: /* term ::= NULL */
+      case 192: /* term ::= INTEGER|FLOAT|BLOB */
+      case 193: /* term ::= STRING */
+{spanExpr(&yygotominor.yy214, pParse, yymsp[0].major, &yymsp[0].minor.yy0);}
         break;
-      case 186: /* expr ::= id */
-      case 187: /* expr ::= JOIN_KW */
-{spanExpr(&yygotominor.yy342, pParse, TK_ID, &yymsp[0].minor.yy0);}
+      case 188: /* expr ::= id */
+      case 189: /* expr ::= JOIN_KW */
+{spanExpr(&yygotominor.yy214, pParse, TK_ID, &yymsp[0].minor.yy0);}
         break;
-      case 188: /* expr ::= nm DOT nm */
+      case 190: /* expr ::= nm DOT nm */
 {
   Expr *temp1 = pParse.Expr(TK_ID, nil, nil, &yymsp[-2].minor.yy0)
   Expr *temp2 = pParse.Expr(TK_ID, nil, nil, &yymsp[0].minor.yy0)
-  yygotominor.yy342.Expr = pParse.Expr(TK_DOT, temp1, temp2, "")
-  spanSet(&yygotominor.yy342,&yymsp[-2].minor.yy0,&yymsp[0].minor.yy0);
+  yygotominor.yy214.Expr = pParse.Expr(TK_DOT, temp1, temp2, "")
+  spanSet(&yygotominor.yy214,&yymsp[-2].minor.yy0,&yymsp[0].minor.yy0);
 }
         break;
-      case 189: /* expr ::= nm DOT nm DOT nm */
+      case 191: /* expr ::= nm DOT nm DOT nm */
 {
   Expr *temp1 = pParse.Expr(TK_ID, nil, nil, &yymsp[-4].minor.yy0)
   Expr *temp2 = pParse.Expr(TK_ID, nil, nil, &yymsp[-2].minor.yy0)
   Expr *temp3 = pParse.Expr(TK_ID, nil, nil, &yymsp[0].minor.yy0)
   Expr *temp4 = pParse.Expr(TK_DOT, temp2, temp3, "")
-  yygotominor.yy342.Expr = pParse.Expr(TK_DOT, temp1, temp4, "")
-  spanSet(&yygotominor.yy342,&yymsp[-4].minor.yy0,&yymsp[0].minor.yy0);
+  yygotominor.yy214.Expr = pParse.Expr(TK_DOT, temp1, temp4, "")
+  spanSet(&yygotominor.yy214,&yymsp[-4].minor.yy0,&yymsp[0].minor.yy0);
 }
         break;
-      case 192: /* expr ::= REGISTER */
+      case 194: /* expr ::= REGISTER */
 {
   /* When doing a nested parse, one can include terms in an expression
   ** that look like this:   #1 #2 ...  These terms refer to registers
   ** in the virtual machine.  #N is the N-th register. */
   if( pParse.nested==0 ){
     pParse.SetErrorMsg("near \"%v\": syntax error", &yymsp[0].minor.yy0);
-    yygotominor.yy342.Expr = 0;
+    yygotominor.yy214.Expr = 0;
   }else{
-    yygotominor.yy342.Expr = pParse.Expr(TK_REGISTER, nil, nil, &yymsp[0].minor.yy0);
-    if( yygotominor.yy342.Expr ) sqlite3GetInt32(&yymsp[0].minor.yy0.z[1], &yygotominor.yy342.Expr.iTable);
+    yygotominor.yy214.Expr = pParse.Expr(TK_REGISTER, nil, nil, &yymsp[0].minor.yy0);
+    if( yygotominor.yy214.Expr ) sqlite3GetInt32(&yymsp[0].minor.yy0.z[1], &yygotominor.yy214.Expr.iTable);
   }
-  spanSet(&yygotominor.yy342, &yymsp[0].minor.yy0, &yymsp[0].minor.yy0);
+  spanSet(&yygotominor.yy214, &yymsp[0].minor.yy0, &yymsp[0].minor.yy0);
 }
         break;
-      case 193: /* expr ::= VARIABLE */
+      case 195: /* expr ::= VARIABLE */
 {
-  spanExpr(&yygotominor.yy342, pParse, TK_VARIABLE, &yymsp[0].minor.yy0);
-  sqlite3ExprAssignVarNumber(pParse, yygotominor.yy342.Expr);
-  spanSet(&yygotominor.yy342, &yymsp[0].minor.yy0, &yymsp[0].minor.yy0);
+  spanExpr(&yygotominor.yy214, pParse, TK_VARIABLE, &yymsp[0].minor.yy0);
+  sqlite3ExprAssignVarNumber(pParse, yygotominor.yy214.Expr);
+  spanSet(&yygotominor.yy214, &yymsp[0].minor.yy0, &yymsp[0].minor.yy0);
 }
         break;
-      case 194: /* expr ::= expr COLLATE ids */
-		yygotominor.yy342.Expr = sqlite3ExprSetCollByToken(pParse, yymsp[-2].minor.yy342.Expr, &yymsp[0].minor.yy0)
-		yygotominor.yy342.zStart = yymsp[-2].minor.yy342.zStart
-		yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n]
+      case 196: /* expr ::= expr COLLATE ids */
+		yygotominor.yy214.Expr = sqlite3ExprSetCollByToken(pParse, yymsp[-2].minor.yy214.Expr, &yymsp[0].minor.yy0)
+		yygotominor.yy214.zStart = yymsp[-2].minor.yy214.zStart
+		yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n]
 
-      case 195: /* expr ::= CAST LP expr AS typetoken RP */
-		yygotominor.yy342.Expr = pParse.Expr(TK_CAST, yymsp[-3].minor.yy342.Expr, nil, &yymsp[-1].minor.yy0)
-		spanSet(&yygotominor.yy342,&yymsp[-5].minor.yy0,&yymsp[0].minor.yy0)
+      case 197: /* expr ::= CAST LP expr AS typetoken RP */
+		yygotominor.yy214.Expr = pParse.Expr(TK_CAST, yymsp[-3].minor.yy214.Expr, nil, &yymsp[-1].minor.yy0)
+		spanSet(&yygotominor.yy214,&yymsp[-5].minor.yy0,&yymsp[0].minor.yy0)
 
-      case 196: /* expr ::= ID LP distinct exprlist RP */
-		yygotominor.yy342.Expr = pParse.ExprFunction(yymsp[-1].minor.yy442, &yymsp[-4].minor.yy0)
-		spanSet(&yygotominor.yy342,&yymsp[-4].minor.yy0,&yymsp[0].minor.yy0)
-		if yymsp[-2].minor.yy392 && yygotominor.yy342.Expr {
-			yygotominor.yy342.Expr.flags |= EP_Distinct
+      case 198: /* expr ::= ID LP distinct exprlist RP */
+		yygotominor.yy214.Expr = pParse.ExprFunction(yymsp[-1].minor.yy402, &yymsp[-4].minor.yy0)
+		spanSet(&yygotominor.yy214,&yymsp[-4].minor.yy0,&yymsp[0].minor.yy0)
+		if yymsp[-2].minor.yy60 && yygotominor.yy214.Expr {
+			yygotominor.yy214.Expr.flags |= EP_Distinct
 		}
+		pParse.attachOver(&yygotominor.yy214)
 
-      case 197: /* expr ::= ID LP STAR RP */
-		yygotominor.yy342.Expr = pParse.ExprFunction(nil, &yymsp[-3].minor.yy0)
-		spanSet(&yygotominor.yy342,&yymsp[-3].minor.yy0,&yymsp[0].minor.yy0)
+      case 199: /* expr ::= ID LP STAR RP */
+		yygotominor.yy214.Expr = pParse.ExprFunction(nil, &yymsp[-3].minor.yy0)
+		spanSet(&yygotominor.yy214,&yymsp[-3].minor.yy0,&yymsp[0].minor.yy0)
+		pParse.attachOver(&yygotominor.yy214)
 
-      case 198: /* term ::= CTIME_KW */
+      case 200: /* term ::= CTIME_KW */
 		//	The CURRENT_TIME, CURRENT_DATE, and CURRENT_TIMESTAMP values are treated as functions that return constants
-		yygotominor.yy342.Expr = pParse.ExprFunction(nil, &yymsp[0].minor.yy0)
-		if yygotominor.yy342.Expr {
-			yygotominor.yy342.Expr.op = TK_CONST_FUNC
+		yygotominor.yy214.Expr = pParse.ExprFunction(nil, &yymsp[0].minor.yy0)
+		if yygotominor.yy214.Expr {
+			yygotominor.yy214.Expr.op = TK_CONST_FUNC
 		}
-		spanSet(&yygotominor.yy342, &yymsp[0].minor.yy0, &yymsp[0].minor.yy0)
+		spanSet(&yygotominor.yy214, &yymsp[0].minor.yy0, &yymsp[0].minor.yy0)
 
-      case 199: /* expr ::= expr AND expr */
-      case 200: /* expr ::= expr OR expr */
-      case 201: /* expr ::= expr LT|GT|GE|LE expr */
-      case 202: /* expr ::= expr EQ|NE expr */
-      case 203: /* expr ::= expr BITAND|BITOR|LSHIFT|RSHIFT expr */
-      case 204: /* expr ::= expr PLUS|MINUS expr */
-      case 205: /* expr ::= expr STAR|SLASH|REM expr */
-      case 206: /* expr ::= expr CONCAT expr */
-{spanBinaryExpr(&yygotominor.yy342,pParse,yymsp[-1].major,&yymsp[-2].minor.yy342,&yymsp[0].minor.yy342);}
+      case 201: /* expr ::= expr AND expr */
+      case 202: /* expr ::= expr OR expr */
+      case 203: /* expr ::= expr LT|GT|GE|LE expr */
+      case 204: /* expr ::= expr EQ|NE expr */
+      case 205: /* expr ::= expr BITAND|BITOR|LSHIFT|RSHIFT expr */
+      case 206: /* expr ::= expr PLUS|MINUS expr */
+      case 207: /* expr ::= expr STAR|SLASH|REM expr */
+      case 208: /* expr ::= expr CONCAT expr */
+{spanBinaryExpr(&yygotominor.yy214,pParse,yymsp[-1].major,&yymsp[-2].minor.yy214,&yymsp[0].minor.yy214);}
         break;
-      case 207: /* likeop ::= LIKE_KW */
-      case 209: /* likeop ::= MATCH */
-{yygotominor.yy318.eOperator = yymsp[0].minor.yy0; yygotominor.yy318.bNot = 0;}
+      case 209: /* likeop ::= LIKE_KW */
+      case 211: /* likeop ::= MATCH */
+{yygotominor.yy54.eOperator = yymsp[0].minor.yy0; yygotominor.yy54.bNot = 0;}
         break;
-      case 208: /* likeop ::= NOT LIKE_KW */
-      case 210: /* likeop ::= NOT MATCH */
-{yygotominor.yy318.eOperator = yymsp[0].minor.yy0; yygotominor.yy318.bNot = 1;}
+      case 210: /* likeop ::= NOT LIKE_KW */
+      case 212: /* likeop ::= NOT MATCH */
+{yygotominor.yy54.eOperator = yymsp[0].minor.yy0; yygotominor.yy54.bNot = 1;}
         break;
-      case 211: /* expr ::= expr likeop expr */
+      case 213: /* expr ::= expr likeop expr */
 {
-  pList := NewExprList(yymsp[0].minor.yy342.Expr, yymsp[-2].minor.yy342.Expr)
-  yygotominor.yy342.Expr = pParse.ExprFunction(pList, &yymsp[-1].minor.yy318.eOperator)
-  if( yymsp[-1].minor.yy318.bNot ) yygotominor.yy342.Expr = pParse.Expr(TK_NOT, yygotominor.yy342.Expr, nil, "")
-  yygotominor.yy342.zStart = yymsp[-2].minor.yy342.zStart;
-  yygotominor.yy342.zEnd = yymsp[0].minor.yy342.zEnd;
-  if( yygotominor.yy342.Expr ) yygotominor.yy342.Expr.flags |= EP_InfixFunc;
+  pList := NewExprList(yymsp[0].minor.yy214.Expr, yymsp[-2].minor.yy214.Expr)
+  yygotominor.yy214.Expr = pParse.ExprFunction(pList, &yymsp[-1].minor.yy54.eOperator)
+  if( yymsp[-1].minor.yy54.bNot ) yygotominor.yy214.Expr = pParse.Expr(TK_NOT, yygotominor.yy214.Expr, nil, "")
+  yygotominor.yy214.zStart = yymsp[-2].minor.yy214.zStart;
+  yygotominor.yy214.zEnd = yymsp[0].minor.yy214.zEnd;
+  if( yygotominor.yy214.Expr ) yygotominor.yy214.Expr.flags |= EP_InfixFunc;
 }
         break;
-      case 212: /* expr ::= expr likeop expr ESCAPE expr */
+      case 214: /* expr ::= expr likeop expr ESCAPE expr */
 {
-  pList := NewExprList(yymsp[-2].minor.yy342.Expr, yymsp[-4].minor.yy342.Expr, yymsp[0].minor.yy342.Expr)
-  yygotominor.yy342.Expr = pParse.ExprFunction(pList, &yymsp[-3].minor.yy318.eOperator)
-  if( yymsp[-3].minor.yy318.bNot ) yygotominor.yy342.Expr = pParse.Expr(TK_NOT, yygotominor.yy342.Expr, nil, "")
-  yygotominor.yy342.zStart = yymsp[-4].minor.yy342.zStart;
-  yygotominor.yy342.zEnd = yymsp[0].minor.yy342.zEnd;
-  if( yygotominor.yy342.Expr ) yygotominor.yy342.Expr.flags |= EP_InfixFunc;
+  pList := NewExprList(yymsp[-2].minor.yy214.Expr, yymsp[-4].minor.yy214.Expr, yymsp[0].minor.yy214.Expr)
+  yygotominor.yy214.Expr = pParse.ExprFunction(pList, &yymsp[-3].minor.yy54.eOperator)
+  if( yymsp[-3].minor.yy54.bNot ) yygotominor.yy214.Expr = pParse.Expr(TK_NOT, yygotominor.yy214.Expr, nil, "")
+  yygotominor.yy214.zStart = yymsp[-4].minor.yy214.zStart;
+  yygotominor.yy214.zEnd = yymsp[0].minor.yy214.zEnd;
+  if( yygotominor.yy214.Expr ) yygotominor.yy214.Expr.flags |= EP_InfixFunc;
 }
         break;
-      case 213: /* expr ::= expr ISNULL|NOTNULL */
-{spanUnaryPostfix(&yygotominor.yy342,pParse,yymsp[0].major,&yymsp[-1].minor.yy342,&yymsp[0].minor.yy0);}
+      case 215: /* expr ::= expr ISNULL|NOTNULL */
+{spanUnaryPostfix(&yygotominor.yy214,pParse,yymsp[0].major,&yymsp[-1].minor.yy214,&yymsp[0].minor.yy0);}
         break;
-      case 214: /* expr ::= expr NOT NULL */
-{spanUnaryPostfix(&yygotominor.yy342,pParse,TK_NOTNULL,&yymsp[-2].minor.yy342,&yymsp[0].minor.yy0);}
+      case 216: /* expr ::= expr NOT NULL */
+{spanUnaryPostfix(&yygotominor.yy214,pParse,TK_NOTNULL,&yymsp[-2].minor.yy214,&yymsp[0].minor.yy0);}
         break;
-      case 215: /* expr ::= expr IS expr */
+      case 217: /* expr ::= expr IS expr */
 {
-  spanBinaryExpr(&yygotominor.yy342,pParse,TK_IS,&yymsp[-2].minor.yy342,&yymsp[0].minor.yy342);
-  binaryToUnaryIfNull(pParse, yymsp[0].minor.yy342.Expr, yygotominor.yy342.Expr, TK_ISNULL);
+  spanBinaryExpr(&yygotominor.yy214,pParse,TK_IS,&yymsp[-2].minor.yy214,&yymsp[0].minor.yy214);
+  binaryToUnaryIfNull(pParse, yymsp[0].minor.yy214.Expr, yygotominor.yy214.Expr, TK_ISNULL);
 }
         break;
-      case 216: /* expr ::= expr IS NOT expr */
+      case 218: /* expr ::= expr IS NOT expr */
 {
-  spanBinaryExpr(&yygotominor.yy342,pParse,TK_ISNOT,&yymsp[-3].minor.yy342,&yymsp[0].minor.yy342);
-  binaryToUnaryIfNull(pParse, yymsp[0].minor.yy342.Expr, yygotominor.yy342.Expr, TK_NOTNULL);
+  spanBinaryExpr(&yygotominor.yy214,pParse,TK_ISNOT,&yymsp[-3].minor.yy214,&yymsp[0].minor.yy214);
+  binaryToUnaryIfNull(pParse, yymsp[0].minor.yy214.Expr, yygotominor.yy214.Expr, TK_NOTNULL);
 }
         break;
-      case 217: /* expr ::= NOT expr */
-      case 218: /* expr ::= BITNOT expr */
-{spanUnaryPrefix(&yygotominor.yy342,pParse,yymsp[-1].major,&yymsp[0].minor.yy342,&yymsp[-1].minor.yy0);}
+      case 219: /* expr ::= NOT expr */
+      case 220: /* expr ::= BITNOT expr */
+{spanUnaryPrefix(&yygotominor.yy214,pParse,yymsp[-1].major,&yymsp[0].minor.yy214,&yymsp[-1].minor.yy0);}
         break;
-      case 219: /* expr ::= MINUS expr */
-{spanUnaryPrefix(&yygotominor.yy342,pParse,TK_UMINUS,&yymsp[0].minor.yy342,&yymsp[-1].minor.yy0);}
+      case 221: /* expr ::= MINUS expr */
+{spanUnaryPrefix(&yygotominor.yy214,pParse,TK_UMINUS,&yymsp[0].minor.yy214,&yymsp[-1].minor.yy0);}
         break;
-      case 220: /* expr ::= PLUS expr */
-{spanUnaryPrefix(&yygotominor.yy342,pParse,TK_UPLUS,&yymsp[0].minor.yy342,&yymsp[-1].minor.yy0);}
+      case 222: /* expr ::= PLUS expr */
+{spanUnaryPrefix(&yygotominor.yy214,pParse,TK_UPLUS,&yymsp[0].minor.yy214,&yymsp[-1].minor.yy0);}
         break;
-      case 223: /* expr ::= expr between_op expr AND expr */
+      case 225: /* expr ::= expr between_op expr AND expr */
 {
-  pList := NewExprList(yymsp[-2].minor.yy342.Expr, yymsp[0].minor.yy342.Expr)
-  yygotominor.yy342.Expr = pParse.Expr(TK_BETWEEN, yymsp[-4].minor.yy342.Expr, nil, "")
-  if( yygotominor.yy342.Expr ){
-    yygotominor.yy342.Expr.pList = pList;
+  pList := NewExprList(yymsp[-2].minor.yy214.Expr, yymsp[0].minor.yy214.Expr)
+  yygotominor.yy214.Expr = pParse.Expr(TK_BETWEEN, yymsp[-4].minor.yy214.Expr, nil, "")
+  if( yygotominor.yy214.Expr ){
+    yygotominor.yy214.Expr.pList = pList;
   }else{
     pParse.db.ExprListDelete(pList);
   } 
-  if( yymsp[-3].minor.yy392 ) yygotominor.yy342.Expr = pParse.Expr(TK_NOT, yygotominor.yy342.Expr, nil, "")
-  yygotominor.yy342.zStart = yymsp[-4].minor.yy342.zStart;
-  yygotominor.yy342.zEnd = yymsp[0].minor.yy342.zEnd;
+  if( yymsp[-3].minor.yy60 ) yygotominor.yy214.Expr = pParse.Expr(TK_NOT, yygotominor.yy214.Expr, nil, "")
+  yygotominor.yy214.zStart = yymsp[-4].minor.yy214.zStart;
+  yygotominor.yy214.zEnd = yymsp[0].minor.yy214.zEnd;
 }
         break;
-      case 226: /* expr ::= expr in_op LP exprlist RP */
+      case 228: /* expr ::= expr in_op LP exprlist RP */
 {
-    if( yymsp[-1].minor.yy442==0 ){
+    if( yymsp[-1].minor.yy402==0 ){
       /* Expressions of the form
       **
       **      expr1 IN ()
@@ -2298,340 +2460,378 @@ static void yy_reduce(
       ** simplify to constants 0 (false) and 1 (true), respectively,
       ** regardless of the value of expr1.
       */
-      yygotominor.yy342.Expr = pParse.Expr(TK_INTEGER, nil, nil, &sqlite3IntTokens[yymsp[-3].minor.yy392])
-      pParse.db.ExprDelete(yymsp[-4].minor.yy342.Expr)
+      yygotominor.yy214.Expr = pParse.Expr(TK_INTEGER, nil, nil, &sqlite3IntTokens[yymsp[-3].minor.yy60])
+      pParse.db.ExprDelete(yymsp[-4].minor.yy214.Expr)
     }else{
-      yygotominor.yy342.Expr = pParse.Expr(TK_IN, yymsp[-4].minor.yy342.Expr, nil, "")
-      if( yygotominor.yy342.Expr ){
-        yygotominor.yy342.Expr.pList = yymsp[-1].minor.yy442;
+      yygotominor.yy214.Expr = pParse.Expr(TK_IN, yymsp[-4].minor.yy214.Expr, nil, "")
+      if( yygotominor.yy214.Expr ){
+        yygotominor.yy214.Expr.pList = yymsp[-1].minor.yy402;
       }else{
-        pParse.db.ExprListDelete(yymsp[-1].minor.yy442);
+        pParse.db.ExprListDelete(yymsp[-1].minor.yy402);
       }
-      if( yymsp[-3].minor.yy392 ) yygotominor.yy342.Expr = pParse.Expr(TK_NOT, yygotominor.yy342.Expr, nil, "")
+      if( yymsp[-3].minor.yy60 ) yygotominor.yy214.Expr = pParse.Expr(TK_NOT, yygotominor.yy214.Expr, nil, "")
     }
-    yygotominor.yy342.zStart = yymsp[-4].minor.yy342.zStart;
-    yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+    yygotominor.yy214.zStart = yymsp[-4].minor.yy214.zStart;
+    yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
   }
         break;
-      case 227: /* expr ::= LP select RP */
+      case 229: /* expr ::= LP select RP */
 {
-    yygotominor.yy342.Expr = pParse.Expr(TK_SELECT, nil, nil, "")
-    if( yygotominor.yy342.Expr ){
-      yygotominor.yy342.Expr.x.Select = yymsp[-1].minor.yy159;
-      yygotominor.yy342.Expr.SetProperty(EP_xIsSelect);
+    yygotominor.yy214.Expr = pParse.Expr(TK_SELECT, nil, nil, "")
+    if( yygotominor.yy214.Expr ){
+      yygotominor.yy214.Expr.x.Select = yymsp[-1].minor.yy331;
+      yygotominor.yy214.Expr.SetProperty(EP_xIsSelect);
     }else{
-      sqlite3SelectDelete(pParse.db, yymsp[-1].minor.yy159);
+      sqlite3SelectDelete(pParse.db, yymsp[-1].minor.yy331);
     }
-    yygotominor.yy342.zStart = yymsp[-2].minor.yy0.z;
-    yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+    yygotominor.yy214.zStart = yymsp[-2].minor.yy0.z;
+    yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
   }
         break;
-      case 228: /* expr ::= expr in_op LP select RP */
+      case 230: /* expr ::= expr in_op LP select RP */
 {
-    yygotominor.yy342.Expr = pParse.Expr(TK_IN, yymsp[-4].minor.yy342.Expr, nil, "")
-    if( yygotominor.yy342.Expr ){
-      yygotominor.yy342.Expr.x.Select = yymsp[-1].minor.yy159;
-      yygotominor.yy342.Expr.SetProperty(EP_xIsSelect);
+    yygotominor.yy214.Expr = pParse.Expr(TK_IN, yymsp[-4].minor.yy214.Expr, nil, "")
+    if( yygotominor.yy214.Expr ){
+      yygotominor.yy214.Expr.x.Select = yymsp[-1].minor.yy331;
+      yygotominor.yy214.Expr.SetProperty(EP_xIsSelect);
     }else{
-      sqlite3SelectDelete(pParse.db, yymsp[-1].minor.yy159);
+      sqlite3SelectDelete(pParse.db, yymsp[-1].minor.yy331);
     }
-    if( yymsp[-3].minor.yy392 ) yygotominor.yy342.Expr = pParse.Expr(TK_NOT, yygotominor.yy342.Expr, nil, "")
-    yygotominor.yy342.zStart = yymsp[-4].minor.yy342.zStart;
-    yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+    if( yymsp[-3].minor.yy60 ) yygotominor.yy214.Expr = pParse.Expr(TK_NOT, yygotominor.yy214.Expr, nil, "")
+    yygotominor.yy214.zStart = yymsp[-4].minor.yy214.zStart;
+    yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
   }
         break;
-      case 229: /* expr ::= expr in_op nm dbnm */
+      case 231: /* expr ::= expr in_op nm dbnm */
 {
     pSrc = pParse.db.SrcListAppend(nil, &yymsp[-1].minor.yy0, &yymsp[0].minor.yy0);
-    yygotominor.yy342.Expr = pParse.Expr(TK_IN, yymsp[-3].minor.yy342.Expr, nil, "")
-    if( yygotominor.yy342.Expr ){
-      yygotominor.yy342.Expr.x.Select = sqlite3SelectNew(pParse, 0,pSrc,0,0,0,0,0,0,0);
-      yygotominor.yy342.Expr.SetProperty(EP_xIsSelect);
+    yygotominor.yy214.Expr = pParse.Expr(TK_IN, yymsp[-3].minor.yy214.Expr, nil, "")
+    if( yygotominor.yy214.Expr ){
+      yygotominor.yy214.Expr.x.Select = sqlite3SelectNew(pParse, 0,pSrc,0,0,0,0,0,0,0);
+      yygotominor.yy214.Expr.SetProperty(EP_xIsSelect);
     }else{
       sqlite3SrcListDelete(pParse.db, pSrc);
     }
-    if( yymsp[-2].minor.yy392 ) yygotominor.yy342.Expr = pParse.Expr(TK_NOT, yygotominor.yy342.Expr, nil, "")
-    yygotominor.yy342.zStart = yymsp[-3].minor.yy342.zStart;
-    yygotominor.yy342.zEnd = yymsp[0].minor.yy0.z ? &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n] : &yymsp[-1].minor.yy0.z[yymsp[-1].minor.yy0.n];
+    if( yymsp[-2].minor.yy60 ) yygotominor.yy214.Expr = pParse.Expr(TK_NOT, yygotominor.yy214.Expr, nil, "")
+    yygotominor.yy214.zStart = yymsp[-3].minor.yy214.zStart;
+    yygotominor.yy214.zEnd = yymsp[0].minor.yy0.z ? &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n] : &yymsp[-1].minor.yy0.z[yymsp[-1].minor.yy0.n];
   }
         break;
-      case 230: /* expr ::= EXISTS LP select RP */
+      case 232: /* expr ::= EXISTS LP select RP */
 {
-    Expr *p = yygotominor.yy342.Expr = pParse.Expr(TK_EXISTS, nil, nil, "")
+    Expr *p = yygotominor.yy214.Expr = pParse.Expr(TK_EXISTS, nil, nil, "")
     if( p ){
-      p.x.Select = yymsp[-1].minor.yy159;
+      p.x.Select = yymsp[-1].minor.yy331;
       p.SetProperty(EP_xIsSelect);
     }else{
-      sqlite3SelectDelete(pParse.db, yymsp[-1].minor.yy159);
+      sqlite3SelectDelete(pParse.db, yymsp[-1].minor.yy331);
     }
-    yygotominor.yy342.zStart = yymsp[-3].minor.yy0.z;
-    yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+    yygotominor.yy214.zStart = yymsp[-3].minor.yy0.z;
+    yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
   }
         break;
-      case 231: /* expr ::= CASE case_operand case_exprlist case_else END */
+      case 233: /* expr ::= CASE case_operand case_exprlist case_else END */
 {
-  yygotominor.yy342.Expr = pParse.Expr(TK_CASE, yymsp[-3].minor.yy122, yymsp[-1].minor.yy122, "")
-  if( yygotominor.yy342.Expr ){
-    yygotominor.yy342.Expr.pList = yymsp[-2].minor.yy442;
+  yygotominor.yy214.Expr = pParse.Expr(TK_CASE, yymsp[-3].minor.yy386, yymsp[-1].minor.yy386, "")
+  if( yygotominor.yy214.Expr ){
+    yygotominor.yy214.Expr.pList = yymsp[-2].minor.yy402;
   }else{
-    pParse.db.ExprListDelete(yymsp[-2].minor.yy442);
+    pParse.db.ExprListDelete(yymsp[-2].minor.yy402);
   }
-  yygotominor.yy342.zStart = yymsp[-4].minor.yy0.z;
-  yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+  yygotominor.yy214.zStart = yymsp[-4].minor.yy0.z;
+  yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
 }
         break;
-      case 232: /* case_exprlist ::= case_exprlist WHEN expr THEN expr */
+      case 234: /* case_exprlist ::= case_exprlist WHEN expr THEN expr */
 {
-  yygotominor.yy442 = append(yymsp[-4].minor.yy442.Items, yymsp[-2].minor.yy342.Expr);
-  yygotominor.yy442 = append(yygotominor.yy442.Items, yymsp[0].minor.yy342.Expr);
+  yygotominor.yy402 = append(yymsp[-4].minor.yy402.Items, yymsp[-2].minor.yy214.Expr);
+  yygotominor.yy402 = append(yygotominor.yy402.Items, yymsp[0].minor.yy214.Expr);
 }
         break;
-      case 233: /* case_exprlist ::= WHEN expr THEN expr */
+      case 235: /* case_exprlist ::= WHEN expr THEN expr */
 {
-  yygotominor.yy442 = NewExprList(yymsp[-2].minor.yy342.Expr, yymsp[0].minor.yy342.Expr)
+  yygotominor.yy402 = NewExprList(yymsp[-2].minor.yy214.Expr, yymsp[0].minor.yy214.Expr)
 }
         break;
-      case 240: /* nexprlist ::= nexprlist COMMA expr */
-{yygotominor.yy442 = append(yymsp[-2].minor.yy442.Items, yymsp[0].minor.yy342.Expr);}
+      case 242: /* nexprlist ::= nexprlist COMMA expr */
+{yygotominor.yy402 = append(yymsp[-2].minor.yy402.Items, yymsp[0].minor.yy214.Expr);}
         break;
-      case 241: /* nexprlist ::= expr */
-{yygotominor.yy442 = &Expr{ Items: []*Expr{ yymsp[0].minor.yy342.Expr } };}
+      case 243: /* nexprlist ::= expr */
+{yygotominor.yy402 = &Expr{ Items: []*Expr{ yymsp[0].minor.yy214.Expr } };}
         break;
-      case 242: /* cmd ::= createkw uniqueflag INDEX ifnotexists nm dbnm ON nm LP idxlist RP */
+      case 244: /* cmd ::= createkw uniqueflag INDEX ifnotexists nm dbnm ON nm LP idxlist RP */
 {
   sqlite3CreateIndex(pParse, &yymsp[-6].minor.yy0, &yymsp[-5].minor.yy0, 
-                     pParse.db.SrcListAppend(nil ,&yymsp[-3].minor.yy0, ""), yymsp[-1].minor.yy442, yymsp[-9].minor.yy392,
-                      &yymsp[-10].minor.yy0, &yymsp[0].minor.yy0, SQLITE_SO_ASC, yymsp[-7].minor.yy392);
+                     pParse.db.SrcListAppend(nil ,&yymsp[-3].minor.yy0, ""), yymsp[-1].minor.yy402, yymsp[-9].minor.yy60,
+                      &yymsp[-10].minor.yy0, &yymsp[0].minor.yy0, SQLITE_SO_ASC, yymsp[-7].minor.yy60);
 }
         break;
-      case 243: /* uniqueflag ::= UNIQUE */
-      case 296: /* raisetype ::= ABORT */
-	  	yygotominor.yy392 = OE_Abort
+      case 245: /* uniqueflag ::= UNIQUE */
+      case 298: /* raisetype ::= ABORT */
+	  	yygotominor.yy60 = OE_Abort
 
-      case 244: /* uniqueflag ::= */
-		yygotominor.yy392 = OE_None
+      case 246: /* uniqueflag ::= */
+		yygotominor.yy60 = OE_None
 
-      case 247: /* idxlist ::= idxlist COMMA nm collate sortorder */
+      case 249: /* idxlist ::= idxlist COMMA nm collate sortorder */
 		var p	*Expr
 		if len(yymsp[-1].minor.yy0) > 0 {
 			p = pParse.db.Expr(TK_COLUMN, "")
 			sqlite3ExprSetCollByToken(pParse, p, &yymsp[-1].minor.yy0)
 		}
-		yygotominor.yy442 = append(yymsp[-4].minor.yy442.Items, p)
-		pParse,yygotominor.yy442.SetName(&yymsp[-2].minor.yy0, true)
-		if yygotominor.yy442 {
-			yygotominor.yy442.Last().sortOrder = byte(yymsp[0].minor.yy392)
+		yygotominor.yy402 = append(yymsp[-4].minor.yy402.Items, p)
+		pParse,yygotominor.yy402.SetName(&yymsp[-2].minor.yy0, true)
+		if yygotominor.yy402 {
+			yygotominor.yy402.Last().sortOrder = byte(yymsp[0].minor.yy60)
 		}
 
-      case 248: /* idxlist ::= nm collate sortorder */
+      case 250: /* idxlist ::= nm collate sortorder */
 		var p	*Expr
 		if len(yymsp[-1].minor.yy0) > 0 {
 			p = pParse.Expr(TK_COLUMN, nil, nil, "")
 			sqlite3ExprSetCollByToken(pParse, p, &yymsp[-1].minor.yy0)
 		}
-		yygotominor.yy442 = NewExprList(p)
-		yygotominor.yy442.SetName(&yymsp[-2].minor.yy0, true)
-		if yygotominor.yy442 {
-			yygotominor.yy442.Last().sortOrder = byte(yymsp[0].minor.yy392)
+		yygotominor.yy402 = NewExprList(p)
+		yygotominor.yy402.SetName(&yymsp[-2].minor.yy0, true)
+		if yygotominor.yy402 {
+			yygotominor.yy402.Last().sortOrder = byte(yymsp[0].minor.yy60)
 		}
 
-      case 249: /* collate ::= */
+      case 251: /* collate ::= */
 		yygotominor.yy0 = ""
 
-      case 251: /* cmd ::= DROP INDEX ifexists fullname */
-{sqlite3DropIndex(pParse, yymsp[0].minor.yy347, yymsp[-1].minor.yy392);}
+      case 253: /* cmd ::= DROP INDEX ifexists fullname */
+{sqlite3DropIndex(pParse, yymsp[0].minor.yy291, yymsp[-1].minor.yy60);}
         break;
-      case 252: /* cmd ::= VACUUM */
-      case 253: /* cmd ::= VACUUM nm */
+      case 254: /* cmd ::= VACUUM */
+      case 255: /* cmd ::= VACUUM nm */
 	  	pParse.Vacuum()
-      case 254: /* cmd ::= PRAGMA nm dbnm */
+      case 256: /* cmd ::= PRAGMA nm dbnm */
 {sqlite3Pragma(pParse,&yymsp[-1].minor.yy0,&yymsp[0].minor.yy0,0,0);}
         break;
-      case 255: /* cmd ::= PRAGMA nm dbnm EQ nmnum */
+      case 257: /* cmd ::= PRAGMA nm dbnm EQ nmnum */
 {sqlite3Pragma(pParse,&yymsp[-3].minor.yy0,&yymsp[-2].minor.yy0,&yymsp[0].minor.yy0,0);}
         break;
-      case 256: /* cmd ::= PRAGMA nm dbnm LP nmnum RP */
+      case 258: /* cmd ::= PRAGMA nm dbnm LP nmnum RP */
 {sqlite3Pragma(pParse,&yymsp[-4].minor.yy0,&yymsp[-3].minor.yy0,&yymsp[-1].minor.yy0,0);}
         break;
-      case 257: /* cmd ::= PRAGMA nm dbnm EQ minus_num */
+      case 259: /* cmd ::= PRAGMA nm dbnm EQ minus_num */
 {sqlite3Pragma(pParse,&yymsp[-3].minor.yy0,&yymsp[-2].minor.yy0,&yymsp[0].minor.yy0,1);}
         break;
-      case 258: /* cmd ::= PRAGMA nm dbnm LP minus_num RP */
+      case 260: /* cmd ::= PRAGMA nm dbnm LP minus_num RP */
 {sqlite3Pragma(pParse,&yymsp[-4].minor.yy0,&yymsp[-3].minor.yy0,&yymsp[-1].minor.yy0,1);}
         break;
-      case 268: /* cmd ::= createkw trigger_decl BEGIN trigger_cmd_list END */
+      case 270: /* cmd ::= createkw trigger_decl BEGIN trigger_cmd_list END */
 {
   Token all;
   all.z = yymsp[-3].minor.yy0.z;
   all.n = (int)(yymsp[0].minor.yy0.z - yymsp[-3].minor.yy0.z) + yymsp[0].minor.yy0.n;
-  sqlite3FinishTrigger(pParse, yymsp[-1].minor.yy327, &all);
+  sqlite3FinishTrigger(pParse, yymsp[-1].minor.yy251, &all);
 }
         break;
-      case 269: /* trigger_decl ::= temp TRIGGER ifnotexists nm dbnm trigger_time trigger_event ON fullname foreach_clause when_clause */
+      case 271: /* trigger_decl ::= temp TRIGGER ifnotexists nm dbnm trigger_time trigger_event ON fullname foreach_clause when_clause */
 {
-  sqlite3BeginTrigger(pParse, &yymsp[-7].minor.yy0, &yymsp[-6].minor.yy0, yymsp[-5].minor.yy392, yymsp[-4].minor.yy410.a, yymsp[-4].minor.yy410.b, yymsp[-2].minor.yy347, yymsp[0].minor.yy122, yymsp[-10].minor.yy392, yymsp[-8].minor.yy392);
+  sqlite3BeginTrigger(pParse, &yymsp[-7].minor.yy0, &yymsp[-6].minor.yy0, yymsp[-5].minor.yy60, yymsp[-4].minor.yy403.a, yymsp[-4].minor.yy403.b, yymsp[-2].minor.yy291, yymsp[0].minor.yy386, yymsp[-10].minor.yy60, yymsp[-8].minor.yy60);
   yygotominor.yy0 = (yymsp[-6].minor.yy0.n==0?yymsp[-7].minor.yy0:yymsp[-6].minor.yy0);
 }
         break;
-      case 270: /* trigger_time ::= BEFORE */
-      case 273: /* trigger_time ::= */
-{ yygotominor.yy392 = TK_BEFORE; }
+      case 272: /* trigger_time ::= BEFORE */
+      case 275: /* trigger_time ::= */
+{ yygotominor.yy60 = TK_BEFORE; }
         break;
-      case 271: /* trigger_time ::= AFTER */
-{ yygotominor.yy392 = TK_AFTER;  }
+      case 273: /* trigger_time ::= AFTER */
+{ yygotominor.yy60 = TK_AFTER;  }
         break;
-      case 272: /* trigger_time ::= INSTEAD OF */
-{ yygotominor.yy392 = TK_INSTEAD;}
+      case 274: /* trigger_time ::= INSTEAD OF */
+{ yygotominor.yy60 = TK_INSTEAD;}
         break;
-      case 274: /* trigger_event ::= DELETE|INSERT */
-      case 275: /* trigger_event ::= UPDATE */
-{yygotominor.yy410.a = yymsp[0].major; yygotominor.yy410.b = 0;}
+      case 276: /* trigger_event ::= DELETE|INSERT */
+      case 277: /* trigger_event ::= UPDATE */
+{yygotominor.yy403.a = yymsp[0].major; yygotominor.yy403.b = 0;}
         break;
-      case 276: /* trigger_event ::= UPDATE OF inscollist */
-{yygotominor.yy410.a = TK_UPDATE; yygotominor.yy410.b = yymsp[0].minor.yy180;}
+      case 278: /* trigger_event ::= UPDATE OF inscollist */
+{yygotominor.yy403.a = TK_UPDATE; yygotominor.yy403.b = yymsp[0].minor.yy272;}
         break;
-      case 279: /* when_clause ::= */
-      case 301: /* key_opt ::= */
-{ yygotominor.yy122 = 0; }
+      case 281: /* when_clause ::= */
+      case 303: /* key_opt ::= */
+{ yygotominor.yy386 = 0; }
         break;
-      case 280: /* when_clause ::= WHEN expr */
-      case 302: /* key_opt ::= KEY expr */
-{ yygotominor.yy122 = yymsp[0].minor.yy342.Expr; }
+      case 282: /* when_clause ::= WHEN expr */
+      case 304: /* key_opt ::= KEY expr */
+{ yygotominor.yy386 = yymsp[0].minor.yy214.Expr; }
         break;
-      case 281: /* trigger_cmd_list ::= trigger_cmd_list trigger_cmd SEMI */
+      case 283: /* trigger_cmd_list ::= trigger_cmd_list trigger_cmd SEMI */
 {
-  assert( yymsp[-2].minor.yy327!=0 );
-  yymsp[-2].minor.yy327.Last.Next = yymsp[-1].minor.yy327;
-  yymsp[-2].minor.yy327.Last = yymsp[-1].minor.yy327;
-  yygotominor.yy327 = yymsp[-2].minor.yy327;
+  assert( yymsp[-2].minor.yy251!=0 );
+  yymsp[-2].minor.yy251.Last.Next = yymsp[-1].minor.yy251;
+  yymsp[-2].minor.yy251.Last = yymsp[-1].minor.yy251;
+  yygotominor.yy251 = yymsp[-2].minor.yy251;
 }
         break;
-      case 282: /* trigger_cmd_list ::= trigger_cmd SEMI */
+      case 284: /* trigger_cmd_list ::= trigger_cmd SEMI */
 { 
-  assert( yymsp[-1].minor.yy327!=0 );
-  yymsp[-1].minor.yy327.Last = yymsp[-1].minor.yy327;
-  yygotominor.yy327 = yymsp[-1].minor.yy327;
+  assert( yymsp[-1].minor.yy251!=0 );
+  yymsp[-1].minor.yy251.Last = yymsp[-1].minor.yy251;
+  yygotominor.yy251 = yymsp[-1].minor.yy251;
 }
         break;
-      case 284: /* trnm ::= nm DOT nm */
+      case 286: /* trnm ::= nm DOT nm */
 {
   yygotominor.yy0 = yymsp[0].minor.yy0;
   pParse.SetErrorMsg("qualified table names are not allowed on INSERT, UPDATE, and DELETE statements within triggers");
 }
         break;
-      case 286: /* tridxby ::= INDEXED BY nm */
+      case 288: /* tridxby ::= INDEXED BY nm */
 {
   pParse.SetErrorMsg("the INDEXED BY clause is not allowed on UPDATE or DELETE statements within triggers");
 }
         break;
-      case 287: /* tridxby ::= NOT INDEXED */
+      case 289: /* tridxby ::= NOT INDEXED */
 {
   pParse, "the NOT INDEXED clause is not allowed on UPDATE or DELETE statements within triggers");
 }
         break;
-      case 288: /* trigger_cmd ::= UPDATE OnConflict trnm tridxby SET setlist where_opt */
-{ yygotominor.yy327 = sqlite3TriggerUpdateStep(pParse.db, &yymsp[-4].minor.yy0, yymsp[-1].minor.yy442, yymsp[0].minor.yy122, yymsp[-5].minor.yy258); }
+      case 290: /* trigger_cmd ::= UPDATE OnConflict trnm tridxby SET setlist where_opt */
+{ yygotominor.yy251 = sqlite3TriggerUpdateStep(pParse.db, &yymsp[-4].minor.yy0, yymsp[-1].minor.yy402, yymsp[0].minor.yy386, yymsp[-5].minor.yy274); }
         break;
-      case 289: /* trigger_cmd ::= insert_cmd INTO trnm inscollist_opt valuelist */
-{yygotominor.yy327 = sqlite3TriggerInsertStep(pParse.db, &yymsp[-2].minor.yy0, yymsp[-1].minor.yy180, yymsp[0].minor.yy487.pList, yymsp[0].minor.yy487.Select, yymsp[-4].minor.yy258);}
+      case 291: /* trigger_cmd ::= insert_cmd INTO trnm inscollist_opt valuelist */
+{
+  yygotominor.yy251 = sqlite3TriggerInsertStep(pParse.db, &yymsp[-2].minor.yy0, yymsp[-1].minor.yy272, yymsp[0].minor.yy211.pList, yymsp[0].minor.yy211.Select, yymsp[-4].minor.yy274);
+  pParse.TriggerStepUpsert(yygotominor.yy251)
+}
         break;
-      case 290: /* trigger_cmd ::= insert_cmd INTO trnm inscollist_opt select */
-{yygotominor.yy327 = sqlite3TriggerInsertStep(pParse.db, &yymsp[-2].minor.yy0, yymsp[-1].minor.yy180, 0, yymsp[0].minor.yy159, yymsp[-4].minor.yy258);}
+      case 292: /* trigger_cmd ::= insert_cmd INTO trnm inscollist_opt select */
+{
+  yygotominor.yy251 = sqlite3TriggerInsertStep(pParse.db, &yymsp[-2].minor.yy0, yymsp[-1].minor.yy272, 0, yymsp[0].minor.yy331, yymsp[-4].minor.yy274);
+  pParse.TriggerStepUpsert(yygotominor.yy251)
+}
         break;
-      case 291: /* trigger_cmd ::= DELETE FROM trnm tridxby where_opt */
-{yygotominor.yy327 = sqlite3TriggerDeleteStep(pParse.db, &yymsp[-2].minor.yy0, yymsp[0].minor.yy122);}
+      case 293: /* trigger_cmd ::= DELETE FROM trnm tridxby where_opt */
+{yygotominor.yy251 = sqlite3TriggerDeleteStep(pParse.db, &yymsp[-2].minor.yy0, yymsp[0].minor.yy386);}
         break;
-      case 292: /* trigger_cmd ::= select */
-{yygotominor.yy327 = sqlite3TriggerSelectStep(pParse.db, yymsp[0].minor.yy159); }
+      case 294: /* trigger_cmd ::= select */
+{yygotominor.yy251 = sqlite3TriggerSelectStep(pParse.db, yymsp[0].minor.yy331); }
         break;
-      case 293: /* expr ::= RAISE LP IGNORE RP */
+      case 295: /* expr ::= RAISE LP IGNORE RP */
 {
-  yygotominor.yy342.Expr = pParse.Expr(TK_RAISE, nil, nil, "")
-  if( yygotominor.yy342.Expr ){
-    yygotominor.yy342.Expr.affinity = OE_Ignore;
+  yygotominor.yy214.Expr = pParse.Expr(TK_RAISE, nil, nil, "")
+  if( yygotominor.yy214.Expr ){
+    yygotominor.yy214.Expr.affinity = OE_Ignore;
   }
-  yygotominor.yy342.zStart = yymsp[-3].minor.yy0.z;
-  yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+  yygotominor.yy214.zStart = yymsp[-3].minor.yy0.z;
+  yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
 }
         break;
-      case 294: /* expr ::= RAISE LP raisetype COMMA nm RP */
+      case 296: /* expr ::= RAISE LP raisetype COMMA nm RP */
 {
-  yygotominor.yy342.Expr = pParse.Expr(TK_RAISE, nil, nil, &yymsp[-1].minor.yy0)
-  if( yygotominor.yy342.Expr ) {
-    yygotominor.yy342.Expr.affinity = (char)yymsp[-3].minor.yy392;
+  yygotominor.yy214.Expr = pParse.Expr(TK_RAISE, nil, nil, &yymsp[-1].minor.yy0)
+  if( yygotominor.yy214.Expr ) {
+    yygotominor.yy214.Expr.affinity = (char)yymsp[-3].minor.yy60;
   }
-  yygotominor.yy342.zStart = yymsp[-5].minor.yy0.z;
-  yygotominor.yy342.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
+  yygotominor.yy214.zStart = yymsp[-5].minor.yy0.z;
+  yygotominor.yy214.zEnd = &yymsp[0].minor.yy0.z[yymsp[0].minor.yy0.n];
 }
         break;
-      case 295: /* raisetype ::= ROLLBACK */
-{yygotominor.yy392 = OE_Rollback;}
+      case 297: /* raisetype ::= ROLLBACK */
+{yygotominor.yy60 = OE_Rollback;}
         break;
-      case 297: /* raisetype ::= FAIL */
-{yygotominor.yy392 = OE_Fail;}
+      case 299: /* raisetype ::= FAIL */
+{yygotominor.yy60 = OE_Fail;}
         break;
-      case 298: /* cmd ::= DROP TRIGGER ifexists fullname */
+      case 300: /* cmd ::= DROP TRIGGER ifexists fullname */
 {
-  sqlite3DropTrigger(pParse,yymsp[0].minor.yy347,yymsp[-1].minor.yy392);
+  sqlite3DropTrigger(pParse,yymsp[0].minor.yy291,yymsp[-1].minor.yy60);
 }
         break;
-      case 299: /* cmd ::= ATTACH database_kw_opt expr AS expr key_opt */
+      case 301: /* cmd ::= ATTACH database_kw_opt expr AS expr key_opt */
 {
-  sqlite3Attach(pParse, yymsp[-3].minor.yy342.Expr, yymsp[-1].minor.yy342.Expr, yymsp[0].minor.yy122);
+  sqlite3Attach(pParse, yymsp[-3].minor.yy214.Expr, yymsp[-1].minor.yy214.Expr, yymsp[0].minor.yy386);
 }
         break;
-      case 300: /* cmd ::= DETACH database_kw_opt expr */
+      case 302: /* cmd ::= DETACH database_kw_opt expr */
 {
-  sqlite3Detach(pParse, yymsp[0].minor.yy342.Expr);
+  sqlite3Detach(pParse, yymsp[0].minor.yy214.Expr);
 }
         break;
-      case 305: /* cmd ::= REINDEX */
+      case 307: /* cmd ::= REINDEX */
 {sqlite3Reindex(pParse, 0, 0);}
         break;
-      case 306: /* cmd ::= REINDEX nm dbnm */
+      case 308: /* cmd ::= REINDEX nm dbnm */
 {sqlite3Reindex(pParse, &yymsp[-1].minor.yy0, &yymsp[0].minor.yy0);}
         break;
-      case 307: /* cmd ::= ANALYZE */
+      case 309: /* cmd ::= ANALYZE */
 {pParse.Analyze("", "")}
         break;
-      case 308: /* cmd ::= ANALYZE nm dbnm */
+      case 310: /* cmd ::= ANALYZE nm dbnm */
 {pParse.Analyze(&yymsp[-1].minor.yy0, &yymsp[0].minor.yy0)}
         break;
-      case 309: /* cmd ::= ALTER TABLE fullname RENAME TO nm */
+      case 311: /* cmd ::= ALTER TABLE fullname RENAME TO nm */
 {
-  sqlite3AlterRenameTable(pParse,yymsp[-3].minor.yy347,&yymsp[0].minor.yy0);
+  sqlite3AlterRenameTable(pParse,yymsp[-3].minor.yy291,&yymsp[0].minor.yy0);
 }
         break;
-      case 310: /* cmd ::= ALTER TABLE add_column_fullname ADD kwcolumn_opt column */
+      case 312: /* cmd ::= ALTER TABLE add_column_fullname ADD kwcolumn_opt column */
 {
   sqlite3AlterFinishAddColumn(pParse, &yymsp[0].minor.yy0);
 }
         break;
-      case 311: /* add_column_fullname ::= fullname */
+      case 313: /* add_column_fullname ::= fullname */
 {
   pParse.db.lookaside.bEnabled = 0;
-  sqlite3AlterBeginAddColumn(pParse, yymsp[0].minor.yy347);
+  sqlite3AlterBeginAddColumn(pParse, yymsp[0].minor.yy291);
 }
         break;
-      case 314: /* cmd ::= create_vtab */
+      case 316: /* cmd ::= create_vtab */
 {pParseVtabFinishParse(0);}
         break;
-      case 315: /* cmd ::= create_vtab LP vtabarglist RP */
+      case 317: /* cmd ::= create_vtab LP vtabarglist RP */
 {pParse.VtabFinishParse(&yymsp[0].minor.yy0);}
         break;
-      case 316: /* create_vtab ::= createkw VIRTUAL TABLE ifnotexists nm dbnm USING nm */
+      case 318: /* create_vtab ::= createkw VIRTUAL TABLE ifnotexists nm dbnm USING nm */
 {
-    pParse.VtabBeginParse(&yymsp[-3].minor.yy0, &yymsp[-2].minor.yy0, &yymsp[0].minor.yy0, yymsp[-4].minor.yy392)
+    pParse.VtabBeginParse(&yymsp[-3].minor.yy0, &yymsp[-2].minor.yy0, &yymsp[0].minor.yy0, yymsp[-4].minor.yy60)
 }
         break;
-      case 319: /* vtabarg ::= */
+      case 321: /* vtabarg ::= */
 {sqlite3VtabArgInit(pParse);}
         break;
-      case 321: /* vtabargtoken ::= ANY */
-      case 322: /* vtabargtoken ::= lp anylist RP */
-      case 323: /* lp ::= LP */
+      case 323: /* vtabargtoken ::= ANY */
+      case 324: /* vtabargtoken ::= lp anylist RP */
+      case 325: /* lp ::= LP */
 {sqlite3VtabArgExtend(pParse,&yymsp[0].minor.yy0);}
         break;
+      case 329: /* with ::= */
+{yygotominor.yy227 = 0;}
+        break;
+      case 330: /* with ::= WITH wqlist */
+{yygotominor.yy227 = yymsp[0].minor.yy227;}
+        break;
+      case 331: /* with ::= WITH RECURSIVE wqlist */
+{
+  yygotominor.yy227 = yymsp[0].minor.yy227;
+  yygotominor.yy227.Recursive = true
+}
+        break;
+      case 332: /* wqlist ::= nm idxlist_opt AS LP select RP */
+{
+  yygotominor.yy227 = pParse.WithAdd(nil, &yymsp[-5].minor.yy0, yymsp[-4].minor.yy402, yymsp[-1].minor.yy331)
+}
+        break;
+      case 333: /* wqlist ::= nm idxlist_opt AS LP valuelist RP */
+{
+  yygotominor.yy227 = pParse.WithAdd(nil, &yymsp[-5].minor.yy0, yymsp[-4].minor.yy402, pParse.valuesSelect(yymsp[-1].minor.yy211.pList, yymsp[-1].minor.yy211.Select))
+}
+        break;
+      case 334: /* wqlist ::= wqlist COMMA nm idxlist_opt AS LP select RP */
+{
+  yygotominor.yy227 = pParse.WithAdd(yymsp[-7].minor.yy227, &yymsp[-5].minor.yy0, yymsp[-4].minor.yy402, yymsp[-1].minor.yy331)
+}
+        break;
+      case 335: /* wqlist ::= wqlist COMMA nm idxlist_opt AS LP valuelist RP */
+{
+  yygotominor.yy227 = pParse.WithAdd(yymsp[-7].minor.yy227, &yymsp[-5].minor.yy0, yymsp[-4].minor.yy402, pParse.valuesSelect(yymsp[-1].minor.yy211.pList, yymsp[-1].minor.yy211.Select))
+}
+        break;
       default:
       /* (0) input ::= cmdlist */
       /* (1) cmdlist ::= cmdlist ecmd */
@@ -2655,19 +2855,19 @@ static void yy_reduce(
       /* (89) conslist ::= conslist tconscomma tcons */
       /* (90) conslist ::= tcons */
       /* (92) tconscomma ::= */
-      /* (277) foreach_clause ::= */
-      /* (278) foreach_clause ::= FOR EACH ROW */
-      /* (285) tridxby ::= */
-      /* (303) database_kw_opt ::= DATABASE */
-      /* (304) database_kw_opt ::= */
-      /* (312) kwcolumn_opt ::= */
-      /* (313) kwcolumn_opt ::= COLUMNKW */
-      /* (317) vtabarglist ::= vtabarg */
-      /* (318) vtabarglist ::= vtabarglist COMMA vtabarg */
-      /* (320) vtabarg ::= vtabarg vtabargtoken */
-      /* (324) anylist ::= */
-      /* (325) anylist ::= anylist LP anylist RP */
-      /* (326) anylist ::= anylist ANY */
+      /* (279) foreach_clause ::= */
+      /* (280) foreach_clause ::= FOR EACH ROW */
+      /* (287) tridxby ::= */
+      /* (305) database_kw_opt ::= DATABASE */
+      /* (306) database_kw_opt ::= */
+      /* (314) kwcolumn_opt ::= */
+      /* (315) kwcolumn_opt ::= COLUMNKW */
+      /* (319) vtabarglist ::= vtabarg */
+      /* (320) vtabarglist ::= vtabarglist COMMA vtabarg */
+      /* (322) vtabarg ::= vtabarg vtabargtoken */
+      /* (326) anylist ::= */
+      /* (327) anylist ::= anylist LP anylist RP */
+      /* (328) anylist ::= anylist ANY */
         break;
   };
   yygoto = yyRuleInfo[yyruleno].lhs;
@@ -0,0 +1,130 @@
+//	This file implements partial indexes: indexes with a WHERE clause that holds entries only for the rows of the table that satisfy it.
+//
+//		CREATE INDEX i1 ON t(b) WHERE c IS NOT NULL
+//		CREATE UNIQUE INDEX i2 ON t(a) WHERE deleted = 0
+//
+//	Parse.ParseCreateIndex() in index.go removes the WHERE clause from the statement and records it on Parse.pPartIdxWhere, where
+//	sqlite3CreateIndex() finds it. The clause is resolved against the indexed table in the same way as a CHECK constraint, so that
+//	it can be evaluated over the registers of a new row during INSERT and UPDATE, or over a table cursor when the index is filled or
+//	its entries are deleted. The query planner only considers a partial index when every term of its WHERE clause also appears in
+//	the WHERE clause of the query, or is implied by one of its terms: "X > 10" in the query implies "X > 5" or "X IS NOT NULL" in the
+//	index.
+
+//	Generate code that jumps to dest unless the row that cursor iCur points to belongs in index pIdx. Nothing is coded unless pIdx is a
+//	partial index.
+func (pParse *Parse) PartialIndexSkip(pIdx *Index, iCur, dest int) {
+	if pIdx.pPartIdxWhere != nil {
+		pWhere := indexExprOnCursor(pIdx.pPartIdxWhere, iCur)
+		pParse.ExprCachePush()
+		sqlite3ExprIfFalse(pParse, pWhere, dest, SQLITE_JUMPIFNULL)
+		pParse.ExprCachePop(1)
+		pParse.db.ExprDelete(pWhere)
+	}
+}
+
+//	Return true if the WHERE clause of partial index pIdx refers to column iCol of the indexed table, or to the rowid if iCol is negative.
+func (pIdx *Index) PartialIndexUses(iCol int) bool {
+	return exprUsesColumn(pIdx.pPartIdxWhere, iCol)
+}
+
+//	If p compares a column of the table with cursor iTab with an integer constant, return the column, the operator rewritten as if
+//	the column were on its left, and the constant. Otherwise return ok false. A column with TEXT affinity is compared as text, so
+//	its comparisons are not ordered as the integers are and are not returned.
+func partialIndexRange(p *Expr, iTab int) (iColumn, op, v int, ok bool) {
+	switch p.op {
+	case TK_EQ, TK_LT, TK_LE, TK_GT, TK_GE:
+	default:
+		return
+	}
+	pCol, pVal, op := p.pLeft, p.pRight, p.op
+	if pCol.op != TK_COLUMN {
+		pCol, pVal = pVal, pCol
+		switch op {
+		case TK_LT:
+			op = TK_GT
+		case TK_LE:
+			op = TK_GE
+		case TK_GT:
+			op = TK_LT
+		case TK_GE:
+			op = TK_LE
+		}
+	}
+	if pCol.op != TK_COLUMN || pCol.iTable != iTab || sqlite3ExprAffinity(pCol) == SQLITE_AFF_TEXT || !sqlite3ExprIsInteger(pVal, &v) {
+		return
+	}
+	return pCol.iColumn, op, v, true
+}
+
+//	Return true if "X op1 v1" implies "X op2 v2" for every value of X that the comparisons are true of.
+func partialIndexRangeImplies(op1, v1, op2, v2 int) bool {
+	switch op2 {
+	case TK_GT:
+		return (op1 == TK_GT && v1 >= v2) || ((op1 == TK_GE || op1 == TK_EQ) && v1 > v2)
+	case TK_GE:
+		return (op1 == TK_GT || op1 == TK_GE || op1 == TK_EQ) && v1 >= v2
+	case TK_LT:
+		return (op1 == TK_LT && v1 <= v2) || ((op1 == TK_LE || op1 == TK_EQ) && v1 < v2)
+	case TK_LE:
+		return (op1 == TK_LT || op1 == TK_LE || op1 == TK_EQ) && v1 <= v2
+	case TK_EQ:
+		return op1 == TK_EQ && v1 == v2
+	}
+	return false
+}
+
+//	Return true if pTerm, a term of a WHERE clause in which the indexed table is read through cursor iTab, implies pPart, a single
+//	AND-term of a partial index WHERE clause. pPart is implied if it is identical to pTerm, if it is "X IS NOT NULL" and pTerm
+//	compares column X with one of the operators that are never true for a NULL, or if both compare column X with an integer and
+//	pTerm admits a narrower range of values than pPart, as "X > 10" does of "X > 5".
+func partialIndexTermImplies(pTerm *Expr, iTab int, pPart *Expr) bool {
+	pCopy := indexExprOnCursor(pPart, iTab)
+	if pTerm.Compare(pCopy) == 0 {
+		return true
+	}
+	if iCol2, op2, v2, ok := partialIndexRange(pCopy, iTab); ok {
+		iCol1, op1, v1, ok := partialIndexRange(pTerm, iTab)
+		return ok && iCol1 == iCol2 && partialIndexRangeImplies(op1, v1, op2, v2)
+	}
+	if pCopy.op != TK_NOTNULL || pCopy.pLeft.op != TK_COLUMN {
+		return false
+	}
+	switch pTerm.op {
+	case TK_EQ, TK_NE, TK_LT, TK_LE, TK_GT, TK_GE:
+		for _, pSide := range []*Expr{ pTerm.pLeft, pTerm.pRight } {
+			if pSide != nil && pSide.op == TK_COLUMN && pSide.iTable == iTab && pSide.iColumn == pCopy.pLeft.iColumn {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//	Return true if every AND-term of pPart, the WHERE clause of a partial index on the table with cursor iTab, is implied by one of
+//	the terms in aTerm. Each element of aTerm must be true of every row that the index is to be used for.
+func partialIndexImplied(aTerm []*Expr, iTab int, pPart *Expr) bool {
+	for pPart.op == TK_AND {
+		if !partialIndexImplied(aTerm, iTab, pPart.pLeft) {
+			return false
+		}
+		pPart = pPart.pRight
+	}
+	for _, pTerm := range aTerm {
+		if partialIndexTermImplies(pTerm, iTab, pPart) {
+			return true
+		}
+	}
+	return false
+}
+
+//	Split pExpr on its AND operators and append the terms to aTerm.
+func partialIndexSplit(aTerm []*Expr, pExpr *Expr) []*Expr {
+	if pExpr == nil {
+		return aTerm
+	}
+	if pExpr.op == TK_AND {
+		aTerm = partialIndexSplit(aTerm, pExpr.pLeft)
+		return partialIndexSplit(aTerm, pExpr.pRight)
+	}
+	return append(aTerm, pExpr)
+}
@@ -0,0 +1,82 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestPartialIndexRangeImplies(t *testing.T) {
+	for _, test := range []struct {
+		op1, v1, op2, v2	int
+		want				bool
+	}{
+		{ TK_GT, 10, TK_GT, 5, true },
+		{ TK_GT, 5, TK_GT, 5, true },
+		{ TK_GE, 5, TK_GT, 5, false },
+		{ TK_GE, 6, TK_GT, 5, true },
+		{ TK_EQ, 6, TK_GT, 5, true },
+		{ TK_EQ, 5, TK_GE, 5, true },
+		{ TK_GT, 4, TK_GE, 5, false },
+		{ TK_LT, 3, TK_LT, 5, true },
+		{ TK_LE, 5, TK_LT, 5, false },
+		{ TK_LE, 5, TK_LE, 5, true },
+		{ TK_EQ, 7, TK_EQ, 7, true },
+		{ TK_GT, 7, TK_EQ, 7, false },
+		{ TK_LT, 10, TK_GT, 5, false },
+	} {
+		if got := partialIndexRangeImplies(test.op1, test.v1, test.op2, test.v2); got != test.want {
+			t.Errorf("partialIndexRangeImplies(%v, %v, %v, %v) = %v, want %v", test.op1, test.v1, test.op2, test.v2, got, test.want)
+		}
+	}
+}
+
+//	A partial index holds entries only for the rows its WHERE clause is true of, so that a UNIQUE partial index only constrains those
+//	rows, however a row comes to match or stop matching.
+func TestPartialIndexEntries(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(a, b INTEGER, c)")
+	testExec(t, db, "CREATE UNIQUE INDEX live ON t(a) WHERE c IS NULL")
+	testExec(t, db, "INSERT INTO t VALUES('x', 1, 'gone'), ('x', 2, 'gone'), ('x', 3, NULL)")
+	if _, err := db.Exec("INSERT INTO t VALUES('x', 4, NULL)"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("duplicate in the index: %v, want SQLITE_CONSTRAINT", err)
+	}
+	if _, err := db.Exec("UPDATE t SET c = NULL WHERE b = 1"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("update into the index: %v, want SQLITE_CONSTRAINT", err)
+	}
+	testExec(t, db, "UPDATE t SET c = 'gone' WHERE b = 3")
+	testExec(t, db, "INSERT INTO t VALUES('x', 4, NULL)")
+	testExec(t, db, "DELETE FROM t WHERE b = 4")
+	testExec(t, db, "UPDATE t SET c = NULL WHERE b = 2")
+	testQueryIs(t, db, "2", "SELECT b FROM t INDEXED BY live WHERE a = 'x' AND c IS NULL")
+
+	//	An index created on a table with rows is filled with the matching rows only.
+	testExec(t, db, "CREATE INDEX big ON t(b) WHERE b > 2")
+	testQueryIs(t, db, "3", "SELECT b FROM t INDEXED BY big WHERE b > 2")
+	testQueryIs(t, db, "ok", "PRAGMA integrity_check")
+}
+
+func TestPartialIndexPlanner(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(a, b INTEGER, c)")
+	testExec(t, db, "CREATE INDEX big ON t(b) WHERE b > 5")
+	testExec(t, db, "CREATE INDEX named ON t(a) WHERE c IS NOT NULL")
+	for _, test := range []struct {
+		query	string
+		index	string
+	}{
+		{ "SELECT * FROM t WHERE b > 10", "big" },
+		{ "SELECT * FROM t WHERE b = 7", "big" },
+		{ "SELECT * FROM t WHERE b > 5", "big" },
+		{ "SELECT * FROM t WHERE b > 3", "" },
+		{ "SELECT * FROM t WHERE b < 10", "" },
+		{ "SELECT * FROM t WHERE a = 'x' AND c = 1", "named" },
+		{ "SELECT * FROM t WHERE a = 'x' AND c IS NOT NULL", "named" },
+		{ "SELECT * FROM t WHERE a = 'x'", "" },
+
+		//	The ON clause of a LEFT JOIN does not restrict the rows of the left table.
+		{ "SELECT * FROM t LEFT JOIN (SELECT 1 AS one) ON t.b > 10", "" },
+	} {
+		plan := testQueryPlan(t, db, test.query)
+		if uses := strings.Contains(plan, "INDEX"); uses != (test.index != "") || !strings.Contains(plan, test.index) {
+			t.Errorf("%v: plan %q, want index %q", test.query, plan, test.index)
+		}
+	}
+}
@@ -174,6 +174,7 @@ static int flagPragma(Parse *pParse, const char *zLeft, const char *zRight){
     { "reverse_unordered_selects", SQLITE_ReverseOrder  },
     { "automatic_index",          SQLITE_AutoIndex     },
     { "ignore_check_constraints", SQLITE_IgnoreChecks  },
+    { "query_only",               SQLITE_QueryOnly     },
     /* The following is VERY experimental */
     { "writable_schema",          SQLITE_WriteSchema|SQLITE_RecoveryMode },
 
@@ -739,9 +740,19 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
   ** type:       Column declaration type.
   ** notnull:    True if 'NOT NULL' is part of column declaration
   ** dflt_value: The default value for the column, if any.
+  ** pk:         True if the column is part of the PRIMARY KEY
+  **
+  **   PRAGMA table_xinfo(<table>)
+  **
+  ** The same as table_info, but with a row for the hidden columns of a
+  ** virtual table as well, and an extra column:
+  **
+  ** hidden:     0 for an ordinary column, 1 for a hidden column, 2 for a
+  **             VIRTUAL generated column and 3 for a STORED one
   */
-  if CaseInsensitiveMatch(zLeft, "table_info") && zRight != "" {
+  if (CaseInsensitiveMatch(zLeft, "table_info") || CaseInsensitiveMatch(zLeft, "table_xinfo")) && zRight != "" {
     Table *pTab;
+    isXinfo := CaseInsensitiveMatch(zLeft, "table_xinfo")
 		if pParse.ReadSchema() != SQLITE_OK {
 			goto pragma_out
 		}
@@ -750,17 +761,24 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
       int i;
       int nHidden = 0;
       Column *pCol;
-      sqlite3VdbeSetNumCols(v, 6);
-      pParse.nMem = 6;
+      nResult := 6
+      if isXinfo {
+        nResult = 7
+      }
+      sqlite3VdbeSetNumCols(v, nResult);
+      pParse.nMem = nResult;
       sqlite3VdbeSetColName(v, 0, COLNAME_NAME, "cid", SQLITE_STATIC);
       sqlite3VdbeSetColName(v, 1, COLNAME_NAME, "name", SQLITE_STATIC);
       sqlite3VdbeSetColName(v, 2, COLNAME_NAME, "type", SQLITE_STATIC);
       sqlite3VdbeSetColName(v, 3, COLNAME_NAME, "notnull", SQLITE_STATIC);
       sqlite3VdbeSetColName(v, 4, COLNAME_NAME, "dflt_value", SQLITE_STATIC);
       sqlite3VdbeSetColName(v, 5, COLNAME_NAME, "pk", SQLITE_STATIC);
+      if isXinfo {
+        sqlite3VdbeSetColName(v, 6, COLNAME_NAME, "hidden", SQLITE_STATIC)
+      }
       sqlite3ViewGetColumnNames(pParse, pTab);
       for(i=0, pCol=pTab.Columns; i<pTab.nCol; i++, pCol++){
-        if pCol.IsHidden {
+        if pCol.IsHidden && !isXinfo {
           nHidden++;
           continue;
         }
@@ -775,7 +793,19 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
           v.AddOp2(OP_Null, 0, 5);
         }
         v.AddOp2(OP_Integer, pCol.isPrimKey, 6);
-        v.AddOp2(OP_ResultRow, 1, 6);
+        if isXinfo {
+          hidden := 0
+          switch {
+          case pCol.IsHidden:
+            hidden = 1
+          case pCol.IsVirtualGenerated():
+            hidden = 2
+          case pCol.IsGenerated():
+            hidden = 3
+          }
+          v.AddOp2(OP_Integer, hidden, 7)
+        }
+        v.AddOp2(OP_ResultRow, 1, nResult);
       }
     }
   }else
@@ -799,7 +829,11 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
         v.AddOp2(OP_Integer, i, 1);
         v.AddOp2(OP_Integer, column, 2);
         assert( pTab.nCol > column );
-        sqlite3VdbeAddOp4(v, OP_String8, 0, 3, 0, pTab.Columns[column].Name, 0);
+        if column == XN_EXPR {
+          v.AddOp2(OP_Null, 0, 3)			//	An expression has no name
+        } else {
+          sqlite3VdbeAddOp4(v, OP_String8, 0, 3, 0, pTab.Columns[column].Name, 0);
+        }
         v.AddOp2(OP_ResultRow, 1, 3);
       }
     }
@@ -956,6 +990,9 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
 				v.AddOp2(OP_Integer, pTab.tnum, 2 + cnt)
 				cnt++
 				for _, index := range pTab.Indices {
+					if index.IsClustered() {
+						continue					//	Checked as the root page of the table
+					}
 					v.AddOp2(OP_Integer, index.tnum, 2 + cnt)
 					cnt++
 				}
@@ -988,18 +1025,30 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
 						loopTop := v.AddOp2(OP_Rewind, 1, 0)
 						v.AddOp2(OP_AddImm, 2, 1)				//	increment entry count
 						for j, index := range table.Indices {
+							if index.IsClustered() {
+								continue				//	The entries of the index are the rows of the table
+							}
+							skip := v.MakeLabel()
+							pParse.PartialIndexSkip(index, 1, skip)
 							r1 := sqlite3GenerateIndexKey(pParse, index, 1, 3, 0)
-							jmp2 := sqlite3VdbeAddOp4Int(v, OP_Found, j + 2, 0, r1, len(index.Columns) + 1)
+							jmp2 := sqlite3VdbeAddOp4Int(v, OP_Found, j + 2, 0, r1, index.KeyFields())
 							addr = v.AddOpList(INTEGRITY_CHECK_INDEX_ERROR...)
 							sqlite3VdbeChangeP4(v, addr + 1, "rowid ", P4_STATIC)
 							sqlite3VdbeChangeP4(v, addr + 3, " missing from index ", P4_STATIC)
 							sqlite3VdbeChangeP4(v, addr + 4, pIdx.Name, P4_TRANSIENT)
 							v.JumpHere(addr + 9)
 							v.JumpHere(jmp2)
+							v.ResolveLabel(skip)
 						}
 						v.AddOp2(OP_Next, 1, loopTop+1);
 						v.JumpHere(loopTop)
 						for j, index := range table.Indices {
+							if index.pPartIdxWhere != nil {
+								continue					//	A partial index holds fewer entries than the table
+							}
+							if index.IsClustered() {
+								continue
+							}
 							addr = v.AddOp1(OP_IfPos, 1)
 							v.AddOp2(OP_Halt, 0, 0)
 							v.JumpHere(addr)
@@ -1153,21 +1202,33 @@ const JOURNAL_MODES = []string{ "delete", "persist", "off", "truncate", "memory"
   if CaseInsensitiveMatch(zLeft, "shrink_memory") {
     db.db_release_memory()
   } else if CaseInsensitiveMatch(zLeft, "key") && zRight != "" {
-    sqlite3_key(db, zRight, sqlite3Strlen30(zRight));
-  }else if CaseInsensitiveMatch(zLeft, "rekey") && zRight != "" {
-    sqlite3_rekey(db, zRight, sqlite3Strlen30(zRight));
-  }else if zRight != "" && (CaseInsensitiveMatch(zLeft, "hexkey") || CaseInsensitiveMatch(zLeft, "hexrekey")) {
-    int i, h1, h2;
-    char zKey[40];
-    for(i=0; (h1 = zRight[i])!=0 && (h2 = zRight[i+1])!=0; i+=2){
-      h1 += 9*(1&(h1>>6));
-      h2 += 9*(1&(h2>>6));
-      zKey[i/2] = (h2 & 0x0f) | ((h1 & 0xf)<<4);
+    if rc = sqlite3_key_v2(db, zDb, zRight, len(zRight)); rc != SQLITE_OK {
+      pParse.SetErrorMsg("%v", sqlite3_errmsg(db))
     }
-    if( (zLeft[3] & 0xf)==0xb ){
-      sqlite3_key(db, zKey, i/2);
-    }else{
-      sqlite3_rekey(db, zKey, i/2);
+  } else if CaseInsensitiveMatch(zLeft, "rekey") && pValue != nil {
+    /* An empty passphrase decrypts the database. */
+    if rc = sqlite3_rekey_v2(db, zDb, zRight, len(zRight)); rc != SQLITE_OK {
+      pParse.SetErrorMsg("%v", sqlite3_errmsg(db))
+    }
+  } else if (CaseInsensitiveMatch(zLeft, "hexkey") && zRight != "") || (CaseInsensitiveMatch(zLeft, "hexrekey") && pValue != nil) {
+    isHex := len(zRight) % 2 == 0
+    for i := 0; isHex && i < len(zRight); i++ {
+      isHex = sqlite3Isxdigit(zRight[i])
+    }
+    zKey := make([]byte, len(zRight) / 2)
+    for i := 0; isHex && i < len(zKey); i++ {
+      zKey[i] = (sqlite3HexToInt(zRight[2 * i]) << 4) | sqlite3HexToInt(zRight[2 * i + 1])
+    }
+    switch {
+    case !isHex:
+      pParse.SetErrorMsg("%v must be an even number of hexadecimal digits", zLeft)
+    case CaseInsensitiveMatch(zLeft, "hexkey"):
+      rc = sqlite3_key_v2(db, zDb, string(zKey), len(zKey))
+    default:
+      rc = sqlite3_rekey_v2(db, zDb, string(zKey), len(zKey))
+    }
+    if rc != SQLITE_OK {
+      pParse.SetErrorMsg("%v", sqlite3_errmsg(db))
     }
   }else
 #if defined(SQLITE_ENABLE_CEROD)
@@ -121,6 +121,8 @@ static int lookupName(
 
   assert( pNC != nil )
   assert( zCol );    /* The Z in X.Y.Z cannot be NULL */
+  zName := zCol						//	The name as it appears in the statement
+  zCol = pParse.renameName(zCol)
 
   /* Initialize the node to no-match */
   pExpr.iTable = -1;
@@ -209,7 +211,7 @@ static int lookupName(
             break;
           }
         }
-        if( iCol>=pTab.nCol && sqlite3IsRowid(zCol) ){
+        if( iCol>=pTab.nCol && pTab.HasRowid() && sqlite3IsRowid(zCol) ){
           iCol = -1;        /* IMP: R-44911-55124 */
         }
         if( iCol<pTab.nCol ){
@@ -229,9 +231,10 @@ static int lookupName(
     }
 
     /*
-    ** Perhaps the name is a reference to the ROWID
+    ** Perhaps the name is a reference to the ROWID. A WITHOUT ROWID table
+    ** has none.
     */
-    if( cnt==0 && cntTab==1 && sqlite3IsRowid(zCol) ){
+    if( cnt==0 && cntTab==1 && (pExpr.pTab==0 || pExpr.pTab.HasRowid()) && sqlite3IsRowid(zCol) ){
       cnt = 1;
       pExpr.iColumn = -1;     /* IMP: R-44911-55124 */
       pExpr.affinity = SQLITE_AFF_INTEGER;
@@ -253,6 +256,10 @@ static int lookupName(
 						pParse.SetErrorMsg("misuse of aliased aggregate %v", zAs)
 						return WRC_Abort
 					}
+					if (pNC.Flags & NC_AllowWin) == 0 && pOrig.HasWindow() {
+						pParse.SetErrorMsg("misuse of aliased window function %v", zAs)
+						return WRC_Abort
+					}
 					pExpr = pParse.resolveAlias(pEList, j, pExpr, "")
 					cnt = 1
 					pMatch = 0
@@ -316,6 +323,14 @@ static int lookupName(
     }
     assert( pMatch.iCursor==pExpr.iTable );
     pMatch.colUsed |= ((Bitmask)1)<<n;
+    //	A VIRTUAL generated column is computed from the columns it uses, so they are read as well
+    if pNC.Flags & NC_GenCol == 0 && pExpr.pTab.Columns[pExpr.iColumn].IsVirtualGenerated() {
+      pMatch.colUsed |= pExpr.pTab.GeneratedColUsed(pExpr.iColumn)
+    }
+  }
+
+  if cnt == 1 {
+    pParse.renameResolved(zName, pExpr.pTab, pExpr.iColumn)
   }
 
   /* Clean up and return
@@ -363,6 +378,22 @@ lookupname_end:
   return p;
 }
 
+//	Return the name of the part of the schema whose expressions pNC resolves, for use in error messages, or "" if pNC resolves the
+//	expressions of a statement.
+func (pNC *NameContext) schemaClause() string {
+	switch {
+	case pNC.Flags & NC_IsCheck != 0:
+		return "CHECK constraints"
+	case pNC.Flags & NC_PartIdx != 0:
+		return "partial index WHERE clauses"
+	case pNC.Flags & NC_IdxExpr != 0:
+		return "index expressions"
+	case pNC.Flags & NC_GenCol != 0:
+		return "generated columns"
+	}
+	return ""
+}
+
 //	This routine is callback for Expr().
 //	Resolve symbolic names into TK_COLUMN operators for the current node in the expression tree. Return 0 to continue the search down the tree or 2 to abort the tree walk.
 //	This routine also does error checking and name resolution for function names.  The operator for aggregate functions is changed to TK_AGG_FUNCTION.
@@ -458,6 +489,25 @@ static int resolveExprStep(Walker *pWalker, Expr *pExpr){
       }else{
         is_agg = pDef.xFunc==0;
       }
+      if pWin := pExpr.pWin; pWin != nil {
+        switch {
+        case (pNC.Flags & NC_AllowWin)==0:
+          pParse.SetErrorMsg("misuse of window function %v()", zId)
+          pNC.Errors++
+        case pDef != nil && pDef.xFunc != nil:
+          pParse.SetErrorMsg("%v() may not be used as a window function", zId)
+          pNC.Errors++
+        case pExpr.HasProperty(EP_Distinct):
+          pParse.SetErrorMsg("DISTINCT is not supported for window functions")
+          pNC.Errors++
+        }
+        pWin.pFunc = pDef
+        is_agg = 0
+      }else if pDef != nil && (pDef.flags & SQLITE_FUNC_WINDOW)!=0 {
+        pParse.SetErrorMsg("misuse of window function %v()", zId)
+        pNC.Errors++
+        is_agg = 0
+      }
       if( pDef ){
         if auth = pParse.AuthCheck(SQLITE_FUNCTION, 0, pDef.Name, 0); auth != SQLITE_OK {
           if auth == SQLITE_DENY {
@@ -478,14 +528,26 @@ static int resolveExprStep(Walker *pWalker, Expr *pExpr){
       }else if( wrong_num_args ){
         pParse.SetErrorMsg("wrong number of arguments to function %.v%v()", nId, zId);
         pNC.Errors++
+      }else if pDef.flags & SQLITE_FUNC_NONDETERM != 0 && pNC.Flags & (NC_PartIdx | NC_IdxExpr | NC_GenCol) != 0 {
+        pParse.SetErrorMsg("non-deterministic functions prohibited in %v", pNC.schemaClause())
+        pNC.Errors++
       }
       if( is_agg ){
         pExpr.op = TK_AGG_FUNCTION;
         pNC.Flags |= NC_HasAgg;
       }
+      savedFlags := pNC.Flags & (NC_AllowAgg | NC_AllowWin)
       if( is_agg ) pNC.Flags &= ~NC_AllowAgg;
+      if is_agg || pExpr.pWin != nil {
+        //	Window functions may not be nested in aggregates or in other windows.
+        pNC.Flags &= ~NC_AllowWin
+      }
       pWalker.ExprList(pList)
-      if( is_agg ) pNC.Flags |= NC_AllowAgg;
+      if pWin := pExpr.pWin; pWin != nil {
+        pWalker.ExprList(pWin.pPartition)
+        pWalker.ExprList(pWin.pOrderBy)
+      }
+      pNC.Flags |= savedFlags
       /* FIX ME:  Compute pExpr.affinity based on the expected return
       ** type of the function 
       */
@@ -496,8 +558,8 @@ static int resolveExprStep(Walker *pWalker, Expr *pExpr){
     case TK_IN: {
       if( pExpr.HasProperty(EP_xIsSelect) ){
         int nRef = pNC.References
-        if( (pNC.Flags & NC_IsCheck)!=0 ){
-          pParse.SetErrorMsg("subqueries prohibited in CHECK constraints");
+        if zClause := pNC.schemaClause(); zClause != "" {
+          pParse.SetErrorMsg("subqueries prohibited in %v", zClause)
         }
         pWalker.Select(pExpr.x.Select)
         assert( pNC.References >= nRef );
@@ -508,8 +570,8 @@ static int resolveExprStep(Walker *pWalker, Expr *pExpr){
       break;
     }
     case TK_VARIABLE: {
-      if( (pNC.Flags & NC_IsCheck)!=0 ){
-        pParse.SetErrorMsg("parameters prohibited in CHECK constraints");
+      if zClause := pNC.schemaClause(); zClause != "" {
+        pParse.SetErrorMsg("parameters prohibited in %v", zClause)
       }
       break;
     }
@@ -760,7 +822,7 @@ static int resolveSelectStep(Walker *pWalker, Select *p){
     /* Set up the local name-context to pass to sqlite3ResolveExprNames() to
     ** resolve the result-set expression list.
     */
-    sNC.Flags = NC_AllowAgg;
+    sNC.Flags = NC_AllowAgg | NC_AllowWin;
     sNC.SrcList = p.pSrc;
     sNC.Next = pOuterNC;
   
@@ -827,6 +889,7 @@ static int resolveSelectStep(Walker *pWalker, Select *p){
     ** re-evaluated for each reference to it.
     */
     sNC.pEList = p.pEList;
+    sNC.Flags &= ~NC_AllowWin;
     if( sqlite3ResolveExprNames(&sNC, p.Where) ||
        sqlite3ResolveExprNames(&sNC, p.pHaving)
     ){
@@ -837,7 +900,7 @@ static int resolveSelectStep(Walker *pWalker, Select *p){
     ** outer queries 
     */
     sNC.Next = 0;
-    sNC.Flags |= NC_AllowAgg;
+    sNC.Flags |= NC_AllowAgg | NC_AllowWin;
 
     /* Process the ORDER BY clause for singleton SELECT statements.
     ** The ORDER BY clause for compounds SELECT statements is handled
@@ -853,6 +916,7 @@ static int resolveSelectStep(Walker *pWalker, Select *p){
   
 	//	Resolve the GROUP BY clause. At the same time, make sure the GROUP BY clause does not contain aggregate functions.
 	if pGroupBy != nil {
+		sNC.Flags &= ~NC_AllowWin
 		if !sNC.resolveOrderGroupBy(p, pGroupBy, "GROUP") || db.mallocFailed {
 			return WRC_Abort;
 		}
@@ -861,6 +925,10 @@ static int resolveSelectStep(Walker *pWalker, Select *p){
 				pParse.SetErrorMsg("aggregate functions are not allowed in the GROUP BY clause")
 				return WRC_Abort
 			}
+			if item.HasWindow() {
+				pParse.SetErrorMsg("window functions are not allowed in the GROUP BY clause")
+				return WRC_Abort
+			}
 		}
 	}
 
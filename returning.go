@@ -0,0 +1,186 @@
+import (
+	"fmt"
+)
+
+//	This file implements the RETURNING clause of INSERT, UPDATE and DELETE statements.
+//
+//		INSERT INTO t VALUES(...) RETURNING *
+//		UPDATE t SET a = a + 1 WHERE ... RETURNING rowid, a
+//		DELETE FROM t WHERE ... RETURNING a, b
+//
+//	Parse.ParseReturning() (see Parse.Run()) removes the clause from the statement and records it on Parse.pReturning. The statement
+//	evaluates the RETURNING expressions against each row it changes, after defaults have been applied and triggers have run, and
+//	stores the results in an ephemeral table. Once every change has been made the contents of the ephemeral table are returned
+//	through sqlite3_step() as if the statement were a SELECT.
+
+//	A Returning is the RETURNING clause of an INSERT, UPDATE or DELETE statement.
+type Returning struct {
+	pList			*ExprList			//	The RETURNING expressions
+	pTab			*Table				//	The table the statement changes. Set by ReturningBegin()
+	iTabCur			int					//	Read cursor used to load each changed row
+	iRetCur			int					//	Ephemeral table accumulating the result rows
+	regRet			int					//	First of pList.Len() registers holding a result row
+}
+
+//	If the first statement of zSql is an INSERT, REPLACE, UPDATE or DELETE with a RETURNING clause, parse the clause into
+//	pParse.pReturning and return the statement without it. Otherwise return zSql unchanged. Errors are left in pParse.
+func (pParse *Parse) ParseReturning(zSql string) string {
+	s := newSqlScanner(zSql)
+	s.SkipWith()
+	if s.Type != TK_INSERT && s.Type != TK_REPLACE && s.Type != TK_UPDATE && s.Type != TK_DELETE {
+		return zSql
+	}
+
+	//	Find RETURNING outside of any parentheses. It is not a keyword, so a column named "returning" is recognized by what follows it.
+	depth := 0
+	for ; s.Type != 0 && s.Type != TK_SEMI; s.Next() {
+		if s.Type == TK_LP {
+			depth++
+		} else if s.Type == TK_RP {
+			depth--
+		} else if depth == 0 && s.IsWord("RETURNING") {
+			t := *s
+			if t.Next() && t.Type != TK_EQ && t.Type != TK_DOT && t.Type != TK_COMMA && t.Type != TK_RP {
+				break
+			}
+		}
+	}
+	if s.Type == 0 || s.Type == TK_SEMI {
+		return zSql
+	}
+	iStart := s.Start
+	s.Next()
+	iList := s.Start
+	for depth = 0; s.Type != 0 && (s.Type != TK_SEMI || depth > 0); s.Next() {
+		if s.Type == TK_LP {
+			depth++
+		} else if s.Type == TK_RP {
+			depth--
+		}
+	}
+	pSel := pParse.ParseSelect("SELECT " + zSql[iList:s.Start])
+	if pSel == nil {
+		return zSql
+	}
+	if pSel.pSrc != nil && pSel.pSrc.nSrc > 0 || pSel.Where != nil || pSel.pGroupBy != nil || pSel.pOrderBy != nil || pSel.pLimit != nil {
+		pParse.SetErrorMsg("near \"RETURNING\": syntax error")
+		return zSql
+	}
+	pParse.pReturning = &Returning{ pList: pSel.pEList }
+	return zSql[:iStart] + zSql[s.Start:]
+}
+
+//	Prepare to return rows of pTab through pRet: expand "*" terms, resolve the expressions against pTab, open the cursors and name
+//	the result columns. Leave an error in pParse and return SQLITE_ERROR if the RETURNING clause cannot be used.
+func (pParse *Parse) ReturningBegin(pRet *Returning, pTab *Table) (rc int) {
+	db := pParse.db
+	switch {
+	case pTab.Select != nil:
+		pParse.SetErrorMsg("cannot use RETURNING with a view")
+		return SQLITE_ERROR
+	case pTab.IsVirtual():
+		pParse.SetErrorMsg("RETURNING not implemented for virtual table \"%v\"", pTab.Name)
+		return SQLITE_ERROR
+	}
+	v := pParse.GetVdbe()
+	iDb := db.SchemaToIndex(pTab.Schema)
+
+	//	Replace "*" and "table.*" with the columns of pTab.
+	pList := NewExprList()
+	for _, item := range pRet.pList.Items {
+		pE := item.Expr
+		switch {
+		case pE.op == TK_DOT && pE.pRight.op == TK_ALL && !CaseInsensitiveMatch(pE.pLeft.Token, pTab.Name):
+			pParse.SetErrorMsg("no such table: %v", pE.pLeft.Token)
+			return SQLITE_ERROR
+		case pE.op == TK_ALL, pE.op == TK_DOT && pE.pRight.op == TK_ALL:
+			for _, pCol := range pTab.Columns {
+				if pCol.IsHidden {
+					continue
+				}
+				pList.Items = append(pList.Items, &ExprList_item{ Expr: db.Expr(TK_ID, pCol.Name), Name: pCol.Name })
+			}
+		default:
+			pList.Items = append(pList.Items, item)
+		}
+	}
+	pRet.pList = pList
+	pRet.pTab = pTab
+
+	pRet.iTabCur = pParse.nTab
+	pParse.nTab++
+	pRet.iRetCur = pParse.nTab
+	pParse.nTab++
+	pSrc := db.SrcListAppend(nil, pTab.Name, db.Databases[iDb].Name)
+	pSrc.a[0].pTab = pTab
+	pSrc.a[0].iCursor = pRet.iTabCur
+	sNC := NameContext{ Parse: pParse, SrcList: pSrc }
+	for _, item := range pList.Items {
+		if sqlite3ResolveExprNames(&sNC, item.Expr) {
+			return SQLITE_ERROR
+		}
+		if item.Expr.HasProperty(EP_xIsSelect) {
+			pParse.SetErrorMsg("subqueries are not supported in RETURNING")
+			return SQLITE_ERROR
+		}
+	}
+
+	nCol := pList.Len()
+	pRet.regRet = pParse.nMem + 1
+	pParse.nMem += nCol
+	pParse.OpenTable(pTab, pRet.iTabCur, iDb, OP_OpenRead)
+	v.AddOp2(OP_OpenEphemeral, pRet.iRetCur, nCol)
+
+	sqlite3VdbeSetNumCols(v, nCol)
+	for i, item := range pList.Items {
+		zName := item.Name
+		switch {
+		case zName != "":
+		case item.Span != "":
+			zName = item.Span
+		case item.Expr.op == TK_ID:
+			zName = item.Expr.Token
+		default:
+			zName = fmt.Sprintf("column%v", i + 1)
+		}
+		sqlite3VdbeSetColName(v, i, COLNAME_NAME, zName, SQLITE_TRANSIENT)
+	}
+	return SQLITE_OK
+}
+
+//	Generate code that evaluates the RETURNING expressions against the row of the table whose rowid is in register regRowid, and
+//	saves the result for ReturningEnd(). Nothing is saved if there is no such row.
+func (pParse *Parse) ReturningRow(pRet *Returning, regRowid int) {
+	v := pParse.GetVdbe()
+	nCol := pRet.pList.Len()
+	addrSkip := pParse.SeekRowKey(pRet.pTab, pRet.iTabCur, 0, regRowid)
+	pParse.ExprCachePush()
+	for i, item := range pRet.pList.Items {
+		sqlite3ExprCode(pParse, item.Expr, pRet.regRet + i)
+	}
+	pParse.ExprCachePop(1)
+	regRec := pParse.GetTempReg()
+	regKey := pParse.GetTempReg()
+	v.AddOp3(OP_MakeRecord, pRet.regRet, nCol, regRec)
+	v.AddOp2(OP_NewRowid, pRet.iRetCur, regKey)
+	v.AddOp3(OP_Insert, pRet.iRetCur, regRec, regKey)
+	pParse.ReleaseTempReg(regKey)
+	pParse.ReleaseTempReg(regRec)
+	v.JumpHere(addrSkip)
+}
+
+//	Generate code that returns each row saved by ReturningRow() and closes the cursors opened by ReturningBegin().
+func (pParse *Parse) ReturningEnd(pRet *Returning) {
+	v := pParse.GetVdbe()
+	nCol := pRet.pList.Len()
+	v.AddOp1(OP_Close, pRet.iTabCur)
+	addrEnd := v.AddOp2(OP_Rewind, pRet.iRetCur, 0)
+	addrTop := v.CurrentAddr()
+	for i := 0; i < nCol; i++ {
+		v.AddOp3(OP_Column, pRet.iRetCur, i, pRet.regRet + i)
+	}
+	v.AddOp2(OP_ResultRow, pRet.regRet, nCol)
+	v.AddOp2(OP_Next, pRet.iRetCur, addrTop)
+	v.JumpHere(addrEnd)
+	v.AddOp1(OP_Close, pRet.iRetCur)
+}
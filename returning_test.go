@@ -0,0 +1,51 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestReturning(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(id INTEGER PRIMARY KEY, v TEXT, d DEFAULT 'dflt')")
+
+	testQueryIs(t, db, "1|a|dflt\n2|b|dflt", "INSERT INTO t(v) VALUES('a'), ('b') RETURNING *")
+	testQueryIs(t, db, "3|C", "INSERT INTO t(v) VALUES('c') RETURNING id, upper(v)")
+	testQueryIs(t, db, "1|a!\n3|c!", "UPDATE t SET v = v || '!' WHERE id != 2 RETURNING id, v")
+	testQueryIs(t, db, "2|b", "DELETE FROM t WHERE v NOT LIKE '%!' RETURNING id, v")
+	testQueryIs(t, db, "1|a!\n3|c!", "SELECT id, v FROM t ORDER BY id")
+
+	//	An UPSERT returns the row that was inserted or updated, and nothing for a row left as it was.
+	testQueryIs(t, db, "1|x", "INSERT INTO t(id, v) VALUES(1, 'x') ON CONFLICT(id) DO UPDATE SET v = excluded.v RETURNING id, v")
+	testQueryIs(t, db, "", "INSERT INTO t(id, v) VALUES(1, 'y') ON CONFLICT DO NOTHING RETURNING id")
+
+	rows, err := db.Query("DELETE FROM t RETURNING id AS removed, v")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns, _ := rows.Columns(); strings.Join(columns, ",") != "removed,v" {
+		t.Errorf("Columns() = %v", columns)
+	}
+	rows.Close()
+	testQueryIs(t, db, "0", "SELECT count(*) FROM t")
+}
+
+func TestReturningExec(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x)")
+	res, err := db.Exec("INSERT INTO t VALUES(1), (2), (3) RETURNING x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 3 {
+		t.Errorf("RowsAffected() = %v, want 3", n)
+	}
+	for _, query := range []string{
+		"INSERT INTO t VALUES(4) RETURNING missing",
+		"DELETE FROM t RETURNING max(x)",
+		"SELECT x FROM t RETURNING x",
+	} {
+		if _, err := db.Exec(query); err == nil {
+			t.Errorf("%v: no error", query)
+		}
+	}
+	testQueryIs(t, db, "3", "SELECT count(*) FROM t")
+}
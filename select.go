@@ -297,6 +297,12 @@ static void setJoinExpr(Expr *p, int iTable){
 //	This routine returns the number of errors encountered.
 func (pParse *Parse) ProcessJoin(p *Select) (errors int) {
 	pSrc := p.pSrc
+	//	Bind the arguments of table-valued function calls to the hidden columns of their tables.
+	for i, pItem := range pSrc {
+		if pItem.pFuncArg != nil && pItem.pTab != nil && pParse.TableFunctionArgs(p, i) {
+			return 1
+		}
+	}
 	pLeft := &pSrc[0]
 	pRight := &pLeft[1]
 	for i := 0; i < len(pSrc) - 1; i++, pRight++, pLeft++ {
@@ -563,6 +569,26 @@ static void selectInnerLoop(
       break;
     }
 
+    /* Append the result to the queue of a recursive query. For SRT_DistFifo
+    ** the index at iParm+1 holds every row ever queued so that each
+    ** distinct row is queued only once.
+    */
+    case SRT_Fifo, SRT_DistFifo: {
+      r1 := pParse.GetTempReg()
+      v.AddOp3(OP_MakeRecord, regResult, nColumn, r1)
+      if eDest == SRT_DistFifo {
+        sqlite3VdbeAddOp4Int(v, OP_Found, iParm + 1, v.CurrentAddr() + 3, r1, 0)
+        v.AddOp2(OP_IdxInsert, iParm + 1, r1)
+      }
+      r2 := pParse.GetTempReg()
+      v.AddOp2(OP_NewRowid, iParm, r2)
+      v.AddOp3(OP_Insert, iParm, r1, r2)
+      v.ChangeP5(OPFLAG_APPEND)
+      pParse.ReleaseTempReg(r2)
+      pParse.ReleaseTempReg(r1)
+      break;
+    }
+
     /* Store the result as data using a unique key.
     */
     case SRT_Table:
@@ -1447,6 +1473,135 @@ static int multiSelectOrderBy(
 ** Notice that because of the way SQLite parses compound SELECTs, the
 ** individual selects always group from left to right.
 */
+/*
+** Generate code for a recursive common table expression:
+**
+**     WITH RECURSIVE t(...) AS (<setup> UNION [ALL] <recursive>) ...
+**
+** p is the compound SELECT "<setup> UNION [ALL] <recursive>". Exactly one
+** FROM clause term of <recursive> has isRecursive set; it refers to the
+** row currently being processed. The algorithm is:
+**
+**     Run <setup> and append its rows to the Queue.
+**     while Queue is not empty:
+**         Remove the first row from the Queue and make it Current.
+**         Output Current.
+**         Run <recursive> with t bound to Current, appending to the Queue.
+**
+** For UNION, a row is only appended to the Queue if it has never been
+** appended before (SRT_DistFifo). The LIMIT and OFFSET of p apply to the
+** rows output, which is what terminates an otherwise infinite recursion.
+** An ORDER BY clause is not supported.
+*/
+static int generateWithRecursiveQuery(
+  Parse *pParse,        /* Parsing context */
+  Select *p,            /* The recursive SELECT to be coded */
+  SelectDest *pDest     /* What to do with query results */
+){
+  SrcList *pSrc = p.pSrc;       /* The FROM clause of the recursive query */
+  int nCol = p.pEList.nExpr;    /* Number of columns in the CTE */
+  Vdbe *v = pParse.pVdbe;       /* The prepared statement under construction */
+  Select *pSetup = p.pPrior;    /* The setup query */
+  int addrTop;                  /* Top of the loop */
+  int addrCont, addrBreak;      /* CONTINUE and BREAK addresses */
+  int iCurrent = 0;             /* The Current table */
+  int regCurrent;               /* Register holding Current table */
+  int iQueue;                   /* The Queue table */
+  int eDest = SRT_Fifo;         /* How to write to Queue */
+  SelectDest destQueue;         /* SelectDest targetting the Queue table */
+  int i;                        /* Loop counter */
+  int rc;                       /* Result code */
+  Expr *pLimit, *pOffset;       /* Saved LIMIT and OFFSET */
+  int regLimit, regOffset;      /* Registers used by LIMIT and OFFSET */
+
+  if( p.pOrderBy ){
+    pParse.SetErrorMsg("ORDER BY in a recursive query is not allowed");
+    return SQLITE_ERROR;
+  }
+  if( p.selFlags & SF_Aggregate ){
+    pParse.SetErrorMsg("recursive aggregate queries not supported");
+    return SQLITE_ERROR;
+  }
+
+  /* Process the LIMIT and OFFSET clauses, if they exist. They apply to
+  ** the rows output, not to the rows added to the Queue.
+  */
+  addrBreak = v.MakeLabel()
+  computeLimitRegisters(pParse, p, addrBreak);
+  pLimit = p.pLimit;
+  pOffset = p.pOffset;
+  regLimit = p.iLimit;
+  regOffset = p.iOffset;
+  p.pLimit = p.pOffset = 0;
+  p.iLimit = p.iOffset = 0;
+
+  /* Locate the cursor number of the Current table */
+  for(i=0; i<pSrc.nSrc; i++){
+    if( pSrc.a[i].isRecursive ){
+      iCurrent = pSrc.a[i].iCursor;
+      break;
+    }
+  }
+  assert( i<pSrc.nSrc );
+
+  /* Allocate cursors for the Queue and, for UNION, the index of every
+  ** row ever queued, which SRT_DistFifo expects at iQueue+1.
+  */
+  iQueue = pParse.nTab++;
+  if( p.op==TK_UNION ){
+    eDest = SRT_DistFifo;
+    pParse.nTab++;
+  }
+  sqlite3SelectDestInit(&destQueue, eDest, iQueue);
+
+  regCurrent = ++pParse.nMem;
+  v.AddOp3(OP_OpenPseudo, iCurrent, regCurrent, nCol);
+  v.AddOp2(OP_OpenEphemeral, iQueue, nCol);
+  if( eDest==SRT_DistFifo ){
+    assert( p.addrOpenEphm[0] == -1 );
+    p.addrOpenEphm[0] = v.AddOp2(OP_OpenEphemeral, iQueue+1, 0);
+    p.selFlags |= SF_UsesEphemeral;
+  }
+
+  /* Store the results of the setup-query in the Queue. */
+  rc = sqlite3Select(pParse, pSetup, &destQueue);
+  if( rc ) goto end_of_recursive_query;
+
+  /* Find the next row in the Queue and make it Current */
+  addrTop = v.AddOp2(OP_Rewind, iQueue, addrBreak);
+  v.AddOp1(OP_NullRow, iCurrent);       /* To reset column cache */
+  v.AddOp2(OP_RowData, iQueue, regCurrent);
+  v.AddOp1(OP_Delete, iQueue);
+
+  /* Output the single row in Current */
+  addrCont = v.MakeLabel()
+  p.iLimit = regLimit;
+  p.iOffset = regOffset;
+  selectInnerLoop(pParse, p, p.pEList, iCurrent, nCol, 0, -1, pDest, addrCont, addrBreak);
+  p.iLimit = p.iOffset = 0;
+  v.ResolveLabel(addrCont)
+
+  /* Run the recursive SELECT with Current as the value of the recursive
+  ** table, storing its results in the Queue.
+  */
+  p.pPrior = 0;
+  rc = sqlite3Select(pParse, p, &destQueue);
+  assert( p.pPrior==0 );
+  p.pPrior = pSetup;
+  if( rc ) goto end_of_recursive_query;
+
+  /* Keep running the loop until the Queue is empty */
+  v.AddOp2(OP_Goto, 0, addrTop);
+  v.ResolveLabel(addrBreak)
+
+end_of_recursive_query:
+  p.pLimit = pLimit;
+  p.pOffset = pOffset;
+  p.iLimit = regLimit;
+  p.iOffset = regOffset;
+  return rc;
+}
+
 static int multiSelect(
   Parse *pParse,        /* Parsing context */
   Select *p,            /* The right-most of SELECTs to be coded */
@@ -1509,6 +1664,14 @@ static int multiSelect(
     goto multi_select_end;
   }
 
+  /* Recursive common table expressions are coded separately. They may
+  ** still need KeyInfo attached to their distinct index.
+  */
+  if( p.selFlags & SF_Recursive ){
+    rc = generateWithRecursiveQuery(pParse, p, &dest);
+    goto multi_select_keyinfo;
+  }
+
   /* Compound SELECTs that have an ORDER BY clause are handled separately.
   */
   if( p.pOrderBy ){
@@ -1731,6 +1894,7 @@ static int multiSelect(
 
   explainComposite(pParse, p.op, iSub1, iSub2, p.op!=TK_ALL);
 
+multi_select_keyinfo:
   /* Compute collating sequences used by
   ** temporary tables needed to implement the compound select.
   ** Attach the KeyInfo structure to all temporary tables.
@@ -2552,6 +2716,13 @@ static void substSelect(
 **  (21)  The subquery does not use LIMIT or the outer query is not
 **        DISTINCT.  (See ticket [752e1646fc]).
 **
+**  (22)  The subquery is not a recursive CTE.
+**
+**  (23)  The parent is not a recursive CTE, or the sub-query is not a
+**        compound query.
+**
+**  (24)  The subquery does not compute window functions.
+**
 ** In this routine, the "p" parameter is a pointer to the outer query.
 ** The subquery is p.pSrc.a[iFrom].  isAgg is true if the outer query
 ** uses aggregates and subqueryIsAgg is true if the subquery uses aggregates.
@@ -2622,6 +2793,9 @@ static int flattenSubquery(
   if( pSub.pLimit && (p.selFlags & SF_Distinct)!=0 ){
      return 0;         /* Restriction (21) */
   }
+  if( pSub.selFlags & SF_Recursive ) return 0;           /* Restriction (22) */
+  if( (p.selFlags & SF_Recursive) && pSub.pPrior ) return 0;  /* (23) */
+  if( pSub.pWinProgram ) return 0;                       /* Restriction (24) */
 
   /* OBSOLETE COMMENT 1:
   ** Restriction 3:  If the subquery is a join, make sure the subquery is
@@ -3052,6 +3226,11 @@ static int selectExpander(Walker *pWalker, Select *p){
   pTabList = p.pSrc;
   pEList = p.pEList;
 
+  /* Bring the WITH clause of the compound into scope.  selectPopWith()
+  ** takes it out again once the left-most SELECT has been walked.
+  */
+  pParse.pushWith(p)
+
   /* Make sure cursor numbers have been assigned to all entries in
   ** the FROM clause of the SELECT statement.
   */
@@ -3063,13 +3242,23 @@ static int selectExpander(Walker *pWalker, Select *p){
   */
   for(i=0, pFrom=pTabList.a; i<pTabList.nSrc; i++, pFrom++){
     Table *pTab;
+    if( pFrom.isRecursive ){
+      /* The recursive reference of a CTE was bound by withExpand(). */
+      continue;
+    }
     if( pFrom.pTab!=0 ){
       /* This statement has already been prepared.  There is no need
       ** to go further. */
       assert( i==0 );
       return WRC_Prune;
     }
-    if( pFrom.Name==0 ){
+    if( pParse.withExpand(pWalker, pFrom)!=SQLITE_OK ){
+      return WRC_Abort;
+    }
+    if( pFrom.pTab!=0 ){
+      /* A common table expression from the WITH clause */
+      pTab = pFrom.pTab;
+    }else if( pFrom.Name==0 ){
       Select *pSel = pFrom.Select;
       /* A sub-query in the FROM clause of a SELECT */
       assert( pSel!=0 );
@@ -3095,7 +3284,11 @@ static int selectExpander(Walker *pWalker, Select *p){
         if( sqlite3ViewGetColumnNames(pParse, pTab) ) return WRC_Abort;
         assert( pFrom.Select==0 );
         pFrom.Select = pTab.Select.Dup();
+        /* No CTE of this statement is in scope within the view */
+        pSavedWith := pParse.pWith
+        pParse.pWith = nil
         pWalker.Select(pFrom.Select)
+        pParse.pWith = pSavedWith
       }
     }
 
@@ -3271,6 +3464,7 @@ static int exprWalkNoop(Walker *NotUsed, Expr *NotUsed2){
 static void sqlite3SelectExpand(Parse *pParse, Select *pSelect){
   Walker w;
   w.xSelectCallback = selectExpander;
+  w.SelectCallback2 = selectPopWith
   w.xExprCallback = exprWalkNoop;
   w.pParse = pParse;
   w.Select(pSelect)
@@ -3631,6 +3825,17 @@ static void explainSimpleCount(
     goto select_end;
   }
 
+  //	Move the FROM, WHERE and GROUP BY of a SELECT with window functions into a subquery whose rows the window functions are computed over.
+  if( !p.pPrior ){
+    if pParse.WindowRewrite(p) != SQLITE_OK {
+      goto select_end;
+    }
+    pTabList = p.pSrc;
+    pEList = p.pEList;
+    pOrderBy = p.pOrderBy;
+    isAgg = (p.selFlags & SF_Aggregate)!=0;
+  }
+
   //	Generate code for all sub-queries in the FROM clause
   for(i=0; !p.pPrior && i<pTabList.nSrc; i++){
     struct SrcList_item *pItem = &pTabList.a[i];
@@ -3666,9 +3871,17 @@ static void explainSimpleCount(
 		//	If the subquery is no correlated and if we are not inside of a trigger, then we only need to compute the value of the subquery once.
 		onceAddr = pParse.CodeOnce()
       }
-      sqlite3SelectDestInit(&dest, SRT_EphemTab, pItem.iCursor);
       explainSetInteger(pItem.iSelectId, (byte)pParse.iNextSelectId);
-      sqlite3Select(pParse, pSub, &dest);
+      if pSub.pWinProgram != nil {
+        //	The subquery fills a scratch table that its window functions read.
+        iWin := pParse.nTab++
+        sqlite3SelectDestInit(&dest, SRT_EphemTab, iWin);
+        sqlite3Select(pParse, pSub, &dest);
+        pParse.CodeWindow(pSub.pWinProgram, iWin, pItem.iCursor)
+      } else {
+        sqlite3SelectDestInit(&dest, SRT_EphemTab, pItem.iCursor);
+        sqlite3Select(pParse, pSub, &dest);
+      }
       pItem.pTab.nRowEst = (unsigned)pSub.nSelectRow;
       if onceAddr {
 		  v.JumpHere(onceAddr)
@@ -4173,7 +4386,7 @@ static void explainSimpleCount(
         ** passed to keep OP_OpenRead happy.
         */
 		for _, index := range pTab.Indices {
-			if !index.bUnordered && (!pBest || len(index.Columns) < len(pBest.Columns)) {
+			if !index.bUnordered && index.pPartIdxWhere == nil && (!pBest || len(index.Columns) < len(pBest.Columns)) {
 				pBest = index
 			}
         }
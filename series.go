@@ -0,0 +1,214 @@
+import (
+	"unsafe"
+)
+
+//	This file implements the generate_series eponymous virtual table, which returns a sequence of integers:
+//
+//		SELECT value FROM generate_series(1, 10, 2)			--	1, 3, 5, 7, 9
+//		SELECT value FROM generate_series WHERE start = 5 AND stop = 8
+//
+//	The arguments are the hidden start, stop and step columns. stop defaults to 4294967295 and step to 1. The series holds start and
+//	every value start + n * step up to stop. A negative step returns the same values in descending order, as does ORDER BY value DESC;
+//	ORDER BY value returns them in ascending order whatever the step.
+
+//	Columns of generate_series.
+const (
+	SERIES_VALUE = iota
+	SERIES_START
+	SERIES_STOP
+	SERIES_STEP
+)
+
+const seriesSchema = "CREATE TABLE x(value,start HIDDEN,stop HIDDEN,step HIDDEN)"
+
+//	Bits of the idxNum of a generate_series plan. The first three say which of the start, stop and step arguments are passed to
+//	xFilter, in that order.
+const (
+	seriesStart = 1 << iota
+	seriesStop
+	seriesStep
+	seriesDesc								//	Return the values in descending order
+	seriesAsc								//	Return the values in ascending order, even for a negative step
+)
+
+type seriesCursor struct {
+	base		sqlite3_vtab_cursor
+	iStart		int64					//	Arguments of the series, returned by the hidden columns
+	iStop		int64
+	iStep		int64
+	iFirst		int64					//	First value of the series
+	iLast		int64					//	Last value of the series
+	iIncr		uint64					//	Difference between successive values
+	isDesc		bool
+	iValue		int64					//	Current value
+	iRowid		int64
+	isEof		bool
+}
+
+func seriesConnect(db *sqlite3, pAux interface{}, argc int, argv []string, ppVtab **sqlite3_vtab, pzErr *string) (rc int) {
+	if rc = db.DeclareVTab(seriesSchema); rc == SQLITE_OK {
+		*ppVtab = new(sqlite3_vtab)
+	}
+	return
+}
+
+func seriesDisconnect(pVtab *sqlite3_vtab) int {
+	return SQLITE_OK
+}
+
+//	Pass every usable equality constraint on the start, stop and step columns to xFilter. The start argument is required: without it
+//	the plan is given a very high cost, and xFilter reports an error if it is used anyway.
+func seriesBestIndex(pVtab *sqlite3_vtab, pIdxInfo *sqlite3_index_info) int {
+	aIdx := [3]int{ -1, -1, -1 }
+	for i := 0; i < pIdxInfo.nConstraint; i++ {
+		pCons := &pIdxInfo.aConstraint[i]
+		if pCons.usable == 0 || pCons.op != SQLITE_INDEX_CONSTRAINT_EQ || pCons.iColumn < SERIES_START || pCons.iColumn > SERIES_STEP {
+			continue
+		}
+		aIdx[pCons.iColumn - SERIES_START] = i
+	}
+	nArg := 0
+	for j, i := range aIdx {
+		if i >= 0 {
+			nArg++
+			pIdxInfo.aConstraintUsage[i].argvIndex = nArg
+			pIdxInfo.aConstraintUsage[i].omit = 1
+			pIdxInfo.idxNum |= 1 << uint(j)
+		}
+	}
+	if pIdxInfo.idxNum & seriesStart == 0 {
+		pIdxInfo.estimatedCost = 1e99
+		return SQLITE_OK
+	}
+	if pIdxInfo.idxNum & seriesStop != 0 {
+		pIdxInfo.estimatedCost = 1000
+	} else {
+		pIdxInfo.estimatedCost = 4294967295
+	}
+	if pIdxInfo.nOrderBy == 1 && pIdxInfo.aOrderBy[0].iColumn == SERIES_VALUE {
+		if pIdxInfo.aOrderBy[0].desc != 0 {
+			pIdxInfo.idxNum |= seriesDesc
+		} else {
+			pIdxInfo.idxNum |= seriesAsc
+		}
+		pIdxInfo.orderByConsumed = 1
+	}
+	return SQLITE_OK
+}
+
+func seriesOpen(pVtab *sqlite3_vtab, ppCursor **sqlite3_vtab_cursor) int {
+	pCur := new(seriesCursor)
+	*ppCursor = &pCur.base
+	return SQLITE_OK
+}
+
+func seriesClose(cur *sqlite3_vtab_cursor) int {
+	return SQLITE_OK
+}
+
+func seriesFilter(cur *sqlite3_vtab_cursor, idxNum int, idxStr string, argc int, argv []*sqlite3_value) int {
+	pCur := (*seriesCursor)(unsafe.Pointer(cur))
+	if idxNum & seriesStart == 0 {
+		cur.pVtab.zErrMsg = "first argument to generate_series() missing or unusable"
+		return SQLITE_ERROR
+	}
+	pCur.iStart, pCur.iStop, pCur.iStep = 0, 4294967295, 1
+	pCur.isEof = true
+	pCur.iRowid = 1
+	i := 0
+	for j, p := range []*int64{ &pCur.iStart, &pCur.iStop, &pCur.iStep } {
+		if idxNum & (1 << uint(j)) == 0 {
+			continue
+		}
+		if sqlite3_value_type(argv[i]) == SQLITE_NULL {
+			//	A NULL argument makes the series empty.
+			return SQLITE_OK
+		}
+		*p = sqlite3_value_int64(argv[i])
+		i++
+	}
+	//	The range and the step are computed unsigned, so that neither overflows for any start, stop and step.
+	iStep := uint64(pCur.iStep)
+	pCur.isDesc = idxNum & seriesDesc != 0
+	switch {
+	case pCur.iStep == 0:
+		iStep = 1
+	case pCur.iStep < 0:
+		iStep = -iStep
+		if idxNum & (seriesDesc | seriesAsc) == 0 {
+			pCur.isDesc = true
+		}
+	}
+	if pCur.iStart > pCur.iStop {
+		return SQLITE_OK
+	}
+	pCur.iFirst = pCur.iStart
+	pCur.iLast = int64(uint64(pCur.iStart) + (uint64(pCur.iStop) - uint64(pCur.iStart)) / iStep * iStep)
+	pCur.iIncr = iStep
+	pCur.isEof = false
+	if pCur.isDesc {
+		pCur.iValue = pCur.iLast
+	} else {
+		pCur.iValue = pCur.iFirst
+	}
+	return SQLITE_OK
+}
+
+func seriesNext(cur *sqlite3_vtab_cursor) int {
+	pCur := (*seriesCursor)(unsafe.Pointer(cur))
+	if pCur.isDesc {
+		pCur.isEof = pCur.iValue == pCur.iFirst
+		pCur.iValue = int64(uint64(pCur.iValue) - pCur.iIncr)
+	} else {
+		pCur.isEof = pCur.iValue == pCur.iLast
+		pCur.iValue = int64(uint64(pCur.iValue) + pCur.iIncr)
+	}
+	pCur.iRowid++
+	return SQLITE_OK
+}
+
+func seriesEof(cur *sqlite3_vtab_cursor) int {
+	if (*seriesCursor)(unsafe.Pointer(cur)).isEof {
+		return 1
+	}
+	return 0
+}
+
+func seriesColumn(cur *sqlite3_vtab_cursor, ctx *sqlite3_context, i int) int {
+	pCur := (*seriesCursor)(unsafe.Pointer(cur))
+	switch i {
+	case SERIES_VALUE:
+		sqlite3_result_int64(ctx, pCur.iValue)
+	case SERIES_START:
+		sqlite3_result_int64(ctx, pCur.iStart)
+	case SERIES_STOP:
+		sqlite3_result_int64(ctx, pCur.iStop)
+	case SERIES_STEP:
+		sqlite3_result_int64(ctx, pCur.iStep)
+	}
+	return SQLITE_OK
+}
+
+func seriesRowid(cur *sqlite3_vtab_cursor, pRowid *int64) int {
+	*pRowid = (*seriesCursor)(unsafe.Pointer(cur)).iRowid
+	return SQLITE_OK
+}
+
+//	generate_series has no xCreate method, which makes it eponymous-only.
+var seriesModule = sqlite3_module{
+	xConnect:		seriesConnect,
+	xBestIndex:		seriesBestIndex,
+	xDisconnect:	seriesDisconnect,
+	xOpen:			seriesOpen,
+	xClose:			seriesClose,
+	xFilter:		seriesFilter,
+	xNext:			seriesNext,
+	xEof:			seriesEof,
+	xColumn:		seriesColumn,
+	xRowid:			seriesRowid,
+}
+
+//	Register the generate_series module with connection db. This is called when the connection is opened.
+func (db *sqlite3) SeriesInit() int {
+	return db.create_module("generate_series", &seriesModule, nil)
+}
@@ -0,0 +1,114 @@
+//	This file implements snapshots of databases in WAL mode, which let a read transaction of one connection see the database exactly
+//	as a read transaction of another connection does:
+//
+//		sqlite3_exec(db1, "BEGIN", nil, nil, nil)
+//		sqlite3_snapshot_get(db1, "main", &pSnapshot)
+//
+//		sqlite3_exec(db2, "BEGIN", nil, nil, nil)
+//		sqlite3_snapshot_open(db2, "main", pSnapshot)
+//		...												db2 reads the database as db1 does
+//		sqlite3_snapshot_free(pSnapshot)
+//
+//	A Snapshot is a copy of the wal-index header of the read transaction it was taken from, which does not change once taken. It
+//	holds mxFrame, the last frame of the WAL that the transaction reads, the size of the database and the salt of the WAL, which
+//	changes each time the WAL is restarted. The next read transaction of a connection that opens a snapshot locks an aReadMark[] slot
+//	no greater than its mxFrame and uses the snapshot as its wal-index header instead of the head of the WAL.
+//
+//	A snapshot can be opened as long as the WAL has not been restarted and no checkpoint has copied frames past its mxFrame into the
+//	database file; otherwise the read transaction fails with SQLITE_BUSY_SNAPSHOT. A read transaction on the snapshot holds a read mark
+//	that prevents both, so a connection that keeps the transaction the snapshot was taken from open keeps the snapshot available to
+//	the others.
+
+//	A Snapshot identifies a state of a database in WAL mode. Snapshots are ordered by sqlite3_snapshot_cmp().
+type Snapshot struct {
+	hdr		WalIndexHdr
+}
+
+//	Return the b-tree of database zSchema of db for the snapshot functions, which require db to be in a transaction.
+func snapshotBtree(db *sqlite3, zSchema string) *Btree {
+	if db.autoCommit != 0 {
+		db.Error(SQLITE_ERROR, "cannot use a snapshot outside a transaction")
+		return nil
+	}
+	iDb := db.FindDbName(zSchema)
+	if iDb < 0 || db.Databases[iDb].pBt == nil {
+		db.Error(SQLITE_ERROR, "unknown database %v", zSchema)
+		return nil
+	}
+	return db.Databases[iDb].pBt
+}
+
+//	Take a snapshot of database zSchema as the read transaction of db sees it, starting the read transaction if there is none. The
+//	transaction must not have written to the database.
+func sqlite3_snapshot_get(db *sqlite3, zSchema string, ppSnapshot **Snapshot) (rc int) {
+	*ppSnapshot = nil
+	db.mutex.CriticalSection(func() {
+		pBt := snapshotBtree(db, zSchema)
+		switch {
+		case pBt == nil:
+			rc = SQLITE_ERROR
+		case pBt.IsInTrans():
+			db.Error(SQLITE_ERROR, "cannot take a snapshot within a write transaction")
+			rc = SQLITE_ERROR
+		default:
+			if rc = pBt.BeginTransaction(0); rc == SQLITE_OK {
+				var pHdr *WalIndexHdr
+				if pHdr, rc = sqlite3PagerSnapshotGet(pBt.Pager()); rc == SQLITE_OK {
+					*ppSnapshot = &Snapshot{ hdr: *pHdr }
+				}
+			}
+		}
+	})
+	return
+}
+
+//	Start the read transaction of db on database zSchema at pSnapshot. db must be in a transaction that has not read the database yet.
+func sqlite3_snapshot_open(db *sqlite3, zSchema string, pSnapshot *Snapshot) (rc int) {
+	db.mutex.CriticalSection(func() {
+		pBt := snapshotBtree(db, zSchema)
+		switch {
+		case pBt == nil:
+			rc = SQLITE_ERROR
+		case sqlite3BtreeIsInReadTrans(pBt) != 0:
+			db.Error(SQLITE_ERROR, "cannot open a snapshot within a read transaction")
+			rc = SQLITE_ERROR
+		default:
+			pPager := pBt.Pager()
+			if rc = sqlite3PagerSnapshotOpen(pPager, &pSnapshot.hdr); rc != SQLITE_OK {
+				//	The WAL is opened by the first read transaction on the database.
+				if rc = pBt.BeginTransaction(0); rc == SQLITE_OK {
+					rc = pBt.Commit()
+				}
+				if rc == SQLITE_OK {
+					rc = sqlite3PagerSnapshotOpen(pPager, &pSnapshot.hdr)
+				}
+			}
+			if rc == SQLITE_OK {
+				rc = pBt.BeginTransaction(0)
+				sqlite3PagerSnapshotOpen(pPager, nil)
+			}
+		}
+	})
+	return
+}
+
+//	Return a negative number, zero or a positive number as p1 is older than, the same as or newer than p2. Only snapshots of the same
+//	database can be compared, and the result is only meaningful if both can still be opened.
+func sqlite3_snapshot_cmp(p1, p2 *Snapshot) int {
+	//	The first salt is incremented each time the WAL is restarted.
+	iSalt1, iSalt2 := Buffer(p1.hdr.aSalt[0:]).ReadUint32(), Buffer(p2.hdr.aSalt[0:]).ReadUint32()
+	switch {
+	case iSalt1 < iSalt2:
+		return -1
+	case iSalt1 > iSalt2:
+		return 1
+	case p1.hdr.mxFrame < p2.hdr.mxFrame:
+		return -1
+	case p1.hdr.mxFrame > p2.hdr.mxFrame:
+		return 1
+	}
+	return 0
+}
+
+//	Release pSnapshot. A Snapshot holds no locks or other resources, so this only exists to pair with sqlite3_snapshot_get().
+func sqlite3_snapshot_free(pSnapshot *Snapshot) {}
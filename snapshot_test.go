@@ -0,0 +1,79 @@
+import "testing"
+
+func TestSnapshot(t *testing.T) {
+	zFile := testFile(t)
+	db1, db2, db3 := testOpen(t, zFile), testOpen(t, zFile), testOpen(t, zFile)
+	testQueryIs(t, db1, "wal", "PRAGMA journal_mode = WAL")
+	testExec(t, db1, "CREATE TABLE t(x); INSERT INTO t VALUES(1)")
+
+	var pOld, pNew *Snapshot
+	testExec(t, db1, "BEGIN")
+	testRaw(t, db1, func(db *sqlite3) {
+		if rc := sqlite3_snapshot_get(db, "main", &pOld); rc != SQLITE_OK {
+			t.Fatalf("sqlite3_snapshot_get() = %v", rc)
+		}
+	})
+	testExec(t, db3, "INSERT INTO t VALUES(2)")
+
+	//	Another connection reads the database as the snapshot saw it, and then as it is.
+	testExec(t, db2, "BEGIN")
+	testRaw(t, db2, func(db *sqlite3) {
+		if rc := sqlite3_snapshot_open(db, "main", pOld); rc != SQLITE_OK {
+			t.Fatalf("sqlite3_snapshot_open() = %v", rc)
+		}
+	})
+	testQueryIs(t, db2, "1", "SELECT count(*) FROM t")
+	testExec(t, db2, "COMMIT")
+	testQueryIs(t, db2, "2", "SELECT count(*) FROM t")
+
+	testExec(t, db2, "BEGIN")
+	testRaw(t, db2, func(db *sqlite3) {
+		if rc := sqlite3_snapshot_get(db, "main", &pNew); rc != SQLITE_OK {
+			t.Fatalf("sqlite3_snapshot_get() = %v", rc)
+		}
+		//	A transaction that has read the database cannot open a snapshot.
+		if rc := sqlite3_snapshot_open(db, "main", pOld); rc != SQLITE_ERROR {
+			t.Errorf("sqlite3_snapshot_open() in a read transaction = %v, want SQLITE_ERROR", rc)
+		}
+	})
+	testExec(t, db2, "COMMIT")
+	if sqlite3_snapshot_cmp(pOld, pNew) >= 0 || sqlite3_snapshot_cmp(pNew, pOld) <= 0 || sqlite3_snapshot_cmp(pOld, pOld) != 0 {
+		t.Error("snapshots not ordered")
+	}
+
+	//	Once the frames after the snapshot are checkpointed it can no longer be opened.
+	testExec(t, db1, "COMMIT")
+	testExec(t, db3, "PRAGMA wal_checkpoint")
+	testExec(t, db2, "BEGIN")
+	testRaw(t, db2, func(db *sqlite3) {
+		if rc := sqlite3_snapshot_open(db, "main", pOld); rc & 0xff != SQLITE_BUSY {
+			t.Errorf("sqlite3_snapshot_open() after a checkpoint = %v, want SQLITE_BUSY_SNAPSHOT", rc)
+		}
+	})
+	testExec(t, db2, "COMMIT")
+	sqlite3_snapshot_free(pOld)
+	sqlite3_snapshot_free(pNew)
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	db := testOpen(t, testFile(t))
+	testQueryIs(t, db, "wal", "PRAGMA journal_mode = WAL")
+	testExec(t, db, "CREATE TABLE t(x)")
+	testRaw(t, db, func(db *sqlite3) {
+		var pSnapshot *Snapshot
+		if rc := sqlite3_snapshot_get(db, "main", &pSnapshot); rc != SQLITE_ERROR || pSnapshot != nil {
+			t.Errorf("sqlite3_snapshot_get() outside a transaction = %v", rc)
+		}
+	})
+	testExec(t, db, "BEGIN; INSERT INTO t VALUES(1)")
+	testRaw(t, db, func(db *sqlite3) {
+		var pSnapshot *Snapshot
+		if rc := sqlite3_snapshot_get(db, "main", &pSnapshot); rc != SQLITE_ERROR {
+			t.Errorf("sqlite3_snapshot_get() in a write transaction = %v", rc)
+		}
+		if rc := sqlite3_snapshot_get(db, "missing", &pSnapshot); rc != SQLITE_ERROR {
+			t.Errorf("sqlite3_snapshot_get() of an unknown database = %v", rc)
+		}
+	})
+	testExec(t, db, "ROLLBACK")
+}
@@ -542,6 +542,7 @@ func int sqlite3_exec(
 #define SQLITE_IOERR_SEEK              (SQLITE_IOERR | (22<<8))
 #define SQLITE_LOCKED_SHAREDCACHE      (SQLITE_LOCKED |  (1<<8))
 #define SQLITE_BUSY_RECOVERY           (SQLITE_BUSY   |  (1<<8))
+#define SQLITE_BUSY_SNAPSHOT           (SQLITE_BUSY   |  (2<<8))
 #define SQLITE_CANTOPEN_NOTEMPDIR      (SQLITE_CANTOPEN | (1<<8))
 #define SQLITE_CANTOPEN_ISDIR          (SQLITE_CANTOPEN | (2<<8))
 #define SQLITE_CORRUPT_VTAB            (SQLITE_CORRUPT | (1<<8))
@@ -575,8 +576,9 @@ func int sqlite3_exec(
 #define SQLITE_OPEN_SHAREDCACHE      0x00020000  /* Ok for sqlite3_open_v2() */
 #define SQLITE_OPEN_PRIVATECACHE     0x00040000  /* Ok for sqlite3_open_v2() */
 #define SQLITE_OPEN_WAL              0x00080000  /* VFS only */
+#define SQLITE_OPEN_EXCLUSIVE_PROCESS 0x00100000 /* Ok for sqlite3_open_v2() */
 
-/* Reserved:                         0x00F00000 */
+/* Reserved:                         0x00E00000 */
 
 /*
 ** CAPI3REF: Device Characteristics
@@ -673,6 +675,7 @@ func int sqlite3_exec(
 typedef struct sqlite3_file sqlite3_file;
 struct sqlite3_file {
   const struct sqlite3_io_methods *pMethods;  /* Methods for an open file */
+	goFile				File						//	Go implementation of the file if opened through a VFS added by RegisterVFS()
 };
 
 /*
@@ -4016,8 +4019,7 @@ func int sqlite3_collation_needed(
 ** Specify the key for an encrypted database.  This routine should be
 ** called right after sqlite3_open().
 **
-** The code to implement this API is not available in the public release
-** of SQLite.
+** The built-in AES-GCM codec that implements this API is in codec.go.
 */
 func int sqlite3_key(
   sqlite3 *db,                   /* Database to be rekeyed */
@@ -4029,8 +4031,7 @@ func int sqlite3_key(
 ** encrypted, this routine will encrypt it.  If pNew==0 or nNew==0, the
 ** database is decrypted.
 **
-** The code to implement this API is not available in the public release
-** of SQLite.
+** The built-in AES-GCM codec that implements this API is in codec.go.
 */
 func int sqlite3_rekey(
   sqlite3 *db,                   /* Database to be rekeyed */
@@ -6424,6 +6425,8 @@ struct sqlite3_rtree_geometry {
 #define TK_CONST_FUNC                     155
 #define TK_UMINUS                         156
 #define TK_UPLUS                          157
+#define TK_WITH                           158
+#define TK_RECURSIVE                      159
 
 /************** End of parse.h ***********************************************/
 /************** Continuing where we left off in sqliteInt.h ******************/
@@ -6841,6 +6844,7 @@ struct VdbeOp {
     KeyInfo *pKeyInfo;     /* Used when p4type is P4_KEYINFO */
     int *ai;               /* Used when p4type is P4_INTARRAY */
     *SubProgram				//	Used when p4type is P4_SUBPROGRAM
+    *Window					//	Used when p4type is P4_WINDOW
     int (*xAdvance)(btree.Cursor *, int *);
   } p4;
 #ifdef VDBE_PROFILE
@@ -6889,6 +6893,7 @@ type VdbeOpList {
 #define P4_INTARRAY (-15) /* P4 is a vector of 32-bit integers */
 #define P4_SUBPROGRAM  (-18) /* P4 is a pointer to a SubProgram structure */
 #define P4_ADVANCE  (-19) /* P4 is a pointer to BtreeNext() or BtreePrev() */
+#define P4_WINDOW   (-20) /* P4 is a pointer to a Window structure */
 
 /* When adding a P4 argument using P4_KEYINFO, a copy of the KeyInfo structure
 ** is made.  That copy is freed when the Vdbe is finalized.  But if the
@@ -7072,6 +7077,7 @@ type VdbeOpList {
 #define OP_Trace                              148
 #define OP_Noop                               149
 #define OP_Explain                            150
+#define OP_Window                             151
 
 
 // Properties such as "out2" or "jump" that are specified in comments following the "case" for each opcode in the vdbe.c are encoded into BitVectors as follows:
@@ -7101,7 +7107,7 @@ type VdbeOpList {
 /* 120 */ 0x05, 0x05, 0x05, 0x00, 0x00, 0x00, 0x02, 0x00,\
 /* 128 */ 0x01, 0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00,\
 /* 136 */ 0x01, 0x00, 0x01, 0x00, 0x00, 0x04, 0x04, 0x04,\
-/* 144 */ 0x04, 0x04, 0x02, 0x02, 0x00, 0x00, 0x00,}
+/* 144 */ 0x04, 0x04, 0x02, 0x02, 0x00, 0x00, 0x00, 0x01,}
 
 /************** End of opcodes.h *********************************************/
 /************** Continuing where we left off in vdbe.h ***********************/
@@ -7734,6 +7740,7 @@ struct sqlite3 {
   int errCode;                  /* Most recent error code (SQLITE_*) */
   int errMask;                  /* & result codes with this before returning */
   byte autoCommit;                /* The auto-commit flag. */
+	isConcurrent			bool					//	True if the open transaction was started by BEGIN CONCURRENT
   byte temp_store;                /* 1: file 2: memory 0: default */
   byte mallocFailed;              /* True if we have seen a malloc failure */
   byte dfltLockMode;              /* Default locking-mode for attached dbs */
@@ -7778,6 +7785,9 @@ struct sqlite3 {
     volatile int isInterrupted; /* True if sqlite3_interrupt has been called */
     double notUsed1;            /* Spacer */
   } u1;
+	ctx						context.Context			//	Context whose cancellation interrupts the running statement
+	pStepping				*Vdbe					//	Statement being run by StepContext()
+	stepMutex				sync.Mutex				//	Guards ctx and pStepping against the goroutines that watch contexts
   Lookaside lookaside;          /* Lookaside malloc configuration */
   int (*xAuth)(void*,int,const char*,const char*,const char*,const char*);
                                 /* Access authorization function */
@@ -7848,7 +7858,7 @@ func (db *sqlite3) SetEncoding(e byte) {
 #define SQLITE_SqlTrace       0x00004000  /* Debug print SQL as it executes */
 #define SQLITE_VdbeListing    0x00008000  /* Debug listings of VDBE programs */
 #define SQLITE_WriteSchema    0x00010000  /* OK to update SQLITE_MASTER */
-                         /*   0x00020000  Unused */
+#define SQLITE_QueryOnly     0x00020000  /* Disable database changes */
 #define SQLITE_IgnoreChecks   0x00040000  /* Do not enforce check constraints */
 #define SQLITE_ReadUncommitted 0x0080000  /* For shared-cache mode */
 #define SQLITE_LegacyFileFmt  0x00100000  /* Create new databases in format 1 */
@@ -7883,7 +7893,7 @@ func (db *sqlite3) SetEncoding(e byte) {
 struct FuncDef {
   int16 nArg;            /* Number of arguments.  -1 means unlimited */
   byte iPrefEnc;         /* Preferred text encoding (SQLITE_UTF8) */
-  byte flags;            /* Some combination of SQLITE_FUNC_* */
+  uint16 flags;          /* Some combination of SQLITE_FUNC_* */
   void *pUserData;     /* User data parameter */
   FuncDef *Next;      /* Next function with same name */
   void (*xFunc)(sqlite3_context*,int,sqlite3_value**); /* Regular function */
@@ -7892,6 +7902,8 @@ struct FuncDef {
   char *Name;         /* SQL name of the function. */
   FuncDef *pHash;      /* Next with a different name but the same hash */
   FuncDestructor *pDestructor;   /* Reference counted destructor function */
+  void (*xValue)(sqlite3_context*);                   /* Window aggregate current value */
+  void (*xInverse)(sqlite3_context*,int,sqlite3_value**); /* Window aggregate inverse step */
 };
 
 /*
@@ -7927,6 +7939,8 @@ struct FuncDestructor {
 #define SQLITE_FUNC_COALESCE 0x20 /* Built-in coalesce() or ifnull() function */
 #define SQLITE_FUNC_LENGTH   0x40 /* Built-in length() function */
 #define SQLITE_FUNC_TYPEOF   0x80 /* Built-in typeof() function */
+#define SQLITE_FUNC_WINDOW  0x100 /* Built-in window-only function */
+#define SQLITE_FUNC_NONDETERM 0x200 /* Result may differ between calls with the same arguments */
 
 /*
 ** The following three macros, FUNCTION(), LIKEFUNC() and AGGREGATE() are
@@ -7967,6 +7981,11 @@ struct FuncDestructor {
 #define AGGREGATE(Name, nArg, arg, nc, xStep, xFinal) \
   {nArg, SQLITE_UTF8, nc*SQLITE_FUNC_NEEDCOLL, \
    SQLITE_INT_TO_PTR(arg), 0, 0, xStep,xFinal,#Name,0,0}
+#define WINDOWAGG(Name, nArg, arg, nc, xStep, xFinal, xValue, xInverse) \
+  {nArg, SQLITE_UTF8, nc*SQLITE_FUNC_NEEDCOLL, \
+   SQLITE_INT_TO_PTR(arg), 0, 0, xStep,xFinal,#Name,0,0,xValue,xInverse}
+#define WINDOWFUNC(Name, nArg) \
+  {nArg, SQLITE_UTF8, SQLITE_FUNC_WINDOW, 0, 0, 0, 0, 0, #Name, 0, 0}
 
 /*
 ** All current savepoints are stored in a linked list starting at
@@ -7993,6 +8012,7 @@ type Module struct {
 	Name		string
 	Parameter	interface{}
 	xDestroy	func(interface{}) interface{}		//	Module destructor function
+	pEpoTab		*Table								//	Eponymous virtual table, connected on first use, or nil
 }
 
 /*
@@ -8009,6 +8029,8 @@ struct Column {
   byte isPrimKey;    /* True if this column is part of the PRIMARY KEY */
   char affinity;   /* One of the SQLITE_AFF_... values */
 	IsHidden		bool			//	True if this column is 'hidden'
+	pGenerated		*Expr			//	Expression that computes a generated column, or nil
+	isStored		bool			//	True if the generated column is STORED rather than VIRTUAL
 };
 
 /*
@@ -8158,11 +8180,17 @@ struct Table {
 #define TF_HasPrimaryKey   0x04    /* Table has a primary key */
 #define TF_Autoincrement   0x08    /* Integer primary key is autoincrement */
 #define TF_Virtual         0x10    /* Is a virtual table */
+#define TF_Eponymous       0x20    /* Eponymous virtual table, not in the schema */
+#define TF_WithoutRowid    0x40    /* Rows are stored in the PRIMARY KEY b-tree */
 
 func (t *Table) IsVirtual() bool {
 	return t.tabFlags & TF_Virtual != 0
 }
 
+func (t *Table) HasRowid() bool {
+	return t.tabFlags & TF_WithoutRowid == 0
+}
+
 /*
 ** Each foreign key constraint is an instance of the following structure.
 **
@@ -8238,6 +8266,7 @@ struct ForeignKey {
 #define OE_SetNull  7   /* Set the foreign key value to NULL */
 #define OE_SetDflt  8   /* Set the foreign key value to its default */
 #define OE_Cascade  9   /* Cascade the changes */
+#define OE_Update   10  /* Run the DO UPDATE of an UPSERT */
 
 #define OE_Default  99  /* Do whatever the default action is */
 
@@ -8304,8 +8333,15 @@ struct Index {
 	nSample			int						//	Number of elements in aSample[]
 	avgEq			tRowcnt					//	Average nEq value for key values not in aSample
 	aSample			*IndexSample			//	Samples of the left-most key
+	pPartIdxWhere	*Expr					//	WHERE clause of a partial index, or nil
+	aColExpr		[]*Expr					//	Expression for each XN_EXPR entry of Columns, or nil if there are none
 }
 
+//	Special values of Index.Columns.
+const (
+	XN_EXPR = -2							//	The index column is the expression in Index.aColExpr
+)
+
 /*
 ** Each sample stored in the sqlite_stat3 table is represented in memory
 ** using a structure of this type.  See documentation at the top of the
@@ -8420,6 +8456,7 @@ struct Expr {
 									//	If TK_COLUMN, the value of p5 for OP_Column
 	pAggInfo		*AggInfo		//	Used by TK_AGG_COLUMN and TK_AGG_FUNCTION
 	pTab			*Table			//	Table for TK_COLUMN expressions.
+	pWin			*Window			//	TK_FUNCTION: the OVER clause of a window function, or nil
 }
 
 /*
@@ -8556,12 +8593,14 @@ type struct SrcList_item {
     byte jointype;      /* Type of join between this able and the previous */
     byte notIndexed;    /* True if there is a NOT INDEXED clause */
     byte isCorrelated;  /* True if sub-query is correlated */
+    byte isRecursive;   /* True for recursive reference in WITH */
 #ifndef SQLITE_OMIT_EXPLAIN
     byte iSelectId;     /* If pSelect!=0, the id of the sub-select in EQP */
 #endif
     int iCursor;      /* The VDBE cursor number used to access this table */
     Expr *pOn;        /* The ON clause of a join */
     IdList *pUsing;   /* The USING clause of a join */
+    ExprList *pFuncArg; /* Arguments of a table-valued function call, or NULL */
     Bitmask colUsed;  /* Bit N (1<<N) set if column N of pTab is used */
     char *zIndex;     /* Identifier from "INDEXED BY <zIndex>" clause */
     Index *pIndex;    /* Index structure corresponding to zIndex, if any */
@@ -8705,6 +8744,10 @@ type NameContext struct {
 #define NC_HasAgg    0x02    /* One or more aggregate functions seen */
 #define NC_IsCheck   0x04    /* True if resolving names in a CHECK constraint */
 #define NC_InAggFunc 0x08    /* True if analyzing arguments to an agg func */
+#define NC_AllowWin  0x10    /* Window functions are allowed here */
+#define NC_PartIdx   0x20    /* True if resolving a partial index WHERE clause */
+#define NC_IdxExpr   0x40    /* True if resolving the expressions of an index */
+#define NC_GenCol    0x80    /* True if resolving the expression of a generated column */
 
 /*
 ** An instance of the following structure contains all information
@@ -8744,6 +8787,8 @@ struct Select {
   Select *pRightmost;    /* Right-most select in a compound select statement */
   Expr *pLimit;          /* LIMIT expression. NULL means not used. */
   Expr *pOffset;         /* OFFSET expression. NULL means not used. */
+	pWinProgram		*WindowProgram		//	Window functions computed over the result of this SELECT
+	pWith			*With				//	WITH clause that begins the compound, on its left-most SELECT only
 };
 
 /*
@@ -8758,6 +8803,7 @@ struct Select {
 #define SF_HasTypeInfo     0x20  /* FROM subqueries have Table metadata */
 #define SF_UseSorter       0x40  /* Sort using a sorter */
 #define SF_Values          0x80  /* Synthesized from VALUES clause */
+#define SF_Recursive       0x100 /* The recursive part of a recursive CTE */
 
 
 /*
@@ -8778,6 +8824,8 @@ struct Select {
 #define SRT_Table        8  /* Store result as data with an automatic rowid */
 #define SRT_EphemTab     9  /* Create transient tab and store like SRT_Table */
 #define SRT_Coroutine   10  /* Generate a single row of result */
+#define SRT_Fifo        11  /* Store result as data with an automatic rowid */
+#define SRT_DistFifo    12  /* Like SRT_Fifo, but unique results only */
 
 /*
 ** A structure used to customize the behavior of sqlite3Select(). See
@@ -8951,6 +8999,25 @@ struct Parse {
   Table **apVtabLock;       /* Pointer to virtual tables needing locking */
   Table *pZombieTab;        /* List of Table objects to delete after code gen */
   TriggerPrg *pTriggerPrg;  /* Linked list of coded triggers */
+	pWith				*With			//	Innermost WITH clause in scope, or nil
+	nDepth				int				//	Depth of parentheses of the token being parsed
+	pOver				*windowOver		//	OVER clause waiting for the function call it follows (see ParseOver())
+	aWindowDefn			[]windowDefn	//	WINDOW clauses waiting for the SELECT they belong to (see ParseWindowClause())
+	pUpsert				*Upsert			//	ON CONFLICT clause removed from the statement text by ParseUpsert()
+	aTriggerUpsert		[]*Upsert		//	ON CONFLICT clauses, or nil, of the INSERTs of a CREATE TRIGGER not yet given to their trigger steps
+	zTriggerText		string			//	Original text of a CREATE TRIGGER rewritten by ParseUpsert(), from the trigger name onwards
+	isUpsertUpdate		bool			//	True while coding the DO UPDATE of an UPSERT
+	pReturning			*Returning		//	RETURNING clause removed from the statement text by ParseReturning()
+	captureSelect		bool			//	True if a top-level SELECT is to be saved in pCapture rather than coded
+	pCapture			*Select			//	The SELECT saved when captureSelect is true
+	pPartIdxWhere		*Expr			//	WHERE clause of a CREATE INDEX removed from the statement text by ParseCreateIndex()
+	aIdxExpr			[]*Expr			//	Expressions in the column list of a CREATE INDEX removed by ParseCreateIndex(), or nil
+	zIndexText			string			//	Original text of a CREATE INDEX rewritten by ParseCreateIndex(), from the index name onwards
+	withoutRowid		bool			//	True if ParseCreateTable() removed WITHOUT ROWID from a CREATE TABLE
+	isConcurrent		bool			//	True if ParseBeginConcurrent() removed CONCURRENT from a BEGIN
+	aGenCol				[]*genColumnClause	//	GENERATED ALWAYS AS clauses blanked out of the statement text by ParseGeneratedColumns()
+	zGenColText			string			//	Original text of a statement rewritten by ParseGeneratedColumns(): a CREATE TABLE from the table name to the end of the column list, or the column definition of an ALTER TABLE ADD COLUMN
+	pRename				*renameColumn	//	Column whose references sqlite_rename_column() is looking for while it resolves a view or trigger
 };
 
 //	Return true if currently inside an DeclareVTab(() call.
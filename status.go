@@ -124,6 +124,7 @@ struct Mem {
 	flags	uint16							//	Some combination of MEM_Null, MEM_Str, MEM_Dyn, etc.
 	type	byte							//	One of SQLITE_NULL, SQLITE_TEXT, SQLITE_INTEGER, etc
 	enc		byte							//	SQLITE_UTF8
+	eSubtype	byte						//	Subtype set by sqlite3_result_subtype(), or 0
 	xDel	func(interface{}) interface{}	//	If not null, call this function to delete Mem.z
 	zMalloc	[]byte							//	Dynamic buffer allocated by sqlite3_malloc()
 }
@@ -385,6 +386,8 @@ struct Vdbe {
   Routines			[]*SubProgram
   int nOnceFlag;          /* Size of array aOnceFlag[] */
   byte *aOnceFlag;          /* Flags for OP_Once */
+	ctx			context.Context		//	Context watched by StepContext()
+	stopWatch	func() bool			//	Removes the watch on ctx
 };
 
 /*
@@ -0,0 +1,61 @@
+//	This file implements table-valued functions: virtual tables called like functions in the FROM clause of a SELECT.
+//
+//		SELECT value FROM generate_series(1, 100, 5)
+//		SELECT t.id, j.value FROM t, json_each(t.doc) AS j
+//
+//	The arguments of the call are bound to the hidden columns of the virtual table in the order they were declared, as if the query
+//	had been written with a constraint "hidden = arg" on each. Any virtual table with hidden columns can be called like this, including
+//	the eponymous virtual table of a module such as json_each or generate_series, which need not be created first.
+//
+//	The grammar parses the arguments of the call into an ExprList, which SrcListFuncArgs() attaches to the FROM clause term as
+//	SrcList_item.pFuncArg. ProcessJoin() turns them into terms of the WHERE clause once the table is known, so that they reach the
+//	xBestIndex method of the virtual table as constraints on its hidden columns.
+
+//	Called by the grammar for a table-valued function call in a FROM clause. Attach the arguments of the call, pList, to the term
+//	just added to p. A call without arguments gets an empty list, which still marks the term as a call.
+func (pParse *Parse) SrcListFuncArgs(p *SrcList, pList *ExprList) {
+	if p != nil && len(p) > 0 {
+		if pList == nil {
+			pList = &ExprList{}
+		}
+		p[len(p) - 1].pFuncArg = pList
+	} else {
+		pParse.db.ExprListDelete(pList)
+	}
+}
+
+//	Add a term "hidden = arg" to the WHERE clause of p for each argument of the table-valued function call that is term iSrc of its
+//	FROM clause, where hidden is the corresponding hidden column of the table. If the call is the right operand of a LEFT JOIN the
+//	terms are part of the join constraint. Return true if there are errors.
+func (pParse *Parse) TableFunctionArgs(p *Select, iSrc int) bool {
+	db := pParse.db
+	pItem := p.pSrc[iSrc]
+	pTab := pItem.pTab
+	if !pTab.IsVirtual() {
+		pParse.SetErrorMsg("'%v' is not a function", pItem.Name)
+		return true
+	}
+	iCol := 0
+	for _, item := range pItem.pFuncArg.Items {
+		for iCol < pTab.nCol && !pTab.Columns[iCol].IsHidden {
+			iCol++
+		}
+		if iCol >= pTab.nCol {
+			nHidden := 0
+			for _, pCol := range pTab.Columns {
+				if pCol.IsHidden {
+					nHidden++
+				}
+			}
+			pParse.SetErrorMsg("too many arguments on %v() - max %v", pItem.Name, nHidden)
+			return true
+		}
+		pEq := pParse.Expr(TK_EQ, sqlite3CreateColumnExpr(db, p.pSrc, iSrc, iCol), item.Expr.Dup(), "")
+		if pEq != nil && pItem.jointype & JT_OUTER != 0 {
+			setJoinExpr(pEq, pItem.iCursor)
+		}
+		p.Where = db.ExprAnd(p.Where, pEq)
+		iCol++
+	}
+	return false
+}
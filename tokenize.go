@@ -38,20 +38,20 @@
 ** is substantially reduced.  This is important for embedded applications
 ** on platforms with limited memory.
 */
-/* Hash score: 175 */
+/* Hash score: 180 */
 static int keywordCode(const char *z, int n){
-  /* zText[] encodes 811 bytes of keywords in 541 bytes */
+  /* zText[] encodes 826 bytes of keywords in 554 bytes */
   /*   REINDEXEDESCAPEACHECKEYBEFOREIGNOREGEXPLAINSTEADDATABASELECT       */
   /*   ABLEFTHENDEFERRABLELSEXCEPTRANSACTIONATURALTERAISEXCLUSIVE         */
   /*   XISTSAVEPOINTERSECTRIGGEREFERENCESCONSTRAINTOFFSETEMPORARY         */
   /*   UNIQUERYATTACHAVINGROUPDATEBEGINNERELEASEBETWEENOTNULLIKE          */
   /*   CASCADELETECASECOLLATECREATECURRENT_DATEDETACHIMMEDIATEJOIN        */
-  /*   SERTMATCHPLANALYZEPRAGMABORTVALUESVIRTUALIMITWHENWHERENAME         */
-  /*   AFTEREPLACEANDEFAULTAUTOINCREMENTCASTCOLUMNCOMMITCONFLICTCROSS     */
-  /*   CURRENT_TIMESTAMPRIMARYDEFERREDISTINCTDROPFAILFROMFULLGLOBYIF      */
-  /*   ISNULLORDERESTRICTOUTERIGHTROLLBACKROWUNIONUSINGVACUUMVIEW         */
-  /*   INITIALLY                                                          */
-  static const char zText[540] = {
+  /*   SERTMATCHPLANALYZEPRAGMABORTVALUESVIRTUALIMITWHENWHERECURSIVE      */
+  /*   WITHAFTERENAMEANDEFAULTAUTOINCREMENTCASTCOLUMNCOMMITCONFLICT       */
+  /*   CROSSCURRENT_TIMESTAMPRIMARYDEFERREDISTINCTDROPFAILFROMFULL        */
+  /*   GLOBYIFISNULLORDEREPLACEOUTERESTRICTRIGHTROLLBACKROWUNIONUSING     */
+  /*   VACUUMVIEWINITIALLY                                                */
+  static const char zText[553] = {
     'R','E','I','N','D','E','X','E','D','E','S','C','A','P','E','A','C','H',
     'E','C','K','E','Y','B','E','F','O','R','E','I','G','N','O','R','E','G',
     'E','X','P','L','A','I','N','S','T','E','A','D','D','A','T','A','B','A',
@@ -71,67 +71,68 @@ static int keywordCode(const char *z, int n){
     'J','O','I','N','S','E','R','T','M','A','T','C','H','P','L','A','N','A',
     'L','Y','Z','E','P','R','A','G','M','A','B','O','R','T','V','A','L','U',
     'E','S','V','I','R','T','U','A','L','I','M','I','T','W','H','E','N','W',
-    'H','E','R','E','N','A','M','E','A','F','T','E','R','E','P','L','A','C',
-    'E','A','N','D','E','F','A','U','L','T','A','U','T','O','I','N','C','R',
-    'E','M','E','N','T','C','A','S','T','C','O','L','U','M','N','C','O','M',
-    'M','I','T','C','O','N','F','L','I','C','T','C','R','O','S','S','C','U',
-    'R','R','E','N','T','_','T','I','M','E','S','T','A','M','P','R','I','M',
-    'A','R','Y','D','E','F','E','R','R','E','D','I','S','T','I','N','C','T',
-    'D','R','O','P','F','A','I','L','F','R','O','M','F','U','L','L','G','L',
-    'O','B','Y','I','F','I','S','N','U','L','L','O','R','D','E','R','E','S',
-    'T','R','I','C','T','O','U','T','E','R','I','G','H','T','R','O','L','L',
-    'B','A','C','K','R','O','W','U','N','I','O','N','U','S','I','N','G','V',
-    'A','C','U','U','M','V','I','E','W','I','N','I','T','I','A','L','L','Y',
+    'H','E','R','E','C','U','R','S','I','V','E','W','I','T','H','A','F','T',
+    'E','R','E','N','A','M','E','A','N','D','E','F','A','U','L','T','A','U',
+    'T','O','I','N','C','R','E','M','E','N','T','C','A','S','T','C','O','L',
+    'U','M','N','C','O','M','M','I','T','C','O','N','F','L','I','C','T','C',
+    'R','O','S','S','C','U','R','R','E','N','T','_','T','I','M','E','S','T',
+    'A','M','P','R','I','M','A','R','Y','D','E','F','E','R','R','E','D','I',
+    'S','T','I','N','C','T','D','R','O','P','F','A','I','L','F','R','O','M',
+    'F','U','L','L','G','L','O','B','Y','I','F','I','S','N','U','L','L','O',
+    'R','D','E','R','E','P','L','A','C','E','O','U','T','E','R','E','S','T',
+    'R','I','C','T','R','I','G','H','T','R','O','L','L','B','A','C','K','R',
+    'O','W','U','N','I','O','N','U','S','I','N','G','V','A','C','U','U','M',
+    'V','I','E','W','I','N','I','T','I','A','L','L','Y',
   };
   static const unsigned char aHash[127] = {
-      72, 101, 114,  70,   0,  45,   0,   0,  78,   0,  73,   0,   0,
-      42,  12,  74,  15,   0, 113,  81,  50, 108,   0,  19,   0,   0,
-     118,   0, 116, 111,   0,  22,  89,   0,   9,   0,   0,  66,  67,
-       0,  65,   6,   0,  48,  86,  98,   0, 115,  97,   0,   0,  44,
-       0,  99,  24,   0,  17,   0, 119,  49,  23,   0,   5, 106,  25,
-      92,   0,   0, 121, 102,  56, 120,  53,  28,  51,   0,  87,   0,
-      96,  26,   0,  95,   0,   0,   0,  91,  88,  93,  84, 105,  14,
-      39, 104,   0,  77,   0,  18,  85, 107,  32,   0, 117,  76, 109,
-      58,  46,  80,   0,   0,  90,  40,   0, 112,   0,  36,   0,   0,
+      72, 102, 116,  70,   0,  45,   0,   0,  78,   0,  73,   0,   0,
+      42,  12,  74,  15,   0, 115,  81,  50, 109,   0,  19,   0,   0,
+     120,   0, 118, 114,   0,  22,  90,   0,   9,   0,   0,  66,  67,
+       0,  65,   6,   0,  48,  87,  99,   0, 117,  98,   0,   0,  44,
+       0, 100,  24,   0,  17,   0, 121,  49,  23,   0,   5, 107,  25,
+      93,   0,   0, 123, 103,  56, 122,  53,  28,  51,   0,  88,   0,
+      97,  26,   0,  96,   0,   0,   0,  92,  89,  94,  85, 106,  14,
+      39, 105,   0,  77,   0,  18, 112, 108,  32,   0, 119,  76, 110,
+      58,  46,  80,   0,   0,  91,  40,  83, 113,   0,  36,   0,   0,
       29,   0,  82,  59,  60,   0,  20,  57,   0,  52,
   };
-  static const unsigned char aNext[121] = {
+  static const unsigned char aNext[123] = {
        0,   0,   0,   0,   4,   0,   0,   0,   0,   0,   0,   0,   0,
        0,   2,   0,   0,   0,   0,   0,   0,  13,   0,   0,   0,   0,
        0,   7,   0,   0,   0,   0,   0,   0,   0,   0,   0,   0,   0,
        0,   0,   0,   0,  33,   0,  21,   0,   0,   0,  43,   3,  47,
        0,   0,   0,   0,  30,   0,  54,   0,  38,   0,   0,   0,   1,
       62,   0,   0,  63,   0,  41,   0,   0,   0,   0,   0,   0,   0,
-      61,   0,   0,   0,   0,  31,  55,  16,  34,  10,   0,   0,   0,
-       0,   0,   0,   0,  11,  68,  75,   0,   8,   0, 100,  94,   0,
-     103,   0,  83,   0,  71,   0,   0, 110,  27,  37,  69,  79,   0,
-      35,  64,   0,   0,
+      61,   0,   0,   0,   0,  55,  31,   0,  16,  34,  10,   0,   0,
+       0,   0,   0,   0,   0,  11,  68,  75,   0,   8,   0, 101,  95,
+       0, 104,   0,  86,   0,  71,   0,  84, 111,   0,  27,  37,  69,
+      79,   0,  35,  64,   0,   0,
   };
-  static const unsigned char aLen[121] = {
+  static const unsigned char aLen[123] = {
        7,   7,   5,   4,   6,   4,   5,   3,   6,   7,   3,   6,   6,
        7,   7,   3,   8,   2,   6,   5,   4,   4,   3,  10,   4,   6,
       11,   6,   2,   7,   5,   5,   9,   6,   9,   9,   7,  10,  10,
        4,   6,   2,   3,   9,   4,   2,   6,   5,   6,   6,   5,   6,
        5,   5,   7,   7,   7,   3,   2,   4,   4,   7,   3,   6,   4,
        7,   6,  12,   6,   9,   4,   6,   5,   4,   7,   6,   5,   6,
-       7,   5,   4,   5,   6,   5,   7,   3,   7,  13,   2,   2,   4,
-       6,   6,   8,   5,  17,  12,   7,   8,   8,   2,   4,   4,   4,
-       4,   4,   2,   2,   6,   5,   8,   5,   5,   8,   3,   5,   5,
-       6,   4,   9,   3,
+       7,   5,   4,   5,   9,   4,   5,   6,   3,   7,  13,   2,   2,
+       4,   6,   6,   8,   5,  17,  12,   7,   8,   8,   2,   4,   4,
+       4,   4,   4,   2,   2,   6,   5,   7,   5,   8,   5,   8,   3,
+       5,   5,   6,   4,   9,   3,
   };
-  static const unsigned short int aOffset[121] = {
+  static const unsigned short int aOffset[123] = {
        0,   2,   2,   8,   9,  14,  16,  20,  23,  25,  25,  29,  33,
       36,  41,  46,  48,  53,  54,  59,  62,  65,  67,  69,  78,  81,
       86,  91,  95,  96, 101, 105, 109, 117, 122, 128, 136, 142, 152,
      159, 162, 162, 165, 167, 167, 171, 176, 179, 184, 189, 194, 197,
      203, 206, 210, 217, 223, 223, 223, 226, 229, 233, 234, 238, 244,
      248, 255, 261, 273, 279, 288, 290, 296, 301, 303, 310, 315, 320,
-     326, 332, 337, 341, 344, 350, 354, 361, 363, 370, 372, 374, 383,
-     387, 393, 399, 407, 412, 412, 428, 435, 442, 443, 450, 454, 458,
-     462, 466, 469, 471, 473, 479, 483, 491, 495, 500, 508, 511, 516,
-     521, 527, 531, 536,
+     326, 332, 337, 341, 344, 353, 357, 361, 367, 369, 376, 378, 380,
+     389, 393, 399, 405, 413, 418, 418, 434, 441, 448, 449, 456, 460,
+     464, 468, 472, 475, 477, 479, 485, 489, 496, 500, 508, 513, 521,
+     524, 529, 534, 540, 544, 549,
   };
-  static const unsigned char aCode[121] = {
+  static const unsigned char aCode[123] = {
     TK_REINDEX,    TK_INDEXED,    TK_INDEX,      TK_DESC,       TK_ESCAPE,
     TK_EACH,       TK_CHECK,      TK_KEY,        TK_BEFORE,     TK_FOREIGN,
     TK_FOR,        TK_IGNORE,     TK_LIKE_KW,    TK_EXPLAIN,    TK_INSTEAD,
@@ -148,15 +149,15 @@ static int keywordCode(const char *z, int n){
     TK_COLLATE,    TK_CREATE,     TK_CTIME_KW,   TK_DETACH,     TK_IMMEDIATE,
     TK_JOIN,       TK_INSERT,     TK_MATCH,      TK_PLAN,       TK_ANALYZE,
     TK_PRAGMA,     TK_ABORT,      TK_VALUES,     TK_VIRTUAL,    TK_LIMIT,
-    TK_WHEN,       TK_WHERE,      TK_RENAME,     TK_AFTER,      TK_REPLACE,
-    TK_AND,        TK_DEFAULT,    TK_AUTOINCR,   TK_TO,         TK_IN,
-    TK_CAST,       TK_COLUMNKW,   TK_COMMIT,     TK_CONFLICT,   TK_JOIN_KW,
-    TK_CTIME_KW,   TK_CTIME_KW,   TK_PRIMARY,    TK_DEFERRED,   TK_DISTINCT,
-    TK_IS,         TK_DROP,       TK_FAIL,       TK_FROM,       TK_JOIN_KW,
-    TK_LIKE_KW,    TK_BY,         TK_IF,         TK_ISNULL,     TK_ORDER,
-    TK_RESTRICT,   TK_JOIN_KW,    TK_JOIN_KW,    TK_ROLLBACK,   TK_ROW,
-    TK_UNION,      TK_USING,      TK_VACUUM,     TK_VIEW,       TK_INITIALLY,
-    TK_ALL,
+    TK_WHEN,       TK_WHERE,      TK_RECURSIVE,  TK_WITH,       TK_AFTER,
+    TK_RENAME,     TK_AND,        TK_DEFAULT,    TK_AUTOINCR,   TK_TO,
+    TK_IN,         TK_CAST,       TK_COLUMNKW,   TK_COMMIT,     TK_CONFLICT,
+    TK_JOIN_KW,    TK_CTIME_KW,   TK_CTIME_KW,   TK_PRIMARY,    TK_DEFERRED,
+    TK_DISTINCT,   TK_IS,         TK_DROP,       TK_FAIL,       TK_FROM,
+    TK_JOIN_KW,    TK_LIKE_KW,    TK_BY,         TK_IF,         TK_ISNULL,
+    TK_ORDER,      TK_REPLACE,    TK_JOIN_KW,    TK_RESTRICT,   TK_JOIN_KW,
+    TK_ROLLBACK,   TK_ROW,        TK_UNION,      TK_USING,      TK_VACUUM,
+    TK_VIEW,       TK_INITIALLY,  TK_ALL,
   };
   int h, i;
   if( n<2 ) return TK_ID;
@@ -173,7 +174,7 @@ static int keywordCode(const char *z, int n){
  int sqlite3KeywordCode(const unsigned char *z, int n){
   return keywordCode((char*)z, n);
 }
-#define SQLITE_N_KEYWORD 121
+#define SQLITE_N_KEYWORD 123
 
 /************** End of keywordhash.h *****************************************/
 /************** Continuing where we left off in tokenize.c *******************/
@@ -448,13 +449,69 @@ static int keywordCode(const char *z, int n){
 }
 
 //	Run the parser on the given SQL string. The parser structure is passed in. An SQLITE_ status code is returned.
+//
+//	The LEMON grammar in parse.go predates much of the syntax that SQLite accepts today. Rather than change the grammar, Run() hands
+//	the statement to a chain of pre-parse rewriters before the parser sees it. Each recognizes one piece of newer syntax in the first
+//	statement of the text with a sqlScanner, takes it out of the text or rewrites it into something the grammar accepts, and records
+//	what it found on the Parse, where the code that the grammar calls picks it up:
+//
+//		ParseJsonOperators()	-> and ->> become calls of the functions "->" and "->>"	json.go
+//		ParseReturning()		RETURNING clause, on Parse.pReturning					returning.go
+//		ParseUpsert()			ON CONFLICT clause of an INSERT, on Parse.pUpsert		upsert.go
+//		ParseCreateIndex()		WHERE clause and expression keys of CREATE INDEX		index.go
+//		ParseCreateTable()		WITHOUT ROWID, on Parse.withoutRowid					withoutrowid.go
+//		ParseGeneratedColumns()	GENERATED ALWAYS AS clauses, on Parse.aGenCol			generated.go
+//		ParseBeginConcurrent()	BEGIN CONCURRENT, on Parse.isConcurrent					concurrent.go
+//
+//	A rewriter only changes the first statement, so zTail is mapped back into the original text, and the rewriters that change a
+//	CREATE statement keep its original text for the sqlite_master table. The WITH clause is part of the grammar. Syntax that may
+//	appear anywhere a SELECT may - OVER and WINDOW - is not rewritten at all: the loop below hands the identifier that begins it to
+//	ParseOver() or ParseWindowClause(), which parse the clause, skip the loop past it and hold it until the grammar completes the
+//	expression or SELECT it belongs to. Nor is ALTER TABLE ... RENAME COLUMN or DROP COLUMN, which the grammar has no rule to reduce
+//	to: the loop hands a statement that begins with ALTER or EXPLAIN to ParseAlterColumn() in altercolumn.go, which codes the whole
+//	statement itself when it is one of these.
 func (pParse *Parse) Run(zSQL string) (ErrMsg string, nErr int) {
 	db := pParse.db
 	if db.activeVdbeCnt == 0 {
 		db.u1.isInterrupted = false
 	}
 	pParse.rc = SQLITE_OK
-	pParse.zTail = zSql
+
+	//	The pre-parse rewriters below change only the text of the first statement, so the end of the first statement in the text the
+	//	parser sees is the same distance from the end of the text as it is in zText, which zTail must point into.
+	zText := zSql
+	iText := func(i int) int {
+		if i -= len(zSql) - len(zText); i < 0 {
+			return 0
+		}
+		return i
+	}
+	zSql = pParse.ParseJsonOperators(zSql)
+	if pParse.nErr == 0 {
+		zSql = pParse.ParseReturning(zSql)
+	}
+	if pParse.nErr == 0 {
+		zSql = pParse.ParseUpsert(zSql)
+	}
+	if pParse.nErr == 0 {
+		zSql = pParse.ParseCreateIndex(zSql)
+	}
+	if pParse.nErr == 0 {
+		zSql = pParse.ParseCreateTable(zSql)
+	}
+	if pParse.nErr == 0 {
+		zSql = pParse.ParseGeneratedColumns(zSql)
+	}
+	if pParse.nErr == 0 {
+		zSql = pParse.ParseBeginConcurrent(zSql)
+	}
+	if pParse.nErr > 0 {
+		ErrMsg = pParse.zErrMsg
+		pParse.zErrMsg = ""
+		pParse.rc = SQLITE_ERROR
+		return ErrMsg, pParse.nErr
+	}
+	pParse.zTail = zText
 	pEngine := sqlite3ParserAlloc((void*(*)(size_t))sqlite3Malloc)
 	if pEngine == nil {
 		db.mallocFailed = true
@@ -476,6 +533,29 @@ func (pParse *Parse) Run(zSQL string) (ErrMsg string, nErr int) {
 			pParse.rc = SQLITE_TOOBIG
 			break
 		}
+		if tokenType == TK_EXPLAIN || tokenType == TK_ALTER {
+			if iNext := pParse.ParseAlterColumn(zSql, i - pParse.sLastToken.n, lastTokenParsed); iNext > 0 {
+				//	The statement has been coded already.
+				pParse.zTail = &zText[iText(iNext)]
+				i = iNext
+				goto abort_parse
+			} else if pParse.nErr > 0 {
+				goto abort_parse
+			}
+		}
+		if tokenType == TK_ID {
+			iToken := i - pParse.sLastToken.n
+			iNext := pParse.ParseOver(zSql, iToken, lastTokenParsed)
+			if iNext == 0 && pParse.nErr == 0 {
+				iNext = pParse.ParseWindowClause(zSql, iToken)
+			}
+			if iNext > 0 {
+				i = iNext
+				continue
+			} else if pParse.nErr > 0 {
+				goto abort_parse
+			}
+		}
 		switch tokenType {
 		case TK_SPACE:
 			if db.u1.isInterrupted {
@@ -488,21 +568,34 @@ func (pParse *Parse) Run(zSQL string) (ErrMsg string, nErr int) {
 			nErr++
 			goto abort_parse
 		case TK_SEMI:
-			pParse.zTail = &zSql[i]
+			pParse.zTail = &zText[iText(i)]
 			fallthrough
 		default:
 			sqlite3Parser(pEngine, tokenType, pParse.sLastToken, pParse);
 			lastTokenParsed = tokenType
+			if pParse.pOver != nil {
+				//	The grammar completes a function call at the token after it, which must have taken the OVER clause.
+				pParse.SetErrorMsg("near \"OVER\": syntax error")
+			}
 			if pParse.rc != SQLITE_OK {
 				goto abort_parse
 			}
+			switch tokenType {
+			case TK_LP:
+				pParse.nDepth++
+			case TK_RP:
+				pParse.nDepth--
+			}
 		}
 	}
 abort_parse:
 	if i == len(zSql) && nErr == 0 && pParse.rc == SQLITE_OK {
 		if lastTokenParsed != TK_SEMI {
 			sqlite3Parser(pEngine, TK_SEMI, pParse.sLastToken, pParse)
-			pParse.zTail = &zSql[i]
+			pParse.zTail = &zText[iText(i)]
+		}
+		if pParse.pOver != nil {
+			pParse.SetErrorMsg("near \"OVER\": syntax error")
 		}
 		sqlite3Parser(pEngine, 0, pParse.sLastToken, pParse)
 	}
@@ -539,10 +632,13 @@ abort_parse:
 	}
 
 	db.DeleteTrigger(pParse.pNewTrigger)
-	for i := pParse.nzVar - 1; i >= 0; i-- {
-		pParse.azVar[i] = nil
+	if !pParse.captureSelect {
+		//	A captured SELECT shares its parameter names with the statement that contains it (see ParseSelect()).
+		for i := pParse.nzVar - 1; i >= 0; i-- {
+			pParse.azVar[i] = nil
+		}
+		pParse.azVar = nil
 	}
-	pParse.azVar = nil
 	pParse.aAlias = nil
 	for pParse.pAinc != nil {
 		p := pParse.pAinc
@@ -558,3 +654,75 @@ abort_parse:
 	}
 	return nErr
 }
+
+//	A sqlScanner splits SQL text into tokens for the clauses that are recognized ahead of the LEMON parser (see Parse.Run()).
+//	White-space and comments are skipped. After each call to Next(), Type, Text and Start describe the current token; at the end of
+//	the input Type is 0 and Start is len(zSql).
+type sqlScanner struct {
+	zSql		string
+	iNext		int				//	Offset of the first byte after the current token
+	Type		int				//	TK_* code of the current token
+	Text		string			//	Text of the current token
+	Start		int				//	Offset of the current token in zSql
+}
+
+func newSqlScanner(zSql string) (s *sqlScanner) {
+	s = &sqlScanner{ zSql: zSql }
+	s.Next()
+	return
+}
+
+//	Advance to the next token. Return false at the end of the input.
+func (s *sqlScanner) Next() bool {
+	var tokenType int
+	for s.iNext < len(s.zSql) {
+		n := sqlite3GetToken(s.zSql[s.iNext:], &tokenType)
+		s.Start = s.iNext
+		s.iNext += n
+		if tokenType != TK_SPACE {
+			s.Type = tokenType
+			s.Text = s.zSql[s.Start:s.iNext]
+			return true
+		}
+	}
+	s.Type = 0
+	s.Text = ""
+	s.Start = len(s.zSql)
+	return false
+}
+
+//	Return true if the current token is the word w. Words that are not SQLite keywords are tokenized as TK_ID, so this is how the
+//	scanner recognizes them.
+func (s *sqlScanner) IsWord(w string) bool {
+	return s.Type != 0 && CaseInsensitiveMatch(s.Text, w)
+}
+
+//	Return true if the current token can be used as a name.
+func (s *sqlScanner) IsName() bool {
+	return s.Type == TK_ID || s.Type == TK_STRING
+}
+
+//	The current token must be "(". Advance to the matching ")" and return the text between them. ok is false if the input ends before
+//	the parentheses balance or if a ";" appears within them.
+func (s *sqlScanner) Parenthesized() (body string, ok bool) {
+	assert( s.Type == TK_LP )
+	start := s.iNext
+	for depth := 1; s.Next(); {
+		switch s.Type {
+		case TK_LP:
+			depth++
+		case TK_RP:
+			if depth--; depth == 0 {
+				return s.zSql[start:s.Start], true
+			}
+		case TK_SEMI:
+			return "", false
+		}
+	}
+	return "", false
+}
+
+//	Return the text from the current token to the end of the input.
+func (s *sqlScanner) Rest() string {
+	return s.zSql[s.Start:]
+}
@@ -0,0 +1,191 @@
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+//	This file implements full-text tokenizers written as Go types:
+//
+//		sqlite3.RegisterTokenizer("cjk", func(args []string) (sqlite3.Tokenizer, error) { return newSegmenter(args) })
+//		CREATE VIRTUAL TABLE docs USING fts5(body, tokenize = 'cjk')
+//		CREATE VIRTUAL TABLE notes USING fts4(body, tokenize=cjk)
+//
+//	A Tokenizer splits text into TextTokens, each with the text that is indexed for it, the byte offsets of the text it came from and
+//	its position. Positions count the tokens of the text from 0, so that a phrase matches tokens at consecutive positions. A tokenizer
+//	may give several tokens the same position, to index synonyms of a word as if each had been written in its place, or skip
+//	positions, so that phrases do not match across a word that is not indexed.
+//
+//	Tokenizers are registered by name for the whole process. The fts5 module uses them directly, as it does the built-in ascii and
+//	unicode61 tokenizers, which are registered in the same way. fts3 and fts4 tables use a tokenizer that is not one of their own
+//	through the sqlite3_tokenizer_module made by goTokenizerModule().
+
+//	A TextToken is a token of a text.
+type TextToken struct {
+	Text			string			//	The text that is indexed for the token, such as the word folded to lower case
+	Start, End		int				//	Byte offsets in the text of the first byte of the word the token came from and of the byte after it
+	Position		int				//	Position of the token in the text
+}
+
+//	Tokenizer is implemented by a full-text tokenizer. Tokenize calls emit for each token of text in order of position, which must not
+//	decrease. If emit returns an error Tokenize must stop and return it.
+type Tokenizer interface {
+	Tokenize(text string, emit func(TextToken) error) error
+}
+
+var tokenizers = struct {
+	sync.RWMutex
+	m		map[string]func(args []string) (Tokenizer, error)
+}{
+	m:		map[string]func(args []string) (Tokenizer, error){
+		"ascii":		newAsciiTokenizer,
+		"unicode61":	newUnicode61Tokenizer,
+	},
+}
+
+//	RegisterTokenizer registers a tokenizer by name. A table that uses the tokenizer calls newTokenizer with the arguments that follow
+//	the name in its tokenize option to make the Tokenizer it uses. Names are case-insensitive and may not be registered twice.
+func RegisterTokenizer(name string, newTokenizer func(args []string) (Tokenizer, error)) error {
+	if newTokenizer == nil {
+		return fmt.Errorf("sqlite3: RegisterTokenizer needs a function to make the tokenizer")
+	}
+	name = strings.ToLower(name)
+	tokenizers.Lock()
+	defer tokenizers.Unlock()
+	if _, ok := tokenizers.m[name]; ok {
+		return fmt.Errorf("sqlite3: tokenizer %v is already registered", name)
+	}
+	tokenizers.m[name] = newTokenizer
+	return nil
+}
+
+//	Return the function that makes the tokenizer registered as name, or nil if there is none.
+func findTokenizer(name string) func(args []string) (Tokenizer, error) {
+	tokenizers.RLock()
+	defer tokenizers.RUnlock()
+	return tokenizers.m[strings.ToLower(name)]
+}
+
+//	Call xToken for each token of zText, checking that the tokens are well formed.
+func tokenizeText(pTokenizer Tokenizer, zText string, xToken func(TextToken)) error {
+	iPos := 0
+	return pTokenizer.Tokenize(zText, func(tok TextToken) error {
+		if tok.Position < iPos || tok.Start < 0 || tok.End < tok.Start || tok.End > len(zText) {
+			return fmt.Errorf("sqlite3: tokenizer returned a bad token %q at offset %v and position %v", tok.Text, tok.Start, tok.Position)
+		}
+		iPos = tok.Position
+		xToken(tok)
+		return nil
+	})
+}
+
+//	The ascii tokenizer. Tokens are runs of ASCII letters and digits and of bytes outside the ASCII range. ASCII letters are folded to
+//	lower case.
+type asciiTokenizer struct{}
+
+func newAsciiTokenizer(args []string) (Tokenizer, error) {
+	if len(args) > 0 {
+		return nil, fmt.Errorf("unrecognized ascii tokenizer option: %v", args[0])
+	}
+	return asciiTokenizer{}, nil
+}
+
+func (asciiTokenizer) Tokenize(text string, emit func(TextToken) error) error {
+	isToken := func(c byte) bool {
+		return c >= 0x80 || (c >= '0' && c <= '9') || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+	}
+	iPos := 0
+	for i := 0; i < len(text); {
+		if !isToken(text[i]) {
+			i++
+			continue
+		}
+		iStart := i
+		zToken := []byte{}
+		for ; i < len(text) && isToken(text[i]); i++ {
+			c := text[i]
+			if c >= 'A' && c <= 'Z' {
+				c += 'a' - 'A'
+			}
+			zToken = append(zToken, c)
+		}
+		if err := emit(TextToken{ Text: string(zToken), Start: iStart, End: i, Position: iPos }); err != nil {
+			return err
+		}
+		iPos++
+	}
+	return nil
+}
+
+//	A goTokenizer is the sqlite3_tokenizer of a Tokenizer used by an fts3 table.
+type goTokenizer struct {
+	base			sqlite3_tokenizer
+	tokenizer		Tokenizer
+}
+
+//	A goTokenizerCursor is the sqlite3_tokenizer_cursor of a text being tokenized by a goTokenizer. The tokens are found when the
+//	cursor is opened.
+type goTokenizerCursor struct {
+	base			sqlite3_tokenizer_cursor
+	aToken			[]TextToken
+	iToken			int				//	Index in aToken of the next token to return
+	err				error
+}
+
+//	Return an sqlite3_tokenizer_module for the tokenizer registered as name, or nil if there is none.
+func goTokenizerModule(name string) *sqlite3_tokenizer_module {
+	newTokenizer := findTokenizer(name)
+	if newTokenizer == nil {
+		return nil
+	}
+	return &sqlite3_tokenizer_module{
+		xCreate:	func(argc int, argv []string, ppTokenizer **sqlite3_tokenizer) int {
+			tokenizer, err := newTokenizer(append([]string{}, argv[:argc]...))
+			if err != nil {
+				return SQLITE_ERROR
+			}
+			p := &goTokenizer{ tokenizer: tokenizer }
+			*ppTokenizer = &p.base
+			return SQLITE_OK
+		},
+		xDestroy:	goTokenizerDestroy,
+		xOpen:		goTokenizerOpen,
+		xClose:		goTokenizerClose,
+		xNext:		goTokenizerNext,
+	}
+}
+
+func goTokenizerDestroy(pTokenizer *sqlite3_tokenizer) int {
+	return SQLITE_OK
+}
+
+func goTokenizerOpen(pTokenizer *sqlite3_tokenizer, pInput string, nBytes int, ppCursor **sqlite3_tokenizer_cursor) int {
+	if nBytes >= 0 && nBytes < len(pInput) {
+		pInput = pInput[:nBytes]
+	}
+	c := new(goTokenizerCursor)
+	c.err = tokenizeText((*goTokenizer)(unsafe.Pointer(pTokenizer)).tokenizer, pInput, func(tok TextToken) {
+		c.aToken = append(c.aToken, tok)
+	})
+	*ppCursor = &c.base
+	return SQLITE_OK
+}
+
+func goTokenizerClose(pCursor *sqlite3_tokenizer_cursor) int {
+	return SQLITE_OK
+}
+
+func goTokenizerNext(pCursor *sqlite3_tokenizer_cursor, ppToken *string, pnBytes, piStartOffset, piEndOffset, piPosition *int) int {
+	c := (*goTokenizerCursor)(unsafe.Pointer(pCursor))
+	switch {
+	case c.err != nil:
+		return SQLITE_ERROR
+	case c.iToken == len(c.aToken):
+		return SQLITE_DONE
+	}
+	tok := c.aToken[c.iToken]
+	c.iToken++
+	*ppToken, *pnBytes = tok.Text, len(tok.Text)
+	*piStartOffset, *piEndOffset, *piPosition = tok.Start, tok.End, tok.Position
+	return SQLITE_OK
+}
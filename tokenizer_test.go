@@ -0,0 +1,143 @@
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+//	A Tokenizer that splits text at spaces and folds it to lower case. A word of its stop list takes a position but is not indexed, and
+//	the synonyms of a word are indexed at the position of the word.
+type testSynTokenizer struct {
+	aStop		map[string]bool
+}
+
+var testSynonyms = map[string][]string{
+	"car":		{ "auto" },
+	"quick":	{ "fast" },
+}
+
+//	The options are words of the stop list, each given as "stop=word".
+func newTestSynTokenizer(args []string) (Tokenizer, error) {
+	p := testSynTokenizer{ aStop: make(map[string]bool) }
+	for _, zArg := range args {
+		if !strings.HasPrefix(zArg, "stop=") {
+			return nil, fmt.Errorf("testsyn: unknown option %v", zArg)
+		}
+		p.aStop[zArg[5:]] = true
+	}
+	return p, nil
+}
+
+func (p testSynTokenizer) Tokenize(text string, emit func(TextToken) error) error {
+	iPos := 0
+	for iOff := 0; iOff < len(text); {
+		n := strings.IndexByte(text[iOff:], ' ')
+		if n < 0 {
+			n = len(text) - iOff
+		}
+		if n > 0 {
+			zWord := strings.ToLower(text[iOff:iOff + n])
+			if !p.aStop[zWord] {
+				for _, zTerm := range append([]string{ zWord }, testSynonyms[zWord]...) {
+					if err := emit(TextToken{ zTerm, iOff, iOff + n, iPos }); err != nil {
+						return err
+					}
+				}
+			}
+			iPos++
+		}
+		iOff += n + 1
+	}
+	return nil
+}
+
+//	A Tokenizer that returns its tokens in the wrong order.
+type testBadTokenizer struct{}
+
+func (testBadTokenizer) Tokenize(text string, emit func(TextToken) error) error {
+	if err := emit(TextToken{ "b", 0, 0, 1 }); err != nil {
+		return err
+	}
+	return emit(TextToken{ "a", 0, 0, 0 })
+}
+
+func init() {
+	RegisterTokenizer("TestSyn", newTestSynTokenizer)
+	RegisterTokenizer("testbad", func(args []string) (Tokenizer, error) { return testBadTokenizer{}, nil })
+}
+
+func TestRegisterTokenizer(t *testing.T) {
+	for _, name := range []string{ "testsyn", "ASCII", "unicode61" } {
+		if err := RegisterTokenizer(name, newTestSynTokenizer); err == nil || !strings.Contains(err.Error(), "already registered") {
+			t.Errorf("RegisterTokenizer(%q) a second time: %v", name, err)
+		}
+	}
+	if err := RegisterTokenizer("testnil", nil); err == nil {
+		t.Error("RegisterTokenizer() with no function succeeded")
+	}
+	if findTokenizer("testnil") != nil {
+		t.Error("testnil registered")
+	}
+}
+
+func TestTokenizerFts5(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE VIRTUAL TABLE d USING fts5(body, tokenize = 'testsyn stop=the')")
+	testExec(t, db, "INSERT INTO d(rowid, body) VALUES(1, 'The quick car'), (2, 'a fast auto'), (3, 'the car of the year')")
+	for zQuery, want := range map[string]string{
+		//	Synonyms are indexed with the word, and found by a phrase of the word or of the synonym.
+		"car":				"1\n3",
+		"auto":				"1\n2\n3",
+		"fast":				"1\n2",
+		"quick":			"1",
+		`"quick car"`:		"1",
+		`"fast auto"`:		"1\n2",
+
+		//	A stop word is not indexed, but phrases do not match across it.
+		`"car of"`:			"3",
+		`"of year"`:		"",
+		"NEAR(of year, 1)":	"3",
+	} {
+		testQueryIs(t, db, want, "SELECT rowid FROM d WHERE d MATCH ? ORDER BY rowid", zQuery)
+	}
+
+	//	The offsets of a token are those of the word it came from.
+	testQueryIs(t, db, "1|The quick [car]\n2|a fast [auto]\n3|the [car] of the year",
+		"SELECT rowid, highlight(d, 0, '[', ']') FROM d WHERE d MATCH 'auto' ORDER BY rowid")
+
+	for query, want := range map[string]string{
+		"CREATE VIRTUAL TABLE e USING fts5(body, tokenize = 'testsyn bogus')":	"testsyn: unknown option bogus",
+		"CREATE VIRTUAL TABLE e USING fts5(body, tokenize = 'nope')":			"no such tokenizer: nope",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+	testExec(t, db, "CREATE VIRTUAL TABLE bad USING fts5(body, tokenize = 'testbad')")
+	if _, err := db.Exec("INSERT INTO bad VALUES('x')"); err == nil || !strings.Contains(err.Error(), "tokenizer returned a bad token") {
+		t.Errorf("bad tokens: %v", err)
+	}
+}
+
+func TestTokenizerFts4(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE VIRTUAL TABLE d USING fts4(body, tokenize=testsyn \"stop=the\")")
+	testExec(t, db, "INSERT INTO d(docid, body) VALUES(1, 'The quick car'), (2, 'a fast auto'), (3, 'the car of the year')")
+	for zQuery, want := range map[string]string{
+		"car":				"1\n3",
+		"auto":				"1\n2\n3",
+		"fast":				"1\n2",
+		`"car of"`:			"3",
+		`"of year"`:		"",
+		"of NEAR/1 year":	"3",
+	} {
+		testQueryIs(t, db, want, "SELECT docid FROM d WHERE d MATCH ? ORDER BY docid", zQuery)
+	}
+
+	if _, err := db.Exec("CREATE VIRTUAL TABLE e USING fts4(body, tokenize=nope)"); err == nil || !strings.Contains(err.Error(), "unknown tokenizer: nope") {
+		t.Errorf("unknown tokenizer: %v", err)
+	}
+	testExec(t, db, "CREATE VIRTUAL TABLE bad USING fts4(body, tokenize=testbad)")
+	if _, err := db.Exec("INSERT INTO bad VALUES('x')"); err == nil {
+		t.Error("bad tokens indexed")
+	}
+}
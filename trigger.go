@@ -37,6 +37,7 @@ const(
 //		target    . A token holding the quoted name of the table to insert into.
 //		ExprList . If this is an INSERT INTO ... VALUES ... statement, then this stores values to be inserted. Otherwise NULL.
 //		IdList   . If this is an INSERT INTO ... (<column-names>) VALUES ... statement, then this stores the column-names to be inserted into.
+//		pUpsert  . The ON CONFLICT clause of the INSERT, if it has one. Otherwise NULL.
 //
 //		(op == TK_DELETE)
 //		target    . A token holding the quoted name of the table to delete from.
@@ -56,6 +57,7 @@ type TriggerStep struct {
 	Where			*Expr			//	The WHERE clause for DELETE or UPDATE steps
 	*ExprList						//	SET clause for UPDATE.  VALUES clause for INSERT
 	*IdList							//	Column names for INSERT
+	pUpsert			*Upsert			//	ON CONFLICT clause for INSERT
 	Next			*TriggerStep
 	Last			*TriggerStep	//	Last element in link-list. Valid for 1st elem only
 }
@@ -197,7 +199,8 @@ void sqlite3BeginTrigger(
   if( !Name || SQLITE_OK!=sqlite3CheckObjectName(pParse, Name) ){
     goto trigger_cleanup;
   }
-  if db.Databases[iDb].Schema.Triggers[Name] {
+  //	sqlite_rename_column() parses the text of a trigger that exists already.
+  if db.Databases[iDb].Schema.Triggers[Name] && pParse.pRename == nil {
     if( !noErr ){
       pParse.SetErrorMsg("trigger %v already exists", pName)
     }else{
@@ -305,6 +308,12 @@ trigger_cleanup:
   if sFix := pParse.NewDbFixer(iDb, "trigger", nameToken); sFix != nil && sFix.FixTriggerStep(pTrig.Steps) != SQLITE_OK {
     goto triggerfinish_cleanup;
   }
+  if pParse.pRename != nil {
+    //	sqlite_rename_column() resolves the trigger itself.
+    pParse.pRename.pTrigger = pTrig
+    pTrig = nil
+    goto triggerfinish_cleanup
+  }
 
   /* if we are not initializing,
   ** build the sqlite_master entry
@@ -317,7 +326,12 @@ trigger_cleanup:
     v = pParse.GetVdbe()
     if( v==0 ) goto triggerfinish_cleanup;
     pParse.BeginWriteOperation(0, iDb)
-    z = sqlite3DbStrNDup(db, (char*)pAll.z, pAll.n);
+    if pParse.zTriggerText != "" {
+      //	ParseUpsert() took ON CONFLICT clauses out of the text that was parsed
+      z = pParse.zTriggerText
+    }else{
+      z = sqlite3DbStrNDup(db, (char*)pAll.z, pAll.n);
+    }
     sqlite3NestedParse(pParse,
        "INSERT INTO %Q.%s VALUES('trigger',%Q,%Q,0,'CREATE TRIGGER %q')",
        db.Databases[iDb].Name, SCHEMA_TABLE(iDb), Name,
@@ -710,6 +724,7 @@ static int codeTriggerProgram(
         break;
       }
       case TK_INSERT: {
+        pParse.pUpsert = pStep.pUpsert.Dup()
         sqlite3Insert(pParse, 
           targetSrcList(pParse, pStep),
           pStep.ExprList.Dup(), 
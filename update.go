@@ -61,6 +61,7 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
   Trigger *pTrigger;     /* List of triggers on pTab, if required */
   int tmask;             /* Mask of TRIGGER_BEFORE|TRIGGER_AFTER */
   int newmask;           /* Mask of NEW.* columns accessed by BEFORE triggers */
+  Returning *pReturning; /* The RETURNING clause, if any */
 
   /* Register Allocations */
   int regRowCount = 0;   /* A count of rows changed */
@@ -72,6 +73,8 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 
   memset(&sContext, 0, sizeof(sContext));
   db = pParse.db;
+  pReturning = pParse.pReturning
+  pParse.pReturning = nil
   if( pParse.nErr || db.mallocFailed ){
     goto update_cleanup;
   }
@@ -104,9 +107,14 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
   ** need to occur right after the database cursor.  So go ahead and
   ** allocate enough space, just in case.
   */
-  iCur = pParse.nTab
-  pTabList.a[0].iCursor = iCur
-	pParse.nTab += len(pTab.Indices) + 1
+	if pParse.isUpsertUpdate {
+		//	The cursors were allocated by UpsertDoUpdate(), whose WHERE clause refers to the table cursor.
+		iCur = pTabList.a[0].iCursor
+	} else {
+		iCur = pParse.nTab
+		pTabList.a[0].iCursor = iCur
+		pParse.nTab += len(pTab.Indices) + 1
+	}
 
   /* Initialize the name-context */
   memset(&sNC, 0, sizeof(sNC));
@@ -126,6 +134,10 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
     }
     for(j=0; j<pTab.nCol; j++){
       if !CaseInsensitiveMatch(pTab.Columns[j].Name, pChanges.a[i].Name) {
+        if pTab.Columns[j].IsGenerated() {
+          pParse.SetErrorMsg("cannot UPDATE generated column \"%v\"", pTab.Columns[j].Name)
+          goto update_cleanup
+        }
         if( j==pTab.iPKey ){
           chngRowid = 1;
           pRowidExpr = pChanges.a[i].Expr;
@@ -135,7 +147,7 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
       }
     }
     if( j>=pTab.nCol ){
-      if( sqlite3IsRowid(pChanges.a[i].Name) ){
+      if( pTab.HasRowid() && sqlite3IsRowid(pChanges.a[i].Name) ){
         chngRowid = 1;
         pRowidExpr = pChanges.a[i].Expr;
       }else{
@@ -156,13 +168,21 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 
   hasFK = sqlite3FkRequired(pParse, pTab, aXRef, chngRowid);
 
+	//	Changing a PRIMARY KEY column of a WITHOUT ROWID table changes the key of the row, as changing the rowid does for other tables.
+	chngPk := false
+	if !pTab.HasRowid() {
+		for _, iCol := range pTab.PrimaryKey().Columns {
+			chngPk = chngPk || aXRef[iCol] >= 0
+		}
+	}
+
 	//	Allocate memory for the array aRegIdx[]. There is one entry in the array for each index associated with table being updated. Fill in the value with a register number for indices that are to be used and with zero for unused indices.
 	if nIndex := len(pTab.Indices); nIndex > 0 {
 		aRegIdx = make([]*Index, nIndex)
 	}
 	for _, index := range pTab.Indices {
 		reg			int
-		if hasFK || chngRowid {
+		if hasFK || chngRowid || chngPk {
 			pParse.nMem++
 			reg = pParse.nMem
 		} else {
@@ -174,6 +194,14 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 					break
 				}
 			}
+			//	A row may move into or out of a partial index if a column of its WHERE clause changes, and the key of an index on
+			//	expressions or on generated columns changes with the columns they are computed from.
+			for i := 0; reg == 0 && i < pTab.nCol; i++ {
+				if aXRef[i] >= 0 && (index.PartialIndexUses(i) || index.ExprUses(i) || index.GeneratedUses(i)) {
+					pParse.nMem++
+					reg = pParse.nMem
+				}
+			}
 		}
 		aRegIdx = append(aRegIdx, reg)
 	}
@@ -184,6 +212,11 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
   if( pParse.nested==0 ) sqlite3VdbeCountChanges(v);
   pParse.BeginWriteOperation(1, iDb)
 
+	//	Within the DO UPDATE of an UPSERT, the INSERT has already begun the RETURNING clause and returns the rows itself.
+	if pReturning != nil && !pParse.isUpsertUpdate && pParse.ReturningBegin(pReturning, pTab) != SQLITE_OK {
+		goto update_cleanup
+	}
+
   /* Virtual tables must be handled separately */
   if( pTab.IsVirtual() ){
     updateVirtualTable(pParse, pTabList, pTab, pChanges, pRowidExpr, aXRef, pWhere, onError);
@@ -193,13 +226,12 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
   }
 
   /* Allocate required registers. */
-  regRowSet = ++pParse.nMem;
   regOldRowid = regNewRowid = ++pParse.nMem;
   if( pTrigger || hasFK ){
     regOld = pParse.nMem + 1;
     pParse.nMem += pTab.nCol;
   }
-  if( chngRowid || pTrigger || hasFK ){
+  if( chngRowid || chngPk || pTrigger || hasFK ){
     regNewRowid = ++pParse.nMem;
   }
   regNew = pParse.nMem + 1;
@@ -226,16 +258,17 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 
   /* Begin the database scan
   */
-  v.AddOp3(OP_Null, 0, regRowSet, regOldRowid);
+  regRowSet = pParse.RowKeySetOpen(pTab)
+  v.AddOp2(OP_Null, 0, regOldRowid)
   pWInfo = pParse.WhereBegin(pTabList, pWhere, nil, nil, WHERE_ONEPASS_DESIRED)
   if( pWInfo==0 ) goto update_cleanup;
   okOnePass = pWInfo.okOnePass;
 
   /* Remember the rowid of every item to be updated.
   */
-  v.AddOp2(OP_Rowid, iCur, regOldRowid);
+  pParse.CodeRowKey(pTab, iCur, regOldRowid)
   if( !okOnePass ){
-    v.AddOp2(OP_RowSetAdd, regRowSet, regOldRowid);
+    pParse.RowKeySetAdd(pTab, regRowSet, regOldRowid)
   }
 
   /* End the database scan loop.
@@ -268,7 +301,7 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 
 		for i, index := range pTab.Indices {
 			assert( aRegIdx )
-			if openAll || aRegIdx[i] > 0 {
+			if (openAll || aRegIdx[i] > 0) && !index.IsClustered() {
 				pKey := pParse.IndexKeyinfo(index)
 				sqlite3VdbeAddOp4(v, OP_OpenWrite, iCur + i + 1, index.tnum, iDb, (char*)pKey, P4_KEYINFO_HANDOFF)
 				assert( pParse.nTab > iCur + i + 1 )
@@ -282,13 +315,13 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
     addr = v.AddOp0(OP_Goto);
     v.JumpHere(a1)
   }else{
-    addr = v.AddOp3(OP_RowSetRead, regRowSet, 0, regOldRowid);
+    addr = pParse.RowKeySetRead(pTab, regRowSet, 0, regOldRowid)
   }
 
   /* Make cursor iCur point to the record that is being updated. If
   ** this record does not exist for some reason (deleted by a trigger,
   ** for example, then jump to the next iteration of the RowSet loop.  */
-  v.AddOp3(OP_NotExists, iCur, addr, regOldRowid);
+  pParse.SeekRowKey(pTab, iCur, addr, regOldRowid)
 
   /* If the record number will change, set register regNewRowid to
   ** contain the new value. If the record number is not being modified,
@@ -310,7 +343,7 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
     oldmask |= sqlite3TriggerColmask(pParse, pTrigger, pChanges, 0, TRIGGER_BEFORE|TRIGGER_AFTER, pTab, onError);
     for(i=0; i<pTab.nCol; i++){
       if( aXRef[i]<0 || oldmask==0xffffffff || (i<32 && (oldmask & (1<<i))) ){
-        v.ExprCodeGetColumnOfTable(pTab, iCur, i, regOld+i)
+        pParse.ExprCodeGetColumnOfTable(pTab, iCur, i, regOld+i)
       }else{
         v.AddOp2(OP_Null, 0, regOld+i);
       }
@@ -338,23 +371,24 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
   );
   v.AddOp3(OP_Null, 0, regNew, regNew+pTab.nCol-1);
   for(i=0; i<pTab.nCol; i++){
-    if( i==pTab.iPKey ){
+    if( i==pTab.iPKey || pTab.Columns[i].IsGenerated() ){
       /*v.AddOp2(OP_Null, 0, regNew+i);*/
     }else{
       j = aXRef[i];
       if( j>=0 ){
         sqlite3ExprCode(pParse, pChanges.a[j].Expr, regNew+i);
-      }else if( 0==(tmask&TRIGGER_BEFORE) || i>31 || (newmask&(1<<i)) ){
+      }else if( 0==(tmask&TRIGGER_BEFORE) || i>31 || (newmask&(1<<i)) || pTab.HasGenerated() ){
         /* This branch loads the value of a column that will not be changed 
         ** into a register. This is done if there are no BEFORE triggers, or
         ** if there are one or more BEFORE triggers that use this value via
-        ** a new.* reference in a trigger program.
+        ** a new.* reference in a trigger program, or if the generated
+        ** columns may be computed from it.
         */
-        v.AddOp3(OP_Column, iCur, i, regNew+i);
-        v.ColumnDefault(pTab, i, regNew + i)
+        pParse.ExprCodeGetColumnOfTable(pTab, iCur, i, regNew+i)
       }
     }
   }
+  pParse.CodeGeneratedColumns(pTab, regNew)
 
   /* Fire any BEFORE UPDATE triggers. This happens before constraints are
   ** verified. One could argue that this is wrong.
@@ -371,7 +405,7 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
     ** is deleted or renamed by a BEFORE trigger - is left undefined in the
     ** documentation.
     */
-    v.AddOp3(OP_NotExists, iCur, addr, regOldRowid);
+    pParse.SeekRowKey(pTab, iCur, addr, regOldRowid)
 
     /* If it did not delete it, the row-trigger may still have modified 
     ** some of the columns of the row being updated. Load the values for 
@@ -379,11 +413,11 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
     ** registers in case this has happened.
     */
     for(i=0; i<pTab.nCol; i++){
-      if( aXRef[i]<0 && i!=pTab.iPKey ){
-        v.AddOp3(OP_Column, iCur, i, regNew+i);
-        v.ColumnDefault(pTab, i, regNew + i)
+      if( aXRef[i]<0 && i!=pTab.iPKey && !pTab.Columns[i].IsGenerated() ){
+        pParse.ExprCodeGetColumnOfTable(pTab, iCur, i, regNew+i)
       }
     }
+    pParse.CodeGeneratedColumns(pTab, regNew)
   }
 
   if( !isView ){
@@ -391,7 +425,7 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 
     /* Do constraint checks. */
     sqlite3GenerateConstraintChecks(pParse, pTab, iCur, regNewRowid,
-        aRegIdx, (chngRowid?regOldRowid:0), 1, onError, addr, 0);
+        aRegIdx, ((chngRowid || chngPk)?regOldRowid:0), 1, onError, addr, 0, nil);
 
     /* Do FK constraint checks. */
     if( hasFK ){
@@ -399,11 +433,13 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
     }
 
     /* Delete the index entries associated with the current record.  */
-    j1 = v.AddOp3(OP_NotExists, iCur, 0, regOldRowid);
+    j1 = pParse.SeekRowKey(pTab, iCur, 0, regOldRowid)
     pParse.GenerateRowIndexDelete(pTab, iCur, aRegIdx)
   
-    /* If changing the record number, delete the old record.  */
-    if( hasFK || chngRowid ){
+    /* If changing the record number, delete the old record. The row of a
+    ** WITHOUT ROWID table is an index entry, which the new record does not
+    ** replace, so it is always deleted.  */
+    if( hasFK || chngRowid || !pTab.HasRowid() ){
       v.AddOp2(OP_Delete, iCur, 0);
     }
     v.JumpHere(j1)
@@ -429,6 +465,11 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 
   sqlite3CodeRowTrigger(pParse, pTrigger, TK_UPDATE, pChanges, TRIGGER_AFTER, pTab, regOldRowid, onError, addr);
 
+	//	Save the RETURNING values of the updated row, as changed by triggers.
+	if pReturning != nil {
+		pParse.ReturningRow(pReturning, regNewRowid)
+	}
+
   /* Repeat the above with the next record to be updated, until
   ** all record selected by the WHERE clause have been updated.
   */
@@ -445,12 +486,17 @@ func (v *Vdbe) ColumnDefault(table *Table, i, register int) {
 	v.AddOp2(OP_Close, iCur, 0)
 
 	//	Update the sqlite_sequence table by storing the content of the maximum rowid counter values recorded while inserting into autoincrement tables.
-	if !pParse.nested && pParse.pTriggerTab == nil {
+	if !pParse.nested && pParse.pTriggerTab == nil && !pParse.isUpsertUpdate {
 		sqlite3AutoincrementEnd(pParse)
 	}
 
+	//	Return the rows saved for the RETURNING clause. These replace the count of rows updated.
+	if pReturning != nil && !pParse.isUpsertUpdate {
+		pParse.ReturningEnd(pReturning)
+	}
+
 	//	Return the number of rows that were changed. If this routine is generating code because of a call to sqlite3NestedParse(), do not invoke the callback function.
-	if (db.flags & SQLITE_CountRows) && !pParse.pTriggerTab && !pParse.nested {
+	if (db.flags & SQLITE_CountRows) && !pParse.pTriggerTab && !pParse.nested && !pParse.isUpsertUpdate && pReturning == nil {
 		v.AddOp2(OP_ResultRow, regRowCount, 1)
 		sqlite3VdbeSetNumCols(v, 1)
 		sqlite3VdbeSetColName(v, 0, COLNAME_NAME, "rows updated", SQLITE_STATIC)
@@ -0,0 +1,428 @@
+//	This file implements UPSERT: the ON CONFLICT clause of an INSERT statement.
+//
+//		INSERT INTO t VALUES(...) ON CONFLICT (target) DO UPDATE SET ... WHERE ...
+//		INSERT INTO t VALUES(...) ON CONFLICT [(target)] DO NOTHING
+//
+//	Parse.ParseUpsert() (see Parse.Run()) removes the clause from the statement and records it on Parse.pUpsert, where
+//	sqlite3Insert() finds it. The clause of an INSERT in a trigger program is kept on its TriggerStep, from which codeTriggerProgram()
+//	puts it on Parse.pUpsert. sqlite3GenerateConstraintChecks() resolves a conflict on the target constraint by skipping the new
+//	row, after running the DO UPDATE through sqlite3Update() if there is one. Within the DO UPDATE, the pseudo-table "excluded"
+//	names the row that could not be inserted.
+
+//	An Upsert is the ON CONFLICT clause of an INSERT statement.
+type Upsert struct {
+	pTarget			*ExprList			//	The conflict target columns, or nil if there is no target
+	pTargetWhere	*Expr				//	WHERE clause of the conflict target, or nil
+	pSet			*ExprList			//	The DO UPDATE SET assignments, or nil for DO NOTHING
+	pWhere			*Expr				//	The DO UPDATE WHERE clause, or nil
+	isRowid			bool				//	True if the target is the INTEGER PRIMARY KEY. Set by UpsertAnalyzeTarget()
+	pIdx			*Index				//	The UNIQUE index that is the target, or nil. Set by UpsertAnalyzeTarget()
+	pReturning		*Returning			//	The RETURNING clause of the INSERT, or nil. Set by sqlite3Insert()
+}
+
+//	If the first statement of zSql is an INSERT with an ON CONFLICT clause, parse the clause into pParse.pUpsert and return the
+//	statement without it. If it is a CREATE TRIGGER, do the same for each INSERT of the trigger program, queueing the clauses on
+//	pParse.aTriggerUpsert for the trigger steps, and save the original text of the statement from the trigger name onwards in
+//	pParse.zTriggerText for the sqlite_master table. Otherwise return zSql unchanged. Errors are left in pParse.
+func (pParse *Parse) ParseUpsert(zSql string) string {
+	s := newSqlScanner(zSql)
+	if s.Type == TK_CREATE {
+		return pParse.parseTriggerUpserts(s, zSql)
+	}
+	s.SkipWith()
+	if s.Type != TK_INSERT && s.Type != TK_REPLACE {
+		return zSql
+	}
+	pUpsert, iStart, iEnd := pParse.parseUpsert(s, zSql)
+	if pUpsert == nil {
+		return zSql
+	}
+	pParse.pUpsert = pUpsert
+	return zSql[:iStart] + zSql[iEnd:]
+}
+
+//	Parse the ON CONFLICT clauses of the INSERT statements of the trigger program of the CREATE TRIGGER statement at s.
+func (pParse *Parse) parseTriggerUpserts(s *sqlScanner, zSql string) string {
+	if s.Next(); s.Type == TK_TEMP {
+		s.Next()
+	}
+	if s.Type != TK_TRIGGER {
+		return zSql
+	}
+	if s.Next(); s.Type == TK_IF {
+		s.Next()			//	NOT
+		s.Next()			//	EXISTS
+		s.Next()
+	}
+	//	The text kept for sqlite_master starts at the trigger name, leaving out any database name, as sqlite3FinishTrigger() does.
+	iName := s.Start
+	if t := *s; t.Next() && t.Type == TK_DOT && t.Next() {
+		iName = t.Start
+	}
+	for depth := 0; s.Type != TK_BEGIN || depth > 0; s.Next() {
+		switch s.Type {
+		case 0, TK_SEMI:
+			return zSql
+		case TK_LP:
+			depth++
+		case TK_RP:
+			depth--
+		}
+	}
+
+	//	Each statement of the program ends with a ";" outside of parentheses. The program ends with the END that starts a statement.
+	type edit struct {
+		start, end	int
+	}
+	var edits []edit
+	var aUpsert []*Upsert
+	for s.Next(); s.Type != TK_END; s.Next() {
+		if s.Type == TK_INSERT || s.Type == TK_REPLACE {
+			pUpsert, iStart, iEnd := pParse.parseUpsert(s, zSql)
+			if pParse.nErr > 0 {
+				return zSql
+			}
+			if pUpsert != nil {
+				edits = append(edits, edit{ iStart, iEnd })
+			}
+			aUpsert = append(aUpsert, pUpsert)
+		}
+		for depth := 0; s.Type != TK_SEMI || depth > 0; s.Next() {
+			switch s.Type {
+			case 0:
+				return zSql
+			case TK_LP:
+				depth++
+			case TK_RP:
+				depth--
+			}
+		}
+	}
+	if len(edits) == 0 {
+		return zSql
+	}
+	pParse.aTriggerUpsert = aUpsert
+	pParse.zTriggerText = zSql[iName:s.iNext]
+	for i := len(edits) - 1; i >= 0; i-- {
+		zSql = zSql[:edits[i].start] + zSql[edits[i].end:]
+	}
+	return zSql
+}
+
+//	Parse the ON CONFLICT clause of the INSERT statement at s. Return the clause and the start and end of its text, or nil if the
+//	statement has none. s is left at the end of the statement. Errors are left in pParse.
+func (pParse *Parse) parseUpsert(s *sqlScanner, zSql string) (pUpsert *Upsert, iStart, iEnd int) {
+	syntaxError := func() (*Upsert, int, int) {
+		if s.Type == 0 {
+			pParse.SetErrorMsg("incomplete input")
+		} else {
+			pParse.SetErrorMsg("near \"%v\": syntax error", s.Text)
+		}
+		return nil, 0, 0
+	}
+
+	//	Find "ON CONFLICT (" or "ON CONFLICT DO" outside of any parentheses. A plain "ON CONFLICT" followed by a resolution such as
+	//	REPLACE is the legacy conflict clause of a column or table constraint, not an UPSERT.
+	depth := 0
+	for ; s.Type != 0 && s.Type != TK_SEMI; s.Next() {
+		if s.Type == TK_LP {
+			depth++
+		} else if s.Type == TK_RP {
+			depth--
+		} else if s.Type == TK_ON && depth == 0 {
+			t := *s
+			if t.Next() && t.Type == TK_CONFLICT && t.Next() && (t.Type == TK_LP || t.IsWord("DO")) {
+				break
+			}
+		}
+	}
+	if s.Type != TK_ON {
+		return nil, 0, 0
+	}
+	iStart = s.Start
+	s.Next()
+	s.Next()
+
+	//	Return the text up to the first of the given words found outside parentheses, or up to the end of the statement.
+	clause := func(stop func() bool) string {
+		start := s.Start
+		for depth := 0; s.Type != 0 && s.Type != TK_SEMI; s.Next() {
+			switch {
+			case s.Type == TK_LP:
+				depth++
+			case s.Type == TK_RP:
+				depth--
+			case depth == 0 && stop():
+				return zSql[start:s.Start]
+			}
+		}
+		return zSql[start:s.Start]
+	}
+
+	pUpsert = new(Upsert)
+	if s.Type == TK_LP {
+		body, ok := s.Parenthesized()
+		if !ok {
+			return syntaxError()
+		}
+		pSel := pParse.ParseSelect("SELECT " + body)
+		if pSel == nil {
+			return nil, 0, 0
+		}
+		pUpsert.pTarget = pSel.pEList
+		if s.Next(); s.Type == TK_WHERE {
+			s.Next()
+			zText := clause(func() bool { return s.IsWord("DO") })
+			if pSel = pParse.ParseSelect("SELECT 0 WHERE " + zText); pSel == nil {
+				return nil, 0, 0
+			}
+			pUpsert.pTargetWhere = pSel.Where
+		}
+	}
+	if !s.IsWord("DO") {
+		return syntaxError()
+	}
+	switch s.Next(); {
+	case s.IsWord("NOTHING"):
+		s.Next()
+	case s.Type == TK_UPDATE:
+		if s.Next(); s.Type != TK_SET {
+			return syntaxError()
+		}
+		pUpsert.pSet = NewExprList()
+		for {
+			if s.Next(); !s.IsName() {
+				return syntaxError()
+			}
+			zName := Dequote(s.Text)
+			if s.Next(); s.Type != TK_EQ {
+				return syntaxError()
+			}
+			s.Next()
+			zText := clause(func() bool { return s.Type == TK_COMMA || s.Type == TK_WHERE })
+			pSel := pParse.ParseSelect("SELECT " + zText)
+			if pSel == nil {
+				return nil, 0, 0
+			}
+			pUpsert.pSet.Items = append(pUpsert.pSet.Items, &ExprList_item{ Expr: pSel.pEList.Items[0].Expr, Name: zName })
+			if s.Type != TK_COMMA {
+				break
+			}
+		}
+		if s.Type == TK_WHERE {
+			s.Next()
+			zText := clause(func() bool { return false })
+			pSel := pParse.ParseSelect("SELECT 0 WHERE " + zText)
+			if pSel == nil {
+				return nil, 0, 0
+			}
+			pUpsert.pWhere = pSel.Where
+		}
+	default:
+		return syntaxError()
+	}
+	if s.Type != 0 && s.Type != TK_SEMI {
+		return syntaxError()
+	}
+	return pUpsert, iStart, s.Start
+}
+
+//	Find the constraint of pTab that the conflict target of pUpsert names: either the INTEGER PRIMARY KEY or a UNIQUE index with
+//	exactly the target columns. Leave an error in pParse and return SQLITE_ERROR if there is no such constraint.
+func (pParse *Parse) UpsertAnalyzeTarget(pTab *Table, pUpsert *Upsert) (rc int) {
+	db := pParse.db
+	if pUpsert.pTarget == nil {
+		if pUpsert.pSet != nil {
+			pParse.SetErrorMsg("ON CONFLICT DO UPDATE requires a conflict target")
+			return SQLITE_ERROR
+		}
+		return SQLITE_OK
+	}
+
+	//	Resolve the target columns against pTab.
+	iDb := db.SchemaToIndex(pTab.Schema)
+	pSrc := db.SrcListAppend(nil, pTab.Name, db.Databases[iDb].Name)
+	pSrc.a[0].pTab = pTab
+	pSrc.a[0].iCursor = -1
+	sNC := NameContext{ Parse: pParse, SrcList: pSrc }
+	for _, item := range pUpsert.pTarget.Items {
+		if sqlite3ResolveExprNames(&sNC, item.Expr) {
+			return SQLITE_ERROR
+		}
+	}
+	if pUpsert.pTargetWhere != nil && sqlite3ResolveExprNames(&sNC, pUpsert.pTargetWhere) {
+		return SQLITE_ERROR
+	}
+
+	nTerm := pUpsert.pTarget.Len()
+	if nTerm == 1 && pUpsert.pTarget.Items[0].Expr.op == TK_COLUMN && pUpsert.pTarget.Items[0].Expr.iColumn < 0 {
+		pUpsert.isRowid = true
+		return SQLITE_OK
+	}
+	for _, pIdx := range pTab.Indices {
+		if pIdx.onError == OE_None || len(pIdx.Columns) != nTerm {
+			continue
+		}
+		//	A partial index is only a target if the WHERE clause of the target implies its own.
+		if pIdx.pPartIdxWhere != nil && !partialIndexImplied(partialIndexSplit(nil, pUpsert.pTargetWhere), -1, pIdx.pPartIdxWhere) {
+			continue
+		}
+		matched := true
+		for i, iCol := range pIdx.Columns {
+			found := false
+			for _, item := range pUpsert.pTarget.Items {
+				e := item.Expr
+				switch {
+				case iCol == XN_EXPR:
+					//	Compare the target with the indexed expression, ignoring any COLLATE clause at the top level.
+					if e.Compare(pIdx.aColExpr[i]) > 1 {
+						continue
+					}
+				case e.op != TK_COLUMN || e.iColumn != iCol:
+					continue
+				}
+				if e.flags & EP_ExpCollate != 0 && e.pColl != nil && !CaseInsensitiveMatch(e.pColl.Name, pIdx.azColl[i]) {
+					continue
+				}
+				found = true
+				break
+			}
+			if !found {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			pUpsert.pIdx = pIdx
+			return SQLITE_OK
+		}
+	}
+	pParse.SetErrorMsg("ON CONFLICT clause does not match any PRIMARY KEY or UNIQUE constraint")
+	return SQLITE_ERROR
+}
+
+//	Return a copy of p for a trigger step to code, since coding an UPSERT resolves its expressions in place.
+func (p *Upsert) Dup() *Upsert {
+	if p == nil {
+		return nil
+	}
+	return &Upsert{ pTarget: p.pTarget.Dup(), pTargetWhere: p.pTargetWhere.Dup(), pSet: p.pSet.Dup(), pWhere: p.pWhere.Dup() }
+}
+
+//	Give pStep, the trigger step of the next INSERT of the CREATE TRIGGER being parsed, the ON CONFLICT clause that ParseUpsert() took
+//	out of the INSERT, if any.
+func (pParse *Parse) TriggerStepUpsert(pStep *TriggerStep) {
+	if len(pParse.aTriggerUpsert) == 0 {
+		return
+	}
+	if pStep != nil {
+		pStep.pUpsert = pParse.aTriggerUpsert[0]
+	}
+	pParse.aTriggerUpsert = pParse.aTriggerUpsert[1:]
+}
+
+//	Return true if the conflict resolution of pUpsert applies to a conflict on pIdx, or on the INTEGER PRIMARY KEY if pIdx is nil.
+func (p *Upsert) Matches(pIdx *Index) bool {
+	switch {
+	case p == nil:
+		return false
+	case p.pTarget == nil:
+		return true
+	case pIdx == nil:
+		return p.isRowid
+	}
+	return p.pIdx == pIdx
+}
+
+//	Return a copy of pExpr in which each reference to a column of the "excluded" pseudo-table is replaced by the register holding that
+//	column of the row that could not be inserted. The rowid is in register regRowid and the columns follow it.
+func (pParse *Parse) upsertExcluded(pTab *Table, regRowid int, pExpr *Expr) *Expr {
+	if pExpr == nil {
+		return nil
+	}
+	if pExpr.op == TK_DOT && pExpr.pLeft.op == TK_ID && CaseInsensitiveMatch(pExpr.pLeft.Token, "excluded") && pExpr.pRight.op == TK_ID {
+		zCol := pExpr.pRight.Token
+		pNew := sqlite3ExprAlloc(pParse.db, TK_REGISTER, 0, 0)
+		pNew.op2 = TK_COLUMN
+		pNew.pTab = pTab
+		pNew.iColumn = -1
+		pNew.iTable = regRowid
+		for i, pCol := range pTab.Columns {
+			if CaseInsensitiveMatch(pCol.Name, zCol) {
+				if i != pTab.iPKey {
+					pNew.iColumn = i
+					pNew.iTable = regRowid + 1 + i
+				}
+				return pNew
+			}
+		}
+		if !pTab.HasRowid() || !sqlite3IsRowid(zCol) {
+			pParse.SetErrorMsg("no such column: excluded.%v", zCol)
+		}
+		return pNew
+	}
+	pExpr.pLeft = pParse.upsertExcluded(pTab, regRowid, pExpr.pLeft)
+	pExpr.pRight = pParse.upsertExcluded(pTab, regRowid, pExpr.pRight)
+	if pExpr.pList != nil {
+		for _, item := range pExpr.pList.Items {
+			item.Expr = pParse.upsertExcluded(pTab, regRowid, item.Expr)
+		}
+	}
+	return pExpr
+}
+
+//	Generate code for the DO UPDATE of pUpsert, applied to the row of pTab whose rowid is in register iConflict, or for a WITHOUT ROWID
+//	table, whose PRIMARY KEY columns are in the registers starting with iConflict. The row that could not be inserted is in the
+//	registers starting with regRowid, laid out as for sqlite3GenerateConstraintChecks().
+func (pParse *Parse) UpsertDoUpdate(pUpsert *Upsert, pTab *Table, regRowid, iConflict int) {
+	db := pParse.db
+	v := pParse.GetVdbe()
+	assert( pUpsert.pSet != nil )
+
+	//	The excluded row gets the affinities it would have had if it had been inserted.
+	v.AddOp2(OP_Affinity, regRowid + 1, pTab.nCol)
+	sqlite3TableAffinityStr(v, pTab)
+	sqlite3ExprCacheAffinityChange(pParse, regRowid + 1, pTab.nCol)
+
+	pSet := pUpsert.pSet.Dup()
+	for _, item := range pSet.Items {
+		item.Expr = pParse.upsertExcluded(pTab, regRowid, item.Expr)
+	}
+	//	The row to update is found by comparing its rowid, or the PRIMARY KEY columns of a WITHOUT ROWID table, on the cursor that
+	//	sqlite3Update() opens on pTab with the registers that hold them, so that no column of pTab can hide the rowid. The cursors
+	//	of the UPDATE are allocated here so that the WHERE clause can refer to the table cursor.
+	iDb := db.SchemaToIndex(pTab.Schema)
+	pSrc := db.SrcListAppend(nil, pTab.Name, db.Databases[iDb].Name)
+	pSrc.a[0].iCursor = pParse.nTab
+	pParse.nTab += len(pTab.Indices) + 1
+	isEqual := func(iCol, iReg int) *Expr {
+		pCol := sqlite3ExprAlloc(db, TK_COLUMN, 0, 0)
+		pCol.pTab = pTab
+		pCol.iTable = pSrc.a[0].iCursor
+		pCol.iColumn = iCol
+		pCol.SetProperty(EP_Resolved)
+		pKey := sqlite3ExprAlloc(db, TK_REGISTER, 0, 0)
+		pKey.iTable = iReg
+		return pParse.Expr(TK_EQ, pCol, pKey, "")
+	}
+	var pWhere *Expr
+	if pTab.HasRowid() {
+		pWhere = isEqual(-1, iConflict)
+	} else {
+		for i, iCol := range pTab.PrimaryKey().Columns {
+			if pEq := isEqual(iCol, iConflict + i); pWhere == nil {
+				pWhere = pEq
+			} else {
+				pWhere = pParse.Expr(TK_AND, pWhere, pEq, "")
+			}
+		}
+	}
+	if pUpsert.pWhere != nil {
+		pWhere = pParse.Expr(TK_AND, pWhere, pParse.upsertExcluded(pTab, regRowid, pUpsert.pWhere.Dup()), "")
+	}
+
+	pParse.isUpsertUpdate = true
+	pParse.pReturning = pUpsert.pReturning
+	sqlite3Update(pParse, pSrc, pSet, pWhere, OE_Abort)
+	pParse.isUpsertUpdate = false
+}
@@ -0,0 +1,89 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestUpsert(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE kv(k TEXT PRIMARY KEY, n INTEGER, note TEXT)")
+	const upsert = "INSERT INTO kv(k, n) VALUES(?, ?) ON CONFLICT(k) DO UPDATE SET n = n + excluded.n"
+	testExec(t, db, upsert, "a", 1)
+	testExec(t, db, upsert, "a", 2)
+	testExec(t, db, upsert, "b", 5)
+	testQueryIs(t, db, "a|3\nb|5", "SELECT k, n FROM kv ORDER BY k")
+
+	//	The WHERE clause of DO UPDATE can leave the row as it was.
+	testExec(t, db, "INSERT INTO kv(k, n) VALUES('a', 100) ON CONFLICT(k) DO UPDATE SET n = excluded.n WHERE excluded.n < n")
+	testExec(t, db, "INSERT INTO kv(k, n) VALUES('b', 1) ON CONFLICT(k) DO UPDATE SET n = excluded.n WHERE excluded.n < n")
+	testQueryIs(t, db, "a|3\nb|1", "SELECT k, n FROM kv ORDER BY k")
+
+	//	DO NOTHING with and without a target, for each row of a multi-row INSERT.
+	res, err := db.Exec("INSERT INTO kv(k, n) VALUES('a', 0), ('c', 0), ('c', 1) ON CONFLICT DO NOTHING")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		t.Errorf("RowsAffected() = %v, want 1", n)
+	}
+	testExec(t, db, "INSERT INTO kv(k, n) SELECT k, 9 FROM kv WHERE true ON CONFLICT(k) DO NOTHING")
+	testQueryIs(t, db, "a|3\nb|1\nc|0", "SELECT k, n FROM kv ORDER BY k")
+}
+
+func TestUpsertTargets(t *testing.T) {
+	db := testOpen(t, ":memory:")
+
+	//	The INTEGER PRIMARY KEY as the target.
+	testExec(t, db, "CREATE TABLE r(id INTEGER PRIMARY KEY, v)")
+	testExec(t, db, "INSERT INTO r VALUES(1, 'x')")
+	testExec(t, db, "INSERT INTO r VALUES(1, 'y') ON CONFLICT(id) DO UPDATE SET v = v || excluded.v")
+	testQueryIs(t, db, "1|xy", "SELECT id, v FROM r")
+
+	//	A UNIQUE index that is not the PRIMARY KEY, and a WITHOUT ROWID table.
+	testExec(t, db, "CREATE TABLE u(a PRIMARY KEY, b UNIQUE, c)")
+	testExec(t, db, "INSERT INTO u VALUES(1, 10, 'one')")
+	testExec(t, db, "INSERT INTO u VALUES(2, 10, 'two') ON CONFLICT(b) DO UPDATE SET c = excluded.c")
+	testQueryIs(t, db, "1|10|two", "SELECT * FROM u")
+	testExec(t, db, "CREATE TABLE w(a, b, c, PRIMARY KEY(a, b)) WITHOUT ROWID")
+	testExec(t, db, "INSERT INTO w VALUES(1, 2, 'old')")
+	testExec(t, db, "INSERT INTO w VALUES(1, 2, 'new') ON CONFLICT(a, b) DO UPDATE SET c = excluded.c")
+	testExec(t, db, "INSERT INTO w VALUES(1, 3, 'other') ON CONFLICT(a, b) DO UPDATE SET c = excluded.c")
+	testQueryIs(t, db, "1|2|new\n1|3|other", "SELECT * FROM w ORDER BY b")
+
+	//	A conflict on a constraint other than the target is still an error.
+	if _, err := db.Exec("INSERT INTO u VALUES(1, 20, 'x') ON CONFLICT(b) DO UPDATE SET c = excluded.c"); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+		t.Errorf("conflict outside the target: %v, want SQLITE_CONSTRAINT", err)
+	}
+	for _, query := range []string{
+		"INSERT INTO u VALUES(3, 30, 'x') ON CONFLICT(c) DO NOTHING",
+		"INSERT INTO u VALUES(3, 30, 'x') ON CONFLICT(b) DO UPDATE SET missing = 1",
+	} {
+		if _, err := db.Exec(query); err == nil {
+			t.Errorf("%v: no error", query)
+		}
+	}
+	testQueryIs(t, db, "1|10|two", "SELECT * FROM u")
+}
+
+//	An UPSERT in any statement of a trigger program, including after the first, and after the schema is read back from the file.
+func TestUpsertTrigger(t *testing.T) {
+	zFile := testFile(t)
+	db := testOpen(t, zFile)
+	testExec(t, db, "CREATE TABLE log(x)")
+	testExec(t, db, "CREATE TABLE counts(k PRIMARY KEY, n)")
+	testExec(t, db, "CREATE TABLE seen(k PRIMARY KEY)")
+	testExec(t, db, `CREATE TRIGGER count_log AFTER INSERT ON log BEGIN
+		INSERT INTO seen VALUES(new.x) ON CONFLICT DO NOTHING;
+		INSERT INTO counts VALUES(new.x, 1) ON CONFLICT(k) DO UPDATE SET n = n + 1;
+	END`)
+	testExec(t, db, "INSERT INTO log VALUES('a'), ('b'), ('a')")
+	testQueryIs(t, db, "a|2\nb|1", "SELECT k, n FROM counts ORDER BY k")
+	testQueryIs(t, db, "a\nb", "SELECT k FROM seen ORDER BY k")
+	db.Close()
+
+	db = testOpen(t, zFile)
+	testExec(t, db, "INSERT INTO log VALUES('b')")
+	testQueryIs(t, db, "a|2\nb|2", "SELECT k, n FROM counts ORDER BY k")
+	if got := testQuery(t, db, "SELECT sql FROM sqlite_master WHERE name = 'count_log'"); !strings.Contains(got, "ON CONFLICT(k) DO UPDATE SET n = n + 1") {
+		t.Errorf("trigger text = %q, want the ON CONFLICT clause kept", got)
+	}
+}
@@ -0,0 +1,346 @@
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+//	This file implements the registration of SQL functions written as ordinary Go functions.
+//
+//		db.RegisterFunc("reverse", func(s string) string { ... })
+//		db.RegisterFunc("div", func(a, b int64) (float64, error) { ... })
+//		db.RegisterAggregate("product", 1, func() Aggregate { return &product{ n: 1 } })
+//
+//	RegisterFunc() reflects over the argument and result types of the function once, when it is registered, and builds a converter for
+//	each argument, and refuses a function whose result type cannot be stored. The FuncDef it creates has a single xFunc, goFuncCall(),
+//	which finds the function in the user data of the context, converts the sqlite3_value arguments, calls it and converts the result
+//	back. An error returned by the function, or a panic, becomes the error of the SQL statement through sqlite3_result_error().
+//
+//	An aggregate is a Go value that implements Aggregate. A fresh one is made for each group by the function passed to
+//	RegisterAggregate() and kept in the aggregate state of the context, as the built-in json_group_array() keeps its array. If the value
+//	also implements WindowAggregate, the function can be used as an aggregate window function with an efficient moving frame.
+
+//	Aggregate is implemented by the state of an aggregate function registered with RegisterAggregate(). Step is called with the
+//	arguments of each row of the group and Final once after the last row. The arguments are passed as int64, float64, string, []byte
+//	or nil according to their storage class. Final may return any value that RegisterFunc() accepts as a result.
+type Aggregate interface {
+	Step(args ...interface{}) error
+	Final() (interface{}, error)
+}
+
+//	WindowAggregate is implemented by an Aggregate that can also be used as an aggregate window function. Value returns the value of
+//	the aggregate for the current frame without ending it and Inverse removes the oldest row that was added by Step and has not been
+//	removed yet.
+type WindowAggregate interface {
+	Aggregate
+	Value() (interface{}, error)
+	Inverse(args ...interface{}) error
+}
+
+//	A Go function registered with RegisterFunc().
+type goFunc struct {
+	fn			reflect.Value
+	aConv		[]func(*sqlite3_value) reflect.Value	//	Converter for each fixed argument
+	xVariadic	func(*sqlite3_value) reflect.Value		//	Converter for the variadic arguments, or nil
+	hasErr		bool									//	True if the second result of fn is an error
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+//	RegisterFunc registers fn, which must be a Go function, as the SQL scalar function name of connection db. The function takes as
+//	many arguments as fn, or any number of arguments at least as many as its fixed arguments if fn is variadic. Arguments may be of any
+//	integer, floating point, bool, string or []byte type, a pointer to one of them, which is nil for a NULL, or interface{}, which
+//	receives an int64, float64, string, []byte or nil according to the storage class of the value. fn must return one result, or a
+//	result and an error. The result may be of any of the argument types, or a time.Time, which is returned as text, and a function with
+//	a result of any other type is refused. Returning a nil pointer, slice or interface returns NULL.
+func (db *sqlite3) RegisterFunc(name string, fn interface{}) error {
+	v := reflect.ValueOf(fn)
+	t := v.Type()
+	if t.Kind() != reflect.Func {
+		return fmt.Errorf("sqlite3: RegisterFunc needs a function, not %T", fn)
+	}
+	p := &goFunc{ fn: v }
+	switch {
+	case t.NumOut() == 2 && t.Out(1) == errorType:
+		p.hasErr = true
+	case t.NumOut() != 1:
+		return fmt.Errorf("sqlite3: function %v must return a value, or a value and an error", name)
+	}
+	if !goResultType(t.Out(0)) {
+		return fmt.Errorf("sqlite3: function %v has a result of unsupported type %v", name, t.Out(0))
+	}
+	nArg := t.NumIn()
+	for i := 0; i < nArg; i++ {
+		tArg := t.In(i)
+		if t.IsVariadic() && i == nArg - 1 {
+			tArg = tArg.Elem()
+		}
+		xConv := goValueConverter(tArg)
+		if xConv == nil {
+			return fmt.Errorf("sqlite3: function %v has an argument of unsupported type %v", name, tArg)
+		}
+		if t.IsVariadic() && i == nArg - 1 {
+			p.xVariadic = xConv
+		} else {
+			p.aConv = append(p.aConv, xConv)
+		}
+	}
+	if t.IsVariadic() {
+		nArg = -1
+	}
+	return db.createGoFunction(name, nArg, p, goFuncCall, nil, nil)
+}
+
+//	RegisterAggregate registers the SQL aggregate function name of connection db, which takes nArg arguments, or any number of
+//	arguments if nArg is -1. newAggregate is called for each group to make the Aggregate that computes its value.
+func (db *sqlite3) RegisterAggregate(name string, nArg int, newAggregate func() Aggregate) error {
+	if newAggregate == nil {
+		return fmt.Errorf("sqlite3: RegisterAggregate needs a function to make the aggregate")
+	}
+	if _, ok := newAggregate().(WindowAggregate); ok {
+		return db.lastError(sqlite3_create_window_function(db, name, nArg, SQLITE_UTF8, newAggregate, goAggregateStep, goAggregateFinal, goAggregateValue, goAggregateInverse, nil))
+	}
+	return db.createGoFunction(name, nArg, newAggregate, nil, goAggregateStep, goAggregateFinal)
+}
+
+//	Register a function whose user data is the Go value p.
+func (db *sqlite3) createGoFunction(name string, nArg int, p interface{}, xFunc, xStep func(*sqlite3_context, int, []*sqlite3_value), xFinal func(*sqlite3_context)) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+	if rc := db.ApiExit(sqlite3CreateFunc(db, name, nArg, SQLITE_UTF8, p, xFunc, xStep, xFinal, nil)); rc != SQLITE_OK {
+		return db.lastError(rc)
+	}
+	return nil
+}
+
+//	Return a function that converts an sqlite3_value to a reflect.Value of type t, or nil if values of type t cannot be made.
+func goValueConverter(t reflect.Type) func(*sqlite3_value) reflect.Value {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return func(pVal *sqlite3_value) reflect.Value {
+			return reflect.ValueOf(sqlite3_value_int64(pVal)).Convert(t)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return func(pVal *sqlite3_value) reflect.Value {
+			return reflect.ValueOf(uint64(sqlite3_value_int64(pVal))).Convert(t)
+		}
+	case reflect.Float32, reflect.Float64:
+		return func(pVal *sqlite3_value) reflect.Value {
+			return reflect.ValueOf(sqlite3_value_double(pVal)).Convert(t)
+		}
+	case reflect.Bool:
+		return func(pVal *sqlite3_value) reflect.Value {
+			return reflect.ValueOf(sqlite3_value_int64(pVal) != 0).Convert(t)
+		}
+	case reflect.String:
+		return func(pVal *sqlite3_value) reflect.Value {
+			return reflect.ValueOf(sqlite3_value_text(pVal)).Convert(t)
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() != reflect.Uint8 {
+			return nil
+		}
+		return func(pVal *sqlite3_value) reflect.Value {
+			if sqlite3_value_type(pVal) == SQLITE_NULL {
+				return reflect.Zero(t)
+			}
+			//	The buffer of the value is only valid until the function returns, so it must be copied.
+			return reflect.ValueOf(append([]byte{}, sqlite3_value_blob(pVal)...)).Convert(t)
+		}
+	case reflect.Ptr:
+		xElem := goValueConverter(t.Elem())
+		if xElem == nil {
+			return nil
+		}
+		return func(pVal *sqlite3_value) reflect.Value {
+			if sqlite3_value_type(pVal) == SQLITE_NULL {
+				return reflect.Zero(t)
+			}
+			p := reflect.New(t.Elem())
+			p.Elem().Set(xElem(pVal))
+			return p
+		}
+	case reflect.Interface:
+		if t.NumMethod() != 0 {
+			return nil
+		}
+		return func(pVal *sqlite3_value) reflect.Value {
+			if v := goValue(pVal); v != nil {
+				return reflect.ValueOf(v)
+			}
+			return reflect.Zero(t)
+		}
+	}
+	return nil
+}
+
+//	Return the value of pVal as the Go type of its storage class: int64, float64, string, []byte or nil.
+func goValue(pVal *sqlite3_value) interface{} {
+	switch sqlite3_value_type(pVal) {
+	case SQLITE_INTEGER:
+		return sqlite3_value_int64(pVal)
+	case SQLITE_FLOAT:
+		return sqlite3_value_double(pVal)
+	case SQLITE_TEXT:
+		return sqlite3_value_text(pVal)
+	case SQLITE_BLOB:
+		return append([]byte{}, sqlite3_value_blob(pVal)...)
+	}
+	return nil
+}
+
+//	Return the values of the arguments of a function as the Go types of their storage classes.
+func goValues(argv []*sqlite3_value) (args []interface{}) {
+	args = make([]interface{}, len(argv))
+	for i, pVal := range argv {
+		args[i] = goValue(pVal)
+	}
+	return
+}
+
+//	Return true if goResult() can store values of type t. The dynamic type of an interface can only be checked when it is returned.
+func goResultType(t reflect.Type) bool {
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+	case reflect.Float32, reflect.Float64, reflect.Bool, reflect.String, reflect.Interface:
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8
+	case reflect.Ptr:
+		return goResultType(t.Elem())
+	default:
+		return false
+	}
+	return true
+}
+
+//	Make a panic in a Go function called for context the error of the SQL statement. It must be deferred by the callbacks that call
+//	into Go code registered by the application.
+func goRecover(context *sqlite3_context) {
+	if r := recover(); r != nil {
+		sqlite3_result_error(context, fmt.Sprintf("panic in function %v(): %v", context.pFunc.Name, r), -1)
+	}
+}
+
+//	Set the result of context to the Go value v. A value of a type that cannot be stored is an error.
+func goResult(context *sqlite3_context, v reflect.Value) {
+	if !v.IsValid() {
+		sqlite3_result_null(context)
+		return
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		sqlite3_result_text(context, t.Format(driverTimeFormats[0]), -1, SQLITE_TRANSIENT)
+		return
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		sqlite3_result_int64(context, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		sqlite3_result_int64(context, int64(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		context.SetFloat64(v.Float())
+	case reflect.Bool:
+		if v.Bool() {
+			sqlite3_result_int64(context, 1)
+		} else {
+			sqlite3_result_int64(context, 0)
+		}
+	case reflect.String:
+		sqlite3_result_text(context, v.String(), -1, SQLITE_TRANSIENT)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.Uint8 {
+			sqlite3_result_error(context, fmt.Sprintf("unsupported result type %v", v.Type()), -1)
+		} else if v.IsNil() {
+			sqlite3_result_null(context)
+		} else {
+			b := v.Bytes()
+			sqlite3_result_blob(context, string(b), len(b), SQLITE_TRANSIENT)
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			sqlite3_result_null(context)
+		} else {
+			goResult(context, v.Elem())
+		}
+	default:
+		sqlite3_result_error(context, fmt.Sprintf("unsupported result type %v", v.Type()), -1)
+	}
+}
+
+//	The xFunc of a function registered with RegisterFunc().
+func goFuncCall(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	defer goRecover(context)
+	p := sqlite3_user_data(context).(*goFunc)
+	if argc < len(p.aConv) {
+		sqlite3_result_error(context, fmt.Sprintf("wrong number of arguments to function %v()", context.pFunc.Name), -1)
+		return
+	}
+	aIn := make([]reflect.Value, argc)
+	for i, pVal := range argv[:argc] {
+		if i < len(p.aConv) {
+			aIn[i] = p.aConv[i](pVal)
+		} else {
+			aIn[i] = p.xVariadic(pVal)
+		}
+	}
+	aOut := p.fn.Call(aIn)
+	if p.hasErr && !aOut[1].IsNil() {
+		sqlite3_result_error(context, aOut[1].Interface().(error).Error(), -1)
+		return
+	}
+	goResult(context, aOut[0])
+}
+
+//	Return the Aggregate of the current group of an aggregate registered with RegisterAggregate(), making it if create is true and
+//	there is none yet. nil is returned if create is false and Step has not been called for the group.
+func goAggregate(context *sqlite3_context, create bool) Aggregate {
+	newAggregate := sqlite3_user_data(context).(func() Aggregate)
+	fresh := func() interface{} {
+		return newAggregate()
+	}
+	if !create {
+		fresh = nil
+	}
+	p, _ := context.AggregateState(fresh).(Aggregate)
+	return p
+}
+
+//	Set the result of context to a value or an error returned by an Aggregate.
+func goAggregateResult(context *sqlite3_context, v interface{}, err error) {
+	if err != nil {
+		sqlite3_result_error(context, err.Error(), -1)
+	} else {
+		goResult(context, reflect.ValueOf(v))
+	}
+}
+
+func goAggregateStep(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	defer goRecover(context)
+	if err := goAggregate(context, true).Step(goValues(argv[:argc])...); err != nil {
+		sqlite3_result_error(context, err.Error(), -1)
+	}
+}
+
+//	The xFinal of an aggregate. For an empty group there is no state, so Final is called on a fresh Aggregate.
+func goAggregateFinal(context *sqlite3_context) {
+	defer goRecover(context)
+	p := goAggregate(context, false)
+	if p == nil {
+		p = sqlite3_user_data(context).(func() Aggregate)()
+	}
+	goAggregateResult(context, p.Final())
+}
+
+func goAggregateValue(context *sqlite3_context) {
+	defer goRecover(context)
+	goAggregateResult(context, goAggregate(context, true).(WindowAggregate).Value())
+}
+
+func goAggregateInverse(context *sqlite3_context, argc int, argv []*sqlite3_value) {
+	defer goRecover(context)
+	if err := goAggregate(context, true).(WindowAggregate).Inverse(goValues(argv[:argc])...); err != nil {
+		sqlite3_result_error(context, err.Error(), -1)
+	}
+}
@@ -0,0 +1,211 @@
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+//	Register the Go functions of fns with the connection of db by name.
+func testRegisterFuncs(t *testing.T, db *sql.DB, fns map[string]interface{}) {
+	t.Helper()
+	testRaw(t, db, func(db *sqlite3) {
+		for name, fn := range fns {
+			if err := db.RegisterFunc(name, fn); err != nil {
+				t.Fatalf("RegisterFunc(%q): %v", name, err)
+			}
+		}
+	})
+}
+
+func TestRegisterFuncArguments(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testRegisterFuncs(t, db, map[string]interface{}{
+		"add":		func(a, b int) int { return a + b },
+		"small":	func(a int8, b uint16) int64 { return int64(a) + int64(b) },
+		"half":		func(x float64) float64 { return x / 2 },
+		"negate":	func(b bool) bool { return !b },
+		"blen":		func(b []byte) int { return len(b) },
+		"reverse": func(s string) string {
+			r := []rune(s)
+			for i, j := 0, len(r) - 1; i < j; i, j = i + 1, j - 1 {
+				r[i], r[j] = r[j], r[i]
+			}
+			return string(r)
+		},
+		"describe": func(p *int64) string {
+			if p == nil {
+				return "nil"
+			}
+			return fmt.Sprint(*p)
+		},
+		"gotype":	func(v interface{}) string { return fmt.Sprintf("%T", v) },
+		"join":		func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+	})
+
+	//	Arguments are converted to the type of the parameter as by sqlite3_value_int64() and friends.
+	testQueryIs(t, db, "5|3|1.5|-1", "SELECT add(2, '3'), add(2.9, 1), half(3), small(-2, 1)")
+	testQueryIs(t, db, "1|0|0", "SELECT negate(0), negate(5), negate('1')")
+	testQueryIs(t, db, "2|3|0", "SELECT blen(x'0102'), blen('abc'), blen(NULL)")
+	testQueryIs(t, db, "cbà|321", "SELECT reverse('àbc'), reverse(123)")
+
+	//	A pointer is nil for NULL, and interface{} gets the Go type of the storage class.
+	testQueryIs(t, db, "nil|7|0", "SELECT describe(NULL), describe(7), describe('x')")
+	testQueryIs(t, db, "int64|float64|string|[]uint8|<nil>", "SELECT gotype(1), gotype(1.5), gotype('x'), gotype(x'00'), gotype(NULL)")
+
+	//	A variadic function takes any number of arguments after its fixed ones.
+	testQueryIs(t, db, "a-b-c||1,2.5", "SELECT join('-', 'a', 'b', 'c'), join('-'), join(',', 1, 2.5)")
+
+	if _, err := db.Exec("SELECT reverse('a', 'b')"); err == nil || !strings.Contains(err.Error(), "wrong number of arguments to function reverse()") {
+		t.Errorf("too many arguments: %v", err)
+	}
+	if _, err := db.Exec("SELECT join()"); err == nil || !strings.Contains(err.Error(), "wrong number of arguments to function join()") {
+		t.Errorf("too few arguments: %v", err)
+	}
+}
+
+func TestRegisterFuncResults(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	when := time.Date(2024, 2, 29, 13, 14, 15, 0, time.UTC)
+	testRegisterFuncs(t, db, map[string]interface{}{
+		"when":		func() time.Time { return when },
+		"maybe": func(ok bool) *int {
+			if !ok {
+				return nil
+			}
+			n := 42
+			return &n
+		},
+		"bytes":	func(n int) []byte { return make([]byte, n) },
+		"nobytes":	func() []byte { return nil },
+		"any": func(i int) interface{} {
+			return []interface{}{ int32(1), 2.5, "three", []byte("four"), nil, uint8(6) }[i]
+		},
+		"bad":		func() interface{} { return struct{}{} },
+		"div": func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, errors.New("division by zero")
+			}
+			return a / b, nil
+		},
+		"boom":		func() int { panic("kaboom") },
+	})
+
+	testQueryIs(t, db, "2024-02-29 13:14:15+00:00|text", "SELECT when(), typeof(when())")
+	testQueryIs(t, db, "42|NULL", "SELECT maybe(1), maybe(0)")
+	testQueryIs(t, db, "blob|3|NULL", "SELECT typeof(bytes(3)), length(bytes(3)), nobytes()")
+	testQueryIs(t, db, "integer|real|text|blob|null|integer", "SELECT typeof(any(0)), typeof(any(1)), typeof(any(2)), typeof(any(3)), typeof(any(4)), typeof(any(5))")
+	testQueryIs(t, db, "3", "SELECT div(7, 2)")
+
+	//	Errors and panics become the error of the statement.
+	for query, want := range map[string]string{
+		"SELECT div(1, 0)":		"division by zero",
+		"SELECT boom()":		"panic in function boom(): kaboom",
+		"SELECT bad()":			"unsupported result type struct {}",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+	testQueryIs(t, db, "1", "SELECT 1")
+}
+
+func TestRegisterFuncRefused(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testRaw(t, db, func(db *sqlite3) {
+		for name, fn := range map[string]interface{}{
+			"notfunc":		5,
+			"noresult":		func() {},
+			"tworesults":	func() (int, int) { return 0, 0 },
+			"chanresult":	func() chan int { return nil },
+			"mapresult":	func() map[string]int { return nil },
+			"chanarg":		func(c chan int) int { return 0 },
+			"slicearg":		func(a []int) int { return 0 },
+		} {
+			if err := db.RegisterFunc(name, fn); err == nil {
+				t.Errorf("RegisterFunc(%q) succeeded", name)
+			}
+		}
+		if err := db.RegisterAggregate("nil", 1, nil); err == nil {
+			t.Errorf("RegisterAggregate() with no function succeeded")
+		}
+	})
+}
+
+//	An Aggregate that multiplies its arguments.
+type testProduct struct {
+	n		float64
+}
+
+func (p *testProduct) Step(args ...interface{}) error {
+	switch v := args[0].(type) {
+	case int64:
+		p.n *= float64(v)
+	case float64:
+		p.n *= v
+	case nil:
+	default:
+		return fmt.Errorf("product() of %T", v)
+	}
+	return nil
+}
+
+func (p *testProduct) Final() (interface{}, error) {
+	return p.n, nil
+}
+
+//	A WindowAggregate that sums its arguments, and counts the calls of its methods.
+type testSum struct {
+	sum				int64
+	nStep, nInverse	*int
+}
+
+func (p *testSum) Step(args ...interface{}) error {
+	*p.nStep++
+	p.sum += args[0].(int64)
+	return nil
+}
+
+func (p *testSum) Inverse(args ...interface{}) error {
+	*p.nInverse++
+	p.sum -= args[0].(int64)
+	return nil
+}
+
+func (p *testSum) Value() (interface{}, error) {
+	return p.sum, nil
+}
+
+func (p *testSum) Final() (interface{}, error) {
+	return p.sum, nil
+}
+
+func TestRegisterAggregate(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	var nStep, nInverse int
+	testRaw(t, db, func(db *sqlite3) {
+		if err := db.RegisterAggregate("product", 1, func() Aggregate { return &testProduct{ n: 1 } }); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.RegisterAggregate("gosum", 1, func() Aggregate { return &testSum{ nStep: &nStep, nInverse: &nInverse } }); err != nil {
+			t.Fatal(err)
+		}
+	})
+	testExec(t, db, "CREATE TABLE t(g, x)")
+	testExec(t, db, "INSERT INTO t VALUES('a', 1), ('a', 2), ('b', 3), ('b', 4), ('b', NULL), ('c', 0.5)")
+
+	//	A fresh Aggregate for each group, and for an empty result.
+	testQueryIs(t, db, "a|2\nb|12\nc|0.5", "SELECT g, product(x) FROM t GROUP BY g ORDER BY g")
+	testQueryIs(t, db, "1", "SELECT product(x) FROM t WHERE 0")
+	if _, err := db.Exec("SELECT product(g) FROM t"); err == nil || !strings.Contains(err.Error(), "product() of string") {
+		t.Errorf("error in Step: %v", err)
+	}
+
+	//	A WindowAggregate removes the rows that leave a moving frame rather than starting again.
+	testQueryIs(t, db, "1|1\n2|3\n3|5\n4|7", "SELECT x, gosum(x) OVER (ORDER BY x ROWS BETWEEN 1 PRECEDING AND CURRENT ROW) FROM t WHERE g IN ('a', 'b') AND x IS NOT NULL")
+	if nStep != 4 || nInverse != 2 {
+		t.Errorf("Step called %v times and Inverse %v times, want 4 and 2", nStep, nInverse)
+	}
+	testQueryIs(t, db, "10", "SELECT gosum(x) FROM t WHERE x >= 1")
+}
@@ -0,0 +1,372 @@
+import (
+	"fmt"
+	"reflect"
+	"unsafe"
+)
+
+//	This file implements virtual table modules written as Go types rather than as an sqlite3_module of C-style callbacks.
+//
+//		db.CreateModule("people", peopleModule{ rows })
+//		SELECT name FROM people WHERE age > 30
+//
+//	A VTabModule connects to a table by returning the CREATE TABLE statement that declares its columns and a VTab. A VTab plans queries
+//	through a typed IndexInfo and opens VTabCursors that walk its rows. A VTab that also implements VTabUpdater accepts INSERT, UPDATE
+//	and DELETE, and one that implements VTabTransactioner takes part in transactions.
+//
+//	CreateModule() registers one of two static sqlite3_module tables, goVtabModule or goEponymousModule, with the VTabModule as the
+//	client data. Their callbacks find the Go value in the sqlite3_vtab or sqlite3_vtab_cursor they are passed, which is embedded in a
+//	goVtab or goVtabCursor in the same way as json_each embeds its own, and translate between sqlite3_value and Go values with the
+//	converters of userfunc.go. An error returned by a Go method becomes the error message of the virtual table. If it is an *Error its
+//	code is returned, otherwise SQLITE_ERROR.
+//
+//	A module that does not implement VTabCreator cannot be used by CREATE VIRTUAL TABLE. It is eponymous-only: it is used as a table
+//	of the same name in the main schema, like json_each.
+
+//	VTabModule is implemented by a virtual table module registered with CreateModule(). Connect is called the first time a connection
+//	uses a table of the module. args are the arguments of the module: the module name, the database name, the table name and then the
+//	arguments of the CREATE VIRTUAL TABLE statement, if any. Connect returns a CREATE TABLE statement that declares the columns of the
+//	table, in which a column whose type contains HIDDEN is a hidden column, and the VTab that implements it.
+type VTabModule interface {
+	Connect(args []string) (schema string, vtab VTab, err error)
+}
+
+//	VTabCreator is implemented by a VTabModule that supports CREATE VIRTUAL TABLE. Create is called by the statement to create the
+//	table, with the same arguments and results as Connect.
+type VTabCreator interface {
+	Create(args []string) (schema string, vtab VTab, err error)
+}
+
+//	VTab is implemented by a virtual table. BestIndex chooses how a query uses the table, Open opens a cursor on it and Disconnect is
+//	called when the connection no longer uses it. If the VTab has a Destroy() error method it is called instead of Disconnect when
+//	the table is dropped.
+type VTab interface {
+	BestIndex(info *IndexInfo) error
+	Open() (VTabCursor, error)
+	Disconnect() error
+}
+
+//	VTabCursor is implemented by a cursor on a virtual table. Filter starts a scan with the plan chosen by BestIndex: idxNum and idxStr
+//	are the values it set and args are the values of the constraints it asked for, in ArgvIndex order. Next moves to the next row and Eof
+//	is true after the last row. Column returns the value of column i of the current row, which may be of any type that RegisterFunc()
+//	accepts as a result, and Rowid returns its rowid.
+type VTabCursor interface {
+	Filter(idxNum int, idxStr string, args []interface{}) error
+	Next() error
+	Eof() bool
+	Column(i int) (interface{}, error)
+	Rowid() (int64, error)
+	Close() error
+}
+
+//	VTabUpdater is implemented by a VTab that can be modified. values holds the value of every column of the row in table order. The
+//	rowid passed to Insert is nil if the statement did not give one, in which case Insert chooses it. Insert returns the rowid of the
+//	new row. Update passes the old rowid of the row and its new rowid, which differ if the statement changes the rowid.
+type VTabUpdater interface {
+	Insert(rowid interface{}, values []interface{}) (int64, error)
+	Update(oldRowid, newRowid int64, values []interface{}) error
+	Delete(rowid int64) error
+}
+
+//	VTabTransactioner is implemented by a VTab that takes part in transactions. Begin is called before the table is first modified
+//	in a transaction, Sync at the start of the commit of the transaction and then either Commit or Rollback.
+type VTabTransactioner interface {
+	Begin() error
+	Sync() error
+	Commit() error
+	Rollback() error
+}
+
+//	IndexInfo describes a use of a virtual table to VTab.BestIndex and holds the plan that it chooses.
+type IndexInfo struct {
+	Constraints			[]IndexConstraint	//	The WHERE clause terms on the columns of the table
+	OrderBy				[]IndexOrderBy		//	The ORDER BY clause, if every term is a column of the table
+	IdxNum				int					//	Output: the number that identifies the plan to Filter
+	IdxStr				string				//	Output: the string that identifies the plan to Filter
+	OrderByConsumed		bool				//	Output: true if the rows are returned in the order of OrderBy
+	EstimatedCost		float64				//	Output: the cost of the plan. A full scan of N rows should cost about N.
+}
+
+//	IndexConstraint is a WHERE clause term of the form "column OP expr". Op is one of the SQLITE_INDEX_CONSTRAINT_ codes. If Usable is
+//	false the plan cannot use the term, because the value of expr is not known at the start of the scan. BestIndex sets ArgvIndex to
+//	a number greater than zero to have the value of expr passed to Filter at that position of args, counting from 1, and sets Omit if
+//	the cursor only returns rows that satisfy the term, so that it need not be tested again.
+type IndexConstraint struct {
+	Column		int				//	The column of the table, or -1 for the rowid
+	Op			byte
+	Usable		bool
+	ArgvIndex	int				//	Output
+	Omit		bool			//	Output
+}
+
+//	IndexOrderBy is a term of the ORDER BY clause of a query.
+type IndexOrderBy struct {
+	Column		int
+	Desc		bool
+}
+
+//	A goVtab is the sqlite3_vtab of a table of a Go module.
+type goVtab struct {
+	base		sqlite3_vtab
+	vtab		VTab
+	zName		string				//	Name of the table, for error messages
+}
+
+//	A goVtabCursor is the sqlite3_vtab_cursor of a cursor on a table of a Go module.
+type goVtabCursor struct {
+	base		sqlite3_vtab_cursor
+	cursor		VTabCursor
+}
+
+//	CreateModule registers module as the virtual table module name of connection db.
+func (db *sqlite3) CreateModule(name string, module VTabModule) error {
+	if module == nil {
+		return fmt.Errorf("sqlite3: CreateModule needs a module")
+	}
+	pModule := &goEponymousModule
+	if _, ok := module.(VTabCreator); ok {
+		pModule = &goVtabModule
+	}
+	return db.lastError(db.create_module(name, pModule, module))
+}
+
+//	Set the error message of pVtab from err and return the error code for it.
+func (pVtab *sqlite3_vtab) goError(err error) int {
+	pVtab.zErrMsg = err.Error()
+	if e, ok := err.(*Error); ok && e.Code != SQLITE_OK {
+		return e.Code
+	}
+	return SQLITE_ERROR
+}
+
+//	Call xConstruct, the Connect or Create method of a Go module, and declare the schema of the table it returns.
+func goVtabConstruct(db *sqlite3, argc int, argv []string, ppVtab **sqlite3_vtab, pzErr *string, xConstruct func([]string) (string, VTab, error)) (rc int) {
+	zSchema, vtab, err := xConstruct(append([]string{}, argv[:argc]...))
+	if err != nil {
+		*pzErr = err.Error()
+		return SQLITE_ERROR
+	}
+	if rc = db.DeclareVTab(zSchema); rc != SQLITE_OK {
+		*pzErr = sqlite3_errmsg(db)
+		vtab.Disconnect()
+		return
+	}
+	p := &goVtab{ vtab: vtab, zName: argv[2] }
+	*ppVtab = &p.base
+	return SQLITE_OK
+}
+
+func goVtabCreate(db *sqlite3, pAux interface{}, argc int, argv []string, ppVtab **sqlite3_vtab, pzErr *string) int {
+	return goVtabConstruct(db, argc, argv, ppVtab, pzErr, pAux.(VTabCreator).Create)
+}
+
+func goVtabConnect(db *sqlite3, pAux interface{}, argc int, argv []string, ppVtab **sqlite3_vtab, pzErr *string) int {
+	return goVtabConstruct(db, argc, argv, ppVtab, pzErr, pAux.(VTabModule).Connect)
+}
+
+func goVtabOf(pVtab *sqlite3_vtab) *goVtab {
+	return (*goVtab)(unsafe.Pointer(pVtab))
+}
+
+func goVtabCursorOf(cur *sqlite3_vtab_cursor) *goVtabCursor {
+	return (*goVtabCursor)(unsafe.Pointer(cur))
+}
+
+//	Copy the inputs of pIdxInfo into an IndexInfo, pass it to BestIndex and copy its outputs back.
+func goVtabBestIndex(pVtab *sqlite3_vtab, pIdxInfo *sqlite3_index_info) int {
+	info := &IndexInfo{ EstimatedCost: pIdxInfo.estimatedCost }
+	for i := 0; i < pIdxInfo.nConstraint; i++ {
+		pCons := &pIdxInfo.aConstraint[i]
+		info.Constraints = append(info.Constraints, IndexConstraint{ Column: pCons.iColumn, Op: pCons.op, Usable: pCons.usable != 0 })
+	}
+	for i := 0; i < pIdxInfo.nOrderBy; i++ {
+		pOrderBy := &pIdxInfo.aOrderBy[i]
+		info.OrderBy = append(info.OrderBy, IndexOrderBy{ Column: pOrderBy.iColumn, Desc: pOrderBy.desc != 0 })
+	}
+	if err := goVtabOf(pVtab).vtab.BestIndex(info); err != nil {
+		return pVtab.goError(err)
+	}
+	for i, cons := range info.Constraints {
+		if cons.ArgvIndex > 0 && !cons.Usable {
+			pVtab.zErrMsg = "BestIndex used an unusable constraint"
+			return SQLITE_ERROR
+		}
+		pIdxInfo.aConstraintUsage[i].argvIndex = cons.ArgvIndex
+		if cons.Omit {
+			pIdxInfo.aConstraintUsage[i].omit = 1
+		}
+	}
+	pIdxInfo.idxNum = info.IdxNum
+	pIdxInfo.idxStr = info.IdxStr
+	if info.OrderByConsumed {
+		pIdxInfo.orderByConsumed = 1
+	}
+	pIdxInfo.estimatedCost = info.EstimatedCost
+	return SQLITE_OK
+}
+
+func goVtabDisconnect(pVtab *sqlite3_vtab) int {
+	if err := goVtabOf(pVtab).vtab.Disconnect(); err != nil {
+		return pVtab.goError(err)
+	}
+	return SQLITE_OK
+}
+
+func goVtabDestroy(pVtab *sqlite3_vtab) int {
+	if p, ok := goVtabOf(pVtab).vtab.(interface{ Destroy() error }); ok {
+		if err := p.Destroy(); err != nil {
+			return pVtab.goError(err)
+		}
+		return SQLITE_OK
+	}
+	return goVtabDisconnect(pVtab)
+}
+
+func goVtabOpen(pVtab *sqlite3_vtab, ppCursor **sqlite3_vtab_cursor) int {
+	cursor, err := goVtabOf(pVtab).vtab.Open()
+	if err != nil {
+		return pVtab.goError(err)
+	}
+	pCur := &goVtabCursor{ cursor: cursor }
+	*ppCursor = &pCur.base
+	return SQLITE_OK
+}
+
+func goVtabClose(cur *sqlite3_vtab_cursor) int {
+	if err := goVtabCursorOf(cur).cursor.Close(); err != nil {
+		return cur.pVtab.goError(err)
+	}
+	return SQLITE_OK
+}
+
+func goVtabFilter(cur *sqlite3_vtab_cursor, idxNum int, idxStr string, argc int, argv []*sqlite3_value) int {
+	if err := goVtabCursorOf(cur).cursor.Filter(idxNum, idxStr, goValues(argv[:argc])); err != nil {
+		return cur.pVtab.goError(err)
+	}
+	return SQLITE_OK
+}
+
+func goVtabNext(cur *sqlite3_vtab_cursor) int {
+	if err := goVtabCursorOf(cur).cursor.Next(); err != nil {
+		return cur.pVtab.goError(err)
+	}
+	return SQLITE_OK
+}
+
+func goVtabEof(cur *sqlite3_vtab_cursor) int {
+	if goVtabCursorOf(cur).cursor.Eof() {
+		return 1
+	}
+	return 0
+}
+
+func goVtabColumn(cur *sqlite3_vtab_cursor, ctx *sqlite3_context, i int) int {
+	v, err := goVtabCursorOf(cur).cursor.Column(i)
+	if err != nil {
+		return cur.pVtab.goError(err)
+	}
+	goResult(ctx, reflect.ValueOf(v))
+	return SQLITE_OK
+}
+
+func goVtabRowid(cur *sqlite3_vtab_cursor, pRowid *int64) int {
+	rowid, err := goVtabCursorOf(cur).cursor.Rowid()
+	if err != nil {
+		return cur.pVtab.goError(err)
+	}
+	*pRowid = rowid
+	return SQLITE_OK
+}
+
+//	argv[0] is the rowid of the row to delete or update, or NULL for an INSERT. For an INSERT or UPDATE argv[1] is the new rowid, which
+//	may be NULL for an INSERT, and the values of the columns follow. A DELETE has argv[0] only.
+func goVtabUpdate(pVtab *sqlite3_vtab, argc int, argv []*sqlite3_value, pRowid *int64) int {
+	p := goVtabOf(pVtab)
+	updater, ok := p.vtab.(VTabUpdater)
+	if !ok {
+		pVtab.zErrMsg = fmt.Sprintf("table %v may not be modified", p.zName)
+		return SQLITE_READONLY
+	}
+	var err error
+	switch {
+	case argc == 1:
+		err = updater.Delete(sqlite3_value_int64(argv[0]))
+	case sqlite3_value_type(argv[0]) == SQLITE_NULL:
+		var rowid interface{}
+		if sqlite3_value_type(argv[1]) != SQLITE_NULL {
+			rowid = sqlite3_value_int64(argv[1])
+		}
+		*pRowid, err = updater.Insert(rowid, goValues(argv[2:argc]))
+	default:
+		err = updater.Update(sqlite3_value_int64(argv[0]), sqlite3_value_int64(argv[1]), goValues(argv[2:argc]))
+	}
+	if err != nil {
+		return pVtab.goError(err)
+	}
+	return SQLITE_OK
+}
+
+//	Call one of the methods of a VTabTransactioner, if the table implements it.
+func goVtabTransaction(pVtab *sqlite3_vtab, xMethod func(VTabTransactioner) error) int {
+	if p, ok := goVtabOf(pVtab).vtab.(VTabTransactioner); ok {
+		if err := xMethod(p); err != nil {
+			return pVtab.goError(err)
+		}
+	}
+	return SQLITE_OK
+}
+
+func goVtabBegin(pVtab *sqlite3_vtab) int {
+	return goVtabTransaction(pVtab, VTabTransactioner.Begin)
+}
+
+func goVtabSync(pVtab *sqlite3_vtab) int {
+	return goVtabTransaction(pVtab, VTabTransactioner.Sync)
+}
+
+func goVtabCommit(pVtab *sqlite3_vtab) int {
+	return goVtabTransaction(pVtab, VTabTransactioner.Commit)
+}
+
+func goVtabRollback(pVtab *sqlite3_vtab) int {
+	return goVtabTransaction(pVtab, VTabTransactioner.Rollback)
+}
+
+//	The module of a VTabModule that implements VTabCreator.
+var goVtabModule = sqlite3_module{
+	xCreate:		goVtabCreate,
+	xConnect:		goVtabConnect,
+	xBestIndex:		goVtabBestIndex,
+	xDisconnect:	goVtabDisconnect,
+	xDestroy:		goVtabDestroy,
+	xOpen:			goVtabOpen,
+	xClose:			goVtabClose,
+	xFilter:		goVtabFilter,
+	xNext:			goVtabNext,
+	xEof:			goVtabEof,
+	xColumn:		goVtabColumn,
+	xRowid:			goVtabRowid,
+	xUpdate:		goVtabUpdate,
+	xBegin:			goVtabBegin,
+	xSync:			goVtabSync,
+	xCommit:		goVtabCommit,
+	xRollback:		goVtabRollback,
+}
+
+//	The module of a VTabModule that does not implement VTabCreator. Having no xCreate method makes it eponymous-only.
+var goEponymousModule = sqlite3_module{
+	xConnect:		goVtabConnect,
+	xBestIndex:		goVtabBestIndex,
+	xDisconnect:	goVtabDisconnect,
+	xOpen:			goVtabOpen,
+	xClose:			goVtabClose,
+	xFilter:		goVtabFilter,
+	xNext:			goVtabNext,
+	xEof:			goVtabEof,
+	xColumn:		goVtabColumn,
+	xRowid:			goVtabRowid,
+	xUpdate:		goVtabUpdate,
+	xBegin:			goVtabBegin,
+	xSync:			goVtabSync,
+	xCommit:		goVtabCommit,
+	xRollback:		goVtabRollback,
+}
@@ -0,0 +1,362 @@
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+)
+
+//	A row of a testKV table.
+type testKVRow struct {
+	rowid		int64
+	k, v		interface{}
+}
+
+//	A VTabModule whose tables are testKV tables. The last table made is kept so that a test can look at it.
+type testKVModule struct {
+	pTab		*testKV
+}
+
+func (m *testKVModule) Create(args []string) (string, VTab, error) {
+	if len(args) > 3 {
+		return "", nil, fmt.Errorf("kv takes no arguments, not %v", args[3:])
+	}
+	m.pTab = &testKV{}
+	return "CREATE TABLE x(k, v)", m.pTab, nil
+}
+
+func (m *testKVModule) Connect(args []string) (string, VTab, error) {
+	if m.pTab == nil {
+		return m.Create(args)
+	}
+	return "CREATE TABLE x(k, v)", m.pTab, nil
+}
+
+//	A testKV table holds its rows in memory in rowid order, with a unique column k, and records the plans and transactions it sees.
+type testKV struct {
+	aRow		[]testKVRow
+	aSaved		[]testKVRow			//	The rows at the start of the transaction
+	info		IndexInfo			//	The last IndexInfo passed to BestIndex
+	aFilter		[]string			//	The arguments of each Filter
+	aTxn		[]string			//	The transaction methods called
+}
+
+//	An equality constraint on k is used with idxNum 1. ORDER BY rowid is the order of the rows.
+func (p *testKV) BestIndex(info *IndexInfo) error {
+	info.EstimatedCost = 1000
+	for i, cons := range info.Constraints {
+		if cons.Column == 0 && cons.Op == SQLITE_INDEX_CONSTRAINT_EQ && cons.Usable {
+			info.Constraints[i].ArgvIndex = 1
+			info.Constraints[i].Omit = true
+			info.IdxNum, info.IdxStr, info.EstimatedCost = 1, "k=?", 1
+			break
+		}
+	}
+	if len(info.OrderBy) == 1 && info.OrderBy[0].Column == -1 && !info.OrderBy[0].Desc {
+		info.OrderByConsumed = true
+	}
+	p.info = *info
+	return nil
+}
+
+func (p *testKV) Open() (VTabCursor, error) {
+	return &testKVCursor{ pTab: p }, nil
+}
+
+func (p *testKV) Disconnect() error {
+	return nil
+}
+
+func (p *testKV) find(rowid int64) int {
+	for i := range p.aRow {
+		if p.aRow[i].rowid == rowid {
+			return i
+		}
+	}
+	return -1
+}
+
+//	Store row, replacing the row at index i if i is not -1, unless its key is already used by another row.
+func (p *testKV) store(i int, row testKVRow) error {
+	if s, ok := row.v.(string); ok && s == "bad" {
+		return errors.New("kv: bad value")
+	}
+	for j := range p.aRow {
+		if j != i && p.aRow[j].k == row.k {
+			return &Error{ Code: SQLITE_CONSTRAINT, Message: fmt.Sprintf("kv: duplicate key %v", row.k) }
+		}
+	}
+	if i < 0 {
+		p.aRow = append(p.aRow, row)
+	} else {
+		p.aRow[i] = row
+	}
+	sort.Slice(p.aRow, func(i, j int) bool { return p.aRow[i].rowid < p.aRow[j].rowid })
+	return nil
+}
+
+func (p *testKV) Insert(rowid interface{}, values []interface{}) (int64, error) {
+	row := testKVRow{ k: values[0], v: values[1] }
+	if rowid != nil {
+		row.rowid = rowid.(int64)
+	} else if n := len(p.aRow); n > 0 {
+		row.rowid = p.aRow[n - 1].rowid + 1
+	} else {
+		row.rowid = 1
+	}
+	return row.rowid, p.store(-1, row)
+}
+
+func (p *testKV) Update(oldRowid, newRowid int64, values []interface{}) error {
+	return p.store(p.find(oldRowid), testKVRow{ newRowid, values[0], values[1] })
+}
+
+func (p *testKV) Delete(rowid int64) error {
+	i := p.find(rowid)
+	p.aRow = append(p.aRow[:i], p.aRow[i + 1:]...)
+	return nil
+}
+
+func (p *testKV) Begin() error {
+	p.aTxn = append(p.aTxn, "begin")
+	p.aSaved = append([]testKVRow{}, p.aRow...)
+	return nil
+}
+
+func (p *testKV) Sync() error {
+	p.aTxn = append(p.aTxn, "sync")
+	return nil
+}
+
+func (p *testKV) Commit() error {
+	p.aTxn = append(p.aTxn, "commit")
+	return nil
+}
+
+func (p *testKV) Rollback() error {
+	p.aTxn = append(p.aTxn, "rollback")
+	p.aRow = p.aSaved
+	return nil
+}
+
+type testKVCursor struct {
+	pTab		*testKV
+	aRow		[]testKVRow
+	i			int
+}
+
+func (c *testKVCursor) Filter(idxNum int, idxStr string, args []interface{}) error {
+	c.pTab.aFilter = append(c.pTab.aFilter, fmt.Sprintf("%v %v %v", idxNum, idxStr, args))
+	c.aRow, c.i = nil, 0
+	for _, row := range c.pTab.aRow {
+		if idxNum == 0 || row.k == args[0] {
+			c.aRow = append(c.aRow, row)
+		}
+	}
+	return nil
+}
+
+func (c *testKVCursor) Next() error {
+	c.i++
+	return nil
+}
+
+func (c *testKVCursor) Eof() bool {
+	return c.i >= len(c.aRow)
+}
+
+func (c *testKVCursor) Column(i int) (interface{}, error) {
+	if i == 0 {
+		return c.aRow[c.i].k, nil
+	}
+	return c.aRow[c.i].v, nil
+}
+
+func (c *testKVCursor) Rowid() (int64, error) {
+	return c.aRow[c.i].rowid, nil
+}
+
+func (c *testKVCursor) Close() error {
+	return nil
+}
+
+//	An eponymous-only module of a read-only table of the numbers 1 to 3.
+type testNumsModule struct{}
+
+type testNums struct{}
+
+type testNumsCursor struct {
+	n			int64
+}
+
+func (testNumsModule) Connect(args []string) (string, VTab, error) {
+	return "CREATE TABLE x(value)", testNums{}, nil
+}
+
+func (testNums) BestIndex(info *IndexInfo) error {
+	info.EstimatedCost = 3
+	return nil
+}
+
+func (testNums) Open() (VTabCursor, error) {
+	return &testNumsCursor{}, nil
+}
+
+func (testNums) Disconnect() error {
+	return nil
+}
+
+func (c *testNumsCursor) Filter(idxNum int, idxStr string, args []interface{}) error {
+	c.n = 1
+	return nil
+}
+
+func (c *testNumsCursor) Next() error {
+	c.n++
+	return nil
+}
+
+func (c *testNumsCursor) Eof() bool {
+	return c.n > 3
+}
+
+func (c *testNumsCursor) Column(i int) (interface{}, error) {
+	if c.n == 2 {
+		return nil, nil
+	}
+	return c.n, nil
+}
+
+func (c *testNumsCursor) Rowid() (int64, error) {
+	return c.n, nil
+}
+
+func (c *testNumsCursor) Close() error {
+	return nil
+}
+
+func TestVTabModule(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	m := &testKVModule{}
+	testRaw(t, db, func(db *sqlite3) {
+		if err := db.CreateModule("kv", m); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.CreateModule("nums", testNumsModule{}); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.CreateModule("none", nil); err == nil {
+			t.Error("CreateModule() with no module succeeded")
+		}
+	})
+	testExec(t, db, "CREATE VIRTUAL TABLE t USING kv")
+	pTab := m.pTab
+	testExec(t, db, "INSERT INTO t(k, v) VALUES('b', 2), ('a', 1)")
+	testExec(t, db, "INSERT INTO t(rowid, k, v) VALUES(10, 'c', 3.5)")
+	testQueryIs(t, db, "1|b|2\n2|a|1\n10|c|3.5", "SELECT rowid, k, v FROM t")
+
+	//	BestIndex sees the WHERE terms on the columns, and Filter the values of those it asked for.
+	testQueryIs(t, db, "2", "SELECT v FROM t WHERE k = 'b' AND v > 1")
+	for _, want := range []IndexConstraint{
+		{ Column: 0, Op: SQLITE_INDEX_CONSTRAINT_EQ, Usable: true, ArgvIndex: 1, Omit: true },
+		{ Column: 1, Op: SQLITE_INDEX_CONSTRAINT_GT, Usable: true },
+	} {
+		found := false
+		for _, cons := range pTab.info.Constraints {
+			found = found || cons == want
+		}
+		if !found {
+			t.Errorf("constraints %+v, want %+v among them", pTab.info.Constraints, want)
+		}
+	}
+	if n := len(pTab.aFilter); n == 0 || pTab.aFilter[n - 1] != "1 k=? [b]" {
+		t.Errorf("Filter() called with %q", pTab.aFilter)
+	}
+	if plan := testQueryPlan(t, db, "SELECT v FROM t WHERE k = ?", "a"); !strings.Contains(plan, "VIRTUAL TABLE INDEX 1:k=?") {
+		t.Errorf("plan %q, want index 1", plan)
+	}
+	if plan := testQueryPlan(t, db, "SELECT v FROM t WHERE v = 1"); !strings.Contains(plan, "VIRTUAL TABLE INDEX 0:") {
+		t.Errorf("plan %q, want index 0", plan)
+	}
+
+	//	ORDER BY terms, which need no sorting if BestIndex consumes them.
+	testQueryIs(t, db, "b\na\nc", "SELECT k FROM t ORDER BY rowid")
+	if plan := testQueryPlan(t, db, "SELECT k FROM t ORDER BY rowid"); strings.Contains(plan, "TEMP B-TREE") {
+		t.Errorf("plan %q sorts", plan)
+	}
+	testQueryIs(t, db, "c\nb\na", "SELECT k FROM t ORDER BY k DESC")
+	if len(pTab.info.OrderBy) != 1 || pTab.info.OrderBy[0] != (IndexOrderBy{ Column: 0, Desc: true }) || pTab.info.OrderByConsumed {
+		t.Errorf("ORDER BY k DESC seen as %+v", pTab.info.OrderBy)
+	}
+
+	//	INSERT, UPDATE, including of the rowid, and DELETE through the VTabUpdater.
+	testExec(t, db, "UPDATE t SET v = v * 10 WHERE k = 'a'")
+	testExec(t, db, "UPDATE t SET rowid = 5 WHERE k = 'c'")
+	testExec(t, db, "DELETE FROM t WHERE k = 'b'")
+	testQueryIs(t, db, "2|a|10\n5|c|3.5", "SELECT rowid, k, v FROM t")
+
+	//	An error returned by the table is the error of the statement, with its code if it is an *Error.
+	if _, err := db.Exec("INSERT INTO t(k, v) VALUES('a', 0)"); testCode(err) & 0xff != SQLITE_CONSTRAINT || !strings.Contains(err.Error(), "kv: duplicate key a") {
+		t.Errorf("duplicate key: %v", err)
+	}
+	for query, want := range map[string]string{
+		"UPDATE t SET v = 'bad'":					"kv: bad value",
+		"CREATE VIRTUAL TABLE t2 USING kv(x)":		"kv takes no arguments, not [x]",
+		"INSERT INTO nums VALUES(4)":				"table nums may not be modified",
+		"CREATE VIRTUAL TABLE n USING nums":		"no such module: nums",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+	testQueryIs(t, db, "2|a|10\n5|c|3.5", "SELECT rowid, k, v FROM t")
+
+	//	The eponymous table, and a WHERE term that its BestIndex leaves to SQLite.
+	testQueryIs(t, db, "1\nNULL\n3", "SELECT value FROM nums")
+	testQueryIs(t, db, "3", "SELECT value FROM nums WHERE value > 1")
+}
+
+func TestVTabTransaction(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	m := &testKVModule{}
+	testRaw(t, db, func(db *sqlite3) {
+		if err := db.CreateModule("kv", m); err != nil {
+			t.Fatal(err)
+		}
+	})
+	testExec(t, db, "CREATE VIRTUAL TABLE t USING kv")
+	pTab := m.pTab
+	for _, test := range []struct {
+		aQuery		[]string
+		want		string				//	Transaction methods called
+		rows		string				//	The rows of t afterwards
+	}{
+		//	A statement in autocommit mode is a transaction of its own. Reading the table starts none.
+		{ []string{ "INSERT INTO t(k, v) VALUES('a', 1)" }, "begin sync commit", "a|1" },
+		{ []string{ "SELECT * FROM t" }, "", "a|1" },
+
+		//	The table joins a transaction once, when it is first modified.
+		{ []string{ "BEGIN", "SELECT * FROM t", "INSERT INTO t(k, v) VALUES('b', 2)", "UPDATE t SET v = 3", "COMMIT" }, "begin sync commit", "a|3\nb|3" },
+		{ []string{ "BEGIN", "DELETE FROM t WHERE k = 'a'", "INSERT INTO t(k, v) VALUES('c', 4)", "ROLLBACK" }, "begin rollback", "a|3\nb|3" },
+		{ []string{ "BEGIN", "COMMIT" }, "", "a|3\nb|3" },
+	} {
+		pTab.aTxn = nil
+		for _, query := range test.aQuery {
+			testExec(t, db, query)
+		}
+		if got := strings.Join(pTab.aTxn, " "); got != test.want {
+			t.Errorf("%q: %q, want %q", test.aQuery, got, test.want)
+		}
+		testQueryIs(t, db, test.rows, "SELECT k, v FROM t")
+	}
+
+	//	A statement that fails in autocommit mode rolls the table back.
+	pTab.aTxn = nil
+	if _, err := db.Exec("UPDATE t SET v = 'bad' WHERE k = 'b'"); err == nil {
+		t.Error("UPDATE succeeded")
+	}
+	if got := strings.Join(pTab.aTxn, " "); got != "begin rollback" {
+		t.Errorf("failed statement: %q, want %q", got, "begin rollback")
+	}
+	testQueryIs(t, db, "a|3\nb|3", "SELECT k, v FROM t")
+}
@@ -110,10 +110,9 @@ int sqlite3RunVacuum(char **pzErrMsg, sqlite3 *db){
 
   //	A VACUUM cannot change the pagesize of an encrypted database.
   if db.nextPagesize != 0 {
-    extern void sqlite3CodecGetKey(sqlite3*, int, void**, int*);
     int nKey;
-    char *zKey;
-    sqlite3CodecGetKey(db, 0, (void**)&zKey, &nKey);
+    var zKey string
+    sqlite3CodecGetKey(db, 0, &zKey, &nKey);
     if nKey {
 		db.nextPagesize = 0
 	}
@@ -1342,6 +1342,7 @@ case OP_Function:
 	//	The output cell may already have a buffer allocated. Move the pointer to u.ah.ctx.s so in case the user-function can use the already allocated buffer instead of allocating a new one.
 	sqlite3VdbeMemMove(&u.ah.ctx.s, pOut)
 	u.ah.ctx.s.Value = nil
+	u.ah.ctx.s.eSubtype = 0
 
 	u.ah.ctx.isError = false
 	if u.ah.ctx.pFunc.flags & SQLITE_FUNC_NEEDCOLL {
@@ -2276,13 +2277,16 @@ case OP_Savepoint: {
 	break
 }
 
-/* Opcode: AutoCommit P1 P2 * * *
+/* Opcode: AutoCommit P1 P2 P3 * *
 **
 ** Set the database auto-commit flag to P1 (1 or 0). If P2 is true, roll
 ** back any currently active btree transactions. If there are any active
 ** VMs (apart from this one), then a ROLLBACK fails.  A COMMIT fails if
 ** there are active writing VMs or active VMs that use shared cache.
 **
+** If P3 is true when P1 is 0, the transaction is a BEGIN CONCURRENT
+** transaction (see concurrent.go).
+**
 ** This instruction causes the VM to halt.
 */
 case OP_AutoCommit: {
@@ -2308,6 +2312,9 @@ case OP_AutoCommit: {
       goto vdbe_return;
     }else{
       db.autoCommit = (byte)u.as.desiredAutoCommit;
+      if !u.as.desiredAutoCommit {
+        db.isConcurrent = pOp.p3 != 0
+      }
       if p.Halt() == SQLITE_BUSY {
         p.pc = pc;
         db.autoCommit = (byte)(1-u.as.desiredAutoCommit);
@@ -2360,12 +2367,19 @@ case OP_AutoCommit: {
 ** will automatically commit when the VDBE halts.
 **
 ** If P2 is zero, then a read-lock is obtained on the database file.
+**
+** If P2 is non-zero and PRAGMA query_only is on, SQLITE_READONLY is
+** returned instead.
 */
 case OP_Transaction: {
   assert( pOp.p1 >= 0 && pOp.p1 < len(db.Databases) )
   assert( (p.btreeMask & (((yDbMask)1)<<pOp.p1))!=0 );
   u.at.pBt = db.Databases[pOp.p1].pBt;
 
+  if( pOp.p2 && (db.flags & SQLITE_QueryOnly)!=0 ){
+    rc = SQLITE_READONLY;
+    goto abort_due_to_error;
+  }
   if( u.at.pBt ){
     rc = u.at.pBt.BeginTransaction(pOp.p2)
     if( rc==SQLITE_BUSY ){
@@ -3572,6 +3586,10 @@ case OP_Next:							//	jump
 ** P3 is a flag that provides a hint to the b-tree layer that this
 ** insert is likely to be an append.
 **
+** If the OPFLAG_NCHANGE flag of P5 is set, then the row change count is
+** incremented (otherwise not).  This is how the rows of a WITHOUT ROWID
+** table, which are stored as index entries, are counted.
+**
 ** This instruction only works for indices.  The equivalent instruction
 ** for tables is OP_Insert.
 */
@@ -3599,6 +3617,9 @@ case OP_IdxInsert: {        /* in2 */
             );
         assert( u.br.pC.deferredMoveto==0 );
         u.br.pC.cacheStatus = CACHE_STALE;
+        if( rc==SQLITE_OK && (pOp.p5 & OPFLAG_NCHANGE)!=0 ){
+          p.nChange++;
+        }
       }
     }
   }
@@ -4711,6 +4732,30 @@ case OP_Trace:
 #endif
 
 
+//	Opcode: Window P1 P2 P3 P4 *
+//	Compute window function P4 for the next row of the partition held by the ephemeral table on cursor P1, whose rows are numbered
+//	from 1 in the order of the window, and store the rowid of its output row in register P3+1 and the value in register P3+2. Register
+//	P3 holds the state of the window between calls and must be NULL before the first. Once every row has been returned, set register
+//	P3 to NULL and jump to P2.
+case OP_Window:				//	jump
+	pIn1 = &aMem[pOp.p3]
+	pState, ok := pIn1.Value.(*windowState)
+	if !ok {
+		pState = newWindowState(db, pOp.p4.Window, p.apCsr[pOp.p1])
+		pIn1.Value = pState
+	}
+	done, rcWin, zErr := pState.Next(&aMem[pOp.p3 + 1], &aMem[pOp.p3 + 2])
+	if rcWin != SQLITE_OK {
+		p.zErrMsg = zErr
+		rc = rcWin
+	}
+	if done {
+		pIn1.Value = nil
+		pIn1.SetNull()
+		pc = pOp.p2 - 1
+	}
+
+
 //	Opcode: Noop * * * * *
 //	Do nothing. This instruction is often useful as a jump destination.
 //	The magic Explain opcode are only inserted when explain == 2 (which is to say when the EXPLAIN QUERY PLAN syntax is used.) This opcode records information from the optimizer. It is the the same as a no-op. This opcode never appears in a real VM program.
@@ -4791,6 +4836,11 @@ abort_due_to_interrupt:
   assert( db.u1.isInterrupted );
   rc = SQLITE_INTERRUPT;
   p.rc = rc;
-  p.zErrMsg = fmt.Sprintf("%v", sqlite3ErrStr(rc));
+  if db.ctx != nil && db.ctx.Err() != nil {
+    //	The interrupt was raised for the context of StepContext(). Report why the context ended.
+    p.zErrMsg = fmt.Sprintf("%v: %v", sqlite3ErrStr(rc), db.ctx.Err())
+  } else {
+    p.zErrMsg = fmt.Sprintf("%v", sqlite3ErrStr(rc));
+  }
   goto vdbe_error_halt;
 }
@@ -44,6 +44,7 @@ func int sqlite3_finalize(sqlite3_stmt *pStmt){
     sqlite3 *db = v.db;
     sqlite3_mutex *mutex;
     if( vdbeSafety(v) ) return SQLITE_MISUSE_BKPT;
+    v.unwatchContext()
     v.db.mutex.CriticalSection(func() {
 		rc = v.Finalize()
 		rc = db.ApiExit(rc)
@@ -66,6 +67,7 @@ func int sqlite3_reset(sqlite3_stmt *pStmt){
 		rc = SQLITE_OK;
 	}else{
 		Vdbe *v = (Vdbe*)pStmt;
+		v.unwatchContext()
 		v.db.mutex.CriticalSection(func() {
 			rc = v.Reset()
 			v.Rewind()
@@ -181,6 +183,17 @@ func void sqlite3_result_text(
 func void sqlite3_result_value(sqlite3_context *pCtx, sqlite3_value *pValue){
   sqlite3VdbeMemCopy(&pCtx.s, pValue);
 }
+
+//	Set the subtype of the result of an application-defined function. Only the low 8 bits of eSubtype are kept. A subtype lets one
+//	function tell another that its result is to be interpreted specially: the JSON functions use it to mark text that is JSON.
+func sqlite3_result_subtype(pCtx *sqlite3_context, eSubtype uint) {
+	pCtx.s.eSubtype = byte(eSubtype)
+}
+
+//	Return the subtype of a function argument, or 0 if it has none.
+func sqlite3_value_subtype(pVal *sqlite3_value) uint {
+	return uint(pVal.eSubtype)
+}
 func (pCtx *sqlite3_context) result_zeroblob(n Zeroes) {
 	pCtx.s.SetZeroBlob(n)
 }
@@ -277,7 +290,7 @@ static int sqlite3Step(Vdbe *p){
     ** reset the interrupt flag.  This prevents a call to sqlite3_interrupt
     ** from interrupting a statement that has not yet started.
     */
-    if( db.activeVdbeCnt==0 ){
+    if( db.activeVdbeCnt==0 && (db.ctx == nil || db.ctx.Err() == nil) ){
       db.u1.isInterrupted = 0;
     }
 
@@ -382,6 +395,66 @@ func int sqlite3_step(sqlite3_stmt *pStmt){
   return rc;
 }
 
+//	StepContext is sqlite3_step() with cancellation. If ctx is cancelled or its deadline passes while the statement is running, the VDBE
+//	stops at its next interrupt check and SQLITE_INTERRUPT is returned. A context that is already done when StepContext is called stops the
+//	statement before any opcode is executed. The context is watched from the first call until the statement is reset or finalized or is
+//	stepped with another context, and ending it only interrupts the connection while this statement is the one being stepped.
+func StepContext(ctx context.Context, pStmt *sqlite3_stmt) (rc int) {
+	v := (Vdbe *)(pStmt)
+	if vdbeSafetyNotNull(v) {
+		return SQLITE_MISUSE_BKPT
+	}
+	db := v.db
+	v.watchContext(ctx)
+	db.stepMutex.Lock()
+	db.pStepping, db.ctx = v, v.ctx
+	if ctx != nil && ctx.Err() != nil {
+		sqlite3_interrupt(db)
+	}
+	db.stepMutex.Unlock()
+
+	rc = sqlite3_step(pStmt)
+
+	db.stepMutex.Lock()
+	//	A cancellation that came after the statement last checked the flag must not interrupt the next statement stepped.
+	if db.ctx != nil && db.ctx.Err() != nil {
+		db.u1.isInterrupted = 0
+	}
+	db.pStepping, db.ctx = nil, nil
+	db.stepMutex.Unlock()
+	return
+}
+
+//	Watch ctx for StepContext(), replacing the context watched before. Nothing is done if ctx is already watched, so stepping a
+//	statement row by row with the same context costs nothing after the first row.
+func (v *Vdbe) watchContext(ctx context.Context) {
+	if ctx == v.ctx {
+		return
+	}
+	v.unwatchContext()
+	if ctx == nil || ctx.Done() == nil {
+		return
+	}
+	db := v.db
+	v.ctx = ctx
+	v.stopWatch = context.AfterFunc(ctx, func() {
+		db.stepMutex.Lock()
+		defer db.stepMutex.Unlock()
+		if db.pStepping == v {
+			sqlite3_interrupt(db)
+		}
+	})
+}
+
+//	Remove the watch on the context of the last StepContext(), if any.
+func (v *Vdbe) unwatchContext() {
+	if v.stopWatch != nil {
+		v.stopWatch()
+		v.stopWatch = nil
+	}
+	v.ctx = nil
+}
+
 /*
 ** Extract the user data from a sqlite3_context structure and return a
 ** pointer to it.
@@ -448,6 +521,24 @@ func void *sqlite3_aggregate_context(sqlite3_context *p, int nByte){
   return (void*)pMem.z;
 }
 
+//	Return the Go value holding the state of the aggregate function for the current group. The first time it is called for a group
+//	with a non-nil fresh, fresh() creates the state. If fresh is nil and there is no state, nil is returned: this is how an xFinal
+//	method learns that xStep was never called for the group.
+func (p *sqlite3_context) AggregateState(fresh func() interface{}) interface{} {
+	assert( p != nil && p.pFunc != nil && p.pFunc.xStep != nil )
+	pMem := p.pMem
+	if pMem.flags & MEM_Agg == 0 {
+		if fresh == nil {
+			return nil
+		}
+		pMem.Release()
+		pMem.flags = MEM_Agg
+		pMem.u.pDef = p.pFunc
+		pMem.Value = fresh()
+	}
+	return pMem.Value
+}
+
 /*
 ** Return the auxilary data pointer, if any, for the iArg'th argument to
 ** the user-function defined by pCtx.
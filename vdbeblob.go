@@ -1,3 +1,8 @@
+import (
+	"errors"
+	"io"
+)
+
 /* This file contains code used to implement incremental BLOB I/O.
 */
 
@@ -14,6 +19,7 @@ struct Incrblob {
   btree.Cursor *pCsr;         /* Cursor pointing at blob row */
   sqlite3_stmt *pStmt;    /* Statement holding cursor open */
   sqlite3 *db;            /* The associated database */
+	iPos		int64			//	Offset of the next Read() or Write() within the blob
 };
 
 
@@ -110,6 +116,10 @@ func int sqlite3_blob_open(
       pTab = 0;
       pParse.SetErrorMsg("cannot open view: %v", zTable);
     }
+    if( pTab && !pTab.HasRowid() ){
+      pTab = 0;
+      pParse.SetErrorMsg("cannot open table without rowid: %v", zTable);
+    }
     if( !pTab ){
       if( pParse.zErrMsg ){
         zErr = pParse.zErrMsg;
@@ -323,6 +333,7 @@ func int sqlite3_blob_reopen(sqlite3_blob *pBlob, int64 iRow) {
 			//	If there is no statement handle, then the blob-handle has already been invalidated. Return SQLITE_ABORT in this case.
 			rc = SQLITE_ABORT
 		} else {
+			p.iPos = 0
 			if rc, zErr = p.SeekToRow(iRow); rc!=SQLITE_OK {
 				db.Error(rc, (zErr ? "%s" : 0), zErr)
 				zErr = nil
@@ -334,3 +345,115 @@ func int sqlite3_blob_reopen(sqlite3_blob *pBlob, int64 iRow) {
 	})
 	return rc;
 }
+
+//	The methods below let a blob handle be used with the io package. Reads stop at sqlite3_blob_bytes() with io.EOF. A blob cannot be
+//	resized through a handle, so a write that would extend past the end writes what fits and fails with io.ErrShortWrite. Once the
+//	handle has expired they fail with SQLITE_ABORT, as sqlite3_blob_read() and sqlite3_blob_write() do.
+
+//	Return the SQLITE_ABORT error of an expired handle, or nil if the handle is still valid. sqlite3_blob_bytes() is 0 once the handle
+//	has expired, so this must be checked before the size is used.
+func (pBlob *sqlite3_blob) expired() error {
+	if (Incrblob *)(pBlob).pStmt != nil {
+		return nil
+	}
+	return &Error{ Code: SQLITE_ABORT }
+}
+
+//	Convert a result code from ReadWrite() into an error carrying the message left on the connection.
+func (pBlob *sqlite3_blob) error(rc int) error {
+	if rc == SQLITE_OK {
+		return nil
+	}
+	return (Incrblob *)(pBlob).db.lastError(rc)
+}
+
+//	ReadAt implements io.ReaderAt.
+func (pBlob *sqlite3_blob) ReadAt(b []byte, off int64) (n int, err error) {
+	if err = pBlob.expired(); err != nil {
+		return 0, err
+	}
+	size := int64(sqlite3_blob_bytes(pBlob))
+	switch {
+	case off < 0:
+		return 0, errors.New("sqlite3_blob.ReadAt: negative offset")
+	case off >= size:
+		return 0, io.EOF
+	}
+	if n = len(b); int64(n) > size - off {
+		n = int(size - off)
+		err = io.EOF
+	}
+	if rc := sqlite3_blob_read(pBlob, b[:n], n, int(off)); rc != SQLITE_OK {
+		return 0, pBlob.error(rc)
+	}
+	return
+}
+
+//	WriteAt implements io.WriterAt.
+func (pBlob *sqlite3_blob) WriteAt(b []byte, off int64) (n int, err error) {
+	if err = pBlob.expired(); err != nil {
+		return 0, err
+	}
+	size := int64(sqlite3_blob_bytes(pBlob))
+	switch {
+	case off < 0:
+		return 0, errors.New("sqlite3_blob.WriteAt: negative offset")
+	case off >= size && len(b) > 0:
+		return 0, io.ErrShortWrite
+	case off >= size:
+		//	An empty write past the end, as after seeking there, writes nothing.
+		return 0, nil
+	}
+	if n = len(b); int64(n) > size - off {
+		n = int(size - off)
+		err = io.ErrShortWrite
+	}
+	if rc := sqlite3_blob_write(pBlob, b[:n], n, int(off)); rc != SQLITE_OK {
+		return 0, pBlob.error(rc)
+	}
+	return
+}
+
+//	Read implements io.Reader, reading from the current position and advancing it.
+func (pBlob *sqlite3_blob) Read(b []byte) (n int, err error) {
+	p := (Incrblob *)(pBlob)
+	n, err = pBlob.ReadAt(b, p.iPos)
+	p.iPos += int64(n)
+	if err == io.EOF && n > 0 {
+		//	io.Reader allows the EOF to be deferred to the next call, which is what most callers expect.
+		err = nil
+	}
+	return
+}
+
+//	Write implements io.Writer, writing at the current position and advancing it.
+func (pBlob *sqlite3_blob) Write(b []byte) (n int, err error) {
+	p := (Incrblob *)(pBlob)
+	n, err = pBlob.WriteAt(b, p.iPos)
+	p.iPos += int64(n)
+	return
+}
+
+//	Seek implements io.Seeker. Seeking past the end is allowed; a later Read returns io.EOF and a later Write io.ErrShortWrite.
+func (pBlob *sqlite3_blob) Seek(offset int64, whence int) (int64, error) {
+	p := (Incrblob *)(pBlob)
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += p.iPos
+	case io.SeekEnd:
+		offset += int64(sqlite3_blob_bytes(pBlob))
+	default:
+		return 0, errors.New("sqlite3_blob.Seek: invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("sqlite3_blob.Seek: negative position")
+	}
+	p.iPos = offset
+	return offset, nil
+}
+
+//	Size returns the size of the blob in bytes, as sqlite3_blob_bytes().
+func (pBlob *sqlite3_blob) Size() int64 {
+	return int64(sqlite3_blob_bytes(pBlob))
+}
@@ -0,0 +1,78 @@
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestBlobIO(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(b BLOB)")
+	testExec(t, db, "INSERT INTO t(rowid, b) VALUES(1, zeroblob(10)), (2, 'abcdef')")
+	testRaw(t, db, func(db *sqlite3) {
+		var pBlob *sqlite3_blob
+		if rc := sqlite3_blob_open(db, "main", "t", "b", 1, 1, &pBlob); rc != SQLITE_OK {
+			t.Fatalf("sqlite3_blob_open() = %v", rc)
+		}
+		defer pBlob.Close()
+		if n := pBlob.Size(); n != 10 {
+			t.Errorf("Size() = %v, want 10", n)
+		}
+
+		if n, err := pBlob.Write([]byte("hello")); n != 5 || err != nil {
+			t.Errorf("Write() = %v, %v", n, err)
+		}
+		if n, err := pBlob.WriteAt([]byte("world!"), 5); n != 5 || err != io.ErrShortWrite {
+			t.Errorf("WriteAt() past the end = %v, %v, want 5, %v", n, err, io.ErrShortWrite)
+		}
+		if n, err := pBlob.WriteAt([]byte("x"), 10); n != 0 || err != io.ErrShortWrite {
+			t.Errorf("WriteAt() at the end = %v, %v, want 0, %v", n, err, io.ErrShortWrite)
+		}
+		if _, err := pBlob.Seek(20, io.SeekStart); err != nil {
+			t.Errorf("Seek() past the end = %v", err)
+		}
+		if n, err := pBlob.Write(nil); n != 0 || err != nil {
+			t.Errorf("empty Write() past the end = %v, %v, want 0, nil", n, err)
+		}
+
+		if off, err := pBlob.Seek(0, io.SeekStart); off != 0 || err != nil {
+			t.Errorf("Seek() = %v, %v", off, err)
+		}
+		if b, err := io.ReadAll(pBlob); string(b) != "helloworld" || err != nil {
+			t.Errorf("ReadAll() = %q, %v", b, err)
+		}
+		b := make([]byte, 4)
+		if n, err := pBlob.ReadAt(b, 8); n != 2 || err != io.EOF || string(b[:n]) != "ld" {
+			t.Errorf("ReadAt() past the end = %v, %v, %q", n, err, b[:n])
+		}
+		if off, err := pBlob.Seek(-3, io.SeekEnd); off != 7 || err != nil {
+			t.Errorf("Seek() from the end = %v, %v", off, err)
+		}
+		if n, err := pBlob.Read(b); n != 3 || err != nil || string(b[:n]) != "rld" {
+			t.Errorf("Read() = %v, %v, %q", n, err, b[:n])
+		}
+		if n, err := pBlob.Read(b); n != 0 || err != io.EOF {
+			t.Errorf("Read() at the end = %v, %v", n, err)
+		}
+
+		//	A handle moved to another row reads that row.
+		if rc := sqlite3_blob_reopen(pBlob, 2); rc != SQLITE_OK {
+			t.Fatalf("sqlite3_blob_reopen() = %v", rc)
+		}
+		var buf bytes.Buffer
+		if _, err := io.Copy(&buf, io.NewSectionReader(pBlob, 0, pBlob.Size())); buf.String() != "abcdef" || err != nil {
+			t.Errorf("row 2 = %q, %v", buf.String(), err)
+		}
+
+		//	Changing the row expires the handle.
+		if rc := sqlite3_exec(db, "UPDATE t SET b = 'changed' WHERE rowid = 2", nil, nil, nil); rc != SQLITE_OK {
+			t.Fatalf("UPDATE: %v", rc)
+		}
+		if _, err := pBlob.ReadAt(b, 0); testCode(err) != SQLITE_ABORT {
+			t.Errorf("ReadAt() on an expired handle = %v, want SQLITE_ABORT", err)
+		}
+		if _, err := pBlob.WriteAt(b, 0); testCode(err) != SQLITE_ABORT {
+			t.Errorf("WriteAt() on an expired handle = %v, want SQLITE_ABORT", err)
+		}
+	})
+	testQueryIs(t, db, "helloworld\nchanged", "SELECT CAST(b AS TEXT) FROM t ORDER BY rowid")
+}
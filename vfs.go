@@ -0,0 +1,352 @@
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+//	This file defines the Go interface to the operating system layer. A VFS opens, deletes and names files; a File performs I/O and
+//	locking on one open file. Either may be registered with RegisterVFS(), which wraps it in an sqlite3_vfs so that it can be used
+//	anywhere the C-style VFS objects are, including by name in sqlite3_open_v2().
+//
+//	Methods report failure through the error return. An *Error carries its own SQLITE_* result code; any other error is reported to the
+//	core as the I/O error code appropriate to the method that failed.
+
+//	VFS is implemented by file systems that can be registered with RegisterVFS().
+type VFS interface {
+	//	Open opens or creates the file called name. flags is a combination of the SQLITE_OPEN_* values passed to xOpen(). name is empty
+	//	when the core wants an anonymous temporary file, which must be removed when closed. The returned flags are passed back to the core
+	//	as the xOpen() pOutFlags value.
+	Open(name string, flags int) (f File, outFlags int, err error)
+
+	//	Delete removes the named file. If syncDir is true the directory entry must be durable before Delete returns.
+	Delete(name string, syncDir bool) error
+
+	//	Access reports whether the named file exists (SQLITE_ACCESS_EXISTS) or is readable and writable (SQLITE_ACCESS_READWRITE).
+	Access(name string, flags int) (bool, error)
+
+	//	FullPathname returns the canonical name of the file called name.
+	FullPathname(name string) (string, error)
+}
+
+//	File is implemented by the files returned from VFS.Open.
+//	ReadAt must fill the whole of p if it returns a nil error. A read that reaches the end of the file returns the number of bytes read
+//	and io.EOF; the bridge zero-fills the rest of the buffer and reports SQLITE_IOERR_SHORT_READ as the pager expects.
+type File interface {
+	io.ReaderAt
+	io.WriterAt
+	Close() error
+	Truncate(size int64) error
+	Sync(flags int) error
+	Size() (int64, error)
+
+	//	Lock raises the lock held on the file to level, one of SQLITE_LOCK_SHARED, SQLITE_LOCK_RESERVED or SQLITE_LOCK_EXCLUSIVE. If the
+	//	lock cannot be obtained because of another connection Lock returns an *Error with code SQLITE_BUSY.
+	Lock(level int) error
+
+	//	Unlock lowers the lock held on the file to level, either SQLITE_LOCK_SHARED or SQLITE_LOCK_NONE.
+	Unlock(level int) error
+
+	//	CheckReservedLock reports whether any connection holds a RESERVED or greater lock on the file.
+	CheckReservedLock() (bool, error)
+}
+
+//	A File may also implement SectorSizer to report a sector size other than SQLITE_DEFAULT_SECTOR_SIZE.
+type SectorSizer interface {
+	SectorSize() int
+}
+
+//	A File may also implement DeviceCharacteristicser to report the SQLITE_IOCAP_* values that apply to it.
+type DeviceCharacteristicser interface {
+	DeviceCharacteristics() int
+}
+
+//	A database File may also implement SharedMemory to provide the wal-index through which connections in several processes share the
+//	database in WAL mode. The methods are those of xShmMap(), xShmLock(), xShmBarrier() and xShmUnmap(). Without it a database can only
+//	be in WAL mode with locking_mode=EXCLUSIVE or in exclusive-process mode.
+type SharedMemory interface {
+	//	ShmMap returns region iRegion, of szRegion bytes, of the wal-index. A region that does not exist is created if bExtend is true;
+	//	otherwise nil is returned. A wal-index that can only be read returns the region with an *Error with code SQLITE_READONLY.
+	ShmMap(iRegion, szRegion int, bExtend bool) ([]uint32, error)
+
+	//	ShmLock takes or releases the n wal-index locks starting at offset. flags is SQLITE_SHM_LOCK or SQLITE_SHM_UNLOCK combined with
+	//	SQLITE_SHM_SHARED or SQLITE_SHM_EXCLUSIVE. A lock held by another connection is reported as an *Error with code SQLITE_BUSY.
+	ShmLock(offset, n, flags int) error
+
+	//	ShmBarrier orders the wal-index reads and writes before it against those after it.
+	ShmBarrier()
+
+	//	ShmUnmap releases the wal-index of the handle. If deleteFlag is true and no other connection uses it, it is deleted.
+	ShmUnmap(deleteFlag bool) error
+}
+
+//	Go VFS implementations registered by name. These are consulted by FindVFS(); the sqlite3_vfs wrapping each one is kept on the usual
+//	vfsList by sqlite3_vfs_register().
+var (
+	vfsRegistry		= make(map[string]VFS)
+	vfsRegistryLock	sync.Mutex
+)
+
+//	RegisterVFS makes v available under name. If makeDflt is true v becomes the VFS used when none is named. Registering a second VFS
+//	with the same name replaces the first.
+func RegisterVFS(name string, v VFS, makeDflt bool) (rc int) {
+	if rc = Initialize(); rc != SQLITE_OK {
+		return
+	}
+	vfsRegistryLock.Lock()
+	defer vfsRegistryLock.Unlock()
+	if pOld := sqlite3_vfs_find(name); pOld != nil {
+		if _, ok := vfsRegistry[name]; ok {
+			sqlite3_vfs_unregister(pOld)
+		}
+	}
+	vfsRegistry[name] = v
+	return sqlite3_vfs_register(newGoVfs(name, v), makeDflt)
+}
+
+//	FindVFS returns the Go VFS registered under name, or nil if there is none.
+func FindVFS(name string) VFS {
+	vfsRegistryLock.Lock()
+	defer vfsRegistryLock.Unlock()
+	return vfsRegistry[name]
+}
+
+//	Convert an error returned by a VFS or File method into a result code. ioerr is the code used for errors that carry no code of
+//	their own.
+func vfsErrorCode(err error, ioerr int) int {
+	var e *Error
+	switch {
+	case err == nil:
+		return SQLITE_OK
+	case errors.As(err, &e):
+		return e.Code
+	default:
+		return ioerr
+	}
+}
+
+//	Build the sqlite3_vfs object through which the core calls v.
+func newGoVfs(name string, v VFS) *sqlite3_vfs {
+	return &sqlite3_vfs{
+		iVersion:		2,
+		szOsFile:		sizeof(sqlite3_file),
+		mxPathname:		MAX_PATHNAME,
+		Name:			name,
+		pAppData:		v,
+		xOpen:			goVfsOpen,
+		xDelete:		goVfsDelete,
+		xAccess:		goVfsAccess,
+		xFullPathname:	goVfsFullPathname,
+		xRandomness:	goVfsRandomness,
+		xSleep:			goVfsSleep,
+		xCurrentTime:	goVfsCurrentTime,
+		xGetLastError:	goVfsGetLastError,
+		xCurrentTimeInt64:	goVfsCurrentTimeInt64,
+	}
+}
+
+func goVfsOpen(pVfs *sqlite3_vfs, zPath string, pFile *sqlite3_file, flags int, pOutFlags *int) (rc int) {
+	pFile.pMethods = nil
+	f, outFlags, err := pVfs.pAppData.(VFS).Open(zPath, flags)
+	if err != nil {
+		return vfsErrorCode(err, SQLITE_CANTOPEN)
+	}
+	pFile.goFile = f
+	if _, ok := f.(SharedMemory); ok {
+		pFile.pMethods = &goShmIoMethods
+	} else {
+		pFile.pMethods = &goIoMethods
+	}
+	if pOutFlags != nil {
+		*pOutFlags = outFlags
+	}
+	return SQLITE_OK
+}
+
+func goVfsDelete(pVfs *sqlite3_vfs, zPath string, syncDir int) int {
+	return vfsErrorCode(pVfs.pAppData.(VFS).Delete(zPath, syncDir != 0), SQLITE_IOERR_DELETE)
+}
+
+func goVfsAccess(pVfs *sqlite3_vfs, zPath string, flags int, pResOut *int) int {
+	ok, err := pVfs.pAppData.(VFS).Access(zPath, flags)
+	if ok {
+		*pResOut = 1
+	} else {
+		*pResOut = 0
+	}
+	return vfsErrorCode(err, SQLITE_IOERR_ACCESS)
+}
+
+func goVfsFullPathname(pVfs *sqlite3_vfs, zPath string, nOut int, zOut *string) int {
+	name, err := pVfs.pAppData.(VFS).FullPathname(zPath)
+	if err != nil {
+		return vfsErrorCode(err, SQLITE_CANTOPEN)
+	}
+	if len(name) >= nOut {
+		return SQLITE_CANTOPEN
+	}
+	*zOut = name
+	return SQLITE_OK
+}
+
+func goVfsRandomness(pVfs *sqlite3_vfs, nByte int, zOut []byte) int {
+	n, _ := rand.Read(zOut[:nByte])
+	return n
+}
+
+func goVfsSleep(pVfs *sqlite3_vfs, microseconds int) int {
+	time.Sleep(time.Duration(microseconds) * time.Microsecond)
+	return microseconds
+}
+
+//	The Julian day number of the Unix epoch, in milliseconds.
+const unixEpochJulianMs = int64(24405875) * 8640000
+
+func goVfsCurrentTimeInt64(pVfs *sqlite3_vfs, piNow *int64) int {
+	*piNow = unixEpochJulianMs + time.Now().UnixNano() / int64(time.Millisecond)
+	return SQLITE_OK
+}
+
+func goVfsCurrentTime(pVfs *sqlite3_vfs, prNow *float64) int {
+	var i int64
+	rc := goVfsCurrentTimeInt64(pVfs, &i)
+	*prNow = float64(i) / 86400000.0
+	return rc
+}
+
+func goVfsGetLastError(pVfs *sqlite3_vfs, nBuf int, zBuf *string) int {
+	return 0
+}
+
+//	The sqlite3_io_methods used for every file opened through a Go VFS. Each method forwards to sqlite3_file.goFile.
+var goIoMethods = sqlite3_io_methods{
+	iVersion:				1,
+	xClose:					goFileClose,
+	xRead:					goFileRead,
+	xWrite:					goFileWrite,
+	xTruncate:				goFileTruncate,
+	xSync:					goFileSync,
+	xFileSize:				goFileSize,
+	xLock:					goFileLock,
+	xUnlock:				goFileUnlock,
+	xCheckReservedLock:		goFileCheckReservedLock,
+	xFileControl:			goFileControl,
+	xSectorSize:			goFileSectorSize,
+	xDeviceCharacteristics:	goFileDeviceCharacteristics,
+}
+
+//	The sqlite3_io_methods used for files that implement SharedMemory.
+var goShmIoMethods = sqlite3_io_methods{
+	iVersion:				2,
+	xClose:					goFileClose,
+	xRead:					goFileRead,
+	xWrite:					goFileWrite,
+	xTruncate:				goFileTruncate,
+	xSync:					goFileSync,
+	xFileSize:				goFileSize,
+	xLock:					goFileLock,
+	xUnlock:				goFileUnlock,
+	xCheckReservedLock:		goFileCheckReservedLock,
+	xFileControl:			goFileControl,
+	xSectorSize:			goFileSectorSize,
+	xDeviceCharacteristics:	goFileDeviceCharacteristics,
+	xShmMap:				goFileShmMap,
+	xShmLock:				goFileShmLock,
+	xShmBarrier:			goFileShmBarrier,
+	xShmUnmap:				goFileShmUnmap,
+}
+
+func goFileClose(id *sqlite3_file) (rc int) {
+	rc = vfsErrorCode(id.goFile.Close(), SQLITE_IOERR_CLOSE)
+	id.goFile = nil
+	return
+}
+
+func goFileRead(id *sqlite3_file, pBuf []byte, amt int, offset int64) int {
+	switch n, err := id.goFile.ReadAt(pBuf[:amt], offset); {
+	case err == nil:
+		return SQLITE_OK
+	case err == io.EOF:
+		//	Unread parts of the buffer must be zero-filled, otherwise the pager may see stale data in a partially read page.
+		for i := n; i < amt; i++ {
+			pBuf[i] = 0
+		}
+		return SQLITE_IOERR_SHORT_READ
+	default:
+		return vfsErrorCode(err, SQLITE_IOERR_READ)
+	}
+}
+
+func goFileWrite(id *sqlite3_file, pBuf []byte, amt int, offset int64) int {
+	_, err := id.goFile.WriteAt(pBuf[:amt], offset)
+	return vfsErrorCode(err, SQLITE_IOERR_WRITE)
+}
+
+func goFileTruncate(id *sqlite3_file, size int64) int {
+	return vfsErrorCode(id.goFile.Truncate(size), SQLITE_IOERR_TRUNCATE)
+}
+
+func goFileSync(id *sqlite3_file, flags int) int {
+	return vfsErrorCode(id.goFile.Sync(flags), SQLITE_IOERR_FSYNC)
+}
+
+func goFileSize(id *sqlite3_file, pSize *int64) (rc int) {
+	size, err := id.goFile.Size()
+	*pSize = size
+	return vfsErrorCode(err, SQLITE_IOERR_FSTAT)
+}
+
+func goFileLock(id *sqlite3_file, eLock int) int {
+	return vfsErrorCode(id.goFile.Lock(eLock), SQLITE_IOERR_LOCK)
+}
+
+func goFileUnlock(id *sqlite3_file, eLock int) int {
+	return vfsErrorCode(id.goFile.Unlock(eLock), SQLITE_IOERR_UNLOCK)
+}
+
+func goFileCheckReservedLock(id *sqlite3_file, pResOut *int) int {
+	reserved, err := id.goFile.CheckReservedLock()
+	if reserved {
+		*pResOut = 1
+	} else {
+		*pResOut = 0
+	}
+	return vfsErrorCode(err, SQLITE_IOERR_CHECKRESERVEDLOCK)
+}
+
+func goFileControl(id *sqlite3_file, op int, pArg interface{}) int {
+	return SQLITE_NOTFOUND
+}
+
+func goFileSectorSize(id *sqlite3_file) int {
+	if s, ok := id.goFile.(SectorSizer); ok {
+		return s.SectorSize()
+	}
+	return SQLITE_DEFAULT_SECTOR_SIZE
+}
+
+func goFileDeviceCharacteristics(id *sqlite3_file) int {
+	if d, ok := id.goFile.(DeviceCharacteristicser); ok {
+		return d.DeviceCharacteristics()
+	}
+	return 0
+}
+
+func goFileShmMap(id *sqlite3_file, iPage, pgsz, bExtend int, pp *[]uint32) int {
+	aPage, err := id.goFile.(SharedMemory).ShmMap(iPage, pgsz, bExtend != 0)
+	*pp = aPage
+	return vfsErrorCode(err, SQLITE_IOERR_SHMMAP)
+}
+
+func goFileShmLock(id *sqlite3_file, offset, n, flags int) int {
+	return vfsErrorCode(id.goFile.(SharedMemory).ShmLock(offset, n, flags), SQLITE_IOERR_SHMLOCK)
+}
+
+func goFileShmBarrier(id *sqlite3_file) {
+	id.goFile.(SharedMemory).ShmBarrier()
+}
+
+func goFileShmUnmap(id *sqlite3_file, deleteFlag int) int {
+	return vfsErrorCode(id.goFile.(SharedMemory).ShmUnmap(deleteFlag != 0), SQLITE_IOERR_SHMOPEN)
+}
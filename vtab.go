@@ -21,6 +21,7 @@ func (db *sqlite3) createModule(Name string, module *sqlite3_module, parameter i
 			Name:			Name,
 		}
 		if pDel := db.Modules[zCopy]; pDel != nil {
+			db.VtabEponymousTableClear(pDel)
 			if pDel.xDestroy != nil {
 				db.ResetInternalSchema(-1)
 				pDel.xDestroy(pDel.Parameter)
@@ -430,7 +431,8 @@ static void addToVTrans(sqlite3 *db, VTable *pVTab){
   ** invoke it now. If the module has not been registered, return an
   ** error. Otherwise, do nothing.
   */
-  if( !pMod ){
+  if( !pMod || pMod.Callbacks.xCreate==nil ){
+    //	A module without xCreate is eponymous-only. It cannot be used by CREATE VIRTUAL TABLE.
     *pzErr = fmt.Sprintf("no such module: %v", zMod);
     rc = SQLITE_ERROR;
   }else{
@@ -447,6 +449,45 @@ static void addToVTrans(sqlite3 *db, VTable *pVTab){
   return rc;
 }
 
+//	Return the eponymous virtual table of module pMod, connecting to it if this is its first use on the connection. An eponymous
+//	virtual table has the same name as its module and exists in the "main" schema without being created by CREATE VIRTUAL TABLE.
+//	Only modules with no xCreate method have one. Return nil if pMod is not eponymous, or if xConnect fails, in which case an error is
+//	left in pParse.
+func (pParse *Parse) VtabEponymousTable(pMod *Module) *Table {
+	if pMod.Callbacks.xCreate != nil {
+		return nil
+	}
+	if pMod.pEpoTab == nil {
+		db := pParse.db
+		pTab := &Table{
+			Name:			pMod.Name,
+			iPKey:			-1,
+			nRef:			1,
+			tabFlags:		TF_Virtual | TF_Eponymous,
+			Schema:			db.Databases[0].Schema,
+			azModuleArg:	[]string{ pMod.Name, db.Databases[0].Name, pMod.Name },
+			nModuleArg:		3,
+		}
+		if sqlite3VtabCallConnect(pParse, pTab) != SQLITE_OK {
+			return nil
+		}
+		pMod.pEpoTab = pTab
+	}
+	return pMod.pEpoTab
+}
+
+//	Disconnect the eponymous virtual table of pMod, if it has been connected. This is done when the module is replaced or the
+//	connection is closed.
+func (db *sqlite3) VtabEponymousTableClear(pMod *Module) {
+	if pTab := pMod.pEpoTab; pTab != nil {
+		pMod.pEpoTab = nil
+		for pVTab := pTab.VirtualTables; pVTab != nil; pVTab = pVTab.Next {
+			pVTab.Unlock()
+		}
+		pTab.VirtualTables = nil
+	}
+}
+
 //	This function is used to set the schema of a virtual table. It is only valid to call this function from within the xCreate() or xConnect() of a virtual table module.
 func (db *sqlite3) DeclareVTab(zCreateTable string) (rc int) {
 	db.mutex.CriticalSection(func() {
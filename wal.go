@@ -406,6 +406,9 @@ struct Wal {
   WalIndexHdr hdr;           /* Wal-index header for current transaction */
   const char *zWalName;      /* Name of WAL file */
   uint32 nCkpt;                 /* Checkpoint sequence counter in the wal-header */
+	pShm				*walShm			//	In-process wal-index of an exclusive-process database, or nil
+	cacheStale			bool			//	True if a BEGIN CONCURRENT commit moved hdr past frames of other transactions
+	pSnapshot			*WalIndexHdr	//	Snapshot for the next read transaction to open, or nil
 };
 
 /*
@@ -511,6 +514,8 @@ static int walIndexPage(Wal *pWal, int iPage, volatile uint32 **ppPage){
     if( pWal.exclusiveMode==WAL_HEAPMEMORY_MODE ){
       pWal.apWiData[iPage] = (uint32 volatile *)sqlite3MallocZero(WALINDEX_PGSZ);
       if( !pWal.apWiData[iPage] ) rc = SQLITE_NOMEM;
+    }else if( pWal.pShm ){
+      pWal.apWiData[iPage], rc = pWal.pShm.Map(iPage, pWal.writeLock)
     }else{
       rc = sqlite3OsShmMap(pWal.pDbFd, iPage, WALINDEX_PGSZ, 
           pWal.writeLock, (void volatile **)&pWal.apWiData[iPage]
@@ -603,7 +608,9 @@ static void walChecksumBytes(
 }
 
 static void walShmBarrier(Wal *pWal){
-  if( pWal.exclusiveMode!=WAL_HEAPMEMORY_MODE ){
+  if( pWal.pShm ){
+    pWal.pShm.Barrier()
+  }else if( pWal.exclusiveMode!=WAL_HEAPMEMORY_MODE ){
     sqlite3OsShmBarrier(pWal.pDbFd);
   }
 }
@@ -621,9 +628,9 @@ static void walIndexWriteHdr(Wal *pWal){
   pWal.hdr.isInit = true
   pWal.hdr.iVersion = WALINDEX_MAX_VERSION;
   walChecksumBytes(1, (byte*)&pWal.hdr, nCksum, 0, pWal.hdr.aCksum);
-  memcpy((void *)&aHdr[1], (void *)&pWal.hdr, sizeof(WalIndexHdr));
+  walHdrStore(&aHdr[1], &pWal.hdr);
   walShmBarrier(pWal);
-  memcpy((void *)&aHdr[0], (void *)&pWal.hdr, sizeof(WalIndexHdr));
+  walHdrStore(&aHdr[0], &pWal.hdr);
 }
 
 /*
@@ -724,22 +731,32 @@ static int walDecodeFrame(
 static int walLockShared(Wal *pWal, int lockIdx){
   int rc;
   if( pWal.exclusiveMode ) return SQLITE_OK;
+  if( pWal.pShm ) return pWal.pShm.Lock(lockIdx, 1, SQLITE_SHM_LOCK | SQLITE_SHM_SHARED)
   rc = sqlite3OsShmLock(pWal.pDbFd, lockIdx, 1, SQLITE_SHM_LOCK | SQLITE_SHM_SHARED);
   return rc;
 }
 static void walUnlockShared(Wal *pWal, int lockIdx){
   if( pWal.exclusiveMode ) return;
+  if( pWal.pShm ){
+    pWal.pShm.Lock(lockIdx, 1, SQLITE_SHM_UNLOCK | SQLITE_SHM_SHARED)
+    return
+  }
   (void)sqlite3OsShmLock(pWal.pDbFd, lockIdx, 1,
                          SQLITE_SHM_UNLOCK | SQLITE_SHM_SHARED);
 }
 static int walLockExclusive(Wal *pWal, int lockIdx, int n){
   int rc;
   if( pWal.exclusiveMode ) return SQLITE_OK;
+  if( pWal.pShm ) return pWal.pShm.Lock(lockIdx, n, SQLITE_SHM_LOCK | SQLITE_SHM_EXCLUSIVE)
   rc = sqlite3OsShmLock(pWal.pDbFd, lockIdx, n, SQLITE_SHM_LOCK | SQLITE_SHM_EXCLUSIVE);
   return rc;
 }
 static void walUnlockExclusive(Wal *pWal, int lockIdx, int n){
   if( pWal.exclusiveMode ) return;
+  if( pWal.pShm ){
+    pWal.pShm.Lock(lockIdx, n, SQLITE_SHM_UNLOCK | SQLITE_SHM_EXCLUSIVE)
+    return
+  }
   (void)sqlite3OsShmLock(pWal.pDbFd, lockIdx, n,
                          SQLITE_SHM_UNLOCK | SQLITE_SHM_EXCLUSIVE);
 }
@@ -1124,9 +1141,9 @@ finished:
     ** checkpointers.
     */
     pInfo = walCkptInfo(pWal);
-    pInfo.nBackfill = 0;
-    pInfo.aReadMark[0] = 0;
-    for(i=1; i<WAL_NREADER; i++) pInfo.aReadMark[i] = READMARK_NOT_USED;
+    walShmPut(&pInfo.nBackfill, 0);
+    walShmPut(&pInfo.aReadMark[0], 0);
+    for(i=1; i<WAL_NREADER; i++) walShmPut(&pInfo.aReadMark[i], READMARK_NOT_USED);
 
     /* If more than one frame was recovered from the log file, report an
     ** event via sqlite3_log(). This is to help with identifying performance
@@ -1154,6 +1171,13 @@ static void walIndexClose(Wal *pWal, int isDelete){
     for(i=0; i<pWal.nWiData; i++){
       pWal.apWiData[i] = nil
     }
+  }else if( pWal.pShm ){
+    int i;
+    for(i=0; i<pWal.nWiData; i++){
+      pWal.apWiData[i] = nil
+    }
+    pWal.pShm.Unmap(isDelete)
+    pWal.pShm = nil
   }else{
     sqlite3OsShmUnmap(pWal.pDbFd, isDelete);
   }
@@ -1586,7 +1610,7 @@ static int walCheckpoint(
 
   PageSize = walPagesize(pWal);
   pInfo = walCkptInfo(pWal);
-  if( pInfo.nBackfill>=pWal.hdr.mxFrame ) return SQLITE_OK;
+  if( walShmGet(&pInfo.nBackfill)>=pWal.hdr.mxFrame ) return SQLITE_OK;
 
   /* Allocate the iterator */
   rc = walIteratorInit(pWal, &pIter);
@@ -1605,12 +1629,12 @@ static int walCheckpoint(
   mxSafeFrame = pWal.hdr.mxFrame;
   mxPage = pWal.hdr.nPage;
   for(i=1; i<WAL_NREADER; i++){
-    uint32 y = pInfo.aReadMark[i];
+    uint32 y = walShmGet(&pInfo.aReadMark[i]);
     if( mxSafeFrame>y ){
       assert( y<=pWal.hdr.mxFrame );
       rc = walBusyLock(pWal, xBusy, pBusyArg, WAL_READ_LOCK(i), 1);
       if( rc==SQLITE_OK ){
-        pInfo.aReadMark[i] = READMARK_NOT_USED;
+        walShmPut(&pInfo.aReadMark[i], READMARK_NOT_USED);
         walUnlockExclusive(pWal, WAL_READ_LOCK(i), 1);
       }else if( rc==SQLITE_BUSY ){
         mxSafeFrame = y;
@@ -1621,11 +1645,11 @@ static int walCheckpoint(
     }
   }
 
-  if( pInfo.nBackfill<mxSafeFrame
+  if( walShmGet(&pInfo.nBackfill)<mxSafeFrame
    && (rc = walBusyLock(pWal, xBusy, pBusyArg, WAL_READ_LOCK(0), 1))==SQLITE_OK
   ){
     int64 nSize;                    /* Current size of database file */
-    uint32 nBackfill = pInfo.nBackfill;
+    uint32 nBackfill = walShmGet(&pInfo.nBackfill);
 
     /* Sync the WAL to disk */
     if( sync_flags ){
@@ -1658,7 +1682,7 @@ static int walCheckpoint(
 
     /* If work was actually accomplished... */
     if( rc==SQLITE_OK ){
-      if( mxSafeFrame==walIndexHdr(pWal).mxFrame ){
+      if( mxSafeFrame==walShmGet(&walIndexHdr(pWal).mxFrame) ){
         int64 szDb = pWal.hdr.nPage*(int64)PageSize;
         rc = sqlite3OsTruncate(pWal.pDbFd, szDb);
         if( rc==SQLITE_OK && sync_flags ){
@@ -1666,7 +1690,7 @@ static int walCheckpoint(
         }
       }
       if( rc==SQLITE_OK ){
-        pInfo.nBackfill = mxSafeFrame;
+        walShmPut(&pInfo.nBackfill, mxSafeFrame);
       }
     }
 
@@ -1687,7 +1711,7 @@ static int walCheckpoint(
   */
   if( rc==SQLITE_OK && eMode!=SQLITE_CHECKPOINT_PASSIVE ){
     assert( pWal.writeLock );
-    if( pInfo.nBackfill<pWal.hdr.mxFrame ){
+    if( walShmGet(&pInfo.nBackfill)<pWal.hdr.mxFrame ){
       rc = SQLITE_BUSY;
     }else if( eMode==SQLITE_CHECKPOINT_RESTART ){
       assert( mxSafeFrame==pWal.hdr.mxFrame );
@@ -1815,9 +1839,9 @@ static int walIndexTryHdr(Wal *pWal, int *pChanged){
   ** reordering the reads and writes.
   */
   aHdr = walIndexHdr(pWal);
-  memcpy(&h1, (void *)&aHdr[0], sizeof(h1));
+  walHdrLoad(&h1, &aHdr[0]);
   walShmBarrier(pWal);
-  memcpy(&h2, (void *)&aHdr[1], sizeof(h2));
+  walHdrLoad(&h2, &aHdr[1]);
 
   if( memcmp(&h1, &h2, sizeof(h1))!=0 ){
     return 1;   /* Dirty read */
@@ -1975,6 +1999,7 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
   int mxI;                        /* Index of largest aReadMark[] value */
   int i;                          /* Loop counter */
   int rc = SQLITE_OK;             /* Return code  */
+  uint32 mxFrame;                    /* Wal frame to lock to */
 
   assert( pWal.readLock<0 );     /* Not currently locked */
 
@@ -2037,14 +2062,16 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
   }
 
   pInfo = walCkptInfo(pWal);
-  if( !useWal && pInfo.nBackfill==pWal.hdr.mxFrame ){
+  if( !useWal && walShmGet(&pInfo.nBackfill)==pWal.hdr.mxFrame
+   && (pWal.pSnapshot==0 || pWal.hdr.mxFrame==0)
+  ){
     /* The WAL has been completely backfilled (or it is empty).
     ** and can be safely ignored.
     */
     rc = walLockShared(pWal, WAL_READ_LOCK(0));
     walShmBarrier(pWal);
     if( rc==SQLITE_OK ){
-      if( memcmp((void *)walIndexHdr(pWal), &pWal.hdr, sizeof(WalIndexHdr)) ){
+      if( walIndexHdrChanged(pWal) ){
         /* It is not safe to allow the reader to continue here if frames
         ** may have been appended to the log before READ_LOCK(0) was obtained.
         ** When holding READ_LOCK(0), the reader ignores the entire log file,
@@ -2071,13 +2098,19 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
   /* If we get this far, it means that the reader will want to use
   ** the WAL to get at content from recent commits.  The job now is
   ** to select one of the aReadMark[] entries that is closest to
-  ** but not exceeding pWal.hdr.mxFrame and lock that entry.
+  ** but not exceeding pWal.hdr.mxFrame and lock that entry. When a
+  ** snapshot is being opened, the entry must not exceed the mxFrame of
+  ** the snapshot instead.
   */
   mxReadMark = 0;
   mxI = 0;
+  mxFrame = pWal.hdr.mxFrame;
+  if( pWal.pSnapshot && pWal.pSnapshot.mxFrame<mxFrame ){
+    mxFrame = pWal.pSnapshot.mxFrame;
+  }
   for(i=1; i<WAL_NREADER; i++){
-    uint32 thisMark = pInfo.aReadMark[i];
-    if( mxReadMark<=thisMark && thisMark<=pWal.hdr.mxFrame ){
+    uint32 thisMark = walShmGet(&pInfo.aReadMark[i]);
+    if( mxReadMark<=thisMark && thisMark<=mxFrame ){
       assert( thisMark!=READMARK_NOT_USED );
       mxReadMark = thisMark;
       mxI = i;
@@ -2086,12 +2119,13 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
   /* There was once an "if" here. The extra "{" is to preserve indentation. */
   {
     if( (pWal.readOnly & WAL_SHM_RDONLY)==0
-     && (mxReadMark<pWal.hdr.mxFrame || mxI==0)
+     && (mxReadMark<mxFrame || mxI==0)
     ){
       for(i=1; i<WAL_NREADER; i++){
         rc = walLockExclusive(pWal, WAL_READ_LOCK(i), 1);
         if( rc==SQLITE_OK ){
-          mxReadMark = pInfo.aReadMark[i] = pWal.hdr.mxFrame;
+          walShmPut(&pInfo.aReadMark[i], mxFrame);
+          mxReadMark = mxFrame;
           mxI = i;
           walUnlockExclusive(pWal, WAL_READ_LOCK(i), 1);
           break;
@@ -2130,8 +2164,8 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
     ** WAL_READ_LOCK(mxI)) has not occurred since the snapshot was valid.
     */
     walShmBarrier(pWal);
-    if( pInfo.aReadMark[mxI]!=mxReadMark
-     || memcmp((void *)walIndexHdr(pWal), &pWal.hdr, sizeof(WalIndexHdr))
+    if( walShmGet(&pInfo.aReadMark[mxI])!=mxReadMark
+     || walIndexHdrChanged(pWal)
     ){
       walUnlockShared(pWal, WAL_READ_LOCK(mxI));
       return WAL_RETRY;
@@ -2160,10 +2194,50 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
  int sqlite3WalBeginReadTransaction(Wal *pWal, int *pChanged){
   int rc;                         /* Return code */
   int cnt = 0;                    /* Number of TryBeginRead attempts */
+  WalIndexHdr *pSnapshot = pWal.pSnapshot;
+  int bChanged = 0;
+
+  if( pSnapshot && memcmp(pSnapshot, &pWal.hdr, sizeof(WalIndexHdr))!=0 ){
+    bChanged = 1;
+  }
 
   do{
     rc = walTryBeginRead(pWal, pChanged, 0, ++cnt);
   }while( rc==WAL_RETRY );
+
+  if( rc==SQLITE_OK && pSnapshot
+   && memcmp(pSnapshot, &pWal.hdr, sizeof(WalIndexHdr))!=0
+  ){
+    /* The read lock is on an aReadMark[] slot no greater than the mxFrame
+    ** of the snapshot, but pWal.hdr is the head of the WAL. The snapshot
+    ** can no longer be opened if the WAL has been restarted since it was
+    ** taken, which changes the salt, or if a checkpoint has copied frames
+    ** past its mxFrame into the database file. Holding the shared CKPT
+    ** lock makes sure that no checkpoint is running while nBackfill is
+    ** read.
+    */
+    volatile WalCkptInfo *pInfo = walCkptInfo(pWal);
+    assert( pWal.readLock>0 || pWal.hdr.mxFrame==0 );
+    if rc = walLockShared(pWal, WAL_CKPT_LOCK); rc==SQLITE_OK {
+      if( pSnapshot.aSalt==pWal.hdr.aSalt && pSnapshot.mxFrame>=walShmGet(&pInfo.nBackfill) ){
+        memcpy(&pWal.hdr, pSnapshot, sizeof(WalIndexHdr));
+        *pChanged = bChanged;
+      }else{
+        rc = SQLITE_BUSY_SNAPSHOT;
+      }
+      walUnlockShared(pWal, WAL_CKPT_LOCK);
+    }
+    if( rc!=SQLITE_OK ){
+      sqlite3WalEndReadTransaction(pWal);
+    }
+  }
+
+  /* The pages that the other transactions of a BEGIN CONCURRENT commit
+  ** wrote may be out of date in the cache, although hdr is not. */
+  if( rc==SQLITE_OK && pWal.cacheStale ){
+    *pChanged = 1;
+    pWal.cacheStale = false
+  }
   return rc;
 }
 
@@ -2345,7 +2419,7 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
   ** time the read transaction on this connection was started, then
   ** the write is disallowed.
   */
-  if( memcmp(&pWal.hdr, (void *)walIndexHdr(pWal), sizeof(WalIndexHdr))!=0 ){
+  if( walIndexHdrChanged(pWal) ){
     walUnlockExclusive(pWal, WAL_WRITE_LOCK, 1);
     pWal.writeLock = 0;
     rc = SQLITE_BUSY;
@@ -2388,7 +2462,7 @@ static int walTryBeginRead(Wal *pWal, int *pChanged, int useWal, int cnt){
     /* Restore the clients cache of the wal-index header to the state it
     ** was in before the client began writing to the database. 
     */
-    memcpy(&pWal.hdr, (void *)walIndexHdr(pWal), sizeof(WalIndexHdr));
+    walHdrLoad(&pWal.hdr, walIndexHdr(pWal));
 
     for(iFrame=pWal.hdr.mxFrame+1; 
         rc == SQLITE_OK && iFrame<=iMax
@@ -2478,8 +2552,8 @@ static int walRestartLog(Wal *pWal){
 
   if( pWal.readLock==0 ){
     volatile WalCkptInfo *pInfo = walCkptInfo(pWal);
-    assert( pInfo.nBackfill==pWal.hdr.mxFrame );
-    if( pInfo.nBackfill>0 ){
+    assert( walShmGet(&pInfo.nBackfill)==pWal.hdr.mxFrame );
+    if( walShmGet(&pInfo.nBackfill)>0 ){
       uint32 salt1;
 
 		  rand.Read(&salt1)
@@ -2503,9 +2577,9 @@ static int walRestartLog(Wal *pWal){
         Buffer(aSalt[0:]).IncrementUint32(1)
         aSalt[1] = salt1;
         walIndexWriteHdr(pWal);
-        pInfo.nBackfill = 0;
-        for(i=1; i<WAL_NREADER; i++) pInfo.aReadMark[i] = READMARK_NOT_USED;
-        assert( pInfo.aReadMark[0]==0 );
+        walShmPut(&pInfo.nBackfill, 0);
+        for(i=1; i<WAL_NREADER; i++) walShmPut(&pInfo.aReadMark[i], READMARK_NOT_USED);
+        assert( walShmGet(&pInfo.aReadMark[0])==0 );
         walUnlockExclusive(pWal, WAL_READ_LOCK(1), WAL_NREADER-1);
       }else if( rc!=SQLITE_BUSY ){
         return rc;
@@ -2578,8 +2652,8 @@ static int walWriteOneFrame(
   int rc;                         /* Result code from subfunctions */
   void *pData;                    /* Data actually written */
   byte aFrame[WAL_FRAME_HDRSIZE];   /* Buffer to assemble frame-header in */
-  if pData = pPage.Codec(); pData == nil {
-	  return SQLITE_NOMEM
+  if pData, rc = pPage.Codec(); rc != SQLITE_OK {
+	  return rc
   }
   walEncodeFrame(p.pWal, pPage.pgno, nTruncate, pData, aFrame);
   rc = walWriteToLog(p, aFrame, sizeof(aFrame), iOffset);
@@ -2803,7 +2877,7 @@ func (pWal *Wal) Checkpoint(mode int,
 				*pnLog = int(pWal.hdr.mxFrame)
 			}
 			if pnCkpt != nil {
-				*pnCkpt = int(walCkptInfo(pWal).nBackfill)
+				*pnCkpt = int(walShmGet(&walCkptInfo(pWal).nBackfill))
 			}
 		}
 	}
@@ -2886,6 +2960,14 @@ func (p *Wal) ExclusiveMode(op int) (rc bool) {
 	return
 }
 
+//	Share the wal-index with the other connections of this process that have the same WAL file open, instead of mapping the -shm file
+//	through the VFS. This is used when the database is opened in exclusive-process mode; see walshm.go. It must be called before the
+//	wal-index is first used.
+func (p *Wal) UseProcessShm() {
+	assert( p.nWiData == 0 && p.pShm == nil && p.exclusiveMode == WAL_NORMAL_MODE )
+	p.pShm = walShmOpen(p.zWalName)
+}
+
 //	Return true if the argument is non-NULL and the WAL module is using heap-memory for the wal-index. Otherwise, if the argument is NULL or the
 //	WAL module is using shared-memory, return false. 
 func (p *Wal) HeapMemory() {
@@ -2900,4 +2982,19 @@ func (p *Wal) FrameSize() (r int) {
 		r = p.PageSize
 	}
 	return
+}
+
+//	Return a copy of the wal-index header of the open read transaction, which identifies its snapshot of the database.
+func (p *Wal) SnapshotGet() (pSnapshot *WalIndexHdr, rc int) {
+	if p.readLock < 0 {
+		return nil, SQLITE_ERROR
+	}
+	hdr := p.hdr
+	return &hdr, SQLITE_OK
+}
+
+//	Make the next read transaction open pSnapshot rather than the head of the WAL, or the head again if pSnapshot is nil. The read
+//	transaction fails with SQLITE_BUSY_SNAPSHOT if the snapshot is no longer available.
+func (p *Wal) SnapshotOpen(pSnapshot *WalIndexHdr) {
+	p.pSnapshot = pSnapshot
 }
\ No newline at end of file
@@ -4,6 +4,7 @@
 type Walker struct {
 	ExprCallback		func (*Walker, *Expr) int
 	SelectCallback		func (*Walker, *Select) int
+	SelectCallback2		func (*Walker, *Select)		//	Called after the subqueries of a Select have been walked, if not nil
 	*Parse
 	union {
 		pNC			*NameContext				//	Naming context
@@ -104,6 +105,8 @@ func (w *Walker) Select(p *Select) (rc int) {
 			return WRC_Abort
     	case w.SelectFrom(p) != WRC_Continue:
 			return WRC_Abort
+		case w.SelectCallback2 != nil:
+			w.SelectCallback2(w, p)
 		}
 	}
 	return rc & WRC_Abort
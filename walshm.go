@@ -0,0 +1,189 @@
+import (
+	"sync"
+	"sync/atomic"
+	"unsafe"
+)
+
+//	This file implements the wal-index of a database opened in exclusive-process mode, in which the process promises that no other
+//	process uses the database:
+//
+//		sqlite3_open_v2("file:data.db?process=exclusive", &db, SQLITE_OPEN_READWRITE | SQLITE_OPEN_URI, "")
+//		sqlite3_open_v2("data.db", &db, SQLITE_OPEN_READWRITE | SQLITE_OPEN_EXCLUSIVE_PROCESS, "")
+//		sqlite3_open_v2("data.db", &db, SQLITE_OPEN_READWRITE, "unix-excl")
+//
+//	Instead of mapping a -shm file through the xShmMap() method of the VFS, every connection of the process that has the database open
+//	in WAL mode shares a walShmNode held in Go memory. The wal-index pages are the same as those of a -shm file, and wal.go reads and
+//	writes them just as it does mapped memory, so that readers work against the snapshot their read mark pins while a writer appends
+//	frames. The eight wal-index locks are words updated with compare-and-swap: 0 when free, the number of holders while held SHARED and
+//	-1 while held EXCLUSIVE. A lock that cannot be taken at once fails with SQLITE_BUSY, as xShmLock() does. walShmBarrier() is an
+//	atomic read-modify-write of a word of the node, which orders the wal-index writes before it against the reads after it in other
+//	goroutines.
+//
+//	Connections read the wal-index header and the WalCkptInfo words without holding the lock that guards them against writers, as the
+//	C code does with its volatile pointers. So that these reads are neither torn nor reordered, the copies of the header in the
+//	wal-index are read with walHdrLoad() and written with walHdrStore(), and nBackfill and aReadMark[] go through walShmGet() and
+//	walShmPut(), all of which load or store each word atomically. A writer stores the header only after the hash-table entries of
+//	its frames, and a reader ignores the entries for frames past the mxFrame of the header it loaded.
+//
+//	Since no -shm file is used, the VFS need not provide the shared-memory methods at all, so WAL mode also works with Go VFS
+//	implementations whose files do not implement SharedMemory. UnixVFS makes good on the promise by holding a posix lock on the database
+//	for the whole process, as it does for the unix-excl VFS.
+
+//	A walShmNode is the wal-index of a database shared by the connections of this process.
+type walShmNode struct {
+	zName		string
+	nRef		int							//	Number of walShm handles open on the node. Guarded by walShmNodes
+	mutex		sync.Mutex					//	Guards aPage
+	aPage		[][]uint32					//	wal-index pages of WALINDEX_PGSZ bytes
+	aLock		[SQLITE_SHM_NLOCK]int32		//	State of each wal-index lock
+	iBarrier	uint32
+}
+
+//	The walShmNode of each database, by the name of its WAL file.
+var walShmNodes = struct {
+	sync.Mutex
+	m		map[string]*walShmNode
+}{ m: make(map[string]*walShmNode) }
+
+//	A walShm is the handle of one connection on a walShmNode. It records the locks the connection holds.
+type walShm struct {
+	pNode			*walShmNode
+	sharedMask		uint16					//	Locks held SHARED
+	exclMask		uint16					//	Locks held EXCLUSIVE
+}
+
+//	Return a handle on the wal-index of the WAL file zWalName, which is created if this is the first.
+func walShmOpen(zWalName string) *walShm {
+	walShmNodes.Lock()
+	defer walShmNodes.Unlock()
+	pNode := walShmNodes.m[zWalName]
+	if pNode == nil {
+		pNode = &walShmNode{ zName: zWalName }
+		walShmNodes.m[zWalName] = pNode
+	}
+	pNode.nRef++
+	return &walShm{ pNode: pNode }
+}
+
+//	Return wal-index page iPage. If the page does not exist it is added if bExtend is true, and otherwise nil is returned.
+func (p *walShm) Map(iPage int, bExtend bool) ([]uint32, int) {
+	pNode := p.pNode
+	pNode.mutex.Lock()
+	defer pNode.mutex.Unlock()
+	if iPage >= len(pNode.aPage) {
+		if !bExtend {
+			return nil, SQLITE_OK
+		}
+		for len(pNode.aPage) <= iPage {
+			pNode.aPage = append(pNode.aPage, make([]uint32, WALINDEX_PGSZ / 4))
+		}
+	}
+	return pNode.aPage[iPage], SQLITE_OK
+}
+
+//	Take or release the n locks starting at ofst, as xShmLock() does. flags is SQLITE_SHM_LOCK or SQLITE_SHM_UNLOCK combined with
+//	SQLITE_SHM_SHARED or SQLITE_SHM_EXCLUSIVE.
+func (p *walShm) Lock(ofst, n, flags int) int {
+	aLock := &p.pNode.aLock
+	mask := uint16((1 << uint(ofst + n)) - (1 << uint(ofst)))
+	assert( ofst >= 0 && ofst + n <= SQLITE_SHM_NLOCK )
+	switch {
+	case flags & SQLITE_SHM_UNLOCK != 0:
+		for i := ofst; i < ofst + n; i++ {
+			switch bit := uint16(1 << uint(i)); {
+			case p.exclMask & bit != 0:
+				atomic.StoreInt32(&aLock[i], 0)
+			case p.sharedMask & bit != 0:
+				atomic.AddInt32(&aLock[i], -1)
+			}
+		}
+		p.exclMask &^= mask
+		p.sharedMask &^= mask
+	case flags & SQLITE_SHM_SHARED != 0:
+		assert( n == 1 && p.exclMask & mask == 0 )
+		if p.sharedMask & mask != 0 {
+			return SQLITE_OK
+		}
+		for {
+			v := atomic.LoadInt32(&aLock[ofst])
+			if v < 0 {
+				return SQLITE_BUSY
+			}
+			if atomic.CompareAndSwapInt32(&aLock[ofst], v, v + 1) {
+				break
+			}
+		}
+		p.sharedMask |= mask
+	default:
+		assert( p.sharedMask & mask == 0 )
+		for i := ofst; i < ofst + n; i++ {
+			if p.exclMask & (1 << uint(i)) != 0 {
+				continue
+			}
+			if !atomic.CompareAndSwapInt32(&aLock[i], 0, -1) {
+				for j := ofst; j < i; j++ {
+					if p.exclMask & (1 << uint(j)) == 0 {
+						atomic.StoreInt32(&aLock[j], 0)
+					}
+				}
+				return SQLITE_BUSY
+			}
+		}
+		p.exclMask |= mask
+	}
+	return SQLITE_OK
+}
+
+func (p *walShm) Barrier() {
+	atomic.AddUint32(&p.pNode.iBarrier, 1)
+}
+
+//	Release the locks of the handle and close it. The wal-index is discarded with its last handle. isDelete is ignored, as there is no
+//	file to delete.
+func (p *walShm) Unmap(isDelete bool) {
+	p.Lock(0, SQLITE_SHM_NLOCK, SQLITE_SHM_UNLOCK | SQLITE_SHM_EXCLUSIVE)
+	walShmNodes.Lock()
+	defer walShmNodes.Unlock()
+	if p.pNode.nRef--; p.pNode.nRef == 0 {
+		delete(walShmNodes.m, p.pNode.zName)
+	}
+	p.pNode = nil
+}
+
+//	Return the words of the wal-index header pHdr.
+func walHdrWords(pHdr *WalIndexHdr) []uint32 {
+	return unsafe.Slice((*uint32)(unsafe.Pointer(pHdr)), unsafe.Sizeof(*pHdr) / 4)
+}
+
+//	Copy the wal-index header pFrom, in the wal-index, to pTo, loading each word atomically.
+func walHdrLoad(pTo, pFrom *WalIndexHdr) {
+	aTo, aFrom := walHdrWords(pTo), walHdrWords(pFrom)
+	for i := range aFrom {
+		aTo[i] = atomic.LoadUint32(&aFrom[i])
+	}
+}
+
+//	Copy the wal-index header pFrom to pTo, in the wal-index, storing each word atomically.
+func walHdrStore(pTo, pFrom *WalIndexHdr) {
+	aTo, aFrom := walHdrWords(pTo), walHdrWords(pFrom)
+	for i := range aFrom {
+		atomic.StoreUint32(&aTo[i], aFrom[i])
+	}
+}
+
+//	Return true if the wal-index header of pWal is not the same as the copy in pWal.hdr.
+func walIndexHdrChanged(pWal *Wal) bool {
+	var hdr WalIndexHdr
+	walHdrLoad(&hdr, walIndexHdr(pWal))
+	return hdr != pWal.hdr
+}
+
+//	Return the WalCkptInfo word at p.
+func walShmGet(p *uint32) uint32 {
+	return atomic.LoadUint32(p)
+}
+
+//	Set the WalCkptInfo word at p to v.
+func walShmPut(p *uint32, v uint32) {
+	atomic.StoreUint32(p, v)
+}
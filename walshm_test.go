@@ -0,0 +1,118 @@
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"os"
+	"sync"
+	"testing"
+)
+
+//	A testConnector opens connections with sqlite3_open_v2(), flags and the VFS called zVfs, or the default VFS if it is empty, for
+//	tests that need flags or a VFS the driver does not use. Each connection waits up to 5 seconds for a lock.
+type testConnector struct {
+	zName		string
+	flags		int
+	zVfs		string
+}
+
+func (c *testConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	var db *sqlite3
+	if rc := sqlite3_open_v2(c.zName, &db, c.flags, c.zVfs); rc != SQLITE_OK {
+		err := db.lastError(rc)
+		if db != nil {
+			db.Close()
+		}
+		return nil, err
+	}
+	sqlite3_busy_timeout(db, 5000)
+	return &Conn{ db: db }, nil
+}
+
+func (c *testConnector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+//	Open zFile in exclusive-process mode with a single connection.
+func testOpenExclusive(t *testing.T, zFile string) *sql.DB {
+	t.Helper()
+	db := sql.OpenDB(&testConnector{ zName: zFile, flags: SQLITE_OPEN_READWRITE | SQLITE_OPEN_CREATE | SQLITE_OPEN_FULLMUTEX | SQLITE_OPEN_EXCLUSIVE_PROCESS })
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestWalShmReaders(t *testing.T) {
+	zFile := testFile(t)
+	db1 := testOpenExclusive(t, zFile)
+	testQueryIs(t, db1, "wal", "PRAGMA journal_mode = WAL")
+	testExec(t, db1, "CREATE TABLE t(x); INSERT INTO t VALUES(1)")
+	if _, err := os.Stat(zFile + "-shm"); !os.IsNotExist(err) {
+		t.Errorf("-shm file used: %v", err)
+	}
+
+	//	A read transaction keeps its snapshot while another connection commits and checkpoints.
+	db2 := testOpenExclusive(t, zFile)
+	testExec(t, db2, "BEGIN")
+	testQueryIs(t, db2, "1", "SELECT count(*) FROM t")
+	testExec(t, db1, "INSERT INTO t VALUES(2)")
+	testExec(t, db1, "PRAGMA wal_checkpoint")
+	testQueryIs(t, db2, "1", "SELECT count(*) FROM t")
+	testExec(t, db2, "COMMIT")
+	testQueryIs(t, db2, "2", "SELECT count(*) FROM t")
+
+	//	The wal-index is discarded with the last connection and rebuilt from the WAL by the next.
+	db1.Close()
+	db2.Close()
+	db3 := testOpenExclusive(t, zFile)
+	testQueryIs(t, db3, "1\n2", "SELECT x FROM t ORDER BY x")
+}
+
+func TestWalShmConcurrentReaders(t *testing.T) {
+	const nReader = 4
+	const nRow = 200
+	zFile := testFile(t)
+	db := testOpenExclusive(t, zFile)
+	testExec(t, db, "PRAGMA journal_mode = WAL; CREATE TABLE t(x)")
+	aReader := make([]*sql.DB, nReader)
+	for i := range aReader {
+		aReader[i] = testOpenExclusive(t, zFile)
+	}
+
+	//	Each reader must see the rows of whole transactions, so that the sum of the rows it sees is that of 1 to n.
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for _, r := range aReader {
+		wg.Add(1)
+		go func(r *sql.DB) {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				var n, sum int64
+				if err := r.QueryRow("SELECT count(*), coalesce(sum(x), 0) FROM t").Scan(&n, &sum); err != nil {
+					t.Error(err)
+					return
+				}
+				if sum != n * (n + 1) / 2 {
+					t.Errorf("%v rows sum to %v", n, sum)
+					return
+				}
+			}
+		}(r)
+	}
+	for i := 1; i <= nRow; i++ {
+		if _, err := db.Exec("INSERT INTO t VALUES(?)", i); err != nil {
+			t.Error(err)
+			break
+		}
+		if i % 50 == 0 {
+			testExec(t, db, "PRAGMA wal_checkpoint")
+		}
+	}
+	close(done)
+	wg.Wait()
+	testQueryIs(t, aReader[0], "200", "SELECT count(*) FROM t")
+}
@@ -336,6 +336,43 @@ static WhereTerm *findTerm(
   return 0;
 }
 
+//	Search for a term in the WHERE clause that constrains column iIdxCol of index pIdx on table iCur, where <op> is one of the WO_xx
+//	operator codes specified by the op parameter. Column len(pIdx.Columns) of the index is the rowid, as is every column of the fake
+//	index that bestBtreeIndex() uses for the rowid primary key, passed as a nil pIdx. A column that is an expression is matched by a
+//	term of the form "<expr> <op> <expr>" with the same expression on the left.
+func findIndexTerm(pWC *WhereClause, iCur int, pIdx *Index, iIdxCol int, notReady Bitmask, op uint32) *WhereTerm {
+	switch {
+	case pIdx == nil || iIdxCol >= len(pIdx.Columns):
+		return findTerm(pWC, iCur, -1, notReady, op, pIdx)
+	case pIdx.Columns[iIdxCol] != XN_EXPR:
+		return findTerm(pWC, iCur, pIdx.Columns[iIdxCol], notReady, op, pIdx)
+	}
+	op &= WO_ALL
+	for ; pWC != nil; pWC = pWC.OuterConjunction {
+		for i := range pWC.Terms {
+			pTerm := &pWC.Terms[i]
+			if pTerm.leftCursor != iCur || pTerm.u.leftColumn != XN_EXPR || pTerm.prereqRight & notReady != 0 || pTerm.eOperator & op == 0 {
+				continue
+			}
+			pX := pTerm.Expr
+			if !pIdx.ExprMatches(iIdxCol, iCur, pX.pLeft) {
+				continue
+			}
+			if pTerm.eOperator != WO_ISNULL {
+				if !sqlite3IndexAffinityOk(pX, pIdx.ColumnAffinity(iIdxCol)) {
+					continue
+				}
+				pColl := sqlite3BinaryCompareCollSeq(pWC.Parse, pX.pLeft, pX.pRight)
+				if pColl != nil && !CaseInsensitiveMatch(pColl.Name, pIdx.azColl[iIdxCol]) {
+					continue
+				}
+			}
+			return pTerm
+		}
+	}
+	return nil
+}
+
 //	Call exprAnalyze on all terms in a WHERE clause.  
 static void exprAnalyzeAll(
   SrcList *pTabList,       /* the FROM clause */
@@ -712,7 +749,7 @@ static void exprAnalyzeOrTerm(
         assert( pOrTerm.eOperator==WO_EQ );
         if( pOrTerm.leftCursor!=iCursor ){
           pOrTerm.wtFlags &= ~TERM_OR_OK;
-        }else if( pOrTerm.u.leftColumn!=iColumn ){
+        }else if( pOrTerm.u.leftColumn!=iColumn || iColumn==XN_EXPR ){
           okToChngToIN = 0;
         }else{
           int affLeft, affRight;
@@ -849,8 +886,14 @@ static void exprAnalyze(
       pTerm.leftCursor = pLeft.iTable;
       pTerm.u.leftColumn = pLeft.iColumn;
       pTerm.eOperator = operatorMask(op);
+    }else if iCur, ok := indexedExprCursor(pSrc, pLeft); ok {
+      /* The left-hand side is the same as an expression that is indexed */
+      pTerm.leftCursor = iCur
+      pTerm.u.leftColumn = XN_EXPR
+      pTerm.eOperator = operatorMask(op);
     }
-    if( pRight && pRight.op==TK_COLUMN ){
+    iRightCur, isRightExpr := indexedExprCursor(pSrc, pRight)
+    if( pRight && (pRight.op==TK_COLUMN || isRightExpr) ){
       WhereTerm *pNew;
       Expr *pDup;
       if( pTerm.leftCursor>=0 ){
@@ -873,8 +916,13 @@ static void exprAnalyze(
       }
       exprCommute(pParse, pDup);
       pLeft = pDup.pLeft;
-      pNew.leftCursor = pLeft.iTable;
-      pNew.u.leftColumn = pLeft.iColumn;
+      if isRightExpr {
+        pNew.leftCursor = iRightCur
+        pNew.u.leftColumn = XN_EXPR
+      } else {
+        pNew.leftCursor = pLeft.iTable;
+        pNew.u.leftColumn = pLeft.iColumn;
+      }
       pNew.prereqRight = prereqLeft | extraRight;
       pNew.prereqAll = prereqAll;
       pNew.eOperator = operatorMask(pDup.op);
@@ -1101,7 +1149,7 @@ static int findIndexCol(
 
   for(i=0; i<pList.nExpr; i++){
     Expr *p = pList.a[i].Expr;
-    if( p.op==TK_COLUMN
+    if( pIdx.ExprMatches(iCol, iBase, p) || p.op==TK_COLUMN
      && p.iColumn==pIdx.Columns[iCol]
      && p.iTable==iBase
     ){
@@ -1197,8 +1245,8 @@ func (pParse *Parse) isDistinctRedundant(tables SrcList, pWC *WhereClause, pDist
 		for _, index := range table.Indices { 
 			if index.onError == OE_None {
 				for i, column := range index.Columns {
-					if findTerm(pWC, iBase, column, ~Bitmask(0), WO_EQ, index) == 0 {
-						if findIndexCol(pParse, pDistinct, iBase, index, i) < 0 || !table.Columns[column].notNull {
+					if findIndexTerm(pWC, iBase, index, i, ~Bitmask(0), WO_EQ) == 0 {
+						if findIndexCol(pParse, pDistinct, iBase, index, i) < 0 || column < 0 || !table.Columns[column].notNull {
 							break
 						}
 					}
@@ -1277,9 +1325,11 @@ static int isSortingIndex(
     const char *zColl; /* Name of the collating sequence for i-th index term */
 
     pExpr = pTerm.Expr;
-    if( pExpr.op!=TK_COLUMN || pExpr.iTable!=base ){
+    isColumn := pExpr.op==TK_COLUMN && pExpr.iTable==base
+    isExpr := pIdx.Name != "" && pIdx.ExprMatches(i, base, pExpr)
+    if( !isColumn && !isExpr && i>=nEqCol ){
       /* Can not use an index sort on anything that is not a column in the
-      ** left-most table of the FROM clause */
+      ** left-most table of the FROM clause, or an expression that is indexed */
       break;
     }
     pColl = sqlite3ExprCollSeq(pParse, pExpr);
@@ -1298,7 +1348,7 @@ static int isSortingIndex(
       iSortOrder = 0;
       zColl = pColl.Name;
     }
-    if pExpr.iColumn!=iColumn || !CaseInsensitiveMatch(pColl.Name, zColl) {
+    if !(isExpr || isColumn && pExpr.iColumn==iColumn) || !CaseInsensitiveMatch(pColl.Name, zColl) {
 		//	Term j of the ORDER BY clause does not match column i of the index
 		switch {
 		case i < nEqCol:
@@ -1327,7 +1377,7 @@ static int isSortingIndex(
     }
     j++;
     pTerm++;
-    if( iColumn<0 && !referencesOtherTables(pOrderBy, pMaskSet, j, base) ){
+    if( iColumn==-1 && !referencesOtherTables(pOrderBy, pMaskSet, j, base) ){
       /* If the indexed column is the primary key and everything matches
       ** so far and none of the ORDER BY terms to the right reference other
       ** tables in the join, then we are assured that the index can be used 
@@ -1361,8 +1411,8 @@ static int isSortingIndex(
     ** not be true). So if all remaining index columns have NOT NULL 
     ** constaints attached to them, we can be confident that the visited
     ** index entries are free of NULLs.  */
-	for _, column := pIdx.Columns {
-		if !aCol[column].notNull {
+	for i = 0; i < len(pIdx.Columns); i++ {
+		if column := pIdx.Columns[i]; column == XN_EXPR || !aCol[column].notNull {
 			break
 		}
     }
@@ -1418,6 +1468,10 @@ static void bestOrClauseIndex(
   if( pSrc.notIndexed || pSrc.pIndex!=0 ){
     return;
   }
+  /* The rows found by each index are merged in a RowSet of rowids */
+  if !pSrc.pTab.HasRowid() {
+    return
+  }
   if( pWC.Flags & WHERE_AND_ONLY ){
     return;
   }
@@ -1494,6 +1548,7 @@ static int termCanDriveIndex(
   if( pTerm.leftCursor!=pSrc.iCursor ) return 0;
   if( pTerm.eOperator!=WO_EQ ) return 0;
   if( (pTerm.prereqRight & notReady)!=0 ) return 0;
+  if( pTerm.u.leftColumn<0 ) return 0;
   aff = pSrc.pTab.Columns[pTerm.u.leftColumn].affinity;
   if( !sqlite3IndexAffinityOk(pTerm.Expr, aff) ) return 0;
   return 1;
@@ -1535,6 +1590,14 @@ static void bestAutomaticIndex(
     /* The source is a correlated sub-query. No point in indexing it. */
     return;
   }
+  if pSrc.isRecursive {
+    /* The current row of a recursive query. It has only one row. */
+    return
+  }
+  if !pSrc.pTab.HasRowid() {
+    /* The entries of an automatic index end with a rowid */
+    return
+  }
 
   assert( pParse.nQueryLoop >= (double)1 );
   pTable = pSrc.pTab;
@@ -2213,7 +2276,7 @@ static int whereRangeScanEst(
     tRowcnt iLower = 0;
     tRowcnt iUpper = p.aiRowEst[0];
     tRowcnt a[2];
-    byte aff = p.pTable.Columns[p.Columns[0]].affinity;
+    byte aff = p.ColumnAffinity(0);
 
     if( pLower ){
       Expr *pExpr = pLower.Expr.pRight;
@@ -2266,7 +2329,7 @@ func (pParse *Parse) whereEqualScanEst(index *Index, expression *Expr) (rows flo
 	}()
 
 	assert( len(index.aSample) != 0 )
-	affinity := index.pTable.Columns[index.Columns[0]].affinity
+	affinity := index.ColumnAffinity(0)
 	if expression != nil {
 		if RHS, rc = pParse.valueFromExpr(expression, affinity); rc != SQLITE_OK {
 			return
@@ -2322,6 +2385,21 @@ func (pParse *Parse) whereInScanEst(index *Index, expressions *ExprList) (rows f
 }
 
 
+//	Return true if the terms of pWC imply pWhere, the WHERE clause of a partial index on the table of pSrc. Terms from the ON clause of a
+//	LEFT JOIN do not restrict the rows of the tables to the left of the join, so they are only considered if pSrc is the right table.
+func whereUsablePartialIndex(pWC *WhereClause, pSrc *SrcList_item, pWhere *Expr) bool {
+	iCur := pSrc.iCursor
+	aTerm := []*Expr{}
+	for _, pTerm := range pWC.Terms {
+		pExpr := pTerm.Expr
+		if pExpr.HasProperty(EP_FromJoin) && int(pExpr.iRightJoinTable) != iCur {
+			continue
+		}
+		aTerm = append(aTerm, pExpr)
+	}
+	return partialIndexImplied(aTerm, iCur, pWhere)
+}
+
 /*
 ** Find the best query plan for accessing a particular table.  Write the
 ** best query plan and its cost into the WhereCost object supplied as the
@@ -2421,6 +2499,11 @@ static void bestBtreeIndex(
   */
   for(; pProbe; pIdx=pProbe=pProbe.Next){
     const tRowcnt * const aiRowEst = pProbe.aiRowEst;
+
+    /* A partial index can only be used if the WHERE clause implies its own */
+    if pProbe.pPartIdxWhere != nil && !whereUsablePartialIndex(pWC, pSrc, pProbe.pPartIdxWhere) {
+      continue
+    }
     double cost;                /* Cost of using pProbe */
     double log10N = (double)1;  /* base-10 logarithm of nRow (inexact) */
     int rev;                    /* True to scan in reverse order */
@@ -2502,7 +2585,7 @@ static void bestBtreeIndex(
 
     //	Determine the values of nEq and nInMul
 	for nEq, j := range pProbe.Columns {
-		if pTerm = findTerm(pWC, iCur, j, notReady, eqTermMask, pIdx); pTerm == 0 {
+		if pTerm = findIndexTerm(pWC, iCur, pIdx, nEq, notReady, eqTermMask); pTerm == 0 {
 			break
 		}
 		wsFlags |= (WHERE_COLUMN_EQ|WHERE_ROWID_EQ)
@@ -2544,10 +2627,9 @@ static void bestBtreeIndex(
         wsFlags |= WHERE_UNIQUE;
       }
     }else if( pProbe.bUnordered==0 ){
-      int j = (nEq == len(pProbe.Columns) ? -1 : pProbe.Columns[nEq]);
-      if( findTerm(pWC, iCur, j, notReady, WO_LT|WO_LE|WO_GT|WO_GE, pIdx) ){
-        WhereTerm *pTop = findTerm(pWC, iCur, j, notReady, WO_LT|WO_LE, pIdx);
-        WhereTerm *pBtm = findTerm(pWC, iCur, j, notReady, WO_GT|WO_GE, pIdx);
+      if( findIndexTerm(pWC, iCur, pIdx, nEq, notReady, WO_LT|WO_LE|WO_GT|WO_GE) ){
+        WhereTerm *pTop = findIndexTerm(pWC, iCur, pIdx, nEq, notReady, WO_LT|WO_LE);
+        WhereTerm *pBtm = findIndexTerm(pWC, iCur, pIdx, nEq, notReady, WO_GT|WO_GE);
         whereRangeScanEst(pParse, pProbe, nEq, pBtm, pTop, &rangeDiv);
         if( pTop ){
           nBound = 1;
@@ -2593,10 +2675,21 @@ static void bestBtreeIndex(
     if( pIdx && wsFlags ){
       Bitmask m = pSrc.colUsed;
       for _, column := range pIdx.Columns {
-        if column < BMS - 1 {
+        if column >= 0 && column < BMS - 1 {
           m &= ~(((Bitmask)1) << column)
         }
       }
+      /* The entries of the PRIMARY KEY of a WITHOUT ROWID table are its rows, and
+      ** the entries of its other indices end with the PRIMARY KEY columns */
+      if pIdx.IsClustered() {
+        m = 0
+      } else if !pSrc.pTab.HasRowid() {
+        for _, column := range pSrc.pTab.PrimaryKey().Columns {
+          if column < BMS - 1 {
+            m &= ~(((Bitmask)1) << column)
+          }
+        }
+      }
       if( m==0 ){
         wsFlags |= WHERE_IDX_ONLY;
       }else{
@@ -3052,8 +3145,7 @@ static int codeAllEqualityTerms(
   assert( len(pIdx.Columns) >= nEq )
   for(j=0; j<nEq; j++){
     int r1;
-    int k = pIdx.Columns[j];
-    pTerm = findTerm(pWC, iCur, k, notReady, pLevel.plan.wsFlags, pIdx);
+    pTerm = findIndexTerm(pWC, iCur, pIdx, j, notReady, pLevel.plan.wsFlags);
     if( pTerm==0 ) break;
     /* The following true for indices with redundant columns. 
     ** Ex: CREATE INDEX i1 ON t1(a,b,a); SELECT * FROM t1 WHERE a=0 AND b=0; */
@@ -3124,19 +3216,26 @@ static char *explainIndexRange(sqlite3 *db, WhereLevel *pLevel, Table *pTab){
   if( nEq==0 && (pPlan.wsFlags & (WHERE_BTM_LIMIT|WHERE_TOP_LIMIT))==0 ){
     return 0;
   }
+  columnName := func(j int) string {
+    switch {
+    case j == len(Columns):
+      return "rowid"
+    case Columns[j] == XN_EXPR:
+      return "<expr>"
+    }
+    return aCol[Columns[j]].Name
+  }
   txt := " ("
   for(i=0; i<nEq; i++){
-    txt = explainAppendTerm(txt, i, aCol[Columns[i]].Name, "=");
+    txt = explainAppendTerm(txt, i, columnName(i), "=");
   }
 
   j = i;
   if( pPlan.wsFlags&WHERE_BTM_LIMIT ){
-    char *z = (j == len(pIndex.Columns) ) ? "rowid" : aCol[Columns[j]].Name
-    txt = explainAppendTerm(txt, i++, z, ">")
+    txt = explainAppendTerm(txt, i++, columnName(j), ">")
   }
   if( pPlan.wsFlags&WHERE_TOP_LIMIT ){
-    char *z = (j == len(pIndex.Columns) ) ? "rowid" : aCol[Columns[j]].Name
-    txt = explainAppendTerm(txt, i, z, "<")
+    txt = explainAppendTerm(txt, i, columnName(j), "<")
   }
   txt = append(txt, ")")
   return txt
@@ -3485,7 +3584,6 @@ static Bitmask codeOneLoopStart(
 
     pIdx = pLevel.plan.u.pIdx;
     iIdxCur = pLevel.iIdxCur;
-    k = (nEq == len(pIdx.Columns) ? -1 : pIdx.Columns[nEq])
 
     /* If this loop satisfies a sort order (pOrderBy) request that 
     ** was passed to this function to implement a "SELECT min(x) ..." 
@@ -3506,11 +3604,11 @@ static Bitmask codeOneLoopStart(
     ** of the range. 
     */
     if( pLevel.plan.wsFlags & WHERE_TOP_LIMIT ){
-      pRangeEnd = findTerm(pWC, iCur, k, notReady, (WO_LT|WO_LE), pIdx);
+      pRangeEnd = findIndexTerm(pWC, iCur, pIdx, nEq, notReady, (WO_LT|WO_LE));
       nExtraReg = 1;
     }
     if( pLevel.plan.wsFlags & WHERE_BTM_LIMIT ){
-      pRangeStart = findTerm(pWC, iCur, k, notReady, (WO_GT|WO_GE), pIdx);
+      pRangeStart = findIndexTerm(pWC, iCur, pIdx, nEq, notReady, (WO_GT|WO_GE));
       nExtraReg = 1;
     }
 
@@ -3616,7 +3714,22 @@ static Bitmask codeOneLoopStart(
     /* Seek the table cursor, if required */
     disableTerm(pLevel, pRangeStart);
     disableTerm(pLevel, pRangeEnd);
-    if( !omitTable ){
+    if( !omitTable && !pTabItem.pTab.HasRowid() ){
+      /* Find the row of a WITHOUT ROWID table by the PRIMARY KEY columns of
+      ** the index entry. They follow the indexed columns, except in the
+      ** PRIMARY KEY itself. */
+      nPk := len(pTabItem.pTab.PrimaryKey().Columns)
+      iPkField := len(pIdx.Columns)
+      if pIdx.IsClustered() {
+        iPkField = 0
+      }
+      regPk := pParse.GetTempRange(nPk)
+      for i := 0; i < nPk; i++ {
+        v.AddOp3(OP_Column, iIdxCur, iPkField + i, regPk + i)
+      }
+      sqlite3VdbeAddOp4Int(v, OP_NotFound, iCur, addrCont, regPk, nPk)
+      pParse.ReleaseTempRange(regPk, nPk)
+    }else if( !omitTable ){
       iRowidReg = iReleaseReg = pParse.GetTempReg()
       v.AddOp2(OP_IdxRowid, iIdxCur, iRowidReg);
       sqlite3ExprCacheStore(pParse, iCur, -1, iRowidReg);
@@ -3792,7 +3905,13 @@ static Bitmask codeOneLoopStart(
 	} else
 #endif /* SQLITE_OMIT_OR_OPTIMIZATION */
 
-  {
+  if pTabItem.isRecursive {
+    /* Case 5a: The recursive reference of a WITH RECURSIVE query holds
+    **          a single row in a pseudo-cursor, so there is nothing to
+    **          step through.
+    */
+    pLevel.op = OP_Noop
+  } else {
     /* Case 5:  There is no usable index.  We must do a complete
     **          scan of the entire table.
     */
@@ -4142,7 +4261,8 @@ func (pParse *Parse) WhereBegin(tables *SrcList, WHERE *Expr, ORDER_BY **ExprLis
 
 	//	If the caller is an UPDATE or DELETE statement that is requesting to use a one-pass algorithm, determine if this is appropriate. The one-pass algorithm only works if the WHERE clause constraints the statement to update a single row.
 	assert( Flags & WHERE_ONEPASS_DESIRED == 0 || pWInfo.nLevel == 1 )
-	if Flags & WHERE_ONEPASS_DESIRED != 0 && andFlags & WHERE_UNIQUE != 0 {
+	//	The rows of a WITHOUT ROWID table are always visited through a row key set instead.
+	if Flags & WHERE_ONEPASS_DESIRED != 0 && andFlags & WHERE_UNIQUE != 0 && tables.a[0].pTab.HasRowid() {
 		pWInfo.okOnePass = true
 		pWInfo.a[0].plan.wsFlags &= ~WHERE_IDX_ONLY
 	}
@@ -4168,7 +4288,7 @@ func (pParse *Parse) WhereBegin(tables *SrcList, WHERE *Expr, ORDER_BY **ExprLis
 			} else {
 				pParse.OpenTable(table, pTabItem.iCursor, iDb, OP_OpenRead)
 			}
-			if !pWInfo.okOnePass && table.nCol < BMS {
+			if !pWInfo.okOnePass && table.nCol < BMS && table.HasRowid() {
 				n := 0
 				for b := pTabItem.colUsed; b != 0; b = b >> 1, n++ {}
 				sqlite3VdbeChangeP4(v, v.CurrentAddr() - 1, SQLITE_INT_TO_PTR(n), P4_INT32)
@@ -4298,9 +4418,18 @@ whereBeginError:
 				if pOp.p1 != pLevel.iTabCur {
 					continue
 				}
-				if pOp.opcode == OP_Column {
+				if pOp.opcode == OP_Column && !pTab.HasRowid() {
+					//	The records of a WITHOUT ROWID table hold its columns in storage order
+					if j := pIdx.ColumnField(pTab.TableColumn(pOp.p2)); j >= 0 {
+						pOp.p2 = j
+						pOp.p1 = pLevel.iIdxCur
+					} else {
+						assert( (pLevel.plan.wsFlags & WHERE_IDX_ONLY) == 0 )
+					}
+				} else if pOp.opcode == OP_Column {
+					//	VIRTUAL generated columns are not stored, so a column of the record may not be the column of the table
 					for j, column := range pIdx.Columns {
-						if pOp.p2 == column {
+						if pTab.TableColumn(pOp.p2) == column {
 							pOp.p2 = j
 							pOp.p1 = pLevel.iIdxCur
 							break
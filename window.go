@@ -0,0 +1,1239 @@
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//	This file implements window functions: calls of the form "fname(args) OVER (spec)" and the WINDOW clause of a SELECT.
+//
+//	OVER and WINDOW are not keywords of the grammar, so Parse.Run() hands them to Parse.ParseOver() and Parse.ParseWindowClause()
+//	before the parser sees them, as it does WITH. An OVER clause that follows the ")" of a call is parsed into a Window, which the
+//	grammar attaches to the call when it completes it. A WINDOW clause waits on Parse.aWindowDefn until the grammar completes the
+//	SELECT it belongs to, whose calls that name a window then take its PARTITION BY, ORDER BY and frame. The text of the statement
+//	is left as it was written, so window functions may be used in views and triggers like any other function.
+//
+//	Window functions are computed by WindowRewrite() and CodeWindow(). A SELECT that uses them is rewritten so that its FROM,
+//	WHERE, GROUP BY and HAVING clauses move into a subquery which returns the window-free parts of the result set along with the
+//	arguments, PARTITION BY and ORDER BY terms of each window. For each window, the VDBE sorter orders the rows of the subquery by
+//	its PARTITION BY and ORDER BY terms, and each partition in turn is copied to an ephemeral table. OP_Window reads the partition
+//	from the table and returns the value of the window function for one row at a time, which is stored in the output row of the
+//	ephemeral table that the outer SELECT reads.
+//
+//	An aggregate used as a window function keeps a single accumulator while its frame moves forward along the partition: xStep adds
+//	each row that enters the frame and xInverse removes each row that leaves it, which is always the oldest row in the accumulator.
+//	If the frame moves any other way, the accumulator is started again. An aggregate without xInverse is computed afresh for each
+//	frame.
+
+//	A Window is the OVER clause of a window function call or a window defined by a WINDOW clause.
+type Window struct {
+	zFunc		string				//	Name of the window for a WINDOW clause entry
+	zBase		string				//	Name of the window that this one is based on, or ""
+	pPartition	*ExprList			//	PARTITION BY terms, or nil
+	pOrderBy	*ExprList			//	ORDER BY terms, or nil
+	eFrame		int					//	windowRows or windowRange
+	eStart		int					//	Start of the frame: windowUnboundedPreceding, windowPreceding, ...
+	eEnd		int					//	End of the frame
+	nStart		int64				//	Offset for a windowPreceding or windowFollowing start
+	nEnd		int64				//	Offset for a windowPreceding or windowFollowing end
+	hasFrame	bool				//	True if the frame was given explicitly
+	pFunc		*FuncDef			//	The window function, set by the resolver
+
+	//	The following are set by WindowRewrite(). They locate the inputs of the window within a row of the subquery.
+	iArg		int					//	First argument
+	nArg		int					//	Number of arguments
+	pColl		*CollSeq			//	Collating sequence passed to the function if it is SQLITE_FUNC_NEEDCOLL
+	iPartition	int					//	First PARTITION BY term
+	aPartColl	[]*CollSeq			//	Collating sequence for each PARTITION BY term
+	iOrderBy	int					//	First ORDER BY term
+	aOrderColl	[]*CollSeq			//	Collating sequence for each ORDER BY term
+	aSortOrder	[]byte				//	SQLITE_SO_ASC or SQLITE_SO_DESC for each ORDER BY term
+}
+
+//	Frame types and frame boundaries.
+const (
+	windowRows = iota
+	windowRange
+)
+
+const (
+	windowUnboundedPreceding = iota
+	windowPreceding
+	windowCurrentRow
+	windowFollowing
+	windowUnboundedFollowing
+)
+
+//	A WindowProgram describes the window functions computed over the rows of a subquery. It is attached to the subquery by
+//	WindowRewrite() and coded by CodeWindow().
+type WindowProgram struct {
+	nIn			int					//	Number of columns in each row of the subquery
+	nOut		int					//	Number of columns in each output row
+	nLeaf		int					//	Number of subquery columns copied to the output unchanged
+	Windows		[]*Window			//	The windows, in output column order
+}
+
+//	An OVER clause waiting for the grammar to complete the call it follows.
+type windowOver struct {
+	pWin		*Window
+	zEnd		*byte				//	One character past the end of the clause
+}
+
+//	A WINDOW clause waiting for the grammar to complete the SELECT it belongs to.
+type windowDefn struct {
+	aWin		[]*Window
+	iDepth		int					//	Depth of parentheses at which the clause appeared
+}
+
+//	Return a copy of p. The PARTITION BY and ORDER BY lists are duplicated so that the copy may be resolved on its own.
+func (p *Window) Dup() (pNew *Window) {
+	if p != nil {
+		pNew = new(Window)
+		*pNew = *p
+		pNew.pPartition = p.pPartition.Dup()
+		pNew.pOrderBy = p.pOrderBy.Dup()
+	}
+	return
+}
+
+//	If the identifier at offset iOver of zSql is the OVER clause of a function call, that is, it follows a ")" and is followed by a
+//	window specification or the name of a window, parse the clause into pParse.pOver and return the offset of the text after it.
+//	Return 0 if the identifier is just a name, and also after leaving an error in pParse if the clause is not valid.
+func (pParse *Parse) ParseOver(zSql string, iOver, lastToken int) int {
+	s := newSqlScanner(zSql[iOver:])
+	if lastToken != TK_RP || pParse.pOver != nil || !s.IsWord("OVER") {
+		return 0
+	}
+	if t := *s; !t.Next() || (t.Type != TK_LP && !t.IsName()) {
+		return 0
+	}
+	pWin := new(Window)
+	if s.Next(); s.Type == TK_LP {
+		body, ok := s.Parenthesized()
+		if !ok {
+			pParse.SetErrorMsg("incomplete input")
+			return 0
+		}
+		if !pParse.parseWindowSpec(pWin, body) {
+			return 0
+		}
+	} else {
+		pWin.zBase = Dequote(s.Text)
+	}
+	iEnd := iOver + s.iNext
+	pParse.pOver = &windowOver{ pWin: pWin, zEnd: &zSql[iEnd] }
+	return iEnd
+}
+
+//	If the identifier at offset iWindow of zSql begins a WINDOW clause, parse the clause onto pParse.aWindowDefn and return the offset
+//	of the text after it. Return 0 if the identifier is just a name, and also after leaving an error in pParse if the clause is not
+//	valid.
+func (pParse *Parse) ParseWindowClause(zSql string, iWindow int) int {
+	s := newSqlScanner(zSql[iWindow:])
+	if !s.IsWord("WINDOW") {
+		return 0
+	}
+	if t := *s; !t.Next() || !t.IsName() || !t.Next() || t.Type != TK_AS {
+		return 0
+	}
+	syntaxError := func() int {
+		if s.Type == 0 {
+			pParse.SetErrorMsg("incomplete input")
+		} else {
+			pParse.SetErrorMsg("near \"%v\": syntax error", s.Text)
+		}
+		return 0
+	}
+	var aWin []*Window
+	for {
+		if s.Next(); !s.IsName() {
+			return syntaxError()
+		}
+		pWin := &Window{ zFunc: Dequote(s.Text) }
+		for _, p := range aWin {
+			if CaseInsensitiveMatch(p.zFunc, pWin.zFunc) {
+				pParse.SetErrorMsg("duplicate WINDOW name: %v", pWin.zFunc)
+				return 0
+			}
+		}
+		if s.Next(); s.Type != TK_AS {
+			return syntaxError()
+		}
+		if s.Next(); s.Type != TK_LP {
+			return syntaxError()
+		}
+		body, ok := s.Parenthesized()
+		if !ok {
+			return syntaxError()
+		}
+		if !pParse.parseWindowSpec(pWin, body) {
+			return 0
+		}
+		aWin = append(aWin, pWin)
+		if t := *s; !t.Next() || t.Type != TK_COMMA {
+			break
+		}
+		s.Next()
+	}
+	pParse.aWindowDefn = append(pParse.aWindowDefn, windowDefn{ aWin: aWin, iDepth: pParse.nDepth })
+	return iWindow + s.iNext
+}
+
+//	Called by the grammar for each function call it completes. If an OVER clause followed the call, attach its Window to the call and
+//	extend the span of the call over the clause.
+func (pParse *Parse) attachOver(pSpan *ExprSpan) {
+	if pOver := pParse.pOver; pOver != nil {
+		pParse.pOver = nil
+		if pSpan.Expr != nil {
+			pSpan.Expr.pWin = pOver.pWin
+		}
+		pSpan.zEnd = pOver.zEnd
+	}
+}
+
+//	Called by the grammar for each SELECT it completes. Take the WINDOW clause of p, if it has one, and give each window function call
+//	of p that names a window the PARTITION BY, ORDER BY and frame of that window. Errors are left in pParse.
+func (pParse *Parse) attachWindows(p *Select) {
+	var aDefn []*Window
+	if n := len(pParse.aWindowDefn); n > 0 && pParse.aWindowDefn[n - 1].iDepth == pParse.nDepth {
+		aDefn = pParse.aWindowDefn[n - 1].aWin
+		pParse.aWindowDefn = pParse.aWindowDefn[:n - 1]
+	}
+	if p == nil {
+		return
+	}
+	var apply func(*Expr) bool
+	apply = func(e *Expr) bool {
+		if e == nil {
+			return true
+		}
+		if pWin := e.pWin; pWin != nil && pWin.zBase != "" && !pParse.applyWindow(pWin, aDefn) {
+			return false
+		}
+		if !apply(e.pLeft) || !apply(e.pRight) {
+			return false
+		}
+		if e.pList != nil {
+			for _, item := range e.pList.Items {
+				if !apply(item.Expr) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+	for _, pList := range []*ExprList{ p.pEList, p.pOrderBy } {
+		if pList != nil {
+			for _, item := range pList.Items {
+				if !apply(item.Expr) {
+					return
+				}
+			}
+		}
+	}
+}
+
+//	Complete pWin with the window it names, which must be one of aDefn. Return false after leaving an error in pParse if there is no
+//	such window or pWin overrides a part of it that may not be overridden.
+func (pParse *Parse) applyWindow(pWin *Window, aDefn []*Window) bool {
+	var pBase *Window
+	for _, p := range aDefn {
+		if CaseInsensitiveMatch(p.zFunc, pWin.zBase) {
+			pBase = p
+		}
+	}
+	switch {
+	case pBase == nil:
+		pParse.SetErrorMsg("no such window: %v", pWin.zBase)
+	case pWin.pPartition != nil:
+		pParse.SetErrorMsg("cannot override PARTITION clause of window: %v", pWin.zBase)
+	case pWin.pOrderBy != nil && pBase.pOrderBy != nil:
+		pParse.SetErrorMsg("cannot override ORDER BY clause of window: %v", pWin.zBase)
+	case pBase.hasFrame:
+		pParse.SetErrorMsg("cannot override frame specification of window: %v", pWin.zBase)
+	}
+	if pParse.nErr > 0 {
+		return false
+	}
+	pWin.pPartition = pBase.pPartition.Dup()
+	if pWin.pOrderBy == nil {
+		pWin.pOrderBy = pBase.pOrderBy.Dup()
+	}
+	if !pWin.hasFrame {
+		pWin.eFrame, pWin.eStart, pWin.eEnd = pBase.eFrame, pBase.eStart, pBase.eEnd
+		pWin.nStart, pWin.nEnd = pBase.nStart, pBase.nEnd
+	}
+	pWin.zBase = ""
+	return true
+}
+
+//	Parse the text between the parentheses of an OVER clause or a WINDOW clause entry into pWin. Return false after leaving an error
+//	in pParse if it is not a valid window specification.
+func (pParse *Parse) parseWindowSpec(pWin *Window, body string) bool {
+	pWin.eFrame, pWin.eStart, pWin.eEnd = windowRange, windowUnboundedPreceding, windowCurrentRow
+	s := newSqlScanner(body)
+	syntaxError := func() bool {
+		if s.Type == 0 {
+			pParse.SetErrorMsg("incomplete input")
+		} else {
+			pParse.SetErrorMsg("near \"%v\": syntax error", s.Text)
+		}
+		return false
+	}
+	isFrame := func() bool {
+		return s.Type == TK_ID && (s.IsWord("ROWS") || s.IsWord("RANGE"))
+	}
+
+	//	Return the text of the current clause, which ends at the first of the given words found outside parentheses.
+	clause := func(stop func() bool) string {
+		start := s.Start
+		for depth := 0; s.Type != 0; s.Next() {
+			switch {
+			case s.Type == TK_LP:
+				depth++
+			case s.Type == TK_RP:
+				depth--
+			case depth == 0 && stop():
+				return body[start:s.Start]
+			}
+		}
+		return body[start:]
+	}
+
+	if s.Type == TK_ID && !s.IsWord("PARTITION") && !isFrame() {
+		pWin.zBase = Dequote(s.Text)
+		s.Next()
+	}
+	if s.Type == TK_ID && s.IsWord("PARTITION") {
+		if s.Next(); s.Type != TK_BY {
+			return syntaxError()
+		}
+		s.Next()
+		zText := clause(func() bool { return s.Type == TK_ORDER || isFrame() })
+		pSel := pParse.ParseSelect("SELECT " + zText)
+		if pSel == nil {
+			return false
+		}
+		pWin.pPartition = pSel.pEList
+	}
+	if s.Type == TK_ORDER {
+		if s.Next(); s.Type != TK_BY {
+			return syntaxError()
+		}
+		s.Next()
+		zText := clause(isFrame)
+		pSel := pParse.ParseSelect("SELECT 0 ORDER BY " + zText)
+		if pSel == nil {
+			return false
+		}
+		pWin.pOrderBy = pSel.pOrderBy
+	}
+	if s.Type == 0 {
+		return true
+	}
+	if !isFrame() {
+		return syntaxError()
+	}
+
+	//	Parse "ROWS|RANGE bound" or "ROWS|RANGE BETWEEN bound AND bound".
+	pWin.hasFrame = true
+	if s.IsWord("ROWS") {
+		pWin.eFrame = windowRows
+	}
+	bound := func() (eBound int, n int64, ok bool) {
+		switch {
+		case s.IsWord("UNBOUNDED"):
+			s.Next()
+			switch {
+			case s.IsWord("PRECEDING"):
+				eBound = windowUnboundedPreceding
+			case s.IsWord("FOLLOWING"):
+				eBound = windowUnboundedFollowing
+			default:
+				return
+			}
+		case s.IsWord("CURRENT"):
+			if s.Next(); !s.IsWord("ROW") {
+				return
+			}
+			eBound = windowCurrentRow
+		case s.Type == TK_INTEGER:
+			var err error
+			if n, err = strconv.ParseInt(s.Text, 0, 64); err != nil {
+				return
+			}
+			s.Next()
+			switch {
+			case s.IsWord("PRECEDING"):
+				eBound = windowPreceding
+			case s.IsWord("FOLLOWING"):
+				eBound = windowFollowing
+			default:
+				return
+			}
+		default:
+			return
+		}
+		s.Next()
+		return eBound, n, true
+	}
+	var ok bool
+	if s.Next(); s.Type == TK_BETWEEN {
+		s.Next()
+		if pWin.eStart, pWin.nStart, ok = bound(); !ok {
+			return syntaxError()
+		}
+		if s.Type != TK_AND {
+			return syntaxError()
+		}
+		s.Next()
+		if pWin.eEnd, pWin.nEnd, ok = bound(); !ok {
+			return syntaxError()
+		}
+	} else {
+		if pWin.eStart, pWin.nStart, ok = bound(); !ok {
+			return syntaxError()
+		}
+		pWin.eEnd, pWin.nEnd = windowCurrentRow, 0
+	}
+	if s.Type != 0 {
+		return syntaxError()
+	}
+	if pWin.eStart == windowUnboundedFollowing || pWin.eEnd == windowUnboundedPreceding || pWin.eStart > pWin.eEnd {
+		pParse.SetErrorMsg("unsupported frame specification")
+		return false
+	}
+	if pWin.eFrame == windowRange && (pWin.eStart == windowPreceding || pWin.eStart == windowFollowing || pWin.eEnd == windowPreceding || pWin.eEnd == windowFollowing) {
+		if pWin.pOrderBy == nil || pWin.pOrderBy.Len() != 1 {
+			pParse.SetErrorMsg("RANGE with offset PRECEDING/FOLLOWING requires one ORDER BY expression")
+			return false
+		}
+	}
+	return true
+}
+
+//	Return true if p calls a window function. Subqueries are not searched.
+func (p *Expr) HasWindow() bool {
+	if p == nil {
+		return false
+	}
+	if p.pWin != nil || p.pLeft.HasWindow() || p.pRight.HasWindow() {
+		return true
+	}
+	if p.pList != nil {
+		for _, item := range p.pList.Items {
+			if item.Expr.HasWindow() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//	If the result set or ORDER BY clause of p calls window functions, move the FROM, WHERE, GROUP BY and HAVING clauses of p into a
+//	subquery whose rows the window functions are computed over, and make that subquery the only term of the FROM clause of p. p must
+//	have been resolved. p is left unchanged if it does not use window functions.
+func (pParse *Parse) WindowRewrite(p *Select) (rc int) {
+	db := pParse.db
+	var aWinExpr []*Expr
+	var aWin []*Window
+	var collect func(*Expr)
+	collect = func(e *Expr) {
+		switch {
+		case e == nil:
+		case e.pWin != nil:
+			for _, pWin := range aWin {
+				if pWin == e.pWin {
+					return
+				}
+			}
+			aWin = append(aWin, e.pWin)
+			aWinExpr = append(aWinExpr, e)
+		default:
+			collect(e.pLeft)
+			collect(e.pRight)
+			if e.pList != nil {
+				for _, item := range e.pList.Items {
+					collect(item.Expr)
+				}
+			}
+		}
+	}
+	for _, item := range p.pEList.Items {
+		collect(item.Expr)
+	}
+	if p.pOrderBy != nil {
+		for _, item := range p.pOrderBy.Items {
+			collect(item.Expr)
+		}
+	}
+	if len(aWin) == 0 {
+		return SQLITE_OK
+	}
+
+	pSub := &Select{
+		pEList:			NewExprList(),
+		pSrc:			p.pSrc,
+		Where:			p.Where,
+		pGroupBy:		p.pGroupBy,
+		pHaving:		p.pHaving,
+		selFlags:		p.selFlags & (SF_Expanded | SF_Resolved | SF_HasTypeInfo | SF_Aggregate),
+		addrOpenEphm:	[]int{ -1, -1, -1 },
+	}
+	pTab := &Table{
+		nRef:		1,
+		iPKey:		-1,
+		nRowEst:	1000000,
+		tabFlags:	TF_Ephemeral,
+	}
+	pSrc := db.SrcListAppend(nil, "", "")
+	pItem := &pSrc.a[0]
+	pItem.pTab = pTab
+	pItem.Select = pSub
+	pItem.iCursor = pParse.nTab
+	pItem.isCorrelated = 1
+	pParse.nTab++
+
+	//	Replace each window function call in p with a reference to its output column, and each window-free subtree with a reference to
+	//	a column of the subquery that is copied to the output.
+	nWin := len(aWin)
+	var aLeaf []*Expr
+	var aLeafSpan []string
+	column := func(iCol int, e *Expr) (pNew *Expr) {
+		pNew = sqlite3CreateColumnExpr(db, pSrc, 0, iCol)
+		if e.HasProperty(EP_ExpCollate) {
+			pNew.pColl = e.pColl
+			pNew.flags |= EP_ExpCollate
+		}
+		return
+	}
+	var rewrite func(*Expr, string) *Expr
+	rewrite = func(e *Expr, zSpan string) *Expr {
+		switch {
+		case e == nil:
+			return nil
+		case e.pWin != nil:
+			for i, pWin := range aWin {
+				if pWin == e.pWin {
+					return column(i, e)
+				}
+			}
+		case !e.HasWindow():
+			aLeaf = append(aLeaf, e)
+			aLeafSpan = append(aLeafSpan, zSpan)
+			return column(nWin + len(aLeaf) - 1, e)
+		}
+		e.pLeft = rewrite(e.pLeft, "")
+		e.pRight = rewrite(e.pRight, "")
+		if e.pList != nil {
+			for _, item := range e.pList.Items {
+				item.Expr = rewrite(item.Expr, "")
+			}
+		}
+		return e
+	}
+	for _, item := range p.pEList.Items {
+		item.Expr = rewrite(item.Expr, item.zSpan)
+	}
+	if p.pOrderBy != nil {
+		for _, item := range p.pOrderBy.Items {
+			item.Expr = rewrite(item.Expr, "")
+		}
+	}
+	if len(aLeaf) == 0 {
+		aLeaf = append(aLeaf, sqlite3ExprAlloc(db, TK_NULL, 0, 0))
+		aLeafSpan = append(aLeafSpan, "")
+	}
+
+	//	The subquery returns the leaves followed by the arguments, PARTITION BY and ORDER BY terms of each window.
+	collSeq := func(e *Expr) (pColl *CollSeq) {
+		if pColl = sqlite3ExprCollSeq(pParse, e); pColl == nil {
+			pColl = db.pDfltColl
+		}
+		return
+	}
+	pEList := pSub.pEList
+	for i, e := range aLeaf {
+		pEList.Items = append(pEList.Items, &ExprList_item{ Expr: e, zSpan: aLeafSpan[i] })
+	}
+	for i, pWin := range aWin {
+		e := aWinExpr[i]
+		pWin.iArg = pEList.Len()
+		if e.pList != nil {
+			for _, item := range e.pList.Items {
+				pEList.Items = append(pEList.Items, &ExprList_item{ Expr: item.Expr })
+			}
+			pWin.nArg = e.pList.Len()
+		}
+		if pWin.nArg > 0 && pWin.pFunc != nil && pWin.pFunc.flags & SQLITE_FUNC_NEEDCOLL != 0 {
+			pWin.pColl = collSeq(e.pList.Items[0].Expr)
+		}
+		pWin.iPartition = pEList.Len()
+		pWin.aPartColl = nil
+		if pWin.pPartition != nil {
+			for _, item := range pWin.pPartition.Items {
+				pEList.Items = append(pEList.Items, &ExprList_item{ Expr: item.Expr })
+				pWin.aPartColl = append(pWin.aPartColl, collSeq(item.Expr))
+			}
+		}
+		pWin.iOrderBy = pEList.Len()
+		pWin.aOrderColl = nil
+		pWin.aSortOrder = nil
+		if pWin.pOrderBy != nil {
+			for _, item := range pWin.pOrderBy.Items {
+				pEList.Items = append(pEList.Items, &ExprList_item{ Expr: item.Expr })
+				pWin.aOrderColl = append(pWin.aOrderColl, collSeq(item.Expr))
+				pWin.aSortOrder = append(pWin.aSortOrder, item.sortOrder)
+			}
+		}
+	}
+
+	//	Describe the output rows: the result of each window followed by the leaves.
+	pOut := NewExprList()
+	for _, e := range aWinExpr {
+		pOut.Items = append(pOut.Items, &ExprList_item{ Expr: e })
+	}
+	for i, e := range aLeaf {
+		pOut.Items = append(pOut.Items, &ExprList_item{ Expr: e, zSpan: aLeafSpan[i] })
+	}
+	selectColumnsFromExprList(pParse, pOut, &pTab.nCol, &pTab.Columns)
+	selectAddColumnTypeAndCollation(pParse, pTab.nCol, pTab.Columns, &Select{ pEList: pOut, pSrc: pSub.pSrc, selFlags: SF_Resolved })
+
+	p.pSrc = pSrc
+	p.Where = nil
+	p.pGroupBy = nil
+	p.pHaving = nil
+	p.selFlags &= ~SF_Aggregate
+	pSub.pWinProgram = &WindowProgram{
+		nIn:		pEList.Len(),
+		nOut:		nWin + len(aLeaf),
+		nLeaf:		len(aLeaf),
+		Windows:	aWin,
+	}
+	if pParse.nErr > 0 {
+		return SQLITE_ERROR
+	}
+	return SQLITE_OK
+}
+
+//	Generate code that reads every row of the ephemeral table on cursor iIn, which holds the rows of a subquery with window program
+//	pProgram, computes the window functions and writes the output rows to a new ephemeral table on cursor iOut. The output rows are
+//	written in the order of the first window.
+func (pParse *Parse) CodeWindow(pProgram *WindowProgram, iIn, iOut int) {
+	v := pParse.GetVdbe()
+	nWin := len(pProgram.Windows)
+	iTmp := pParse.nTab
+	pParse.nTab++
+	regOut := pParse.nMem + 1
+	pParse.nMem += pProgram.nOut
+	v.AddOp2(OP_OpenEphemeral, iOut, pProgram.nOut)
+	v.AddOp2(OP_OpenEphemeral, iTmp, pProgram.nOut)
+
+	//	Copy the leaves of each row of the subquery to a row of iTmp with the same rowid, leaving the window results NULL.
+	r1 := pParse.GetTempReg()
+	r2 := pParse.GetTempReg()
+	addrEmpty := v.AddOp2(OP_Rewind, iIn, 0)
+	addrTop := v.CurrentAddr()
+	v.AddOp3(OP_Null, 0, regOut, regOut + nWin - 1)
+	for i := 0; i < pProgram.nLeaf; i++ {
+		v.AddOp3(OP_Column, iIn, i, regOut + nWin + i)
+	}
+	v.AddOp3(OP_MakeRecord, regOut, pProgram.nOut, r1)
+	v.AddOp2(OP_Rowid, iIn, r2)
+	v.AddOp3(OP_Insert, iTmp, r1, r2)
+	v.ChangeP5(OPFLAG_APPEND)
+	v.AddOp2(OP_Next, iIn, addrTop)
+	v.JumpHere(addrEmpty)
+	pParse.ReleaseTempReg(r2)
+	pParse.ReleaseTempReg(r1)
+
+	//	Each window but the first fills in its results in place. The first moves the completed rows to iOut.
+	for iWin := nWin - 1; iWin >= 0; iWin-- {
+		iDest := iTmp
+		if iWin == 0 {
+			iDest = iOut
+		}
+		pParse.codeWindowFunction(pProgram, iWin, iIn, iTmp, iDest, regOut)
+	}
+	v.AddOp1(OP_Close, iTmp)
+	v.AddOp1(OP_Close, iIn)
+}
+
+//	Generate code that computes window iWin of pProgram over the rows of the subquery on cursor iIn and stores the result for each row
+//	in column iWin of the row of iTmp with the same rowid. If iDest is not iTmp, the row is appended to iDest instead. regOut is the
+//	first of pProgram.nOut registers to build rows in.
+//
+//	The sorter orders the rows by the PARTITION BY terms, the ORDER BY terms and the rowid, followed by the arguments of the window.
+//	Each partition is copied to an ephemeral table in which the rows are numbered from 1, and the subroutine at addrFlush runs
+//	OP_Window over the table once the partition is complete.
+func (pParse *Parse) codeWindowFunction(pProgram *WindowProgram, iWin, iIn, iTmp, iDest, regOut int) {
+	v := pParse.pVdbe
+	db := pParse.db
+	pWin := pProgram.Windows[iWin]
+	nPart := len(pWin.aPartColl)
+	nOrder := len(pWin.aOrderColl)
+	nKey := nPart + nOrder + 1
+	nCol := nKey + pWin.nArg
+
+	iSort := pParse.nTab
+	iSorted := iSort + 1
+	iPart := iSort + 2
+	pParse.nTab += 3
+	regReturn := pParse.nMem + 1
+	regState := regReturn + 1
+	regRow := regState + 3
+	regSorted := regRow + nCol
+	regHave := regSorted + 1
+	regPrev := regHave + 1
+	pParse.nMem += 6 + nCol + 2 * nPart
+	regKey := regPrev + nPart
+
+	sqlite3VdbeAddOp4(v, OP_SorterOpen, iSort, 0, 0, (char*)pWin.keyInfo(db, false), P4_KEYINFO_HANDOFF)
+	v.AddOp2(OP_OpenEphemeral, iPart, nCol)
+	v.AddOp2(OP_Null, 0, regState)
+	v.AddOp2(OP_Integer, 0, regHave)
+
+	//	Sort the rows of the subquery.
+	r1 := pParse.GetTempReg()
+	addrEmpty := v.AddOp2(OP_Rewind, iIn, 0)
+	addrTop := v.CurrentAddr()
+	for i := 0; i < nPart; i++ {
+		v.AddOp3(OP_Column, iIn, pWin.iPartition + i, regRow + i)
+	}
+	for i := 0; i < nOrder; i++ {
+		v.AddOp3(OP_Column, iIn, pWin.iOrderBy + i, regRow + nPart + i)
+	}
+	v.AddOp2(OP_Rowid, iIn, regRow + nKey - 1)
+	for i := 0; i < pWin.nArg; i++ {
+		v.AddOp3(OP_Column, iIn, pWin.iArg + i, regRow + nKey + i)
+	}
+	v.AddOp3(OP_MakeRecord, regRow, nCol, r1)
+	v.AddOp2(OP_SorterInsert, iSort, r1)
+	v.AddOp2(OP_Next, iIn, addrTop)
+	v.JumpHere(addrEmpty)
+	pParse.ReleaseTempReg(r1)
+
+	//	Copy the sorted rows to iPart, flushing the partition each time the PARTITION BY terms change.
+	addrBreak := v.MakeLabel()
+	addrFlush := v.MakeLabel()
+	addrEnd := v.MakeLabel()
+	v.AddOp3(OP_OpenPseudo, iSorted, regSorted, nCol)
+	addrLoop := 1 + v.AddOp2(OP_SorterSort, iSort, addrBreak)
+	v.AddOp2(OP_SorterData, iSort, regSorted)
+	if nPart > 0 {
+		addrSame := v.MakeLabel()
+		for i := 0; i < nPart; i++ {
+			v.AddOp3(OP_Column, iSorted, i, regKey + i)
+			if i == 0 {
+				v.ChangeP5(OPFLAG_CLEARCACHE)
+			}
+		}
+		j1 := v.AddOp1(OP_IfNot, regHave)
+		j2 := sqlite3VdbeAddOp4(v, OP_Compare, regKey, regPrev, nPart, (char*)pWin.keyInfo(db, true), P4_KEYINFO_HANDOFF)
+		v.AddOp3(OP_Jump, j2 + 2, addrSame, j2 + 2)
+		v.AddOp2(OP_Gosub, regReturn, addrFlush)
+		v.JumpHere(j1)
+		sqlite3ExprCodeCopy(pParse, regKey, regPrev, nPart)
+		v.AddOp2(OP_Integer, 1, regHave)
+		v.ResolveLabel(addrSame)
+	}
+	r1 = pParse.GetTempReg()
+	v.AddOp2(OP_NewRowid, iPart, r1)
+	v.AddOp3(OP_Insert, iPart, regSorted, r1)
+	v.ChangeP5(OPFLAG_APPEND)
+	pParse.ReleaseTempReg(r1)
+	v.AddOp2(OP_SorterNext, iSort, addrLoop)
+	v.ResolveLabel(addrBreak)
+	v.AddOp2(OP_Gosub, regReturn, addrFlush)
+	v.AddOp2(OP_Goto, 0, addrEnd)
+
+	//	The flush subroutine. OP_Window returns the rowid of each row of the partition in regState+1 and its result in regState+2.
+	v.ResolveLabel(addrFlush)
+	addrWindow := sqlite3VdbeAddOp4(v, OP_Window, iPart, 0, regState, (char*)pWin, P4_WINDOW)
+	v.AddOp3(OP_NotExists, iTmp, addrWindow, regState + 1)
+	for i := 0; i < pProgram.nOut; i++ {
+		if i != iWin {
+			v.AddOp3(OP_Column, iTmp, i, regOut + i)
+		}
+	}
+	v.AddOp2(OP_SCopy, regState + 2, regOut + iWin)
+	r1 = pParse.GetTempReg()
+	r2 := pParse.GetTempReg()
+	v.AddOp3(OP_MakeRecord, regOut, pProgram.nOut, r1)
+	if iDest == iTmp {
+		v.AddOp3(OP_Insert, iTmp, r1, regState + 1)
+	} else {
+		v.AddOp2(OP_NewRowid, iDest, r2)
+		v.AddOp3(OP_Insert, iDest, r1, r2)
+		v.ChangeP5(OPFLAG_APPEND)
+	}
+	pParse.ReleaseTempReg(r2)
+	pParse.ReleaseTempReg(r1)
+	v.AddOp2(OP_Goto, 0, addrWindow)
+	v.JumpHere(addrWindow)
+
+	//	Opening iPart again empties it for the next partition.
+	v.AddOp2(OP_OpenEphemeral, iPart, nCol)
+	v.AddOp1(OP_Return, regReturn)
+
+	v.ResolveLabel(addrEnd)
+	v.AddOp1(OP_Close, iSorted)
+	v.AddOp1(OP_Close, iSort)
+	v.AddOp1(OP_Close, iPart)
+}
+
+//	Return the KeyInfo that compares the PARTITION BY terms of pWin if bPartition is true, or else the KeyInfo that sorts the rows of
+//	pWin by their PARTITION BY terms, ORDER BY terms and rowid.
+func (pWin *Window) keyInfo(db *sqlite3, bPartition bool) (pInfo *KeyInfo) {
+	pInfo = &KeyInfo{ db: db, enc: db.Encoding() }
+	pInfo.Collations = append(pInfo.Collations, pWin.aPartColl...)
+	pInfo.aSortOrder = make([]byte, len(pWin.aPartColl))
+	if !bPartition {
+		pInfo.Collations = append(pInfo.Collations, pWin.aOrderColl...)
+		pInfo.aSortOrder = append(pInfo.aSortOrder, pWin.aSortOrder...)
+		pInfo.Collations = append(pInfo.Collations, db.pDfltColl)
+		pInfo.aSortOrder = append(pInfo.aSortOrder, SQLITE_SO_ASC)
+	}
+	pInfo.nField = uint16(len(pInfo.Collations))
+	return
+}
+
+//	A windowState computes a window function over one partition, which OP_Window reads from an ephemeral table on the cursor pCsr
+//	whose rows are numbered from 1 in the order of the window. Each record holds the PARTITION BY terms, the ORDER BY terms, the
+//	rowid of the output row and the arguments of the function. Only the two rows read most recently are kept in memory. The state is
+//	stored in the Value of the state register of OP_Window.
+type windowState struct {
+	db			*sqlite3
+	pWin		*Window
+	zName		string				//	Name of a built-in window function in lower case, or "" for an aggregate
+	pCsr		*VdbeCursor			//	Cursor on the partition
+	pKeyInfo	*KeyInfo			//	Describes the records of the partition
+	iOrderBy	int					//	Field of the first ORDER BY term in a record
+	iRowid		int					//	Field of the rowid of the output row
+	iArg		int					//	Field of the first argument
+	nRow		int					//	Number of rows in the partition
+	rc			int					//	First error reading the partition
+	aRow		[2]windowRow		//	Rows read most recently
+	iLru		int					//	Index in aRow of the row to replace next
+
+	iRow		int					//	Index of the next row to return
+	iPeer		int					//	Index of the first row of the peer group of the current row
+	iPeerEnd	int					//	Index after the last row of the peer group of the current row
+	nGroup		int					//	Number of peer groups up to and including that of the current row
+	iKeyLo		int					//	Index of the first row whose first ORDER BY term is not NULL, for RANGE frames
+	iKeyHi		int					//	Index after the last row whose first ORDER BY term is not NULL
+	hasKeyRange	bool				//	True once iKeyLo and iKeyHi have been set
+
+	acc			Mem					//	Accumulator of an aggregate with xInverse
+	hasAcc		bool				//	True if acc has been stepped and must be finalized
+	value		Mem					//	Value of an aggregate without xInverse over the frame iAccStart..iAccEnd
+	hasValue	bool				//	True if value has been computed
+	iAccStart	int					//	First row in the accumulator or in value
+	iAccEnd		int					//	Index after the last row in the accumulator or in value
+}
+
+//	A windowRow is a row of a partition read by windowState.row().
+type windowRow struct {
+	iRow		int					//	Index of the row in the partition, or -1
+	m			Mem					//	The record
+	rec			UnpackedRecord		//	The fields of the record
+}
+
+//	Return the state of window pWin over the partition on cursor pCsr.
+func newWindowState(db *sqlite3, pWin *Window, pCsr *VdbeCursor) (p *windowState) {
+	nPart := len(pWin.aPartColl)
+	nCol := nPart + len(pWin.aOrderColl) + 1 + pWin.nArg
+	p = &windowState{
+		db:			db,
+		pWin:		pWin,
+		pCsr:		pCsr,
+		pKeyInfo:	&KeyInfo{ db: db, enc: db.Encoding(), nField: uint16(nCol) },
+		iOrderBy:	nPart,
+		iRowid:		nPart + len(pWin.aOrderColl),
+		iArg:		nPart + len(pWin.aOrderColl) + 1,
+		acc:		Mem{ flags: MEM_Null, db: db },
+		value:		Mem{ flags: MEM_Null, db: db },
+	}
+	if pFunc := pWin.pFunc; pFunc != nil && pFunc.flags & SQLITE_FUNC_WINDOW != 0 {
+		p.zName = strings.ToLower(pFunc.Name)
+	}
+	for i := range p.aRow {
+		p.aRow[i] = windowRow{ iRow: -1, m: Mem{ flags: MEM_Null, db: db } }
+		p.aRow[i].rec = UnpackedRecord{ KeyInfo: p.pKeyInfo, Values: make([]Mem, nCol) }
+	}
+
+	//	The rows are numbered from 1, so the rowid of the last row is the number of rows.
+	var res int
+	if p.rc = sqlite3BtreeLast(pCsr.pCursor, &res); p.rc == SQLITE_OK && res == 0 {
+		var nKey int64
+		sqlite3BtreeKeySize(pCsr.pCursor, &nKey)
+		p.nRow = int(nKey)
+	}
+	pCsr.cacheStatus = CACHE_STALE
+	return
+}
+
+//	Return the fields of row i of the partition. If the row cannot be read, the error is saved in p.rc and NULL fields are returned.
+//	The fields remain valid until two other rows have been read.
+func (p *windowState) row(i int) []Mem {
+	for k := range p.aRow {
+		if p.aRow[k].iRow == i {
+			p.iLru = 1 - k
+			return p.aRow[k].rec.Values
+		}
+	}
+	r := &p.aRow[p.iLru]
+	p.iLru = 1 - p.iLru
+	r.iRow = -1
+	r.m.Release()
+	for k := range r.rec.Values {
+		r.rec.Values[k].SetNull()
+	}
+	if p.rc != SQLITE_OK {
+		return r.rec.Values
+	}
+
+	pCrsr := p.pCsr.pCursor
+	res, rc := pCrsr.BtreeMovetoUnpacked(nil, int64(i + 1), false)
+	p.pCsr.cacheStatus = CACHE_STALE
+	if rc == SQLITE_OK && res != 0 {
+		rc = SQLITE_CORRUPT_BKPT
+	}
+	if rc == SQLITE_OK {
+		var n uint32
+		sqlite3BtreeDataSize(pCrsr, &n)
+		if rc = sqlite3VdbeMemFromBtree(pCrsr, 0, int(n), 0, &r.m); rc == SQLITE_OK {
+			//	The record must outlive the position of the cursor.
+			rc = sqlite3VdbeMemMakeWriteable(&r.m)
+		}
+	}
+	if rc != SQLITE_OK {
+		p.rc = rc
+		return r.rec.Values
+	}
+	p.pKeyInfo.RecordUnpack(r.m.n, r.m.z, &r.rec)
+	r.iRow = i
+	return r.rec.Values
+}
+
+//	Return true if rows i and j of the partition are peers, that is, their ORDER BY terms are equal.
+func (p *windowState) peer(i, j int) bool {
+	a, b := p.row(i), p.row(j)
+	for k, pColl := range p.pWin.aOrderColl {
+		if sqlite3MemCompare(&a[p.iOrderBy + k], &b[p.iOrderBy + k], pColl) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+//	Store the rowid of the output row of the next row of the partition in pRowid and the value of the window function for it in
+//	pValue. done is true if every row has been returned, in which case the state is released.
+func (p *windowState) Next(pRowid, pValue *Mem) (done bool, rc int, zErr string) {
+	if p.rc == SQLITE_OK && p.iRow < p.nRow {
+		i := p.iRow
+		if i >= p.iPeerEnd {
+			p.iPeer = i
+			p.nGroup++
+			if len(p.pWin.aOrderColl) == 0 {
+				p.iPeerEnd = p.nRow
+			} else {
+				for p.iPeerEnd = i + 1; p.iPeerEnd < p.nRow && p.peer(i, p.iPeerEnd); p.iPeerEnd++ {}
+			}
+		}
+		pRowid.SetInt64(p.row(i)[p.iRowid].IntValue())
+		pValue.SetNull()
+		if p.zName != "" {
+			rc, zErr = p.compute(pValue)
+		} else {
+			rc, zErr = p.computeAggregate(pValue)
+		}
+		p.iRow++
+		if rc == SQLITE_OK && p.rc == SQLITE_OK {
+			return false, SQLITE_OK, ""
+		}
+	}
+	if rc == SQLITE_OK && p.rc != SQLITE_OK {
+		rc, zErr = p.rc, sqlite3ErrStr(p.rc)
+	}
+	p.release()
+	return true, rc, zErr
+}
+
+//	Release the accumulator and the rows held by the state.
+func (p *windowState) release() {
+	if p.hasAcc {
+		p.acc.Finalize(p.pWin.pFunc)
+		p.hasAcc = false
+	}
+	p.acc.Release()
+	p.value.Release()
+	p.hasValue = false
+	for k := range p.aRow {
+		p.aRow[k].iRow = -1
+		p.aRow[k].m.Release()
+	}
+}
+
+//	Compute the built-in window function p.zName for the current row.
+func (p *windowState) compute(pOut *Mem) (rc int, zErr string) {
+	pWin := p.pWin
+	i := p.iRow
+	arg := func(j, iArg int) *Mem {
+		return &p.row(j)[p.iArg + iArg]
+	}
+	positiveArg := func(iArg int) (n int64, ok bool) {
+		pArg := arg(i, iArg)
+		if pArg.flags & MEM_Int == 0 {
+			if pArg.flags & MEM_Real == 0 || pArg.RealValue() != float64(pArg.IntValue()) {
+				return 0, false
+			}
+		}
+		n = pArg.IntValue()
+		return n, n > 0
+	}
+	switch p.zName {
+	case "row_number":
+		pOut.SetInt64(int64(i + 1))
+	case "rank":
+		pOut.SetInt64(int64(p.iPeer + 1))
+	case "dense_rank":
+		pOut.SetInt64(int64(p.nGroup))
+	case "percent_rank":
+		if p.nRow > 1 {
+			pOut.SetFloat64(float64(p.iPeer) / float64(p.nRow - 1))
+		} else {
+			pOut.SetFloat64(0)
+		}
+	case "cume_dist":
+		pOut.SetFloat64(float64(p.iPeerEnd) / float64(p.nRow))
+	case "ntile":
+		n, ok := positiveArg(0)
+		if !ok {
+			return SQLITE_ERROR, "argument of ntile must be a positive integer"
+		}
+		nSize := int64(p.nRow) / n
+		nLarge := int64(p.nRow) % n
+		if iRow := int64(i); nSize == 0 {
+			pOut.SetInt64(iRow + 1)
+		} else if iRow < nLarge * (nSize + 1) {
+			pOut.SetInt64(iRow / (nSize + 1) + 1)
+		} else {
+			pOut.SetInt64((iRow - nLarge * (nSize + 1)) / nSize + nLarge + 1)
+		}
+	case "lag", "lead":
+		nOffset := int64(1)
+		if pWin.nArg > 1 {
+			nOffset = arg(i, 1).IntValue()
+		}
+		if p.zName == "lag" {
+			nOffset = -nOffset
+		}
+		if j := int64(i) + nOffset; j >= 0 && j < int64(p.nRow) {
+			sqlite3VdbeMemCopy(pOut, arg(int(j), 0))
+		} else if pWin.nArg > 2 {
+			sqlite3VdbeMemCopy(pOut, arg(i, 2))
+		}
+	case "first_value", "last_value", "nth_value":
+		iStart, iEnd := p.frame(i)
+		j := -1
+		switch p.zName {
+		case "first_value":
+			j = iStart
+		case "last_value":
+			j = iEnd - 1
+		case "nth_value":
+			n, ok := positiveArg(1)
+			if !ok {
+				return SQLITE_ERROR, "second argument to nth_value must be a positive integer"
+			}
+			if n <= int64(iEnd - iStart) {
+				j = iStart + int(n) - 1
+			}
+		}
+		if j >= iStart && j < iEnd {
+			sqlite3VdbeMemCopy(pOut, arg(j, 0))
+		}
+	}
+	return SQLITE_OK, ""
+}
+
+//	Call xStep or xInverse of the aggregate with the arguments of row i of the partition.
+func (p *windowState) step(acc *Mem, i int, xStep func(*sqlite3_context, int, []*sqlite3_value)) (rc int, zErr string) {
+	pWin := p.pWin
+	aRow := p.row(i)
+	apArg := make([]*Mem, pWin.nArg)
+	for k := range apArg {
+		apArg[k] = &aRow[p.iArg + k]
+	}
+	ctx := sqlite3_context{ pFunc: pWin.pFunc, pMem: acc, pColl: pWin.pColl }
+	ctx.s.flags = MEM_Null
+	ctx.s.db = p.db
+	acc.n++
+	xStep(&ctx, len(apArg), apArg)
+	if ctx.isError != 0 {
+		rc, zErr = ctx.isError, fmt.Sprintf("%v", sqlite3_value_text(&ctx.s))
+	}
+	ctx.s.Release()
+	return
+}
+
+//	Compute an aggregate window function for the current row. If the function has xValue and xInverse methods, a single
+//	accumulator follows the frame along the partition: rows entering the frame are added with xStep and rows leaving it are removed
+//	with xInverse, oldest first. Otherwise the aggregate is computed afresh whenever the frame changes.
+func (p *windowState) computeAggregate(pOut *Mem) (rc int, zErr string) {
+	pFunc := p.pWin.pFunc
+	iStart, iEnd := p.frame(p.iRow)
+	if pFunc.xValue != nil && pFunc.xInverse != nil {
+		if !p.hasAcc || iStart < p.iAccStart || iEnd < p.iAccEnd || iStart > p.iAccEnd {
+			//	The frame moved backwards or past every row in the accumulator. Start again with a new one.
+			if p.hasAcc {
+				p.acc.Finalize(pFunc)
+			}
+			p.acc.Release()
+			p.acc = Mem{ flags: MEM_Null, db: p.db }
+			p.hasAcc = true
+			p.iAccStart, p.iAccEnd = iStart, iStart
+		}
+		for ; p.iAccEnd < iEnd; p.iAccEnd++ {
+			if rc, zErr = p.step(&p.acc, p.iAccEnd, pFunc.xStep); rc != SQLITE_OK {
+				return
+			}
+		}
+		for ; p.iAccStart < iStart; p.iAccStart++ {
+			if rc, zErr = p.step(&p.acc, p.iAccStart, pFunc.xInverse); rc != SQLITE_OK {
+				return
+			}
+		}
+		ctx := sqlite3_context{ pFunc: pFunc, pMem: &p.acc, pColl: p.pWin.pColl }
+		ctx.s.flags = MEM_Null
+		ctx.s.db = p.db
+		pFunc.xValue(&ctx)
+		if ctx.isError != 0 {
+			rc, zErr = ctx.isError, fmt.Sprintf("%v", sqlite3_value_text(&ctx.s))
+		} else {
+			sqlite3VdbeMemCopy(pOut, &ctx.s)
+		}
+		ctx.s.Release()
+		return
+	}
+
+	if !p.hasValue || iStart != p.iAccStart || iEnd != p.iAccEnd {
+		acc := Mem{ flags: MEM_Null, db: p.db }
+		for j := iStart; j < iEnd; j++ {
+			if rc, zErr = p.step(&acc, j, pFunc.xStep); rc != SQLITE_OK {
+				acc.Finalize(pFunc)
+				acc.Release()
+				return
+			}
+		}
+		if rc = acc.Finalize(pFunc); rc != SQLITE_OK {
+			zErr = fmt.Sprintf("%v", sqlite3_value_text(&acc))
+			acc.Release()
+			return
+		}
+		p.value.Release()
+		p.value = acc
+		p.hasValue = true
+		p.iAccStart, p.iAccEnd = iStart, iEnd
+	}
+	sqlite3VdbeMemCopy(pOut, &p.value)
+	return SQLITE_OK, ""
+}
+
+//	Return the frame of row i of the partition as the range [iStart, iEnd) of row indexes. Row i must be the current row.
+func (p *windowState) frame(i int) (iStart, iEnd int) {
+	pWin := p.pWin
+	nRow := p.nRow
+	if pWin.eFrame == windowRows {
+		bound := func(eBound int, n int64, bEnd bool) int {
+			var j int64
+			switch eBound {
+			case windowUnboundedPreceding:
+				return 0
+			case windowUnboundedFollowing:
+				return nRow
+			case windowPreceding:
+				j = int64(i) - n
+			case windowFollowing:
+				j = int64(i) + n
+			default:
+				j = int64(i)
+			}
+			if bEnd {
+				j++
+			}
+			switch {
+			case j < 0:
+				return 0
+			case j > int64(nRow):
+				return nRow
+			}
+			return int(j)
+		}
+		iStart, iEnd = bound(pWin.eStart, pWin.nStart, false), bound(pWin.eEnd, pWin.nEnd, true)
+	} else {
+		//	For RANGE frames with an offset, find the rows whose ORDER BY value is within the offset of the value of row i. The values
+		//	are compared as numbers, negated for a descending ORDER BY so that they ascend along the partition. Rows with a NULL value
+		//	are peers of each other and are never within an offset of a non-NULL value.
+		sign := 1.0
+		if len(pWin.aSortOrder) > 0 && pWin.aSortOrder[0] == SQLITE_SO_DESC {
+			sign = -1.0
+		}
+		key := func(j int) (float64, bool) {
+			pKey := &p.row(j)[p.iOrderBy]
+			return sign * pKey.RealValue(), pKey.flags & MEM_Null == 0
+		}
+		bound := func(eBound int, n int64, bEnd bool) int {
+			switch eBound {
+			case windowUnboundedPreceding:
+				return 0
+			case windowUnboundedFollowing:
+				return nRow
+			case windowCurrentRow:
+				if bEnd {
+					return p.iPeerEnd
+				}
+				return p.iPeer
+			}
+			v, ok := key(i)
+			if !ok {
+				if bEnd {
+					return p.iPeerEnd
+				}
+				return p.iPeer
+			}
+			if eBound == windowPreceding {
+				v -= float64(n)
+			} else {
+				v += float64(n)
+			}
+			//	NULLs sort first in ascending order and last in descending order.
+			if !p.hasKeyRange {
+				p.hasKeyRange = true
+				for p.iKeyLo = 0; p.iKeyLo < nRow; p.iKeyLo++ {
+					if _, ok := key(p.iKeyLo); ok {
+						break
+					}
+				}
+				for p.iKeyHi = nRow; p.iKeyHi > p.iKeyLo; p.iKeyHi-- {
+					if _, ok := key(p.iKeyHi - 1); ok {
+						break
+					}
+				}
+			}
+			return p.iKeyLo + sort.Search(p.iKeyHi - p.iKeyLo, func(j int) bool {
+				k, _ := key(p.iKeyLo + j)
+				if bEnd {
+					return k > v
+				}
+				return k >= v
+			})
+		}
+		iStart, iEnd = bound(pWin.eStart, pWin.nStart, false), bound(pWin.eEnd, pWin.nEnd, true)
+	}
+	if iEnd < iStart {
+		iEnd = iStart
+	}
+	return
+}
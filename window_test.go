@@ -0,0 +1,45 @@
+import "testing"
+
+func TestWindow(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE s(grp, v); INSERT INTO s VALUES('a', 1), ('a', 2), ('a', 2), ('b', 5), ('b', 7)")
+	testExec(t, db, "CREATE TABLE n(x); INSERT INTO n VALUES(1), (2), (3), (4), (5)")
+
+	testQueryIs(t, db, "1|1|1|1\n2|2|2|2\n2|3|2|2\n5|4|4|3\n7|5|5|4",
+		"SELECT v, row_number() OVER w, rank() OVER w, dense_rank() OVER w FROM s WINDOW w AS (ORDER BY v) ORDER BY v, 2")
+	testQueryIs(t, db, "1|NULL|3|1|1\n2|1|4|1|1\n3|2|5|1|1\n4|3|0|1|2\n5|4|0|1|2",
+		"SELECT x, lag(x) OVER w, lead(x, 2, 0) OVER w, first_value(x) OVER w, ntile(2) OVER w FROM n WINDOW w AS (ORDER BY x) ORDER BY x")
+	testQueryIs(t, db, "1|0|0.2\n2|0.25|0.4\n3|0.5|0.6\n4|0.75|0.8\n5|1|1",
+		"SELECT x, percent_rank() OVER (ORDER BY x), cume_dist() OVER (ORDER BY x) FROM n ORDER BY x")
+
+	//	Aggregates over partitions and frames.
+	testQueryIs(t, db, "a|1|5\na|2|5\na|2|5\nb|5|12\nb|7|12", "SELECT grp, v, sum(v) OVER (PARTITION BY grp) FROM s ORDER BY grp, v")
+	testQueryIs(t, db, "1|1\n2|5\n2|5\n5|10\n7|17", "SELECT v, sum(v) OVER (ORDER BY v) FROM s ORDER BY v")
+	testQueryIs(t, db, "1|3\n2|5\n2|9\n5|14\n7|12",
+		"SELECT v, sum(v) OVER (ORDER BY v ROWS BETWEEN 1 PRECEDING AND 1 FOLLOWING) FROM s ORDER BY v, 2")
+	testQueryIs(t, db, "1|1|5\n2|1|5\n3|1|5\n4|2|5\n5|3|5",
+		"SELECT x, min(x) OVER (ORDER BY x ROWS 2 PRECEDING), max(x) OVER (ORDER BY x ROWS BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING) FROM n ORDER BY x")
+
+	//	A window based on a named window, and windows over the groups of an aggregate query.
+	testQueryIs(t, db, "a|1|1\na|2|3\na|2|3\nb|5|1\nb|7|2",
+		"SELECT grp, v, count(*) OVER (w ORDER BY v) FROM s WINDOW w AS (PARTITION BY grp) ORDER BY grp, v")
+	testQueryIs(t, db, "b|12|1\na|5|2", "SELECT grp, sum(v), rank() OVER (ORDER BY sum(v) DESC) FROM s GROUP BY grp ORDER BY 3")
+
+	testExec(t, db, "CREATE VIEW ranked AS SELECT v, row_number() OVER (ORDER BY v DESC) AS r FROM s")
+	testQueryIs(t, db, "7", "SELECT v FROM ranked WHERE r = 1")
+}
+
+func TestWindowErrors(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE s(v)")
+	for _, query := range []string{
+		"SELECT v FROM s WHERE row_number() OVER () > 1",
+		"SELECT row_number() FROM s",
+		"SELECT sum(v) OVER missing FROM s",
+		"SELECT ntile(0) OVER () FROM (SELECT 1)",
+	} {
+		if _, err := db.Exec(query); err == nil {
+			t.Errorf("%v: no error", query)
+		}
+	}
+}
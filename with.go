@@ -0,0 +1,255 @@
+import "fmt"
+
+//	This file implements the WITH clause: common table expressions, both ordinary and recursive.
+//
+//	The grammar in parse.go parses the clause, each CTE body into a Select, and WithAdd() collects the CTEs into a With. A clause that
+//	begins a SELECT, wherever the SELECT is - a subquery, a CTE body, a view, a trigger program or an INSERT - is attached by the select
+//	rule to the left-most SELECT of the compound that it begins, as Select.pWith. A clause that begins an INSERT, REPLACE, UPDATE or
+//	DELETE statement is held on Parse.pWith for the whole statement.
+//
+//	Parse.pWith is the innermost WITH clause in scope, linked to the enclosing ones through With.pOuter. selectExpander() pushes the
+//	clause of each compound SELECT as it expands it and selectPopWith() pops it when the walk is done with the compound. When
+//	selectExpander() meets a FROM clause term whose name matches a CTE in scope, withExpand() replaces it with a copy of the CTE's Select,
+//	just as a view is expanded. The body of a CTE is in the scope of the clause that defines it; the body of a view is in the scope of
+//	no clause of the statement that uses the view, so a CTE can never replace a table used by a view. A recursive CTE is coded by
+//	generateWithRecursiveQuery() in select.go.
+
+//	A With is a WITH clause.
+type With struct {
+	Recursive	bool			//	True if the RECURSIVE keyword is present
+	CTEs		[]*Cte			//	The common table expressions, in the order they were declared
+	pOuter		*With			//	The next enclosing WITH clause in scope while this one is on Parse.pWith
+}
+
+//	A Cte is a single common table expression.
+type Cte struct {
+	Name		string			//	Name of the CTE
+	Columns		[]string		//	Column names given after the name, or nil
+	Select		*Select			//	The definition of the CTE
+	zErr		string			//	Error message used if the CTE is referenced while it is being expanded
+}
+
+//	Return a copy of p.
+func (p *With) Dup() (pNew *With) {
+	if p != nil {
+		pNew = &With{ Recursive: p.Recursive, CTEs: make([]*Cte, len(p.CTEs)) }
+		for i, pCte := range p.CTEs {
+			pNew.CTEs[i] = &Cte{ Name: pCte.Name, Columns: pCte.Columns, Select: pCte.Select.Dup() }
+		}
+	}
+	return
+}
+
+//	If the current token begins a WITH clause, advance to the first token after the clause. The grammar checks the clause; this
+//	only finds where it ends, for the rewriters that look at the statement the clause begins.
+func (s *sqlScanner) SkipWith() {
+	if s.Type != TK_WITH {
+		return
+	}
+	for s.Next(); s.Type != 0 && s.Type != TK_SEMI; s.Next() {
+		switch s.Type {
+		case TK_SELECT, TK_VALUES, TK_INSERT, TK_REPLACE, TK_UPDATE, TK_DELETE:
+			return
+		case TK_LP:
+			if _, ok := s.Parenthesized(); !ok {
+				return
+			}
+		}
+	}
+}
+
+//	Called by the grammar for each CTE of a WITH clause. Append the CTE named pName, with the column names of pArglist, if any, and
+//	the definition pSelect, to pWith and return it. pWith is nil for the first CTE of the clause.
+func (pParse *Parse) WithAdd(pWith *With, pName *Token, pArglist *ExprList, pSelect *Select) *With {
+	if pWith == nil {
+		pWith = new(With)
+	}
+	pCte := &Cte{ Name: Dequote(string(*pName)), Select: pSelect }
+	for _, p := range pWith.CTEs {
+		if CaseInsensitiveMatch(p.Name, pCte.Name) {
+			pParse.SetErrorMsg("duplicate WITH table name: %v", pCte.Name)
+		}
+	}
+	if pArglist != nil {
+		for _, pItem := range pArglist.Items {
+			pCte.Columns = append(pCte.Columns, pItem.Name)
+		}
+	}
+	pWith.CTEs = append(pWith.CTEs, pCte)
+	return pWith
+}
+
+//	Return the Select for a VALUES clause used as the body of a CTE. The grammar leaves a single row in pList and builds a Select only
+//	for two or more.
+func (pParse *Parse) valuesSelect(pList *ExprList, pSelect *Select) *Select {
+	if pList == nil {
+		return pSelect
+	}
+	if pSelect = sqlite3SelectNew(pParse, pList, 0, 0, 0, 0, 0, 0, 0, 0); pSelect != nil {
+		pSelect.selFlags |= SF_Values
+	}
+	return pSelect
+}
+
+//	Parse zSql, which must be a single SELECT statement, and return its parse tree without generating any code. Bound parameters are
+//	numbered along with those of the statement being parsed by pParse. Return nil after leaving an error in pParse if zSql is not a
+//	valid SELECT.
+func (pParse *Parse) ParseSelect(zSql string) (p *Select) {
+	s := newSqlScanner(zSql)
+	s.SkipWith()
+	if s.Type != TK_SELECT && s.Type != TK_VALUES {
+		if s.Type == 0 {
+			pParse.SetErrorMsg("incomplete input")
+		} else {
+			pParse.SetErrorMsg("near \"%v\": syntax error", s.Text)
+		}
+		return nil
+	}
+	pSub := &Parse{
+		db:				pParse.db,
+		nQueryLoop:		1,
+		nested:			1,
+		captureSelect:	true,
+		nVar:			pParse.nVar,
+		nzVar:			pParse.nzVar,
+		azVar:			pParse.azVar,
+	}
+	zErr, nErr := pSub.Run(zSql)
+	pParse.nVar = pSub.nVar
+	pParse.nzVar = pSub.nzVar
+	pParse.azVar = pSub.azVar
+	if nErr > 0 || pSub.pCapture == nil {
+		if zErr == "" {
+			zErr = "syntax error"
+		}
+		pParse.SetErrorMsg("%v", zErr)
+		return nil
+	}
+	return pSub.pCapture
+}
+
+//	Return the CTE in scope that the FROM clause term pItem refers to and the WITH clause that defines it, or nil if pItem does not name
+//	a CTE.
+func (pParse *Parse) SearchWith(pItem *SrcList_item) (*Cte, *With) {
+	if pItem.zDatabase != "" || pItem.Name == "" {
+		return nil, nil
+	}
+	for pWith := pParse.pWith; pWith != nil; pWith = pWith.pOuter {
+		for _, pCte := range pWith.CTEs {
+			if CaseInsensitiveMatch(pCte.Name, pItem.Name) {
+				return pCte, pWith
+			}
+		}
+	}
+	return nil, nil
+}
+
+//	Bring the WITH clause of the compound SELECT that p belongs to into scope, unless it already is. The clause is attached to the
+//	left-most SELECT of the compound, while the walk visits the right-most first.
+func (pParse *Parse) pushWith(p *Select) {
+	for p.pPrior != nil {
+		p = p.pPrior
+	}
+	if pWith := p.pWith; pWith != nil && pParse.pWith != pWith {
+		pWith.pOuter = pParse.pWith
+		pParse.pWith = pWith
+	}
+}
+
+//	Take the WITH clause that pushWith() brought into scope out of it again once the walk is done with the left-most SELECT of the
+//	compound.
+func selectPopWith(pWalker *Walker, p *Select) {
+	pParse := pWalker.Parse
+	if pWith := p.pWith; p.pPrior == nil && pWith != nil && pParse.pWith == pWith {
+		pParse.pWith = pWith.pOuter
+	}
+}
+
+//	If the FROM clause term pFrom names a CTE, attach a copy of the CTE's Select to pFrom and build the ephemeral Table describing its
+//	result, expanding the Select with pWalker. If the CTE is a UNION or UNION ALL of a WITH RECURSIVE clause, the reference to the CTE in
+//	its right-most SELECT is bound to the same Table and marked isRecursive. pFrom is left alone if it does not name a CTE.
+func (pParse *Parse) withExpand(pWalker *Walker, pFrom *SrcList_item) (rc int) {
+	pCte, pWith := pParse.SearchWith(pFrom)
+	if pCte == nil {
+		return SQLITE_OK
+	}
+	if pCte.zErr != "" {
+		pParse.SetErrorMsg(pCte.zErr, pCte.Name)
+		return SQLITE_ERROR
+	}
+	assert( pFrom.pTab == nil )
+	pTab := &Table{
+		Name:		pCte.Name,
+		nRef:		1,
+		iPKey:		-1,
+		nRowEst:	1000000,
+		tabFlags:	TF_Ephemeral,
+	}
+	pFrom.pTab = pTab
+	pFrom.Select = pCte.Select.Dup()
+	pSel := pFrom.Select
+
+	//	The body of the CTE is in the scope of the WITH clause that defines it, not of the SELECT that refers to it.
+	pSavedWith := pParse.pWith
+	pParse.pWith = pWith
+	defer func() {
+		pParse.pWith = pSavedWith
+	}()
+
+	//	Bind the references to the CTE in the recursive part of its own definition.
+	bMayRecursive := pWith.Recursive && (pSel.op == TK_ALL || pSel.op == TK_UNION)
+	if bMayRecursive {
+		pSrc := pSel.pSrc
+		for i := 0; i < pSrc.nSrc; i++ {
+			pItem := &pSrc.a[i]
+			if pItem.zDatabase == "" && pItem.Name != "" && CaseInsensitiveMatch(pItem.Name, pCte.Name) {
+				pItem.pTab = pTab
+				pItem.isRecursive = 1
+				pTab.nRef++
+				pSel.selFlags |= SF_Recursive
+			}
+		}
+	}
+	if pTab.nRef > 2 {
+		pParse.SetErrorMsg("multiple references to recursive table: %v", pCte.Name)
+		return SQLITE_ERROR
+	}
+	assert( pTab.nRef == 1 || (pSel.selFlags & SF_Recursive != 0 && pTab.nRef == 2) )
+
+	//	Expand the setup query (or the whole Select if it is not recursive). Any reference to the CTE found now is circular.
+	pCte.zErr = "circular reference: %v"
+	if bMayRecursive {
+		pWalker.Select(pSel.pPrior)
+	} else {
+		pWalker.Select(pSel)
+	}
+
+	pLeft := pSel
+	for pLeft.pPrior != nil {
+		pLeft = pLeft.pPrior
+	}
+	if pCte.Columns != nil && len(pCte.Columns) != pLeft.pEList.nExpr {
+		pParse.SetErrorMsg("table %v has %v values for %v columns", pCte.Name, fmt.Sprint(pLeft.pEList.nExpr), fmt.Sprint(len(pCte.Columns)))
+		pCte.zErr = ""
+		return SQLITE_ERROR
+	}
+	selectColumnsFromExprList(pParse, pLeft.pEList, &pTab.nCol, &pTab.Columns)
+	for i, name := range pCte.Columns {
+		pTab.Columns[i].Name = name
+	}
+
+	//	Expand the recursive part. The CTE's own reference has already been bound, so any other reference to it is in a subquery.
+	if bMayRecursive {
+		if pSel.selFlags & SF_Recursive != 0 {
+			pCte.zErr = "multiple recursive references: %v"
+		} else {
+			pCte.zErr = "recursive reference in a subquery: %v"
+		}
+		pWalker.Select(pSel)
+	}
+	pCte.zErr = ""
+	if pParse.nErr > 0 {
+		return SQLITE_ERROR
+	}
+	return SQLITE_OK
+}
@@ -0,0 +1,61 @@
+import "testing"
+
+func TestWith(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE t(x); INSERT INTO t VALUES(1), (2), (3)")
+
+	testQueryIs(t, db, "2\n3", "WITH big(v) AS (SELECT x FROM t WHERE x > 1) SELECT v FROM big ORDER BY v")
+	testQueryIs(t, db, "6", "WITH a AS (SELECT x FROM t), b AS (SELECT sum(x) AS s FROM a) SELECT s FROM b")
+
+	//	A CTE in a subquery, and a CTE that hides a table only within its own SELECT.
+	testQueryIs(t, db, "10", "SELECT (WITH c(n) AS (SELECT 10) SELECT n FROM c)")
+	testQueryIs(t, db, "1|3", "SELECT (WITH t(x) AS (SELECT 1) SELECT x FROM t), (SELECT count(*) FROM t)")
+	testQueryIs(t, db, "3", "SELECT count(*) FROM t WHERE x IN (WITH v(y) AS (SELECT x FROM t) SELECT y FROM v)")
+
+	//	A WITH clause that begins an INSERT, UPDATE or DELETE statement.
+	testExec(t, db, "WITH n(v) AS (VALUES(4), (5)) INSERT INTO t SELECT v FROM n")
+	testExec(t, db, "WITH odd AS (SELECT x FROM t WHERE x % 2 = 1) DELETE FROM t WHERE x IN odd")
+	testQueryIs(t, db, "2\n4", "SELECT x FROM t ORDER BY x")
+
+	//	The statements after one that begins with WITH are run.
+	testExec(t, db, "WITH n(v) AS (SELECT 6) INSERT INTO t SELECT v FROM n; INSERT INTO t VALUES(8)")
+	testQueryIs(t, db, "2\n4\n6\n8", "SELECT x FROM t ORDER BY x")
+	testExec(t, db, "WITH m(v) AS (SELECT max(x) FROM t) UPDATE t SET x = (SELECT v FROM m) + 1 WHERE x = 2")
+	testQueryIs(t, db, "4\n6\n8\n9", "SELECT x FROM t ORDER BY x")
+
+	//	WITH and RECURSIVE are keywords, but may still be used as names.
+	testExec(t, db, "CREATE TABLE recursive(with); INSERT INTO recursive VALUES(7)")
+	testQueryIs(t, db, "7", "WITH c AS (SELECT with FROM recursive) SELECT * FROM c")
+}
+
+func TestWithRecursive(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testQueryIs(t, db, "1\n2\n3\n4\n5", "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 5) SELECT x FROM c")
+	testQueryIs(t, db, "120", "WITH RECURSIVE f(n, v) AS (SELECT 1, 1 UNION ALL SELECT n + 1, v * (n + 1) FROM f WHERE n < 5) SELECT max(v) FROM f")
+
+	testExec(t, db, "CREATE TABLE emp(name, boss)")
+	testExec(t, db, "INSERT INTO emp VALUES('ann', NULL), ('bob', 'ann'), ('cat', 'bob'), ('dan', 'ann'), ('eve', 'dan')")
+	testQueryIs(t, db, "ann|0\nbob|1\ndan|1\ncat|2\neve|2", `
+		WITH RECURSIVE under(name, depth) AS (
+			SELECT name, 0 FROM emp WHERE boss IS NULL
+			UNION ALL
+			SELECT emp.name, depth + 1 FROM emp JOIN under ON emp.boss = under.name
+		)
+		SELECT name, depth FROM under ORDER BY depth, name`)
+
+	//	UNION discards the rows already produced, so the recursion ends.
+	testQueryIs(t, db, "0\n1\n2", "WITH RECURSIVE c(x) AS (SELECT 0 UNION SELECT (x + 1) % 3 FROM c) SELECT x FROM c ORDER BY x")
+}
+
+func TestWithErrors(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	for _, query := range []string{
+		"WITH c(a, b) AS (SELECT 1) SELECT * FROM c",
+		"WITH c AS (SELECT 1), c AS (SELECT 2) SELECT * FROM c",
+		"SELECT * FROM (WITH c AS (SELECT 1) SELECT 2), c",
+	} {
+		if _, err := db.Exec(query); err == nil {
+			t.Errorf("%v: no error", query)
+		}
+	}
+}
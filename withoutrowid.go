@@ -0,0 +1,277 @@
+//	This file implements WITHOUT ROWID tables: tables whose rows are stored in an index b-tree keyed by the PRIMARY KEY rather than
+//	in a table b-tree keyed by a rowid.
+//
+//		CREATE TABLE t(a, b, c, PRIMARY KEY(a, b)) WITHOUT ROWID
+//
+//	Parse.ParseCreateTable() (see Parse.Run()) removes the option from the statement and sets Parse.withoutRowid, where
+//	sqlite3StartTable() finds it. The PRIMARY KEY of such a table is an Index like any other, but it is "clustered": it shares the
+//	root page of the table and its entries are the rows themselves. Each record holds the PRIMARY KEY columns in key order followed
+//	by the other columns in table order, so Table.StorageColumn() must be used to find a column in a record.
+//
+//	Where a rowid table uses the rowid of a row, a WITHOUT ROWID table uses its key: a record of the PRIMARY KEY columns made by
+//	OP_MakeRecord. The key is found with OP_NotFound rather than OP_NotExists, and the entries of the other indices of the table end
+//	with the PRIMARY KEY columns rather than with the rowid.
+
+//	If the first statement of zSql is a CREATE TABLE that ends with WITHOUT ROWID, set pParse.withoutRowid and return the statement
+//	without the option. Otherwise return zSql unchanged. Errors are left in pParse.
+func (pParse *Parse) ParseCreateTable(zSql string) string {
+	s := newSqlScanner(zSql)
+	if s.Type != TK_CREATE {
+		return zSql
+	}
+	if s.Next(); s.Type == TK_TEMP {
+		s.Next()
+	}
+	if s.Type != TK_TABLE {
+		return zSql
+	}
+	for s.Type != 0 && s.Type != TK_SEMI && s.Type != TK_LP && s.Type != TK_AS {
+		s.Next()
+	}
+	if s.Type != TK_LP {
+		return zSql
+	}
+	if _, ok := s.Parenthesized(); !ok {
+		return zSql
+	}
+	iEnd := s.iNext
+	if !s.Next() || !s.IsWord("WITHOUT") {
+		return zSql
+	}
+	if !s.Next() || !s.IsWord("ROWID") {
+		if s.Type == 0 {
+			pParse.SetErrorMsg("incomplete input")
+		} else {
+			pParse.SetErrorMsg("unknown table option: %v", s.Text)
+		}
+		return zSql
+	}
+	s.Next()
+	pParse.withoutRowid = true
+	return zSql[:iEnd] + " " + zSql[s.Start:]
+}
+
+//	Return the index that implements the PRIMARY KEY of table t, or nil if t has no PRIMARY KEY or it is an INTEGER PRIMARY KEY.
+func (t *Table) PrimaryKey() *Index {
+	for _, pIdx := range t.Indices {
+		if pIdx.autoIndex == 2 {
+			return pIdx
+		}
+	}
+	return nil
+}
+
+//	Return true if pIdx is the PRIMARY KEY of a WITHOUT ROWID table, whose entries are the rows of the table.
+func (pIdx *Index) IsClustered() bool {
+	return pIdx.autoIndex == 2 && !pIdx.pTable.HasRowid()
+}
+
+//	Return the columns of table t in the order in which they are stored in its records. For a WITHOUT ROWID table that is the
+//	PRIMARY KEY columns in key order followed by the other columns in table order. VIRTUAL generated columns are not stored.
+func (t *Table) storageOrder() (aCol []int) {
+	if t.HasRowid() {
+		for i, pCol := range t.Columns {
+			if !pCol.IsVirtualGenerated() {
+				aCol = append(aCol, i)
+			}
+		}
+		return
+	}
+	pPk := t.PrimaryKey()
+	aCol = append(aCol, pPk.Columns...)
+	for i, pCol := range t.Columns {
+		inKey := false
+		for _, iCol := range pPk.Columns {
+			inKey = inKey || iCol == i
+		}
+		if !inKey && !pCol.IsVirtualGenerated() {
+			aCol = append(aCol, i)
+		}
+	}
+	return
+}
+
+//	Return the position in the records of table t of column iCol, or -1 if iCol is a VIRTUAL generated column.
+func (t *Table) StorageColumn(iCol int) int {
+	if t.HasRowid() && !t.HasGenerated() {
+		return iCol
+	}
+	for i, c := range t.storageOrder() {
+		if c == iCol {
+			return i
+		}
+	}
+	return -1
+}
+
+//	Return the column of table t that is stored at position iStore of its records. This is the inverse of StorageColumn().
+func (t *Table) TableColumn(iStore int) int {
+	if aCol := t.storageOrder(); iStore < len(aCol) {
+		return aCol[iStore]
+	}
+	return iStore
+}
+
+//	Return the position of column iCol of the indexed table in the entries of index pIdx, or -1 if the entries do not hold it.
+func (pIdx *Index) ColumnField(iCol int) int {
+	pTab := pIdx.pTable
+	if pIdx.IsClustered() {
+		return pTab.StorageColumn(iCol)
+	}
+	for j, column := range pIdx.Columns {
+		if column == iCol {
+			return j
+		}
+	}
+	if !pTab.HasRowid() {
+		for j, column := range pTab.PrimaryKey().Columns {
+			if column == iCol {
+				return len(pIdx.Columns) + j
+			}
+		}
+	}
+	return -1
+}
+
+//	Return the number of fields in an entry of index pIdx: the indexed columns followed by the rowid or by the PRIMARY KEY columns of
+//	a WITHOUT ROWID table, or every column of the table for the PRIMARY KEY of a WITHOUT ROWID table.
+func (pIdx *Index) KeyFields() int {
+	switch pTab := pIdx.pTable; {
+	case pTab.HasRowid():
+		return len(pIdx.Columns) + 1
+	case pIdx.IsClustered():
+		return len(pTab.storageOrder())
+	}
+	return len(pIdx.Columns) + len(pIdx.pTable.PrimaryKey().Columns)
+}
+
+//	Return the collation sequence names and sort orders of every field of an entry of index pIdx. For a rowid table these are the
+//	indexed columns. For a WITHOUT ROWID table the entries of the PRIMARY KEY go on with the other columns of the table, and the
+//	entries of the other indices go on with the PRIMARY KEY columns.
+func (pIdx *Index) KeySequence() (azColl []string, aSortOrder []byte) {
+	azColl = append(azColl, pIdx.azColl[:len(pIdx.Columns)]...)
+	aSortOrder = append(aSortOrder, pIdx.aSortOrder[:len(pIdx.Columns)]...)
+	switch pTab := pIdx.pTable; {
+	case pTab.HasRowid():
+	case pIdx.IsClustered():
+		for _, iCol := range pTab.storageOrder()[len(pIdx.Columns):] {
+			zColl := pTab.Columns[iCol].zColl
+			if zColl == "" {
+				zColl = "BINARY"
+			}
+			azColl = append(azColl, zColl)
+			aSortOrder = append(aSortOrder, SQLITE_SO_ASC)
+		}
+	default:
+		pPk := pTab.PrimaryKey()
+		azColl = append(azColl, pPk.azColl[:len(pPk.Columns)]...)
+		aSortOrder = append(aSortOrder, pPk.aSortOrder[:len(pPk.Columns)]...)
+	}
+	return
+}
+
+//	Generate code that stores the key of the row that cursor iCur on table pTab points to in register regOut. The key of a row is its
+//	rowid or, for a WITHOUT ROWID table, a record of its PRIMARY KEY columns.
+func (pParse *Parse) CodeRowKey(pTab *Table, iCur, regOut int) {
+	v := pParse.pVdbe
+	if pTab.HasRowid() {
+		v.AddOp2(OP_Rowid, iCur, regOut)
+		return
+	}
+	nPk := len(pTab.PrimaryKey().Columns)
+	regBase := pParse.GetTempRange(nPk)
+	for i := 0; i < nPk; i++ {
+		v.AddOp3(OP_Column, iCur, i, regBase + i)
+	}
+	v.AddOp3(OP_MakeRecord, regBase, nPk, regOut)
+	pParse.ReleaseTempRange(regBase, nPk)
+}
+
+//	Generate code that stores the key of a row of WITHOUT ROWID table pTab, whose columns are in the registers starting at regData,
+//	in register regOut.
+func (pParse *Parse) CodeRowKeyFromRegisters(pTab *Table, regData, regOut int) {
+	v := pParse.pVdbe
+	pPk := pTab.PrimaryKey()
+	nPk := len(pPk.Columns)
+	regBase := pParse.GetTempRange(nPk)
+	for i, iCol := range pPk.Columns {
+		v.AddOp2(OP_SCopy, regData + iCol, regBase + i)
+	}
+	v.AddOp3(OP_MakeRecord, regBase, nPk, regOut)
+	sqlite3VdbeChangeP4(v, -1, v.IndexAffinityStr(pPk), P4_TRANSIENT)
+	pParse.ReleaseTempRange(regBase, nPk)
+}
+
+//	Generate code that moves cursor iCur on table pTab to the row whose key is in register regKey, or jumps to dest if there is no such
+//	row. Return the address of the instruction.
+func (pParse *Parse) SeekRowKey(pTab *Table, iCur, dest, regKey int) int {
+	v := pParse.pVdbe
+	if pTab.HasRowid() {
+		return v.AddOp3(OP_NotExists, iCur, dest, regKey)
+	}
+	return sqlite3VdbeAddOp4Int(v, OP_NotFound, iCur, dest, regKey, 0)
+}
+
+//	Generate code that makes the record stored for a row of table pTab, whose columns are in the registers starting at regData, in
+//	register regRec.
+func (pParse *Parse) CodeTableRecord(pTab *Table, regData, regRec int) {
+	v := pParse.pVdbe
+	if pTab.HasRowid() && !pTab.HasGenerated() {
+		v.AddOp3(OP_MakeRecord, regData, pTab.nCol, regRec)
+		sqlite3TableAffinityStr(v, pTab)
+		return
+	}
+	aCol := pTab.storageOrder()
+	regBase := pParse.GetTempRange(len(aCol))
+	zAff := make([]byte, len(aCol))
+	for i, iCol := range aCol {
+		v.AddOp2(OP_SCopy, regData + iCol, regBase + i)
+		zAff[i] = pTab.Columns[iCol].affinity
+	}
+	v.AddOp3(OP_MakeRecord, regBase, len(aCol), regRec)
+	sqlite3VdbeChangeP4(v, -1, string(zAff), P4_TRANSIENT)
+	pParse.ReleaseTempRange(regBase, len(aCol))
+}
+
+//	A row key set holds the keys of the rows that a DELETE or UPDATE is to change, so that they can be changed after the scan that
+//	finds them is complete. For a rowid table it is a RowSet in a register. For a WITHOUT ROWID table it is an ephemeral index of
+//	PRIMARY KEY records, and the set is identified by its cursor.
+
+//	Generate code that creates an empty row key set for table pTab and return the register or cursor that identifies it.
+func (pParse *Parse) RowKeySetOpen(pTab *Table) (iSet int) {
+	v := pParse.pVdbe
+	if pTab.HasRowid() {
+		pParse.nMem++
+		iSet = pParse.nMem
+		v.AddOp2(OP_Null, 0, iSet)
+	} else {
+		iSet = pParse.nTab
+		pParse.nTab++
+		pKey := pParse.IndexKeyinfo(pTab.PrimaryKey())
+		sqlite3VdbeAddOp4(v, OP_OpenEphemeral, iSet, 0, 0, (char*)pKey, P4_KEYINFO_HANDOFF)
+	}
+	return
+}
+
+//	Generate code that adds the row key in register regKey to row key set iSet of table pTab.
+func (pParse *Parse) RowKeySetAdd(pTab *Table, iSet, regKey int) {
+	v := pParse.pVdbe
+	if pTab.HasRowid() {
+		v.AddOp2(OP_RowSetAdd, iSet, regKey)
+	} else {
+		v.AddOp2(OP_IdxInsert, iSet, regKey)
+	}
+}
+
+//	Generate code that removes a row key from row key set iSet of table pTab and stores it in register regKey, or jumps to dest if the
+//	set is empty. Return the address of the first instruction, which is where the next key is read.
+func (pParse *Parse) RowKeySetRead(pTab *Table, iSet, dest, regKey int) (addr int) {
+	v := pParse.pVdbe
+	if pTab.HasRowid() {
+		return v.AddOp3(OP_RowSetRead, iSet, dest, regKey)
+	}
+	addr = v.AddOp2(OP_Rewind, iSet, dest)
+	v.AddOp2(OP_RowKey, iSet, regKey)
+	v.AddOp1(OP_Delete, iSet)
+	return
+}
@@ -0,0 +1,94 @@
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithoutRowid(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE w(a, b TEXT, c, PRIMARY KEY(a, b)) WITHOUT ROWID")
+	testExec(t, db, "INSERT INTO w VALUES(2, 'y', 'two y'), (1, 'z', 'one z'), (1, 'y', 'one y'), (2, 'x', 'two x')")
+
+	//	The rows are the entries of the PRIMARY KEY, so a scan returns them in key order.
+	testQueryIs(t, db, "1|y|one y\n1|z|one z\n2|x|two x\n2|y|two y", "SELECT a, b, c FROM w")
+
+	//	Updates of columns outside and inside the key, and deletes by key and by other columns.
+	testExec(t, db, "UPDATE w SET c = upper(c) WHERE a = 1")
+	testExec(t, db, "UPDATE w SET b = 'w' WHERE a = 2 AND b = 'y'")
+	testExec(t, db, "UPDATE w SET a = a + 10 WHERE c = 'two x'")
+	testQueryIs(t, db, "1|y|ONE Y\n1|z|ONE Z\n2|w|two y\n12|x|two x", "SELECT a, b, c FROM w")
+	testExec(t, db, "DELETE FROM w WHERE a = 1 AND b = 'z'")
+	testExec(t, db, "DELETE FROM w WHERE c LIKE 'two%'")
+	testQueryIs(t, db, "1|y|ONE Y", "SELECT a, b, c FROM w")
+
+	//	The key is unique and its columns may not be NULL. A failed statement changes nothing.
+	for _, query := range []string{
+		"INSERT INTO w VALUES(1, 'y', 'again')",
+		"INSERT INTO w VALUES(3, 'q', 'ok'), (1, 'y', 'again')",
+		"INSERT INTO w VALUES(NULL, 'y', 'null')",
+		"UPDATE w SET b = NULL",
+	} {
+		if _, err := db.Exec(query); testCode(err) & 0xff != SQLITE_CONSTRAINT {
+			t.Errorf("%v: %v, want SQLITE_CONSTRAINT", query, err)
+		}
+	}
+	testQueryIs(t, db, "1|y|ONE Y", "SELECT a, b, c FROM w")
+	testExec(t, db, "INSERT OR REPLACE INTO w VALUES(1, 'y', 'replaced')")
+	testExec(t, db, "INSERT OR IGNORE INTO w VALUES(1, 'y', 'ignored')")
+	testQueryIs(t, db, "1|y|replaced", "SELECT a, b, c FROM w")
+
+	//	A secondary index, whose entries end with the key, stays in step with the table.
+	testExec(t, db, "CREATE INDEX w_c ON w(c)")
+	testExec(t, db, "INSERT INTO w VALUES(5, 'e', 'five'), (6, 'f', 'six')")
+	testExec(t, db, "UPDATE w SET c = 'FIVE' WHERE a = 5")
+	testQueryIs(t, db, "5|e", "SELECT a, b FROM w WHERE c = 'FIVE'")
+	testQueryIs(t, db, "", "SELECT a, b FROM w WHERE c = 'five'")
+	testQueryIs(t, db, "ok", "PRAGMA integrity_check")
+}
+
+func TestWithoutRowidErrors(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	for query, want := range map[string]string{
+		"CREATE TABLE t(a, b) WITHOUT ROWID":								"PRIMARY KEY missing on table t",
+		"CREATE TABLE t(a INTEGER PRIMARY KEY AUTOINCREMENT) WITHOUT ROWID":	"AUTOINCREMENT not allowed on WITHOUT ROWID tables",
+		"CREATE TABLE t(a PRIMARY KEY) WITHOUT":							"incomplete input",
+		"CREATE TABLE t(a PRIMARY KEY) WITHOUT OID":						"unknown table option: OID",
+	} {
+		if _, err := db.Exec(query); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%v: %v, want %q", query, err, want)
+		}
+	}
+	testExec(t, db, "CREATE TABLE t(a PRIMARY KEY, b) WITHOUT ROWID")
+	if _, err := db.Exec("SELECT rowid FROM t"); err == nil || !strings.Contains(err.Error(), "no such column: rowid") {
+		t.Errorf("rowid of a WITHOUT ROWID table: %v", err)
+	}
+}
+
+func TestWithoutRowidPlan(t *testing.T) {
+	db := testOpen(t, ":memory:")
+	testExec(t, db, "CREATE TABLE w(a, b, c, d, PRIMARY KEY(a, b)) WITHOUT ROWID")
+	testExec(t, db, "CREATE INDEX w_c ON w(c)")
+	for _, test := range []struct {
+		query	string
+		index	string
+	}{
+		//	The PRIMARY KEY b-tree is searched directly, for the whole key or a prefix of it.
+		{ "SELECT d FROM w WHERE a = 1 AND b = 2", "sqlite_autoindex_w_1" },
+		{ "SELECT d FROM w WHERE a = 1", "sqlite_autoindex_w_1" },
+		{ "SELECT d FROM w WHERE a > 1", "sqlite_autoindex_w_1" },
+		{ "SELECT d FROM w WHERE c = 1", "w_c" },
+	} {
+		if plan := testQueryPlan(t, db, test.query); !strings.Contains(plan, "INDEX " + test.index + " ") {
+			t.Errorf("%v: plan %q, want index %q", test.query, plan, test.index)
+		}
+	}
+
+	//	A secondary index covers the key columns, which its entries end with.
+	if plan := testQueryPlan(t, db, "SELECT a, b FROM w WHERE c = 1"); !strings.Contains(plan, "COVERING INDEX w_c") {
+		t.Errorf("plan %q, want COVERING INDEX w_c", plan)
+	}
+
+	//	ORDER BY the key needs no sorting.
+	if plan := testQueryPlan(t, db, "SELECT * FROM w ORDER BY a, b"); strings.Contains(plan, "TEMP B-TREE") {
+		t.Errorf("plan %q sorts", plan)
+	}
+}